@@ -0,0 +1,643 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package appstudioredhatcom
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	appstudioshared "github.com/redhat-appstudio/application-api/api/v1alpha1"
+	apibackend "github.com/redhat-appstudio/managed-gitops/backend-shared/apis/managed-gitops/v1alpha1"
+	logutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util/log"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// This file is responsible for automated promotion: a PromotionRun with .spec.automatedPromotion.initialEnvironment
+// promotes the Snapshot through the Environment graph, beginning with the initial environment.
+//
+// The Environment graph is defined by the .spec.parentEnvironment field of Environments: an Environment with the
+// 'AppStudioAutomated' deployment strategy is promoted to, once its parent Environment has been successfully promoted to.
+//
+// The graph is promoted one depth at a time (a 'step'):
+// - At each step, the binding of each Environment of the step is created (or updated) to target the Snapshot.
+// - The step is successful once all the GitOpsDeployments of those bindings are Synced/Healthy at the GitOps repository
+//   commit of the Snapshot, and the promotion gates of the step have passed (see promotionrun_gates.go).
+// - Each step has its own start time (recorded by the StepStartTimes condition): the time limit of the step, and the
+//   Pod restart gate, are relative to it.
+// - If any Environment of a step fails (or times out), the promotion is complete, and no further Environments are promoted to.
+//   The Environments of that step which have rollback enabled are rolled back (see promotionrun_rollback.go).
+//
+// The state of the promotion is stored in .status.environmentStatus, so that it is not lost between reconciles.
+
+const (
+	ErrMessageInitialEnvironmentDoesNotExist = "Initial Environment does not exist: "
+)
+
+// reconcileAutomatedPromotion reconciles one step of an automated promotion: it locates the Environments of the current
+// step of the promotion, and promotes the Snapshot to them.
+func reconcileAutomatedPromotion(ctx context.Context, promotionRun *appstudioshared.PromotionRun, k8sClient client.Client, log logr.Logger) (ctrl.Result, error) {
+
+	environmentList := appstudioshared.EnvironmentList{}
+	if err := k8sClient.List(ctx, &environmentList, &client.ListOptions{Namespace: promotionRun.Namespace}); err != nil {
+		log.Error(err, "unable to list Environments for automated promotion: "+promotionRun.Name)
+		return ctrl.Result{}, fmt.Errorf("unable to list Environments: %v", err)
+	}
+
+	initialEnvironment := promotionRun.Spec.AutomatedPromotion.InitialEnvironment
+
+	if !environmentExists(environmentList.Items, initialEnvironment) {
+		log.Error(nil, ErrMessageInitialEnvironmentDoesNotExist+initialEnvironment)
+
+		// Update Status.Conditions field.
+		if err := updateStatusConditions(ctx, k8sClient, ErrMessageInitialEnvironmentDoesNotExist+initialEnvironment, promotionRun,
+			appstudioshared.PromotionRunConditionErrorOccurred, appstudioshared.PromotionRunConditionStatusTrue, appstudioshared.PromotionRunReasonErrorOccurred); err != nil {
+			log.Error(err, "unable to update PromotionRun status conditions.")
+			return ctrl.Result{}, fmt.Errorf("unable to update PromotionRun status conditions %v", err)
+		}
+		return ctrl.Result{}, nil
+	}
+
+	// Verify that the Snapshot actually exists, if not, throw error
+	snapshot := appstudioshared.Snapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      promotionRun.Spec.Snapshot,
+			Namespace: promotionRun.Namespace,
+		},
+	}
+	if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(&snapshot), &snapshot); err != nil {
+
+		message := "unable to retrieve Snapshot: " + snapshot.Name
+		if apierr.IsNotFound(err) {
+			message = "Snapshot: " + snapshot.Name + " referred in PromotionRun: " + promotionRun.Name + " does not exist."
+		}
+		log.Error(err, message)
+
+		// Update Status.Conditions field.
+		if err := updateStatusConditions(ctx, k8sClient, message, promotionRun, appstudioshared.PromotionRunConditionErrorOccurred,
+			appstudioshared.PromotionRunConditionStatusTrue, appstudioshared.PromotionRunReasonErrorOccurred); err != nil {
+			log.Error(err, "unable to update PromotionRun status conditions.")
+			return ctrl.Result{}, fmt.Errorf("unable to update PromotionRun status conditions %v", err)
+		}
+
+		if apierr.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("unable to retrieve Snapshot: %v", err)
+	}
+
+	// 1) Determine the Environments of the current step of the promotion.
+	stepEnvironments, previousSteps, failed := getCurrentAutomatedPromotionStep(*promotionRun, environmentList.Items)
+	stepNumber := len(previousSteps) + 1
+
+	if failed {
+		// An Environment of the step failed: the promotion is complete once the step has been rolled back, so
+		// retry the rollback if it previously failed.
+		return failAutomatedPromotion(ctx, promotionRun, stepEnvironments, k8sClient, log)
+	}
+
+	if len(stepEnvironments) == 0 {
+		// There are no more Environments to promote to, so the promotion is complete.
+		return ctrl.Result{}, completeAutomatedPromotion(ctx, promotionRun, true, k8sClient, log)
+	}
+
+	// 2) Locate or create the bindings of the current step, and set them to target the Snapshot
+	bindings := []appstudioshared.SnapshotEnvironmentBinding{}
+	bindingNames := []string{}
+
+	for _, environmentName := range stepEnvironments {
+
+		binding, err := locateOrCreateTargetBinding(ctx, *promotionRun, environmentName, k8sClient, log)
+		if err != nil {
+			log.Error(err, "error locating or creating Binding for PromotionRun: "+promotionRun.Name, "environment", environmentName)
+
+			// Update Status.Conditions field.
+			if err := updateStatusConditions(ctx, k8sClient, "unable to locate or create Binding for Environment: "+environmentName,
+				promotionRun, appstudioshared.PromotionRunConditionErrorOccurred, appstudioshared.PromotionRunConditionStatusTrue, appstudioshared.PromotionRunReasonErrorOccurred); err != nil {
+				log.Error(err, "unable to update PromotionRun status conditions.")
+				return ctrl.Result{}, fmt.Errorf("unable to update PromotionRun status conditions %v", err)
+			}
+			return ctrl.Result{}, nil
+		}
+
+		if binding.Spec.Snapshot != promotionRun.Spec.Snapshot {
+
+			// If rollback is enabled, record the Snapshot that the binding targeted before the promotion.
+			if err := recordPreviousSnapshot(ctx, promotionRun, binding, k8sClient, log); err != nil {
+				log.Error(err, "unable to record previous Snapshot of Binding: "+binding.Name)
+				return ctrl.Result{}, err
+			}
+
+			binding.Spec.Snapshot = promotionRun.Spec.Snapshot
+
+			if err := k8sClient.Update(ctx, &binding); err != nil {
+				log.Error(err, "unable to update Binding: "+binding.Name)
+				return ctrl.Result{}, fmt.Errorf("unable to update Binding '%s' snapshot: %v", binding.Name, err)
+			}
+
+			logutil.LogAPIResourceChangeEvent(binding.Namespace, binding.Name, binding, logutil.ResourceModified, log)
+
+			log.Info("Updating Binding: " + binding.Name + " to target the Snapshot: " + promotionRun.Spec.Snapshot)
+		}
+
+		bindings = append(bindings, binding)
+		bindingNames = append(bindingNames, binding.Name)
+	}
+
+	if promotionRun.Status.State != appstudioshared.PromotionRunState_Active || promotionRun.Status.PromotionStartTime.IsZero() ||
+		strings.Join(promotionRun.Status.ActiveBindings, ",") != strings.Join(bindingNames, ",") {
+
+		promotionRun.Status.State = appstudioshared.PromotionRunState_Active
+		promotionRun.Status.ActiveBindings = bindingNames
+
+		// Set the time of the first reconcilation of the PromotionRun if not set already. This will be used later to check for time out of Promotion.
+		if promotionRun.Status.PromotionStartTime.IsZero() {
+			promotionRun.Status.PromotionStartTime = metav1.Now()
+		}
+
+		if err := k8sClient.Status().Update(ctx, promotionRun); err != nil {
+			log.Error(err, "unable to update PromotionRun active bindings: "+promotionRun.Name)
+			return ctrl.Result{}, fmt.Errorf("unable to update PromotionRun active bindings: %v", err)
+		}
+	}
+
+	// Record the time at which the current step began
+	stepStartTime, err := recordPromotionStepStartTime(ctx, promotionRun, stepNumber, k8sClient)
+	if err != nil {
+		log.Error(err, "unable to record the start time of the step of PromotionRun: "+promotionRun.Name, "step", stepNumber)
+		return ctrl.Result{}, err
+	}
+
+	// 3) Wait for all the GitOpsDeployments of the bindings to be Synced/Healthy
+	displayStatuses := map[string]string{}
+	waitingEnvironments := []string{}
+	degradedEnvironments := []string{}
+
+	for _, binding := range bindings {
+
+		displayStatus, degraded, err := getBindingPromotionDisplayStatus(ctx, binding, k8sClient)
+		if err != nil {
+			log.Error(err, "unable to retrieve the GitOpsDeployments of Binding: "+binding.Name)
+
+			// Update Status.Conditions field.
+			if err := updateStatusConditions(ctx, k8sClient, "unable to retrieve the GitOpsDeployments of Binding: "+binding.Name,
+				promotionRun, appstudioshared.PromotionRunConditionErrorOccurred, appstudioshared.PromotionRunConditionStatusTrue, appstudioshared.PromotionRunReasonErrorOccurred); err != nil {
+				log.Error(err, "unable to update PromotionRun status conditions.")
+				return ctrl.Result{}, fmt.Errorf("unable to update PromotionRun status conditions %v", err)
+			}
+			return ctrl.Result{}, err
+		}
+
+		displayStatuses[binding.Spec.Environment] = displayStatus
+		if displayStatus != StatusMessageAllGitOpsDeploymentsAreSyncedHealthy {
+			waitingEnvironments = append(waitingEnvironments, binding.Spec.Environment)
+		}
+
+		if degraded {
+			// A Degraded GitOpsDeployment only fails the promotion if rollback is enabled, otherwise wait for it to recover.
+			rollbackEnabled, err := isRollbackOnFailureEnabled(ctx, promotionRun, binding.Spec.Environment, k8sClient)
+			if err != nil {
+				log.Error(err, "unable to retrieve Environment: "+binding.Spec.Environment)
+				return ctrl.Result{}, err
+			}
+			if rollbackEnabled {
+				degradedEnvironments = append(degradedEnvironments, binding.Spec.Environment)
+			}
+		}
+	}
+
+	if len(degradedEnvironments) > 0 {
+		message := "Promotion Failed. The GitOpsDeployments of the following Environments are Degraded: " + strings.Join(degradedEnvironments, ", ")
+		log.Info(message, "step", stepNumber)
+
+		for _, environmentName := range degradedEnvironments {
+			if err := updateStatusEnvironmentStatusForEnvironment(ctx, k8sClient, environmentName, message, promotionRun,
+				appstudioshared.PromotionRunEnvironmentStatus_Failed, log); err != nil {
+				log.Error(err, "unable to update PromotionRun environment status: "+promotionRun.Name)
+				return ctrl.Result{}, fmt.Errorf("unable to update PromotionRun environment status %v", err)
+			}
+		}
+
+		// Update status conditions
+		if err := updateStatusConditions(ctx, k8sClient, message, promotionRun, appstudioshared.PromotionRunConditionErrorOccurred,
+			appstudioshared.PromotionRunConditionStatusTrue, appstudioshared.PromotionRunReasonErrorOccurred); err != nil {
+			log.Error(err, "unable to update PromotionRun status conditions.")
+			return ctrl.Result{}, fmt.Errorf("unable to update PromotionRun status conditions %v", err)
+		}
+
+		return failAutomatedPromotion(ctx, promotionRun, stepEnvironments, k8sClient, log)
+	}
+
+	// 4) Wait for the promotion gates of the Environments of the step to pass
+	gateResult, gateMessage, err := evaluatePromotionGates(ctx, promotionRun, stepEnvironments, len(waitingEnvironments) == 0, k8sClient, log)
+	if err != nil {
+		log.Error(err, "unable to evaluate promotion gates: "+promotionRun.Name)
+
+		// Update Status.Conditions field.
+		if err := updateStatusConditions(ctx, k8sClient, "unable to evaluate promotion gates: "+err.Error(), promotionRun, appstudioshared.PromotionRunConditionErrorOccurred,
+			appstudioshared.PromotionRunConditionStatusTrue, appstudioshared.PromotionRunReasonErrorOccurred); err != nil {
+			log.Error(err, "unable to update PromotionRun status conditions.")
+			return ctrl.Result{}, fmt.Errorf("unable to update PromotionRun status conditions %v", err)
+		}
+		return ctrl.Result{}, err
+	}
+
+	incompleteEnvironments := []string{}
+
+	for _, environmentName := range stepEnvironments {
+
+		displayStatus := displayStatuses[environmentName]
+		status := appstudioshared.PromotionRunEnvironmentStatus_InProgress
+
+		// An Environment with Synced/Healthy GitOpsDeployments is only successful once the gates have passed
+		if displayStatus == StatusMessageAllGitOpsDeploymentsAreSyncedHealthy {
+			switch gateResult {
+			case promotionGateResult_Passed:
+				status = appstudioshared.PromotionRunEnvironmentStatus_Success
+			case promotionGateResult_Failed:
+				status, displayStatus = appstudioshared.PromotionRunEnvironmentStatus_Failed, gateMessage
+			default:
+				displayStatus = gateMessage
+			}
+		}
+
+		if status == appstudioshared.PromotionRunEnvironmentStatus_InProgress {
+			incompleteEnvironments = append(incompleteEnvironments, environmentName)
+		}
+
+		if err := updateStatusEnvironmentStatusForEnvironment(ctx, k8sClient, environmentName, displayStatus, promotionRun, status, log); err != nil {
+			log.Error(err, "unable to update PromotionRun environment status: "+promotionRun.Name)
+			return ctrl.Result{}, fmt.Errorf("unable to update PromotionRun environment status %v", err)
+		}
+	}
+
+	if gateResult == promotionGateResult_Failed {
+		log.Info("Promotion gate failed: "+gateMessage, "step", stepNumber)
+
+		// Update status conditions
+		if err := updateStatusConditions(ctx, k8sClient, gateMessage, promotionRun, appstudioshared.PromotionRunConditionErrorOccurred,
+			appstudioshared.PromotionRunConditionStatusTrue, appstudioshared.PromotionRunReasonErrorOccurred); err != nil {
+			log.Error(err, "unable to update PromotionRun status conditions.")
+			return ctrl.Result{}, fmt.Errorf("unable to update PromotionRun status conditions %v", err)
+		}
+
+		return failAutomatedPromotion(ctx, promotionRun, stepEnvironments, k8sClient, log)
+	}
+
+	if len(incompleteEnvironments) == 0 {
+		// All the Environments of this step have been promoted to: requeue to begin the next step.
+		log.Info("Automated promotion step complete", "step", stepNumber, "environments", strings.Join(stepEnvironments, ", "))
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	// 5) Check the time limit of the step: each step of an automated promotion has the time limit of its Environments,
+	// from the time at which the step began.
+	timeLimit, err := getPromotionTimeout(ctx, promotionRun, stepEnvironments, k8sClient)
+	if err != nil {
+		log.Error(err, "unable to determine the time limit of PromotionRun: "+promotionRun.Name)
+
+		// Update Status.Conditions field.
+		if err := updateStatusConditions(ctx, k8sClient, err.Error(), promotionRun, appstudioshared.PromotionRunConditionErrorOccurred,
+			appstudioshared.PromotionRunConditionStatusTrue, appstudioshared.PromotionRunReasonErrorOccurred); err != nil {
+			log.Error(err, "unable to update PromotionRun status conditions.")
+			return ctrl.Result{}, fmt.Errorf("unable to update PromotionRun status conditions %v", err)
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if metav1.Now().Sub(stepStartTime.Time) > timeLimit {
+
+		message := promotionTimeoutMessage(timeLimit)
+
+		for _, environmentName := range incompleteEnvironments {
+			if err := updateStatusEnvironmentStatusForEnvironment(ctx, k8sClient, environmentName, message, promotionRun,
+				appstudioshared.PromotionRunEnvironmentStatus_Failed, log); err != nil {
+				log.Error(err, "unable to update PromotionRun environment status: "+promotionRun.Name)
+				return ctrl.Result{}, fmt.Errorf("unable to update PromotionRun environment status %v", err)
+			}
+		}
+
+		// Update status conditions
+		if err := updateStatusConditions(ctx, k8sClient, message, promotionRun, appstudioshared.PromotionRunConditionErrorOccurred,
+			appstudioshared.PromotionRunConditionStatusTrue, appstudioshared.PromotionRunReasonErrorOccurred); err != nil {
+			log.Error(err, "unable to update PromotionRun status conditions.")
+			return ctrl.Result{}, fmt.Errorf("unable to update PromotionRun status conditions %v", err)
+		}
+
+		return failAutomatedPromotion(ctx, promotionRun, stepEnvironments, k8sClient, log)
+	}
+
+	log.Info("Waiting for Environments of automated promotion to be Synced/Healthy, and for their promotion gates to pass: "+strings.Join(incompleteEnvironments, ", "), "step", stepNumber)
+
+	// set ErrorOccurred condition to false
+	if err := updateStatusConditions(ctx, k8sClient, "", promotionRun, appstudioshared.PromotionRunConditionErrorOccurred,
+		appstudioshared.PromotionRunConditionStatusFalse, ""); err != nil {
+		log.Error(err, "unable to update PromotionRun status conditions.")
+		return ctrl.Result{}, fmt.Errorf("unable to update PromotionRun status conditions %v", err)
+	}
+
+	return ctrl.Result{RequeueAfter: time.Second * 15}, nil
+}
+
+// getCurrentAutomatedPromotionStep walks the Environment graph, beginning with the initial environment, and returns the
+// Environments of the first step that has not yet been successfully promoted to, along with the Environments of the steps before it.
+// - Returns true if an Environment of a step failed, in which case the promotion should not continue.
+// - Returns no Environments if all the steps have been successfully promoted to.
+func getCurrentAutomatedPromotionStep(promotionRun appstudioshared.PromotionRun, environments []appstudioshared.Environment) ([]string, [][]string, bool) {
+
+	environmentStatus := map[string]appstudioshared.PromotionRunEnvironmentStatusField{}
+	for _, envStatus := range promotionRun.Status.EnvironmentStatus {
+		environmentStatus[envStatus.EnvironmentName] = envStatus.Status
+	}
+
+	step := []string{promotionRun.Spec.AutomatedPromotion.InitialEnvironment}
+
+	// visited prevents a cycle in the .spec.parentEnvironment fields from causing an infinite loop
+	visited := map[string]bool{promotionRun.Spec.AutomatedPromotion.InitialEnvironment: true}
+
+	previousSteps := [][]string{}
+
+	for {
+
+		stepComplete := true
+		for _, environmentName := range step {
+			switch environmentStatus[environmentName] {
+			case appstudioshared.PromotionRunEnvironmentStatus_Failed:
+				return step, previousSteps, true
+			case appstudioshared.PromotionRunEnvironmentStatus_Success:
+			default:
+				stepComplete = false
+			}
+		}
+
+		if !stepComplete {
+			return step, previousSteps, false
+		}
+
+		// The step is complete, so move on to the children of the Environments of the step
+		nextStep := []string{}
+		for _, environment := range environments {
+
+			if visited[environment.Name] || environment.Spec.DeploymentStrategy != appstudioshared.DeploymentStrategy_AppStudioAutomated {
+				continue
+			}
+
+			for _, parent := range step {
+				if environment.Spec.ParentEnvironment == parent {
+					nextStep = append(nextStep, environment.Name)
+					visited[environment.Name] = true
+					break
+				}
+			}
+		}
+
+		previousSteps = append(previousSteps, step)
+
+		if len(nextStep) == 0 {
+			return nil, previousSteps, false
+		}
+
+		sort.Strings(nextStep)
+		step = nextStep
+	}
+}
+
+// getBindingPromotionDisplayStatus returns StatusMessageAllGitOpsDeploymentsAreSyncedHealthy if all of the GitOpsDeployments
+// of the binding are Synced/Healthy, otherwise it returns a message describing what the binding is waiting for.
+//   - A GitOpsDeployment is only Synced/Healthy for the promotion once it has synced the GitOps repository commit of its
+//     Component in the binding status: otherwise, its status may still describe the previous Snapshot.
+//   - Also returns true if any of the GitOpsDeployments of the binding are Degraded, or the rollout of the binding failed.
+func getBindingPromotionDisplayStatus(ctx context.Context, binding appstudioshared.SnapshotEnvironmentBinding, k8sClient client.Client) (string, bool, error) {
+
+	// Wait for the environment binding to create all of the expected GitOpsDeployments
+	if len(binding.Status.GitOpsDeployments) != len(binding.Spec.Components) {
+		return "Waiting for the environment binding to create all of the expected GitOpsDeployments.", false, nil
+	}
+
+	// map: component name -> the GitOps repository commit of the component
+	componentCommitIDs := map[string]string{}
+	for _, componentStatus := range binding.Status.Components {
+		componentCommitIDs[componentStatus.Name] = componentStatus.GitOpsRepository.CommitID
+	}
+
+	degraded := false
+
+	waitingGitOpsDeployments := []string{}
+
+	for _, bindingGitOpsDeployment := range binding.Status.GitOpsDeployments {
+
+		gitopsDeployment := &apibackend.GitOpsDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      bindingGitOpsDeployment.GitOpsDeployment,
+				Namespace: binding.Namespace,
+			},
+		}
+		if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(gitopsDeployment), gitopsDeployment); err != nil {
+			return "", false, fmt.Errorf("unable to retrieve GitOpsDeployment '%s', %v", gitopsDeployment.Name, err)
+		}
+
+		if gitopsDeployment.Status.Health.Status == apibackend.HeathStatusCodeDegraded {
+			degraded = true
+		}
+
+		// Must have status of Synced/Healthy, at the commit of the Component
+		commitID := componentCommitIDs[bindingGitOpsDeployment.ComponentName]
+
+		if gitopsDeployment.Status.Sync.Status != apibackend.SyncStatusCodeSynced ||
+			gitopsDeployment.Status.Health.Status != apibackend.HeathStatusCodeHealthy ||
+			commitID == "" || gitopsDeployment.Status.Sync.Revision != commitID {
+			waitingGitOpsDeployments = append(waitingGitOpsDeployments, gitopsDeployment.Name)
+		}
+	}
+
+	if len(waitingGitOpsDeployments) > 0 {
+		return "Waiting for following GitOpsDeployments to be Synced/Healthy: " + strings.Join(waitingGitOpsDeployments, ", "), degraded, nil
+	}
+
+	// If the Environment has a deployment strategy, the binding must also complete the rollout of the Snapshot
+	if rolloutMessage, rolloutFailed := getBindingRolloutStatus(binding); rolloutMessage != "" {
+		return rolloutMessage, rolloutFailed, nil
+	}
+
+	return StatusMessageAllGitOpsDeploymentsAreSyncedHealthy, false, nil
+}
+
+const (
+	PromotionRunConditionStepStartTimes appstudioshared.PromotionRunConditionType = "StepStartTimes"
+
+	PromotionRunReasonStepStarted appstudioshared.PromotionRunReasonType = "StepStarted"
+)
+
+// stepStartTimesMessagePrefix is the prefix of the message of the StepStartTimes condition, which is followed by the
+// start time of each step (see formatPromotionStepStartTimes).
+const stepStartTimesMessagePrefix = "Step start times: "
+
+// getPromotionStepStartTimes returns the time at which each step of the automated promotion began, by step number.
+func getPromotionStepStartTimes(promotionRun *appstudioshared.PromotionRun) map[int]metav1.Time {
+
+	res := map[int]metav1.Time{}
+
+	condition := getPromotionRunCondition(promotionRun, PromotionRunConditionStepStartTimes)
+	if condition == nil || !strings.HasPrefix(condition.Message, stepStartTimesMessagePrefix) {
+		return res
+	}
+
+	for _, entry := range strings.Split(strings.TrimPrefix(condition.Message, stepStartTimesMessagePrefix), ", ") {
+
+		stepValue, timeValue, found := strings.Cut(entry, ": ")
+		if !found {
+			continue
+		}
+
+		step, err := strconv.Atoi(stepValue)
+		if err != nil {
+			continue
+		}
+
+		startTime, err := time.Parse(time.RFC3339, timeValue)
+		if err != nil {
+			continue
+		}
+
+		res[step] = metav1.NewTime(startTime)
+	}
+
+	return res
+}
+
+// formatPromotionStepStartTimes returns the start time of each step, ordered by step number, for the message of the
+// StepStartTimes condition.
+func formatPromotionStepStartTimes(stepStartTimes map[int]metav1.Time) string {
+
+	steps := []int{}
+	for step := range stepStartTimes {
+		steps = append(steps, step)
+	}
+	sort.Ints(steps)
+
+	entries := []string{}
+	for _, step := range steps {
+		entries = append(entries, fmt.Sprintf("%d: %s", step, stepStartTimes[step].UTC().Format(time.RFC3339)))
+	}
+
+	return strings.Join(entries, ", ")
+}
+
+// recordPromotionStepStartTime records the time at which the given step of the automated promotion began, the first
+// time the step is reconciled, and returns it. The first step begins when the promotion begins.
+func recordPromotionStepStartTime(ctx context.Context, promotionRun *appstudioshared.PromotionRun, stepNumber int,
+	k8sClient client.Client) (metav1.Time, error) {
+
+	stepStartTimes := getPromotionStepStartTimes(promotionRun)
+	if startTime, exists := stepStartTimes[stepNumber]; exists {
+		return startTime, nil
+	}
+
+	startTime := metav1.Now()
+	if stepNumber == 1 && !promotionRun.Status.PromotionStartTime.IsZero() {
+		startTime = promotionRun.Status.PromotionStartTime
+	}
+	stepStartTimes[stepNumber] = startTime
+
+	if err := updateStatusConditions(ctx, k8sClient, stepStartTimesMessagePrefix+formatPromotionStepStartTimes(stepStartTimes), promotionRun,
+		PromotionRunConditionStepStartTimes, appstudioshared.PromotionRunConditionStatusTrue, PromotionRunReasonStepStarted); err != nil {
+		return metav1.Time{}, fmt.Errorf("unable to record the start time of step %d: %v", stepNumber, err)
+	}
+
+	return startTime, nil
+}
+
+// getPromotionStepStartTime returns the time at which the current step of the promotion began: the start time of the
+// latest step of an automated promotion, otherwise the time at which the promotion began.
+func getPromotionStepStartTime(promotionRun *appstudioshared.PromotionRun) metav1.Time {
+
+	res := promotionRun.Status.PromotionStartTime
+
+	latestStep := 0
+	for step, startTime := range getPromotionStepStartTimes(promotionRun) {
+		if step > latestStep {
+			latestStep, res = step, startTime
+		}
+	}
+
+	return res
+}
+
+// completeAutomatedPromotion marks the automated promotion as complete, with the given result.
+func completeAutomatedPromotion(ctx context.Context, promotionRun *appstudioshared.PromotionRun, success bool, k8sClient client.Client, log logr.Logger) error {
+
+	promotionRun.Status.State = appstudioshared.PromotionRunState_Complete
+	promotionRun.Status.CompletionResult = appstudioshared.PromotionRunCompleteResult_Failure
+	if success {
+		promotionRun.Status.CompletionResult = appstudioshared.PromotionRunCompleteResult_Success
+	}
+
+	if err := k8sClient.Status().Update(ctx, promotionRun); err != nil {
+		log.Error(err, "unable to update PromotionRun state: "+promotionRun.Name)
+		return fmt.Errorf("unable to update PromotionRun state: %v", err)
+	}
+
+	log.Info("Automated promotion complete", "result", promotionRun.Status.CompletionResult)
+
+	if success {
+		// set ErrorOccurred condition to false
+		if err := updateStatusConditions(ctx, k8sClient, "", promotionRun, appstudioshared.PromotionRunConditionErrorOccurred,
+			appstudioshared.PromotionRunConditionStatusFalse, ""); err != nil {
+			log.Error(err, "unable to update PromotionRun status conditions.")
+			return fmt.Errorf("unable to update PromotionRun status conditions %v", err)
+		}
+	}
+
+	return nil
+}
+
+// failAutomatedPromotion rolls back the Environments of the failed step (for those Environments with rollback enabled),
+// and then marks the automated promotion as complete, with a failure result. If the rollback fails, the promotion
+// remains incomplete, and is requeued so that the rollback is retried.
+func failAutomatedPromotion(ctx context.Context, promotionRun *appstudioshared.PromotionRun, stepEnvironments []string,
+	k8sClient client.Client, log logr.Logger) (ctrl.Result, error) {
+
+	rolledBack, err := rollbackPromotion(ctx, promotionRun, stepEnvironments, k8sClient, log)
+	if err != nil {
+		log.Error(err, "unable to roll back PromotionRun: "+promotionRun.Name)
+		return ctrl.Result{}, err
+	}
+
+	if !rolledBack {
+		log.Info("Unable to roll back PromotionRun, the rollback will be retried: " + promotionRun.Name)
+		return ctrl.Result{RequeueAfter: time.Second * 15}, nil
+	}
+
+	return ctrl.Result{}, completeAutomatedPromotion(ctx, promotionRun, false, k8sClient, log)
+}
+
+// environmentExists returns true if an Environment with the given name is in the list.
+func environmentExists(environments []appstudioshared.Environment, name string) bool {
+	for _, environment := range environments {
+		if environment.Name == name {
+			return true
+		}
+	}
+	return false
+}
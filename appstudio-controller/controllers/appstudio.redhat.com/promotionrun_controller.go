@@ -29,6 +29,7 @@ import (
 	apibackend "github.com/redhat-appstudio/managed-gitops/backend-shared/apis/managed-gitops/v1alpha1"
 	sharedutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util"
 	logutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util/log"
+	batchv1 "k8s.io/api/batch/v1"
 	apierr "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -44,12 +45,13 @@ type PromotionRunReconciler struct {
 }
 
 const (
-	// Time limit in minutes, if GitOpsDeployments are not created/synced/Healthy in given time then cancel the Promotion.
+	// Default time limit in minutes, if GitOpsDeployments are not created/synced/Healthy in given time then cancel the Promotion.
+	// - The time limit can be set on the PromotionRun or the Environment, by the PromotionAnnotationTimeout annotation.
 	PromotionRunTimeOutLimit = 10
 
 	StatusMessageAllGitOpsDeploymentsAreSyncedHealthy = "All GitOpsDeployments are Synced/Healthy"
 	ErrMessageTargetEnvironmentHasInvalidValue        = "Target Environment has invalid value."
-	ErrMessageAutomatedPromotionNotSupported          = "Automated promotion are not yet supported."
+	ErrMessageManualAndAutomatedPromotion             = "Only one of manual or automated promotion should be specified."
 )
 
 //+kubebuilder:rbac:groups=appstudio.redhat.com,resources=components,verbs=get;list;watch;create;update;patch;delete
@@ -59,11 +61,15 @@ const (
 //+kubebuilder:rbac:groups=appstudio.redhat.com,resources=promotionruns/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=appstudio.redhat.com,resources=promotionruns/finalizers,verbs=update
 //+kubebuilder:rbac:groups=appstudio.redhat.com,resources=snapshots,verbs=get;list;watch
+//+kubebuilder:rbac:groups=appstudio.redhat.com,resources=environments,verbs=get;list;watch;
 //+kubebuilder:rbac:groups=managed-gitops.redhat.com,resources=gitopsdeployments,verbs=get;list;watch;
 //+kubebuilder:rbac:groups=appstudio.redhat.com,resources=snapshotenvironmentbindings,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=appstudio.redhat.com,resources=snapshotenvironmentbindings/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=appstudio.redhat.com,resources=snapshotenvironmentbindings/finalizers,verbs=update
 //+kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch;
+//+kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+//+kubebuilder:rbac:groups=batch,resources=cronjobs,verbs=get;list;watch
+//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -120,17 +126,23 @@ func (r *PromotionRunReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		return ctrl.Result{}, nil
 	}
 
-	// If this is a automated promotion, ignore it for now
+	// If this is an automated promotion, promote through the Environment graph, beginning with the initial environment.
 	if promotionRun.Spec.AutomatedPromotion.InitialEnvironment != "" {
-		log.Error(nil, ErrMessageAutomatedPromotionNotSupported+" : "+promotionRun.Name)
 
-		// Update Status.Conditions field.
-		if err := updateStatusConditions(ctx, rClient, ErrMessageAutomatedPromotionNotSupported, promotionRun, appstudioshared.PromotionRunConditionErrorOccurred,
-			appstudioshared.PromotionRunConditionStatusTrue, appstudioshared.PromotionRunReasonErrorOccurred); err != nil {
-			log.Error(err, "unable to update PromotionRun status conditions.")
-			return ctrl.Result{}, fmt.Errorf("unable to update PromotionRun status conditions %v", err)
+		// Only one of manual or automated promotion should be specified.
+		if promotionRun.Spec.ManualPromotion.TargetEnvironment != "" {
+			log.Error(nil, ErrMessageManualAndAutomatedPromotion+" : "+promotionRun.Name)
+
+			// Update Status.Conditions field.
+			if err := updateStatusConditions(ctx, rClient, ErrMessageManualAndAutomatedPromotion, promotionRun, appstudioshared.PromotionRunConditionErrorOccurred,
+				appstudioshared.PromotionRunConditionStatusTrue, appstudioshared.PromotionRunReasonErrorOccurred); err != nil {
+				log.Error(err, "unable to update PromotionRun status conditions.")
+				return ctrl.Result{}, fmt.Errorf("unable to update PromotionRun status conditions %v", err)
+			}
+			return ctrl.Result{}, nil
 		}
-		return ctrl.Result{}, nil
+
+		return reconcileAutomatedPromotion(ctx, promotionRun, rClient, log)
 	}
 
 	// If TargetEnvironment is not valid then stop the Promotion.
@@ -146,8 +158,13 @@ func (r *PromotionRunReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		return ctrl.Result{}, nil
 	}
 
+	// A failed promotion is only complete once the binding has been rolled back, so retry the rollback.
+	if isPromotionEnvironmentFailed(*promotionRun, promotionRun.Spec.ManualPromotion.TargetEnvironment) {
+		return failManualPromotion(ctx, promotionRun, rClient, log)
+	}
+
 	// 1) Locate or create the binding that this PromotionRun is targeting
-	binding, err := locateOrCreateTargetManualBinding(ctx, *promotionRun, r.Client, log)
+	binding, err := locateOrCreateTargetBinding(ctx, *promotionRun, promotionRun.Spec.ManualPromotion.TargetEnvironment, r.Client, log)
 	if err != nil {
 		log.Error(err, "error locating or creating Binding for PromotionRun: "+promotionRun.Name)
 		return ctrl.Result{}, nil
@@ -221,6 +238,20 @@ func (r *PromotionRunReconciler) Reconcile(ctx context.Context, req ctrl.Request
 	// 2) Set the Binding to target the expected snapshot, if not already done
 	if binding.Spec.Snapshot != promotionRun.Spec.Snapshot || len(promotionRun.Status.ActiveBindings) == 0 {
 
+		// If rollback is enabled, record the Snapshot that the binding targeted before the promotion.
+		if err := recordPreviousSnapshot(ctx, promotionRun, binding, rClient, log); err != nil {
+			log.Error(err, "unable to record previous Snapshot of Binding: "+binding.Name)
+
+			// Update Status.Conditions field.
+			if err := updateStatusConditions(ctx, rClient, "unable to record previous Snapshot of Binding: "+binding.Name,
+				promotionRun, appstudioshared.PromotionRunConditionErrorOccurred, appstudioshared.PromotionRunConditionStatusTrue, appstudioshared.PromotionRunReasonErrorOccurred); err != nil {
+				log.Error(err, "unable to update PromotionRun status conditions.")
+				return ctrl.Result{}, fmt.Errorf("unable to update PromotionRun status conditions %v", err)
+			}
+
+			return ctrl.Result{}, err
+		}
+
 		binding.Spec.Snapshot = promotionRun.Spec.Snapshot
 
 		if err := rClient.Update(ctx, &binding); err != nil {
@@ -275,6 +306,7 @@ func (r *PromotionRunReconciler) Reconcile(ctx context.Context, req ctrl.Request
 
 	// 4) Wait for all the GitOpsDeployments of the binding to have the expected state
 	waitingGitOpsDeployments := []string{}
+	degradedGitOpsDeployments := []string{}
 
 	for _, gitopsDeploymentName := range binding.Status.GitOpsDeployments {
 		gitopsDeployment := &apibackend.GitOpsDeployment{
@@ -296,6 +328,10 @@ func (r *PromotionRunReconciler) Reconcile(ctx context.Context, req ctrl.Request
 			return ctrl.Result{}, fmt.Errorf("unable to retrieve GitOpsDeployment '%s', %v", gitopsDeployment.Name, err)
 		}
 
+		if gitopsDeployment.Status.Health.Status == apibackend.HeathStatusCodeDegraded {
+			degradedGitOpsDeployments = append(degradedGitOpsDeployments, gitopsDeployment.Name)
+		}
+
 		// Must have status of Synced/Healthy
 		if gitopsDeployment.Status.Sync.Status == apibackend.SyncStatusCodeSynced && gitopsDeployment.Status.Health.Status != apibackend.HeathStatusCodeHealthy {
 			promotionRun.Status.State = appstudioshared.PromotionRunState_Waiting
@@ -332,39 +368,97 @@ func (r *PromotionRunReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		return ctrl.Result{}, fmt.Errorf("unable to retrieve promotionRun '%s', %v", promotionRun.Name, err)
 	}
 
-	if !promotionRun.Status.PromotionStartTime.IsZero() && (metav1.Now().Sub(promotionRun.Status.PromotionStartTime.Time).Minutes() > PromotionRunTimeOutLimit) {
-		promotionRun.Status.CompletionResult = appstudioshared.PromotionRunCompleteResult_Failure
-		promotionRun.Status.State = appstudioshared.PromotionRunState_Complete
+	timeout, err := getPromotionTimeout(ctx, promotionRun, []string{promotionRun.Spec.ManualPromotion.TargetEnvironment}, rClient)
+	if err != nil {
+		log.Error(err, "unable to determine the time limit of PromotionRun: "+promotionRun.Name)
+
+		// Update Status.Conditions field.
+		if err := updateStatusConditions(ctx, rClient, err.Error(), promotionRun, appstudioshared.PromotionRunConditionErrorOccurred,
+			appstudioshared.PromotionRunConditionStatusTrue, appstudioshared.PromotionRunReasonErrorOccurred); err != nil {
+			log.Error(err, "unable to update PromotionRun status conditions.")
+			return ctrl.Result{}, fmt.Errorf("unable to update PromotionRun status conditions %v", err)
+		}
+		return ctrl.Result{}, nil
+	}
 
+	if !promotionRun.Status.PromotionStartTime.IsZero() && (metav1.Now().Sub(promotionRun.Status.PromotionStartTime.Time) > timeout) {
 		// Update Status.Environment.Status field.
-		if err = updateStatusEnvironmentStatus(ctx, rClient, fmt.Sprintf("Promotion Failed. Could not be completed in %d Minutes.", PromotionRunTimeOutLimit),
+		if err = updateStatusEnvironmentStatus(ctx, rClient, promotionTimeoutMessage(timeout),
 			promotionRun, appstudioshared.PromotionRunEnvironmentStatus_Failed, log); err != nil {
 			log.Error(err, "unable to update PromotionRun environment status: "+promotionRun.Name)
 			return ctrl.Result{}, fmt.Errorf("unable to update promotionRun %v", err)
 		}
 
 		// Update status conditions
-		if err = updateStatusConditions(ctx, rClient, fmt.Sprintf("Promotion Failed. Could not be completed in %d Minutes.", PromotionRunTimeOutLimit), promotionRun, appstudioshared.PromotionRunConditionErrorOccurred,
+		if err = updateStatusConditions(ctx, rClient, promotionTimeoutMessage(timeout), promotionRun, appstudioshared.PromotionRunConditionErrorOccurred,
 			appstudioshared.PromotionRunConditionStatusTrue, appstudioshared.PromotionRunReasonErrorOccurred); err != nil {
 			log.Error(err, "unable to update PromotionRun status conditions.")
 			return ctrl.Result{}, fmt.Errorf("unable to update PromotionRun status conditions %v", err)
 		}
 
-		return ctrl.Result{}, nil
+		return failManualPromotion(ctx, promotionRun, rClient, log)
 	}
 
-	if len(waitingGitOpsDeployments) > 0 {
-		log.Info("Waiting for GitOpsDeployments to have expected commit/sync/health:" + strings.Join(waitingGitOpsDeployments[:], ", "))
+	// If the Environment has a deployment strategy, the binding must also complete the rollout of the Snapshot
+	rolloutMessage, rolloutFailed := getBindingRolloutStatus(binding)
+
+	// If rollback is enabled, a Degraded GitOpsDeployment (or a failed rollout) fails the promotion, rather than waiting for it to recover.
+	if len(degradedGitOpsDeployments) > 0 || rolloutFailed {
+		rollbackEnabled, err := isRollbackOnFailureEnabled(ctx, promotionRun, promotionRun.Spec.ManualPromotion.TargetEnvironment, rClient)
+		if err != nil {
+			log.Error(err, "unable to retrieve Environment of PromotionRun: "+promotionRun.Name)
+			return ctrl.Result{}, err
+		}
+
+		if rollbackEnabled {
+			message := "Promotion Failed. " + rolloutMessage
+			if len(degradedGitOpsDeployments) > 0 {
+				message = "Promotion Failed. The following GitOpsDeployments are Degraded: " + strings.Join(degradedGitOpsDeployments, ", ")
+			}
+			log.Info(message)
+
+			// Update Status.Environment.Status field.
+			if err = updateStatusEnvironmentStatus(ctx, rClient, message, promotionRun, appstudioshared.PromotionRunEnvironmentStatus_Failed, log); err != nil {
+				log.Error(err, "unable to update PromotionRun environment status: "+promotionRun.Name)
+				return ctrl.Result{}, fmt.Errorf("unable to update promotionRun %v", err)
+			}
+
+			// Update status conditions
+			if err = updateStatusConditions(ctx, rClient, message, promotionRun, appstudioshared.PromotionRunConditionErrorOccurred,
+				appstudioshared.PromotionRunConditionStatusTrue, appstudioshared.PromotionRunReasonErrorOccurred); err != nil {
+				log.Error(err, "unable to update PromotionRun status conditions.")
+				return ctrl.Result{}, fmt.Errorf("unable to update PromotionRun status conditions %v", err)
+			}
+
+			return failManualPromotion(ctx, promotionRun, rClient, log)
+		}
+	}
+
+	if len(waitingGitOpsDeployments) > 0 || rolloutMessage != "" {
+
+		waitingMessage := rolloutMessage
+		if len(waitingGitOpsDeployments) > 0 {
+			log.Info("Waiting for GitOpsDeployments to have expected commit/sync/health:" + strings.Join(waitingGitOpsDeployments[:], ", "))
+			waitingMessage = "Waiting for following GitOpsDeployments to be Synced/Healthy: " + strings.Join(waitingGitOpsDeployments[:], ", ")
+		} else {
+			log.Info(rolloutMessage)
+		}
 
 		promotionRun.Status.State = appstudioshared.PromotionRunState_Waiting
 
 		// Update Status.Environment.Status field.
-		if err = updateStatusEnvironmentStatus(ctx, rClient, "Waiting for following GitOpsDeployments to be Synced/Healthy: "+strings.Join(waitingGitOpsDeployments[:], ", "),
+		if err = updateStatusEnvironmentStatus(ctx, rClient, waitingMessage,
 			promotionRun, appstudioshared.PromotionRunEnvironmentStatus_InProgress, log); err != nil {
 			log.Error(err, "unable to update PromotionRun environment status: "+promotionRun.Name)
 			return ctrl.Result{}, fmt.Errorf("unable to update promotionRun %v", err)
 		}
 
+		// The GitOpsDeployments are no longer Synced/Healthy, so the promotion gates must be re-evaluated once they are.
+		if _, _, err := evaluatePromotionGates(ctx, promotionRun, []string{promotionRun.Spec.ManualPromotion.TargetEnvironment}, false, rClient, log); err != nil {
+			log.Error(err, "unable to evaluate promotion gates: "+promotionRun.Name)
+			return ctrl.Result{}, err
+		}
+
 		// set ErrorOccurred condition to false:
 		if err = updateStatusConditions(ctx, rClient, "", promotionRun, appstudioshared.PromotionRunConditionErrorOccurred,
 			appstudioshared.PromotionRunConditionStatusFalse, ""); err != nil {
@@ -375,6 +469,52 @@ func (r *PromotionRunReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		return ctrl.Result{RequeueAfter: time.Second * 15}, nil
 	}
 
+	// 5) Wait for the promotion gates of the target environment to pass
+	gateResult, gateMessage, err := evaluatePromotionGates(ctx, promotionRun, []string{promotionRun.Spec.ManualPromotion.TargetEnvironment}, true, rClient, log)
+	if err != nil {
+		log.Error(err, "unable to evaluate promotion gates: "+promotionRun.Name)
+
+		// Update Status.Conditions field.
+		if err := updateStatusConditions(ctx, rClient, "unable to evaluate promotion gates: "+err.Error(), promotionRun, appstudioshared.PromotionRunConditionErrorOccurred,
+			appstudioshared.PromotionRunConditionStatusTrue, appstudioshared.PromotionRunReasonErrorOccurred); err != nil {
+			log.Error(err, "unable to update PromotionRun status conditions.")
+			return ctrl.Result{}, fmt.Errorf("unable to update PromotionRun status conditions %v", err)
+		}
+		return ctrl.Result{}, err
+	}
+
+	if gateResult == promotionGateResult_Failed {
+		log.Info("Promotion gate failed: " + gateMessage)
+
+		// Update Status.Environment.Status field.
+		if err = updateStatusEnvironmentStatus(ctx, rClient, gateMessage, promotionRun, appstudioshared.PromotionRunEnvironmentStatus_Failed, log); err != nil {
+			log.Error(err, "unable to update PromotionRun environment status: "+promotionRun.Name)
+			return ctrl.Result{}, fmt.Errorf("unable to update promotionRun %v", err)
+		}
+
+		// Update status conditions
+		if err = updateStatusConditions(ctx, rClient, gateMessage, promotionRun, appstudioshared.PromotionRunConditionErrorOccurred,
+			appstudioshared.PromotionRunConditionStatusTrue, appstudioshared.PromotionRunReasonErrorOccurred); err != nil {
+			log.Error(err, "unable to update PromotionRun status conditions.")
+			return ctrl.Result{}, fmt.Errorf("unable to update PromotionRun status conditions %v", err)
+		}
+
+		return failManualPromotion(ctx, promotionRun, rClient, log)
+
+	} else if gateResult == promotionGateResult_Pending {
+		log.Info("Waiting for promotion gates: " + gateMessage)
+
+		promotionRun.Status.State = appstudioshared.PromotionRunState_Waiting
+
+		// Update Status.Environment.Status field.
+		if err = updateStatusEnvironmentStatus(ctx, rClient, gateMessage, promotionRun, appstudioshared.PromotionRunEnvironmentStatus_InProgress, log); err != nil {
+			log.Error(err, "unable to update PromotionRun environment status: "+promotionRun.Name)
+			return ctrl.Result{}, fmt.Errorf("unable to update promotionRun %v", err)
+		}
+
+		return ctrl.Result{RequeueAfter: time.Second * 15}, nil
+	}
+
 	// All the GitOpsDeployments are synced/healthy, and they are synced with a commit that includes the target snapshot.
 	promotionRun.Status.CompletionResult = appstudioshared.PromotionRunCompleteResult_Success
 	promotionRun.Status.State = appstudioshared.PromotionRunState_Complete
@@ -426,20 +566,17 @@ func checkForExistingActivePromotions(ctx context.Context, reconciledPromotionRu
 	return nil
 }
 
-func locateOrCreateTargetManualBinding(ctx context.Context, promotionRun appstudioshared.PromotionRun, k8sClient client.Client, logger logr.Logger) (appstudioshared.SnapshotEnvironmentBinding, error) {
+// locateOrCreateTargetBinding returns the binding of the PromotionRun's Application to the target environment,
+// creating it (for all of the Components of the Application) if it does not exist.
+func locateOrCreateTargetBinding(ctx context.Context, promotionRun appstudioshared.PromotionRun, targetEnvironment string,
+	k8sClient client.Client, logger logr.Logger) (appstudioshared.SnapshotEnvironmentBinding, error) {
 
 	// Locate the corresponding binding
-
-	bindingList := appstudioshared.SnapshotEnvironmentBindingList{}
-	if err := k8sClient.List(ctx, &bindingList, &client.ListOptions{Namespace: promotionRun.Namespace}); err != nil {
-		return appstudioshared.SnapshotEnvironmentBinding{}, fmt.Errorf("unable to list bindings: %v", err)
-	}
-
-	for _, binding := range bindingList.Items {
-
-		if binding.Spec.Application == promotionRun.Spec.Application && binding.Spec.Environment == promotionRun.Spec.ManualPromotion.TargetEnvironment {
-			return binding, nil
-		}
+	existingBinding, err := locateTargetBinding(ctx, promotionRun, targetEnvironment, k8sClient)
+	if err != nil {
+		return appstudioshared.SnapshotEnvironmentBinding{}, err
+	} else if existingBinding != nil {
+		return *existingBinding, nil
 	}
 
 	// Binding not found, so create it
@@ -460,16 +597,16 @@ func locateOrCreateTargetManualBinding(ctx context.Context, promotionRun appstud
 	}
 	binding := appstudioshared.SnapshotEnvironmentBinding{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      createBindingName(&promotionRun),
+			Name:      createBindingName(&promotionRun, targetEnvironment),
 			Namespace: promotionRun.Namespace,
 			Labels: map[string]string{
 				"appstudio.application": promotionRun.Spec.Application,
-				"appstudio.environment": promotionRun.Spec.ManualPromotion.TargetEnvironment,
+				"appstudio.environment": targetEnvironment,
 			},
 		},
 		Spec: appstudioshared.SnapshotEnvironmentBindingSpec{
 			Application: promotionRun.Spec.Application,
-			Environment: promotionRun.Spec.ManualPromotion.TargetEnvironment,
+			Environment: targetEnvironment,
 			Snapshot:    promotionRun.Spec.Snapshot,
 			Components:  components,
 		},
@@ -482,13 +619,33 @@ func locateOrCreateTargetManualBinding(ctx context.Context, promotionRun appstud
 	logutil.LogAPIResourceChangeEvent(binding.Namespace, binding.Name, &binding, logutil.ResourceCreated, logger)
 	logger.Info("Created SnapshotEnvironmentBinding",
 		"application", promotionRun.Spec.Application,
-		"environment", promotionRun.Spec.ManualPromotion.TargetEnvironment)
+		"environment", targetEnvironment)
 
 	return binding, nil
 }
 
-func createBindingName(promotionRun *appstudioshared.PromotionRun) string {
-	name := strings.ToLower(promotionRun.Spec.Application + "-" + promotionRun.Spec.ManualPromotion.TargetEnvironment + "-generated-binding")
+// locateTargetBinding returns the binding of the PromotionRun's Application to the target environment, or nil if it does not exist.
+func locateTargetBinding(ctx context.Context, promotionRun appstudioshared.PromotionRun, targetEnvironment string,
+	k8sClient client.Client) (*appstudioshared.SnapshotEnvironmentBinding, error) {
+
+	bindingList := appstudioshared.SnapshotEnvironmentBindingList{}
+	if err := k8sClient.List(ctx, &bindingList, &client.ListOptions{Namespace: promotionRun.Namespace}); err != nil {
+		return nil, fmt.Errorf("unable to list bindings: %v", err)
+	}
+
+	for i := range bindingList.Items {
+		binding := bindingList.Items[i]
+
+		if binding.Spec.Application == promotionRun.Spec.Application && binding.Spec.Environment == targetEnvironment {
+			return &binding, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func createBindingName(promotionRun *appstudioshared.PromotionRun, targetEnvironment string) string {
+	name := strings.ToLower(promotionRun.Spec.Application + "-" + targetEnvironment + "-generated-binding")
 	if len(name) > 250 {
 		// 'suffix' will have a length of 64
 		hash := sha256.Sum256([]byte(name))
@@ -503,19 +660,65 @@ func (r *PromotionRunReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&appstudioshared.PromotionRun{}).
 		Owns(&appstudioshared.SnapshotEnvironmentBinding{}).
+		Owns(&batchv1.Job{}).
 		Complete(r)
 }
 
+// failManualPromotion rolls back the target Environment (if rollback is enabled for it), and then marks the manual
+// promotion as complete, with a failure result. If the rollback fails, the promotion remains incomplete, and is requeued
+// so that the rollback is retried.
+func failManualPromotion(ctx context.Context, promotionRun *appstudioshared.PromotionRun, k8sClient client.Client, log logr.Logger) (ctrl.Result, error) {
+
+	rolledBack, err := rollbackPromotion(ctx, promotionRun, []string{promotionRun.Spec.ManualPromotion.TargetEnvironment}, k8sClient, log)
+	if err != nil {
+		log.Error(err, "unable to roll back PromotionRun: "+promotionRun.Name)
+		return ctrl.Result{}, err
+	}
+
+	if !rolledBack {
+		log.Info("Unable to roll back PromotionRun, the rollback will be retried: " + promotionRun.Name)
+		return ctrl.Result{RequeueAfter: time.Second * 15}, nil
+	}
+
+	promotionRun.Status.CompletionResult = appstudioshared.PromotionRunCompleteResult_Failure
+	promotionRun.Status.State = appstudioshared.PromotionRunState_Complete
+
+	if err := k8sClient.Status().Update(ctx, promotionRun); err != nil {
+		log.Error(err, "unable to update PromotionRun state: "+promotionRun.Name)
+		return ctrl.Result{}, fmt.Errorf("unable to update PromotionRun state: %v", err)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// isPromotionEnvironmentFailed returns true if the promotion to the given Environment has failed.
+func isPromotionEnvironmentFailed(promotionRun appstudioshared.PromotionRun, environmentName string) bool {
+	for _, envStatus := range promotionRun.Status.EnvironmentStatus {
+		if envStatus.EnvironmentName == environmentName {
+			return envStatus.Status == appstudioshared.PromotionRunEnvironmentStatus_Failed
+		}
+	}
+	return false
+}
+
 // Update Status.Environment.Status field.
 func updateStatusEnvironmentStatus(ctx context.Context, client client.Client, displayStatus string, promotionRun *appstudioshared.PromotionRun,
 	status appstudioshared.PromotionRunEnvironmentStatusField, log logr.Logger) error {
 
+	return updateStatusEnvironmentStatusForEnvironment(ctx, client, promotionRun.Spec.ManualPromotion.TargetEnvironment,
+		displayStatus, promotionRun, status, log)
+}
+
+// Update the Status.Environment.Status field of the given environment.
+func updateStatusEnvironmentStatusForEnvironment(ctx context.Context, client client.Client, environmentName string, displayStatus string,
+	promotionRun *appstudioshared.PromotionRun, status appstudioshared.PromotionRunEnvironmentStatusField, log logr.Logger) error {
+
 	targetEnvIndex, targetEnvStep := -1, 0
 
 	// Check if EnvironmentStatus for given Environment is already present.
 	for i, envStatus := range promotionRun.Status.EnvironmentStatus {
 		// Find the Index in array having status for given environment.
-		if envStatus.EnvironmentName == promotionRun.Spec.ManualPromotion.TargetEnvironment {
+		if envStatus.EnvironmentName == environmentName {
 			targetEnvIndex = i
 			break
 		}
@@ -526,7 +729,7 @@ func updateStatusEnvironmentStatus(ctx context.Context, client client.Client, di
 
 		// Find the max Step and Index available
 		for _, j := range promotionRun.Status.EnvironmentStatus {
-			if j.Step > targetEnvIndex {
+			if j.Step > targetEnvStep {
 				targetEnvStep = j.Step
 			}
 		}
@@ -534,7 +737,7 @@ func updateStatusEnvironmentStatus(ctx context.Context, client client.Client, di
 		promotionRun.Status.EnvironmentStatus = append(promotionRun.Status.EnvironmentStatus,
 			appstudioshared.PromotionRunEnvironmentStatus{
 				Step:            targetEnvStep + 1,
-				EnvironmentName: promotionRun.Spec.ManualPromotion.TargetEnvironment,
+				EnvironmentName: environmentName,
 				DisplayStatus:   displayStatus,
 				Status:          status,
 			})
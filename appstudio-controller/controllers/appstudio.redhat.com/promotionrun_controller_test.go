@@ -2,13 +2,18 @@ package appstudioredhatcom
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -233,7 +238,7 @@ var _ = Describe("PromotionRunController Reconciler Tests", func() {
 			checkStatusCondition(ctx, promotionRunReconciler.Client, promotionRun, "Error occurred while checking for existing active promotions.")
 		})
 
-		It("Should not support auto Promotion and Status.Condition should be updated if it already exists.", func() {
+		It("Should not support both manual and automated promotion, and Status.Condition should be updated if it already exists.", func() {
 			promotionRun.Spec.AutomatedPromotion.InitialEnvironment = "abc"
 			err := promotionRunReconciler.Create(ctx, promotionRun)
 			Expect(err).ToNot(HaveOccurred())
@@ -246,7 +251,7 @@ var _ = Describe("PromotionRunController Reconciler Tests", func() {
 			Expect(err).ToNot(HaveOccurred())
 			conditionsLen := len(promotionRun.Status.Conditions)
 			Expect(conditionsLen).Should(BeNumerically(">", 0))
-			checkStatusCondition(ctx, promotionRunReconciler.Client, promotionRun, "Automated promotion are not yet supported.")
+			checkStatusCondition(ctx, promotionRunReconciler.Client, promotionRun, ErrMessageManualAndAutomatedPromotion)
 
 			By("Trigger Reconciler again.")
 			_, err = promotionRunReconciler.Reconcile(ctx, request)
@@ -281,7 +286,7 @@ var _ = Describe("PromotionRunController Reconciler Tests", func() {
 
 			binding := &appstudiosharedv1.SnapshotEnvironmentBinding{}
 			err = promotionRunReconciler.Get(ctx, types.NamespacedName{
-				Name:      createBindingName(promotionRun),
+				Name:      createBindingName(promotionRun, promotionRun.Spec.ManualPromotion.TargetEnvironment),
 				Namespace: promotionRun.Namespace,
 			}, binding)
 			Expect(err).ToNot(HaveOccurred())
@@ -305,7 +310,7 @@ var _ = Describe("PromotionRunController Reconciler Tests", func() {
 
 			By("Testing createBindingName explicitly")
 
-			createdBindingName := createBindingName(promotionRun)
+			createdBindingName := createBindingName(promotionRun, promotionRun.Spec.ManualPromotion.TargetEnvironment)
 			Expect(len(createdBindingName)).To(BeNumerically("<=", 250))
 			Expect(createdBindingName).To(Equal(expectedBindingName))
 
@@ -374,7 +379,7 @@ var _ = Describe("PromotionRunController Reconciler Tests", func() {
 
 			binding := &appstudiosharedv1.SnapshotEnvironmentBinding{}
 			err = promotionRunReconciler.Get(ctx, types.NamespacedName{
-				Name:      createBindingName(promotionRun),
+				Name:      createBindingName(promotionRun, promotionRun.Spec.ManualPromotion.TargetEnvironment),
 				Namespace: promotionRun.Namespace,
 			}, binding)
 			Expect(err).To(HaveOccurred())
@@ -409,7 +414,7 @@ var _ = Describe("PromotionRunController Reconciler Tests", func() {
 
 			binding := &appstudiosharedv1.SnapshotEnvironmentBinding{}
 			err = promotionRunReconciler.Get(ctx, types.NamespacedName{
-				Name:      createBindingName(promotionRun),
+				Name:      createBindingName(promotionRun, promotionRun.Spec.ManualPromotion.TargetEnvironment),
 				Namespace: promotionRun.Namespace,
 			}, binding)
 			Expect(err).ToNot(HaveOccurred())
@@ -453,7 +458,7 @@ var _ = Describe("PromotionRunController Reconciler Tests", func() {
 
 			binding := &appstudiosharedv1.SnapshotEnvironmentBinding{}
 			err = promotionRunReconciler.Get(ctx, types.NamespacedName{
-				Name:      createBindingName(promotionRun),
+				Name:      createBindingName(promotionRun, promotionRun.Spec.ManualPromotion.TargetEnvironment),
 				Namespace: promotionRun.Namespace,
 			}, binding)
 			Expect(err).ToNot(HaveOccurred())
@@ -975,8 +980,1217 @@ var _ = Describe("PromotionRunController Reconciler Tests", func() {
 			checkStatusCondition(ctx, promotionRunReconciler.Client, promotionRun, fmt.Sprintf("Promotion Failed. Could not be completed in %d Minutes.", PromotionRunTimeOutLimit))
 		})
 	})
+
+	Context("Testing PromotionRunController Reconciler with automated promotion", func() {
+
+		var ctx context.Context
+		var request reconcile.Request
+		var promotionRun *appstudiosharedv1.PromotionRun
+		var promotionRunReconciler PromotionRunReconciler
+
+		// createEnvironment creates an Environment with the given parent and deployment strategy
+		createEnvironment := func(name string, parentEnvironment string, deploymentStrategy appstudiosharedv1.DeploymentStrategyType) {
+			environment := appstudiosharedv1.Environment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: promotionRun.Namespace,
+				},
+				Spec: appstudiosharedv1.EnvironmentSpec{
+					DisplayName:        name,
+					DeploymentStrategy: deploymentStrategy,
+					ParentEnvironment:  parentEnvironment,
+				},
+			}
+			Expect(promotionRunReconciler.Create(ctx, &environment)).To(Succeed())
+		}
+
+		// getBinding returns the binding that was generated by the PromotionRun for the given environment
+		getBinding := func(environmentName string) (*appstudiosharedv1.SnapshotEnvironmentBinding, error) {
+			binding := &appstudiosharedv1.SnapshotEnvironmentBinding{}
+			err := promotionRunReconciler.Get(ctx, types.NamespacedName{
+				Name:      createBindingName(promotionRun, environmentName),
+				Namespace: promotionRun.Namespace,
+			}, binding)
+			return binding, err
+		}
+
+		// simulateBindingDeployed simulates the SnapshotEnvironmentBinding controller creating a GitOpsDeployment for
+		// each component of the binding, which are then Synced/Healthy at the GitOps repository commit of the Snapshot.
+		simulateBindingDeployed := func(environmentName string) {
+			binding, err := getBinding(environmentName)
+			Expect(err).ToNot(HaveOccurred())
+
+			binding.Status.GitOpsDeployments = []appstudiosharedv1.BindingStatusGitOpsDeployment{}
+			binding.Status.Components = []appstudiosharedv1.BindingComponentStatus{}
+			for _, component := range binding.Spec.Components {
+				commitID := "commit-" + binding.Spec.Snapshot + "-" + component.Name
+
+				gitopsDeployment := &apibackend.GitOpsDeployment{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      binding.Name + "-" + component.Name,
+						Namespace: binding.Namespace,
+					},
+					Status: apibackend.GitOpsDeploymentStatus{
+						Sync:   apibackend.SyncStatus{Status: apibackend.SyncStatusCodeSynced, Revision: commitID},
+						Health: apibackend.HealthStatus{Status: apibackend.HeathStatusCodeHealthy},
+					},
+				}
+				Expect(promotionRunReconciler.Create(ctx, gitopsDeployment)).To(Succeed())
+
+				binding.Status.GitOpsDeployments = append(binding.Status.GitOpsDeployments, appstudiosharedv1.BindingStatusGitOpsDeployment{
+					ComponentName:    component.Name,
+					GitOpsDeployment: gitopsDeployment.Name,
+				})
+				binding.Status.Components = append(binding.Status.Components, appstudiosharedv1.BindingComponentStatus{
+					Name:             component.Name,
+					GitOpsRepository: appstudiosharedv1.BindingComponentGitOpsRepository{CommitID: commitID},
+				})
+			}
+			Expect(promotionRunReconciler.Status().Update(ctx, binding)).To(Succeed())
+		}
+
+		getEnvironmentStatus := func(environmentName string) *appstudiosharedv1.PromotionRunEnvironmentStatus {
+			for _, envStatus := range promotionRun.Status.EnvironmentStatus {
+				if envStatus.EnvironmentName == environmentName {
+					return &envStatus
+				}
+			}
+			return nil
+		}
+
+		BeforeEach(func() {
+			ctx = context.Background()
+
+			scheme,
+				argocdNamespace,
+				kubesystemNamespace,
+				apiNamespace,
+				err := tests.GenericTestSetup()
+			Expect(err).ToNot(HaveOccurred())
+
+			err = appstudiosharedv1.AddToScheme(scheme)
+			Expect(err).ToNot(HaveOccurred())
+
+			k8sClient := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(apiNamespace, argocdNamespace, kubesystemNamespace).
+				Build()
+
+			promotionRunReconciler = PromotionRunReconciler{Client: k8sClient, Scheme: scheme}
+
+			promotionRun = &appstudiosharedv1.PromotionRun{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "new-demo-app-automated-promotion",
+					Namespace: apiNamespace.Name,
+				},
+				Spec: appstudiosharedv1.PromotionRunSpec{
+					Snapshot:    "my-snapshot",
+					Application: "new-demo-app",
+					AutomatedPromotion: appstudiosharedv1.AutomatedPromotionConfiguration{
+						InitialEnvironment: "staging",
+					},
+				},
+			}
+
+			By("Create the Environment graph: staging -> (prod-a, prod-b), where prod-b -> prod-c")
+			createEnvironment("staging", "", appstudiosharedv1.DeploymentStrategy_Manual)
+			createEnvironment("prod-a", "staging", appstudiosharedv1.DeploymentStrategy_AppStudioAutomated)
+			createEnvironment("prod-b", "staging", appstudiosharedv1.DeploymentStrategy_AppStudioAutomated)
+			createEnvironment("prod-c", "prod-b", appstudiosharedv1.DeploymentStrategy_AppStudioAutomated)
+			createEnvironment("manual-env", "staging", appstudiosharedv1.DeploymentStrategy_Manual)
+			createEnvironment("unrelated-env", "", appstudiosharedv1.DeploymentStrategy_AppStudioAutomated)
+
+			By("Create a component and Snapshot of the Application")
+			component := appstudiosharedv1.Component{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "comp1",
+					Namespace: apiNamespace.Name,
+				},
+				Spec: appstudiosharedv1.ComponentSpec{
+					ComponentName: "component1",
+					Application:   promotionRun.Spec.Application,
+				},
+			}
+			Expect(promotionRunReconciler.Create(ctx, &component)).To(Succeed())
+
+			snapshot := appstudiosharedv1.Snapshot{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      promotionRun.Spec.Snapshot,
+					Namespace: apiNamespace.Name,
+				},
+				Spec: appstudiosharedv1.SnapshotSpec{
+					Application: promotionRun.Spec.Application,
+					DisplayName: promotionRun.Spec.Application,
+				},
+			}
+			Expect(promotionRunReconciler.Create(ctx, &snapshot)).To(Succeed())
+
+			request = newRequest(apiNamespace.Name, promotionRun.Name)
+		})
+
+		It("Should report an error if the initial Environment does not exist.", func() {
+			promotionRun.Spec.AutomatedPromotion.InitialEnvironment = "does-not-exist"
+			Expect(promotionRunReconciler.Create(ctx, promotionRun)).To(Succeed())
+
+			By("Trigger Reconciler.")
+			_, err := promotionRunReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			checkStatusCondition(ctx, promotionRunReconciler.Client, promotionRun, ErrMessageInitialEnvironmentDoesNotExist+"does-not-exist")
+		})
+
+		It("Should promote the Snapshot through the Environment graph, one step at a time.", func() {
+			Expect(promotionRunReconciler.Create(ctx, promotionRun)).To(Succeed())
+
+			By("Trigger Reconciler: the binding of the initial environment should be created.")
+			_, err := promotionRunReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			stagingBinding, err := getBinding("staging")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stagingBinding.Spec.Snapshot).To(Equal(promotionRun.Spec.Snapshot))
+
+			_, err = getBinding("prod-a")
+			Expect(errors.IsNotFound(err)).To(BeTrue(), "child environments should not be promoted to until the parent is Synced/Healthy")
+
+			Expect(promotionRunReconciler.Get(ctx, client.ObjectKeyFromObject(promotionRun), promotionRun)).To(Succeed())
+			Expect(promotionRun.Status.State).To(Equal(appstudiosharedv1.PromotionRunState_Active))
+			Expect(promotionRun.Status.ActiveBindings).To(Equal([]string{stagingBinding.Name}))
+			Expect(getEnvironmentStatus("staging").Status).To(Equal(appstudiosharedv1.PromotionRunEnvironmentStatus_InProgress))
+
+			By("Simulate the initial environment being deployed.")
+			simulateBindingDeployed("staging")
+
+			_, err = promotionRunReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(promotionRunReconciler.Get(ctx, client.ObjectKeyFromObject(promotionRun), promotionRun)).To(Succeed())
+			Expect(getEnvironmentStatus("staging").Status).To(Equal(appstudiosharedv1.PromotionRunEnvironmentStatus_Success))
+			Expect(getEnvironmentStatus("staging").DisplayStatus).To(Equal(StatusMessageAllGitOpsDeploymentsAreSyncedHealthy))
+
+			By("Trigger Reconciler: the second step should promote to both automated children of the initial environment.")
+			_, err = promotionRunReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			prodABinding, err := getBinding("prod-a")
+			Expect(err).ToNot(HaveOccurred())
+			prodBBinding, err := getBinding("prod-b")
+			Expect(err).ToNot(HaveOccurred())
+
+			for _, environmentName := range []string{"prod-c", "manual-env", "unrelated-env"} {
+				_, err = getBinding(environmentName)
+				Expect(errors.IsNotFound(err)).To(BeTrue(), "environment "+environmentName+" should not be promoted to in the second step")
+			}
+
+			Expect(promotionRunReconciler.Get(ctx, client.ObjectKeyFromObject(promotionRun), promotionRun)).To(Succeed())
+			Expect(promotionRun.Status.ActiveBindings).To(Equal([]string{prodABinding.Name, prodBBinding.Name}))
+
+			By("Simulate the second step being deployed, and promote to the third step.")
+			simulateBindingDeployed("prod-a")
+			simulateBindingDeployed("prod-b")
+
+			_, err = promotionRunReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = promotionRunReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			prodCBinding, err := getBinding("prod-c")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(promotionRunReconciler.Get(ctx, client.ObjectKeyFromObject(promotionRun), promotionRun)).To(Succeed())
+			Expect(promotionRun.Status.ActiveBindings).To(Equal([]string{prodCBinding.Name}))
+
+			By("Simulate the third step being deployed: the promotion should be complete.")
+			simulateBindingDeployed("prod-c")
+
+			_, err = promotionRunReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = promotionRunReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(promotionRunReconciler.Get(ctx, client.ObjectKeyFromObject(promotionRun), promotionRun)).To(Succeed())
+			Expect(promotionRun.Status.State).To(Equal(appstudiosharedv1.PromotionRunState_Complete))
+			Expect(promotionRun.Status.CompletionResult).To(Equal(appstudiosharedv1.PromotionRunCompleteResult_Success))
+			Expect(promotionRun.Status.EnvironmentStatus).To(HaveLen(4))
+			for i, envStatus := range promotionRun.Status.EnvironmentStatus {
+				Expect(envStatus.Step).To(Equal(i + 1))
+				Expect(envStatus.Status).To(Equal(appstudiosharedv1.PromotionRunEnvironmentStatus_Success))
+			}
+		})
+
+		It("Should stop the promotion if a step is not Synced/Healthy in the given time limit.", func() {
+
+			By("Set PromotionStartTime to before the time limit.")
+			promotionRun.Status.PromotionStartTime = metav1.NewTime(metav1.Now().Add(time.Duration(-(PromotionRunTimeOutLimit + 2)) * time.Minute))
+			Expect(promotionRunReconciler.Create(ctx, promotionRun)).To(Succeed())
+
+			By("Trigger Reconciler.")
+			_, err := promotionRunReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			checkStatusCondition(ctx, promotionRunReconciler.Client, promotionRun, fmt.Sprintf("Promotion Failed. Could not be completed in %d Minutes.", PromotionRunTimeOutLimit))
+
+			Expect(promotionRun.Status.State).To(Equal(appstudiosharedv1.PromotionRunState_Complete))
+			Expect(promotionRun.Status.CompletionResult).To(Equal(appstudiosharedv1.PromotionRunCompleteResult_Failure))
+			Expect(getEnvironmentStatus("staging").Status).To(Equal(appstudiosharedv1.PromotionRunEnvironmentStatus_Failed))
+
+			By("Simulating the initial environment being deployed should not continue the promotion.")
+			simulateBindingDeployed("staging")
+
+			_, err = promotionRunReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = getBinding("prod-a")
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("Should wait for the GitOpsDeployments to sync the GitOps repository commit of the Snapshot.", func() {
+			Expect(promotionRunReconciler.Create(ctx, promotionRun)).To(Succeed())
+
+			_, err := promotionRunReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Simulate the GitOpsDeployment still reporting the commit of the previous Snapshot.")
+			simulateBindingDeployed("staging")
+
+			binding, err := getBinding("staging")
+			Expect(err).ToNot(HaveOccurred())
+
+			gitopsDeployment := &apibackend.GitOpsDeployment{}
+			Expect(promotionRunReconciler.Get(ctx, types.NamespacedName{Name: binding.Status.GitOpsDeployments[0].GitOpsDeployment,
+				Namespace: binding.Namespace}, gitopsDeployment)).To(Succeed())
+			newCommitID := gitopsDeployment.Status.Sync.Revision
+			gitopsDeployment.Status.Sync.Revision = "previous-commit"
+			Expect(promotionRunReconciler.Status().Update(ctx, gitopsDeployment)).To(Succeed())
+
+			_, err = promotionRunReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(promotionRunReconciler.Get(ctx, client.ObjectKeyFromObject(promotionRun), promotionRun)).To(Succeed())
+			Expect(getEnvironmentStatus("staging").Status).To(Equal(appstudiosharedv1.PromotionRunEnvironmentStatus_InProgress))
+			Expect(getEnvironmentStatus("staging").DisplayStatus).To(Equal("Waiting for following GitOpsDeployments to be Synced/Healthy: " + gitopsDeployment.Name))
+
+			By("Simulate the GitOpsDeployment syncing the commit of the Snapshot.")
+			gitopsDeployment.Status.Sync.Revision = newCommitID
+			Expect(promotionRunReconciler.Status().Update(ctx, gitopsDeployment)).To(Succeed())
+
+			_, err = promotionRunReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(promotionRunReconciler.Get(ctx, client.ObjectKeyFromObject(promotionRun), promotionRun)).To(Succeed())
+			Expect(getEnvironmentStatus("staging").Status).To(Equal(appstudiosharedv1.PromotionRunEnvironmentStatus_Success))
+		})
+
+		It("Should apply the time limit of each step from the time at which the step began.", func() {
+			promotionRun.Annotations = map[string]string{PromotionAnnotationTimeout: "5m"}
+			Expect(promotionRunReconciler.Create(ctx, promotionRun)).To(Succeed())
+
+			// setStepStartTimes simulates time passing since each step began
+			setStepStartTimes := func(stepStartTimes map[int]metav1.Time) {
+				Expect(promotionRunReconciler.Get(ctx, client.ObjectKeyFromObject(promotionRun), promotionRun)).To(Succeed())
+				promotionRun.Status.PromotionStartTime = stepStartTimes[1]
+				Expect(updateStatusConditions(ctx, promotionRunReconciler.Client, stepStartTimesMessagePrefix+formatPromotionStepStartTimes(stepStartTimes),
+					promotionRun, PromotionRunConditionStepStartTimes, appstudiosharedv1.PromotionRunConditionStatusTrue, PromotionRunReasonStepStarted)).To(Succeed())
+			}
+
+			_, err := promotionRunReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(promotionRunReconciler.Get(ctx, client.ObjectKeyFromObject(promotionRun), promotionRun)).To(Succeed())
+			Expect(getPromotionStepStartTimes(promotionRun)).To(HaveKey(1))
+
+			simulateBindingDeployed("staging")
+			_, err = promotionRunReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("The first step took longer than the time limit of the second step: the second step should not time out.")
+			setStepStartTimes(map[int]metav1.Time{1: metav1.NewTime(time.Now().Add(-10 * time.Minute))})
+
+			_, err = promotionRunReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(promotionRunReconciler.Get(ctx, client.ObjectKeyFromObject(promotionRun), promotionRun)).To(Succeed())
+			stepStartTimes := getPromotionStepStartTimes(promotionRun)
+			Expect(stepStartTimes).To(HaveKey(2))
+			Expect(time.Since(stepStartTimes[2].Time)).To(BeNumerically("<", time.Minute))
+			Expect(getPromotionStepStartTime(promotionRun)).To(Equal(stepStartTimes[2]))
+			Expect(promotionRun.Status.State).To(Equal(appstudiosharedv1.PromotionRunState_Active))
+			Expect(getEnvironmentStatus("prod-a").Status).To(Equal(appstudiosharedv1.PromotionRunEnvironmentStatus_InProgress))
+
+			By("The second step takes longer than its time limit: the promotion should fail.")
+			stepStartTimes[2] = metav1.NewTime(time.Now().Add(-6 * time.Minute))
+			setStepStartTimes(stepStartTimes)
+
+			_, err = promotionRunReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			checkStatusCondition(ctx, promotionRunReconciler.Client, promotionRun, "Promotion Failed. Could not be completed in 5 Minutes.")
+			Expect(getEnvironmentStatus("prod-a").Status).To(Equal(appstudiosharedv1.PromotionRunEnvironmentStatus_Failed))
+			Expect(getEnvironmentStatus("staging").Status).To(Equal(appstudiosharedv1.PromotionRunEnvironmentStatus_Success))
+		})
+
+		It("Should roll back the Environments of a step which fails, if rollback is enabled.", func() {
+
+			By("Create a binding for the initial Environment, which targets a previous Snapshot.")
+			binding := &appstudiosharedv1.SnapshotEnvironmentBinding{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      createBindingName(promotionRun, "staging"),
+					Namespace: promotionRun.Namespace,
+				},
+				Spec: appstudiosharedv1.SnapshotEnvironmentBindingSpec{
+					Application: promotionRun.Spec.Application,
+					Environment: "staging",
+					Snapshot:    "previous-snapshot",
+					Components:  []appstudiosharedv1.BindingComponent{{Name: "comp1"}},
+				},
+			}
+			Expect(promotionRunReconciler.Create(ctx, binding)).To(Succeed())
+
+			promotionRun.Annotations = map[string]string{
+				PromotionAnnotationRollbackOnFailure: "true",
+				PromotionAnnotationTimeout:           "1m",
+			}
+			promotionRun.Status.PromotionStartTime = metav1.NewTime(metav1.Now().Add(-2 * time.Minute))
+			Expect(promotionRunReconciler.Create(ctx, promotionRun)).To(Succeed())
+
+			By("Trigger Reconciler: the binding is updated to the new Snapshot, and the step times out.")
+			_, err := promotionRunReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			checkStatusCondition(ctx, promotionRunReconciler.Client, promotionRun, "Promotion Failed. Could not be completed in 1 Minutes.")
+			Expect(promotionRun.Status.CompletionResult).To(Equal(appstudiosharedv1.PromotionRunCompleteResult_Failure))
+			Expect(getEnvironmentStatus("staging").Status).To(Equal(appstudiosharedv1.PromotionRunEnvironmentStatus_Failed))
+
+			binding, err = getBinding("staging")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(binding.Spec.Snapshot).To(Equal("previous-snapshot"))
+
+			condition := getPromotionRunCondition(promotionRun, PromotionRunConditionRollback)
+			Expect(condition).ToNot(BeNil())
+			Expect(condition.Reason).To(Equal(PromotionRunReasonRolledBack))
+			Expect(condition.Message).To(Equal("Rolled back to the previous Snapshots: staging: previous-snapshot"))
+		})
+
+		It("Should not loop forever if the Environment graph contains a cycle.", func() {
+
+			environments := []appstudiosharedv1.Environment{
+				{ObjectMeta: metav1.ObjectMeta{Name: "env-a"}, Spec: appstudiosharedv1.EnvironmentSpec{ParentEnvironment: "env-b",
+					DeploymentStrategy: appstudiosharedv1.DeploymentStrategy_AppStudioAutomated}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "env-b"}, Spec: appstudiosharedv1.EnvironmentSpec{ParentEnvironment: "env-a",
+					DeploymentStrategy: appstudiosharedv1.DeploymentStrategy_AppStudioAutomated}},
+			}
+
+			promotionRun.Spec.AutomatedPromotion.InitialEnvironment = "env-a"
+			promotionRun.Status.EnvironmentStatus = []appstudiosharedv1.PromotionRunEnvironmentStatus{
+				{Step: 1, EnvironmentName: "env-a", Status: appstudiosharedv1.PromotionRunEnvironmentStatus_Success},
+			}
+
+			step, previousSteps, failed := getCurrentAutomatedPromotionStep(*promotionRun, environments)
+			Expect(failed).To(BeFalse())
+			Expect(previousSteps).To(Equal([][]string{{"env-a"}}))
+			Expect(step).To(Equal([]string{"env-b"}))
+
+			promotionRun.Status.EnvironmentStatus = append(promotionRun.Status.EnvironmentStatus, appstudiosharedv1.PromotionRunEnvironmentStatus{
+				Step: 2, EnvironmentName: "env-b", Status: appstudiosharedv1.PromotionRunEnvironmentStatus_Success,
+			})
+
+			step, _, failed = getCurrentAutomatedPromotionStep(*promotionRun, environments)
+			Expect(failed).To(BeFalse())
+			Expect(step).To(BeEmpty())
+		})
+	})
+
+	Context("Testing PromotionRunController Reconciler with promotion gates", func() {
+
+		var ctx context.Context
+		var request reconcile.Request
+		var environment appstudiosharedv1.Environment
+		var promotionRun *appstudiosharedv1.PromotionRun
+		var promotionRunReconciler PromotionRunReconciler
+
+		BeforeEach(func() {
+			ctx = context.Background()
+
+			scheme,
+				argocdNamespace,
+				kubesystemNamespace,
+				apiNamespace,
+				err := tests.GenericTestSetup()
+			Expect(err).ToNot(HaveOccurred())
+
+			err = appstudiosharedv1.AddToScheme(scheme)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = batchv1.AddToScheme(scheme)
+			Expect(err).ToNot(HaveOccurred())
+
+			k8sClient := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(apiNamespace, argocdNamespace, kubesystemNamespace).
+				Build()
+
+			promotionRunReconciler = PromotionRunReconciler{Client: k8sClient, Scheme: scheme}
+
+			environment = appstudiosharedv1.Environment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "prod",
+					Namespace:   apiNamespace.Name,
+					Annotations: map[string]string{},
+				},
+				Spec: appstudiosharedv1.EnvironmentSpec{
+					DisplayName:        "prod",
+					DeploymentStrategy: appstudiosharedv1.DeploymentStrategy_Manual,
+				},
+			}
+
+			promotionRun = &appstudiosharedv1.PromotionRun{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "new-demo-app-manual-promotion",
+					Namespace:   apiNamespace.Name,
+					Annotations: map[string]string{},
+				},
+				Spec: appstudiosharedv1.PromotionRunSpec{
+					Snapshot:    "my-snapshot",
+					Application: "new-demo-app",
+					ManualPromotion: appstudiosharedv1.ManualPromotionConfiguration{
+						TargetEnvironment: environment.Name,
+					},
+				},
+			}
+
+			By("Create a Snapshot, and a binding whose GitOpsDeployment is Synced/Healthy.")
+			snapshot := appstudiosharedv1.Snapshot{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      promotionRun.Spec.Snapshot,
+					Namespace: apiNamespace.Name,
+				},
+				Spec: appstudiosharedv1.SnapshotSpec{
+					Application: promotionRun.Spec.Application,
+				},
+			}
+			Expect(k8sClient.Create(ctx, &snapshot)).To(Succeed())
+
+			binding := &appstudiosharedv1.SnapshotEnvironmentBinding{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "appa-prod-binding",
+					Namespace: apiNamespace.Name,
+				},
+				Spec: appstudiosharedv1.SnapshotEnvironmentBindingSpec{
+					Application: promotionRun.Spec.Application,
+					Environment: environment.Name,
+					Snapshot:    promotionRun.Spec.Snapshot,
+					Components:  []appstudiosharedv1.BindingComponent{{Name: "component-a"}},
+				},
+				Status: appstudiosharedv1.SnapshotEnvironmentBindingStatus{
+					GitOpsDeployments: []appstudiosharedv1.BindingStatusGitOpsDeployment{
+						{ComponentName: "component-a", GitOpsDeployment: "appa-prod-binding-component-a"},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, binding)).To(Succeed())
+
+			gitopsDeployment := &apibackend.GitOpsDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "appa-prod-binding-component-a",
+					Namespace: apiNamespace.Name,
+				},
+				Status: apibackend.GitOpsDeploymentStatus{
+					Sync:   apibackend.SyncStatus{Status: apibackend.SyncStatusCodeSynced},
+					Health: apibackend.HealthStatus{Status: apibackend.HeathStatusCodeHealthy},
+				},
+			}
+			Expect(k8sClient.Create(ctx, gitopsDeployment)).To(Succeed())
+
+			promotionRun.Status.ActiveBindings = []string{binding.Name}
+			promotionRun.Status.PromotionStartTime = metav1.NewTime(time.Now().Add(-5 * time.Minute))
+
+			request = newRequest(apiNamespace.Name, promotionRun.Name)
+		})
+
+		getGateCondition := func(conditionType appstudiosharedv1.PromotionRunConditionType) *appstudiosharedv1.PromotionRunCondition {
+			Expect(promotionRunReconciler.Get(ctx, client.ObjectKeyFromObject(promotionRun), promotionRun)).To(Succeed())
+			return getPromotionRunCondition(promotionRun, conditionType)
+		}
+
+		It("Should complete the promotion immediately if no gates are configured.", func() {
+			Expect(promotionRunReconciler.Create(ctx, &environment)).To(Succeed())
+			Expect(promotionRunReconciler.Create(ctx, promotionRun)).To(Succeed())
+
+			_, err := promotionRunReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(getGateCondition(PromotionRunConditionHealthySoakGate)).To(BeNil())
+			Expect(promotionRun.Status.State).To(Equal(appstudiosharedv1.PromotionRunState_Complete))
+			Expect(promotionRun.Status.CompletionResult).To(Equal(appstudiosharedv1.PromotionRunCompleteResult_Success))
+		})
+
+		It("Should wait for the GitOpsDeployments to be Synced/Healthy for the minimum duration of the Environment.", func() {
+			environment.Annotations[PromotionGateAnnotationMinimumHealthyDuration] = "1h"
+			Expect(promotionRunReconciler.Create(ctx, &environment)).To(Succeed())
+			Expect(promotionRunReconciler.Create(ctx, promotionRun)).To(Succeed())
+
+			res, err := promotionRunReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.RequeueAfter).ToNot(BeZero())
+
+			condition := getGateCondition(PromotionRunConditionHealthySoakGate)
+			Expect(condition).ToNot(BeNil())
+			Expect(condition.Status).To(Equal(appstudiosharedv1.PromotionRunConditionStatusFalse))
+			Expect(condition.Reason).To(Equal(PromotionRunReasonGatePending))
+			Expect(promotionRun.Status.State).To(Equal(appstudiosharedv1.PromotionRunState_Waiting))
+			Expect(promotionRun.Status.EnvironmentStatus[0].Status).To(Equal(appstudiosharedv1.PromotionRunEnvironmentStatus_InProgress))
+			Expect(promotionRun.Status.EnvironmentStatus[0].DisplayStatus).To(Equal(condition.Message))
+
+			By("Reconciling again before the duration has passed should not complete the promotion.")
+			_, err = promotionRunReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(getGateCondition(PromotionRunConditionHealthySoakGate).Status).To(Equal(appstudiosharedv1.PromotionRunConditionStatusFalse))
+
+			By("Simulate the duration passing, by moving the transition time of the condition into the past.")
+			soakStart := metav1.NewTime(time.Now().Add(-2 * time.Hour))
+			getPromotionRunCondition(promotionRun, PromotionRunConditionHealthySoakGate).LastTransitionTime = &soakStart
+			Expect(promotionRunReconciler.Status().Update(ctx, promotionRun)).To(Succeed())
+
+			_, err = promotionRunReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			condition = getGateCondition(PromotionRunConditionHealthySoakGate)
+			Expect(condition.Status).To(Equal(appstudiosharedv1.PromotionRunConditionStatusTrue))
+			Expect(condition.Reason).To(Equal(PromotionRunReasonGatePassed))
+			Expect(promotionRun.Status.State).To(Equal(appstudiosharedv1.PromotionRunState_Complete))
+			Expect(promotionRun.Status.CompletionResult).To(Equal(appstudiosharedv1.PromotionRunCompleteResult_Success))
+		})
+
+		It("Should fail the promotion if Pods created since the promotion began restarted more than the maximum.", func() {
+			Expect(promotionRunReconciler.Create(ctx, &environment)).To(Succeed())
+
+			By("Set the gate on the PromotionRun, which adds the gate to the Environment.")
+			promotionRun.Annotations[PromotionGateAnnotationMaximumPodRestarts] = "2"
+			Expect(promotionRunReconciler.Create(ctx, promotionRun)).To(Succeed())
+
+			By("Create a Pod which was created before the promotion began, which should be ignored.")
+			oldPod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "old-pod",
+					Namespace:         environment.Namespace,
+					CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Hour)),
+				},
+				Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{Name: "a", RestartCount: 10}}},
+			}
+			Expect(promotionRunReconciler.Create(ctx, oldPod)).To(Succeed())
+
+			newPod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "new-pod",
+					Namespace:         environment.Namespace,
+					CreationTimestamp: metav1.Now(),
+				},
+				Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{Name: "a", RestartCount: 1}, {Name: "b", RestartCount: 1}}},
+			}
+			Expect(promotionRunReconciler.Create(ctx, newPod)).To(Succeed())
+
+			By("The restarts are within the maximum, so the gate should pass.")
+			_, err := promotionRunReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			condition := getGateCondition(PromotionRunConditionPodRestartGate)
+			Expect(condition).ToNot(BeNil())
+			Expect(condition.Status).To(Equal(appstudiosharedv1.PromotionRunConditionStatusTrue))
+			Expect(promotionRun.Status.CompletionResult).To(Equal(appstudiosharedv1.PromotionRunCompleteResult_Success))
+
+			By("Promote again, with a restart spike.")
+			Expect(promotionRunReconciler.Delete(ctx, promotionRun)).To(Succeed())
+			promotionRun.ResourceVersion = ""
+			promotionRun.Status = appstudiosharedv1.PromotionRunStatus{
+				ActiveBindings:     promotionRun.Status.ActiveBindings,
+				PromotionStartTime: promotionRun.Status.PromotionStartTime,
+			}
+			Expect(promotionRunReconciler.Create(ctx, promotionRun)).To(Succeed())
+
+			newPod.Status.ContainerStatuses[1].RestartCount = 5
+			Expect(promotionRunReconciler.Status().Update(ctx, newPod)).To(Succeed())
+
+			_, err = promotionRunReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			condition = getGateCondition(PromotionRunConditionPodRestartGate)
+			Expect(condition.Status).To(Equal(appstudiosharedv1.PromotionRunConditionStatusFalse))
+			Expect(condition.Reason).To(Equal(PromotionRunReasonGateFailed))
+			Expect(condition.Message).To(ContainSubstring("6 Pod restart(s)"))
+			Expect(promotionRun.Status.State).To(Equal(appstudiosharedv1.PromotionRunState_Complete))
+			Expect(promotionRun.Status.CompletionResult).To(Equal(appstudiosharedv1.PromotionRunCompleteResult_Failure))
+			Expect(promotionRun.Status.EnvironmentStatus[0].Status).To(Equal(appstudiosharedv1.PromotionRunEnvironmentStatus_Failed))
+			checkStatusCondition(ctx, promotionRunReconciler.Client, promotionRun, condition.Message)
+		})
+
+		It("Should call the check webhook, and only complete the promotion successfully if it returns a 2xx response.", func() {
+
+			var received []promotionGateWebhookRequest
+			statusCode := http.StatusOK
+
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+				webhookRequest := promotionGateWebhookRequest{}
+				Expect(json.NewDecoder(r.Body).Decode(&webhookRequest)).To(Succeed())
+				received = append(received, webhookRequest)
+				w.WriteHeader(statusCode)
+			}))
+			defer server.Close()
+
+			// Trust the certificate of the test server
+			originalHTTPClient := promotionGateHTTPClient
+			promotionGateHTTPClient = server.Client()
+			defer func() { promotionGateHTTPClient = originalHTTPClient }()
+
+			environment.Annotations[PromotionGateAnnotationCheckWebhook] = server.URL
+			Expect(promotionRunReconciler.Create(ctx, &environment)).To(Succeed())
+
+			By("The webhook fails the check.")
+			statusCode = http.StatusInternalServerError
+			Expect(promotionRunReconciler.Create(ctx, promotionRun)).To(Succeed())
+
+			_, err := promotionRunReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(received).To(Equal([]promotionGateWebhookRequest{{
+				PromotionRun: promotionRun.Name,
+				Namespace:    promotionRun.Namespace,
+				Application:  promotionRun.Spec.Application,
+				Snapshot:     promotionRun.Spec.Snapshot,
+				Environment:  environment.Name,
+			}}))
+
+			condition := getGateCondition(PromotionRunConditionCheckGate)
+			Expect(condition.Reason).To(Equal(PromotionRunReasonGateFailed))
+			Expect(condition.Message).To(ContainSubstring("status code 500"))
+			Expect(promotionRun.Status.CompletionResult).To(Equal(appstudiosharedv1.PromotionRunCompleteResult_Failure))
+
+			By("The webhook passes the check.")
+			Expect(promotionRunReconciler.Delete(ctx, promotionRun)).To(Succeed())
+			promotionRun.ResourceVersion = ""
+			promotionRun.Status = appstudiosharedv1.PromotionRunStatus{ActiveBindings: promotionRun.Status.ActiveBindings}
+			Expect(promotionRunReconciler.Create(ctx, promotionRun)).To(Succeed())
+
+			statusCode = http.StatusOK
+			_, err = promotionRunReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			condition = getGateCondition(PromotionRunConditionCheckGate)
+			Expect(condition.Status).To(Equal(appstudiosharedv1.PromotionRunConditionStatusTrue))
+			Expect(promotionRun.Status.CompletionResult).To(Equal(appstudiosharedv1.PromotionRunCompleteResult_Success))
+		})
+
+		It("Should fail the check gate if the check webhook is not an https URL.", func() {
+			environment.Annotations[PromotionGateAnnotationCheckWebhook] = "http://example.com/check"
+			Expect(promotionRunReconciler.Create(ctx, &environment)).To(Succeed())
+			Expect(promotionRunReconciler.Create(ctx, promotionRun)).To(Succeed())
+
+			_, err := promotionRunReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			condition := getGateCondition(PromotionRunConditionCheckGate)
+			Expect(condition.Reason).To(Equal(PromotionRunReasonGateFailed))
+			Expect(condition.Message).To(ContainSubstring("must be an https URL"))
+			Expect(promotionRun.Status.CompletionResult).To(Equal(appstudiosharedv1.PromotionRunCompleteResult_Failure))
+		})
+
+		It("Should fail the check gate if the host of the check webhook is cluster-internal.", func() {
+			environment.Annotations[PromotionGateAnnotationCheckWebhook] = "https://my-service.my-namespace.svc/check"
+			Expect(promotionRunReconciler.Create(ctx, &environment)).To(Succeed())
+			Expect(promotionRunReconciler.Create(ctx, promotionRun)).To(Succeed())
+
+			_, err := promotionRunReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			condition := getGateCondition(PromotionRunConditionCheckGate)
+			Expect(condition.Reason).To(Equal(PromotionRunReasonGateFailed))
+			Expect(condition.Message).To(ContainSubstring("host 'my-service.my-namespace.svc' is cluster-internal"))
+			Expect(promotionRun.Status.CompletionResult).To(Equal(appstudiosharedv1.PromotionRunCompleteResult_Failure))
+		})
+
+		It("Should only allow the PromotionRun to make the gates of the Environment stricter.", func() {
+			environment.Annotations[PromotionGateAnnotationMinimumHealthyDuration] = "10m"
+			environment.Annotations[PromotionGateAnnotationMaximumPodRestarts] = "2"
+			environment.Annotations[PromotionGateAnnotationCheckCronJob] = "smoke-test"
+			Expect(promotionRunReconciler.Create(ctx, &environment)).To(Succeed())
+
+			By("The PromotionRun attempts to loosen every gate, and to add a check webhook.")
+			promotionRun.Annotations[PromotionGateAnnotationMinimumHealthyDuration] = "0s"
+			promotionRun.Annotations[PromotionGateAnnotationMaximumPodRestarts] = "100"
+			promotionRun.Annotations[PromotionGateAnnotationCheckCronJob] = "always-succeeds"
+			promotionRun.Annotations[PromotionGateAnnotationCheckWebhook] = "https://example.com/check"
+
+			gateEnvironments, err := getPromotionGateEnvironments(ctx, promotionRun, []string{environment.Name}, promotionRunReconciler.Client)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(gateEnvironments).To(HaveLen(1))
+			Expect(gateEnvironments[0].minimumHealthyDuration).To(Equal(10 * time.Minute))
+			Expect(*gateEnvironments[0].maximumPodRestarts).To(Equal(2))
+			Expect(gateEnvironments[0].checkCronJob).To(Equal("smoke-test"))
+			Expect(gateEnvironments[0].checkWebhook).To(BeEmpty())
+
+			By("The PromotionRun makes the gates stricter.")
+			promotionRun.Annotations[PromotionGateAnnotationMinimumHealthyDuration] = "1h"
+			promotionRun.Annotations[PromotionGateAnnotationMaximumPodRestarts] = "0"
+
+			gateEnvironments, err = getPromotionGateEnvironments(ctx, promotionRun, []string{environment.Name}, promotionRunReconciler.Client)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(gateEnvironments[0].minimumHealthyDuration).To(Equal(time.Hour))
+			Expect(*gateEnvironments[0].maximumPodRestarts).To(Equal(0))
+		})
+
+		It("Should create a Job from the check CronJob, and wait for it to succeed.", func() {
+			environment.Annotations[PromotionGateAnnotationCheckCronJob] = "smoke-test"
+			Expect(promotionRunReconciler.Create(ctx, &environment)).To(Succeed())
+			Expect(promotionRunReconciler.Create(ctx, promotionRun)).To(Succeed())
+
+			By("The CronJob doesn't exist, so the gate should fail.")
+			_, err := promotionRunReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(getGateCondition(PromotionRunConditionCheckGate).Reason).To(Equal(PromotionRunReasonGateFailed))
+			Expect(promotionRun.Status.CompletionResult).To(Equal(appstudiosharedv1.PromotionRunCompleteResult_Failure))
+
+			By("Create the CronJob, and promote again.")
+			cronJob := &batchv1.CronJob{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "smoke-test",
+					Namespace: promotionRun.Namespace,
+				},
+				Spec: batchv1.CronJobSpec{
+					Schedule: "@yearly",
+					JobTemplate: batchv1.JobTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"test": "smoke"}},
+						Spec: batchv1.JobSpec{
+							Template: corev1.PodTemplateSpec{
+								Spec: corev1.PodSpec{
+									Containers:    []corev1.Container{{Name: "smoke-test", Image: "quay.io/example/smoke-test:latest"}},
+									RestartPolicy: corev1.RestartPolicyNever,
+								},
+							},
+						},
+					},
+				},
+			}
+			Expect(promotionRunReconciler.Create(ctx, cronJob)).To(Succeed())
+
+			Expect(promotionRunReconciler.Delete(ctx, promotionRun)).To(Succeed())
+			promotionRun.ResourceVersion = ""
+			promotionRun.Status = appstudiosharedv1.PromotionRunStatus{ActiveBindings: promotionRun.Status.ActiveBindings}
+			Expect(promotionRunReconciler.Create(ctx, promotionRun)).To(Succeed())
+
+			_, err = promotionRunReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			job := &batchv1.Job{}
+			Expect(promotionRunReconciler.Get(ctx, types.NamespacedName{
+				Namespace: promotionRun.Namespace,
+				Name:      generateCheckJobName(promotionRun, environment.Name),
+			}, job)).To(Succeed())
+			Expect(job.Labels).To(HaveKeyWithValue("test", "smoke"))
+			Expect(job.Labels).To(HaveKeyWithValue(promotionGateCheckJobLabel, promotionRun.Name))
+			Expect(job.Spec.Template.Spec.Containers[0].Name).To(Equal("smoke-test"))
+			Expect(job.OwnerReferences).To(HaveLen(1))
+			Expect(job.OwnerReferences[0].Name).To(Equal(promotionRun.Name))
+
+			Expect(getGateCondition(PromotionRunConditionCheckGate).Reason).To(Equal(PromotionRunReasonGatePending))
+			Expect(promotionRun.Status.State).To(Equal(appstudiosharedv1.PromotionRunState_Waiting))
+
+			By("Simulate the Job succeeding.")
+			job.Status.Succeeded = 1
+			Expect(promotionRunReconciler.Status().Update(ctx, job)).To(Succeed())
+
+			_, err = promotionRunReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(getGateCondition(PromotionRunConditionCheckGate).Status).To(Equal(appstudiosharedv1.PromotionRunConditionStatusTrue))
+			Expect(promotionRun.Status.State).To(Equal(appstudiosharedv1.PromotionRunState_Complete))
+			Expect(promotionRun.Status.CompletionResult).To(Equal(appstudiosharedv1.PromotionRunCompleteResult_Success))
+		})
+
+		It("Should reuse the client of the cluster of an Environment, until its cluster credentials Secret changes.", func() {
+			DeferCleanup(func() {
+				environmentClientCache = &environmentTargetClientCache{}
+			})
+
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "prod-cluster-credentials",
+					Namespace: environment.Namespace,
+				},
+				Data: map[string][]byte{"kubeconfig": []byte("not a kubeconfig")},
+			}
+			Expect(promotionRunReconciler.Create(ctx, secret)).To(Succeed())
+
+			environment.UID = "prod-environment-uid"
+			environment.Spec.UnstableConfigurationFields = &appstudiosharedv1.UnstableEnvironmentConfiguration{
+				KubernetesClusterCredentials: appstudiosharedv1.KubernetesClusterCredentials{
+					TargetNamespace:          "prod-namespace",
+					APIURL:                   "https://api.prod.example.com:6443",
+					ClusterCredentialsSecret: secret.Name,
+				},
+			}
+
+			By("Cache a client for the current version of the Secret.")
+			cachedClient := promotionRunReconciler.Client
+			environmentClientCache.set(environment, environmentClientConfig{
+				apiURL:                "https://api.prod.example.com:6443",
+				secretName:            secret.Name,
+				secretResourceVersion: secret.ResourceVersion,
+			}, cachedClient)
+
+			targetNamespace, targetClient, err := getEnvironmentTargetNamespaceClient(ctx, environment, promotionRunReconciler.Client)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(targetNamespace).To(Equal("prod-namespace"))
+			Expect(targetClient).To(BeIdenticalTo(cachedClient))
+
+			By("Modify the Secret: a new client should be created from it, which fails as it is not a kubeconfig.")
+			secret.Data["kubeconfig"] = []byte("still not a kubeconfig")
+			Expect(promotionRunReconciler.Update(ctx, secret)).To(Succeed())
+
+			_, _, err = getEnvironmentTargetNamespaceClient(ctx, environment, promotionRunReconciler.Client)
+			Expect(err).To(HaveOccurred())
+
+			By("Another Environment, with the same Secret, should not use the cached client.")
+			otherEnvironment := *environment.DeepCopy()
+			otherEnvironment.Name = "staging"
+			otherEnvironment.UID = "staging-environment-uid"
+			_, found := environmentClientCache.get(otherEnvironment, environmentClientConfig{
+				apiURL:                "https://api.prod.example.com:6443",
+				secretName:            secret.Name,
+				secretResourceVersion: secret.ResourceVersion,
+			})
+			Expect(found).To(BeFalse())
+		})
+	})
+
+	Context("Testing PromotionRunController Reconciler with timeout and rollback", func() {
+
+		var ctx context.Context
+		var request reconcile.Request
+		var environment appstudiosharedv1.Environment
+		var binding *appstudiosharedv1.SnapshotEnvironmentBinding
+		var gitopsDeployment *apibackend.GitOpsDeployment
+		var promotionRun *appstudiosharedv1.PromotionRun
+		var promotionRunReconciler PromotionRunReconciler
+
+		BeforeEach(func() {
+			ctx = context.Background()
+
+			scheme,
+				argocdNamespace,
+				kubesystemNamespace,
+				apiNamespace,
+				err := tests.GenericTestSetup()
+			Expect(err).ToNot(HaveOccurred())
+
+			err = appstudiosharedv1.AddToScheme(scheme)
+			Expect(err).ToNot(HaveOccurred())
+
+			k8sClient := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(apiNamespace, argocdNamespace, kubesystemNamespace).
+				Build()
+
+			promotionRunReconciler = PromotionRunReconciler{Client: k8sClient, Scheme: scheme}
+
+			environment = appstudiosharedv1.Environment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "prod",
+					Namespace:   apiNamespace.Name,
+					Annotations: map[string]string{},
+				},
+				Spec: appstudiosharedv1.EnvironmentSpec{
+					DisplayName:        "prod",
+					DeploymentStrategy: appstudiosharedv1.DeploymentStrategy_Manual,
+				},
+			}
+
+			promotionRun = &appstudiosharedv1.PromotionRun{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "new-demo-app-manual-promotion",
+					Namespace:   apiNamespace.Name,
+					Annotations: map[string]string{},
+				},
+				Spec: appstudiosharedv1.PromotionRunSpec{
+					Snapshot:    "my-snapshot",
+					Application: "new-demo-app",
+					ManualPromotion: appstudiosharedv1.ManualPromotionConfiguration{
+						TargetEnvironment: environment.Name,
+					},
+				},
+			}
+
+			By("Create the previous and new Snapshots, and a binding which targets the previous Snapshot.")
+			for _, snapshotName := range []string{"previous-snapshot", promotionRun.Spec.Snapshot} {
+				snapshot := appstudiosharedv1.Snapshot{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      snapshotName,
+						Namespace: apiNamespace.Name,
+					},
+					Spec: appstudiosharedv1.SnapshotSpec{
+						Application: promotionRun.Spec.Application,
+					},
+				}
+				Expect(k8sClient.Create(ctx, &snapshot)).To(Succeed())
+			}
+
+			binding = &appstudiosharedv1.SnapshotEnvironmentBinding{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "appa-prod-binding",
+					Namespace: apiNamespace.Name,
+				},
+				Spec: appstudiosharedv1.SnapshotEnvironmentBindingSpec{
+					Application: promotionRun.Spec.Application,
+					Environment: environment.Name,
+					Snapshot:    "previous-snapshot",
+					Components:  []appstudiosharedv1.BindingComponent{{Name: "component-a"}},
+				},
+				Status: appstudiosharedv1.SnapshotEnvironmentBindingStatus{
+					GitOpsDeployments: []appstudiosharedv1.BindingStatusGitOpsDeployment{
+						{ComponentName: "component-a", GitOpsDeployment: "appa-prod-binding-component-a"},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, binding)).To(Succeed())
+
+			gitopsDeployment = &apibackend.GitOpsDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "appa-prod-binding-component-a",
+					Namespace: apiNamespace.Name,
+				},
+				Status: apibackend.GitOpsDeploymentStatus{
+					Sync:   apibackend.SyncStatus{Status: apibackend.SyncStatusCodeSynced},
+					Health: apibackend.HealthStatus{Status: apibackend.HeathStatusCodeProgressing},
+				},
+			}
+
+			request = newRequest(apiNamespace.Name, promotionRun.Name)
+		})
+
+		// startPromotion creates the Environment, GitOpsDeployment and PromotionRun, and reconciles the PromotionRun
+		// once, which updates the binding to target the new Snapshot.
+		startPromotion := func() {
+			Expect(promotionRunReconciler.Create(ctx, &environment)).To(Succeed())
+			Expect(promotionRunReconciler.Create(ctx, gitopsDeployment)).To(Succeed())
+			Expect(promotionRunReconciler.Create(ctx, promotionRun)).To(Succeed())
+
+			_, err := promotionRunReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(promotionRunReconciler.Get(ctx, client.ObjectKeyFromObject(binding), binding)).To(Succeed())
+			Expect(binding.Spec.Snapshot).To(Equal(promotionRun.Spec.Snapshot))
+		}
+
+		// simulateElapsedTime moves the start time of the promotion into the past
+		simulateElapsedTime := func(elapsed time.Duration) {
+			Expect(promotionRunReconciler.Get(ctx, client.ObjectKeyFromObject(promotionRun), promotionRun)).To(Succeed())
+			promotionRun.Status.PromotionStartTime = metav1.NewTime(time.Now().Add(-elapsed))
+			Expect(promotionRunReconciler.Status().Update(ctx, promotionRun)).To(Succeed())
+		}
+
+		getRollbackCondition := func() *appstudiosharedv1.PromotionRunCondition {
+			Expect(promotionRunReconciler.Get(ctx, client.ObjectKeyFromObject(promotionRun), promotionRun)).To(Succeed())
+			return getPromotionRunCondition(promotionRun, PromotionRunConditionRollback)
+		}
+
+		It("Should use the timeout of the Environment, rather than the default time limit.", func() {
+			environment.Annotations[PromotionAnnotationTimeout] = "30m"
+			startPromotion()
+
+			By("The promotion should not time out after the default time limit.")
+			simulateElapsedTime(time.Duration(PromotionRunTimeOutLimit+5) * time.Minute)
+
+			_, err := promotionRunReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(promotionRunReconciler.Get(ctx, client.ObjectKeyFromObject(promotionRun), promotionRun)).To(Succeed())
+			Expect(promotionRun.Status.State).To(Equal(appstudiosharedv1.PromotionRunState_Waiting))
+
+			By("The promotion should time out after the timeout of the Environment.")
+			simulateElapsedTime(35 * time.Minute)
+
+			_, err = promotionRunReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			checkStatusCondition(ctx, promotionRunReconciler.Client, promotionRun, "Promotion Failed. Could not be completed in 30 Minutes.")
+			Expect(promotionRun.Status.State).To(Equal(appstudiosharedv1.PromotionRunState_Complete))
+			Expect(promotionRun.Status.CompletionResult).To(Equal(appstudiosharedv1.PromotionRunCompleteResult_Failure))
+
+			By("Rollback is not enabled, so the binding should still target the new Snapshot.")
+			Expect(promotionRunReconciler.Get(ctx, client.ObjectKeyFromObject(binding), binding)).To(Succeed())
+			Expect(binding.Spec.Snapshot).To(Equal(promotionRun.Spec.Snapshot))
+			Expect(getRollbackCondition()).To(BeNil())
+		})
+
+		It("Should use the timeout of the Environment in preference to the timeout of the PromotionRun.", func() {
+			environment.Annotations[PromotionAnnotationTimeout] = "30m"
+			promotionRun.Annotations[PromotionAnnotationTimeout] = "90s"
+			startPromotion()
+
+			simulateElapsedTime(2 * time.Minute)
+
+			_, err := promotionRunReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(promotionRunReconciler.Get(ctx, client.ObjectKeyFromObject(promotionRun), promotionRun)).To(Succeed())
+			Expect(promotionRun.Status.State).To(Equal(appstudiosharedv1.PromotionRunState_Waiting))
+
+			simulateElapsedTime(35 * time.Minute)
+
+			_, err = promotionRunReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			checkStatusCondition(ctx, promotionRunReconciler.Client, promotionRun, "Promotion Failed. Could not be completed in 30 Minutes.")
+			Expect(promotionRun.Status.CompletionResult).To(Equal(appstudiosharedv1.PromotionRunCompleteResult_Failure))
+		})
+
+		It("Should use the timeout of the PromotionRun, if the Environment does not set one.", func() {
+			promotionRun.Annotations[PromotionAnnotationTimeout] = "90s"
+			startPromotion()
+
+			simulateElapsedTime(2 * time.Minute)
+
+			_, err := promotionRunReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			checkStatusCondition(ctx, promotionRunReconciler.Client, promotionRun, "Promotion Failed. Could not be completed in 1m30s.")
+			Expect(promotionRun.Status.CompletionResult).To(Equal(appstudiosharedv1.PromotionRunCompleteResult_Failure))
+		})
+
+		It("Should report an error if the timeout is invalid.", func() {
+			environment.Annotations[PromotionAnnotationTimeout] = "not-a-duration"
+			startPromotion()
+
+			checkStatusCondition(ctx, promotionRunReconciler.Client, promotionRun,
+				"invalid value for annotation '"+PromotionAnnotationTimeout+"' of Environment 'prod': not-a-duration")
+			Expect(promotionRun.Status.State).ToNot(Equal(appstudiosharedv1.PromotionRunState_Complete))
+		})
+
+		It("Should record the previous Snapshot, and roll back the binding to it if the promotion times out.", func() {
+			environment.Annotations[PromotionAnnotationRollbackOnFailure] = "true"
+			startPromotion()
+
+			Expect(getRollbackCondition()).To(BeNil())
+			condition := getPromotionRunCondition(promotionRun, PromotionRunConditionPreviousSnapshots)
+			Expect(condition).ToNot(BeNil())
+			Expect(condition.Status).To(Equal(appstudiosharedv1.PromotionRunConditionStatusTrue))
+			Expect(condition.Reason).To(Equal(PromotionRunReasonPreviousSnapshotRecorded))
+			Expect(condition.Message).To(Equal("Previous Snapshots: prod: previous-snapshot"))
+			Expect(getPreviousSnapshots(promotionRun)).To(Equal(map[string]string{"prod": "previous-snapshot"}))
+			Expect(promotionRun.Annotations).To(BeEmpty())
+
+			By("Reconciling again should not overwrite the previous Snapshot.")
+			_, err := promotionRunReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(promotionRunReconciler.Get(ctx, client.ObjectKeyFromObject(promotionRun), promotionRun)).To(Succeed())
+			Expect(getPreviousSnapshots(promotionRun)).To(Equal(map[string]string{"prod": "previous-snapshot"}))
+
+			simulateElapsedTime(time.Duration(PromotionRunTimeOutLimit+2) * time.Minute)
+
+			_, err = promotionRunReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			checkStatusCondition(ctx, promotionRunReconciler.Client, promotionRun, fmt.Sprintf("Promotion Failed. Could not be completed in %d Minutes.", PromotionRunTimeOutLimit))
+			Expect(promotionRun.Status.CompletionResult).To(Equal(appstudiosharedv1.PromotionRunCompleteResult_Failure))
+
+			Expect(promotionRunReconciler.Get(ctx, client.ObjectKeyFromObject(binding), binding)).To(Succeed())
+			Expect(binding.Spec.Snapshot).To(Equal("previous-snapshot"))
+
+			condition = getRollbackCondition()
+			Expect(condition.Status).To(Equal(appstudiosharedv1.PromotionRunConditionStatusTrue))
+			Expect(condition.Reason).To(Equal(PromotionRunReasonRolledBack))
+			Expect(condition.Message).To(Equal("Rolled back to the previous Snapshots: prod: previous-snapshot"))
+		})
+
+		It("Should roll back the binding if a GitOpsDeployment is Degraded, and rollback is enabled.", func() {
+			promotionRun.Annotations[PromotionAnnotationRollbackOnFailure] = "true"
+			startPromotion()
+
+			gitopsDeployment.Status.Health.Status = apibackend.HeathStatusCodeDegraded
+			Expect(promotionRunReconciler.Update(ctx, gitopsDeployment)).To(Succeed())
+
+			_, err := promotionRunReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			checkStatusCondition(ctx, promotionRunReconciler.Client, promotionRun,
+				"Promotion Failed. The following GitOpsDeployments are Degraded: "+gitopsDeployment.Name)
+			Expect(promotionRun.Status.State).To(Equal(appstudiosharedv1.PromotionRunState_Complete))
+			Expect(promotionRun.Status.CompletionResult).To(Equal(appstudiosharedv1.PromotionRunCompleteResult_Failure))
+			Expect(promotionRun.Status.EnvironmentStatus[0].Status).To(Equal(appstudiosharedv1.PromotionRunEnvironmentStatus_Failed))
+
+			Expect(promotionRunReconciler.Get(ctx, client.ObjectKeyFromObject(binding), binding)).To(Succeed())
+			Expect(binding.Spec.Snapshot).To(Equal("previous-snapshot"))
+			Expect(getRollbackCondition().Reason).To(Equal(PromotionRunReasonRolledBack))
+		})
+
+		It("Should keep waiting if a GitOpsDeployment is Degraded, and rollback is not enabled.", func() {
+			gitopsDeployment.Status.Health.Status = apibackend.HeathStatusCodeDegraded
+			startPromotion()
+
+			Expect(promotionRunReconciler.Get(ctx, client.ObjectKeyFromObject(promotionRun), promotionRun)).To(Succeed())
+			Expect(promotionRun.Status.State).To(Equal(appstudiosharedv1.PromotionRunState_Waiting))
+			Expect(getPromotionRunCondition(promotionRun, PromotionRunConditionPreviousSnapshots)).To(BeNil())
+		})
+
+		It("Should not roll back the binding if the Environment disables rollback, even if the PromotionRun enables it.", func() {
+			environment.Annotations[PromotionAnnotationRollbackOnFailure] = "false"
+			promotionRun.Annotations[PromotionAnnotationRollbackOnFailure] = "true"
+			gitopsDeployment.Status.Health.Status = apibackend.HeathStatusCodeDegraded
+			startPromotion()
+
+			Expect(promotionRunReconciler.Get(ctx, client.ObjectKeyFromObject(promotionRun), promotionRun)).To(Succeed())
+			Expect(promotionRun.Status.State).To(Equal(appstudiosharedv1.PromotionRunState_Waiting))
+			Expect(getPromotionRunCondition(promotionRun, PromotionRunConditionPreviousSnapshots)).To(BeNil())
+		})
+
+		It("Should keep the PromotionRun incomplete, and retry the rollback, if the rollback fails.", func() {
+			environment.Annotations[PromotionAnnotationRollbackOnFailure] = "true"
+
+			failingClient := &failingBindingUpdateClient{Client: promotionRunReconciler.Client}
+			promotionRunReconciler.Client = failingClient
+			startPromotion()
+
+			By("A Degraded GitOpsDeployment fails the promotion, but the binding cannot be rolled back.")
+			gitopsDeployment.Status.Health.Status = apibackend.HeathStatusCodeDegraded
+			Expect(promotionRunReconciler.Update(ctx, gitopsDeployment)).To(Succeed())
+			failingClient.failBindingUpdates = true
+
+			result, err := promotionRunReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+
+			condition := getRollbackCondition()
+			Expect(condition).ToNot(BeNil())
+			Expect(condition.Reason).To(Equal(PromotionRunReasonRollbackFailed))
+			Expect(promotionRun.Status.State).ToNot(Equal(appstudiosharedv1.PromotionRunState_Complete))
+			Expect(promotionRun.Status.EnvironmentStatus[0].Status).To(Equal(appstudiosharedv1.PromotionRunEnvironmentStatus_Failed))
+
+			By("Once the binding can be updated, the rollback is retried, and the promotion is complete.")
+			failingClient.failBindingUpdates = false
+
+			_, err = promotionRunReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(promotionRunReconciler.Get(ctx, client.ObjectKeyFromObject(binding), binding)).To(Succeed())
+			Expect(binding.Spec.Snapshot).To(Equal("previous-snapshot"))
+
+			Expect(getRollbackCondition().Reason).To(Equal(PromotionRunReasonRolledBack))
+			Expect(promotionRun.Status.State).To(Equal(appstudiosharedv1.PromotionRunState_Complete))
+			Expect(promotionRun.Status.CompletionResult).To(Equal(appstudiosharedv1.PromotionRunCompleteResult_Failure))
+		})
+	})
 })
 
+// failingBindingUpdateClient fails every update of a SnapshotEnvironmentBinding, while failBindingUpdates is true.
+type failingBindingUpdateClient struct {
+	client.Client
+	failBindingUpdates bool
+}
+
+func (c *failingBindingUpdateClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	if _, isBinding := obj.(*appstudiosharedv1.SnapshotEnvironmentBinding); isBinding && c.failBindingUpdates {
+		return fmt.Errorf("simulated failure to update binding")
+	}
+	return c.Client.Update(ctx, obj, opts...)
+}
+
 func checkStatusCondition(ctx context.Context, rClient client.Client, promotionRun *appstudiosharedv1.PromotionRun, message string) {
 	err := rClient.Get(ctx, client.ObjectKeyFromObject(promotionRun), promotionRun)
 	Expect(err).ToNot(HaveOccurred())
@@ -0,0 +1,751 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package appstudioredhatcom
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	routev1 "github.com/openshift/api/route/v1"
+	appstudioshared "github.com/redhat-appstudio/application-api/api/v1alpha1"
+	sharedutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util"
+	logutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util/log"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// This file is responsible for promotion gates: checks which must pass, after all the GitOpsDeployments of an
+// Environment are Synced/Healthy, before the promotion to that Environment is successful.
+//
+// The gates are configured by annotations on the target Environment. An annotation on the PromotionRun can only make
+// the gates of the Environment stricter (the check webhook can only be configured by the Environment). The gates are:
+// - Healthy soak: the GitOpsDeployments must remain Synced/Healthy for a minimum duration.
+// - Pod restarts: the containers of the Pods in the target Namespace, which were created since the step of the
+//   promotion began, must not restart more than a maximum number of times.
+// - Check: a Job (generated from the job template of a CronJob) must succeed, and/or a webhook must return a 2xx response.
+//
+// The result of each gate is reported by a condition of the PromotionRun. For an automated promotion, the gates are
+// evaluated for all the Environments of a step at once.
+
+const (
+	// PromotionGateAnnotationMinimumHealthyDuration is the minimum duration (for example, '5m') that the GitOpsDeployments
+	// must be Synced/Healthy for.
+	PromotionGateAnnotationMinimumHealthyDuration = "promotion.appstudio.redhat.com/minimum-healthy-duration"
+
+	// PromotionGateAnnotationMaximumPodRestarts is the maximum number of container restarts, of the Pods in the target
+	// Namespace which were created since the current step of the promotion began.
+	PromotionGateAnnotationMaximumPodRestarts = "promotion.appstudio.redhat.com/maximum-pod-restarts"
+
+	// PromotionGateAnnotationCheckCronJob is the name of a CronJob, in the Namespace of the PromotionRun: a Job is created
+	// from its job template, and must succeed.
+	PromotionGateAnnotationCheckCronJob = "promotion.appstudio.redhat.com/check-cronjob"
+
+	// PromotionGateAnnotationCheckWebhook is an https URL which is sent a POST request (see promotionGateWebhookRequest):
+	// it must return a 2xx response. Only the annotation of the Environment is used.
+	PromotionGateAnnotationCheckWebhook = "promotion.appstudio.redhat.com/check-webhook"
+)
+
+const (
+	PromotionRunConditionHealthySoakGate appstudioshared.PromotionRunConditionType = "HealthySoakGate"
+	PromotionRunConditionPodRestartGate  appstudioshared.PromotionRunConditionType = "PodRestartGate"
+	PromotionRunConditionCheckGate       appstudioshared.PromotionRunConditionType = "CheckGate"
+
+	PromotionRunReasonGatePassed  appstudioshared.PromotionRunReasonType = "GatePassed"
+	PromotionRunReasonGatePending appstudioshared.PromotionRunReasonType = "GatePending"
+	PromotionRunReasonGateFailed  appstudioshared.PromotionRunReasonType = "GateFailed"
+)
+
+// promotionGateCheckJobLabel is the label of a Job that was created for the check gate: the value is the name of the PromotionRun.
+const promotionGateCheckJobLabel = "promotion.appstudio.redhat.com/promotionrun"
+
+type promotionGateResult string
+
+const (
+	promotionGateResult_Passed  promotionGateResult = "Passed"
+	promotionGateResult_Pending promotionGateResult = "Pending"
+	promotionGateResult_Failed  promotionGateResult = "Failed"
+)
+
+// promotionGateWebhookRequest is the body of the POST request that is sent to the check webhook.
+type promotionGateWebhookRequest struct {
+	PromotionRun string `json:"promotionRun"`
+	Namespace    string `json:"namespace"`
+	Application  string `json:"application"`
+	Snapshot     string `json:"snapshot"`
+	Environment  string `json:"environment"`
+}
+
+// promotionGateHTTPClient is used to call check webhooks, which must not be cluster-internal (see external_http.go)
+var promotionGateHTTPClient = sharedutil.NewExternalHTTPClient()
+
+// promotionGateEnvironment is an Environment that is being promoted to, with the gates that are configured for it
+type promotionGateEnvironment struct {
+	environment appstudioshared.Environment
+
+	minimumHealthyDuration time.Duration
+	maximumPodRestarts     *int
+	checkCronJob           string
+	checkWebhook           string
+}
+
+// evaluatePromotionGates evaluates the promotion gates of the given Environments, and reports the result of each gate
+// in the conditions of the PromotionRun.
+// - 'healthy' should be true if all the GitOpsDeployments of the Environments are Synced/Healthy.
+// - Returns Passed if there are no gates, or if all the gates passed.
+// - Returns the message of the first gate that has not passed, if any.
+func evaluatePromotionGates(ctx context.Context, promotionRun *appstudioshared.PromotionRun, environmentNames []string, healthy bool,
+	k8sClient client.Client, log logr.Logger) (promotionGateResult, string, error) {
+
+	gateEnvironments, err := getPromotionGateEnvironments(ctx, promotionRun, environmentNames, k8sClient)
+	if err != nil {
+		return promotionGateResult_Pending, "", err
+	}
+
+	environmentsDescription := "Environment(s) " + strings.Join(environmentNames, ", ")
+
+	// 1) Healthy soak gate: this gate also tracks when the GitOpsDeployments became Synced/Healthy
+	minimumHealthyDuration := time.Duration(0)
+	for _, gateEnv := range gateEnvironments {
+		if gateEnv.minimumHealthyDuration > minimumHealthyDuration {
+			minimumHealthyDuration = gateEnv.minimumHealthyDuration
+		}
+	}
+
+	soakResult := promotionGateResult_Passed
+	soakMessage := ""
+
+	if minimumHealthyDuration > 0 {
+
+		soakResult = promotionGateResult_Pending
+		soakStatus := appstudioshared.PromotionRunConditionStatusFalse
+		soakReason := PromotionRunReasonGatePending
+
+		if !healthy {
+			soakMessage = "Waiting for the GitOpsDeployments of " + environmentsDescription + " to be Synced/Healthy."
+
+		} else {
+			// Note: the message must not change while soaking, so that the LastTransitionTime of the condition is
+			// the time that the GitOpsDeployments became Synced/Healthy.
+			soakMessage = fmt.Sprintf("Waiting for the GitOpsDeployments of %s to remain Synced/Healthy for %s.",
+				environmentsDescription, minimumHealthyDuration)
+			passedMessage := fmt.Sprintf("The GitOpsDeployments of %s were Synced/Healthy for %s.",
+				environmentsDescription, minimumHealthyDuration)
+
+			existingCondition := getPromotionRunCondition(promotionRun, PromotionRunConditionHealthySoakGate)
+
+			if existingCondition != nil && existingCondition.Message == passedMessage {
+				soakResult, soakMessage = promotionGateResult_Passed, passedMessage
+
+			} else if existingCondition != nil && existingCondition.Message == soakMessage && existingCondition.LastTransitionTime != nil &&
+				metav1.Now().Sub(existingCondition.LastTransitionTime.Time) >= minimumHealthyDuration {
+				soakResult, soakMessage = promotionGateResult_Passed, passedMessage
+			}
+		}
+
+		if soakResult == promotionGateResult_Passed {
+			soakStatus = appstudioshared.PromotionRunConditionStatusTrue
+			soakReason = PromotionRunReasonGatePassed
+		}
+
+		if err := updateStatusConditions(ctx, k8sClient, soakMessage, promotionRun, PromotionRunConditionHealthySoakGate, soakStatus, soakReason); err != nil {
+			return promotionGateResult_Pending, "", fmt.Errorf("unable to update PromotionRun status conditions %v", err)
+		}
+	}
+
+	if !healthy {
+		if !isPromotionGateConfigured(gateEnvironments) {
+			return promotionGateResult_Passed, "", nil
+		}
+		// The GitOpsDeployments must be Synced/Healthy before the other gates are evaluated.
+		return promotionGateResult_Pending, "Waiting for the GitOpsDeployments of " + environmentsDescription + " to be Synced/Healthy.", nil
+	}
+
+	// 2) Pod restart gate: this is evaluated while soaking, so that a restart spike fails the promotion as early as possible.
+	restartResult, restartMessage, err := evaluatePodRestartGate(ctx, promotionRun, gateEnvironments, k8sClient)
+	if err != nil {
+		return promotionGateResult_Pending, "", err
+	}
+	if restartResult != "" {
+		status, reason := promotionGateResultToCondition(restartResult)
+		if err := updateStatusConditions(ctx, k8sClient, restartMessage, promotionRun, PromotionRunConditionPodRestartGate, status, reason); err != nil {
+			return promotionGateResult_Pending, "", fmt.Errorf("unable to update PromotionRun status conditions %v", err)
+		}
+		if restartResult == promotionGateResult_Failed {
+			return restartResult, restartMessage, nil
+		}
+	}
+
+	if soakResult != promotionGateResult_Passed {
+		return soakResult, soakMessage, nil
+	}
+
+	// 3) Check gate: the check is only run once the GitOpsDeployments have soaked, so that it tests the final state.
+	checkResult, checkMessage, err := evaluateCheckGate(ctx, promotionRun, gateEnvironments, environmentsDescription, k8sClient, log)
+	if err != nil {
+		return promotionGateResult_Pending, "", err
+	}
+	if checkResult != "" {
+		status, reason := promotionGateResultToCondition(checkResult)
+		if err := updateStatusConditions(ctx, k8sClient, checkMessage, promotionRun, PromotionRunConditionCheckGate, status, reason); err != nil {
+			return promotionGateResult_Pending, "", fmt.Errorf("unable to update PromotionRun status conditions %v", err)
+		}
+		if checkResult != promotionGateResult_Passed {
+			return checkResult, checkMessage, nil
+		}
+	}
+
+	return promotionGateResult_Passed, "", nil
+}
+
+// getPromotionGateEnvironments retrieves the given Environments, along with the gates that are configured for them.
+func getPromotionGateEnvironments(ctx context.Context, promotionRun *appstudioshared.PromotionRun, environmentNames []string,
+	k8sClient client.Client) ([]promotionGateEnvironment, error) {
+
+	res := []promotionGateEnvironment{}
+
+	for _, environmentName := range environmentNames {
+
+		environment, err := getPromotionEnvironment(ctx, promotionRun, environmentName, k8sClient)
+		if err != nil {
+			return nil, err
+		}
+
+		gateEnv := promotionGateEnvironment{environment: environment}
+
+		// The gates are configured by the Environment: an annotation on the PromotionRun can only make them stricter.
+		annotationSources := []struct {
+			annotations map[string]string
+			description string
+		}{
+			{environment.Annotations, fmt.Sprintf("Environment '%s'", environmentName)},
+			{promotionRun.Annotations, fmt.Sprintf("PromotionRun '%s'", promotionRun.Name)},
+		}
+
+		// 1) The longest minimum healthy duration applies
+		for _, source := range annotationSources {
+
+			value := strings.TrimSpace(source.annotations[PromotionGateAnnotationMinimumHealthyDuration])
+			if value == "" {
+				continue
+			}
+			minimumHealthyDuration, err := time.ParseDuration(value)
+			if err != nil || minimumHealthyDuration < 0 {
+				return nil, fmt.Errorf("invalid value for annotation '%s' of %s: %s", PromotionGateAnnotationMinimumHealthyDuration, source.description, value)
+			}
+			if minimumHealthyDuration > gateEnv.minimumHealthyDuration {
+				gateEnv.minimumHealthyDuration = minimumHealthyDuration
+			}
+		}
+
+		// 2) The lowest maximum number of Pod restarts applies
+		for _, source := range annotationSources {
+
+			value := strings.TrimSpace(source.annotations[PromotionGateAnnotationMaximumPodRestarts])
+			if value == "" {
+				continue
+			}
+			maximumPodRestarts, err := strconv.Atoi(value)
+			if err != nil || maximumPodRestarts < 0 {
+				return nil, fmt.Errorf("invalid value for annotation '%s' of %s: %s", PromotionGateAnnotationMaximumPodRestarts, source.description, value)
+			}
+			if gateEnv.maximumPodRestarts == nil || maximumPodRestarts < *gateEnv.maximumPodRestarts {
+				gateEnv.maximumPodRestarts = &maximumPodRestarts
+			}
+		}
+
+		// 3) The check CronJob of the Environment applies: the PromotionRun can only add a check CronJob to an
+		// Environment which has none.
+		gateEnv.checkCronJob = strings.TrimSpace(environment.Annotations[PromotionGateAnnotationCheckCronJob])
+		if gateEnv.checkCronJob == "" {
+			gateEnv.checkCronJob = strings.TrimSpace(promotionRun.Annotations[PromotionGateAnnotationCheckCronJob])
+		}
+
+		// 4) The check webhook is only configured by the Environment, as it is called by the controller
+		gateEnv.checkWebhook = strings.TrimSpace(environment.Annotations[PromotionGateAnnotationCheckWebhook])
+
+		res = append(res, gateEnv)
+	}
+
+	return res, nil
+}
+
+// evaluatePodRestartGate counts the container restarts of the Pods in the target Namespace of each Environment, which
+// were created since the current step of the promotion began (the same count as the RESTARTS column of 'kubectl get pods').
+// - Returns an empty result if the gate is not configured for any of the Environments.
+func evaluatePodRestartGate(ctx context.Context, promotionRun *appstudioshared.PromotionRun, gateEnvironments []promotionGateEnvironment,
+	k8sClient client.Client) (promotionGateResult, string, error) {
+
+	result := promotionGateResult("")
+	messages := []string{}
+
+	for _, gateEnv := range gateEnvironments {
+
+		if gateEnv.maximumPodRestarts == nil {
+			continue
+		}
+
+		targetNamespace, targetClient, err := getEnvironmentTargetNamespaceClient(ctx, gateEnv.environment, k8sClient)
+		if err != nil {
+			return promotionGateResult_Pending, "", err
+		}
+
+		podList := corev1.PodList{}
+		if err := targetClient.List(ctx, &podList, &client.ListOptions{Namespace: targetNamespace}); err != nil {
+			return promotionGateResult_Pending, "", fmt.Errorf("unable to list Pods in Namespace '%s' of Environment '%s': %v", targetNamespace, gateEnv.environment.Name, err)
+		}
+
+		stepStartTime := getPromotionStepStartTime(promotionRun)
+
+		restartCount := int32(0)
+		for _, pod := range podList.Items {
+			if pod.CreationTimestamp.Before(&stepStartTime) {
+				continue
+			}
+			for _, containerStatus := range pod.Status.ContainerStatuses {
+				restartCount += containerStatus.RestartCount
+			}
+		}
+
+		messages = append(messages, fmt.Sprintf("Environment %s: %d Pod restart(s) in Namespace %s (maximum %d)",
+			gateEnv.environment.Name, restartCount, targetNamespace, *gateEnv.maximumPodRestarts))
+
+		if int(restartCount) > *gateEnv.maximumPodRestarts {
+			result = promotionGateResult_Failed
+		} else if result == "" {
+			result = promotionGateResult_Passed
+		}
+	}
+
+	return result, strings.Join(messages, "; "), nil
+}
+
+// evaluateCheckGate runs the check Job and/or calls the check webhook of each Environment.
+// - Returns an empty result if the gate is not configured for any of the Environments.
+func evaluateCheckGate(ctx context.Context, promotionRun *appstudioshared.PromotionRun, gateEnvironments []promotionGateEnvironment,
+	environmentsDescription string, k8sClient client.Client, log logr.Logger) (promotionGateResult, string, error) {
+
+	configured := false
+	for _, gateEnv := range gateEnvironments {
+		if gateEnv.checkCronJob != "" || gateEnv.checkWebhook != "" {
+			configured = true
+		}
+	}
+	if !configured {
+		return "", "", nil
+	}
+
+	passedMessage := "The checks of " + environmentsDescription + " passed."
+
+	// Checks are only run once: if they have already passed, don't run them again.
+	if existingCondition := getPromotionRunCondition(promotionRun, PromotionRunConditionCheckGate); existingCondition != nil &&
+		existingCondition.Status == appstudioshared.PromotionRunConditionStatusTrue && existingCondition.Message == passedMessage {
+		return promotionGateResult_Passed, passedMessage, nil
+	}
+
+	pendingMessages := []string{}
+
+	for _, gateEnv := range gateEnvironments {
+
+		if gateEnv.checkCronJob != "" {
+			result, message, err := evaluateCheckJob(ctx, promotionRun, gateEnv, k8sClient, log)
+			if err != nil {
+				return promotionGateResult_Pending, "", err
+			}
+			if result == promotionGateResult_Failed {
+				return result, message, nil
+			} else if result == promotionGateResult_Pending {
+				pendingMessages = append(pendingMessages, message)
+			}
+		}
+
+		if gateEnv.checkWebhook != "" {
+			result, message := evaluateCheckWebhook(ctx, promotionRun, gateEnv)
+			if result == promotionGateResult_Failed {
+				return result, message, nil
+			} else if result == promotionGateResult_Pending {
+				pendingMessages = append(pendingMessages, message)
+			}
+		}
+	}
+
+	if len(pendingMessages) > 0 {
+		return promotionGateResult_Pending, strings.Join(pendingMessages, "; "), nil
+	}
+
+	return promotionGateResult_Passed, passedMessage, nil
+}
+
+// evaluateCheckJob creates a Job from the job template of the check CronJob of the Environment, if it doesn't already
+// exist, and returns whether it succeeded.
+func evaluateCheckJob(ctx context.Context, promotionRun *appstudioshared.PromotionRun, gateEnv promotionGateEnvironment,
+	k8sClient client.Client, log logr.Logger) (promotionGateResult, string, error) {
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      generateCheckJobName(promotionRun, gateEnv.environment.Name),
+			Namespace: promotionRun.Namespace,
+		},
+	}
+
+	if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(job), job); err != nil {
+		if !apierr.IsNotFound(err) {
+			return promotionGateResult_Pending, "", fmt.Errorf("unable to retrieve check Job '%s': %v", job.Name, err)
+		}
+
+		cronJob := &batchv1.CronJob{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      gateEnv.checkCronJob,
+				Namespace: promotionRun.Namespace,
+			},
+		}
+		if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(cronJob), cronJob); err != nil {
+			if apierr.IsNotFound(err) {
+				return promotionGateResult_Failed, fmt.Sprintf("The check CronJob '%s' of Environment %s does not exist.", cronJob.Name, gateEnv.environment.Name), nil
+			}
+			return promotionGateResult_Pending, "", fmt.Errorf("unable to retrieve check CronJob '%s': %v", cronJob.Name, err)
+		}
+
+		job.Labels = map[string]string{}
+		for key, value := range cronJob.Spec.JobTemplate.Labels {
+			job.Labels[key] = value
+		}
+		job.Labels[promotionGateCheckJobLabel] = promotionRun.Name
+		job.Annotations = cronJob.Spec.JobTemplate.Annotations
+		job.Spec = *cronJob.Spec.JobTemplate.Spec.DeepCopy()
+
+		// The Job is owned by the PromotionRun, so that it is deleted along with it.
+		job.OwnerReferences = []metav1.OwnerReference{{
+			APIVersion: appstudioshared.GroupVersion.String(),
+			Kind:       "PromotionRun",
+			Name:       promotionRun.Name,
+			UID:        promotionRun.UID,
+			Controller: &[]bool{true}[0],
+		}}
+
+		if err := k8sClient.Create(ctx, job); err != nil {
+			return promotionGateResult_Pending, "", fmt.Errorf("unable to create check Job '%s': %v", job.Name, err)
+		}
+
+		logutil.LogAPIResourceChangeEvent(job.Namespace, job.Name, job, logutil.ResourceCreated, log)
+	}
+
+	for _, jobCondition := range job.Status.Conditions {
+		if jobCondition.Type == batchv1.JobFailed && jobCondition.Status == corev1.ConditionTrue {
+			return promotionGateResult_Failed, fmt.Sprintf("The check Job '%s' of Environment %s failed: %s", job.Name, gateEnv.environment.Name, jobCondition.Message), nil
+		}
+	}
+
+	if job.Status.Succeeded > 0 {
+		return promotionGateResult_Passed, "", nil
+	}
+
+	return promotionGateResult_Pending, fmt.Sprintf("Waiting for the check Job '%s' of Environment %s to complete.", job.Name, gateEnv.environment.Name), nil
+}
+
+// evaluateCheckWebhook sends a POST request to the check webhook of the Environment, which must be an https URL: a 2xx
+// response passes the gate, and any other response fails it. If the webhook could not be reached, the gate is pending, so that it is retried.
+func evaluateCheckWebhook(ctx context.Context, promotionRun *appstudioshared.PromotionRun, gateEnv promotionGateEnvironment) (promotionGateResult, string) {
+
+	body, err := json.Marshal(promotionGateWebhookRequest{
+		PromotionRun: promotionRun.Name,
+		Namespace:    promotionRun.Namespace,
+		Application:  promotionRun.Spec.Application,
+		Snapshot:     promotionRun.Spec.Snapshot,
+		Environment:  gateEnv.environment.Name,
+	})
+	if err != nil {
+		return promotionGateResult_Failed, fmt.Sprintf("Unable to generate the request for the check webhook of Environment %s: %v", gateEnv.environment.Name, err)
+	}
+
+	webhookURL, err := url.Parse(gateEnv.checkWebhook)
+	if err != nil || webhookURL.Scheme != "https" || webhookURL.Host == "" {
+		return promotionGateResult_Failed, fmt.Sprintf("Invalid check webhook URL for Environment %s: the URL must be an https URL.", gateEnv.environment.Name)
+	}
+	if err := sharedutil.ValidateExternalURL(webhookURL); err != nil {
+		return promotionGateResult_Failed, fmt.Sprintf("Invalid check webhook URL for Environment %s: %v", gateEnv.environment.Name, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return promotionGateResult_Failed, fmt.Sprintf("Invalid check webhook URL for Environment %s: %v", gateEnv.environment.Name, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := promotionGateHTTPClient.Do(req)
+	if err != nil {
+		return promotionGateResult_Pending, fmt.Sprintf("Unable to call the check webhook of Environment %s, retrying: %v", gateEnv.environment.Name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return promotionGateResult_Failed, fmt.Sprintf("The check webhook of Environment %s failed, with status code %d.", gateEnv.environment.Name, resp.StatusCode)
+	}
+
+	return promotionGateResult_Passed, ""
+}
+
+// getEnvironmentTargetNamespaceClient returns the Namespace that the Environment deploys to, and a client for the
+// cluster of that Namespace. This mirrors the destination of the GitOpsDeployments generated by the binding controller.
+func getEnvironmentTargetNamespaceClient(ctx context.Context, environment appstudioshared.Environment, k8sClient client.Client) (string, client.Client, error) {
+
+	var targetNamespace, apiURL, secretName string
+	var allowInsecureSkipTLSVerify bool
+
+	if environment.Spec.Configuration.Target.DeploymentTargetClaim.ClaimName != "" {
+
+		dtc := appstudioshared.DeploymentTargetClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      environment.Spec.Configuration.Target.DeploymentTargetClaim.ClaimName,
+				Namespace: environment.Namespace,
+			},
+		}
+		if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(&dtc), &dtc); err != nil {
+			return "", nil, fmt.Errorf("unable to retrieve DeploymentTargetClaim '%s' of Environment '%s': %v", dtc.Name, environment.Name, err)
+		}
+
+		dt, err := getDTBoundByDTC(ctx, k8sClient, dtc)
+		if dt == nil || err != nil {
+			return "", nil, fmt.Errorf("unable to locate DeploymentTarget of DeploymentTargetClaim '%s': %v", dtc.Name, err)
+		}
+
+		targetNamespace = dt.Spec.KubernetesClusterCredentials.DefaultNamespace
+		apiURL = dt.Spec.KubernetesClusterCredentials.APIURL
+		secretName = dt.Spec.KubernetesClusterCredentials.ClusterCredentialsSecret
+		allowInsecureSkipTLSVerify = dt.Spec.KubernetesClusterCredentials.AllowInsecureSkipTLSVerify
+
+	} else if environment.Spec.UnstableConfigurationFields != nil {
+
+		targetNamespace = environment.Spec.UnstableConfigurationFields.TargetNamespace
+		apiURL = environment.Spec.UnstableConfigurationFields.APIURL
+		secretName = environment.Spec.UnstableConfigurationFields.ClusterCredentialsSecret
+		allowInsecureSkipTLSVerify = environment.Spec.UnstableConfigurationFields.AllowInsecureSkipTLSVerify
+
+	} else {
+		// An Environment with no credentials deploys to the Namespace of the Environment.
+		return environment.Namespace, k8sClient, nil
+	}
+
+	if targetNamespace == "" {
+		return "", nil, fmt.Errorf("unable to determine the target Namespace of Environment '%s'", environment.Name)
+	}
+
+	secret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretName,
+			Namespace: environment.Namespace,
+		},
+	}
+	if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(&secret), &secret); err != nil {
+		return "", nil, fmt.Errorf("unable to retrieve cluster credentials Secret '%s' of Environment '%s': %v", secret.Name, environment.Name, err)
+	}
+
+	clientConfig := environmentClientConfig{
+		apiURL:                     apiURL,
+		secretName:                 secretName,
+		secretResourceVersion:      secret.ResourceVersion,
+		allowInsecureSkipTLSVerify: allowInsecureSkipTLSVerify,
+	}
+
+	if targetClient, exists := environmentClientCache.get(environment, clientConfig); exists {
+		return targetNamespace, targetClient, nil
+	}
+
+	kubeconfig := secret.Data[sharedutil.ManagedEnvironmentSecretKubeconfigKey]
+
+	config, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to parse the kubeconfig of Environment '%s': %v", environment.Name, err)
+	}
+
+	contextName, _, err := sharedutil.LocateContextThatMatchesAPIURL(config, apiURL)
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to locate the cluster of Environment '%s' in its kubeconfig: %v", environment.Name, err)
+	}
+
+	restConfig, err := sharedutil.RESTConfigFromKubeconfig(kubeconfig, contextName)
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to generate the cluster configuration of Environment '%s': %v", environment.Name, err)
+	}
+
+	if allowInsecureSkipTLSVerify {
+		restConfig.TLSClientConfig.Insecure = true
+		restConfig.TLSClientConfig.CAData = nil
+		restConfig.TLSClientConfig.CAFile = ""
+	}
+
+	// The client supports Routes, which are switched by the deployment strategy of the Environment (see snapshotenvironmentbinding_strategy.go)
+	targetScheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(targetScheme); err != nil {
+		return "", nil, err
+	}
+	if err := routev1.Install(targetScheme); err != nil {
+		return "", nil, err
+	}
+
+	targetClient, err := client.New(restConfig, client.Options{Scheme: targetScheme})
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to create a client for the cluster of Environment '%s': %v", environment.Name, err)
+	}
+
+	environmentClientCache.set(environment, clientConfig, targetClient)
+
+	return targetNamespace, targetClient, nil
+}
+
+// environmentClientCache caches the client of the cluster of each Environment, so that a client (and the discovery
+// of the API resources of the cluster) is not created every time the Environment is reconciled.
+var environmentClientCache = &environmentTargetClientCache{}
+
+// environmentClientConfig is the configuration that the client of an Environment was created from: the cached client
+// is only used while the configuration, including the version of the cluster credentials Secret, is unchanged.
+type environmentClientConfig struct {
+	apiURL                     string
+	secretName                 string
+	secretResourceVersion      string
+	allowInsecureSkipTLSVerify bool
+}
+
+type environmentTargetClientCache struct {
+	mutex   sync.Mutex
+	entries map[string]environmentTargetClientCacheEntry
+}
+
+type environmentTargetClientCacheEntry struct {
+	config environmentClientConfig
+	client client.Client
+}
+
+// environmentClientCacheKey identifies an Environment. The UID ensures that an Environment which is deleted and
+// recreated does not use the client of the previous Environment.
+func environmentClientCacheKey(environment appstudioshared.Environment) string {
+	return environment.Namespace + "/" + environment.Name + "/" + string(environment.UID)
+}
+
+func (c *environmentTargetClientCache) get(environment appstudioshared.Environment, config environmentClientConfig) (client.Client, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry, exists := c.entries[environmentClientCacheKey(environment)]
+	if !exists || entry.config != config {
+		return nil, false
+	}
+	return entry.client, true
+}
+
+func (c *environmentTargetClientCache) set(environment appstudioshared.Environment, config environmentClientConfig, k8sClient client.Client) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.entries == nil {
+		c.entries = map[string]environmentTargetClientCacheEntry{}
+	}
+
+	c.entries[environmentClientCacheKey(environment)] = environmentTargetClientCacheEntry{
+		config: config,
+		client: k8sClient,
+	}
+}
+
+// generateCheckJobName returns the name of the check Job of the PromotionRun, for the given Environment.
+func generateCheckJobName(promotionRun *appstudioshared.PromotionRun, environmentName string) string {
+	name := strings.ToLower(promotionRun.Name + "-" + environmentName + "-check")
+
+	// Job names are used as a label value of their Pods, and so are limited to 63 characters
+	if len(name) > 63 {
+		hash := sha256.Sum256([]byte(name))
+		name = "promotion-check-" + hex.EncodeToString(hash[:])[0:32]
+	}
+	return name
+}
+
+// getPromotionEnvironment retrieves the Environment that is being promoted to. If the Environment doesn't exist, an
+// Environment with only a name is returned, so that only the configuration of the PromotionRun applies.
+func getPromotionEnvironment(ctx context.Context, promotionRun *appstudioshared.PromotionRun, environmentName string,
+	k8sClient client.Client) (appstudioshared.Environment, error) {
+
+	environment := appstudioshared.Environment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      environmentName,
+			Namespace: promotionRun.Namespace,
+		},
+	}
+
+	if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(&environment), &environment); err != nil {
+		if !apierr.IsNotFound(err) {
+			return appstudioshared.Environment{}, fmt.Errorf("unable to retrieve Environment '%s': %v", environmentName, err)
+		}
+	}
+
+	return environment, nil
+}
+
+// getPromotionAnnotation returns the value of an annotation which configures the promotion to an Environment: an
+// annotation on the Environment takes precedence over the same annotation on the PromotionRun.
+func getPromotionAnnotation(promotionRun *appstudioshared.PromotionRun, environment appstudioshared.Environment, key string) string {
+	if value, exists := environment.Annotations[key]; exists {
+		return strings.TrimSpace(value)
+	}
+	return strings.TrimSpace(promotionRun.Annotations[key])
+}
+
+// isPromotionGateConfigured returns true if any gate is configured for the Environments.
+func isPromotionGateConfigured(gateEnvironments []promotionGateEnvironment) bool {
+	for _, gateEnv := range gateEnvironments {
+		if gateEnv.minimumHealthyDuration > 0 || gateEnv.maximumPodRestarts != nil || gateEnv.checkCronJob != "" || gateEnv.checkWebhook != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// getPromotionRunCondition returns the condition of the given type, or nil if it is not set.
+func getPromotionRunCondition(promotionRun *appstudioshared.PromotionRun, conditionType appstudioshared.PromotionRunConditionType) *appstudioshared.PromotionRunCondition {
+	for i := range promotionRun.Status.Conditions {
+		if promotionRun.Status.Conditions[i].Type == conditionType {
+			return &promotionRun.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+func promotionGateResultToCondition(result promotionGateResult) (appstudioshared.PromotionRunConditionStatus, appstudioshared.PromotionRunReasonType) {
+	switch result {
+	case promotionGateResult_Passed:
+		return appstudioshared.PromotionRunConditionStatusTrue, PromotionRunReasonGatePassed
+	case promotionGateResult_Failed:
+		return appstudioshared.PromotionRunConditionStatusFalse, PromotionRunReasonGateFailed
+	default:
+		return appstudioshared.PromotionRunConditionStatusFalse, PromotionRunReasonGatePending
+	}
+}
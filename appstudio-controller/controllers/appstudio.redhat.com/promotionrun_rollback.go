@@ -0,0 +1,242 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package appstudioredhatcom
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	appstudioshared "github.com/redhat-appstudio/application-api/api/v1alpha1"
+	logutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util/log"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// This file is responsible for the time limit of a promotion, and for rolling back a failed promotion.
+//
+// Both are configured by annotations on the target Environment. The same annotation on the PromotionRun is only used
+// if the Environment does not set it, so that the owner of the Environment always has the final say:
+// - Timeout: the time limit of the promotion to the Environment. Defaults to PromotionRunTimeOutLimit minutes.
+// - Rollback on failure: if the promotion times out, a GitOpsDeployment of the Environment becomes Degraded, or a
+//   promotion gate fails, the binding of the Environment is set back to the Snapshot it targeted before the promotion.
+//
+// When rollback is enabled, the previous Snapshot of each binding is recorded (by the PreviousSnapshots condition of the
+// PromotionRun) before the binding is updated to target the new Snapshot. A failed promotion is only marked as complete
+// once its rollback has succeeded: until then, the rollback is retried, and its result is reported by the Rollback condition.
+
+const (
+	// PromotionAnnotationTimeout is the time limit (for example, '30m') for the GitOpsDeployments of the Environment to
+	// be Synced/Healthy, and for the promotion gates to pass.
+	PromotionAnnotationTimeout = "promotion.appstudio.redhat.com/timeout"
+
+	// PromotionAnnotationRollbackOnFailure, when 'true', rolls back the binding of the Environment to the previous
+	// Snapshot if the promotion fails.
+	PromotionAnnotationRollbackOnFailure = "promotion.appstudio.redhat.com/rollback-on-failure"
+)
+
+const (
+	PromotionRunConditionRollback          appstudioshared.PromotionRunConditionType = "Rollback"
+	PromotionRunConditionPreviousSnapshots appstudioshared.PromotionRunConditionType = "PreviousSnapshots"
+
+	PromotionRunReasonPreviousSnapshotRecorded appstudioshared.PromotionRunReasonType = "PreviousSnapshotRecorded"
+	PromotionRunReasonRolledBack               appstudioshared.PromotionRunReasonType = "RolledBack"
+	PromotionRunReasonRollbackFailed           appstudioshared.PromotionRunReasonType = "RollbackFailed"
+)
+
+// previousSnapshotsMessagePrefix is the prefix of the message of the PreviousSnapshots condition, which is followed by
+// the previous Snapshot of each Environment (see formatPreviousSnapshots).
+const previousSnapshotsMessagePrefix = "Previous Snapshots: "
+
+// getPromotionTimeout returns the time limit of the promotion to the given Environments: the largest time limit
+// of any of the Environments.
+func getPromotionTimeout(ctx context.Context, promotionRun *appstudioshared.PromotionRun, environmentNames []string,
+	k8sClient client.Client) (time.Duration, error) {
+
+	var res time.Duration
+
+	for _, environmentName := range environmentNames {
+
+		environment, err := getPromotionEnvironment(ctx, promotionRun, environmentName, k8sClient)
+		if err != nil {
+			return 0, err
+		}
+
+		timeout := time.Duration(PromotionRunTimeOutLimit) * time.Minute
+
+		if value := getPromotionAnnotation(promotionRun, environment, PromotionAnnotationTimeout); value != "" {
+			timeout, err = time.ParseDuration(value)
+			if err != nil || timeout <= 0 {
+				return 0, fmt.Errorf("invalid value for annotation '%s' of Environment '%s': %s", PromotionAnnotationTimeout, environmentName, value)
+			}
+		}
+
+		if timeout > res {
+			res = timeout
+		}
+	}
+
+	return res, nil
+}
+
+// promotionTimeoutMessage returns the message that is reported when the promotion could not be completed in the given time.
+func promotionTimeoutMessage(timeout time.Duration) string {
+	if timeout%time.Minute == 0 {
+		return fmt.Sprintf("Promotion Failed. Could not be completed in %d Minutes.", int64(timeout/time.Minute))
+	}
+	return fmt.Sprintf("Promotion Failed. Could not be completed in %s.", timeout)
+}
+
+// isRollbackOnFailureEnabled returns true if the binding of the Environment should be rolled back, if the promotion fails.
+func isRollbackOnFailureEnabled(ctx context.Context, promotionRun *appstudioshared.PromotionRun, environmentName string,
+	k8sClient client.Client) (bool, error) {
+
+	environment, err := getPromotionEnvironment(ctx, promotionRun, environmentName, k8sClient)
+	if err != nil {
+		return false, err
+	}
+
+	return strings.EqualFold(getPromotionAnnotation(promotionRun, environment, PromotionAnnotationRollbackOnFailure), "true"), nil
+}
+
+// getPreviousSnapshots returns the Snapshots that the bindings targeted before the promotion, by Environment name.
+func getPreviousSnapshots(promotionRun *appstudioshared.PromotionRun) map[string]string {
+
+	res := map[string]string{}
+
+	condition := getPromotionRunCondition(promotionRun, PromotionRunConditionPreviousSnapshots)
+	if condition == nil || !strings.HasPrefix(condition.Message, previousSnapshotsMessagePrefix) {
+		return res
+	}
+
+	// Environment and Snapshot names are resource names, so they cannot contain the separators of the message.
+	for _, entry := range strings.Split(strings.TrimPrefix(condition.Message, previousSnapshotsMessagePrefix), ", ") {
+		if environmentName, snapshot, found := strings.Cut(entry, ": "); found {
+			res[environmentName] = snapshot
+		}
+	}
+
+	return res
+}
+
+// recordPreviousSnapshot records the Snapshot that the binding targets, before it is updated to target the Snapshot
+// of the PromotionRun. The previous Snapshot is only recorded if rollback is enabled for the Environment of the
+// binding, and only the first time, so that it is not overwritten by the Snapshot of the PromotionRun itself.
+func recordPreviousSnapshot(ctx context.Context, promotionRun *appstudioshared.PromotionRun, binding appstudioshared.SnapshotEnvironmentBinding,
+	k8sClient client.Client, log logr.Logger) error {
+
+	if binding.Spec.Snapshot == "" || binding.Spec.Snapshot == promotionRun.Spec.Snapshot {
+		return nil
+	}
+
+	rollbackEnabled, err := isRollbackOnFailureEnabled(ctx, promotionRun, binding.Spec.Environment, k8sClient)
+	if err != nil || !rollbackEnabled {
+		return err
+	}
+
+	previousSnapshots := getPreviousSnapshots(promotionRun)
+	if _, exists := previousSnapshots[binding.Spec.Environment]; exists {
+		return nil
+	}
+	previousSnapshots[binding.Spec.Environment] = binding.Spec.Snapshot
+
+	if err := updateStatusConditions(ctx, k8sClient, previousSnapshotsMessagePrefix+formatPreviousSnapshots(previousSnapshots), promotionRun,
+		PromotionRunConditionPreviousSnapshots, appstudioshared.PromotionRunConditionStatusTrue, PromotionRunReasonPreviousSnapshotRecorded); err != nil {
+		return fmt.Errorf("unable to record previous Snapshot of Binding '%s': %v", binding.Name, err)
+	}
+
+	log.Info("Recorded previous Snapshot of Binding: "+binding.Name, "environment", binding.Spec.Environment, "snapshot", binding.Spec.Snapshot)
+
+	return nil
+}
+
+// rollbackPromotion sets the bindings of the given Environments back to the Snapshots they targeted before the
+// promotion, for the Environments that have rollback enabled. The result is reported by the Rollback condition.
+// Returns false if the rollback failed: the failure is reported by the condition, rather than returned, and the
+// promotion should not be marked as complete until the rollback has been retried successfully.
+func rollbackPromotion(ctx context.Context, promotionRun *appstudioshared.PromotionRun, environmentNames []string,
+	k8sClient client.Client, log logr.Logger) (bool, error) {
+
+	previousSnapshots := getPreviousSnapshots(promotionRun)
+	rolledBack := map[string]string{}
+	errorMessages := []string{}
+
+	for _, environmentName := range environmentNames {
+
+		rollbackEnabled, err := isRollbackOnFailureEnabled(ctx, promotionRun, environmentName, k8sClient)
+		if err != nil {
+			errorMessages = append(errorMessages, err.Error())
+			continue
+		}
+
+		previousSnapshot := previousSnapshots[environmentName]
+		if !rollbackEnabled || previousSnapshot == "" {
+			continue
+		}
+
+		binding, err := locateTargetBinding(ctx, *promotionRun, environmentName, k8sClient)
+		if err != nil {
+			errorMessages = append(errorMessages, err.Error())
+			continue
+		} else if binding == nil {
+			errorMessages = append(errorMessages, "binding of Environment '"+environmentName+"' does not exist")
+			continue
+		}
+
+		if binding.Spec.Snapshot != previousSnapshot {
+			binding.Spec.Snapshot = previousSnapshot
+
+			if err := k8sClient.Update(ctx, binding); err != nil {
+				log.Error(err, "unable to roll back Binding: "+binding.Name)
+				errorMessages = append(errorMessages, fmt.Sprintf("unable to roll back Binding '%s': %v", binding.Name, err))
+				continue
+			}
+
+			logutil.LogAPIResourceChangeEvent(binding.Namespace, binding.Name, binding, logutil.ResourceModified, log)
+
+			log.Info("Rolled back Binding: "+binding.Name+" to the previous Snapshot: "+previousSnapshot, "environment", environmentName)
+		}
+
+		rolledBack[environmentName] = previousSnapshot
+	}
+
+	if len(errorMessages) > 0 {
+		return false, updateStatusConditions(ctx, k8sClient, "Unable to roll back to the previous Snapshots: "+strings.Join(errorMessages, "; "), promotionRun,
+			PromotionRunConditionRollback, appstudioshared.PromotionRunConditionStatusFalse, PromotionRunReasonRollbackFailed)
+	}
+
+	if len(rolledBack) == 0 {
+		return true, nil
+	}
+
+	return true, updateStatusConditions(ctx, k8sClient, "Rolled back to the previous Snapshots: "+formatPreviousSnapshots(rolledBack), promotionRun,
+		PromotionRunConditionRollback, appstudioshared.PromotionRunConditionStatusTrue, PromotionRunReasonRolledBack)
+}
+
+// formatPreviousSnapshots returns a message listing the Snapshot of each Environment, sorted by Environment name.
+func formatPreviousSnapshots(snapshots map[string]string) string {
+
+	res := []string{}
+	for environmentName, snapshot := range snapshots {
+		res = append(res, environmentName+": "+snapshot)
+	}
+	sort.Strings(res)
+
+	return strings.Join(res, ", ")
+}
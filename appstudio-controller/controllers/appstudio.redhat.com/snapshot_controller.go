@@ -18,9 +18,20 @@ package appstudioredhatcom
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/go-logr/logr"
 	appstudioshared "github.com/redhat-appstudio/application-api/api/v1alpha1"
+	sharedutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util"
 	logutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util/log"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -31,18 +42,48 @@ import (
 type SnapshotReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+
+	// APIReader reads directly from the API server, rather than from the cache of the Client: it is used to find the
+	// Snapshots which are referenced, before Snapshots are garbage collected. If nil, the Client is used.
+	APIReader client.Reader
 }
 
+const (
+	// SnapshotAnnotationImageDigests is set by the controller on the Snapshot: it is a JSON map of Component name to
+	// the digest of the container image of the Component.
+	SnapshotAnnotationImageDigests = "appstudio.redhat.com/image-digests"
+
+	// ApplicationAnnotationSnapshotRetentionCount is the number of Snapshots of the Application to keep: older Snapshots
+	// are deleted, unless they are referenced by a SnapshotEnvironmentBinding or a PromotionRun.
+	ApplicationAnnotationSnapshotRetentionCount = "appstudio.redhat.com/snapshot-retention-count"
+)
+
+const (
+	SnapshotConditionReady   = "Ready"
+	SnapshotConditionInvalid = "Invalid"
+
+	SnapshotReasonImagesResolved        = "ImagesResolved"
+	SnapshotReasonInvalidImageReference = "InvalidImageReference"
+	SnapshotReasonImageNotFound         = "ImageNotFound"
+	SnapshotReasonImageNotAccessible    = "ImageNotAccessible"
+	SnapshotReasonRegistryUnavailable   = "RegistryUnavailable"
+)
+
 //+kubebuilder:rbac:groups=appstudio.redhat.com,resources=snapshots,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=appstudio.redhat.com,resources=snapshots/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=appstudio.redhat.com,resources=snapshots/finalizers,verbs=update
+//+kubebuilder:rbac:groups=appstudio.redhat.com,resources=applications,verbs=get;list;watch
+//+kubebuilder:rbac:groups=appstudio.redhat.com,resources=snapshotenvironmentbindings,verbs=get;list;watch
+//+kubebuilder:rbac:groups=appstudio.redhat.com,resources=promotionruns,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=serviceaccounts,verbs=get
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get
 
-// Reconcile is part of the main kubernetes reconciliation loop which aims to
-// move the current state of the cluster closer to the desired state.
-// TODO(user): Modify the Reconcile function to compare the state specified by
-// the Snapshot object against the actual cluster state, and then
-// perform operations to make the cluster state reflect the state specified by
-// the user.
+// Reconcile validates the Snapshot: the container image of each Component of the Snapshot must exist in its
+// registry. The digest of each image is recorded in the SnapshotAnnotationImageDigests annotation, and the result
+// is reported by the Ready and Invalid conditions of the Snapshot.
+//
+// If the Application of the Snapshot has the ApplicationAnnotationSnapshotRetentionCount annotation, the older
+// Snapshots of the Application are then garbage collected.
 //
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.11.0/pkg/reconcile
@@ -55,7 +96,315 @@ func (r *SnapshotReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 
 	log.V(logutil.LogLevel_Debug).Info("Snapshot event", "request", req)
 
-	return ctrl.Result{}, nil
+	rClient := sharedutil.IfEnabledSimulateUnreliableClient(r.Client)
+
+	snapshot := &appstudioshared.Snapshot{}
+	if err := rClient.Get(ctx, req.NamespacedName, snapshot); err != nil {
+		if apierr.IsNotFound(err) {
+			// Nothing more to do!
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("unable to retrieve Snapshot: %v", err)
+	}
+
+	if snapshot.DeletionTimestamp != nil {
+		return ctrl.Result{}, nil
+	}
+
+	res := ctrl.Result{}
+
+	// 1) Validate the container images of the Snapshot, if not already done for the current generation of the Snapshot
+	if !isSnapshotValidated(*snapshot) {
+		var err error
+		if res, err = validateSnapshot(ctx, snapshot, rClient, log); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// 2) Garbage collect the older Snapshots of the Application
+	var apiReader client.Reader = rClient
+	if r.APIReader != nil {
+		apiReader = r.APIReader
+	}
+	if err := garbageCollectSnapshots(ctx, snapshot.Namespace, snapshot.Spec.Application, rClient, apiReader, log); err != nil {
+		log.Error(err, "unable to garbage collect Snapshots of Application: "+snapshot.Spec.Application)
+		return ctrl.Result{}, err
+	}
+
+	return res, nil
+}
+
+// isSnapshotValidated returns true if the Snapshot has been found to be either Ready or Invalid, at its current generation.
+func isSnapshotValidated(snapshot appstudioshared.Snapshot) bool {
+
+	for _, conditionType := range []string{SnapshotConditionReady, SnapshotConditionInvalid} {
+
+		index := findCondition(snapshot.Status.Conditions, conditionType)
+		if index >= 0 && snapshot.Status.Conditions[index].Status == metav1.ConditionTrue &&
+			snapshot.Status.Conditions[index].ObservedGeneration == snapshot.Generation {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validateSnapshot resolves the container image of each Component of the Snapshot to its digest, using the image pull
+// secrets of the Namespace, and updates the annotations and conditions of the Snapshot with the result.
+// - If a registry is unavailable or requires credentials, the Snapshot is neither Ready nor Invalid, and is requeued.
+func validateSnapshot(ctx context.Context, snapshot *appstudioshared.Snapshot, k8sClient client.Client, log logr.Logger) (ctrl.Result, error) {
+
+	credentialStore, err := getRegistryCredentialStore(ctx, snapshot.Namespace, k8sClient)
+	if err != nil {
+		log.Error(err, "unable to retrieve image pull secrets of Namespace: "+snapshot.Namespace)
+		return ctrl.Result{}, err
+	}
+
+	digests := map[string]string{}
+
+	invalidReason, unresolvedReason := "", ""
+	invalidMessages, unresolvedMessages := []string{}, []string{}
+
+	for _, component := range snapshot.Spec.Components {
+
+		digest, err := resolveImageDigest(ctx, component.ContainerImage, credentialStore)
+		if err == nil {
+			digests[component.Name] = digest
+			continue
+		}
+
+		log.Info("Unable to resolve container image of Snapshot component", "component", component.Name, "error", err.Error())
+
+		message := fmt.Sprintf("component '%s': %v", component.Name, err)
+
+		switch {
+		case errors.Is(err, errImageReferenceInvalid):
+			invalidReason = SnapshotReasonInvalidImageReference
+			invalidMessages = append(invalidMessages, message)
+
+		case errors.Is(err, errImageNotFound):
+			if invalidReason == "" {
+				invalidReason = SnapshotReasonImageNotFound
+			}
+			invalidMessages = append(invalidMessages, message)
+
+		case errors.Is(err, errImageNotAccessible):
+			unresolvedReason = SnapshotReasonImageNotAccessible
+			unresolvedMessages = append(unresolvedMessages, message)
+
+		default:
+			if unresolvedReason == "" {
+				unresolvedReason = SnapshotReasonRegistryUnavailable
+			}
+			unresolvedMessages = append(unresolvedMessages, message)
+		}
+	}
+
+	if invalidReason != "" {
+		// At least one of the images does not exist, so the Snapshot will never be valid
+		message := "Snapshot is invalid: " + strings.Join(append(invalidMessages, unresolvedMessages...), "; ")
+
+		return ctrl.Result{}, updateSnapshotStatusConditions(ctx, k8sClient, snapshot, message, invalidReason,
+			metav1.ConditionFalse, metav1.ConditionTrue, log)
+	}
+
+	if unresolvedReason != "" {
+		message := "Unable to resolve container images of Snapshot: " + strings.Join(unresolvedMessages, "; ")
+
+		if err := updateSnapshotStatusConditions(ctx, k8sClient, snapshot, message, unresolvedReason,
+			metav1.ConditionFalse, metav1.ConditionUnknown, log); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		// An image which requires credentials is unlikely to become accessible soon, so requeue less often
+		if unresolvedReason == SnapshotReasonImageNotAccessible {
+			return ctrl.Result{RequeueAfter: time.Minute * 5}, nil
+		}
+		return ctrl.Result{RequeueAfter: time.Minute}, nil
+	}
+
+	// All of the images were resolved: record their digests
+	digestsJSON, err := json.Marshal(digests)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("unable to marshal image digests: %v", err)
+	}
+
+	if snapshot.Annotations[SnapshotAnnotationImageDigests] != string(digestsJSON) {
+
+		if snapshot.Annotations == nil {
+			snapshot.Annotations = map[string]string{}
+		}
+		snapshot.Annotations[SnapshotAnnotationImageDigests] = string(digestsJSON)
+
+		if err := k8sClient.Update(ctx, snapshot); err != nil {
+			log.Error(err, "unable to update image digests of Snapshot: "+snapshot.Name)
+			return ctrl.Result{}, fmt.Errorf("unable to update image digests of Snapshot: %v", err)
+		}
+
+		logutil.LogAPIResourceChangeEvent(snapshot.Namespace, snapshot.Name, snapshot, logutil.ResourceModified, log)
+	}
+
+	return ctrl.Result{}, updateSnapshotStatusConditions(ctx, k8sClient, snapshot, "All container images of the Snapshot were resolved.",
+		SnapshotReasonImagesResolved, metav1.ConditionTrue, metav1.ConditionFalse, log)
+}
+
+// updateSnapshotStatusConditions sets the Ready and Invalid conditions of the Snapshot, at its current generation.
+func updateSnapshotStatusConditions(ctx context.Context, k8sClient client.Client, snapshot *appstudioshared.Snapshot,
+	message string, reason string, readyStatus metav1.ConditionStatus, invalidStatus metav1.ConditionStatus, log logr.Logger) error {
+
+	changed1, newConditions := insertOrUpdateConditionsInSlice(metav1.Condition{
+		Type:               SnapshotConditionReady,
+		Message:            message,
+		Status:             readyStatus,
+		Reason:             reason,
+		ObservedGeneration: snapshot.Generation,
+	}, snapshot.Status.Conditions)
+
+	changed2, newConditions := insertOrUpdateConditionsInSlice(metav1.Condition{
+		Type:               SnapshotConditionInvalid,
+		Message:            message,
+		Status:             invalidStatus,
+		Reason:             reason,
+		ObservedGeneration: snapshot.Generation,
+	}, newConditions)
+
+	// The conditions are otherwise unchanged, but they must be updated to observe the current generation
+	changed3 := false
+	for i := range newConditions {
+		if newConditions[i].Type == SnapshotConditionReady || newConditions[i].Type == SnapshotConditionInvalid {
+			if newConditions[i].ObservedGeneration != snapshot.Generation {
+				newConditions[i].ObservedGeneration = snapshot.Generation
+				changed3 = true
+			}
+		}
+	}
+
+	if changed1 || changed2 || changed3 {
+		snapshot.Status.Conditions = newConditions
+		if err := k8sClient.Status().Update(ctx, snapshot); err != nil {
+			log.Error(err, "unable to update Snapshot status condition.")
+			return err
+		}
+	}
+
+	return nil
+}
+
+// garbageCollectSnapshots deletes the oldest Snapshots of the Application, beyond the retention count of the Application.
+// Snapshots which are referenced by a SnapshotEnvironmentBinding or PromotionRun are never deleted, including the
+// previous Snapshots of an active PromotionRun which may be rolled back to.
+// - The references are read with 'apiReader', so that a SnapshotEnvironmentBinding or PromotionRun which was just
+// created (and is not yet in the cache) still protects its Snapshot.
+func garbageCollectSnapshots(ctx context.Context, namespace string, applicationName string, k8sClient client.Client,
+	apiReader client.Reader, log logr.Logger) error {
+
+	application := appstudioshared.Application{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      applicationName,
+			Namespace: namespace,
+		},
+	}
+	if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(&application), &application); err != nil {
+		if apierr.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("unable to retrieve Application '%s': %v", applicationName, err)
+	}
+
+	value := strings.TrimSpace(application.Annotations[ApplicationAnnotationSnapshotRetentionCount])
+	if value == "" {
+		return nil
+	}
+
+	retentionCount, err := strconv.Atoi(value)
+	if err != nil || retentionCount <= 0 {
+		// An invalid value is a user error, which is not resolved by reconciling again
+		log.Error(nil, fmt.Sprintf("invalid value for annotation '%s' of Application '%s': %s", ApplicationAnnotationSnapshotRetentionCount, applicationName, value))
+		return nil
+	}
+
+	snapshotList := appstudioshared.SnapshotList{}
+	if err := k8sClient.List(ctx, &snapshotList, &client.ListOptions{Namespace: namespace}); err != nil {
+		return fmt.Errorf("unable to list Snapshots: %v", err)
+	}
+
+	snapshots := []appstudioshared.Snapshot{}
+	for _, snapshot := range snapshotList.Items {
+		if snapshot.Spec.Application == applicationName && snapshot.DeletionTimestamp == nil {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+
+	if len(snapshots) <= retentionCount {
+		return nil
+	}
+
+	// Sort the Snapshots from newest to oldest
+	sort.Slice(snapshots, func(i, j int) bool {
+		if !snapshots[i].CreationTimestamp.Equal(&snapshots[j].CreationTimestamp) {
+			return snapshots[j].CreationTimestamp.Before(&snapshots[i].CreationTimestamp)
+		}
+		return snapshots[i].Name < snapshots[j].Name
+	})
+
+	protectedSnapshots, err := getReferencedSnapshots(ctx, namespace, apiReader)
+	if err != nil {
+		return err
+	}
+
+	for i := range snapshots[retentionCount:] {
+		snapshot := snapshots[retentionCount+i]
+
+		if protectedSnapshots[snapshot.Name] {
+			continue
+		}
+
+		if err := k8sClient.Delete(ctx, &snapshot); err != nil {
+			if apierr.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("unable to delete Snapshot '%s': %v", snapshot.Name, err)
+		}
+
+		logutil.LogAPIResourceChangeEvent(snapshot.Namespace, snapshot.Name, snapshot, logutil.ResourceDeleted, log)
+		log.Info("Deleted Snapshot beyond the retention count of Application: "+applicationName, "snapshot", snapshot.Name)
+	}
+
+	return nil
+}
+
+// getReferencedSnapshots returns the names of the Snapshots which are referenced by a SnapshotEnvironmentBinding or
+// PromotionRun in the Namespace.
+func getReferencedSnapshots(ctx context.Context, namespace string, k8sClient client.Reader) (map[string]bool, error) {
+
+	res := map[string]bool{}
+
+	bindingList := appstudioshared.SnapshotEnvironmentBindingList{}
+	if err := k8sClient.List(ctx, &bindingList, &client.ListOptions{Namespace: namespace}); err != nil {
+		return nil, fmt.Errorf("unable to list bindings: %v", err)
+	}
+	for _, binding := range bindingList.Items {
+		res[binding.Spec.Snapshot] = true
+	}
+
+	promotionRunList := appstudioshared.PromotionRunList{}
+	if err := k8sClient.List(ctx, &promotionRunList, &client.ListOptions{Namespace: namespace}); err != nil {
+		return nil, fmt.Errorf("unable to list PromotionRuns: %v", err)
+	}
+	for i := range promotionRunList.Items {
+		promotionRun := promotionRunList.Items[i]
+
+		res[promotionRun.Spec.Snapshot] = true
+
+		// An active PromotionRun may roll back its bindings to their previous Snapshots
+		if promotionRun.Status.State != appstudioshared.PromotionRunState_Complete {
+			for _, previousSnapshot := range getPreviousSnapshots(&promotionRun) {
+				res[previousSnapshot] = true
+			}
+		}
+	}
+
+	return res, nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -0,0 +1,524 @@
+package appstudioredhatcom
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appstudiosharedv1 "github.com/redhat-appstudio/application-api/api/v1alpha1"
+	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/tests"
+	corev1 "k8s.io/api/core/v1"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Snapshot Controller Test", func() {
+
+	Context("Testing the parsing of container image references", func() {
+
+		It("should apply the same defaults as 'docker pull'", func() {
+			ref, err := parseImageReference("nginx")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ref).To(Equal(imageReference{registry: dockerHubRegistry, repository: "library/nginx", tag: "latest"}))
+
+			ref, err = parseImageReference("bitnami/nginx:1.25")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ref).To(Equal(imageReference{registry: dockerHubRegistry, repository: "bitnami/nginx", tag: "1.25"}))
+		})
+
+		It("should parse the registry, repository, tag and digest", func() {
+			ref, err := parseImageReference("quay.io/org/team/app:v1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ref).To(Equal(imageReference{registry: "quay.io", repository: "org/team/app", tag: "v1"}))
+
+			digest := "sha256:" + strings.Repeat("a", 64)
+			ref, err = parseImageReference("localhost:5000/app:v1@" + digest)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ref).To(Equal(imageReference{registry: "localhost:5000", repository: "app", tag: "v1", digest: digest}))
+			Expect(ref.manifestReference()).To(Equal(digest))
+		})
+
+		It("should reject invalid image references", func() {
+			for _, image := range []string{"", "quay.io/Org/App:v1", "quay.io/org/app:v1@not-a-digest", "quay.io/org/app:-v1"} {
+				_, err := parseImageReference(image)
+				Expect(err).To(MatchError(ContainSubstring(errImageReferenceInvalid.Error())), image)
+			}
+		})
+	})
+
+	Context("Testing SnapshotReconciler", func() {
+
+		const (
+			manifestContent = `{"schemaVersion":2}`
+			registryToken   = "anonymous-token"
+			teamToken       = "team-token"
+		)
+
+		basicAuthorization := "Basic " + base64.StdEncoding.EncodeToString([]byte("my-user:my-password"))
+
+		var (
+			ctx                context.Context
+			k8sClient          client.Client
+			reconciler         SnapshotReconciler
+			registry           *httptest.Server
+			registryHost       string
+			registryAvailable  bool
+			manifestDigest     string
+			originalHTTPClient *http.Client
+			namespace          string
+		)
+
+		BeforeEach(func() {
+			ctx = context.Background()
+
+			scheme,
+				argocdNamespace,
+				kubesystemNamespace,
+				apiNamespace,
+				err := tests.GenericTestSetup()
+			Expect(err).ToNot(HaveOccurred())
+
+			err = appstudiosharedv1.AddToScheme(scheme)
+			Expect(err).ToNot(HaveOccurred())
+
+			k8sClient = fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(apiNamespace, argocdNamespace, kubesystemNamespace).
+				Build()
+
+			reconciler = SnapshotReconciler{Client: k8sClient, Scheme: scheme}
+			namespace = apiNamespace.Name
+
+			hash := sha256.Sum256([]byte(manifestContent))
+			manifestDigest = "sha256:" + hex.EncodeToString(hash[:])
+			registryAvailable = true
+
+			By("starting a registry which contains 'org/app:v1', and 'private/app:v1' which requires an anonymous token")
+			registry = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+				if !registryAvailable {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+
+				switch {
+				case r.URL.Path == "/token":
+					Expect(r.URL.Query().Get("scope")).To(Equal("repository:private/app:pull"))
+					Expect(json.NewEncoder(w).Encode(map[string]string{"token": registryToken})).To(Succeed())
+
+				case r.URL.Path == "/v2/org/app/manifests/v1" || r.URL.Path == "/v2/org/app/manifests/"+manifestDigest:
+					w.Header().Set("Docker-Content-Digest", manifestDigest)
+					_, _ = w.Write([]byte(manifestContent))
+
+				case r.URL.Path == "/v2/private/app/manifests/v1":
+					if r.Header.Get("Authorization") != "Bearer "+registryToken {
+						w.Header().Set("WWW-Authenticate", `Bearer realm="`+registry.URL+`/token",service="test",scope="repository:private/app:pull"`)
+						w.WriteHeader(http.StatusUnauthorized)
+						return
+					}
+					// The digest is not returned, so the controller must calculate it from the manifest
+					_, _ = w.Write([]byte(manifestContent))
+
+				case r.URL.Path == "/team-token":
+					if r.Header.Get("Authorization") != basicAuthorization {
+						w.WriteHeader(http.StatusUnauthorized)
+						return
+					}
+					Expect(json.NewEncoder(w).Encode(map[string]string{"access_token": teamToken})).To(Succeed())
+
+				case r.URL.Path == "/v2/team/app/manifests/v1":
+					if r.Header.Get("Authorization") != "Bearer "+teamToken {
+						w.Header().Set("WWW-Authenticate", `Bearer realm="`+registry.URL+`/team-token",service="test",scope="repository:team/app:pull"`)
+						w.WriteHeader(http.StatusUnauthorized)
+						return
+					}
+					_, _ = w.Write([]byte(manifestContent))
+
+				case r.URL.Path == "/v2/large/app/manifests/v1":
+					// The digest is not returned, and the manifest is too large to calculate it from
+					_, _ = w.Write([]byte(strings.Repeat(" ", maxManifestSize+1)))
+
+				case r.URL.Path == "/v2/insecure-realm/app/manifests/v1":
+					w.Header().Set("WWW-Authenticate", `Bearer realm="`+strings.Replace(registry.URL, "https://", "http://", 1)+`/token"`)
+					w.WriteHeader(http.StatusUnauthorized)
+
+				case strings.HasPrefix(r.URL.Path, "/v2/credentials/"):
+					if r.Header.Get("Authorization") == basicAuthorization {
+						w.Header().Set("Docker-Content-Digest", manifestDigest)
+						_, _ = w.Write([]byte(manifestContent))
+						return
+					}
+					w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+					w.WriteHeader(http.StatusUnauthorized)
+
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+
+			registryHost = strings.TrimPrefix(registry.URL, "https://")
+
+			originalHTTPClient = snapshotRegistryHTTPClient
+			snapshotRegistryHTTPClient = registry.Client()
+		})
+
+		AfterEach(func() {
+			snapshotRegistryHTTPClient = originalHTTPClient
+			registry.Close()
+		})
+
+		createSnapshot := func(name string, images ...string) *appstudiosharedv1.Snapshot {
+			snapshot := &appstudiosharedv1.Snapshot{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: namespace,
+				},
+				Spec: appstudiosharedv1.SnapshotSpec{
+					Application: "my-app",
+				},
+			}
+			for i, image := range images {
+				snapshot.Spec.Components = append(snapshot.Spec.Components, appstudiosharedv1.SnapshotComponent{
+					Name:           "component-" + string(rune('a'+i)),
+					ContainerImage: image,
+				})
+			}
+			Expect(k8sClient.Create(ctx, snapshot)).To(Succeed())
+			return snapshot
+		}
+
+		getCondition := func(snapshot *appstudiosharedv1.Snapshot, conditionType string) metav1.Condition {
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(snapshot), snapshot)).To(Succeed())
+			index := findCondition(snapshot.Status.Conditions, conditionType)
+			Expect(index).To(BeNumerically(">=", 0))
+			return snapshot.Status.Conditions[index]
+		}
+
+		It("should set the Ready condition, and record the digest of each image, if all of the images exist", func() {
+			snapshot := createSnapshot("my-snapshot",
+				registryHost+"/org/app:v1",
+				registryHost+"/org/app@"+manifestDigest,
+				registryHost+"/private/app:v1")
+
+			res, err := reconciler.Reconcile(ctx, newRequest(namespace, snapshot.Name))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.RequeueAfter).To(BeZero())
+
+			ready := getCondition(snapshot, SnapshotConditionReady)
+			Expect(ready.Status).To(Equal(metav1.ConditionTrue))
+			Expect(ready.Reason).To(Equal(SnapshotReasonImagesResolved))
+			Expect(getCondition(snapshot, SnapshotConditionInvalid).Status).To(Equal(metav1.ConditionFalse))
+
+			digests := map[string]string{}
+			Expect(json.Unmarshal([]byte(snapshot.Annotations[SnapshotAnnotationImageDigests]), &digests)).To(Succeed())
+			Expect(digests).To(Equal(map[string]string{
+				"component-a": manifestDigest,
+				"component-b": manifestDigest,
+				"component-c": manifestDigest,
+			}))
+
+			By("reconciling again should not contact the registry, as the Snapshot has already been validated")
+			registryAvailable = false
+			_, err = reconciler.Reconcile(ctx, newRequest(namespace, snapshot.Name))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(getCondition(snapshot, SnapshotConditionReady).Status).To(Equal(metav1.ConditionTrue))
+		})
+
+		It("should set the Invalid condition if an image does not exist, or is not a valid reference", func() {
+			snapshot := createSnapshot("my-snapshot",
+				registryHost+"/org/app:v1",
+				registryHost+"/org/app:does-not-exist")
+
+			_, err := reconciler.Reconcile(ctx, newRequest(namespace, snapshot.Name))
+			Expect(err).ToNot(HaveOccurred())
+
+			invalid := getCondition(snapshot, SnapshotConditionInvalid)
+			Expect(invalid.Status).To(Equal(metav1.ConditionTrue))
+			Expect(invalid.Reason).To(Equal(SnapshotReasonImageNotFound))
+			Expect(invalid.Message).To(ContainSubstring("component 'component-b'"))
+			Expect(getCondition(snapshot, SnapshotConditionReady).Status).To(Equal(metav1.ConditionFalse))
+			Expect(snapshot.Annotations).ToNot(HaveKey(SnapshotAnnotationImageDigests))
+
+			snapshot = createSnapshot("my-invalid-snapshot", registryHost+"/org/App:v1")
+
+			_, err = reconciler.Reconcile(ctx, newRequest(namespace, snapshot.Name))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(getCondition(snapshot, SnapshotConditionInvalid).Reason).To(Equal(SnapshotReasonInvalidImageReference))
+		})
+
+		It("should requeue, without setting the Invalid condition, if the registry is unavailable or requires credentials", func() {
+			registryAvailable = false
+			snapshot := createSnapshot("my-snapshot", registryHost+"/org/app:v1")
+
+			res, err := reconciler.Reconcile(ctx, newRequest(namespace, snapshot.Name))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.RequeueAfter).To(Equal(time.Minute))
+
+			ready := getCondition(snapshot, SnapshotConditionReady)
+			Expect(ready.Status).To(Equal(metav1.ConditionFalse))
+			Expect(ready.Reason).To(Equal(SnapshotReasonRegistryUnavailable))
+			Expect(getCondition(snapshot, SnapshotConditionInvalid).Status).To(Equal(metav1.ConditionUnknown))
+
+			By("the Snapshot should become Ready once the registry is available")
+			registryAvailable = true
+			_, err = reconciler.Reconcile(ctx, newRequest(namespace, snapshot.Name))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(getCondition(snapshot, SnapshotConditionReady).Status).To(Equal(metav1.ConditionTrue))
+
+			snapshot = createSnapshot("my-private-snapshot", registryHost+"/credentials/app:v1")
+
+			res, err = reconciler.Reconcile(ctx, newRequest(namespace, snapshot.Name))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.RequeueAfter).To(Equal(5 * time.Minute))
+			Expect(getCondition(snapshot, SnapshotConditionReady).Reason).To(Equal(SnapshotReasonImageNotAccessible))
+			Expect(getCondition(snapshot, SnapshotConditionInvalid).Status).To(Equal(metav1.ConditionUnknown))
+		})
+
+		It("should use the image pull secrets of the default ServiceAccount of the Namespace", func() {
+			By("creating an image pull secret for the registry, and a Secret of another type which should be ignored")
+			dockerConfig, err := json.Marshal(map[string]any{
+				"auths": map[string]any{
+					"https://" + registryHost + "/": map[string]string{
+						"auth": base64.StdEncoding.EncodeToString([]byte("my-user:my-password")),
+					},
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			pullSecret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "my-pull-secret", Namespace: namespace},
+				Type:       corev1.SecretTypeDockerConfigJson,
+				Data:       map[string][]byte{corev1.DockerConfigJsonKey: dockerConfig},
+			}
+			Expect(k8sClient.Create(ctx, pullSecret)).To(Succeed())
+
+			opaqueSecret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "my-opaque-secret", Namespace: namespace},
+				Type:       corev1.SecretTypeOpaque,
+				Data:       map[string][]byte{"password": []byte("not-a-pull-secret")},
+			}
+			Expect(k8sClient.Create(ctx, opaqueSecret)).To(Succeed())
+
+			serviceAccount := &corev1.ServiceAccount{
+				ObjectMeta: metav1.ObjectMeta{Name: imagePullServiceAccount, Namespace: namespace},
+				ImagePullSecrets: []corev1.LocalObjectReference{
+					{Name: opaqueSecret.Name}, {Name: "does-not-exist"}, {Name: pullSecret.Name},
+				},
+			}
+			Expect(k8sClient.Create(ctx, serviceAccount)).To(Succeed())
+
+			By("resolving images which require basic authentication, and a token from a token server which requires credentials")
+			snapshot := createSnapshot("my-snapshot",
+				registryHost+"/credentials/app:v1",
+				registryHost+"/team/app:v1")
+
+			res, err := reconciler.Reconcile(ctx, newRequest(namespace, snapshot.Name))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.RequeueAfter).To(BeZero())
+			Expect(getCondition(snapshot, SnapshotConditionReady).Status).To(Equal(metav1.ConditionTrue))
+		})
+
+		It("should not resolve images of registries which are cluster-internal, or whose token server is not https", func() {
+			snapshot := createSnapshot("my-snapshot", "registry.my-namespace.svc:5000/org/app:v1")
+
+			_, err := reconciler.Reconcile(ctx, newRequest(namespace, snapshot.Name))
+			Expect(err).ToNot(HaveOccurred())
+
+			invalid := getCondition(snapshot, SnapshotConditionInvalid)
+			Expect(invalid.Status).To(Equal(metav1.ConditionTrue))
+			Expect(invalid.Reason).To(Equal(SnapshotReasonInvalidImageReference))
+
+			snapshot = createSnapshot("my-insecure-realm-snapshot", registryHost+"/insecure-realm/app:v1")
+
+			_, err = reconciler.Reconcile(ctx, newRequest(namespace, snapshot.Name))
+			Expect(err).ToNot(HaveOccurred())
+
+			ready := getCondition(snapshot, SnapshotConditionReady)
+			Expect(ready.Reason).To(Equal(SnapshotReasonImageNotAccessible))
+			Expect(ready.Message).To(ContainSubstring("is not an https URL"))
+		})
+
+		It("should not read a manifest which is larger than the maximum manifest size", func() {
+			_, err := resolveImageDigest(ctx, registryHost+"/large/app:v1", registryCredentialStore{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("is larger than"))
+
+			digest, err := resolveImageDigest(ctx, registryHost+"/private/app:v1", registryCredentialStore{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(digest).To(Equal(manifestDigest))
+		})
+
+		Context("Testing the garbage collection of Snapshots", func() {
+
+			var snapshots []*appstudiosharedv1.Snapshot
+
+			BeforeEach(func() {
+				By("creating 6 Snapshots of the Application, from oldest to newest")
+				snapshots = []*appstudiosharedv1.Snapshot{}
+				for i := 0; i < 6; i++ {
+					snapshot := &appstudiosharedv1.Snapshot{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:              "snapshot-" + string(rune('1'+i)),
+							Namespace:         namespace,
+							CreationTimestamp: metav1.NewTime(time.Now().Add(time.Duration(i-6) * time.Hour)),
+						},
+						Spec: appstudiosharedv1.SnapshotSpec{
+							Application: "my-app",
+						},
+					}
+					Expect(k8sClient.Create(ctx, snapshot)).To(Succeed())
+					snapshots = append(snapshots, snapshot)
+				}
+
+				otherSnapshot := &appstudiosharedv1.Snapshot{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:              "other-app-snapshot",
+						Namespace:         namespace,
+						CreationTimestamp: metav1.NewTime(time.Now().Add(-24 * time.Hour)),
+					},
+					Spec: appstudiosharedv1.SnapshotSpec{
+						Application: "other-app",
+					},
+				}
+				Expect(k8sClient.Create(ctx, otherSnapshot)).To(Succeed())
+			})
+
+			snapshotExists := func(name string) bool {
+				err := k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &appstudiosharedv1.Snapshot{})
+				if apierr.IsNotFound(err) {
+					return false
+				}
+				Expect(err).ToNot(HaveOccurred())
+				return true
+			}
+
+			It("should delete the oldest Snapshots beyond the retention count, unless they are referenced", func() {
+				application := &appstudiosharedv1.Application{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "my-app",
+						Namespace: namespace,
+						Annotations: map[string]string{
+							ApplicationAnnotationSnapshotRetentionCount: "2",
+						},
+					},
+				}
+				Expect(k8sClient.Create(ctx, application)).To(Succeed())
+
+				By("referencing snapshot-1 from a binding, snapshot-2 from a PromotionRun, and snapshot-3 as the previous Snapshot of an active PromotionRun")
+				binding := &appstudiosharedv1.SnapshotEnvironmentBinding{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "my-binding",
+						Namespace: namespace,
+					},
+					Spec: appstudiosharedv1.SnapshotEnvironmentBindingSpec{
+						Application: "my-app",
+						Environment: "prod",
+						Snapshot:    "snapshot-1",
+					},
+				}
+				Expect(k8sClient.Create(ctx, binding)).To(Succeed())
+
+				promotionRun := &appstudiosharedv1.PromotionRun{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "my-promotion",
+						Namespace: namespace,
+					},
+					Spec: appstudiosharedv1.PromotionRunSpec{
+						Application: "my-app",
+						Snapshot:    "snapshot-2",
+					},
+					Status: appstudiosharedv1.PromotionRunStatus{
+						State: appstudiosharedv1.PromotionRunState_Active,
+						Conditions: []appstudiosharedv1.PromotionRunCondition{{
+							Type:    PromotionRunConditionPreviousSnapshots,
+							Status:  appstudiosharedv1.PromotionRunConditionStatusTrue,
+							Reason:  PromotionRunReasonPreviousSnapshotRecorded,
+							Message: "Previous Snapshots: staging: snapshot-3",
+						}},
+					},
+				}
+				Expect(k8sClient.Create(ctx, promotionRun)).To(Succeed())
+
+				_, err := reconciler.Reconcile(ctx, newRequest(namespace, snapshots[5].Name))
+				Expect(err).ToNot(HaveOccurred())
+
+				for _, name := range []string{"snapshot-1", "snapshot-2", "snapshot-3", "snapshot-5", "snapshot-6", "other-app-snapshot"} {
+					Expect(snapshotExists(name)).To(BeTrue(), name)
+				}
+				Expect(snapshotExists("snapshot-4")).To(BeFalse())
+
+				By("completing the PromotionRun should no longer protect its previous Snapshot")
+				promotionRun.Status.State = appstudiosharedv1.PromotionRunState_Complete
+				Expect(k8sClient.Status().Update(ctx, promotionRun)).To(Succeed())
+
+				_, err = reconciler.Reconcile(ctx, newRequest(namespace, snapshots[5].Name))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(snapshotExists("snapshot-2")).To(BeTrue())
+				Expect(snapshotExists("snapshot-3")).To(BeFalse())
+			})
+
+			It("should read the references to Snapshots from the API server, rather than the cache", func() {
+				application := &appstudiosharedv1.Application{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "my-app",
+						Namespace: namespace,
+						Annotations: map[string]string{
+							ApplicationAnnotationSnapshotRetentionCount: "2",
+						},
+					},
+				}
+				Expect(k8sClient.Create(ctx, application)).To(Succeed())
+
+				By("creating a binding which references snapshot-1, which is not yet in the cache of the Client")
+				binding := &appstudiosharedv1.SnapshotEnvironmentBinding{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "my-binding",
+						Namespace: namespace,
+					},
+					Spec: appstudiosharedv1.SnapshotEnvironmentBindingSpec{
+						Application: "my-app",
+						Environment: "prod",
+						Snapshot:    "snapshot-1",
+					},
+				}
+				reconciler.APIReader = fake.NewClientBuilder().WithScheme(reconciler.Scheme).WithObjects(binding).Build()
+
+				_, err := reconciler.Reconcile(ctx, newRequest(namespace, snapshots[5].Name))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(snapshotExists("snapshot-1")).To(BeTrue())
+				for _, name := range []string{"snapshot-2", "snapshot-3", "snapshot-4"} {
+					Expect(snapshotExists(name)).To(BeFalse(), name)
+				}
+			})
+
+			It("should not delete any Snapshots if the Application has no retention count", func() {
+				application := &appstudiosharedv1.Application{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "my-app",
+						Namespace: namespace,
+					},
+				}
+				Expect(k8sClient.Create(ctx, application)).To(Succeed())
+
+				_, err := reconciler.Reconcile(ctx, newRequest(namespace, snapshots[5].Name))
+				Expect(err).ToNot(HaveOccurred())
+
+				for _, snapshot := range snapshots {
+					Expect(snapshotExists(snapshot.Name)).To(BeTrue(), snapshot.Name)
+				}
+			})
+		})
+	})
+})
@@ -0,0 +1,489 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package appstudioredhatcom
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	sharedutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util"
+)
+
+// This file is responsible for resolving the container images of a Snapshot to their digests, using the
+// OCI distribution API (https://github.com/opencontainers/distribution-spec) of the registry of each image.
+//
+// Images are resolved with the image pull secrets of the 'default' ServiceAccount of the Namespace (the same
+// credentials that the Pods of the Namespace pull images with), or anonymously if there are none for the registry.
+// If the registry requires a bearer token, the token is requested from the token server of the registry.
+//
+// Both the registry and its token server must be https URLs, and must not be cluster-internal or link-local hosts
+// (see external_http.go).
+
+const (
+	dockerHubDomain   = "docker.io"
+	dockerHubRegistry = "registry-1.docker.io"
+
+	// imagePullServiceAccount is the ServiceAccount whose image pull secrets are used to access registries
+	imagePullServiceAccount = "default"
+
+	// maxManifestSize is the maximum size of an image manifest that is read from a registry, to calculate its digest
+	// (the size that registries are expected to accept, in the OCI distribution spec)
+	maxManifestSize = 4 * 1024 * 1024
+
+	// maxTokenResponseSize is the maximum size of a response from a registry token server
+	maxTokenResponseSize = 1024 * 1024
+)
+
+// dockerHubAliases are the names by which Docker Hub is referred to in image pull secrets.
+var dockerHubAliases = []string{dockerHubDomain, dockerHubRegistry, "index.docker.io"}
+
+var (
+	// errImageReferenceInvalid is returned if the container image is not a valid image reference
+	errImageReferenceInvalid = errors.New("invalid image reference")
+
+	// errImageNotFound is returned if the registry reports that the image does not exist
+	errImageNotFound = errors.New("image not found")
+
+	// errImageNotAccessible is returned if the registry requires credentials to access the image
+	errImageNotAccessible = errors.New("image not accessible without credentials")
+)
+
+var (
+	imageRepositoryRegex = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*$`)
+	imageTagRegex        = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+	imageDigestRegex     = regexp.MustCompile(`^[a-z0-9]+(?:[.+_-][a-z0-9]+)*:[a-zA-Z0-9=_-]+$`)
+)
+
+// imageManifestMediaTypes are the manifest media types that are accepted from the registry: both single and
+// multi-architecture images, in both OCI and Docker formats.
+var imageManifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// snapshotRegistryHTTPClient is the HTTP client used to communicate with container image registries.
+var snapshotRegistryHTTPClient = sharedutil.NewExternalHTTPClient()
+
+// registryCredentials are the credentials of a registry, from an image pull secret
+type registryCredentials struct {
+	username string
+	password string
+}
+
+// registryCredentialStore is the credentials of each registry, by the (normalized) key of the registry in the image
+// pull secret: either a host (for example 'quay.io'), or a host and repository path prefix (for example 'quay.io/org').
+type registryCredentialStore map[string]registryCredentials
+
+// imageReference is a parsed container image reference, for example 'quay.io/org/repo:tag' or 'org/repo@sha256:...'
+type imageReference struct {
+	// registry is the host (and optional port) of the registry, for example 'quay.io'
+	registry string
+	// repository is the path of the repository within the registry, for example 'org/repo'
+	repository string
+	// tag is the tag of the image, if the reference has no digest. Defaults to 'latest'.
+	tag string
+	// digest is the digest of the image, if the reference has one.
+	digest string
+}
+
+// manifestReference returns the tag or digest of the image, as used in the manifest URL of the registry.
+func (ref imageReference) manifestReference() string {
+	if ref.digest != "" {
+		return ref.digest
+	}
+	return ref.tag
+}
+
+// parseImageReference parses a container image reference, using the same defaults as 'docker pull': images without
+// a registry are pulled from Docker Hub, and images without a tag or digest have the 'latest' tag.
+func parseImageReference(image string) (imageReference, error) {
+
+	res := imageReference{}
+
+	name := strings.TrimSpace(image)
+	if name == "" {
+		return res, fmt.Errorf("%w: image is empty", errImageReferenceInvalid)
+	}
+
+	if index := strings.Index(name, "@"); index >= 0 {
+		res.digest = name[index+1:]
+		name = name[:index]
+
+		if !imageDigestRegex.MatchString(res.digest) {
+			return res, fmt.Errorf("%w: invalid digest '%s'", errImageReferenceInvalid, res.digest)
+		}
+	}
+
+	// The tag follows the last ':' after the last '/', as the registry may also contain a ':' (before its port)
+	if index := strings.LastIndex(name, ":"); index > strings.LastIndex(name, "/") {
+		res.tag = name[index+1:]
+		name = name[:index]
+
+		if !imageTagRegex.MatchString(res.tag) {
+			return res, fmt.Errorf("%w: invalid tag '%s'", errImageReferenceInvalid, res.tag)
+		}
+	}
+
+	// The first component of the name is the registry, if it looks like a host name
+	res.registry = dockerHubDomain
+	res.repository = name
+	if index := strings.Index(name, "/"); index >= 0 {
+		if domain := name[:index]; strings.ContainsAny(domain, ".:") || domain == "localhost" {
+			res.registry = domain
+			res.repository = name[index+1:]
+		}
+	}
+
+	if res.registry == dockerHubDomain {
+		res.registry = dockerHubRegistry
+		if !strings.Contains(res.repository, "/") {
+			res.repository = "library/" + res.repository
+		}
+	}
+
+	if !imageRepositoryRegex.MatchString(res.repository) {
+		return res, fmt.Errorf("%w: invalid repository '%s'", errImageReferenceInvalid, res.repository)
+	}
+
+	if res.tag == "" && res.digest == "" {
+		res.tag = "latest"
+	}
+
+	return res, nil
+}
+
+// resolveImageDigest returns the digest of the manifest of the container image, from the registry of the image.
+// - If the image reference includes a digest, the registry must contain a manifest with that digest.
+// - The credentials of the registry are used, if the credential store contains them.
+// - Returns an error wrapping errImageReferenceInvalid, errImageNotFound or errImageNotAccessible, if the image is
+// invalid, does not exist, or requires credentials. Any other error is (presumed to be) temporary.
+func resolveImageDigest(ctx context.Context, image string, credentialStore registryCredentialStore) (string, error) {
+
+	ref, err := parseImageReference(image)
+	if err != nil {
+		return "", err
+	}
+
+	manifestURL, err := url.Parse(fmt.Sprintf("https://%s/v2/%s/manifests/%s", ref.registry, ref.repository, ref.manifestReference()))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", errImageReferenceInvalid, err)
+	}
+	if err := sharedutil.ValidateExternalURL(manifestURL); err != nil {
+		return "", fmt.Errorf("%w: registry '%s' is not allowed: %v", errImageReferenceInvalid, ref.registry, err)
+	}
+
+	credentials, hasCredentials := credentialStore.lookup(ref)
+
+	res, err := sendManifestRequest(ctx, manifestURL.String(), "")
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	// The registry requires authentication: authenticate as the WWW-Authenticate header describes, and try again.
+	if res.StatusCode == http.StatusUnauthorized {
+		authenticateHeader := res.Header.Get("WWW-Authenticate")
+
+		authorization := ""
+		if scheme, _, _ := strings.Cut(strings.TrimSpace(authenticateHeader), " "); strings.EqualFold(scheme, "Basic") {
+			if hasCredentials {
+				authorization = credentials.basicAuthorization()
+			}
+		} else {
+			token, err := requestRegistryToken(ctx, authenticateHeader, credentials)
+			if err != nil {
+				return "", err
+			}
+			if token != "" {
+				authorization = "Bearer " + token
+			}
+		}
+
+		if authorization != "" {
+			if res, err = sendManifestRequest(ctx, manifestURL.String(), authorization); err != nil {
+				return "", err
+			}
+			defer res.Body.Close()
+		}
+	}
+
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		return "", fmt.Errorf("%w: %s", errImageNotFound, image)
+
+	case res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden:
+		return "", fmt.Errorf("%w: %s", errImageNotAccessible, image)
+
+	case res.StatusCode < 200 || res.StatusCode > 299:
+		return "", fmt.Errorf("unexpected response from registry '%s' for image '%s': %s", ref.registry, image, res.Status)
+	}
+
+	digest := res.Header.Get("Docker-Content-Digest")
+
+	if digest == "" {
+		// The registry did not return the digest, so calculate it from the content of the manifest
+		body, err := io.ReadAll(io.LimitReader(res.Body, maxManifestSize+1))
+		if err != nil {
+			return "", fmt.Errorf("unable to read manifest of image '%s': %v", image, err)
+		}
+		if len(body) > maxManifestSize {
+			return "", fmt.Errorf("manifest of image '%s' is larger than %d bytes", image, maxManifestSize)
+		}
+		hash := sha256.Sum256(body)
+		digest = "sha256:" + hex.EncodeToString(hash[:])
+	}
+
+	if ref.digest != "" && digest != ref.digest {
+		return "", fmt.Errorf("%w: registry returned digest '%s' for image '%s'", errImageNotFound, digest, image)
+	}
+
+	return digest, nil
+}
+
+// sendManifestRequest sends a GET request for the manifest of an image, optionally with an Authorization header.
+func sendManifestRequest(ctx context.Context, manifestURL string, authorization string) (*http.Response, error) {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, manifestURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errImageReferenceInvalid, err)
+	}
+
+	req.Header.Set("Accept", strings.Join(imageManifestMediaTypes, ", "))
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	res, err := snapshotRegistryHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve manifest '%s': %v", manifestURL, err)
+	}
+
+	return res, nil
+}
+
+// requestRegistryToken requests a bearer token from the token server described by the WWW-Authenticate header of a
+// registry response, for example: 'Bearer realm="https://quay.io/v2/auth",service="quay.io",scope="repository:org/repo:pull"'
+// - The token is requested with the given credentials, if any, otherwise an anonymous token is requested.
+// - Returns an empty token if the header does not describe a bearer token server.
+func requestRegistryToken(ctx context.Context, authenticateHeader string, credentials registryCredentials) (string, error) {
+
+	scheme, params, _ := strings.Cut(strings.TrimSpace(authenticateHeader), " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", nil
+	}
+
+	values := map[string]string{}
+	for _, param := range strings.Split(params, ",") {
+		if key, value, found := strings.Cut(strings.TrimSpace(param), "="); found {
+			values[strings.ToLower(key)] = strings.Trim(value, `"`)
+		}
+	}
+
+	realm, err := url.Parse(values["realm"])
+	if err != nil || values["realm"] == "" {
+		return "", nil
+	}
+	if err := sharedutil.ValidateExternalURL(realm); err != nil {
+		return "", fmt.Errorf("%w: registry token server '%s' is not allowed: %v", errImageNotAccessible, realm.Host, err)
+	}
+
+	query := realm.Query()
+	for _, key := range []string{"service", "scope"} {
+		if values[key] != "" {
+			query.Set(key, values[key])
+		}
+	}
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("unable to create registry token request: %v", err)
+	}
+	if credentials.username != "" || credentials.password != "" {
+		req.Header.Set("Authorization", credentials.basicAuthorization())
+	}
+
+	res, err := snapshotRegistryHTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("unable to request registry token from '%s': %v", realm.Host, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden {
+		return "", fmt.Errorf("%w: token server '%s' returned %s", errImageNotAccessible, realm.Host, res.Status)
+	} else if res.StatusCode < 200 || res.StatusCode > 299 {
+		return "", fmt.Errorf("unexpected response from registry token server '%s': %s", realm.Host, res.Status)
+	}
+
+	tokenResponse := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := json.NewDecoder(io.LimitReader(res.Body, maxTokenResponseSize)).Decode(&tokenResponse); err != nil {
+		return "", fmt.Errorf("unable to parse response from registry token server '%s': %v", realm.Host, err)
+	}
+
+	if tokenResponse.Token != "" {
+		return tokenResponse.Token, nil
+	}
+	return tokenResponse.AccessToken, nil
+}
+
+// basicAuthorization returns the value of an Authorization header, for HTTP basic authentication with the credentials.
+func (credentials registryCredentials) basicAuthorization() string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials.username+":"+credentials.password))
+}
+
+// lookup returns the credentials of the registry of the image: those of the most specific key which matches the
+// registry, and a prefix of the repository of the image.
+func (store registryCredentialStore) lookup(ref imageReference) (registryCredentials, bool) {
+
+	res, resKey, found := registryCredentials{}, "", false
+
+	for key, credentials := range store {
+
+		host, path, _ := strings.Cut(key, "/")
+		if host != ref.registry || (path != "" && ref.repository != path && !strings.HasPrefix(ref.repository, path+"/")) {
+			continue
+		}
+
+		if !found || len(key) > len(resKey) {
+			res, resKey, found = credentials, key, true
+		}
+	}
+
+	return res, found
+}
+
+// getRegistryCredentialStore returns the credentials of the image pull secrets of the 'default' ServiceAccount of the
+// Namespace. A ServiceAccount or Secret which does not exist, or a Secret which is not an image pull secret, is ignored.
+func getRegistryCredentialStore(ctx context.Context, namespace string, k8sClient client.Client) (registryCredentialStore, error) {
+
+	res := registryCredentialStore{}
+
+	serviceAccount := corev1.ServiceAccount{}
+	if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: imagePullServiceAccount}, &serviceAccount); err != nil {
+		if apierr.IsNotFound(err) {
+			return res, nil
+		}
+		return nil, fmt.Errorf("unable to retrieve ServiceAccount '%s': %v", imagePullServiceAccount, err)
+	}
+
+	for _, secretRef := range serviceAccount.ImagePullSecrets {
+
+		secret := corev1.Secret{}
+		if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: secretRef.Name}, &secret); err != nil {
+			if apierr.IsNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("unable to retrieve image pull secret '%s': %v", secretRef.Name, err)
+		}
+
+		// The 'auths' of a .dockerconfigjson Secret have the same format as the whole of a (legacy) .dockercfg Secret
+		auths := map[string]struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+			Auth     string `json:"auth"`
+		}{}
+
+		switch secret.Type {
+		case corev1.SecretTypeDockerConfigJson:
+			dockerConfig := struct {
+				Auths json.RawMessage `json:"auths"`
+			}{}
+			if err := json.Unmarshal(secret.Data[corev1.DockerConfigJsonKey], &dockerConfig); err != nil || len(dockerConfig.Auths) == 0 {
+				continue
+			}
+			if err := json.Unmarshal(dockerConfig.Auths, &auths); err != nil {
+				continue
+			}
+
+		case corev1.SecretTypeDockercfg:
+			if err := json.Unmarshal(secret.Data[corev1.DockerConfigKey], &auths); err != nil {
+				continue
+			}
+
+		default:
+			continue
+		}
+
+		for key, auth := range auths {
+
+			credentials := registryCredentials{username: auth.Username, password: auth.Password}
+			if credentials.username == "" && auth.Auth != "" {
+				decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+				if err != nil {
+					continue
+				}
+				credentials.username, credentials.password, _ = strings.Cut(string(decoded), ":")
+			}
+
+			// The first image pull secret of the ServiceAccount with credentials for a registry takes precedence
+			if normalizedKey := normalizeRegistryCredentialKey(key); normalizedKey != "" {
+				if _, exists := res[normalizedKey]; !exists {
+					res[normalizedKey] = credentials
+				}
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// normalizeRegistryCredentialKey returns the key of an image pull secret as the host of the registry, followed by the
+// repository path prefix (if any), for example 'https://quay.io/org/' becomes 'quay.io/org'.
+// - Docker Hub keys (for example, 'https://index.docker.io/v1/') become the host of the Docker Hub registry.
+func normalizeRegistryCredentialKey(key string) string {
+
+	key = strings.TrimSpace(key)
+	key = strings.TrimPrefix(strings.TrimPrefix(key, "https://"), "http://")
+	key = strings.Trim(key, "/")
+
+	host, path, _ := strings.Cut(key, "/")
+	if path == "v1" || path == "v2" {
+		// The path of the (legacy) registry API is not a repository path
+		path = ""
+	}
+
+	for _, alias := range dockerHubAliases {
+		if host == alias {
+			host = dockerHubRegistry
+			break
+		}
+	}
+
+	if host == "" || path == "" {
+		return host
+	}
+	return host + "/" + path
+}
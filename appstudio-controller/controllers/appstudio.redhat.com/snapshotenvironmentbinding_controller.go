@@ -91,6 +91,8 @@ type SnapshotEnvironmentBindingReconciler struct {
 //+kubebuilder:rbac:groups=appstudio.redhat.com,resources=environments,verbs=get;list;watch;
 //+kubebuilder:rbac:groups=managed-gitops.redhat.com,resources=gitopsdeployments,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch;
+//+kubebuilder:rbac:groups=route.openshift.io,resources=routes,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch
 
 //+kubebuilder:rbac:groups=appstudio.redhat.com,resources=applications,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=appstudio.redhat.com,resources=applications/status,verbs=get;update;patch
@@ -127,6 +129,11 @@ func (r *SnapshotEnvironmentBindingReconciler) Reconcile(ctx context.Context, re
 		return ctrl.Result{}, nil
 	}
 
+	// Binding is being deleted: delete the Routes of its deployment strategy, which are not owned by the binding.
+	if binding.DeletionTimestamp != nil {
+		return ctrl.Result{}, finalizeSnapshotEnvironmentBinding(ctx, &binding, rClient, log)
+	}
+
 	// If the associated application doesn't exist, delete the Binding
 	application := appstudioshared.Application{
 		ObjectMeta: metav1.ObjectMeta{
@@ -231,9 +238,26 @@ func (r *SnapshotEnvironmentBindingReconciler) Reconcile(ctx context.Context, re
 		return ctrl.Result{}, nil
 	}
 
+	// Determine how new Snapshots are rolled out to the Environment, see snapshotenvironmentbinding_strategy.go
+	strategy, err := getDeploymentStrategy(environment)
+	if err != nil {
+
+		if err := updateSEBReconciledStatusCondition(ctx, rClient, err.Error(),
+			&binding, metav1.ConditionFalse, SnapshotEnvironmentBindingReasonInvalidDeploymentStrategy, log); err != nil {
+
+			log.Error(err, "unable to update snapshotEnvironmentBinding status condition.")
+			return ctrl.Result{}, fmt.Errorf("unable to update snapshotEnvironmentBinding status condition. %v", err)
+		}
+
+		return ctrl.Result{}, nil
+	}
+
 	// map: componentName (string) -> expected GitOpsDeployment for that component name
 	expectedDeployments := map[string]apibackend.GitOpsDeployment{}
 
+	// map: componentName (string) -> status of that component
+	components := map[string]appstudioshared.BindingComponentStatus{}
+
 	for _, component := range binding.Status.Components {
 
 		// sanity test that there are no duplicate components by name
@@ -249,6 +273,8 @@ func (r *SnapshotEnvironmentBindingReconciler) Reconcile(ctx context.Context, re
 			return ctrl.Result{}, nil
 		}
 
+		components[component.Name] = component
+
 		var userDevErr gitopserrors.UserError
 		expectedDeployments[component.Name], userDevErr = generateExpectedGitOpsDeployment(ctx, component, binding, environment, rClient, log)
 
@@ -267,13 +293,52 @@ func (r *SnapshotEnvironmentBindingReconciler) Reconcile(ctx context.Context, re
 	}
 
 	// Delete any existing deployments which don't have a matching component
-	err := deleteUnmatchedDeployments(ctx, binding, expectedDeployments, rClient, log)
+	err = deleteUnmatchedDeployments(ctx, binding, expectedDeployments, rClient, log)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 
+	// If the Environment has no deployment strategy (or no longer has one), delete any deployments and Routes of a
+	// deployment strategy. The uncolored deployments are deleted by processDeploymentStrategy, once traffic has been
+	// switched to the colored deployments.
+	if strategy.name == "" {
+		if err := deleteColoredGitOpsDeployments(ctx, binding, rClient, log); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		if err := removeRolloutRoutes(ctx, &binding, &environment, rClient, log); err != nil {
+			log.Error(err, "unable to delete the Routes of Binding "+binding.Name)
+			return ctrl.Result{RequeueAfter: time.Second * 10}, err
+		}
+	}
+
+	// With a deployment strategy, traffic is switched by Routes in the target Namespace of the Environment
+	target := rolloutTarget{}
+	if strategy.name != "" {
+		needsTarget := hasRolloutRoutesFinalizer(binding)
+		for _, component := range components {
+			if component.GeneratedRouteName != "" {
+				needsTarget = true
+			}
+		}
+
+		if needsTarget {
+			target.namespace, target.client, err = getEnvironmentTargetNamespaceClient(ctx, environment, rClient)
+			if err != nil {
+				log.Error(err, "unable to retrieve the target Namespace of Environment "+environment.Name)
+				return ctrl.Result{RequeueAfter: time.Second * 10}, err
+			}
+
+			if err := reconcileRolloutRoutes(ctx, &binding, target, components, rClient, log); err != nil {
+				log.Error(err, "unable to reconcile the Routes of Binding "+binding.Name)
+				return ctrl.Result{RequeueAfter: time.Second * 10}, err
+			}
+		}
+	}
+
 	var statusField []appstudioshared.BindingStatusGitOpsDeployment
 	var allErrors error
+	var rollouts []componentRollout
 
 	// Sort the component names into a deterministic (lexicographical) order, so that they are always added to .status in that order
 	sortedComponentNames := []string{}
@@ -288,7 +353,20 @@ func (r *SnapshotEnvironmentBindingReconciler) Reconcile(ctx context.Context, re
 
 		expectedGitOpsDeployment := expectedDeployments[componentName]
 
-		if err := processExpectedGitOpsDeployment(ctx, expectedGitOpsDeployment, binding, rClient, log); err != nil {
+		// The GitOpsDeployment that is reported in the status of the binding
+		statusGitOpsDeployment := expectedGitOpsDeployment
+
+		var err error
+		if strategy.name == "" {
+			err = processExpectedGitOpsDeployment(ctx, expectedGitOpsDeployment, binding, rClient, log)
+		} else {
+			var rollout componentRollout
+			rollout, err = processDeploymentStrategy(ctx, expectedGitOpsDeployment, components[componentName], binding, strategy, target, rClient, log)
+			statusGitOpsDeployment.Name = rollout.deploymentName
+			rollouts = append(rollouts, rollout)
+		}
+
+		if err != nil {
 
 			errorMessage := fmt.Sprintf("error occurred while processing expected GitOpsDeployment '%s' for SnapshotEnvironmentBinding",
 				expectedGitOpsDeployment.Name)
@@ -305,11 +383,9 @@ func (r *SnapshotEnvironmentBindingReconciler) Reconcile(ctx context.Context, re
 			deployment := apibackend.GitOpsDeployment{}
 			newStatusEntry := appstudioshared.BindingStatusGitOpsDeployment{
 				ComponentName:    componentName,
-				GitOpsDeployment: expectedGitOpsDeployment.Name,
+				GitOpsDeployment: statusGitOpsDeployment.Name,
 			}
-			// gosec check doesn't allow taking the address of a loop variable. Here, reassign the loop variable.
-			expectedGitOpsDeployment := expectedGitOpsDeployment
-			if err := rClient.Get(ctx, client.ObjectKeyFromObject(&expectedGitOpsDeployment), &deployment); err == nil {
+			if err := rClient.Get(ctx, client.ObjectKeyFromObject(&statusGitOpsDeployment), &deployment); err == nil {
 				newStatusEntry.GitOpsDeploymentSyncStatus = string(deployment.Status.Sync.Status)
 				newStatusEntry.GitOpsDeploymentHealthStatus = string(deployment.Status.Health.Status)
 				newStatusEntry.GitOpsDeploymentCommitID = deployment.Status.Sync.Revision
@@ -326,6 +402,16 @@ func (r *SnapshotEnvironmentBindingReconciler) Reconcile(ctx context.Context, re
 
 	// Update the status field with statusField vars (even if an error occurred)
 	binding.Status.GitOpsDeployments = statusField
+	updateBindingRolloutCondition(&binding, strategy, rollouts)
+
+	// Reconcile again when the next step of a rollout is due
+	res := ctrl.Result{}
+	for _, rollout := range rollouts {
+		if rollout.requeueAfter > 0 && (res.RequeueAfter == 0 || rollout.requeueAfter < res.RequeueAfter) {
+			res.RequeueAfter = rollout.requeueAfter
+		}
+	}
+
 	if err := addComponentDeploymentCondition(ctx, &binding, rClient, log); err != nil {
 		log.Error(err, "unable to update component deployment condition for Binding "+binding.Name)
 		return ctrl.Result{}, fmt.Errorf("unable to update component deployment condition for SnapshotEnvironmentBinding. Error: %w", err)
@@ -334,7 +420,7 @@ func (r *SnapshotEnvironmentBindingReconciler) Reconcile(ctx context.Context, re
 	// If our update logic did not modify the binding at all, there is no need to update the status
 	if reflect.DeepEqual(binding.Status, originalBinding.Status) {
 		log.V(logutil.LogLevel_Debug).Info("Skipping update of status of SnapshotEnvironmentBinding, as the resource did not change")
-		return ctrl.Result{}, nil
+		return res, nil
 	}
 
 	log.Info("Updating SnapshotEnvironmentBinding status")
@@ -347,7 +433,7 @@ func (r *SnapshotEnvironmentBindingReconciler) Reconcile(ctx context.Context, re
 		return ctrl.Result{}, fmt.Errorf("unable to update SnapshotEnvironmentBinding status. Error: %w", err)
 	}
 
-	return ctrl.Result{}, nil
+	return res, nil
 }
 
 // Delete all Deployments which are associated with the given binding but are not contained in the
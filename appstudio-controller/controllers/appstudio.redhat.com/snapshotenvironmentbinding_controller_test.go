@@ -10,6 +10,8 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	routev1 "github.com/openshift/api/route/v1"
+
 	corev1 "k8s.io/api/core/v1"
 	apierr "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -2535,6 +2537,496 @@ var _ = Describe("SnapshotEnvironmentBinding Reconciler Tests", func() {
 			),
 		)
 	})
+
+	Context("Testing SnapshotEnvironmentBindingReconciler with a deployment strategy", func() {
+
+		var ctx context.Context
+		var request reconcile.Request
+		var binding *appstudiosharedv1.SnapshotEnvironmentBinding
+		var bindingReconciler SnapshotEnvironmentBindingReconciler
+		var environment appstudiosharedv1.Environment
+
+		BeforeEach(func() {
+			ctx = context.Background()
+
+			scheme,
+				argocdNamespace,
+				kubesystemNamespace,
+				apiNamespace,
+				err := tests.GenericTestSetup()
+			Expect(err).ToNot(HaveOccurred())
+
+			err = appstudiosharedv1.AddToScheme(scheme)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = routev1.Install(scheme)
+			Expect(err).ToNot(HaveOccurred())
+
+			application := appstudiosharedv1.Application{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "new-demo-app",
+					Namespace: apiNamespace.Name,
+				},
+				Spec: appstudiosharedv1.ApplicationSpec{
+					DisplayName: "my-application",
+				},
+			}
+
+			environment = appstudiosharedv1.Environment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "staging",
+					Namespace: apiNamespace.Name,
+					Annotations: map[string]string{
+						DeploymentAnnotationStrategy: DeploymentStrategyBlueGreen,
+					},
+				},
+				Spec: appstudiosharedv1.EnvironmentSpec{
+					DisplayName:        "my-environment",
+					DeploymentStrategy: appstudiosharedv1.DeploymentStrategy_AppStudioAutomated,
+				},
+			}
+
+			binding = &appstudiosharedv1.SnapshotEnvironmentBinding{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "appa-staging-binding",
+					Namespace: apiNamespace.Name,
+				},
+				Spec: appstudiosharedv1.SnapshotEnvironmentBindingSpec{
+					Application: "new-demo-app",
+					Environment: "staging",
+					Snapshot:    "my-snapshot",
+					Components: []appstudiosharedv1.BindingComponent{
+						{Name: "component-a"},
+					},
+				},
+				Status: appstudiosharedv1.SnapshotEnvironmentBindingStatus{
+					Components: []appstudiosharedv1.BindingComponentStatus{
+						{
+							Name:               "component-a",
+							GeneratedRouteName: "component-a-route",
+							GitOpsRepository: appstudiosharedv1.BindingComponentGitOpsRepository{
+								URL:      "https://github.com/redhat-appstudio/managed-gitops",
+								Branch:   "main",
+								Path:     "resources/test-data/sample-gitops-repository/components/componentA/overlays/staging",
+								CommitID: "commit-1",
+							},
+						},
+					},
+				},
+			}
+
+			objects := []client.Object{apiNamespace, argocdNamespace, kubesystemNamespace, &application}
+
+			// The Route and Service that are deployed by the GitOpsDeployment of each color
+			for _, color := range []string{deploymentColorBlue, deploymentColorGreen} {
+				objects = append(objects,
+					&routev1.Route{
+						ObjectMeta: metav1.ObjectMeta{Name: "component-a-route-" + color, Namespace: apiNamespace.Name},
+						Spec: routev1.RouteSpec{
+							To: routev1.RouteTargetReference{Kind: "Service", Name: "component-a-" + color},
+						},
+					},
+					&corev1.Service{
+						ObjectMeta: metav1.ObjectMeta{Name: "component-a-" + color, Namespace: apiNamespace.Name},
+					})
+			}
+
+			k8sClient := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(objects...).
+				Build()
+
+			bindingReconciler = SnapshotEnvironmentBindingReconciler{Client: k8sClient, Scheme: scheme}
+
+			request = newRequest(apiNamespace.Name, binding.Name)
+		})
+
+		deploymentName := func(color string) string {
+			return generateColoredGitOpsDeploymentName(GenerateBindingGitOpsDeploymentName(*binding, "component-a"), color)
+		}
+
+		// reconcileBinding reconciles the binding, and returns the reconciled binding
+		reconcileBinding := func() (appstudiosharedv1.SnapshotEnvironmentBinding, reconcile.Result) {
+			res, err := bindingReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			reconciledBinding := appstudiosharedv1.SnapshotEnvironmentBinding{}
+			Expect(bindingReconciler.Get(ctx, request.NamespacedName, &reconciledBinding)).To(Succeed())
+
+			return reconciledBinding, res
+		}
+
+		// getRolloutCondition returns the Rollout condition of the binding, or nil if it has none
+		getRolloutCondition := func(reconciledBinding appstudiosharedv1.SnapshotEnvironmentBinding) *metav1.Condition {
+			for index := range reconciledBinding.Status.BindingConditions {
+				if reconciledBinding.Status.BindingConditions[index].Type == SnapshotEnvironmentBindingConditionRollout {
+					return &reconciledBinding.Status.BindingConditions[index]
+				}
+			}
+			return nil
+		}
+
+		getRoute := func() routev1.Route {
+			route := routev1.Route{}
+			Expect(bindingReconciler.Get(ctx, types.NamespacedName{Namespace: binding.Namespace, Name: "component-a-route"}, &route)).To(Succeed())
+			return route
+		}
+
+		// updateCommit simulates a new commit to the GitOps repository, for a new Snapshot
+		updateCommit := func(commitID string) {
+			Expect(bindingReconciler.Get(ctx, request.NamespacedName, binding)).To(Succeed())
+			binding.Status.Components[0].GitOpsRepository.CommitID = commitID
+			Expect(bindingReconciler.Status().Update(ctx, binding)).To(Succeed())
+		}
+
+		// updateDeploymentStatus simulates Argo CD deploying the given commit
+		updateDeploymentStatus := func(name string, commitID string, health apibackend.HealthStatusCode) {
+			gitopsDeployment := apibackend.GitOpsDeployment{}
+			Expect(bindingReconciler.Get(ctx, types.NamespacedName{Namespace: binding.Namespace, Name: name}, &gitopsDeployment)).To(Succeed())
+			gitopsDeployment.Status.Sync.Status = apibackend.SyncStatusCodeSynced
+			gitopsDeployment.Status.Sync.Revision = commitID
+			gitopsDeployment.Status.Health.Status = health
+			Expect(bindingReconciler.Update(ctx, &gitopsDeployment)).To(Succeed())
+		}
+
+		// deployFirstCommit deploys the first commit as the active 'blue' GitOpsDeployment
+		deployFirstCommit := func() {
+			reconcileBinding()
+			updateDeploymentStatus(deploymentName("blue"), "commit-1", apibackend.HeathStatusCodeHealthy)
+			reconcileBinding()
+		}
+
+		It("should deploy the first Snapshot directly, and switch traffic to a new Snapshot once it is healthy, with the BlueGreen strategy", func() {
+			Expect(bindingReconciler.Create(ctx, &environment)).To(Succeed())
+			Expect(bindingReconciler.Create(ctx, binding)).To(Succeed())
+
+			By("deploying the first commit as the 'blue' GitOpsDeployment")
+			reconciledBinding, _ := reconcileBinding()
+
+			blue := apibackend.GitOpsDeployment{}
+			Expect(bindingReconciler.Get(ctx, types.NamespacedName{Namespace: binding.Namespace, Name: deploymentName("blue")}, &blue)).To(Succeed())
+			Expect(blue.Spec.Source.TargetRevision).To(Equal("commit-1"))
+			Expect(blue.Spec.Source.Kustomize.NameSuffix).To(Equal("-blue"))
+			Expect(blue.Spec.Source.Kustomize.CommonLabels).To(HaveKeyWithValue(deploymentColorLabelKey, "blue"))
+			Expect(blue.Labels).To(HaveKeyWithValue(deploymentColorLabelKey, "blue"))
+			Expect(blue.Annotations).ToNot(HaveKey(deploymentAnnotationActive))
+
+			Expect(reconciledBinding.Status.GitOpsDeployments).To(HaveLen(1))
+			Expect(reconciledBinding.Status.GitOpsDeployments[0].GitOpsDeployment).To(Equal(deploymentName("blue")))
+			Expect(reconciledBinding.Finalizers).To(ContainElement(deploymentFinalizerRolloutRoutes))
+
+			err := bindingReconciler.Get(ctx, types.NamespacedName{Namespace: binding.Namespace, Name: "component-a-route"}, &routev1.Route{})
+			Expect(apierr.IsNotFound(err)).To(BeTrue())
+			Expect(getRolloutCondition(reconciledBinding).Message).To(Equal("component-a: waiting for GitOpsDeployment '" + deploymentName("blue") + "' to be Synced/Healthy"))
+
+			By("making the 'blue' GitOpsDeployment active, and switching traffic to it, once it is Synced/Healthy")
+			updateDeploymentStatus(deploymentName("blue"), "commit-1", apibackend.HeathStatusCodeHealthy)
+			reconciledBinding, _ = reconcileBinding()
+
+			Expect(bindingReconciler.Get(ctx, client.ObjectKeyFromObject(&blue), &blue)).To(Succeed())
+			Expect(blue.Annotations).To(HaveKeyWithValue(deploymentAnnotationActive, "true"))
+
+			route := getRoute()
+			Expect(route.Spec.To.Name).To(Equal("component-a-blue"))
+			Expect(route.Spec.AlternateBackends).To(BeEmpty())
+			Expect(route.Labels).To(HaveKeyWithValue(deploymentRolloutRouteLabelKey, "true"))
+
+			Expect(getRolloutCondition(reconciledBinding)).ToNot(BeNil())
+			Expect(getRolloutCondition(reconciledBinding).Status).To(Equal(metav1.ConditionTrue))
+			Expect(getRolloutCondition(reconciledBinding).Reason).To(Equal(SnapshotEnvironmentBindingReasonRolloutComplete))
+
+			By("deploying a new commit alongside the active GitOpsDeployment")
+			updateCommit("commit-2")
+			reconciledBinding, _ = reconcileBinding()
+
+			green := apibackend.GitOpsDeployment{}
+			Expect(bindingReconciler.Get(ctx, types.NamespacedName{Namespace: binding.Namespace, Name: deploymentName("green")}, &green)).To(Succeed())
+			Expect(green.Spec.Source.TargetRevision).To(Equal("commit-2"))
+			Expect(green.Spec.Source.Kustomize.NameSuffix).To(Equal("-green"))
+			Expect(green.Annotations).ToNot(HaveKey(deploymentAnnotationActive))
+
+			Expect(reconciledBinding.Status.GitOpsDeployments[0].GitOpsDeployment).To(Equal(deploymentName("blue")))
+			Expect(getRoute().Spec.To.Name).To(Equal("component-a-blue"))
+
+			condition := getRolloutCondition(reconciledBinding)
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			Expect(condition.Reason).To(Equal(SnapshotEnvironmentBindingReasonRolloutProgressing))
+			Expect(condition.Message).To(Equal("component-a: waiting for GitOpsDeployment '" + deploymentName("green") + "' to be Synced/Healthy"))
+
+			message, failed := getBindingRolloutStatus(reconciledBinding)
+			Expect(message).To(ContainSubstring("Waiting for the rollout of the Snapshot to complete"))
+			Expect(failed).To(BeFalse())
+
+			By("switching traffic to the new GitOpsDeployment once it is Synced/Healthy, and deleting the previous GitOpsDeployment")
+			updateDeploymentStatus(deploymentName("green"), "commit-2", apibackend.HeathStatusCodeHealthy)
+			reconciledBinding, _ = reconcileBinding()
+
+			Expect(getRoute().Spec.To.Name).To(Equal("component-a-green"))
+
+			Expect(bindingReconciler.Get(ctx, client.ObjectKeyFromObject(&green), &green)).To(Succeed())
+			Expect(green.Annotations).To(HaveKeyWithValue(deploymentAnnotationActive, "true"))
+
+			err = bindingReconciler.Get(ctx, client.ObjectKeyFromObject(&blue), &blue)
+			Expect(apierr.IsNotFound(err)).To(BeTrue())
+
+			Expect(reconciledBinding.Status.GitOpsDeployments[0].GitOpsDeployment).To(Equal(deploymentName("green")))
+			Expect(getRolloutCondition(reconciledBinding).Status).To(Equal(metav1.ConditionTrue))
+
+			message, _ = getBindingRolloutStatus(reconciledBinding)
+			Expect(message).To(BeEmpty())
+		})
+
+		It("should shift traffic to a new Snapshot in steps, with the Canary strategy", func() {
+			environment.Annotations[DeploymentAnnotationStrategy] = DeploymentStrategyCanary
+			environment.Annotations[DeploymentAnnotationCanaryWeights] = "20,60"
+			environment.Annotations[DeploymentAnnotationCanaryStepDuration] = "1h"
+			Expect(bindingReconciler.Create(ctx, &environment)).To(Succeed())
+			Expect(bindingReconciler.Create(ctx, binding)).To(Succeed())
+
+			deployFirstCommit()
+
+			updateCommit("commit-2")
+			reconcileBinding()
+			updateDeploymentStatus(deploymentName("green"), "commit-2", apibackend.HeathStatusCodeHealthy)
+
+			// expireCanaryStep simulates the canary step duration passing
+			expireCanaryStep := func() {
+				route := getRoute()
+				route.Annotations[deploymentAnnotationCanaryStepStarted] = time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)
+				Expect(bindingReconciler.Update(ctx, &route)).To(Succeed())
+			}
+
+			By("shifting the first step of traffic to the new GitOpsDeployment")
+			reconciledBinding, res := reconcileBinding()
+
+			route := getRoute()
+			Expect(route.Spec.To.Name).To(Equal("component-a-blue"))
+			Expect(*route.Spec.To.Weight).To(Equal(int32(80)))
+			Expect(route.Spec.AlternateBackends).To(HaveLen(1))
+			Expect(route.Spec.AlternateBackends[0].Name).To(Equal("component-a-green"))
+			Expect(*route.Spec.AlternateBackends[0].Weight).To(Equal(int32(20)))
+			Expect(route.Annotations).To(HaveKey(deploymentAnnotationCanaryStepStarted))
+
+			Expect(res.RequeueAfter).To(BeNumerically(">", 59*time.Minute))
+			Expect(getRolloutCondition(reconciledBinding).Message).To(Equal("component-a: canary step 1 of 2, 20% of traffic to GitOpsDeployment '" + deploymentName("green") + "'"))
+
+			By("not shifting more traffic before the step duration has passed")
+			reconcileBinding()
+			Expect(*getRoute().Spec.AlternateBackends[0].Weight).To(Equal(int32(20)))
+
+			By("shifting the next step of traffic once the step duration has passed")
+			expireCanaryStep()
+			reconciledBinding, _ = reconcileBinding()
+
+			route = getRoute()
+			Expect(*route.Spec.To.Weight).To(Equal(int32(40)))
+			Expect(*route.Spec.AlternateBackends[0].Weight).To(Equal(int32(60)))
+			Expect(getRolloutCondition(reconciledBinding).Message).To(ContainSubstring("canary step 2 of 2, 60% of traffic"))
+
+			By("switching all traffic once the last step has passed")
+			expireCanaryStep()
+			reconciledBinding, _ = reconcileBinding()
+
+			route = getRoute()
+			Expect(route.Spec.To.Name).To(Equal("component-a-green"))
+			Expect(*route.Spec.To.Weight).To(Equal(int32(100)))
+			Expect(route.Spec.AlternateBackends).To(BeEmpty())
+			Expect(route.Annotations).ToNot(HaveKey(deploymentAnnotationCanaryStepStarted))
+
+			Expect(reconciledBinding.Status.GitOpsDeployments[0].GitOpsDeployment).To(Equal(deploymentName("green")))
+			Expect(getRolloutCondition(reconciledBinding).Status).To(Equal(metav1.ConditionTrue))
+
+			err := bindingReconciler.Get(ctx, types.NamespacedName{Namespace: binding.Namespace, Name: deploymentName("blue")}, &apibackend.GitOpsDeployment{})
+			Expect(apierr.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("should return all traffic to the active GitOpsDeployment, and report a failed rollout, if the new GitOpsDeployment is Degraded", func() {
+			environment.Annotations[DeploymentAnnotationStrategy] = DeploymentStrategyCanary
+			Expect(bindingReconciler.Create(ctx, &environment)).To(Succeed())
+			Expect(bindingReconciler.Create(ctx, binding)).To(Succeed())
+
+			deployFirstCommit()
+
+			updateCommit("commit-2")
+			reconcileBinding()
+			updateDeploymentStatus(deploymentName("green"), "commit-2", apibackend.HeathStatusCodeHealthy)
+
+			reconcileBinding()
+			Expect(*getRoute().Spec.AlternateBackends[0].Weight).To(Equal(defaultCanaryWeights[0]))
+
+			updateDeploymentStatus(deploymentName("green"), "commit-2", apibackend.HeathStatusCodeDegraded)
+			reconciledBinding, _ := reconcileBinding()
+
+			route := getRoute()
+			Expect(route.Spec.To.Name).To(Equal("component-a-blue"))
+			Expect(*route.Spec.To.Weight).To(Equal(int32(100)))
+			Expect(route.Spec.AlternateBackends).To(BeEmpty())
+
+			condition := getRolloutCondition(reconciledBinding)
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			Expect(condition.Reason).To(Equal(SnapshotEnvironmentBindingReasonRolloutFailed))
+			Expect(condition.Message).To(Equal("component-a: GitOpsDeployment '" + deploymentName("green") + "' is Degraded"))
+
+			_, failed := getBindingRolloutStatus(reconciledBinding)
+			Expect(failed).To(BeTrue())
+
+			By("deleting the new GitOpsDeployment if the binding returns to the previous Snapshot")
+			updateCommit("commit-1")
+			reconciledBinding, _ = reconcileBinding()
+
+			err := bindingReconciler.Get(ctx, types.NamespacedName{Namespace: binding.Namespace, Name: deploymentName("green")}, &apibackend.GitOpsDeployment{})
+			Expect(apierr.IsNotFound(err)).To(BeTrue())
+			Expect(getRolloutCondition(reconciledBinding).Status).To(Equal(metav1.ConditionTrue))
+		})
+
+		It("should fail the rollout if the Route or Service deployed by a GitOpsDeployment does not exist", func() {
+			Expect(bindingReconciler.Create(ctx, &environment)).To(Succeed())
+			Expect(bindingReconciler.Create(ctx, binding)).To(Succeed())
+
+			By("failing the first rollout if the 'blue' GitOpsDeployment has no Route")
+			blueRoute := routev1.Route{ObjectMeta: metav1.ObjectMeta{Name: "component-a-route-blue", Namespace: binding.Namespace}}
+			Expect(bindingReconciler.Get(ctx, client.ObjectKeyFromObject(&blueRoute), &blueRoute)).To(Succeed())
+			Expect(bindingReconciler.Delete(ctx, &blueRoute)).To(Succeed())
+
+			deployFirstCommit()
+			reconciledBinding, _ := reconcileBinding()
+
+			condition := getRolloutCondition(reconciledBinding)
+			Expect(condition.Reason).To(Equal(SnapshotEnvironmentBindingReasonRolloutFailed))
+			Expect(condition.Message).To(Equal("component-a: Route 'component-a-route-blue' of the blue GitOpsDeployment does not exist"))
+
+			err := bindingReconciler.Get(ctx, types.NamespacedName{Namespace: binding.Namespace, Name: "component-a-route"}, &routev1.Route{})
+			Expect(apierr.IsNotFound(err)).To(BeTrue())
+
+			By("switching traffic to the 'blue' GitOpsDeployment once its Route exists")
+			blueRoute.ResourceVersion = ""
+			Expect(bindingReconciler.Create(ctx, &blueRoute)).To(Succeed())
+			reconciledBinding, _ = reconcileBinding()
+
+			Expect(getRoute().Spec.To.Name).To(Equal("component-a-blue"))
+			Expect(getRolloutCondition(reconciledBinding).Status).To(Equal(metav1.ConditionTrue))
+
+			By("failing the rollout of a new commit if the Service of the 'green' GitOpsDeployment does not exist")
+			Expect(bindingReconciler.Delete(ctx, &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "component-a-green", Namespace: binding.Namespace},
+			})).To(Succeed())
+
+			updateCommit("commit-2")
+			reconcileBinding()
+			updateDeploymentStatus(deploymentName("green"), "commit-2", apibackend.HeathStatusCodeHealthy)
+			reconciledBinding, _ = reconcileBinding()
+
+			condition = getRolloutCondition(reconciledBinding)
+			Expect(condition.Reason).To(Equal(SnapshotEnvironmentBindingReasonRolloutFailed))
+			Expect(condition.Message).To(Equal("component-a: Service 'component-a-green' of Route 'component-a-route-green' does not exist"))
+
+			Expect(getRoute().Spec.To.Name).To(Equal("component-a-blue"))
+			Expect(bindingReconciler.Get(ctx, types.NamespacedName{Namespace: binding.Namespace, Name: deploymentName("blue")},
+				&apibackend.GitOpsDeployment{})).To(Succeed())
+		})
+
+		It("should only delete the previous GitOpsDeployment of a component once traffic has been switched, when a deployment strategy is added", func() {
+			delete(environment.Annotations, DeploymentAnnotationStrategy)
+			Expect(bindingReconciler.Create(ctx, &environment)).To(Succeed())
+			Expect(bindingReconciler.Create(ctx, binding)).To(Succeed())
+
+			reconcileBinding()
+
+			uncolored := apibackend.GitOpsDeployment{}
+			Expect(bindingReconciler.Get(ctx, types.NamespacedName{Namespace: binding.Namespace,
+				Name: GenerateBindingGitOpsDeploymentName(*binding, "component-a")}, &uncolored)).To(Succeed())
+
+			// The Route of the component, as deployed by Argo CD for the previous GitOpsDeployment
+			Expect(bindingReconciler.Create(ctx, &routev1.Route{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "component-a-route",
+					Namespace: binding.Namespace,
+					Labels:    map[string]string{argoCDInstanceLabelKey: "previous-application"},
+				},
+				Spec: routev1.RouteSpec{To: routev1.RouteTargetReference{Kind: "Service", Name: "component-a"}},
+			})).To(Succeed())
+
+			By("keeping the previous GitOpsDeployment until the 'blue' GitOpsDeployment is ready for traffic")
+			environment.Annotations[DeploymentAnnotationStrategy] = DeploymentStrategyBlueGreen
+			Expect(bindingReconciler.Update(ctx, &environment)).To(Succeed())
+
+			reconcileBinding()
+
+			Expect(bindingReconciler.Get(ctx, client.ObjectKeyFromObject(&uncolored), &uncolored)).To(Succeed())
+			Expect(getRoute().Spec.To.Name).To(Equal("component-a"))
+
+			By("deleting the previous GitOpsDeployment once traffic has been switched, without Argo CD tracking the Route")
+			updateDeploymentStatus(deploymentName("blue"), "commit-1", apibackend.HeathStatusCodeHealthy)
+			reconcileBinding()
+
+			route := getRoute()
+			Expect(route.Spec.To.Name).To(Equal("component-a-blue"))
+			Expect(route.Labels).ToNot(HaveKey(argoCDInstanceLabelKey))
+
+			err := bindingReconciler.Get(ctx, client.ObjectKeyFromObject(&uncolored), &uncolored)
+			Expect(apierr.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("should delete the Route of the component when the binding is deleted", func() {
+			Expect(bindingReconciler.Create(ctx, &environment)).To(Succeed())
+			Expect(bindingReconciler.Create(ctx, binding)).To(Succeed())
+
+			deployFirstCommit()
+			getRoute()
+
+			Expect(bindingReconciler.Get(ctx, request.NamespacedName, binding)).To(Succeed())
+			Expect(bindingReconciler.Delete(ctx, binding)).To(Succeed())
+
+			_, err := bindingReconciler.Reconcile(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = bindingReconciler.Get(ctx, types.NamespacedName{Namespace: binding.Namespace, Name: "component-a-route"}, &routev1.Route{})
+			Expect(apierr.IsNotFound(err)).To(BeTrue())
+
+			By("removing the finalizer, so that the binding is deleted")
+			err = bindingReconciler.Get(ctx, request.NamespacedName, &appstudiosharedv1.SnapshotEnvironmentBinding{})
+			Expect(apierr.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("should replace the GitOpsDeployments of the binding, and delete the Route, if the deployment strategy of the Environment is removed", func() {
+			Expect(bindingReconciler.Create(ctx, &environment)).To(Succeed())
+			Expect(bindingReconciler.Create(ctx, binding)).To(Succeed())
+
+			deployFirstCommit()
+			getRoute()
+
+			delete(environment.Annotations, DeploymentAnnotationStrategy)
+			Expect(bindingReconciler.Update(ctx, &environment)).To(Succeed())
+
+			reconciledBinding, _ := reconcileBinding()
+
+			err := bindingReconciler.Get(ctx, types.NamespacedName{Namespace: binding.Namespace, Name: deploymentName("blue")}, &apibackend.GitOpsDeployment{})
+			Expect(apierr.IsNotFound(err)).To(BeTrue())
+
+			err = bindingReconciler.Get(ctx, types.NamespacedName{Namespace: binding.Namespace, Name: "component-a-route"}, &routev1.Route{})
+			Expect(apierr.IsNotFound(err)).To(BeTrue())
+			Expect(reconciledBinding.Finalizers).ToNot(ContainElement(deploymentFinalizerRolloutRoutes))
+
+			gitopsDeployment := apibackend.GitOpsDeployment{}
+			Expect(bindingReconciler.Get(ctx, types.NamespacedName{Namespace: binding.Namespace,
+				Name: GenerateBindingGitOpsDeploymentName(*binding, "component-a")}, &gitopsDeployment)).To(Succeed())
+			Expect(gitopsDeployment.Spec.Source.TargetRevision).To(Equal("main"))
+
+			Expect(getRolloutCondition(reconciledBinding)).To(BeNil())
+		})
+
+		It("should report an invalid deployment strategy in the Reconciled condition", func() {
+			environment.Annotations[DeploymentAnnotationCanaryWeights] = "50,20"
+			Expect(bindingReconciler.Create(ctx, &environment)).To(Succeed())
+			Expect(bindingReconciler.Create(ctx, binding)).To(Succeed())
+
+			reconcileBinding()
+
+			checkStatusConditionOfEnvironmentBinding(ctx, bindingReconciler.Client, binding,
+				"invalid value for annotation '"+DeploymentAnnotationCanaryWeights+"' of Environment 'staging': 50,20",
+				metav1.ConditionFalse, SnapshotEnvironmentBindingReasonInvalidDeploymentStrategy)
+		})
+	})
 })
 
 // newRequest contains the information necessary to reconcile a Kubernetes object.
@@ -0,0 +1,999 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package appstudioredhatcom
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	routev1 "github.com/openshift/api/route/v1"
+	appstudioshared "github.com/redhat-appstudio/application-api/api/v1alpha1"
+	apibackend "github.com/redhat-appstudio/managed-gitops/backend-shared/apis/managed-gitops/v1alpha1"
+	logutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util/log"
+	corev1 "k8s.io/api/core/v1"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// This file is responsible for rolling out a new Snapshot to an Environment with a deployment strategy.
+//
+// By default, a binding has a single GitOpsDeployment per component, which is updated in place when the GitOps
+// repository changes. If the Environment has a deployment strategy annotation, each component instead has up to two
+// GitOpsDeployments, 'blue' and 'green', each pinned to a commit of the GitOps repository:
+// - The 'active' GitOpsDeployment deploys the current commit, and receives the traffic of the component.
+// - When the commit changes, the other GitOpsDeployment deploys the new commit alongside the active one.
+// - BlueGreen: once the new GitOpsDeployment is Synced/Healthy, all traffic is switched to it.
+// - Canary: once the new GitOpsDeployment is Synced/Healthy, traffic is shifted to it in steps (for example, 10% then 50%), and then switched to it.
+// - Once traffic is switched, the new GitOpsDeployment becomes active, and the previous one is deleted.
+//
+// The resources of each GitOpsDeployment are given a '-blue'/'-green' name suffix (and a color label) by Kustomize.
+// Traffic is switched with a Route in the target Namespace of the Environment: the Route has the name of the Route
+// generated for the component, and targets the Service of the '<route>-<color>' Route that is deployed by each
+// GitOpsDeployment. Components without a generated Route are switched over without traffic management, so Canary then
+// behaves as BlueGreen.
+//
+// The Routes are not owned by the binding (they may be on another cluster), so they are deleted by a finalizer of the
+// binding, when the binding is deleted or the Environment no longer has a deployment strategy. When an Environment is
+// given a deployment strategy, the previous (uncolored) GitOpsDeployment of a component is only deleted once traffic
+// has been switched to a colored GitOpsDeployment.
+//
+// The progress of the rollout is reported by the Rollout condition of the binding.
+
+const (
+	// DeploymentAnnotationStrategy, on an Environment, is the strategy used to roll out a new Snapshot to the
+	// Environment: 'BlueGreen' or 'Canary'. If not set, the GitOpsDeployments of the Environment are updated in place.
+	DeploymentAnnotationStrategy = "deployment.appstudio.redhat.com/strategy"
+
+	// DeploymentAnnotationCanaryWeights, on an Environment, is the comma-separated list of traffic percentages that
+	// are shifted to the new Snapshot at each step of a Canary rollout (for example, '10,50'), before all traffic is switched.
+	DeploymentAnnotationCanaryWeights = "deployment.appstudio.redhat.com/canary-weights"
+
+	// DeploymentAnnotationCanaryStepDuration, on an Environment, is how long (for example, '5m') each step of a
+	// Canary rollout lasts before traffic is shifted further.
+	DeploymentAnnotationCanaryStepDuration = "deployment.appstudio.redhat.com/canary-step-duration"
+
+	// deploymentAnnotationActive is set by the controller on the GitOpsDeployment that receives the traffic of the component.
+	deploymentAnnotationActive = "deployment.appstudio.redhat.com/active"
+
+	// deploymentAnnotationCanaryStepStarted is set by the controller on the Route of the component, with the time
+	// that the current step of the Canary rollout started.
+	deploymentAnnotationCanaryStepStarted = "deployment.appstudio.redhat.com/canary-step-started"
+
+	// deploymentFinalizerRolloutRoutes is set by the controller on a binding with components whose traffic is switched
+	// with a Route, so that the Routes are deleted with the binding.
+	deploymentFinalizerRolloutRoutes = "deployment.appstudio.redhat.com/rollout-routes"
+)
+
+const (
+	DeploymentStrategyBlueGreen = "BlueGreen"
+	DeploymentStrategyCanary    = "Canary"
+
+	deploymentColorBlue  = "blue"
+	deploymentColorGreen = "green"
+
+	// deploymentColorLabelKey is the label that identifies the color of a GitOpsDeployment, and of the resources it deploys
+	deploymentColorLabelKey = appstudioLabelKey + "/deployment-color"
+
+	// deploymentRolloutRouteLabelKey identifies the Routes that are managed by the controller to switch traffic
+	deploymentRolloutRouteLabelKey = appstudioLabelKey + "/rollout-route"
+
+	// argoCDInstanceLabelKey is the label with which Argo CD tracks the resources that it deploys
+	argoCDInstanceLabelKey = "app.kubernetes.io/instance"
+)
+
+const (
+	SnapshotEnvironmentBindingConditionRollout = "Rollout"
+
+	SnapshotEnvironmentBindingReasonInvalidDeploymentStrategy = "InvalidDeploymentStrategy"
+	SnapshotEnvironmentBindingReasonRolloutProgressing        = "RolloutProgressing"
+	SnapshotEnvironmentBindingReasonRolloutComplete           = "RolloutComplete"
+	SnapshotEnvironmentBindingReasonRolloutFailed             = "RolloutFailed"
+)
+
+var (
+	defaultCanaryWeights      = []int32{10, 50}
+	defaultCanaryStepDuration = 5 * time.Minute
+)
+
+// deploymentStrategy is the deployment strategy of an Environment, as configured by its annotations.
+type deploymentStrategy struct {
+	// name is DeploymentStrategyBlueGreen or DeploymentStrategyCanary, or empty if the Environment has no deployment strategy.
+	name string
+
+	// canaryWeights is the percentage of traffic that is shifted to the new GitOpsDeployment at each step of a Canary rollout.
+	canaryWeights []int32
+
+	// canaryStepDuration is how long each step of a Canary rollout lasts.
+	canaryStepDuration time.Duration
+}
+
+// componentRollout is the progress of the rollout of a single component.
+type componentRollout struct {
+	componentName string
+
+	// deploymentName is the GitOpsDeployment that is reported in the status of the binding: the active GitOpsDeployment.
+	deploymentName string
+
+	complete bool
+	failed   bool
+
+	// message describes what the rollout is waiting for, if it is not complete.
+	message string
+
+	// requeueAfter is non-zero if the rollout must be reconciled again after a time, for example for the next Canary step.
+	requeueAfter time.Duration
+}
+
+// rolloutTarget is the target Namespace of the Environment, where the Routes of the components are managed.
+type rolloutTarget struct {
+	namespace string
+	client    client.Client
+}
+
+// getDeploymentStrategy returns the deployment strategy configured by the annotations of the Environment.
+func getDeploymentStrategy(environment appstudioshared.Environment) (deploymentStrategy, error) {
+
+	res := deploymentStrategy{
+		canaryWeights:      defaultCanaryWeights,
+		canaryStepDuration: defaultCanaryStepDuration,
+	}
+
+	switch value := environment.Annotations[DeploymentAnnotationStrategy]; {
+	case value == "":
+		return res, nil
+	case strings.EqualFold(value, DeploymentStrategyBlueGreen):
+		res.name = DeploymentStrategyBlueGreen
+	case strings.EqualFold(value, DeploymentStrategyCanary):
+		res.name = DeploymentStrategyCanary
+	default:
+		return res, fmt.Errorf("invalid value for annotation '%s' of Environment '%s': %s", DeploymentAnnotationStrategy, environment.Name, value)
+	}
+
+	if value := environment.Annotations[DeploymentAnnotationCanaryWeights]; value != "" {
+		res.canaryWeights = []int32{}
+
+		for _, weightString := range strings.Split(value, ",") {
+			weight, err := strconv.ParseInt(strings.TrimSpace(weightString), 10, 32)
+
+			// Each step must shift more traffic than the previous step, and some traffic must remain with the active GitOpsDeployment.
+			if err != nil || weight <= 0 || weight >= 100 ||
+				(len(res.canaryWeights) > 0 && int32(weight) <= res.canaryWeights[len(res.canaryWeights)-1]) {
+				return res, fmt.Errorf("invalid value for annotation '%s' of Environment '%s': %s", DeploymentAnnotationCanaryWeights, environment.Name, value)
+			}
+			res.canaryWeights = append(res.canaryWeights, int32(weight))
+		}
+	}
+
+	if value := environment.Annotations[DeploymentAnnotationCanaryStepDuration]; value != "" {
+		duration, err := time.ParseDuration(value)
+		if err != nil || duration <= 0 {
+			return res, fmt.Errorf("invalid value for annotation '%s' of Environment '%s': %s", DeploymentAnnotationCanaryStepDuration, environment.Name, value)
+		}
+		res.canaryStepDuration = duration
+	}
+
+	return res, nil
+}
+
+// processDeploymentStrategy rolls out the GitOpsDeployment expected for a component, using the deployment strategy
+// of the Environment, and returns the progress of the rollout.
+func processDeploymentStrategy(ctx context.Context, expectedGitOpsDeployment apibackend.GitOpsDeployment, component appstudioshared.BindingComponentStatus,
+	binding appstudioshared.SnapshotEnvironmentBinding, strategy deploymentStrategy, target rolloutTarget,
+	k8sClient client.Client, logParam logr.Logger) (componentRollout, error) {
+
+	log := logParam.WithValues("component", component.Name, "strategy", strategy.name)
+
+	res, err := rolloutComponent(ctx, expectedGitOpsDeployment, component, binding, strategy, target, k8sClient, log)
+	if err != nil || !res.complete {
+		return res, err
+	}
+
+	// The GitOpsDeployment that the component had before the Environment had a deployment strategy is only deleted once
+	// traffic has been switched to a colored GitOpsDeployment, so that the component remains available.
+	return res, deleteUncoloredGitOpsDeployments(ctx, binding, component.Name, k8sClient, log)
+}
+
+// rolloutComponent performs the next step of the rollout of the GitOpsDeployment expected for a component.
+func rolloutComponent(ctx context.Context, expectedGitOpsDeployment apibackend.GitOpsDeployment, component appstudioshared.BindingComponentStatus,
+	binding appstudioshared.SnapshotEnvironmentBinding, strategy deploymentStrategy, target rolloutTarget,
+	k8sClient client.Client, log logr.Logger) (componentRollout, error) {
+
+	res := componentRollout{componentName: component.Name}
+
+	deployments, err := getColoredGitOpsDeployments(ctx, binding, component.Name, k8sClient)
+	if err != nil {
+		return res, err
+	}
+
+	activeColor := getActiveDeploymentColor(deployments, expectedGitOpsDeployment, component)
+
+	// A) No GitOpsDeployment is active: this is the first deployment of the component, so there is no traffic to
+	// shift, and the GitOpsDeployment is made active immediately. If traffic is switched with a Route, the Route is
+	// only switched once the GitOpsDeployment is Synced/Healthy, as its Route and Service must exist by then.
+	if activeColor == "" {
+		activeColor = deploymentColorBlue
+		if _, exists := deployments[deploymentColorBlue]; !exists && deployments[deploymentColorGreen] != nil {
+			activeColor = deploymentColorGreen
+		}
+
+		expectedActive := generateColoredGitOpsDeployment(expectedGitOpsDeployment, component, activeColor)
+		res.deploymentName = expectedActive.Name
+
+		if err := processExpectedGitOpsDeployment(ctx, expectedActive, binding, k8sClient, log); err != nil {
+			return res, err
+		}
+
+		if isRolloutRouteManaged(target, component) {
+			active := apibackend.GitOpsDeployment{}
+			if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(&expectedActive), &active); err != nil {
+				return res, fmt.Errorf("unable to retrieve GitOpsDeployment '%s': %v", expectedActive.Name, err)
+			}
+
+			if !isGitOpsDeploymentReadyForTraffic(active, component) {
+				res.message, res.failed = getGitOpsDeploymentNotReadyMessage(active)
+				return res, nil
+			}
+		}
+
+		if message, err := updateRolloutRoute(ctx, target, component, binding, activeColor, "", 0); err != nil {
+			return res, err
+		} else if message != "" {
+			res.message, res.failed = message, true
+			return res, nil
+		}
+
+		if err := setGitOpsDeploymentActive(ctx, expectedActive, k8sClient, log); err != nil {
+			return res, err
+		}
+
+		res.complete = true
+		return res, nil
+	}
+
+	active := deployments[activeColor]
+	res.deploymentName = active.Name
+
+	candidateColor := getOtherDeploymentColor(activeColor)
+
+	// B) The active GitOpsDeployment already deploys the expected commit: the rollout is complete, so any other
+	// GitOpsDeployment is no longer needed (for example, if the binding returned to the active Snapshot mid-rollout).
+	expectedActive := generateColoredGitOpsDeployment(expectedGitOpsDeployment, component, activeColor)
+
+	if reflect.DeepEqual(expectedActive.Spec, active.Spec) {
+
+		if err := processExpectedGitOpsDeployment(ctx, expectedActive, binding, k8sClient, log); err != nil {
+			return res, err
+		}
+
+		if message, err := updateRolloutRoute(ctx, target, component, binding, activeColor, "", 0); err != nil {
+			return res, err
+		} else if message != "" {
+			res.message, res.failed = message, true
+			return res, nil
+		}
+
+		if candidate := deployments[candidateColor]; candidate != nil {
+			if err := deleteColoredGitOpsDeployment(ctx, candidate, k8sClient, log); err != nil {
+				return res, err
+			}
+		}
+
+		res.complete = true
+		return res, nil
+	}
+
+	// C) Deploy the expected commit alongside the active GitOpsDeployment, and wait for it to be Synced/Healthy.
+	expectedCandidate := generateColoredGitOpsDeployment(expectedGitOpsDeployment, component, candidateColor)
+
+	if err := processExpectedGitOpsDeployment(ctx, expectedCandidate, binding, k8sClient, log); err != nil {
+		return res, err
+	}
+
+	candidate := apibackend.GitOpsDeployment{}
+	if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(&expectedCandidate), &candidate); err != nil {
+		return res, fmt.Errorf("unable to retrieve GitOpsDeployment '%s': %v", expectedCandidate.Name, err)
+	}
+
+	if !isGitOpsDeploymentReadyForTraffic(candidate, component) {
+
+		// Until the new GitOpsDeployment is ready, all traffic remains with the active GitOpsDeployment. This also
+		// restarts a Canary rollout, if the new GitOpsDeployment was updated or became unhealthy mid-rollout.
+		if message, err := updateRolloutRoute(ctx, target, component, binding, activeColor, candidateColor, 0); err != nil {
+			return res, err
+		} else if message != "" {
+			res.message, res.failed = message, true
+			return res, nil
+		}
+
+		res.message, res.failed = getGitOpsDeploymentNotReadyMessage(candidate)
+		return res, nil
+	}
+
+	// D) Canary: shift traffic to the new GitOpsDeployment in steps, before switching all traffic to it.
+	if strategy.name == DeploymentStrategyCanary && isRolloutRouteManaged(target, component) {
+
+		candidateRoute, message, err := getColoredRoute(ctx, target, component, candidateColor)
+		if err != nil {
+			return res, err
+		} else if message != "" {
+			res.message, res.failed = message, true
+			return res, nil
+		}
+
+		weight, stepStarted, err := getRolloutRouteCanaryStep(ctx, target, component, candidateRoute.Spec.To.Name)
+		if err != nil {
+			return res, err
+		}
+
+		step := -1
+		for index, canaryWeight := range strategy.canaryWeights {
+			if canaryWeight == weight {
+				step = index
+				break
+			}
+		}
+
+		elapsed := time.Since(stepStarted)
+
+		if step == -1 || (elapsed >= strategy.canaryStepDuration && step+1 < len(strategy.canaryWeights)) {
+			// Start the first step, or move on to the next step
+			step++
+			elapsed = 0
+
+			if message, err := updateRolloutRoute(ctx, target, component, binding, activeColor, candidateColor, strategy.canaryWeights[step]); err != nil {
+				return res, err
+			} else if message != "" {
+				res.message, res.failed = message, true
+				return res, nil
+			}
+
+			log.Info(fmt.Sprintf("Shifted %d%% of traffic to GitOpsDeployment '%s'", strategy.canaryWeights[step], candidate.Name))
+		}
+
+		if elapsed < strategy.canaryStepDuration {
+			res.message = fmt.Sprintf("canary step %d of %d, %d%% of traffic to GitOpsDeployment '%s'",
+				step+1, len(strategy.canaryWeights), strategy.canaryWeights[step], candidate.Name)
+			res.requeueAfter = strategy.canaryStepDuration - elapsed
+			return res, nil
+		}
+	}
+
+	// E) Switch all traffic to the new GitOpsDeployment, make it active, and delete the previous GitOpsDeployment.
+	if message, err := updateRolloutRoute(ctx, target, component, binding, candidateColor, "", 0); err != nil {
+		return res, err
+	} else if message != "" {
+		res.message, res.failed = message, true
+		return res, nil
+	}
+
+	if err := setGitOpsDeploymentActive(ctx, candidate, k8sClient, log); err != nil {
+		return res, err
+	}
+
+	if err := deleteColoredGitOpsDeployment(ctx, active, k8sClient, log); err != nil {
+		return res, err
+	}
+
+	log.Info("Switched traffic of component to GitOpsDeployment: "+candidate.Name, "previousGitOpsDeployment", active.Name)
+
+	res.deploymentName = candidate.Name
+	res.complete = true
+	return res, nil
+}
+
+// generateColoredGitOpsDeploymentName returns the name of the GitOpsDeployment of the given color, for the given
+// (uncolored) GitOpsDeployment name.
+func generateColoredGitOpsDeploymentName(gitopsDeploymentName string, color string) string {
+
+	// Ensure the name, with the color suffix, is still within the K8s maximum
+	if maxLength := 253 - len("-"+color); len(gitopsDeploymentName) > maxLength {
+		gitopsDeploymentName = gitopsDeploymentName[0:maxLength]
+	}
+
+	return gitopsDeploymentName + "-" + color
+}
+
+// generateColoredGitOpsDeployment returns the GitOpsDeployment of the given color, for the GitOpsDeployment that is
+// expected for a component.
+// - The GitOpsDeployment is pinned to the commit of the GitOps repository, so that it is not changed by later commits.
+// - The resources it deploys are given the color as a name suffix and label, so they can run alongside the other color.
+func generateColoredGitOpsDeployment(expectedGitOpsDeployment apibackend.GitOpsDeployment, component appstudioshared.BindingComponentStatus,
+	color string) apibackend.GitOpsDeployment {
+
+	res := *expectedGitOpsDeployment.DeepCopy()
+
+	res.Name = generateColoredGitOpsDeploymentName(expectedGitOpsDeployment.Name, color)
+
+	if component.GitOpsRepository.CommitID != "" {
+		res.Spec.Source.TargetRevision = component.GitOpsRepository.CommitID
+	}
+
+	res.Spec.Source.Kustomize = &apibackend.ApplicationSourceKustomize{
+		NameSuffix:   "-" + color,
+		CommonLabels: map[string]string{deploymentColorLabelKey: color},
+	}
+
+	if res.Labels == nil {
+		res.Labels = map[string]string{}
+	}
+	res.Labels[deploymentColorLabelKey] = color
+
+	return res
+}
+
+// getOtherDeploymentColor returns 'green' for 'blue', and 'blue' for 'green'.
+func getOtherDeploymentColor(color string) string {
+	if color == deploymentColorBlue {
+		return deploymentColorGreen
+	}
+	return deploymentColorBlue
+}
+
+// getActiveDeploymentColor returns the color of the active GitOpsDeployment of a component, or an empty string if
+// no GitOpsDeployment is active.
+func getActiveDeploymentColor(deployments map[string]*apibackend.GitOpsDeployment, expectedGitOpsDeployment apibackend.GitOpsDeployment,
+	component appstudioshared.BindingComponentStatus) string {
+
+	activeColors := []string{}
+	for _, color := range []string{deploymentColorBlue, deploymentColorGreen} {
+		if deployment := deployments[color]; deployment != nil && deployment.Annotations[deploymentAnnotationActive] == "true" {
+			activeColors = append(activeColors, color)
+		}
+	}
+
+	if len(activeColors) == 0 {
+		return ""
+	}
+
+	// Both GitOpsDeployments are only active if the previous switch-over was interrupted before the previous
+	// GitOpsDeployment was deleted: in which case, the GitOpsDeployment that was switched to is the one that
+	// deploys the expected commit.
+	if len(activeColors) > 1 {
+		for _, color := range activeColors {
+			expected := generateColoredGitOpsDeployment(expectedGitOpsDeployment, component, color)
+			if reflect.DeepEqual(expected.Spec, deployments[color].Spec) {
+				return color
+			}
+		}
+	}
+
+	return activeColors[0]
+}
+
+// isGitOpsDeploymentReadyForTraffic returns true if the GitOpsDeployment has deployed the commit of the component,
+// and is Synced/Healthy.
+func isGitOpsDeploymentReadyForTraffic(gitopsDeployment apibackend.GitOpsDeployment, component appstudioshared.BindingComponentStatus) bool {
+
+	// The status may not yet reflect the commit that the GitOpsDeployment was just updated to
+	if component.GitOpsRepository.CommitID != "" && gitopsDeployment.Status.Sync.Revision != component.GitOpsRepository.CommitID {
+		return false
+	}
+
+	return gitopsDeployment.Status.Sync.Status == apibackend.SyncStatusCodeSynced &&
+		gitopsDeployment.Status.Health.Status == apibackend.HeathStatusCodeHealthy
+}
+
+// getGitOpsDeploymentNotReadyMessage returns a message describing why the GitOpsDeployment is not ready for traffic,
+// and true if the rollout has failed: if the GitOpsDeployment is Degraded.
+func getGitOpsDeploymentNotReadyMessage(gitopsDeployment apibackend.GitOpsDeployment) (string, bool) {
+
+	if gitopsDeployment.Status.Health.Status == apibackend.HeathStatusCodeDegraded {
+		return fmt.Sprintf("GitOpsDeployment '%s' is Degraded", gitopsDeployment.Name), true
+	}
+
+	return fmt.Sprintf("waiting for GitOpsDeployment '%s' to be Synced/Healthy", gitopsDeployment.Name), false
+}
+
+// listBindingGitOpsDeployments returns the GitOpsDeployments that are owned by the binding.
+func listBindingGitOpsDeployments(ctx context.Context, binding appstudioshared.SnapshotEnvironmentBinding,
+	k8sClient client.Client) ([]apibackend.GitOpsDeployment, error) {
+
+	deploymentList := apibackend.GitOpsDeploymentList{}
+	if err := k8sClient.List(ctx, &deploymentList, &client.ListOptions{
+		Namespace: binding.Namespace,
+		LabelSelector: labels.SelectorFromSet(labels.Set{
+			applicationLabelKey: binding.Spec.Application,
+			environmentLabelKey: binding.Spec.Environment,
+		}),
+	}); err != nil {
+		return nil, fmt.Errorf("unable to list GitOpsDeployments of Binding '%s': %v", binding.Name, err)
+	}
+
+	res := []apibackend.GitOpsDeployment{}
+	for _, deployment := range deploymentList.Items {
+		for _, ownerRef := range deployment.OwnerReferences {
+			if ownerRef.UID == binding.UID {
+				res = append(res, deployment)
+				break
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// getColoredGitOpsDeployments returns the GitOpsDeployments of a component of the binding, by color.
+func getColoredGitOpsDeployments(ctx context.Context, binding appstudioshared.SnapshotEnvironmentBinding, componentName string,
+	k8sClient client.Client) (map[string]*apibackend.GitOpsDeployment, error) {
+
+	deployments, err := listBindingGitOpsDeployments(ctx, binding, k8sClient)
+	if err != nil {
+		return nil, err
+	}
+
+	res := map[string]*apibackend.GitOpsDeployment{}
+	for index := range deployments {
+		deployment := deployments[index]
+
+		color := deployment.Labels[deploymentColorLabelKey]
+		if deployment.Labels[componentLabelKey] == componentName && (color == deploymentColorBlue || color == deploymentColorGreen) {
+			res[color] = &deployment
+		}
+	}
+
+	return res, nil
+}
+
+// deleteColoredGitOpsDeployments deletes the colored GitOpsDeployments of the binding, which were generated for a
+// deployment strategy that the Environment no longer has.
+func deleteColoredGitOpsDeployments(ctx context.Context, binding appstudioshared.SnapshotEnvironmentBinding,
+	k8sClient client.Client, log logr.Logger) error {
+
+	deployments, err := listBindingGitOpsDeployments(ctx, binding, k8sClient)
+	if err != nil {
+		return err
+	}
+
+	for index := range deployments {
+		deployment := deployments[index]
+
+		if _, colored := deployment.Labels[deploymentColorLabelKey]; !colored {
+			continue
+		}
+
+		if err := deleteColoredGitOpsDeployment(ctx, &deployment, k8sClient, log); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteUncoloredGitOpsDeployments deletes the uncolored GitOpsDeployment of a component of the binding, which was
+// generated before the Environment had a deployment strategy.
+func deleteUncoloredGitOpsDeployments(ctx context.Context, binding appstudioshared.SnapshotEnvironmentBinding, componentName string,
+	k8sClient client.Client, log logr.Logger) error {
+
+	deployments, err := listBindingGitOpsDeployments(ctx, binding, k8sClient)
+	if err != nil {
+		return err
+	}
+
+	for index := range deployments {
+		deployment := deployments[index]
+
+		if _, colored := deployment.Labels[deploymentColorLabelKey]; colored || deployment.Labels[componentLabelKey] != componentName {
+			continue
+		}
+
+		if err := deleteColoredGitOpsDeployment(ctx, &deployment, k8sClient, log); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteColoredGitOpsDeployment deletes a GitOpsDeployment of the binding, if it still exists.
+func deleteColoredGitOpsDeployment(ctx context.Context, gitopsDeployment *apibackend.GitOpsDeployment, k8sClient client.Client, log logr.Logger) error {
+
+	if err := k8sClient.Delete(ctx, gitopsDeployment); err != nil && !apierr.IsNotFound(err) {
+		log.Error(err, "unable to delete GitOpsDeployment: "+gitopsDeployment.Name)
+		return fmt.Errorf("unable to delete GitOpsDeployment '%s': %v", gitopsDeployment.Name, err)
+	}
+
+	log.Info("Deleted GitOpsDeployment that is no longer used by the deployment strategy", "deploymentName", gitopsDeployment.Name)
+	logutil.LogAPIResourceChangeEvent(gitopsDeployment.Namespace, gitopsDeployment.Name, gitopsDeployment, logutil.ResourceDeleted, log)
+
+	return nil
+}
+
+// setGitOpsDeploymentActive marks the GitOpsDeployment as the active GitOpsDeployment of its component.
+func setGitOpsDeploymentActive(ctx context.Context, gitopsDeployment apibackend.GitOpsDeployment, k8sClient client.Client, log logr.Logger) error {
+
+	if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(&gitopsDeployment), &gitopsDeployment); err != nil {
+		return fmt.Errorf("unable to retrieve GitOpsDeployment '%s': %v", gitopsDeployment.Name, err)
+	}
+
+	if gitopsDeployment.Annotations[deploymentAnnotationActive] == "true" {
+		return nil
+	}
+
+	if gitopsDeployment.Annotations == nil {
+		gitopsDeployment.Annotations = map[string]string{}
+	}
+	gitopsDeployment.Annotations[deploymentAnnotationActive] = "true"
+
+	if err := k8sClient.Update(ctx, &gitopsDeployment); err != nil {
+		log.Error(err, "unable to mark GitOpsDeployment as active: "+gitopsDeployment.Name)
+		return fmt.Errorf("unable to mark GitOpsDeployment '%s' as active: %v", gitopsDeployment.Name, err)
+	}
+	logutil.LogAPIResourceChangeEvent(gitopsDeployment.Namespace, gitopsDeployment.Name, gitopsDeployment, logutil.ResourceModified, log)
+
+	return nil
+}
+
+// isRolloutRouteManaged returns true if the traffic of the component is switched with a Route.
+func isRolloutRouteManaged(target rolloutTarget, component appstudioshared.BindingComponentStatus) bool {
+	return target.client != nil && component.GeneratedRouteName != ""
+}
+
+// getRolloutRouteCanaryStep returns the percentage of the traffic of the component that the Route sends to the
+// given Service of the new GitOpsDeployment, and when that percentage was set.
+func getRolloutRouteCanaryStep(ctx context.Context, target rolloutTarget, component appstudioshared.BindingComponentStatus,
+	serviceName string) (int32, time.Time, error) {
+
+	route := routev1.Route{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      component.GeneratedRouteName,
+			Namespace: target.namespace,
+		},
+	}
+	if err := target.client.Get(ctx, client.ObjectKeyFromObject(&route), &route); err != nil {
+		if apierr.IsNotFound(err) {
+			return 0, time.Time{}, nil
+		}
+		return 0, time.Time{}, fmt.Errorf("unable to retrieve Route '%s' of component '%s': %v", route.Name, component.Name, err)
+	}
+
+	// An invalid time is treated as a step that started long ago, so that the rollout is not stuck on the step.
+	stepStarted, _ := time.Parse(time.RFC3339, route.Annotations[deploymentAnnotationCanaryStepStarted])
+
+	for _, backend := range route.Spec.AlternateBackends {
+		if backend.Name == serviceName && backend.Weight != nil {
+			return *backend.Weight, stepStarted, nil
+		}
+	}
+
+	return 0, stepStarted, nil
+}
+
+// updateRolloutRoute ensures that the Route of the component sends the given percentage of traffic to the Service
+// of the candidate color, and the remaining traffic to the Service of the active color.
+// - If the component has no generated Route, there is no traffic to switch, and no Route is managed.
+// - Returns a message, which fails the rollout, if the Route or Service of a color does not exist (see getColoredRoute).
+func updateRolloutRoute(ctx context.Context, target rolloutTarget, component appstudioshared.BindingComponentStatus,
+	binding appstudioshared.SnapshotEnvironmentBinding, activeColor string, candidateColor string, candidateWeight int32) (string, error) {
+
+	if !isRolloutRouteManaged(target, component) {
+		return "", nil
+	}
+
+	// The Service of each color, and the port and TLS configuration, are taken from the Routes that are deployed by
+	// the GitOpsDeployments.
+	activeRoute, message, err := getColoredRoute(ctx, target, component, activeColor)
+	if err != nil || message != "" {
+		return message, err
+	}
+
+	candidateRoute := routev1.Route{}
+	if candidateWeight > 0 {
+		if candidateRoute, message, err = getColoredRoute(ctx, target, component, candidateColor); err != nil || message != "" {
+			return message, err
+		}
+	}
+
+	route := routev1.Route{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      component.GeneratedRouteName,
+			Namespace: target.namespace,
+		},
+	}
+
+	exists := true
+	if err := target.client.Get(ctx, client.ObjectKeyFromObject(&route), &route); err != nil {
+		if !apierr.IsNotFound(err) {
+			return "", fmt.Errorf("unable to retrieve Route '%s' of component '%s': %v", route.Name, component.Name, err)
+		}
+		exists = false
+	}
+
+	expectedSpec := route.Spec
+	expectedSpec.To = routev1.RouteTargetReference{
+		Kind:   "Service",
+		Name:   activeRoute.Spec.To.Name,
+		Weight: pointer.Int32(100 - candidateWeight),
+	}
+	expectedSpec.AlternateBackends = nil
+	if candidateWeight > 0 {
+		expectedSpec.AlternateBackends = []routev1.RouteTargetReference{{
+			Kind:   "Service",
+			Name:   candidateRoute.Spec.To.Name,
+			Weight: pointer.Int32(candidateWeight),
+		}}
+	}
+	expectedSpec.Port = activeRoute.Spec.Port
+	expectedSpec.TLS = activeRoute.Spec.TLS
+
+	weightChanged := !reflect.DeepEqual(expectedSpec.AlternateBackends, route.Spec.AlternateBackends)
+
+	if exists && !weightChanged && reflect.DeepEqual(expectedSpec, route.Spec) && route.Labels[deploymentRolloutRouteLabelKey] == "true" {
+		return "", nil
+	}
+
+	route.Spec = expectedSpec
+
+	route.Labels = cloneMap(route.Labels)
+	route.Labels[applicationLabelKey] = binding.Spec.Application
+	route.Labels[componentLabelKey] = component.Name
+	route.Labels[environmentLabelKey] = binding.Spec.Environment
+	route.Labels[deploymentRolloutRouteLabelKey] = "true"
+
+	// The Route may previously have been deployed by the uncolored GitOpsDeployment of the component (before the
+	// Environment had a deployment strategy): it is no longer tracked by Argo CD, so that it is not deleted along with
+	// that GitOpsDeployment.
+	delete(route.Labels, argoCDInstanceLabelKey)
+
+	route.Annotations = cloneMap(route.Annotations)
+	if candidateWeight > 0 {
+		if weightChanged {
+			route.Annotations[deploymentAnnotationCanaryStepStarted] = time.Now().UTC().Format(time.RFC3339)
+		}
+	} else {
+		delete(route.Annotations, deploymentAnnotationCanaryStepStarted)
+	}
+	route.Annotations = convertToNilIfEmptyMap(route.Annotations)
+
+	if !exists {
+		if err := target.client.Create(ctx, &route); err != nil {
+			return "", fmt.Errorf("unable to create Route '%s' of component '%s': %v", route.Name, component.Name, err)
+		}
+		return "", nil
+	}
+
+	if err := target.client.Update(ctx, &route); err != nil {
+		return "", fmt.Errorf("unable to update Route '%s' of component '%s': %v", route.Name, component.Name, err)
+	}
+
+	return "", nil
+}
+
+// getColoredRoute returns the Route that is deployed by the GitOpsDeployment of the given color: the Route generated
+// for the component, with the color as name suffix. Traffic of that color is sent to the Service the Route targets.
+// - Returns a message if the Route, or its Service, does not exist: traffic cannot be switched to that color.
+func getColoredRoute(ctx context.Context, target rolloutTarget, component appstudioshared.BindingComponentStatus,
+	color string) (routev1.Route, string, error) {
+
+	route := routev1.Route{}
+
+	routeName := component.GeneratedRouteName + "-" + color
+	if err := target.client.Get(ctx, client.ObjectKey{Namespace: target.namespace, Name: routeName}, &route); err != nil {
+		if apierr.IsNotFound(err) {
+			return route, fmt.Sprintf("Route '%s' of the %s GitOpsDeployment does not exist", routeName, color), nil
+		}
+		return route, "", fmt.Errorf("unable to retrieve Route '%s' of component '%s': %v", routeName, component.Name, err)
+	}
+
+	if route.Spec.To.Name == "" || (route.Spec.To.Kind != "" && route.Spec.To.Kind != "Service") {
+		return route, fmt.Sprintf("Route '%s' of the %s GitOpsDeployment does not target a Service", routeName, color), nil
+	}
+
+	service := corev1.Service{}
+	if err := target.client.Get(ctx, client.ObjectKey{Namespace: target.namespace, Name: route.Spec.To.Name}, &service); err != nil {
+		if apierr.IsNotFound(err) {
+			return route, fmt.Sprintf("Service '%s' of Route '%s' does not exist", route.Spec.To.Name, routeName), nil
+		}
+		return route, "", fmt.Errorf("unable to retrieve Service '%s' of component '%s': %v", route.Spec.To.Name, component.Name, err)
+	}
+
+	return route, "", nil
+}
+
+// hasRolloutRoutesFinalizer returns true if the binding has the finalizer which deletes its Routes.
+func hasRolloutRoutesFinalizer(binding appstudioshared.SnapshotEnvironmentBinding) bool {
+	for _, finalizer := range binding.Finalizers {
+		if finalizer == deploymentFinalizerRolloutRoutes {
+			return true
+		}
+	}
+	return false
+}
+
+// reconcileRolloutRoutes adds the finalizer which deletes the Routes of the binding, if any of its components have a
+// generated Route, and deletes the Routes of components which no longer have one (or are no longer part of the binding).
+func reconcileRolloutRoutes(ctx context.Context, binding *appstudioshared.SnapshotEnvironmentBinding, target rolloutTarget,
+	components map[string]appstudioshared.BindingComponentStatus, k8sClient client.Client, log logr.Logger) error {
+
+	routeComponents := map[string]bool{}
+	for componentName, component := range components {
+		if component.GeneratedRouteName != "" {
+			routeComponents[componentName] = true
+		}
+	}
+
+	if len(routeComponents) > 0 && addFinalizer(binding, deploymentFinalizerRolloutRoutes) {
+		if err := k8sClient.Update(ctx, binding); err != nil {
+			return fmt.Errorf("failed to add finalizer %s to Binding %s: %v", deploymentFinalizerRolloutRoutes, binding.Name, err)
+		}
+		log.Info("Added finalizer to Binding", "finalizer", deploymentFinalizerRolloutRoutes)
+	}
+
+	return deleteRolloutRoutes(ctx, target, *binding, routeComponents, log)
+}
+
+// finalizeSnapshotEnvironmentBinding deletes the Routes of a binding that is being deleted, and removes its finalizer.
+func finalizeSnapshotEnvironmentBinding(ctx context.Context, binding *appstudioshared.SnapshotEnvironmentBinding,
+	k8sClient client.Client, log logr.Logger) error {
+
+	if !hasRolloutRoutesFinalizer(*binding) {
+		return nil
+	}
+
+	environment := &appstudioshared.Environment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      binding.Spec.Environment,
+			Namespace: binding.Namespace,
+		},
+	}
+	if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(environment), environment); err != nil {
+		if !apierr.IsNotFound(err) {
+			return fmt.Errorf("unable to retrieve Environment '%s' referenced by Binding: %v", environment.Name, err)
+		}
+		environment = nil
+	}
+
+	return removeRolloutRoutes(ctx, binding, environment, k8sClient, log)
+}
+
+// removeRolloutRoutes deletes the Routes of the binding, and then removes the finalizer of the binding: when the binding
+// is deleted, or the Environment no longer has a deployment strategy.
+// - If the Environment is nil (it no longer exists), the target Namespace of the Routes cannot be determined, so only
+// the finalizer is removed.
+func removeRolloutRoutes(ctx context.Context, binding *appstudioshared.SnapshotEnvironmentBinding, environment *appstudioshared.Environment,
+	k8sClient client.Client, log logr.Logger) error {
+
+	if !hasRolloutRoutesFinalizer(*binding) {
+		return nil
+	}
+
+	if environment != nil {
+		targetNamespace, targetClient, err := getEnvironmentTargetNamespaceClient(ctx, *environment, k8sClient)
+		if err != nil {
+			return fmt.Errorf("unable to retrieve the target Namespace of Environment '%s': %v", environment.Name, err)
+		}
+
+		if err := deleteRolloutRoutes(ctx, rolloutTarget{namespace: targetNamespace, client: targetClient}, *binding, nil, log); err != nil {
+			return err
+		}
+	} else {
+		log.Info("Unable to delete the Routes of Binding, as its Environment no longer exists", "environment", binding.Spec.Environment)
+	}
+
+	if removeFinalizer(binding, deploymentFinalizerRolloutRoutes) {
+		if err := k8sClient.Update(ctx, binding); err != nil && !apierr.IsNotFound(err) {
+			return fmt.Errorf("failed to remove finalizer %s from Binding %s: %v", deploymentFinalizerRolloutRoutes, binding.Name, err)
+		}
+		log.Info("Removed finalizer from Binding", "finalizer", deploymentFinalizerRolloutRoutes)
+	}
+
+	return nil
+}
+
+// deleteRolloutRoutes deletes the Routes that are managed for the components of the binding, except for those of the
+// given components.
+func deleteRolloutRoutes(ctx context.Context, target rolloutTarget, binding appstudioshared.SnapshotEnvironmentBinding,
+	componentsToKeep map[string]bool, log logr.Logger) error {
+
+	if target.client == nil {
+		return nil
+	}
+
+	routeList := routev1.RouteList{}
+	if err := target.client.List(ctx, &routeList, &client.ListOptions{
+		Namespace: target.namespace,
+		LabelSelector: labels.SelectorFromSet(labels.Set{
+			applicationLabelKey:            binding.Spec.Application,
+			environmentLabelKey:            binding.Spec.Environment,
+			deploymentRolloutRouteLabelKey: "true",
+		}),
+	}); err != nil {
+		return fmt.Errorf("unable to list Routes of Binding '%s': %v", binding.Name, err)
+	}
+
+	for index := range routeList.Items {
+		route := routeList.Items[index]
+
+		if componentsToKeep[route.Labels[componentLabelKey]] {
+			continue
+		}
+
+		if err := target.client.Delete(ctx, &route); err != nil && !apierr.IsNotFound(err) {
+			return fmt.Errorf("unable to delete Route '%s' of Binding '%s': %v", route.Name, binding.Name, err)
+		}
+
+		log.Info("Deleted Route that is no longer used by the deployment strategy", "route", route.Name, "namespace", route.Namespace)
+	}
+
+	return nil
+}
+
+// updateBindingRolloutCondition sets the Rollout condition of the binding to describe the progress of the rollouts
+// of its components. The condition is removed if the Environment has no deployment strategy.
+func updateBindingRolloutCondition(binding *appstudioshared.SnapshotEnvironmentBinding, strategy deploymentStrategy, rollouts []componentRollout) {
+
+	if strategy.name == "" {
+		conditions := []metav1.Condition{}
+		for _, condition := range binding.Status.BindingConditions {
+			if condition.Type != SnapshotEnvironmentBindingConditionRollout {
+				conditions = append(conditions, condition)
+			}
+		}
+		if len(conditions) != len(binding.Status.BindingConditions) {
+			binding.Status.BindingConditions = conditions
+		}
+		return
+	}
+
+	failed := false
+	messages := []string{}
+	for _, rollout := range rollouts {
+		if !rollout.complete {
+			messages = append(messages, rollout.componentName+": "+rollout.message)
+		}
+		failed = failed || rollout.failed
+	}
+
+	newCondition := metav1.Condition{
+		Type:    SnapshotEnvironmentBindingConditionRollout,
+		Status:  metav1.ConditionTrue,
+		Reason:  SnapshotEnvironmentBindingReasonRolloutComplete,
+		Message: "All components are deployed using the " + strategy.name + " deployment strategy",
+	}
+
+	if len(messages) > 0 {
+		newCondition.Status = metav1.ConditionFalse
+		newCondition.Reason = SnapshotEnvironmentBindingReasonRolloutProgressing
+		if failed {
+			newCondition.Reason = SnapshotEnvironmentBindingReasonRolloutFailed
+		}
+		newCondition.Message = strings.Join(messages, "; ")
+	}
+
+	_, binding.Status.BindingConditions = insertOrUpdateConditionsInSlice(newCondition, binding.Status.BindingConditions)
+}
+
+// getBindingRolloutStatus returns a message describing the rollout of the binding, if the Environment of the binding
+// has a deployment strategy and the rollout is not complete (otherwise, an empty string), and whether the rollout failed.
+func getBindingRolloutStatus(binding appstudioshared.SnapshotEnvironmentBinding) (string, bool) {
+
+	for _, condition := range binding.Status.BindingConditions {
+		if condition.Type == SnapshotEnvironmentBindingConditionRollout && condition.Status != metav1.ConditionTrue {
+			return "Waiting for the rollout of the Snapshot to complete: " + condition.Message,
+				condition.Reason == SnapshotEnvironmentBindingReasonRolloutFailed
+		}
+	}
+
+	return "", false
+}
@@ -22,6 +22,7 @@ import (
 	"strings"
 
 	codereadytoolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	routev1 "github.com/openshift/api/route/v1"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 
@@ -60,6 +61,7 @@ func init() {
 	utilruntime.Must(gitopsdeploymentv1alpha1.AddToScheme(scheme))
 	utilruntime.Must(applicationv1alpha1.AddToScheme(scheme))
 	utilruntime.Must(codereadytoolchainv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(routev1.Install(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -114,8 +116,9 @@ func main() {
 	}
 
 	if err = (&appstudioredhatcomcontrollers.SnapshotReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:    mgr.GetClient(),
+		Scheme:    mgr.GetScheme(),
+		APIReader: mgr.GetAPIReader(),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Snapshot")
 		os.Exit(1)
@@ -17,12 +17,24 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"strconv"
+	"time"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // GitOpsDeploymentSpec defines the desired state of GitOpsDeployment
 type GitOpsDeploymentSpec struct {
-	Source ApplicationSource `json:"source"`
+	// Source is a reference to the location of the application's manifests or chart.
+	// Exactly one of Source and Sources must be specified.
+	// +optional
+	Source ApplicationSource `json:"source,omitempty"`
+
+	// Sources is a list of references to the locations of the application's manifests or charts, for applications
+	// which combine more than one source (for example, a Helm chart from one repository, and its values from another).
+	// Exactly one of Source and Sources must be specified.
+	// +optional
+	Sources ApplicationSources `json:"sources,omitempty"`
 
 	// Destination is a reference to a target namespace/cluster to deploy to.
 	// This field may be empty: if it is empty, it is assumed that the destination
@@ -40,6 +52,61 @@ type GitOpsDeploymentSpec struct {
 	// Note: This is somewhat of a placeholder for more advanced logic that can be implemented in the future.
 	// For an example of this type of logic, see the 'syncPolicy' field of Argo CD Application.
 	Type string `json:"type"`
+
+	// IgnoreDifferences is a list of resources and their fields which should be ignored when comparing the live state of
+	// the cluster with the desired state in the GitOps repository. For example, the replica count of a Deployment that
+	// is scaled by a HorizontalPodAutoscaler.
+	IgnoreDifferences IgnoreDifferences `json:"ignoreDifferences,omitempty"`
+
+	// SyncWindows is a list of time windows during which syncs of the GitOpsDeployment are either allowed or denied.
+	// For example, a 'deny' window may be used to prevent automated syncs during business hours.
+	// - If one or more 'allow' windows are defined, syncs are only permitted while an 'allow' window is active.
+	// - Syncs are never permitted while a 'deny' window is active, unless the window permits manual syncs.
+	SyncWindows []SyncWindow `json:"syncWindows,omitempty"`
+
+	// DependsOn is a list of the names of other GitOpsDeployments, in the same namespace, that this GitOpsDeployment
+	// depends on. The GitOpsDeployment will not be synced until all of its dependencies are both Synced and Healthy.
+	// - While waiting, the 'WaitingForDependencies' condition of the GitOpsDeployment is True.
+	// - Cycles between GitOpsDeployments are rejected.
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+// SyncWindow defines a recurring time window during which syncs are either allowed or denied
+type SyncWindow struct {
+	// Kind defines if the window allows or denies syncs: either 'allow' or 'deny'
+	Kind SyncWindowKind `json:"kind"`
+	// Schedule is the time the window will begin, specified in cron format, e.g. '0 9 * * 1-5'
+	Schedule string `json:"schedule"`
+	// Duration is the amount of time the sync window will be open, e.g. '1h' or '8h30m'
+	Duration string `json:"duration"`
+	// ManualSync enables manual syncs (via GitOpsDeploymentSyncRun) when they would otherwise be blocked
+	ManualSync bool `json:"manualSync,omitempty"`
+	// TimeZone of the schedule, e.g. 'Europe/London'. Defaults to UTC.
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+type SyncWindowKind string
+
+const (
+	SyncWindowKind_Allow SyncWindowKind = "allow"
+	SyncWindowKind_Deny  SyncWindowKind = "deny"
+)
+
+// IgnoreDifferences is a list of resources and their fields which should be ignored during comparison
+type IgnoreDifferences []ResourceIgnoreDifferences
+
+// ResourceIgnoreDifferences contains a resource filter, and a list of paths to fields of the matching resources which
+// should be ignored during comparison with the live state.
+type ResourceIgnoreDifferences struct {
+	Group string `json:"group,omitempty"`
+	Kind  string `json:"kind"`
+	// Name and Namespace are optional: if not specified, all resources of the given group and kind are matched
+	Name      string `json:"name,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	// JSONPointers is a list of JSON pointers (RFC 6901) to the fields to ignore, e.g. '/spec/replicas'
+	JSONPointers []string `json:"jsonPointers,omitempty"`
+	// JQPathExpressions is a list of JQ path expressions to the fields to ignore, e.g. '.spec.template.spec.initContainers[] | select(.name == "injected")'
+	JQPathExpressions []string `json:"jqPathExpressions,omitempty"`
 }
 
 // ApplicationSource contains all required information about the source of an application
@@ -47,11 +114,85 @@ type ApplicationSource struct {
 	// RepoURL is the URL to the repository (Git or Helm) that contains the application manifests
 	RepoURL string `json:"repoURL"`
 	// Path is a directory path within the Git repository, and is only valid for applications sourced from Git.
-	Path string `json:"path"`
+	// Path is required, unless Chart is specified.
+	Path string `json:"path,omitempty"`
 	// TargetRevision defines the revision of the source to sync the application to.
 	// In case of Git, this can be commit, tag, or branch. If omitted, will equal to HEAD.
 	// In case of Helm, this is a semver tag for the Chart's version.
 	TargetRevision string `json:"targetRevision,omitempty"`
+	// Chart is a Helm chart name, and must be specified for applications sourced from a Helm repo.
+	// Chart and Path are mutually exclusive.
+	Chart string `json:"chart,omitempty"`
+	// Helm holds Helm-specific options
+	Helm *ApplicationSourceHelm `json:"helm,omitempty"`
+	// Kustomize holds Kustomize-specific options, which override the values in the kustomization of the source
+	Kustomize *ApplicationSourceKustomize `json:"kustomize,omitempty"`
+	// Ref is a name that can be used to refer to this source from the other sources of a multi-source GitOpsDeployment,
+	// for example to use the values files of this source in a Helm chart source: '$<ref>/path/to/values.yaml'.
+	// Ref is only valid within .spec.sources.
+	Ref string `json:"ref,omitempty"`
+}
+
+// HasMultipleSources returns true if the GitOpsDeployment uses .spec.sources, rather than .spec.source
+func (spec GitOpsDeploymentSpec) HasMultipleSources() bool {
+	return len(spec.Sources) > 0
+}
+
+// ApplicationSourceKustomize holds options specific to an Application source specific to Kustomize
+type ApplicationSourceKustomize struct {
+	// NamePrefix is a prefix appended to resources for Kustomize apps
+	NamePrefix string `json:"namePrefix,omitempty"`
+	// NameSuffix is a suffix appended to resources for Kustomize apps
+	NameSuffix string `json:"nameSuffix,omitempty"`
+	// Images is a list of Kustomize image override specifications
+	Images KustomizeImages `json:"images,omitempty"`
+	// CommonLabels is a list of additional labels to add to rendered manifests
+	CommonLabels map[string]string `json:"commonLabels,omitempty"`
+	// CommonAnnotations is a list of additional annotations to add to rendered manifests
+	CommonAnnotations map[string]string `json:"commonAnnotations,omitempty"`
+	// Replicas is a list of Kustomize Replicas override specifications
+	Replicas KustomizeReplicas `json:"replicas,omitempty"`
+}
+
+// KustomizeImage is a Kustomize image override, of the form '[old_image_name=]<image_name>:<image_tag>'
+// or '[old_image_name=]<image_name>@<image_digest>'.
+type KustomizeImage string
+
+// KustomizeImages is a list of Kustomize image override specifications
+type KustomizeImages []KustomizeImage
+
+// KustomizeReplica overrides the number of replicas of a Deployment or StatefulSet with a given name
+type KustomizeReplica struct {
+	// Name of Deployment or StatefulSet
+	Name string `json:"name"`
+	// Number of replicas
+	Count int32 `json:"count"`
+}
+
+// KustomizeReplicas is a list of Kustomize Replicas override specifications
+type KustomizeReplicas []KustomizeReplica
+
+// ApplicationSourceHelm holds helm specific options
+type ApplicationSourceHelm struct {
+	// ValueFiles is a list of Helm value files to use when generating a template.
+	// Paths are relative to the chart (or path) within the repository.
+	ValueFiles []string `json:"valueFiles,omitempty"`
+	// Parameters is a list of Helm parameters which are passed to the helm template command upon manifest generation
+	Parameters []HelmParameter `json:"parameters,omitempty"`
+	// ReleaseName is the Helm release name to use. If omitted it will use the application name
+	ReleaseName string `json:"releaseName,omitempty"`
+	// Values specifies Helm values to be passed to helm template, typically defined as a YAML block
+	Values string `json:"values,omitempty"`
+}
+
+// HelmParameter is a parameter that's passed to helm template during manifest generation
+type HelmParameter struct {
+	// Name is the name of the Helm parameter
+	Name string `json:"name"`
+	// Value is the value for the Helm parameter
+	Value string `json:"value,omitempty"`
+	// ForceString determines whether to tell Helm to interpret booleans and numbers as strings
+	ForceString bool `json:"forceString,omitempty"`
 }
 
 // ApplicationDestination holds information about the application's destination
@@ -72,22 +213,78 @@ type SyncOption string
 
 // Supported values for SyncOptions
 const (
-	SyncOptions_CreateNamespace_true  SyncOption = "CreateNamespace=true"
-	SyncOptions_CreateNamespace_false SyncOption = "CreateNamespace=false"
+	SyncOptions_CreateNamespace_true           SyncOption = "CreateNamespace=true"
+	SyncOptions_CreateNamespace_false          SyncOption = "CreateNamespace=false"
+	SyncOptions_ServerSideApply_true           SyncOption = "ServerSideApply=true"
+	SyncOptions_ServerSideApply_false          SyncOption = "ServerSideApply=false"
+	SyncOptions_PruneLast_true                 SyncOption = "PruneLast=true"
+	SyncOptions_PruneLast_false                SyncOption = "PruneLast=false"
+	SyncOptions_ApplyOutOfSyncOnly_true        SyncOption = "ApplyOutOfSyncOnly=true"
+	SyncOptions_ApplyOutOfSyncOnly_false       SyncOption = "ApplyOutOfSyncOnly=false"
+	SyncOptions_Replace_true                   SyncOption = "Replace=true"
+	SyncOptions_Replace_false                  SyncOption = "Replace=false"
+	SyncOptions_RespectIgnoreDifferences_true  SyncOption = "RespectIgnoreDifferences=true"
+	SyncOptions_RespectIgnoreDifferences_false SyncOption = "RespectIgnoreDifferences=false"
 )
 
+// supportedSyncOptions is the list of sync options that may be specified in .spec.syncPolicy.syncOptions
+var supportedSyncOptions = SyncOptions{
+	SyncOptions_CreateNamespace_true,
+	SyncOptions_CreateNamespace_false,
+	SyncOptions_ServerSideApply_true,
+	SyncOptions_ServerSideApply_false,
+	SyncOptions_PruneLast_true,
+	SyncOptions_PruneLast_false,
+	SyncOptions_ApplyOutOfSyncOnly_true,
+	SyncOptions_ApplyOutOfSyncOnly_false,
+	SyncOptions_Replace_true,
+	SyncOptions_Replace_false,
+	SyncOptions_RespectIgnoreDifferences_true,
+	SyncOptions_RespectIgnoreDifferences_false,
+}
+
 type SyncPolicy struct {
 	// Options allow you to specify whole app sync-options.
 	// This option may be empty, if and when it is empty it is considered that there are no SyncOptions present.
 	SyncOptions SyncOptions `json:"syncOptions,omitempty"`
+
+	// Automated controls the behaviour of automated sync, and may only be specified if .spec.type is 'automated'.
+	// If not specified, prune, selfHeal and allowEmpty are all enabled.
+	Automated *SyncPolicyAutomated `json:"automated,omitempty"`
+
+	// Retry controls the strategy to apply if a sync fails.
+	// If not specified, an automated GitOpsDeployment will retry failed syncs indefinitely, with an exponential backoff
+	// of between 5 seconds and 3 minutes.
+	Retry *RetryStrategy `json:"retry,omitempty"`
 }
 type SyncOptions []SyncOption
 
+// SyncPolicyAutomated controls the behavior of an automated sync.
+// Each field defaults to true, if not specified.
+type SyncPolicyAutomated struct {
+	// Prune specifies whether to delete resources from the cluster that are not found in the sources anymore as part of automated sync
+	Prune *bool `json:"prune,omitempty"`
+	// SelfHeal specifies whether to revert resources back to their desired state upon modification in the cluster
+	SelfHeal *bool `json:"selfHeal,omitempty"`
+	// AllowEmpty allows apps have zero live resources
+	AllowEmpty *bool `json:"allowEmpty,omitempty"`
+}
+
 const (
 	GitOpsDeploymentSpecType_Automated = "automated"
 	GitOpsDeploymentSpecType_Manual    = "manual"
 )
 
+// IsSupportedSyncOption returns true if the sync option may be specified in .spec.syncPolicy.syncOptions, false otherwise.
+func IsSupportedSyncOption(syncOption SyncOption) bool {
+	for _, supportedSyncOption := range supportedSyncOptions {
+		if syncOption == supportedSyncOption {
+			return true
+		}
+	}
+	return false
+}
+
 func SyncOptionToStringSlice(syncOptions SyncOptions) []string {
 	if syncOptions == nil {
 		return nil
@@ -112,11 +309,21 @@ type ResourceStatus struct {
 	Name      string         `json:"name,omitempty"`
 	Status    SyncStatusCode `json:"status,omitempty"`
 	Health    *HealthStatus  `json:"health,omitempty"`
+
+	// RequiresPruning is true if the resource is no longer defined in the GitOps repository, and will be deleted
+	// if the GitOpsDeployment is synced with pruning enabled.
+	RequiresPruning bool `json:"requiresPruning,omitempty"`
+
+	// Hook is true if the resource is a sync hook. The result of the hook is available in the
+	// GitOpsDeploymentResourceTree of the GitOpsDeployment.
+	Hook bool `json:"hook,omitempty"`
 }
 
 // ReconciledState contains the last version of the GitOpsDeployment resource that the ArgoCD Controller reconciled
 type ReconciledState struct {
-	Source      GitOpsDeploymentSource      `json:"source"`
+	Source GitOpsDeploymentSource `json:"source"`
+	// Sources contains the reconciled sources of a GitOpsDeployment with multiple sources, in the same order as .spec.sources
+	Sources     []GitOpsDeploymentSource    `json:"sources,omitempty"`
 	Destination GitOpsDeploymentDestination `json:"destination"`
 }
 
@@ -126,6 +333,10 @@ type GitOpsDeploymentSource struct {
 	Path    string `json:"path"`
 	RepoURL string `json:"repoURL"`
 	Branch  string `json:"branch"`
+	// Chart contains the Helm chart name from .status.Sync.CompareTo field of ArgoCD Application, if the source is a Helm chart
+	Chart string `json:"chart,omitempty"`
+	// Kustomize contains the Kustomize overrides from .status.Sync.CompareTo field of ArgoCD Application, if any
+	Kustomize *ApplicationSourceKustomize `json:"kustomize,omitempty"`
 }
 
 // GitOpsDeploymentDestination contains the information of .status.Sync.CompareTo.Destination field of ArgoCD Application
@@ -149,6 +360,52 @@ type GitOpsDeploymentStatus struct {
 
 	// OperationState contains information about any ongoing operations, such as a sync
 	OperationState *OperationState `json:"operationState,omitempty"`
+
+	// SyncWindows reports the current state of the sync windows defined in .spec.syncWindows, if any
+	SyncWindows *SyncWindowsStatus `json:"syncWindows,omitempty"`
+
+	// History contains the revisions that were previously deployed, oldest first. An entry can be rolled back to,
+	// via the .spec.rollbackTo field of GitOpsDeploymentSyncRun.
+	History []RevisionHistory `json:"history,omitempty"`
+
+	// GitOpsEngineInstance is the GitOps engine (Argo CD) instance that the GitOpsDeployment is deployed by
+	GitOpsEngineInstance *GitOpsEngineInstanceStatus `json:"gitopsEngineInstance,omitempty"`
+}
+
+// GitOpsEngineInstanceStatus identifies the GitOps engine (Argo CD) instance that a GitOpsDeployment is deployed by
+type GitOpsEngineInstanceStatus struct {
+	// ID is the unique identifier of the GitOps engine instance
+	ID string `json:"id"`
+	// Namespace is the namespace of the GitOps engine instance, on its cluster
+	Namespace string `json:"namespace"`
+	// ClusterID is the unique identifier of the cluster that the GitOps engine instance is running on
+	ClusterID string `json:"clusterID"`
+}
+
+// RevisionHistory contains information about a previous deployment of the GitOpsDeployment
+type RevisionHistory struct {
+	// ID is an auto incrementing identifier of the deployment
+	ID int64 `json:"id"`
+	// Revision holds the revision (for example, the git commit SHA) that was deployed
+	Revision string `json:"revision,omitempty"`
+	// DeployStartedAt holds the time the deployment started
+	DeployStartedAt *metav1.Time `json:"deployStartedAt,omitempty"`
+	// DeployedAt holds the time the deployment completed
+	DeployedAt metav1.Time `json:"deployedAt"`
+	// Source is the source of the GitOpsDeployment at the time of the deployment
+	Source ApplicationSource `json:"source,omitempty"`
+}
+
+// SyncWindowsStatus reports the current state of the sync windows of a GitOpsDeployment
+type SyncWindowsStatus struct {
+	// Active is true if at least one of the sync windows is currently active
+	Active bool `json:"active"`
+	// ActiveWindows is the list of the sync windows that are currently active
+	ActiveWindows []SyncWindow `json:"activeWindows,omitempty"`
+	// SyncAllowed is true if the sync windows currently permit automated syncs
+	SyncAllowed bool `json:"syncAllowed"`
+	// ManualSyncAllowed is true if the sync windows currently permit manual syncs
+	ManualSyncAllowed bool `json:"manualSyncAllowed"`
 }
 
 // OperationState contains information about state of a running operation
@@ -194,6 +451,15 @@ type RetryStrategy struct {
 	Backoff *Backoff `json:"backoff,omitempty" protobuf:"bytes,2,opt,name=backoff,casttype=Backoff"`
 }
 
+// ParseRetryDuration parses a Backoff duration, which is either a number of seconds, or a duration string (e.g. "2m", "1h"),
+// in the same way as Argo CD.
+func ParseRetryDuration(duration string) (time.Duration, error) {
+	if seconds, err := strconv.Atoi(duration); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	return time.ParseDuration(duration)
+}
+
 // Backoff is the backoff strategy to use on subsequent retries for failing syncs
 type Backoff struct {
 	// Duration is the amount to back off. Default unit is seconds, but could also be a duration (e.g. "2m", "1h")
@@ -389,6 +655,9 @@ type SyncStatus struct {
 	Status SyncStatusCode `json:"status"`
 	// Revision contains information about the revision the comparison has been performed to
 	Revision string `json:"revision,omitempty"`
+	// Revisions contains information about the revisions the comparison has been performed to, for a GitOpsDeployment
+	// with multiple sources. Each revision corresponds to the source at the same index of .spec.sources.
+	Revisions []string `json:"revisions,omitempty"`
 }
 
 // SyncStatusCode is a type which represents possible comparison results
@@ -435,6 +704,10 @@ type GitOpsDeploymentConditionType string
 const (
 	GitOpsDeploymentConditionSyncError     GitOpsDeploymentConditionType = "SyncError"
 	GitOpsDeploymentConditionErrorOccurred GitOpsDeploymentConditionType = "ErrorOccurred"
+
+	// GitOpsDeploymentConditionWaitingForDependencies is True while the GitOpsDeployment is not synced, because one
+	// or more of the GitOpsDeployments in .spec.dependsOn are not yet Synced and Healthy.
+	GitOpsDeploymentConditionWaitingForDependencies GitOpsDeploymentConditionType = "WaitingForDependencies"
 )
 
 // GitOpsConditionStatus is a type which represents possible comparison results
@@ -491,11 +764,22 @@ type GitOpsDeploymentReasonType string
 const (
 	GitopsDeploymentReasonSyncError     GitOpsDeploymentReasonType = "SyncError"
 	GitopsDeploymentReasonErrorOccurred GitOpsDeploymentReasonType = "ErrorOccurred"
+
+	GitopsDeploymentReasonWaitingForDependencies GitOpsDeploymentReasonType = "WaitingForDependencies"
+	GitopsDeploymentReasonDependenciesReady      GitOpsDeploymentReasonType = "DependenciesReady"
+	GitopsDeploymentReasonDependencyCycle        GitOpsDeploymentReasonType = "DependencyCycle"
 )
 
 const (
-	GitOpsDeploymentUserError_InvalidPathSlash = "spec.source.path cannot be '/'"
-	GitOpsDeploymentUserError_PathIsRequired   = "spec.source.path is a required field and it cannot be empty"
+	GitOpsDeploymentUserError_InvalidPathSlash      = "spec.source.path cannot be '/'"
+	GitOpsDeploymentUserError_PathIsRequired        = "spec.source.path is a required field and it cannot be empty"
+	GitOpsDeploymentUserError_PathAndChart          = "spec.source.path and spec.source.chart cannot both be specified"
+	GitOpsDeploymentUserError_SourceAndSources      = "only one of spec.source and spec.sources may be specified"
+	GitOpsDeploymentUserError_RefInSource           = "spec.source.ref is only valid within spec.sources"
+	GitOpsDeploymentUserError_SourcesPathIsRequired = "spec.sources[].path is required, unless chart or ref is specified"
+	GitOpsDeploymentUserError_SourcesPathSlash      = "spec.sources[].path cannot be '/'"
+	GitOpsDeploymentUserError_SourcesPathAndChart   = "spec.sources[].path and spec.sources[].chart cannot both be specified"
+	GitOpsDeploymentUserError_AutomatedNotAutomated = "spec.syncPolicy.automated may only be specified when spec.type is automated"
 )
 
 // +kubebuilder:object:root=true
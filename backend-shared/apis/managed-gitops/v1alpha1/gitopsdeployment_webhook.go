@@ -17,11 +17,23 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"context"
 	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"gopkg.in/yaml.v2"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	logutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util/log"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 )
@@ -30,14 +42,44 @@ const (
 	error_nonempty_namespace_empty_environment = "the environment field should not be empty when the namespace is non-empty"
 	error_invalid_sync_option                  = "the specified sync option in .spec.syncPolicy.syncOptions is either mispelled or is not supported by GitOpsDeployment"
 	error_invalid_spec_type                    = "spec type must be manual or automated"
+	error_invalid_helm_value_file              = "the value files in .spec.source.helm.valueFiles must be relative paths which, relative to .spec.source.path, are within the repository"
+	error_invalid_helm_parameter               = "the parameters in .spec.source.helm.parameters must have a non-empty name, and their names and values must not contain quotes, line breaks, ampersands, semicolons or percent signs"
+	error_invalid_helm_release_name            = "the .spec.source.helm.releaseName field must not contain quotes, line breaks, ampersands, semicolons or percent signs"
+	error_invalid_helm_values                  = "the .spec.source.helm.values field must be a valid YAML object"
+	error_invalid_kustomize_name_affix         = "the .spec.source.kustomize.namePrefix and .nameSuffix fields must not contain quotes, line breaks, ampersands, semicolons or percent signs"
+	error_invalid_kustomize_image              = "the images in .spec.source.kustomize.images must be non-empty and must not contain whitespace, quotes, ampersands, semicolons or percent signs"
+	error_invalid_kustomize_common_labels      = "the .spec.source.kustomize.commonLabels field must only contain valid label keys and values"
+	error_invalid_kustomize_common_annotations = "the .spec.source.kustomize.commonAnnotations field must only contain valid annotation keys, and values which do not contain quotes, line breaks, ampersands, semicolons or percent signs"
+	error_invalid_kustomize_replicas           = "the replicas in .spec.source.kustomize.replicas must have a non-empty name, which does not contain quotes, line breaks, ampersands, semicolons or percent signs, and a non-negative count"
+	error_invalid_retry_backoff_duration       = "the .spec.syncPolicy.retry.backoff.duration and .maxDuration fields must be a number of seconds, or a duration such as '30s', '2m' or '1h'"
+	error_invalid_retry_backoff_factor         = "the .spec.syncPolicy.retry.backoff.factor field must be at least 1"
+	error_invalid_retry_backoff_max_duration   = "the .spec.syncPolicy.retry.backoff.maxDuration field must not be less than the duration field"
+	error_invalid_ignore_differences           = "each entry of .spec.ignoreDifferences must specify a kind, and at least one JSON pointer or JQ path expression"
+	error_invalid_ignore_differences_pointer   = "the JSON pointers in .spec.ignoreDifferences must begin with '/'"
+	error_invalid_sync_window_kind             = "the .spec.syncWindows[].kind field must be either 'allow' or 'deny'"
+	error_invalid_sync_window_schedule         = "the .spec.syncWindows[].schedule field must be specified, in cron format"
+	error_invalid_sync_window_duration         = "the .spec.syncWindows[].duration field must be a positive duration, such as '30m' or '8h'"
+	error_invalid_sync_window_time_zone        = "the .spec.syncWindows[].timeZone field must be a valid time zone, such as 'UTC' or 'Europe/London'"
+	error_invalid_depends_on_name              = "the entries of .spec.dependsOn must be valid GitOpsDeployment names"
+	error_invalid_depends_on_self              = "a GitOpsDeployment cannot depend on itself"
+	error_invalid_depends_on_duplicate         = "the entries of .spec.dependsOn must be unique"
+	error_invalid_depends_on_cycle             = "the .spec.dependsOn field must not introduce a dependency cycle"
 )
 
+// unsupportedSourceCharacters are the characters which may not be used in the Helm parameters, Helm release name and
+// Kustomize fields of a source: the backend does not include them in the Argo CD Application that it generates.
+const unsupportedSourceCharacters = "\"'`\r\n&;%"
+
 // log is for logging in this package.
 var gitopsdeploymentlog = logf.Log.WithName(logutil.LogLogger_managed_gitops)
 
 func (r *GitOpsDeployment) SetupWebhookWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(r).
+		WithValidator(&gitopsDeploymentValidator{
+			// Use the API reader (rather than the cached client), so that we don't need to wait for the cache to sync
+			reader: mgr.GetAPIReader(),
+		}).
 		Complete()
 }
 
@@ -54,10 +96,21 @@ func (r *GitOpsDeployment) Default() {
 
 //+kubebuilder:webhook:path=/validate-managed-gitops-redhat-com-v1alpha1-gitopsdeployment,mutating=false,failurePolicy=fail,sideEffects=None,groups=managed-gitops.redhat.com,resources=gitopsdeployments,verbs=create;update,versions=v1alpha1,name=vgitopsdeployment.kb.io,admissionReviewVersions=v1
 
-var _ webhook.Validator = &GitOpsDeployment{}
+// gitopsDeploymentValidator validates GitOpsDeployments. Unlike the webhooks of the other types, it needs to read the
+// other GitOpsDeployments of the namespace, in order to detect cycles in .spec.dependsOn.
+type gitopsDeploymentValidator struct {
+	reader client.Reader
+}
+
+var _ webhook.CustomValidator = &gitopsDeploymentValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type
+func (v *gitopsDeploymentValidator) ValidateCreate(ctx context.Context, obj runtime.Object) error {
 
-// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
-func (r *GitOpsDeployment) ValidateCreate() error {
+	r, ok := obj.(*GitOpsDeployment)
+	if !ok {
+		return fmt.Errorf("expected a GitOpsDeployment, but received %T", obj)
+	}
 
 	log := gitopsdeploymentlog.WithValues(logutil.Log_K8s_Request_Name, r.Name, logutil.Log_K8s_Request_Namespace, r.Namespace, "kind", "GitOpsDeployment")
 
@@ -68,11 +121,21 @@ func (r *GitOpsDeployment) ValidateCreate() error {
 		return err
 	}
 
+	if err := r.validateDependsOnCycle(ctx, v.reader); err != nil {
+		log.Info("webhook rejected invalid create", "error", fmt.Sprintf("%v", err))
+		return err
+	}
+
 	return nil
 }
 
-// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
-func (r *GitOpsDeployment) ValidateUpdate(old runtime.Object) error {
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type
+func (v *gitopsDeploymentValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+
+	r, ok := newObj.(*GitOpsDeployment)
+	if !ok {
+		return fmt.Errorf("expected a GitOpsDeployment, but received %T", newObj)
+	}
 
 	log := gitopsdeploymentlog.WithValues(logutil.Log_K8s_Request_Name, r.Name, logutil.Log_K8s_Request_Namespace, r.Namespace, "kind", "GitOpsDeployment")
 
@@ -83,11 +146,21 @@ func (r *GitOpsDeployment) ValidateUpdate(old runtime.Object) error {
 		return err
 	}
 
+	if err := r.validateDependsOnCycle(ctx, v.reader); err != nil {
+		log.Info("webhook rejected invalid update", "error", fmt.Sprintf("%v", err))
+		return err
+	}
+
 	return nil
 }
 
-// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
-func (r *GitOpsDeployment) ValidateDelete() error {
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type
+func (v *gitopsDeploymentValidator) ValidateDelete(ctx context.Context, obj runtime.Object) error {
+
+	r, ok := obj.(*GitOpsDeployment)
+	if !ok {
+		return fmt.Errorf("expected a GitOpsDeployment, but received %T", obj)
+	}
 
 	log := gitopsdeploymentlog.WithValues(logutil.Log_K8s_Request_Name, r.Name, logutil.Log_K8s_Request_Namespace, r.Namespace, "kind", "GitOpsDeployment")
 
@@ -107,17 +180,353 @@ func (r *GitOpsDeployment) validateGitOpsDeployment() error {
 	if r.Spec.SyncPolicy != nil {
 		for _, syncOptionString := range r.Spec.SyncPolicy.SyncOptions {
 
-			if !(syncOptionString == SyncOptions_CreateNamespace_true ||
-				syncOptionString == SyncOptions_CreateNamespace_false) {
+			if !IsSupportedSyncOption(syncOptionString) {
 				return fmt.Errorf(error_invalid_sync_option)
 			}
 
 		}
+
+		if r.Spec.SyncPolicy.Automated != nil && !strings.EqualFold(r.Spec.Type, GitOpsDeploymentSpecType_Automated) {
+			return fmt.Errorf(GitOpsDeploymentUserError_AutomatedNotAutomated)
+		}
+
+		if r.Spec.SyncPolicy.Retry != nil {
+			if err := ValidateRetryStrategy(*r.Spec.SyncPolicy.Retry); err != nil {
+				return err
+			}
+		}
 	}
 
 	if r.Spec.Destination.Environment == "" && r.Spec.Destination.Namespace != "" {
 		return fmt.Errorf(error_nonempty_namespace_empty_environment)
 	}
 
+	if err := ValidateIgnoreDifferences(r.Spec.IgnoreDifferences); err != nil {
+		return err
+	}
+
+	if err := validateSyncWindows(r.Spec.SyncWindows); err != nil {
+		return err
+	}
+
+	if err := validateDependsOn(r.Name, r.Spec.DependsOn); err != nil {
+		return err
+	}
+
+	if r.Spec.HasMultipleSources() {
+		if r.Spec.Source.RepoURL != "" || r.Spec.Source.Path != "" || r.Spec.Source.Chart != "" {
+			return fmt.Errorf(GitOpsDeploymentUserError_SourceAndSources)
+		}
+
+		for _, source := range r.Spec.Sources {
+			if source.Chart != "" && source.Path != "" {
+				return fmt.Errorf(GitOpsDeploymentUserError_SourcesPathAndChart)
+			}
+
+			if err := ValidateApplicationSource(source); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if r.Spec.Source.Chart != "" && r.Spec.Source.Path != "" {
+		return fmt.Errorf(GitOpsDeploymentUserError_PathAndChart)
+	}
+
+	if r.Spec.Source.Ref != "" {
+		return fmt.Errorf(GitOpsDeploymentUserError_RefInSource)
+	}
+
+	return ValidateApplicationSource(r.Spec.Source)
+}
+
+// ValidateApplicationSource verifies the Helm and Kustomize fields of a source. It is used by both the webhook and
+// the backend, which reports the error as a user error.
+func ValidateApplicationSource(source ApplicationSource) error {
+
+	if source.Helm != nil {
+		if err := validateApplicationSourceHelm(source.Path, *source.Helm); err != nil {
+			return err
+		}
+	}
+
+	if source.Kustomize != nil {
+		if err := validateApplicationSourceKustomize(*source.Kustomize); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ValidateIgnoreDifferences verifies that each entry of ignoreDifferences can be used by Argo CD. It is used by both the
+// webhook and the backend, which reports the error as a user error.
+func ValidateIgnoreDifferences(ignoreDifferences IgnoreDifferences) error {
+
+	for _, ignoreDifference := range ignoreDifferences {
+
+		if ignoreDifference.Kind == "" || (len(ignoreDifference.JSONPointers) == 0 && len(ignoreDifference.JQPathExpressions) == 0) {
+			return fmt.Errorf(error_invalid_ignore_differences)
+		}
+
+		for _, jsonPointer := range ignoreDifference.JSONPointers {
+			if !strings.HasPrefix(jsonPointer, "/") {
+				return fmt.Errorf(error_invalid_ignore_differences_pointer)
+			}
+		}
+	}
+
+	return nil
+}
+
+// SyncWindowScheduleParser parses sync window schedules using the same standard 5-field cron format as Argo CD. It is
+// used by both the webhook and the backend, which evaluates the sync windows.
+var SyncWindowScheduleParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
+
+// validateSyncWindows verifies the fields of each sync window.
+func validateSyncWindows(syncWindows []SyncWindow) error {
+
+	for _, syncWindow := range syncWindows {
+
+		if syncWindow.Kind != SyncWindowKind_Allow && syncWindow.Kind != SyncWindowKind_Deny {
+			return fmt.Errorf(error_invalid_sync_window_kind)
+		}
+
+		if strings.TrimSpace(syncWindow.Schedule) == "" {
+			return fmt.Errorf(error_invalid_sync_window_schedule)
+		}
+
+		if _, err := SyncWindowScheduleParser.Parse(syncWindow.Schedule); err != nil {
+			return fmt.Errorf(error_invalid_sync_window_schedule)
+		}
+
+		if duration, err := time.ParseDuration(syncWindow.Duration); err != nil || duration <= 0 {
+			return fmt.Errorf(error_invalid_sync_window_duration)
+		}
+
+		if syncWindow.TimeZone != "" {
+			if _, err := time.LoadLocation(syncWindow.TimeZone); err != nil {
+				return fmt.Errorf(error_invalid_sync_window_time_zone)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateDependsOn verifies that each dependency is a valid GitOpsDeployment name, other than the name of the
+// GitOpsDeployment itself, and that no dependency is listed twice.
+func validateDependsOn(name string, dependsOn []string) error {
+
+	dependencies := map[string]bool{}
+
+	for _, dependency := range dependsOn {
+
+		if len(validation.IsDNS1123Subdomain(dependency)) != 0 {
+			return fmt.Errorf(error_invalid_depends_on_name)
+		}
+
+		if dependency == name {
+			return fmt.Errorf(error_invalid_depends_on_self)
+		}
+
+		if dependencies[dependency] {
+			return fmt.Errorf(error_invalid_depends_on_duplicate)
+		}
+		dependencies[dependency] = true
+	}
+
+	return nil
+}
+
+// validateDependsOnCycle rejects the GitOpsDeployment if its dependencies (directly or transitively) depend on the
+// GitOpsDeployment itself. Dependencies which do not (yet) exist are ignored. The GitOpsDeployment is rejected if its
+// dependencies cannot be read.
+func (r *GitOpsDeployment) validateDependsOnCycle(ctx context.Context, reader client.Reader) error {
+
+	if len(r.Spec.DependsOn) == 0 {
+		return nil
+	}
+
+	if reader == nil {
+		return fmt.Errorf("unable to verify .spec.dependsOn: no client is available to read GitOpsDeployments")
+	}
+
+	getDependsOn := func(name string) ([]string, error) {
+
+		gitopsDepl := &GitOpsDeployment{}
+		if err := reader.Get(ctx, types.NamespacedName{Namespace: r.Namespace, Name: name}, gitopsDepl); err != nil {
+			if apierr.IsNotFound(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+
+		return gitopsDepl.Spec.DependsOn, nil
+	}
+
+	cycle, err := findDependsOnCycle(r.Name, r.Spec.DependsOn, getDependsOn)
+	if err != nil {
+		return fmt.Errorf("unable to verify .spec.dependsOn: %v", err)
+	}
+
+	if len(cycle) > 0 {
+		return fmt.Errorf("%s: %s", error_invalid_depends_on_cycle, strings.Join(cycle, " -> "))
+	}
+
+	return nil
+}
+
+// findDependsOnCycle walks the dependency graph, starting from the dependencies of 'name', and returns the cycle
+// (as a list of names, beginning and ending with 'name') if 'name' is reachable from its own dependencies.
+// - getDependsOn returns the dependencies of the named GitOpsDeployment.
+func findDependsOnCycle(name string, dependsOn []string, getDependsOn func(name string) ([]string, error)) ([]string, error) {
+
+	visited := map[string]bool{name: true}
+
+	var visit func(path []string, dependencies []string) ([]string, error)
+
+	visit = func(path []string, dependencies []string) ([]string, error) {
+
+		for _, dependency := range dependencies {
+
+			if dependency == name {
+				return append(append([]string{}, path...), dependency), nil
+			}
+
+			if visited[dependency] {
+				continue
+			}
+			visited[dependency] = true
+
+			next, err := getDependsOn(dependency)
+			if err != nil {
+				return nil, err
+			}
+
+			cycle, err := visit(append(path, dependency), next)
+			if err != nil || cycle != nil {
+				return cycle, err
+			}
+		}
+
+		return nil, nil
+	}
+
+	return visit([]string{name}, dependsOn)
+}
+
+// ValidateRetryStrategy verifies the backoff of a retry strategy. It is used by both the webhook and the backend, which
+// reports the error as a user error.
+func ValidateRetryStrategy(retry RetryStrategy) error {
+
+	if retry.Backoff == nil {
+		return nil
+	}
+
+	var duration, maxDuration time.Duration
+	var err error
+
+	if retry.Backoff.Duration != "" {
+		if duration, err = ParseRetryDuration(retry.Backoff.Duration); err != nil || duration <= 0 {
+			return fmt.Errorf(error_invalid_retry_backoff_duration)
+		}
+	}
+
+	if retry.Backoff.MaxDuration != "" {
+		if maxDuration, err = ParseRetryDuration(retry.Backoff.MaxDuration); err != nil || maxDuration <= 0 {
+			return fmt.Errorf(error_invalid_retry_backoff_duration)
+		}
+	}
+
+	if retry.Backoff.Factor != nil && *retry.Backoff.Factor < 1 {
+		return fmt.Errorf(error_invalid_retry_backoff_factor)
+	}
+
+	if duration != 0 && maxDuration != 0 && maxDuration < duration {
+		return fmt.Errorf(error_invalid_retry_backoff_max_duration)
+	}
+
+	return nil
+}
+
+// isValueFileOutsideOfRepository returns true if the relative path of a value file, resolved (as Argo CD does)
+// against the path of the source, is outside of the root of the repository: for example, '../../envs/prod/values.yaml'
+// is within the repository if the path of the source is 'apps/my-app'. A value file which begins with a
+// '$(ref)/' prefix is resolved against the root of the source with that ref.
+func isValueFileOutsideOfRepository(sourcePath string, valueFile string) bool {
+
+	if strings.HasPrefix(valueFile, "$") {
+		_, valueFile, _ = strings.Cut(valueFile, "/")
+		sourcePath = ""
+	}
+
+	resolvedPath := path.Join(sourcePath, valueFile)
+
+	return resolvedPath == ".." || strings.HasPrefix(resolvedPath, "../")
+}
+
+func validateApplicationSourceHelm(sourcePath string, helm ApplicationSourceHelm) error {
+
+	for _, valueFile := range helm.ValueFiles {
+		if valueFile == "" || filepath.IsAbs(valueFile) || isValueFileOutsideOfRepository(sourcePath, valueFile) {
+			return fmt.Errorf(error_invalid_helm_value_file)
+		}
+	}
+
+	for _, parameter := range helm.Parameters {
+		if parameter.Name == "" || strings.ContainsAny(parameter.Name, unsupportedSourceCharacters) ||
+			strings.ContainsAny(parameter.Value, unsupportedSourceCharacters) {
+			return fmt.Errorf(error_invalid_helm_parameter)
+		}
+	}
+
+	if strings.ContainsAny(helm.ReleaseName, unsupportedSourceCharacters) {
+		return fmt.Errorf(error_invalid_helm_release_name)
+	}
+
+	if helm.Values != "" {
+		values := map[string]interface{}{}
+		if err := yaml.Unmarshal([]byte(helm.Values), &values); err != nil {
+			return fmt.Errorf(error_invalid_helm_values)
+		}
+	}
+
+	return nil
+}
+
+func validateApplicationSourceKustomize(kustomize ApplicationSourceKustomize) error {
+
+	if strings.ContainsAny(kustomize.NamePrefix, unsupportedSourceCharacters) ||
+		strings.ContainsAny(kustomize.NameSuffix, unsupportedSourceCharacters) {
+		return fmt.Errorf(error_invalid_kustomize_name_affix)
+	}
+
+	for _, image := range kustomize.Images {
+		if image == "" || strings.ContainsAny(string(image), " \t"+unsupportedSourceCharacters) {
+			return fmt.Errorf(error_invalid_kustomize_image)
+		}
+	}
+
+	for key, value := range kustomize.CommonLabels {
+		if len(validation.IsQualifiedName(key)) != 0 || len(validation.IsValidLabelValue(value)) != 0 {
+			return fmt.Errorf(error_invalid_kustomize_common_labels)
+		}
+	}
+
+	for key, value := range kustomize.CommonAnnotations {
+		if len(validation.IsQualifiedName(key)) != 0 || strings.ContainsAny(value, unsupportedSourceCharacters) {
+			return fmt.Errorf(error_invalid_kustomize_common_annotations)
+		}
+	}
+
+	for _, replica := range kustomize.Replicas {
+		if replica.Name == "" || strings.ContainsAny(replica.Name, unsupportedSourceCharacters) || replica.Count < 0 {
+			return fmt.Errorf(error_invalid_kustomize_replicas)
+		}
+	}
+
 	return nil
 }
@@ -70,6 +70,190 @@ var _ = Describe("GitOpsDeployment validation webhook", func() {
 
 	})
 
+	Context("Create GitOpsDeployment CR with valid .spec.syncPolicy field", func() {
+		It("Should succeed if the sync options are supported and the automated policy is specified for an automated GitOpsDeployment", func() {
+			prune, selfHeal := false, true
+			gitopsDepl.Spec.Type = GitOpsDeploymentSpecType_Automated
+			gitopsDepl.Spec.SyncPolicy = &SyncPolicy{
+				SyncOptions: SyncOptions{
+					SyncOptions_ServerSideApply_true,
+					SyncOptions_PruneLast_true,
+					SyncOptions_ApplyOutOfSyncOnly_true,
+					SyncOptions_Replace_false,
+					SyncOptions_RespectIgnoreDifferences_true,
+				},
+				Automated: &SyncPolicyAutomated{
+					Prune:    &prune,
+					SelfHeal: &selfHeal,
+				},
+			}
+
+			err := k8sClient.Create(ctx, gitopsDepl)
+			Expect(err).Should(Succeed())
+
+			err = k8sClient.Delete(context.Background(), gitopsDepl)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Context("Create GitOpsDeployment CR with .spec.syncPolicy.automated field and manual .spec.Type", func() {
+		It("Should fail with error saying that the automated policy may only be specified for automated GitOpsDeployments", func() {
+			prune := false
+			gitopsDepl.Spec.Type = GitOpsDeploymentSpecType_Manual
+			gitopsDepl.Spec.SyncPolicy = &SyncPolicy{
+				Automated: &SyncPolicyAutomated{
+					Prune: &prune,
+				},
+			}
+
+			err := k8sClient.Create(ctx, gitopsDepl)
+			Expect(err).Should(Not(Succeed()))
+			Expect(err.Error()).Should(ContainSubstring(GitOpsDeploymentUserError_AutomatedNotAutomated))
+		})
+	})
+
+	Context("Create GitOpsDeployment CR with .spec.syncPolicy.retry field", func() {
+		BeforeEach(func() {
+			gitopsDepl.Spec.Type = GitOpsDeploymentSpecType_Automated
+		})
+
+		It("Should fail with error if the backoff duration is invalid", func() {
+			gitopsDepl.Spec.SyncPolicy = &SyncPolicy{
+				Retry: &RetryStrategy{Limit: 5, Backoff: &Backoff{Duration: "five seconds"}},
+			}
+
+			err := k8sClient.Create(ctx, gitopsDepl)
+			Expect(err).Should(Not(Succeed()))
+			Expect(err.Error()).Should(ContainSubstring(error_invalid_retry_backoff_duration))
+		})
+
+		It("Should fail with error if the backoff factor is less than 1", func() {
+			factor := int64(0)
+			gitopsDepl.Spec.SyncPolicy = &SyncPolicy{
+				Retry: &RetryStrategy{Limit: 5, Backoff: &Backoff{Duration: "5s", Factor: &factor}},
+			}
+
+			err := k8sClient.Create(ctx, gitopsDepl)
+			Expect(err).Should(Not(Succeed()))
+			Expect(err.Error()).Should(ContainSubstring(error_invalid_retry_backoff_factor))
+		})
+
+		It("Should fail with error if the backoff maxDuration is less than the duration", func() {
+			gitopsDepl.Spec.SyncPolicy = &SyncPolicy{
+				Retry: &RetryStrategy{Limit: 5, Backoff: &Backoff{Duration: "2m", MaxDuration: "30"}},
+			}
+
+			err := k8sClient.Create(ctx, gitopsDepl)
+			Expect(err).Should(Not(Succeed()))
+			Expect(err.Error()).Should(ContainSubstring(error_invalid_retry_backoff_max_duration))
+		})
+
+		It("Should succeed if the retry strategy is valid", func() {
+			factor := int64(2)
+			gitopsDepl.Spec.SyncPolicy = &SyncPolicy{
+				Retry: &RetryStrategy{Limit: 5, Backoff: &Backoff{Duration: "10", Factor: &factor, MaxDuration: "5m"}},
+			}
+
+			err := k8sClient.Create(ctx, gitopsDepl)
+			Expect(err).Should(Succeed())
+
+			err = k8sClient.Delete(context.Background(), gitopsDepl)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Context("Create GitOpsDeployment CR with .spec.ignoreDifferences field", func() {
+
+		It("Should fail with error if neither JSON pointers nor JQ path expressions are specified", func() {
+			gitopsDepl.Spec.IgnoreDifferences = IgnoreDifferences{
+				{Group: "apps", Kind: "Deployment"},
+			}
+
+			err := k8sClient.Create(ctx, gitopsDepl)
+			Expect(err).Should(Not(Succeed()))
+			Expect(err.Error()).Should(ContainSubstring(error_invalid_ignore_differences))
+		})
+
+		It("Should fail with error if a JSON pointer does not begin with '/'", func() {
+			gitopsDepl.Spec.IgnoreDifferences = IgnoreDifferences{
+				{Group: "apps", Kind: "Deployment", JSONPointers: []string{"spec/replicas"}},
+			}
+
+			err := k8sClient.Create(ctx, gitopsDepl)
+			Expect(err).Should(Not(Succeed()))
+			Expect(err.Error()).Should(ContainSubstring(error_invalid_ignore_differences_pointer))
+		})
+
+		It("Should succeed if the ignored differences are valid", func() {
+			gitopsDepl.Spec.IgnoreDifferences = IgnoreDifferences{
+				{Group: "apps", Kind: "Deployment", JSONPointers: []string{"/spec/replicas"}},
+				{Kind: "ConfigMap", Name: "generated", JQPathExpressions: []string{".data.timestamp"}},
+			}
+
+			err := k8sClient.Create(ctx, gitopsDepl)
+			Expect(err).Should(Succeed())
+
+			err = k8sClient.Delete(context.Background(), gitopsDepl)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Context("Create GitOpsDeployment CR with .spec.syncWindows field", func() {
+
+		It("Should fail with error if the kind is neither allow nor deny", func() {
+			gitopsDepl.Spec.SyncWindows = []SyncWindow{
+				{Kind: "block", Schedule: "0 9 * * 1-5", Duration: "8h"},
+			}
+
+			err := k8sClient.Create(ctx, gitopsDepl)
+			Expect(err).Should(Not(Succeed()))
+			Expect(err.Error()).Should(ContainSubstring(error_invalid_sync_window_kind))
+		})
+
+		It("Should fail with error if the schedule is not in cron format", func() {
+			gitopsDepl.Spec.SyncWindows = []SyncWindow{
+				{Kind: SyncWindowKind_Deny, Schedule: "every weekday at 9", Duration: "8h"},
+			}
+
+			err := k8sClient.Create(ctx, gitopsDepl)
+			Expect(err).Should(Not(Succeed()))
+			Expect(err.Error()).Should(ContainSubstring(error_invalid_sync_window_schedule))
+		})
+
+		It("Should fail with error if the duration is invalid", func() {
+			gitopsDepl.Spec.SyncWindows = []SyncWindow{
+				{Kind: SyncWindowKind_Deny, Schedule: "0 9 * * 1-5", Duration: "eight hours"},
+			}
+
+			err := k8sClient.Create(ctx, gitopsDepl)
+			Expect(err).Should(Not(Succeed()))
+			Expect(err.Error()).Should(ContainSubstring(error_invalid_sync_window_duration))
+		})
+
+		It("Should fail with error if the time zone is invalid", func() {
+			gitopsDepl.Spec.SyncWindows = []SyncWindow{
+				{Kind: SyncWindowKind_Deny, Schedule: "0 9 * * 1-5", Duration: "8h", TimeZone: "Mars/Olympus_Mons"},
+			}
+
+			err := k8sClient.Create(ctx, gitopsDepl)
+			Expect(err).Should(Not(Succeed()))
+			Expect(err.Error()).Should(ContainSubstring(error_invalid_sync_window_time_zone))
+		})
+
+		It("Should succeed if the sync windows are valid", func() {
+			gitopsDepl.Spec.SyncWindows = []SyncWindow{
+				{Kind: SyncWindowKind_Deny, Schedule: "0 9 * * 1-5", Duration: "8h", ManualSync: true, TimeZone: "Europe/London"},
+				{Kind: SyncWindowKind_Allow, Schedule: "0 22 * * *", Duration: "1h"},
+			}
+
+			err := k8sClient.Create(ctx, gitopsDepl)
+			Expect(err).Should(Succeed())
+
+			err = k8sClient.Delete(context.Background(), gitopsDepl)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
 	Context("Update GitOpsDeployment CR with invalid .spec.Type field", func() {
 		It("Should fail with error saying spec type must be manual or automated", func() {
 			gitopsDepl.Spec.Type = GitOpsDeploymentSpecType_Automated
@@ -149,4 +333,346 @@ var _ = Describe("GitOpsDeployment validation webhook", func() {
 			Expect(err).ToNot(HaveOccurred())
 		})
 	})
+
+	Context("Create GitOpsDeployment CR with both .spec.source.path and .spec.source.chart fields", func() {
+		It("Should fail with error saying that path and chart cannot both be specified", func() {
+			gitopsDepl.Spec.Type = GitOpsDeploymentSpecType_Automated
+			gitopsDepl.Spec.Source.Path = "resources/test-data/sample-gitops-repository/environments/overlays/dev"
+			gitopsDepl.Spec.Source.Chart = "my-chart"
+
+			err := k8sClient.Create(ctx, gitopsDepl)
+			Expect(err).Should(Not(Succeed()))
+			Expect(err.Error()).Should(ContainSubstring(GitOpsDeploymentUserError_PathAndChart))
+		})
+	})
+
+	Context("Create GitOpsDeployment CR with invalid .spec.source.helm field", func() {
+		BeforeEach(func() {
+			gitopsDepl.Spec.Type = GitOpsDeploymentSpecType_Automated
+			gitopsDepl.Spec.Source.Chart = "my-chart"
+		})
+
+		It("Should fail with error if a value file is not a relative path within the repository", func() {
+			gitopsDepl.Spec.Source.Helm = &ApplicationSourceHelm{
+				ValueFiles: []string{"../../secrets/values.yaml"},
+			}
+
+			err := k8sClient.Create(ctx, gitopsDepl)
+			Expect(err).Should(Not(Succeed()))
+			Expect(err.Error()).Should(ContainSubstring(error_invalid_helm_value_file))
+		})
+
+		It("Should succeed if a value file is outside of the path of the source, but within the repository", func() {
+			gitopsDepl.Spec.Source.Chart = ""
+			gitopsDepl.Spec.Source.Path = "apps/my-app"
+			gitopsDepl.Spec.Source.Helm = &ApplicationSourceHelm{
+				ValueFiles: []string{"../../envs/prod/values.yaml"},
+			}
+
+			err := k8sClient.Create(ctx, gitopsDepl)
+			Expect(err).Should(Succeed())
+
+			err = k8sClient.Delete(context.Background(), gitopsDepl)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("Should fail with error if a value file, relative to the path of the source, is outside of the repository", func() {
+			gitopsDepl.Spec.Source.Chart = ""
+			gitopsDepl.Spec.Source.Path = "apps/my-app"
+			gitopsDepl.Spec.Source.Helm = &ApplicationSourceHelm{
+				ValueFiles: []string{"../../../values.yaml"},
+			}
+
+			err := k8sClient.Create(ctx, gitopsDepl)
+			Expect(err).Should(Not(Succeed()))
+			Expect(err.Error()).Should(ContainSubstring(error_invalid_helm_value_file))
+		})
+
+		It("Should fail with error if a parameter has an empty name", func() {
+			gitopsDepl.Spec.Source.Helm = &ApplicationSourceHelm{
+				Parameters: []HelmParameter{{Name: "", Value: "value"}},
+			}
+
+			err := k8sClient.Create(ctx, gitopsDepl)
+			Expect(err).Should(Not(Succeed()))
+			Expect(err.Error()).Should(ContainSubstring(error_invalid_helm_parameter))
+		})
+
+		It("Should fail with error if a parameter value contains an unsupported character", func() {
+			gitopsDepl.Spec.Source.Helm = &ApplicationSourceHelm{
+				Parameters: []HelmParameter{{Name: "image.tag", Value: "v1;v2"}},
+			}
+
+			err := k8sClient.Create(ctx, gitopsDepl)
+			Expect(err).Should(Not(Succeed()))
+			Expect(err.Error()).Should(ContainSubstring(error_invalid_helm_parameter))
+		})
+
+		It("Should fail with error if the values are not a valid YAML object", func() {
+			gitopsDepl.Spec.Source.Helm = &ApplicationSourceHelm{
+				Values: "- a\n- list",
+			}
+
+			err := k8sClient.Create(ctx, gitopsDepl)
+			Expect(err).Should(Not(Succeed()))
+			Expect(err.Error()).Should(ContainSubstring(error_invalid_helm_values))
+		})
+
+		It("Should succeed if the helm field is valid", func() {
+			gitopsDepl.Spec.Source.Helm = &ApplicationSourceHelm{
+				ValueFiles:  []string{"values-prod.yaml", "overlays/../values..yaml"},
+				ReleaseName: "my-release",
+				Values:      "replicaCount: 2",
+				Parameters:  []HelmParameter{{Name: "image.tag", Value: "v1"}},
+			}
+
+			err := k8sClient.Create(ctx, gitopsDepl)
+			Expect(err).Should(Succeed())
+
+			err = k8sClient.Delete(context.Background(), gitopsDepl)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Context("Create GitOpsDeployment CR with invalid .spec.source.kustomize field", func() {
+		BeforeEach(func() {
+			gitopsDepl.Spec.Type = GitOpsDeploymentSpecType_Automated
+		})
+
+		It("Should fail with error if an image override is invalid", func() {
+			gitopsDepl.Spec.Source.Kustomize = &ApplicationSourceKustomize{
+				Images: KustomizeImages{"quay.io/org/app: v2"},
+			}
+
+			err := k8sClient.Create(ctx, gitopsDepl)
+			Expect(err).Should(Not(Succeed()))
+			Expect(err.Error()).Should(ContainSubstring(error_invalid_kustomize_image))
+		})
+
+		It("Should fail with error if a common label is not a valid Kubernetes label", func() {
+			gitopsDepl.Spec.Source.Kustomize = &ApplicationSourceKustomize{
+				CommonLabels: map[string]string{"team": "not a valid value"},
+			}
+
+			err := k8sClient.Create(ctx, gitopsDepl)
+			Expect(err).Should(Not(Succeed()))
+			Expect(err.Error()).Should(ContainSubstring(error_invalid_kustomize_common_labels))
+		})
+
+		It("Should fail with error if a common annotation key is invalid", func() {
+			gitopsDepl.Spec.Source.Kustomize = &ApplicationSourceKustomize{
+				CommonAnnotations: map[string]string{"invalid key": "value"},
+			}
+
+			err := k8sClient.Create(ctx, gitopsDepl)
+			Expect(err).Should(Not(Succeed()))
+			Expect(err.Error()).Should(ContainSubstring(error_invalid_kustomize_common_annotations))
+		})
+
+		It("Should fail with error if a common annotation value contains an unsupported character", func() {
+			gitopsDepl.Spec.Source.Kustomize = &ApplicationSourceKustomize{
+				CommonAnnotations: map[string]string{"example.com/owner": "team \"payments\""},
+			}
+
+			err := k8sClient.Create(ctx, gitopsDepl)
+			Expect(err).Should(Not(Succeed()))
+			Expect(err.Error()).Should(ContainSubstring(error_invalid_kustomize_common_annotations))
+		})
+
+		It("Should fail with error if the name prefix contains an unsupported character", func() {
+			gitopsDepl.Spec.Source.Kustomize = &ApplicationSourceKustomize{
+				NamePrefix: "prod&",
+			}
+
+			err := k8sClient.Create(ctx, gitopsDepl)
+			Expect(err).Should(Not(Succeed()))
+			Expect(err.Error()).Should(ContainSubstring(error_invalid_kustomize_name_affix))
+		})
+
+		It("Should fail with error if a replica override has a negative count", func() {
+			gitopsDepl.Spec.Source.Kustomize = &ApplicationSourceKustomize{
+				Replicas: KustomizeReplicas{{Name: "app", Count: -1}},
+			}
+
+			err := k8sClient.Create(ctx, gitopsDepl)
+			Expect(err).Should(Not(Succeed()))
+			Expect(err.Error()).Should(ContainSubstring(error_invalid_kustomize_replicas))
+		})
+
+		It("Should succeed if the kustomize field is valid", func() {
+			gitopsDepl.Spec.Source.Kustomize = &ApplicationSourceKustomize{
+				NamePrefix:        "prod-",
+				Images:            KustomizeImages{"quay.io/org/app:v2"},
+				CommonLabels:      map[string]string{"app.kubernetes.io/part-of": "payments"},
+				CommonAnnotations: map[string]string{"example.com/owner": "team payments"},
+				Replicas:          KustomizeReplicas{{Name: "app", Count: 3}},
+			}
+
+			err := k8sClient.Create(ctx, gitopsDepl)
+			Expect(err).Should(Succeed())
+
+			err = k8sClient.Delete(context.Background(), gitopsDepl)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Context("Create GitOpsDeployment CR with .spec.sources field", func() {
+		BeforeEach(func() {
+			gitopsDepl.Spec.Type = GitOpsDeploymentSpecType_Automated
+		})
+
+		It("Should fail with error if both .spec.source and .spec.sources are specified", func() {
+			gitopsDepl.Spec.Source = ApplicationSource{
+				RepoURL: "https://github.com/redhat-appstudio/managed-gitops",
+				Path:    "resources/test-data/sample-gitops-repository/environments/overlays/dev",
+			}
+			gitopsDepl.Spec.Sources = ApplicationSources{gitopsDepl.Spec.Source}
+
+			err := k8sClient.Create(ctx, gitopsDepl)
+			Expect(err).Should(Not(Succeed()))
+			Expect(err.Error()).Should(ContainSubstring(GitOpsDeploymentUserError_SourceAndSources))
+		})
+
+		It("Should fail with error if .spec.source.ref is specified", func() {
+			gitopsDepl.Spec.Source = ApplicationSource{
+				RepoURL: "https://github.com/redhat-appstudio/managed-gitops",
+				Ref:     "values",
+			}
+
+			err := k8sClient.Create(ctx, gitopsDepl)
+			Expect(err).Should(Not(Succeed()))
+			Expect(err.Error()).Should(ContainSubstring(GitOpsDeploymentUserError_RefInSource))
+		})
+
+		It("Should fail with error if a source specifies both a path and a chart", func() {
+			gitopsDepl.Spec.Sources = ApplicationSources{{
+				RepoURL: "https://github.com/redhat-appstudio/managed-gitops",
+				Path:    "resources/test-data/sample-gitops-repository/environments/overlays/dev",
+				Chart:   "my-chart",
+			}}
+
+			err := k8sClient.Create(ctx, gitopsDepl)
+			Expect(err).Should(Not(Succeed()))
+			Expect(err.Error()).Should(ContainSubstring(GitOpsDeploymentUserError_SourcesPathAndChart))
+		})
+
+		It("Should fail with error if a source has invalid helm values", func() {
+			gitopsDepl.Spec.Sources = ApplicationSources{{
+				RepoURL: "https://charts.example.com",
+				Chart:   "my-chart",
+				Helm:    &ApplicationSourceHelm{Values: "- a\n- list"},
+			}}
+
+			err := k8sClient.Create(ctx, gitopsDepl)
+			Expect(err).Should(Not(Succeed()))
+			Expect(err.Error()).Should(ContainSubstring(error_invalid_helm_values))
+		})
+
+		It("Should succeed if the sources combine a Helm chart with a values repository", func() {
+			gitopsDepl.Spec.Sources = ApplicationSources{
+				{
+					RepoURL:        "https://charts.example.com",
+					Chart:          "my-chart",
+					TargetRevision: "1.2.3",
+					Helm:           &ApplicationSourceHelm{ValueFiles: []string{"$values/environments/prod/values.yaml"}},
+				},
+				{
+					RepoURL: "https://github.com/redhat-appstudio/managed-gitops",
+					Ref:     "values",
+				},
+			}
+
+			err := k8sClient.Create(ctx, gitopsDepl)
+			Expect(err).Should(Succeed())
+
+			err = k8sClient.Delete(context.Background(), gitopsDepl)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Context("Create GitOpsDeployment CR with .spec.dependsOn field", func() {
+		BeforeEach(func() {
+			gitopsDepl.Spec.Type = GitOpsDeploymentSpecType_Automated
+		})
+
+		It("Should fail with error if a dependency is not a valid name", func() {
+			gitopsDepl.Spec.DependsOn = []string{"Not_A_Valid_Name"}
+
+			err := k8sClient.Create(ctx, gitopsDepl)
+			Expect(err).Should(Not(Succeed()))
+			Expect(err.Error()).Should(ContainSubstring(error_invalid_depends_on_name))
+		})
+
+		It("Should fail with error if the GitOpsDeployment depends on itself", func() {
+			gitopsDepl.Spec.DependsOn = []string{gitopsDepl.Name}
+
+			err := k8sClient.Create(ctx, gitopsDepl)
+			Expect(err).Should(Not(Succeed()))
+			Expect(err.Error()).Should(ContainSubstring(error_invalid_depends_on_self))
+		})
+
+		It("Should fail with error if a dependency is listed twice", func() {
+			gitopsDepl.Spec.DependsOn = []string{"database", "database"}
+
+			err := k8sClient.Create(ctx, gitopsDepl)
+			Expect(err).Should(Not(Succeed()))
+			Expect(err.Error()).Should(ContainSubstring(error_invalid_depends_on_duplicate))
+		})
+
+		It("Should succeed if a dependency does not exist yet, and fail if a dependency cycle is introduced", func() {
+			gitopsDepl.Spec.DependsOn = []string{"database"}
+
+			err := k8sClient.Create(ctx, gitopsDepl)
+			Expect(err).Should(Succeed())
+
+			By("creating a dependency which depends on a GitOpsDeployment which depends on the first")
+			database := &GitOpsDeployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "database", Namespace: gitopsDepl.Namespace},
+				Spec: GitOpsDeploymentSpec{
+					Type:      GitOpsDeploymentSpecType_Automated,
+					DependsOn: []string{"storage"},
+				},
+			}
+			err = k8sClient.Create(ctx, database)
+			Expect(err).Should(Succeed())
+
+			storage := &GitOpsDeployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "storage", Namespace: gitopsDepl.Namespace},
+				Spec: GitOpsDeploymentSpec{
+					Type:      GitOpsDeploymentSpecType_Automated,
+					DependsOn: []string{gitopsDepl.Name},
+				},
+			}
+			err = k8sClient.Create(ctx, storage)
+			Expect(err).Should(Not(Succeed()))
+			Expect(err.Error()).Should(ContainSubstring(error_invalid_depends_on_cycle))
+
+			By("removing the dependency which would have introduced the cycle")
+			storage.Spec.DependsOn = nil
+			err = k8sClient.Create(ctx, storage)
+			Expect(err).Should(Succeed())
+
+			for _, obj := range []client.Object{gitopsDepl, database, storage} {
+				err = k8sClient.Delete(context.Background(), obj)
+				Expect(err).ToNot(HaveOccurred())
+			}
+		})
+	})
+})
+
+var _ = Describe("isValueFileOutsideOfRepository", func() {
+
+	DescribeTable("should resolve the value file against the path of the source",
+		func(sourcePath string, valueFile string, expected bool) {
+			Expect(isValueFileOutsideOfRepository(sourcePath, valueFile)).To(Equal(expected))
+		},
+		Entry("a value file in the path of the source", "apps/my-app", "values.yaml", false),
+		Entry("a value file in another directory of the repository", "apps/my-app", "../../envs/prod/values.yaml", false),
+		Entry("a value file whose name contains '..'", "", "values..yaml", false),
+		Entry("a value file outside of the repository", "apps/my-app", "../../../values.yaml", true),
+		Entry("a value file outside of the repository, via the root", "apps", "../envs/../../values.yaml", true),
+		Entry("a value file of a chart outside of the repository", "", "../values.yaml", true),
+		Entry("a value file of a ref within the repository", "apps/my-app", "$values/envs/prod/values.yaml", false),
+		Entry("a value file of a ref outside of the repository", "apps/my-app", "$values/../values.yaml", true),
+	)
 })
@@ -0,0 +1,114 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GitOpsDeploymentResourceTreeStatus is the resource tree of a GitOpsDeployment: the resources that are deployed by
+// the GitOpsDeployment, and the resources that are (directly or indirectly) owned by them. For example, the
+// ReplicaSets and Pods of a Deployment.
+type GitOpsDeploymentResourceTreeStatus struct {
+
+	// Nodes is the list of resources of the tree. The parents of a resource are referenced by its .parentRefs field.
+	Nodes []ResourceTreeNode `json:"nodes,omitempty"`
+
+	// Truncated is true if the tree contained more resources than can be stored in the GitOpsDeploymentResourceTree,
+	// in which case only the first resources of the tree are listed, or if the health or hook message of a resource was
+	// too long, in which case the message is truncated.
+	Truncated bool `json:"truncated,omitempty"`
+
+	// LastUpdated is the last time the tree was updated
+	LastUpdated *metav1.Time `json:"lastUpdated,omitempty"`
+}
+
+// ResourceTreeNode is a resource of the resource tree of a GitOpsDeployment.
+type ResourceTreeNode struct {
+	ResourceTreeNodeRef `json:",inline"`
+
+	// Version is the API version of the resource
+	Version string `json:"version,omitempty"`
+
+	// ParentRefs are the resources which own this resource. It is empty for resources which are directly
+	// deployed by the GitOpsDeployment.
+	ParentRefs []ResourceTreeNodeRef `json:"parentRefs,omitempty"`
+
+	// Status is the sync status of the resource. It is only set for resources which are directly deployed by
+	// the GitOpsDeployment.
+	Status SyncStatusCode `json:"status,omitempty"`
+
+	// Health is the health of the resource, including a message which describes it (if any)
+	Health *HealthStatus `json:"health,omitempty"`
+
+	// RequiresPruning is true if the resource is no longer defined in the GitOps repository, and will be deleted
+	// if the GitOpsDeployment is synced with pruning enabled.
+	RequiresPruning bool `json:"requiresPruning,omitempty"`
+
+	// SyncWave is the sync wave of the resource (see the 'argocd.argoproj.io/sync-wave' annotation)
+	SyncWave int64 `json:"syncWave,omitempty"`
+
+	// Hook is set if the resource is a sync hook (see the 'argocd.argoproj.io/hook' annotation)
+	Hook *ResourceTreeNodeHook `json:"hook,omitempty"`
+}
+
+// ResourceTreeNodeRef references a resource of the resource tree.
+type ResourceTreeNodeRef struct {
+	Group     string `json:"group,omitempty"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	UID       string `json:"uid,omitempty"`
+}
+
+// ResourceTreeNodeHook is the result of a sync hook, during the last sync operation of the GitOpsDeployment.
+type ResourceTreeNodeHook struct {
+	// Type is the type of the hook, for example 'PreSync'. It is empty if the hook did not run during the last sync operation.
+	Type HookType `json:"type,omitempty"`
+	// Phase is the state of the hook, for example 'Succeeded'
+	Phase OperationPhase `json:"phase,omitempty"`
+	// Message contains an informational or error message for the hook
+	Message string `json:"message,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// GitOpsDeploymentResourceTree is the Schema for the gitopsdeploymentresourcetrees API.
+//
+// A GitOpsDeploymentResourceTree is maintained by the GitOps Service for every GitOpsDeployment: it has the same name
+// as the GitOpsDeployment, and is deleted along with it. The resource tree is stored outside of the GitOpsDeployment,
+// so that the tree of large deployments does not increase the size of the GitOpsDeployment itself.
+type GitOpsDeploymentResourceTree struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Status GitOpsDeploymentResourceTreeStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// GitOpsDeploymentResourceTreeList contains a list of GitOpsDeploymentResourceTree
+type GitOpsDeploymentResourceTreeList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GitOpsDeploymentResourceTree `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&GitOpsDeploymentResourceTree{}, &GitOpsDeploymentResourceTreeList{})
+}
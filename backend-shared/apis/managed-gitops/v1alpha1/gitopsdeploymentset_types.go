@@ -0,0 +1,183 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GitOpsDeploymentSetSpec defines the desired state of GitOpsDeploymentSet.
+//
+// Each generator produces a list of parameter sets: a GitOpsDeployment is generated from .spec.template for every
+// parameter set (of every generator). Within the template, '{{parameter}}' is replaced with the value of the parameter.
+type GitOpsDeploymentSetSpec struct {
+
+	// Generators is the list of generators which produce the parameters of the generated GitOpsDeployments.
+	Generators []GitOpsDeploymentSetGenerator `json:"generators"`
+
+	// Template is the template of the generated GitOpsDeployments.
+	Template GitOpsDeploymentSetTemplate `json:"template"`
+}
+
+// GitOpsDeploymentSetGenerator produces a list of parameter sets. Exactly one of the fields must be specified.
+type GitOpsDeploymentSetGenerator struct {
+
+	// List generates a parameter set for every element of the list.
+	List *ListGenerator `json:"list,omitempty"`
+
+	// GitDirectory generates a parameter set for every directory of a Git repository that matches the given paths.
+	GitDirectory *GitDirectoryGenerator `json:"gitDirectory,omitempty"`
+
+	// ManagedEnvironment generates a parameter set for every GitOpsDeploymentManagedEnvironment (in the namespace of
+	// the GitOpsDeploymentSet) that matches the given selector.
+	ManagedEnvironment *ManagedEnvironmentGenerator `json:"managedEnvironment,omitempty"`
+}
+
+// ListGenerator generates a parameter set for every element of the list: the keys of the element are the parameter names.
+type ListGenerator struct {
+	Elements []map[string]string `json:"elements"`
+}
+
+// GitDirectoryGenerator generates a parameter set for every directory of a Git repository that matches (at least) one
+// of the included paths, and none of the excluded paths. The following parameters are generated:
+// - 'path': the path of the directory, within the repository. For example: 'environments/staging'
+// - 'path.basename': the last element of the path. For example: 'staging'
+type GitDirectoryGenerator struct {
+
+	// RepoURL is the URL of the Git repository. If the repository is private, a GitOpsDeploymentRepositoryCredential
+	// for the repository must exist in the namespace of the GitOpsDeploymentSet.
+	RepoURL string `json:"repoURL"`
+
+	// Revision is the branch or tag of the repository. Defaults to the default branch (HEAD) of the repository.
+	Revision string `json:"revision,omitempty"`
+
+	// Directories is the list of paths to include (or exclude). A path may contain wildcards (see https://pkg.go.dev/path#Match),
+	// for example: 'environments/*'
+	Directories []GitDirectoryGeneratorItem `json:"directories"`
+}
+
+// GitDirectoryGeneratorItem is a path of a GitDirectoryGenerator.
+type GitDirectoryGeneratorItem struct {
+	Path string `json:"path"`
+
+	// Exclude is true if directories which match the path should be excluded.
+	Exclude bool `json:"exclude,omitempty"`
+}
+
+// ManagedEnvironmentGenerator generates a parameter set for every GitOpsDeploymentManagedEnvironment that matches the
+// selector. The following parameters are generated:
+// - 'name': the name of the GitOpsDeploymentManagedEnvironment, which may be used as .spec.destination.environment
+// - 'apiURL': the API URL of the cluster
+// - 'labels.(key)': the value of each label of the GitOpsDeploymentManagedEnvironment
+type ManagedEnvironmentGenerator struct {
+
+	// Selector selects the GitOpsDeploymentManagedEnvironments. If empty, all the GitOpsDeploymentManagedEnvironments
+	// of the namespace are selected.
+	Selector metav1.LabelSelector `json:"selector,omitempty"`
+}
+
+// GitOpsDeploymentSetTemplate is the template of the GitOpsDeployments that are generated by a GitOpsDeploymentSet.
+type GitOpsDeploymentSetTemplate struct {
+	Metadata GitOpsDeploymentSetTemplateMeta `json:"metadata"`
+
+	Spec GitOpsDeploymentSpec `json:"spec"`
+}
+
+// GitOpsDeploymentSetTemplateMeta is the metadata of the generated GitOpsDeployments.
+type GitOpsDeploymentSetTemplateMeta struct {
+
+	// Name is the name of the generated GitOpsDeployment. It must contain parameters which are unique for every
+	// parameter set, for example: '{{path.basename}}-deployment'
+	Name string `json:"name"`
+
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// GitOpsDeploymentSetStatus defines the observed state of GitOpsDeploymentSet
+type GitOpsDeploymentSetStatus struct {
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Summary summarizes the status of the generated GitOpsDeployments
+	Summary GitOpsDeploymentSetSummary `json:"summary,omitempty"`
+
+	// Deployments is the list of the generated GitOpsDeployments, and their status
+	Deployments []GitOpsDeploymentSetDeploymentStatus `json:"deployments,omitempty"`
+}
+
+// GitOpsDeploymentSetSummary summarizes the status of the GitOpsDeployments generated by a GitOpsDeploymentSet
+type GitOpsDeploymentSetSummary struct {
+	// Total is the number of generated GitOpsDeployments
+	Total int `json:"total"`
+	// Synced is the number of generated GitOpsDeployments which are Synced
+	Synced int `json:"synced"`
+	// Healthy is the number of generated GitOpsDeployments which are Healthy
+	Healthy int `json:"healthy"`
+}
+
+// GitOpsDeploymentSetDeploymentStatus is the status of a GitOpsDeployment that was generated by a GitOpsDeploymentSet
+type GitOpsDeploymentSetDeploymentStatus struct {
+	Name   string           `json:"name"`
+	Sync   SyncStatusCode   `json:"sync,omitempty"`
+	Health HealthStatusCode `json:"health,omitempty"`
+}
+
+const (
+	// GitOpsDeploymentSetConditionErrorOccurred is True if the GitOpsDeployments of the GitOpsDeploymentSet could not be generated
+	GitOpsDeploymentSetConditionErrorOccurred = "ErrorOccurred"
+)
+
+const (
+	GitOpsDeploymentSetReasonSucceeded        = "Succeeded"
+	GitOpsDeploymentSetReasonInvalidGenerator = "InvalidGenerator"
+	GitOpsDeploymentSetReasonGeneratorError   = "GeneratorError"
+	GitOpsDeploymentSetReasonInvalidTemplate  = "InvalidTemplate"
+	GitOpsDeploymentSetReasonNameConflict     = "NameConflict"
+	GitOpsDeploymentSetReasonKubeError        = "KubernetesError"
+)
+
+const (
+	// GitOpsDeploymentSetLabel is the label of the GitOpsDeployments that were generated by a GitOpsDeploymentSet:
+	// the value is the name of the GitOpsDeploymentSet (truncated, and suffixed with its hash, if it is longer than 63
+	// characters)
+	GitOpsDeploymentSetLabel = "managed-gitops.redhat.com/gitopsdeploymentset"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// GitOpsDeploymentSet is the Schema for the gitopsdeploymentsets API
+type GitOpsDeploymentSet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   GitOpsDeploymentSetSpec   `json:"spec,omitempty"`
+	Status GitOpsDeploymentSetStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// GitOpsDeploymentSetList contains a list of GitOpsDeploymentSet
+type GitOpsDeploymentSetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GitOpsDeploymentSet `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&GitOpsDeploymentSet{}, &GitOpsDeploymentSetList{})
+}
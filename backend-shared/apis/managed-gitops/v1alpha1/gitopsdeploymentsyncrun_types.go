@@ -27,13 +27,92 @@ type GitOpsDeploymentSyncRunSpec struct {
 
 	// Optional: If specified, tells the GitOps Service to deploy a particular git commit SHA
 	RevisionID string `json:"revisionID,omitempty"`
+
+	// Optional: If true, resources that are no longer defined in the GitOps repository are deleted from the cluster during the sync
+	Prune bool `json:"prune,omitempty"`
+
+	// Optional: If true, the sync is only simulated: no changes are made to the resources on the cluster
+	DryRun bool `json:"dryRun,omitempty"`
+
+	// Optional: If true, resources are forcefully applied: resources that cannot be patched are deleted and recreated
+	Force bool `json:"force,omitempty"`
+
+	// Optional: If true, resources are updated using 'kubectl replace'/'kubectl create', rather than 'kubectl apply'
+	Replace bool `json:"replace,omitempty"`
+
+	// Optional: If specified, only the given subset of the resources of the GitOpsDeployment is synchronized
+	Resources []SyncRunResource `json:"resources,omitempty"`
+
+	// Optional: sync options which apply only to this sync, for example 'ServerSideApply=true'.
+	// The same values as .spec.syncPolicy.syncOptions of GitOpsDeployment are supported.
+	SyncOptions SyncOptions `json:"syncOptions,omitempty"`
+
+	// Optional: If specified, the GitOpsDeployment is rolled back to a previously deployed revision from its
+	// .status.history, rather than synchronized to RevisionID.
+	// Only the 'prune' and 'dryRun' fields may be combined with a rollback.
+	RollbackTo *SyncRunRollback `json:"rollbackTo,omitempty"`
+}
+
+// SyncRunRollback identifies an entry of the deployment history (.status.history) of the GitOpsDeployment to roll back to.
+// Exactly one of ID or Revision should be specified.
+type SyncRunRollback struct {
+	// ID of the deployment history entry to roll back to
+	ID *int64 `json:"id,omitempty"`
+	// Revision (for example, a git commit SHA) to roll back to: the most recent deployment history entry with this revision is used
+	Revision string `json:"revision,omitempty"`
+}
+
+// SyncRunResource identifies a resource of the GitOpsDeployment which should be synchronized
+type SyncRunResource struct {
+	// Group of the resource (empty for resources of the core API group)
+	Group string `json:"group,omitempty"`
+	// Kind of the resource, for example 'Deployment'
+	Kind string `json:"kind"`
+	// Name of the resource
+	Name string `json:"name"`
+	// Namespace of the resource (empty for cluster-scoped resources)
+	Namespace string `json:"namespace,omitempty"`
 }
 
 // GitOpsDeploymentSyncRunStatus defines the observed state of GitOpsDeploymentSyncRun
 type GitOpsDeploymentSyncRunStatus struct {
 	Conditions []GitOpsDeploymentSyncRunCondition `json:"conditions,omitempty"`
+
+	// Phase is the current phase of the sync operation: Pending, Running, Succeeded, Failed or Terminated
+	Phase SyncRunPhase `json:"phase,omitempty"`
+
+	// Message contains a human-readable message describing the outcome of the sync operation
+	Message string `json:"message,omitempty"`
+
+	// StartedAt is the time at which the sync operation started
+	StartedAt *metav1.Time `json:"startedAt,omitempty"`
+
+	// FinishedAt is the time at which the sync operation completed
+	FinishedAt *metav1.Time `json:"finishedAt,omitempty"`
+
+	// Revision is the revision (git commit SHA) that was synced
+	Revision string `json:"revision,omitempty"`
+
+	// Resources contains the result of the sync operation for each individual resource
+	Resources ResourceResults `json:"resources,omitempty"`
 }
 
+// SyncRunPhase is the phase of the sync operation of a GitOpsDeploymentSyncRun
+type SyncRunPhase string
+
+const (
+	// SyncRunPhase_Pending indicates the sync operation has not yet been started by the GitOps Service
+	SyncRunPhase_Pending SyncRunPhase = "Pending"
+	// SyncRunPhase_Running indicates the sync operation is in progress
+	SyncRunPhase_Running SyncRunPhase = "Running"
+	// SyncRunPhase_Succeeded indicates the sync operation completed successfully
+	SyncRunPhase_Succeeded SyncRunPhase = "Succeeded"
+	// SyncRunPhase_Failed indicates the sync operation completed unsuccessfully
+	SyncRunPhase_Failed SyncRunPhase = "Failed"
+	// SyncRunPhase_Terminated indicates the sync operation was terminated before it completed
+	SyncRunPhase_Terminated SyncRunPhase = "Terminated"
+)
+
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 
@@ -68,6 +147,9 @@ type SyncRunReasonType string
 
 const (
 	SyncRunReasonErrorOccurred GitOpsDeploymentReasonType = "ErrorOccurred"
+
+	SyncRunReasonWaitingForDependencies SyncRunReasonType = "WaitingForDependencies"
+	SyncRunReasonDependenciesReady      SyncRunReasonType = "DependenciesReady"
 )
 
 // GitOpsDeploymentConditionType represents type of GitOpsDeployment condition.
@@ -75,6 +157,10 @@ type SyncRunConditionType string
 
 const (
 	GitOpsDeploymentSyncRunConditionErrorOccurred SyncRunConditionType = "ErrorOccurred"
+
+	// GitOpsDeploymentSyncRunConditionWaitingForDependencies is True while the sync is held, because one or more of
+	// the GitOpsDeployments in .spec.dependsOn of the GitOpsDeployment are not yet both Synced and Healthy.
+	GitOpsDeploymentSyncRunConditionWaitingForDependencies SyncRunConditionType = "WaitingForDependencies"
 )
 
 //+kubebuilder:object:root=true
@@ -18,6 +18,7 @@ package v1alpha1
 
 import (
 	"fmt"
+	"strings"
 
 	logutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util/log"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -29,6 +30,13 @@ import (
 const (
 	error_invalid_name = "name should not be zyxwvutsrqponmlkjihgfedcba-abcdefghijklmnoqrstuvwxyz"
 	invalid_name       = "zyxwvutsrqponmlkjihgfedcba-abcdefghijklmnoqrstuvwxyz"
+
+	error_invalid_syncrun_sync_option = "the specified sync option in .spec.syncOptions is either mispelled or is not supported by GitOpsDeploymentSyncRun"
+	error_invalid_syncrun_resource    = "each entry of .spec.resources must specify the kind and name of the resource"
+	error_invalid_syncrun_rollback    = "exactly one of .spec.rollbackTo.id or .spec.rollbackTo.revision must be specified"
+	error_invalid_syncrun_rollback_id = ".spec.rollbackTo.id must not be negative"
+
+	error_invalid_syncrun_rollback_with_options = ".spec.rollbackTo cannot be combined with the force, replace, resources or syncOptions fields"
 )
 
 // log is for logging in this package.
@@ -71,6 +79,11 @@ func (r *GitOpsDeploymentSyncRun) ValidateCreate() error {
 		return err
 	}
 
+	if err := r.validateGitOpsDeploymentSyncRun(); err != nil {
+		log.Info("webhook rejected invalid create", "error", fmt.Sprintf("%v", err))
+		return err
+	}
+
 	return nil
 }
 
@@ -81,6 +94,11 @@ func (r *GitOpsDeploymentSyncRun) ValidateUpdate(old runtime.Object) error {
 
 	log.V(logutil.LogLevel_Debug).Info("validate update")
 
+	if err := r.validateGitOpsDeploymentSyncRun(); err != nil {
+		log.Info("webhook rejected invalid update", "error", fmt.Sprintf("%v", err))
+		return err
+	}
+
 	return nil
 }
 
@@ -93,3 +111,36 @@ func (r *GitOpsDeploymentSyncRun) ValidateDelete() error {
 
 	return nil
 }
+
+func (r *GitOpsDeploymentSyncRun) validateGitOpsDeploymentSyncRun() error {
+
+	for _, syncOption := range r.Spec.SyncOptions {
+		if !IsSupportedSyncOption(syncOption) {
+			return fmt.Errorf(error_invalid_syncrun_sync_option)
+		}
+	}
+
+	for _, resource := range r.Spec.Resources {
+		if strings.TrimSpace(resource.Kind) == "" || strings.TrimSpace(resource.Name) == "" {
+			return fmt.Errorf(error_invalid_syncrun_resource)
+		}
+	}
+
+	if rollbackTo := r.Spec.RollbackTo; rollbackTo != nil {
+
+		if (rollbackTo.ID == nil) == (strings.TrimSpace(rollbackTo.Revision) == "") {
+			return fmt.Errorf(error_invalid_syncrun_rollback)
+		}
+
+		if rollbackTo.ID != nil && *rollbackTo.ID < 0 {
+			return fmt.Errorf(error_invalid_syncrun_rollback_id)
+		}
+
+		// Argo CD rollbacks only support the prune and dry run options
+		if r.Spec.Force || r.Spec.Replace || len(r.Spec.Resources) > 0 || len(r.Spec.SyncOptions) > 0 {
+			return fmt.Errorf(error_invalid_syncrun_rollback_with_options)
+		}
+	}
+
+	return nil
+}
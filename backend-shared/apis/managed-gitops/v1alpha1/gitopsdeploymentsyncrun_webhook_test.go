@@ -55,4 +55,65 @@ var _ = Describe("GitOpsDeploymentSyncRun validation webhook", func() {
 		})
 	})
 
+	Context("Create GitOpsDeploymentSyncRun CR with sync options", func() {
+		It("Should fail with error saying the sync option is not supported", func() {
+			gitopsDeplSyncRunCr.Name = "test-syncrun-sync-option"
+			gitopsDeplSyncRunCr.Spec.SyncOptions = SyncOptions{"Validate=maybe"}
+			err := k8sClient.Create(ctx, gitopsDeplSyncRunCr)
+
+			Expect(err).Should(Not(Succeed()))
+			Expect(err.Error()).Should(ContainSubstring(error_invalid_syncrun_sync_option))
+		})
+
+		It("Should fail with error saying the resource must specify a kind and name", func() {
+			gitopsDeplSyncRunCr.Name = "test-syncrun-resource"
+			gitopsDeplSyncRunCr.Spec.Resources = []SyncRunResource{{Group: "apps", Kind: "Deployment"}}
+			err := k8sClient.Create(ctx, gitopsDeplSyncRunCr)
+
+			Expect(err).Should(Not(Succeed()))
+			Expect(err.Error()).Should(ContainSubstring(error_invalid_syncrun_resource))
+		})
+
+		It("Should succeed with valid sync options and resources", func() {
+			gitopsDeplSyncRunCr.Name = "test-syncrun-valid-options"
+			gitopsDeplSyncRunCr.Spec.Prune = true
+			gitopsDeplSyncRunCr.Spec.DryRun = true
+			gitopsDeplSyncRunCr.Spec.SyncOptions = SyncOptions{SyncOptions_ServerSideApply_true}
+			gitopsDeplSyncRunCr.Spec.Resources = []SyncRunResource{{Group: "apps", Kind: "Deployment", Name: "my-deployment", Namespace: "my-namespace"}}
+			err := k8sClient.Create(ctx, gitopsDeplSyncRunCr)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Context("Create GitOpsDeploymentSyncRun CR with rollbackTo", func() {
+		It("Should fail with error saying exactly one of id or revision must be specified", func() {
+			historyID := int64(1)
+			gitopsDeplSyncRunCr.Name = "test-syncrun-rollback-both"
+			gitopsDeplSyncRunCr.Spec.RollbackTo = &SyncRunRollback{ID: &historyID, Revision: "HEAD"}
+			err := k8sClient.Create(ctx, gitopsDeplSyncRunCr)
+
+			Expect(err).Should(Not(Succeed()))
+			Expect(err.Error()).Should(ContainSubstring(error_invalid_syncrun_rollback))
+		})
+
+		It("Should fail with error saying rollbackTo cannot be combined with sync options", func() {
+			historyID := int64(1)
+			gitopsDeplSyncRunCr.Name = "test-syncrun-rollback-options"
+			gitopsDeplSyncRunCr.Spec.RollbackTo = &SyncRunRollback{ID: &historyID}
+			gitopsDeplSyncRunCr.Spec.SyncOptions = SyncOptions{SyncOptions_ServerSideApply_true}
+			err := k8sClient.Create(ctx, gitopsDeplSyncRunCr)
+
+			Expect(err).Should(Not(Succeed()))
+			Expect(err.Error()).Should(ContainSubstring(error_invalid_syncrun_rollback_with_options))
+		})
+
+		It("Should succeed with a valid rollbackTo", func() {
+			gitopsDeplSyncRunCr.Name = "test-syncrun-valid-rollback"
+			gitopsDeplSyncRunCr.Spec.Prune = true
+			gitopsDeplSyncRunCr.Spec.RollbackTo = &SyncRunRollback{Revision: "0c3ef8b2a8d4f1a0c5b3d3a3c9b2a7f1e6d5c4b3"}
+			err := k8sClient.Create(ctx, gitopsDeplSyncRunCr)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
 })
@@ -0,0 +1,131 @@
+package db
+
+import (
+	"context"
+	"fmt"
+)
+
+func (dbq *PostgreSQLDatabaseQueries) UnsafeListAllAppProjectSyncWindows(ctx context.Context, appProjectSyncWindows *[]AppProjectSyncWindow) error {
+
+	if err := validateUnsafeQueryParamsNoPK(dbq); err != nil {
+		return err
+	}
+
+	if err := dbq.dbConnection.Model(appProjectSyncWindows).Context(ctx).Select(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (dbq *PostgreSQLDatabaseQueries) CreateAppProjectSyncWindow(ctx context.Context, obj *AppProjectSyncWindow) error {
+
+	if err := validateQueryParamsEntity(obj, dbq); err != nil {
+		return err
+	}
+
+	if dbq.allowTestUuids {
+		if IsEmpty(obj.AppprojectSyncwindowID) {
+			obj.AppprojectSyncwindowID = generateUuid()
+		}
+	} else {
+		if !IsEmpty(obj.AppprojectSyncwindowID) {
+			return fmt.Errorf("primary key should be empty")
+		}
+		obj.AppprojectSyncwindowID = generateUuid()
+	}
+
+	if err := isEmptyValues("CreateAppProjectSyncWindow",
+		"clusteruser_id", obj.Clusteruser_id,
+		"application_name", obj.Application_name,
+		"kind", obj.Kind,
+		"schedule", obj.Schedule,
+		"duration", obj.Duration); err != nil {
+		return err
+	}
+
+	if err := validateFieldLength(obj); err != nil {
+		return err
+	}
+
+	result, err := dbq.dbConnection.Model(obj).Context(ctx).Insert()
+	if err != nil {
+		return fmt.Errorf("error on inserting appProjectSyncWindow: %v", err)
+	}
+
+	if result.RowsAffected() != 1 {
+		return fmt.Errorf("unexpected number of rows affected: %d", result.RowsAffected())
+	}
+
+	return nil
+}
+
+func (dbq *PostgreSQLDatabaseQueries) ListAppProjectSyncWindowByClusterUserId(ctx context.Context,
+	clusteruser_id string, appProjectSyncWindows *[]AppProjectSyncWindow) error {
+
+	if err := validateQueryParams(clusteruser_id, dbq); err != nil {
+		return err
+	}
+
+	// Retrieve all appProjectSyncWindows which are targeting this clusteruser_id
+	err := dbq.dbConnection.Model(appProjectSyncWindows).Context(ctx).Where("clusteruser_id = ?", clusteruser_id).Order("seq_id ASC").Select()
+	if err != nil {
+		return fmt.Errorf("unable to retrieve appProjectSyncWindows with clusteruser_id: %v", err)
+	}
+
+	return nil
+}
+
+func (dbq *PostgreSQLDatabaseQueries) DeleteAppProjectSyncWindowByAppProjectSyncWindowID(ctx context.Context, obj *AppProjectSyncWindow) (int, error) {
+
+	if err := validateQueryParamsEntity(obj, dbq); err != nil {
+		return 0, err
+	}
+
+	if err := isEmptyValues("DeleteAppProjectSyncWindowByAppProjectSyncWindowID",
+		"appproject_syncwindow_id", obj.AppprojectSyncwindowID,
+	); err != nil {
+		return 0, err
+	}
+
+	deleteResult, err := dbq.dbConnection.Model(obj).
+		Where("appproject_syncwindow_id = ?", obj.AppprojectSyncwindowID).
+		Context(ctx).Delete()
+	if err != nil {
+		return 0, fmt.Errorf("error on deleting appProjectSyncWindow: %v", err)
+	}
+
+	return deleteResult.RowsAffected(), nil
+}
+
+// GetAsLogKeyValues returns an []interface that can be passed to log.Info(...).
+// e.g. log.Info("Creating database resource", obj.GetAsLogKeyValues()...)
+func (obj *AppProjectSyncWindow) GetAsLogKeyValues() []interface{} {
+	if obj == nil {
+		return []interface{}{}
+	}
+
+	return []interface{}{"appproject_syncwindow_id", obj.AppprojectSyncwindowID,
+		"clusteruser_id", obj.Clusteruser_id,
+		"application_name", obj.Application_name,
+		"kind", obj.Kind,
+		"schedule", obj.Schedule,
+		"duration", obj.Duration}
+}
+
+// DeleteAppProjectSyncWindowsByClusterUserId deletes all the appProjectSyncWindows that reference the specified clusteruser_id row.
+func (dbq *PostgreSQLDatabaseQueries) DeleteAppProjectSyncWindowsByClusterUserId(ctx context.Context, clusteruser_id string) (int, error) {
+
+	if err := validateQueryParams(clusteruser_id, dbq); err != nil {
+		return 0, err
+	}
+
+	deleteResult, err := dbq.dbConnection.Model(&AppProjectSyncWindow{}).
+		Where("clusteruser_id = ?", clusteruser_id).
+		Context(ctx).Delete()
+	if err != nil {
+		return 0, fmt.Errorf("error on deleting appProjectSyncWindows of clusteruser_id: %v", err)
+	}
+
+	return deleteResult.RowsAffected(), nil
+}
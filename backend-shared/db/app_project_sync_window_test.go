@@ -0,0 +1,116 @@
+package db_test
+
+import (
+	"context"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	db "github.com/redhat-appstudio/managed-gitops/backend-shared/db"
+)
+
+var _ = Describe("AppProjectSyncWindow Test", func() {
+
+	var (
+		ctx         context.Context
+		dbq         db.AllDatabaseQueries
+		clusterUser *db.ClusterUser
+	)
+
+	BeforeEach(func() {
+		err := db.SetupForTestingDBGinkgo()
+		Expect(err).ToNot(HaveOccurred())
+
+		ctx = context.Background()
+		dbq, err = db.NewUnsafePostgresDBQueries(true, true)
+		Expect(err).ToNot(HaveOccurred())
+
+		clusterUser = &db.ClusterUser{
+			Clusteruser_id: "test-user-sync-window",
+			User_name:      "test-user-sync-window",
+		}
+		err = dbq.CreateClusterUser(ctx, clusterUser)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		dbq.CloseDatabase()
+	})
+
+	It("Should Create, List and Delete an AppProjectSyncWindow", func() {
+
+		By("creating a deny window with manual sync enabled, and an allow window")
+		denyWindow := &db.AppProjectSyncWindow{
+			AppprojectSyncwindowID: "test-app-project-sync-window-deny",
+			Clusteruser_id:         clusterUser.Clusteruser_id,
+			Application_name:       "gitopsdepl-test-sync-window",
+			Kind:                   "deny",
+			Schedule:               "0 9 * * 1-5",
+			Duration:               "8h",
+			Manual_sync:            true,
+			Time_zone:              "Europe/London",
+		}
+		Expect(dbq.CreateAppProjectSyncWindow(ctx, denyWindow)).To(Succeed())
+
+		allowWindow := &db.AppProjectSyncWindow{
+			AppprojectSyncwindowID: "test-app-project-sync-window-allow",
+			Clusteruser_id:         clusterUser.Clusteruser_id,
+			Application_name:       "gitopsdepl-test-sync-window",
+			Kind:                   "allow",
+			Schedule:               "0 22 * * *",
+			Duration:               "1h",
+		}
+		Expect(dbq.CreateAppProjectSyncWindow(ctx, allowWindow)).To(Succeed())
+
+		By("verifying the windows are returned for the cluster user, in the order they were created")
+		var syncWindows []db.AppProjectSyncWindow
+		Expect(dbq.ListAppProjectSyncWindowByClusterUserId(ctx, clusterUser.Clusteruser_id, &syncWindows)).To(Succeed())
+		Expect(syncWindows).To(HaveLen(2))
+
+		Expect(syncWindows[0].AppprojectSyncwindowID).To(Equal(denyWindow.AppprojectSyncwindowID))
+		Expect(syncWindows[0].Application_name).To(Equal(denyWindow.Application_name))
+		Expect(syncWindows[0].Kind).To(Equal(denyWindow.Kind))
+		Expect(syncWindows[0].Schedule).To(Equal(denyWindow.Schedule))
+		Expect(syncWindows[0].Duration).To(Equal(denyWindow.Duration))
+		Expect(syncWindows[0].Manual_sync).To(BeTrue())
+		Expect(syncWindows[0].Time_zone).To(Equal(denyWindow.Time_zone))
+
+		Expect(syncWindows[1].AppprojectSyncwindowID).To(Equal(allowWindow.AppprojectSyncwindowID))
+		Expect(syncWindows[1].Manual_sync).To(BeFalse())
+		Expect(syncWindows[1].Time_zone).To(BeEmpty())
+
+		By("deleting the windows")
+		for idx := range syncWindows {
+			rowsAffected, err := dbq.DeleteAppProjectSyncWindowByAppProjectSyncWindowID(ctx, &syncWindows[idx])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rowsAffected).To(Equal(1))
+		}
+
+		syncWindows = []db.AppProjectSyncWindow{}
+		Expect(dbq.ListAppProjectSyncWindowByClusterUserId(ctx, clusterUser.Clusteruser_id, &syncWindows)).To(Succeed())
+		Expect(syncWindows).To(BeEmpty())
+	})
+
+	It("Should not create an AppProjectSyncWindow without a schedule", func() {
+		syncWindow := &db.AppProjectSyncWindow{
+			Clusteruser_id:   clusterUser.Clusteruser_id,
+			Application_name: "gitopsdepl-test-sync-window",
+			Kind:             "deny",
+			Duration:         "8h",
+		}
+		Expect(dbq.CreateAppProjectSyncWindow(ctx, syncWindow)).ToNot(Succeed())
+	})
+
+	It("Should not create an AppProjectSyncWindow with a schedule that exceeds the maximum length", func() {
+		syncWindow := &db.AppProjectSyncWindow{
+			Clusteruser_id:   clusterUser.Clusteruser_id,
+			Application_name: "gitopsdepl-test-sync-window",
+			Kind:             "deny",
+			Schedule:         strings.Repeat("*", db.AppProjectSyncWindowScheduleLength+1),
+			Duration:         "8h",
+		}
+		err := dbq.CreateAppProjectSyncWindow(ctx, syncWindow)
+		Expect(db.IsMaxLengthError(err)).To(BeTrue())
+	})
+})
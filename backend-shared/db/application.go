@@ -315,6 +315,59 @@ func (dbq *PostgreSQLDatabaseQueries) ListApplicationsForManagedEnvironment(ctx
 
 }
 
+func (dbq *PostgreSQLDatabaseQueries) CountApplicationsForGitopsEngineInstance(ctx context.Context, gitopsEngineInstanceID string) (int, error) {
+
+	if err := validateQueryParams(gitopsEngineInstanceID, dbq); err != nil {
+		return 0, err
+	}
+
+	// Index Name is idx_application_engine_instance
+	count, err := dbq.dbConnection.Model((*Application)(nil)).Context(ctx).Where("engine_instance_inst_id = ?", gitopsEngineInstanceID).Count()
+	if err != nil {
+		return 0, fmt.Errorf("unable to count applications with gitops engine instance id: %v", err)
+	}
+
+	return count, nil
+}
+
+// UpdateApplicationEngineInstance updates the GitopsEngineInstance and spec field of the Application, in a single
+// conditional update: the row is only updated if it still has the expected GitopsEngineInstance and spec field, so that
+// concurrent updates of the row are not lost. Returns the number of rows updated (0 if the row has changed, or no
+// longer exists).
+func (dbq *PostgreSQLDatabaseQueries) UpdateApplicationEngineInstance(ctx context.Context, obj *Application, expectedEngineInstanceID string,
+	expectedSpecField string) (int, error) {
+
+	if err := validateQueryParamsEntity(obj, dbq); err != nil {
+		return 0, err
+	}
+
+	if err := isEmptyValues("UpdateApplicationEngineInstance",
+		"Application_id", obj.Application_id,
+		"Engine_instance_inst_id", obj.Engine_instance_inst_id,
+		"Spec_field", obj.Spec_field,
+		"expectedEngineInstanceID", expectedEngineInstanceID,
+		"expectedSpecField", expectedSpecField); err != nil {
+		return 0, err
+	}
+
+	if err := validateFieldLength(obj); err != nil {
+		return 0, err
+	}
+
+	result, err := dbq.dbConnection.Model(obj).
+		Column("engine_instance_inst_id", "spec_field").
+		WherePK().
+		Where("engine_instance_inst_id = ?", expectedEngineInstanceID).
+		Where("spec_field = ?", expectedSpecField).
+		Context(ctx).
+		Update()
+	if err != nil {
+		return 0, fmt.Errorf("error on updating engine instance of application %v", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
 // Get applications in a batch. Batch size defined by 'limit' and starting point of batch is defined by 'offSet'.
 // For example if you want applications starting from 51-150 then set the limit to 100 and offset to 50.
 func (dbq *PostgreSQLDatabaseQueries) GetApplicationBatch(ctx context.Context, applications *[]Application, limit, offSet int) error {
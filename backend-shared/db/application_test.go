@@ -207,6 +207,85 @@ var _ = Describe("Application Test", func() {
 		})
 	})
 
+	Context("Test CountApplicationsForGitopsEngineInstance function", func() {
+		It("should count the Applications of a given GitopsEngineInstance", func() {
+			count, err := dbq.CountApplicationsForGitopsEngineInstance(ctx, gitopsEngineInstance.Gitopsengineinstance_id)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(BeZero())
+
+			app := &db.Application{
+				Name:                    "my-application",
+				Spec_field:              "{}",
+				Engine_instance_inst_id: gitopsEngineInstance.Gitopsengineinstance_id,
+				Managed_environment_id:  managedEnvironment.Managedenvironment_id,
+			}
+
+			expectedRows := 3
+			for i := 1; i <= expectedRows; i++ {
+				app.Application_id = fmt.Sprintf("test-app-%d", i)
+				err := dbq.CreateApplication(ctx, app)
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			count, err = dbq.CountApplicationsForGitopsEngineInstance(ctx, gitopsEngineInstance.Gitopsengineinstance_id)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(Equal(expectedRows))
+		})
+
+		It("should return an error if the GitopsEngineInstance ID is empty", func() {
+			count, err := dbq.CountApplicationsForGitopsEngineInstance(ctx, "")
+			Expect(err).To(HaveOccurred())
+			Expect(count).To(BeZero())
+		})
+	})
+
+	Context("Test UpdateApplicationEngineInstance function", func() {
+		It("should only update the Application if it still has the expected GitopsEngineInstance and spec field", func() {
+			otherGitopsEngineInstance := db.GitopsEngineInstance{
+				Gitopsengineinstance_id: "test-other-engine-instance-id",
+				Namespace_name:          "test-other-namespace",
+				Namespace_uid:           "test-other-namespace-uid",
+				EngineCluster_id:        gitopsEngineInstance.EngineCluster_id,
+			}
+			err := dbq.CreateGitopsEngineInstance(ctx, &otherGitopsEngineInstance)
+			Expect(err).ToNot(HaveOccurred())
+
+			app := db.Application{
+				Application_id:          "test-app-engine-instance",
+				Name:                    "my-application",
+				Spec_field:              "{}",
+				Engine_instance_inst_id: gitopsEngineInstance.Gitopsengineinstance_id,
+				Managed_environment_id:  managedEnvironment.Managedenvironment_id,
+			}
+			err = dbq.CreateApplication(ctx, &app)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("not updating the Application if the spec field has changed")
+			switched := app
+			switched.Engine_instance_inst_id = otherGitopsEngineInstance.Gitopsengineinstance_id
+			switched.Spec_field = "{\"namespace\": \"test-other-namespace\"}"
+			rowsAffected, err := dbq.UpdateApplicationEngineInstance(ctx, &switched, gitopsEngineInstance.Gitopsengineinstance_id, "{\"changed\": true}")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rowsAffected).To(BeZero())
+
+			By("updating the Application if it is unchanged")
+			rowsAffected, err = dbq.UpdateApplicationEngineInstance(ctx, &switched, gitopsEngineInstance.Gitopsengineinstance_id, app.Spec_field)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rowsAffected).To(Equal(1))
+
+			fetch := db.Application{Application_id: app.Application_id}
+			err = dbq.GetApplicationById(ctx, &fetch)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fetch.Engine_instance_inst_id).To(Equal(otherGitopsEngineInstance.Gitopsengineinstance_id))
+			Expect(fetch.Spec_field).To(Equal(switched.Spec_field))
+
+			By("not updating the Application if it is no longer on the expected GitopsEngineInstance")
+			rowsAffected, err = dbq.UpdateApplicationEngineInstance(ctx, &switched, gitopsEngineInstance.Gitopsengineinstance_id, switched.Spec_field)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rowsAffected).To(BeZero())
+		})
+	})
+
 	Context("Test RemoveManagedEnvironmentFromAllApplications function", func() {
 		It("should remove environment ID from the target applications", func() {
 			By("create Applications pointing to a given ManagedEnvironment")
@@ -142,6 +142,33 @@ func (dbq *PostgreSQLDatabaseQueries) ListClusterAccessesByManagedEnvironmentID(
 	return nil
 }
 
+func (dbq *PostgreSQLDatabaseQueries) ListClusterAccessesByClusterUserID(ctx context.Context, clusterUserID string, clusterAccesses *[]ClusterAccess) error {
+
+	if err := validateQueryParamsEntity(clusterAccesses, dbq); err != nil {
+		return err
+	}
+
+	if err := isEmptyValues("ListClusterAccessesByClusterUserID",
+		"clusterUserID", clusterUserID); err != nil {
+		return err
+	}
+
+	var dbResults []ClusterAccess
+
+	// Index Name is idx_userid_instance
+	if err := dbq.dbConnection.Model(&dbResults).
+		Where("clusteraccess_user_id = ?", clusterUserID).
+		Context(ctx).
+		Select(); err != nil {
+
+		return fmt.Errorf("error on retrieving ListClusterAccessesByClusterUserID: %v", err)
+	}
+
+	*clusterAccesses = dbResults
+
+	return nil
+}
+
 // Get ClusterAccess in a batch. Batch size defined by 'limit' and starting point of batch is defined by 'offSet'.
 // For example if you want ClusterAccess starting from 51-150 then set the limit to 100 and offset to 50.
 func (dbq *PostgreSQLDatabaseQueries) GetClusterAccessBatch(ctx context.Context, clusterAccess *[]ClusterAccess, limit, offSet int) error {
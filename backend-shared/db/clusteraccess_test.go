@@ -281,4 +281,28 @@ var _ = Describe("ClusterAccess Tests", func() {
 			Expect(err.Error()).To(Equal(expectedErrMsg))
 		})
 	})
+
+	Context("Test ListClusterAccessesByClusterUserID function", func() {
+		It("should return a list of ClusterAccess for a given ClusterUser", func() {
+			clusterAccessList := []db.ClusterAccess{}
+			err := dbq.ListClusterAccessesByClusterUserID(ctx, clusterAccess.Clusteraccess_user_id, &clusterAccessList)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(clusterAccessList).To(HaveLen(1))
+
+			Expect(clusterAccessList[0]).To(Equal(clusterAccess))
+		})
+
+		It("should return an empty list if the ClusterUser has no ClusterAccess", func() {
+			clusterAccessList := []db.ClusterAccess{}
+			err := dbq.ListClusterAccessesByClusterUserID(ctx, "does-not-exist", &clusterAccessList)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(clusterAccessList).To(BeEmpty())
+		})
+
+		It("should return an error if an empty ClusterUser ID is passed", func() {
+			clusterAccessList := []db.ClusterAccess{}
+			err := dbq.ListClusterAccessesByClusterUserID(ctx, "", &clusterAccessList)
+			Expect(err).To(HaveOccurred())
+		})
+	})
 })
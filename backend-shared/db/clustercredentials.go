@@ -22,6 +22,12 @@ func (dbq *PostgreSQLDatabaseQueries) UnsafeListAllClusterCredentials(ctx contex
 		return err
 	}
 
+	for idx := range *clusterCredentials {
+		if err := decryptSecretFields(ctx, (*clusterCredentials)[idx].secretFields()...); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -46,6 +52,16 @@ func (dbq *PostgreSQLDatabaseQueries) CreateClusterCredentials(ctx context.Conte
 		obj.Clustercredentials_cred_id = generateUuid()
 	}
 
+	if err := obj.validateSecretFieldLength(); err != nil {
+		return err
+	}
+
+	restoreSecretFields, err := encryptSecretFields(ctx, obj.secretFields()...)
+	if err != nil {
+		return fmt.Errorf("unable to encrypt cluster credentials: %v", err)
+	}
+	defer restoreSecretFields()
+
 	if err := validateFieldLength(obj); err != nil {
 		return err
 	}
@@ -62,6 +78,82 @@ func (dbq *PostgreSQLDatabaseQueries) CreateClusterCredentials(ctx context.Conte
 	return nil
 }
 
+func (dbq *PostgreSQLDatabaseQueries) UpdateClusterCredentials(ctx context.Context, obj *ClusterCredentials) error {
+
+	if err := validateQueryParamsEntity(obj, dbq); err != nil {
+		return err
+	}
+
+	if IsEmpty(obj.Clustercredentials_cred_id) {
+		return fmt.Errorf("primary key is empty")
+	}
+
+	if err := obj.validateSecretFieldLength(); err != nil {
+		return err
+	}
+
+	restoreSecretFields, err := encryptSecretFields(ctx, obj.secretFields()...)
+	if err != nil {
+		return fmt.Errorf("unable to encrypt cluster credentials: %v", err)
+	}
+	defer restoreSecretFields()
+
+	if err := validateFieldLength(obj); err != nil {
+		return err
+	}
+
+	result, err := dbq.dbConnection.Model(obj).WherePK().Context(ctx).Update()
+	if err != nil {
+		return fmt.Errorf("error on updating cluster credentials: %v", err)
+	}
+
+	if result.RowsAffected() != 1 {
+		return fmt.Errorf("unexpected number of rows affected: %d", result.RowsAffected())
+	}
+
+	return nil
+}
+
+func (dbq *PostgreSQLDatabaseQueries) ReencryptClusterCredentials(ctx context.Context, id string) (bool, error) {
+
+	if err := validateQueryParams(id, dbq); err != nil {
+		return false, err
+	}
+
+	obj := &ClusterCredentials{Clustercredentials_cred_id: id}
+	if err := dbq.dbConnection.Model(obj).WherePK().Context(ctx).Select(); err != nil {
+		return false, fmt.Errorf("error on retrieving ClusterCredentials: %v", err)
+	}
+
+	// The values as they are stored, which must not have changed when the row is updated
+	storedKubeConfig, storedBearerToken := obj.Kube_config, obj.Serviceaccount_bearer_token
+
+	if err := decryptSecretFields(ctx, obj.secretFields()...); err != nil {
+		return false, fmt.Errorf("unable to decrypt cluster credentials: %v", err)
+	}
+
+	if _, err := encryptSecretFields(ctx, obj.secretFields()...); err != nil {
+		return false, fmt.Errorf("unable to encrypt cluster credentials: %v", err)
+	}
+
+	if err := validateFieldLength(obj); err != nil {
+		return false, err
+	}
+
+	result, err := dbq.dbConnection.Model(obj).Context(ctx).
+		Set("kube_config = ?", obj.Kube_config).
+		Set("serviceaccount_bearer_token = ?", obj.Serviceaccount_bearer_token).
+		WherePK().
+		Where("kube_config = ?", storedKubeConfig).
+		Where("serviceaccount_bearer_token = ?", storedBearerToken).
+		Update()
+	if err != nil {
+		return false, fmt.Errorf("error on re-encrypting cluster credentials: %v", err)
+	}
+
+	return result.RowsAffected() == 1, nil
+}
+
 func (dbq *PostgreSQLDatabaseQueries) GetClusterCredentialsById(ctx context.Context, clusterCreds *ClusterCredentials) error {
 
 	if err := validateQueryParamsEntity(clusterCreds, dbq); err != nil {
@@ -84,6 +176,10 @@ func (dbq *PostgreSQLDatabaseQueries) GetClusterCredentialsById(ctx context.Cont
 		return fmt.Errorf("unexpected multiple results found in UnsafeGetClusterCredentialsById")
 	}
 
+	if err := decryptSecretFields(ctx, dbResults[0].secretFields()...); err != nil {
+		return err
+	}
+
 	*clusterCreds = dbResults[0]
 
 	return nil
@@ -129,6 +225,10 @@ func (dbq *PostgreSQLDatabaseQueries) CheckedGetClusterCredentialsById(ctx conte
 		return NewResultNotFoundError("no results found for GetClusterCredentialsById")
 	}
 
+	if err := decryptSecretFields(ctx, dbResults[0].secretFields()...); err != nil {
+		return err
+	}
+
 	*clusterCredentials = dbResults[0]
 
 	return nil
@@ -169,6 +269,9 @@ func (dbq *PostgreSQLDatabaseQueries) CheckedListClusterCredentialsByHost(ctx co
 		}
 
 		if accessibleByUser {
+			if err := decryptSecretFields(ctx, dbResultCredsWithHostnameResults[idx].secretFields()...); err != nil {
+				return err
+			}
 			matchingClusterCreds = append(matchingClusterCreds, dbResultCredsWithHostnameResults[idx])
 		}
 
@@ -185,13 +288,23 @@ func (dbq *PostgreSQLDatabaseQueries) CheckedListClusterCredentialsByHost(ctx co
 // Get ClusterCredentials in a batch. Batch size defined by 'limit' and starting point of batch is defined by 'offSet'.
 // For example if you want ClusterCredentials starting from 51-150 then set the limit to 100 and offset to 50.
 func (dbq *PostgreSQLDatabaseQueries) GetClusterCredentialsBatch(ctx context.Context, clusterCredentials *[]ClusterCredentials, limit, offSet int) error {
-	return dbq.dbConnection.
+	if err := dbq.dbConnection.
 		Model(clusterCredentials).
 		Order("seq_id ASC").
 		Limit(limit).   // Batch size
 		Offset(offSet). // offset+1 is starting point of batch
 		Context(ctx).
-		Select()
+		Select(); err != nil {
+		return err
+	}
+
+	for idx := range *clusterCredentials {
+		if err := decryptSecretFields(ctx, (*clusterCredentials)[idx].secretFields()...); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // A user should only be able to get cluster credentials if:
@@ -303,5 +416,11 @@ func (obj *ClusterCredentials) GetAsLogKeyValues() []interface{} {
 	return []interface{}{"host", obj.Host, "kube-config-length", len(obj.Kube_config),
 		"kube-config-context", len(obj.Kube_config_context), "serviceaccount_ns", obj.Serviceaccount_ns,
 		"serviceaccount-bearer-token-length", len(obj.Serviceaccount_bearer_token), "cluster_resources", obj.ClusterResources,
-		"cluster_namespaces", obj.Namespaces}
+		"cluster_namespaces", obj.Namespaces, "secret_ref_namespace", obj.Secret_ref_namespace, "secret_ref_name", obj.Secret_ref_name}
+}
+
+// HasSecretRef returns true if the credentials are not stored in the row, but instead should be read from
+// the referenced Secret.
+func (obj *ClusterCredentials) HasSecretRef() bool {
+	return obj.Secret_ref_name != ""
 }
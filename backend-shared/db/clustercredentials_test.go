@@ -2,6 +2,7 @@ package db_test
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -56,6 +57,25 @@ var _ = Describe("ClusterCredentials Tests", func() {
 			Expect(true).To(Equal(db.IsResultNotFoundError(err)))
 		})
 
+		It("Should reject ClusterCredentials with a plaintext kube_config or bearer token larger than the original column size", func() {
+
+			By("creating ClusterCredentials with a kube_config that only fits in the widened column")
+			tooLarge := db.ClusterCredentials{
+				Host:                        "test-host",
+				Kube_config:                 strings.Repeat("a", 65001),
+				Kube_config_context:         "test-kube_config_context",
+				Serviceaccount_bearer_token: "test-serviceaccount_bearer_token",
+				Serviceaccount_ns:           "test-serviceaccount_ns",
+			}
+			err := dbq.CreateClusterCredentials(ctx, &tooLarge)
+			Expect(db.IsMaxLengthError(err)).To(BeTrue())
+
+			By("updating ClusterCredentials with a bearer token that only fits in the widened column")
+			clusterCreds.Serviceaccount_bearer_token = strings.Repeat("a", 2049)
+			err = dbq.UpdateClusterCredentials(ctx, &clusterCreds)
+			Expect(db.IsMaxLengthError(err)).To(BeTrue())
+		})
+
 		It("Should Get ClusterCredentials in batch.", func() {
 
 			err := db.SetupForTestingDBGinkgo()
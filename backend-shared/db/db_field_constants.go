@@ -9,11 +9,14 @@ import (
 const (
 	ClusterCredentialsClustercredentialsCredIDLength                        = 48
 	ClusterCredentialsHostLength                                            = 512
-	ClusterCredentialsKubeConfigLength                                      = 65000
+	ClusterCredentialsKubeConfigLength                                      = 90000
 	ClusterCredentialsKubeConfigContextLength                               = 64
-	ClusterCredentialsServiceaccountBearerTokenLength                       = 2048
+	ClusterCredentialsServiceaccountBearerTokenLength                       = 3072
 	ClusterCredentialsServiceaccountNsLength                                = 128
 	ClusterCredentialsNamespacesLength                                      = 4096
+	ClusterCredentialsSecretRefNamespaceLength                              = 64
+	ClusterCredentialsSecretRefNameLength                                   = 256
+	ClusterCredentialsSecretRefResourceVersionLength                        = 64
 	GitopsEngineClusterGitopsengineclusterIDLength                          = 48
 	GitopsEngineInstanceGitopsengineinstanceIDLength                        = 48
 	GitopsEngineInstanceNamespaceNameLength                                 = 48
@@ -38,7 +41,7 @@ const (
 	OperationHumanReadableStateLength                                       = 1024
 	ApplicationApplicationIDLength                                          = 48
 	ApplicationNameLength                                                   = 256
-	ApplicationSpecFieldLength                                              = 16384
+	ApplicationSpecFieldLength                                              = 65536
 	ApplicationEngineInstanceInstIDLength                                   = 48
 	ApplicationManagedEnvironmentIDLength                                   = 48
 	ApplicationStateApplicationstateApplicationIDLength                     = 48
@@ -63,20 +66,36 @@ const (
 	SyncOperationDeploymentNameLength                                       = 256
 	SyncOperationRevisionLength                                             = 256
 	SyncOperationDesiredStateLength                                         = 16
+	SyncOperationResourcesLength                                            = 4096
+	SyncOperationSyncOptionsLength                                          = 1024
+	SyncOperationRollbackToLength                                           = 512
+	SyncOperationPhaseLength                                                = 16
+	SyncOperationPhaseMessageLength                                         = 1024
+	SyncOperationSyncedRevisionLength                                       = 256
 	RepositoryCredentialsRepositorycredentialsIDLength                      = 48
 	RepositoryCredentialsRepoCredUserIDLength                               = 48
 	RepositoryCredentialsRepoCredURLLength                                  = 512
 	RepositoryCredentialsRepoCredUserLength                                 = 256
-	RepositoryCredentialsRepoCredPassLength                                 = 1024
-	RepositoryCredentialsRepoCredSshLength                                  = 1024
+	RepositoryCredentialsRepoCredPassLength                                 = 1536
+	RepositoryCredentialsRepoCredSshLength                                  = 1536
 	RepositoryCredentialsRepoCredSecretLength                               = 48
 	RepositoryCredentialsRepoCredEngineIDLength                             = 48
+	RepositoryCredentialsRepoCredSecretRefNamespaceLength                   = 64
+	RepositoryCredentialsRepoCredSecretRefNameLength                        = 256
+	RepositoryCredentialsRepoCredSecretRefResourceVersionLength             = 64
 	AppProjectRepositoryAppprojectRepositoryIDLength                        = 48
 	AppProjectRepositoryClusteruserIDLength                                 = 48
 	AppProjectRepositoryRepoURLLength                                       = 256
 	AppProjectManagedEnvironmentAppprojectManagedenvIDLength                = 48
 	AppProjectManagedEnvironmentManagedEnvironmentIDLength                  = 48
 	AppProjectManagedEnvironmentClusteruserIDLength                         = 48
+	AppProjectSyncWindowAppprojectSyncwindowIDLength                        = 48
+	AppProjectSyncWindowClusteruserIDLength                                 = 48
+	AppProjectSyncWindowApplicationNameLength                               = 256
+	AppProjectSyncWindowKindLength                                          = 16
+	AppProjectSyncWindowScheduleLength                                      = 256
+	AppProjectSyncWindowDurationLength                                      = 64
+	AppProjectSyncWindowTimeZoneLength                                      = 64
 	ApplicationOwnerApplicationOwnerApplicationIDLength                     = 48
 	ApplicationOwnerApplicationOwnerUserIDLength                            = 48
 )
@@ -118,6 +137,9 @@ var DbFieldMap = map[string]int{
 	"ClusterCredentialsServiceaccountBearerTokenLength":                       ClusterCredentialsServiceaccountBearerTokenLength,
 	"ClusterCredentialsServiceaccountNsLength":                                ClusterCredentialsServiceaccountNsLength,
 	"ClusterCredentialsNamespacesLength":                                      ClusterCredentialsNamespacesLength,
+	"ClusterCredentialsSecretRefNamespaceLength":                              ClusterCredentialsSecretRefNamespaceLength,
+	"ClusterCredentialsSecretRefNameLength":                                   ClusterCredentialsSecretRefNameLength,
+	"ClusterCredentialsSecretRefResourceVersionLength":                        ClusterCredentialsSecretRefResourceVersionLength,
 	"GitopsEngineClusterGitopsengineclusterIDLength":                          GitopsEngineClusterGitopsengineclusterIDLength,
 	"GitopsEngineInstanceGitopsengineinstanceIDLength":                        GitopsEngineInstanceGitopsengineinstanceIDLength,
 	"GitopsEngineInstanceNamespaceNameLength":                                 GitopsEngineInstanceNamespaceNameLength,
@@ -182,6 +204,12 @@ var DbFieldMap = map[string]int{
 	"SyncOperationDeploymentNameFieldLength":                                  SyncOperationDeploymentNameLength,
 	"SyncOperationRevisionLength":                                             SyncOperationRevisionLength,
 	"SyncOperationDesiredStateLength":                                         SyncOperationDesiredStateLength,
+	"SyncOperationResourcesLength":                                            SyncOperationResourcesLength,
+	"SyncOperationSyncOptionsLength":                                          SyncOperationSyncOptionsLength,
+	"SyncOperationRollbackToLength":                                           SyncOperationRollbackToLength,
+	"SyncOperationPhaseLength":                                                SyncOperationPhaseLength,
+	"SyncOperationPhaseMessageLength":                                         SyncOperationPhaseMessageLength,
+	"SyncOperationSyncedRevisionLength":                                       SyncOperationSyncedRevisionLength,
 	"RepositoryCredentialsRepositorycredentialsIDLength":                      RepositoryCredentialsRepositorycredentialsIDLength,
 	"RepositoryCredentialsRepoCredUserIDLength":                               RepositoryCredentialsRepoCredUserIDLength,
 	"RepositoryCredentialsRepoCredURLLength":                                  RepositoryCredentialsRepoCredURLLength,
@@ -190,12 +218,22 @@ var DbFieldMap = map[string]int{
 	"RepositoryCredentialsRepoCredSshLength":                                  RepositoryCredentialsRepoCredSshLength,
 	"RepositoryCredentialsRepoCredSecretLength":                               RepositoryCredentialsRepoCredSecretLength,
 	"RepositoryCredentialsRepoCredEngineIDLength":                             RepositoryCredentialsRepoCredEngineIDLength,
+	"RepositoryCredentialsRepoCredSecretRefNamespaceLength":                   RepositoryCredentialsRepoCredSecretRefNamespaceLength,
+	"RepositoryCredentialsRepoCredSecretRefNameLength":                        RepositoryCredentialsRepoCredSecretRefNameLength,
+	"RepositoryCredentialsRepoCredSecretRefResourceVersionLength":             RepositoryCredentialsRepoCredSecretRefResourceVersionLength,
 	"AppProjectRepositoryAppprojectRepositoryIDLength":                        AppProjectRepositoryAppprojectRepositoryIDLength,
 	"AppProjectRepositoryClusteruserIDLength":                                 AppProjectRepositoryClusteruserIDLength,
 	"AppProjectRepositoryRepoURLLength":                                       AppProjectRepositoryRepoURLLength,
 	"AppProjectManagedEnvironmentAppprojectManagedenvIDLength":                AppProjectManagedEnvironmentAppprojectManagedenvIDLength,
 	"AppProjectManagedEnvironmentManagedEnvironmentIDLength":                  AppProjectManagedEnvironmentManagedEnvironmentIDLength,
 	"AppProjectManagedEnvironmentClusteruserIDLength":                         AppProjectManagedEnvironmentClusteruserIDLength,
+	"AppProjectSyncWindowAppprojectSyncwindowIDLength":                        AppProjectSyncWindowAppprojectSyncwindowIDLength,
+	"AppProjectSyncWindowClusteruserIDLength":                                 AppProjectSyncWindowClusteruserIDLength,
+	"AppProjectSyncWindowApplicationNameLength":                               AppProjectSyncWindowApplicationNameLength,
+	"AppProjectSyncWindowKindLength":                                          AppProjectSyncWindowKindLength,
+	"AppProjectSyncWindowScheduleLength":                                      AppProjectSyncWindowScheduleLength,
+	"AppProjectSyncWindowDurationLength":                                      AppProjectSyncWindowDurationLength,
+	"AppProjectSyncWindowTimeZoneLength":                                      AppProjectSyncWindowTimeZoneLength,
 	"ApplicationOwnerApplicationOwnerApplicationIDLength":                     ApplicationOwnerApplicationOwnerApplicationIDLength,
 	"ApplicationOwnerApplicationOwnerUserIDLength":                            ApplicationOwnerApplicationOwnerUserIDLength,
 }
@@ -0,0 +1,678 @@
+package db
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Sensitive database fields (for example, ClusterCredentials.Kube_config and RepositoryCredentials.AuthPassword)
+// are encrypted at rest using envelope encryption:
+// - Each value is encrypted (AES-256-GCM) with a new, randomly generated data encryption key (DEK).
+// - The DEK is then itself encrypted (wrapped) with a key encryption key (KEK) from an EncryptionKeyProvider.
+// - The ID of the KEK, the wrapped DEK, and the ciphertext are stored together in the database column.
+//
+// Encryption/decryption is performed transparently by the Create/Get/Update functions of the affected tables,
+// so callers of those functions only ever see plaintext values.
+//
+// Values that were written before encryption was enabled (plaintext values) can still be read. They are
+// encrypted the next time they are written, or when ReencryptCredentials is called.
+
+const (
+	// EncryptionKeyDirEnv is the environment variable containing the path of a directory containing the key
+	// encryption keys. This directory is usually a mounted Kubernetes Secret volume.
+	EncryptionKeyDirEnv = "DB_ENCRYPTION_KEY_DIR"
+
+	// EncryptionKeySecretEnv is the environment variable containing the '(namespace)/(name)' of a Kubernetes Secret
+	// containing the key encryption keys. It is only used if EncryptionKeyDirEnv is not set.
+	EncryptionKeySecretEnv = "DB_ENCRYPTION_KEY_SECRET"
+
+	// CurrentEncryptionKeyIDEntry is the name of the file (or Secret data key) that contains the ID of the key that
+	// should be used to encrypt new values. Every other file (or Secret data key) is a key, with the key ID as
+	// the name, and the base64-encoded 32 byte AES-256 key as the value.
+	CurrentEncryptionKeyIDEntry = "current-key-id"
+
+	// encryptedValuePrefix is the prefix of all encrypted database values. Values without this prefix are plaintext.
+	// Encrypted value format: "gitops-enc:v1:(key id):(base64 of nonce + wrapped DEK):(base64 of nonce + ciphertext)"
+	encryptedValuePrefix = "gitops-enc:v1:"
+
+	encryptionKeyLength = 32
+
+	// clusterCredentialsKubeConfigPlaintextLength and clusterCredentialsServiceaccountBearerTokenPlaintextLength are
+	// the maximum lengths of the plaintext values of these fields: the column sizes before migration 000026.
+	clusterCredentialsKubeConfigPlaintextLength                = 65000
+	clusterCredentialsServiceaccountBearerTokenPlaintextLength = 2048
+
+	// repositoryCredentialsAuthPasswordPlaintextLength and repositoryCredentialsAuthSSHKeyPlaintextLength are the
+	// maximum lengths of the plaintext values of these fields: the column sizes before migration 000026.
+	repositoryCredentialsAuthPasswordPlaintextLength = 1024
+	repositoryCredentialsAuthSSHKeyPlaintextLength   = 1024
+
+	// reencryptAttempts is the number of times that ReencryptCredentials attempts to re-encrypt a row which is
+	// concurrently modified.
+	reencryptAttempts = 3
+
+	// encryptionKeyCacheTTL is how long keys are cached by the providers in this file, before they are re-read.
+	// Re-reading the keys allows new keys to be picked up (key rotation) without restarting the process.
+	encryptionKeyCacheTTL = time.Minute
+)
+
+// EncryptionKeyProvider provides the key encryption keys (KEKs) that are used to wrap the per-value data encryption keys.
+// - Implementations must be safe for concurrent use.
+// - Additional implementations (for example, a cloud KMS) can be registered with SetEncryptionKeyProvider.
+type EncryptionKeyProvider interface {
+
+	// CurrentKey returns the ID and the contents of the key that should be used to encrypt new values.
+	CurrentKey(ctx context.Context) (keyID string, key []byte, err error)
+
+	// Key returns the contents of the key with the given ID. Keys that are no longer current must continue to be
+	// returned until all values have been re-encrypted with the current key.
+	Key(ctx context.Context, keyID string) ([]byte, error)
+}
+
+var (
+	encryptionKeyProviderMutex sync.RWMutex
+
+	// encryptionKeyProvider is the provider used by the database query functions. nil if encryption is disabled.
+	encryptionKeyProvider EncryptionKeyProvider
+
+	// encryptionKeyProviderInitialized is true once the provider has been configured, either from the environment
+	// or via SetEncryptionKeyProvider.
+	encryptionKeyProviderInitialized bool
+)
+
+// SetEncryptionKeyProvider sets the key provider used to encrypt/decrypt sensitive database fields. This overrides
+// any provider configured via the environment. A nil provider disables encryption of new values.
+func SetEncryptionKeyProvider(provider EncryptionKeyProvider) {
+	encryptionKeyProviderMutex.Lock()
+	defer encryptionKeyProviderMutex.Unlock()
+
+	encryptionKeyProvider = provider
+	encryptionKeyProviderInitialized = true
+}
+
+// getEncryptionKeyProvider returns the configured key provider, or nil if encryption is not enabled.
+// On first call, the provider is configured from the DB_ENCRYPTION_KEY_DIR environment variable (if set).
+func getEncryptionKeyProvider() EncryptionKeyProvider {
+
+	encryptionKeyProviderMutex.RLock()
+	if encryptionKeyProviderInitialized {
+		defer encryptionKeyProviderMutex.RUnlock()
+		return encryptionKeyProvider
+	}
+	encryptionKeyProviderMutex.RUnlock()
+
+	encryptionKeyProviderMutex.Lock()
+	defer encryptionKeyProviderMutex.Unlock()
+
+	if !encryptionKeyProviderInitialized {
+		if keyDir := os.Getenv(EncryptionKeyDirEnv); keyDir != "" {
+			encryptionKeyProvider = NewFileEncryptionKeyProvider(keyDir)
+		}
+		encryptionKeyProviderInitialized = true
+	}
+
+	return encryptionKeyProvider
+}
+
+// ConfigureEncryptionKeyProviderFromSecretEnv configures a KubernetesSecretEncryptionKeyProvider for the Secret in the
+// DB_ENCRYPTION_KEY_SECRET environment variable, which is read using the given client. This does nothing if the
+// environment variable is not set, or if DB_ENCRYPTION_KEY_DIR is set (as the key directory takes precedence).
+func ConfigureEncryptionKeyProviderFromSecretEnv(k8sClient client.Reader) error {
+
+	keySecret := os.Getenv(EncryptionKeySecretEnv)
+	if keySecret == "" || os.Getenv(EncryptionKeyDirEnv) != "" {
+		return nil
+	}
+
+	namespace, name, err := ParseEncryptionKeySecret(keySecret)
+	if err != nil {
+		return err
+	}
+
+	SetEncryptionKeyProvider(NewKubernetesSecretEncryptionKeyProvider(k8sClient, namespace, name))
+
+	return nil
+}
+
+// ParseEncryptionKeySecret parses a reference to an encryption key Secret, in the form '(namespace)/(name)'.
+func ParseEncryptionKeySecret(keySecret string) (string, string, error) {
+
+	namespaceAndName := strings.Split(keySecret, "/")
+	if len(namespaceAndName) != 2 || namespaceAndName[0] == "" || namespaceAndName[1] == "" {
+		return "", "", fmt.Errorf("invalid Secret '%s': expected (namespace)/(name)", keySecret)
+	}
+
+	return namespaceAndName[0], namespaceAndName[1], nil
+}
+
+// isEncryptedValue returns true if the database value was encrypted by encryptValue, false if it is plaintext.
+func isEncryptedValue(value string) bool {
+	return strings.HasPrefix(value, encryptedValuePrefix)
+}
+
+// encryptValue encrypts a plaintext value using the current key of the provider.
+// - Empty values, and values that are already encrypted, are returned unmodified.
+// - If provider is nil, the value is returned unmodified.
+func encryptValue(ctx context.Context, provider EncryptionKeyProvider, value string) (string, error) {
+
+	if provider == nil || value == "" || isEncryptedValue(value) {
+		return value, nil
+	}
+
+	keyID, kek, err := provider.CurrentKey(ctx)
+	if err != nil {
+		return "", fmt.Errorf("unable to retrieve current encryption key: %v", err)
+	}
+	if keyID == "" || strings.Contains(keyID, ":") {
+		return "", fmt.Errorf("invalid encryption key id: '%s'", keyID)
+	}
+
+	dek := make([]byte, encryptionKeyLength)
+	if _, err := rand.Read(dek); err != nil {
+		return "", fmt.Errorf("unable to generate data encryption key: %v", err)
+	}
+
+	wrappedDEK, err := sealWithKey(kek, dek)
+	if err != nil {
+		return "", fmt.Errorf("unable to wrap data encryption key with key '%s': %v", keyID, err)
+	}
+
+	ciphertext, err := sealWithKey(dek, []byte(value))
+	if err != nil {
+		return "", fmt.Errorf("unable to encrypt value: %v", err)
+	}
+
+	return encryptedValuePrefix + keyID + ":" + base64.StdEncoding.EncodeToString(wrappedDEK) + ":" +
+		base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// decryptValue decrypts a value that was encrypted by encryptValue. Plaintext values are returned unmodified.
+func decryptValue(ctx context.Context, provider EncryptionKeyProvider, value string) (string, error) {
+
+	if !isEncryptedValue(value) {
+		return value, nil
+	}
+
+	keyID, wrappedDEK, ciphertext, err := parseEncryptedValue(value)
+	if err != nil {
+		return "", err
+	}
+
+	if provider == nil {
+		return "", fmt.Errorf("value is encrypted with key '%s', but no encryption key provider is configured", keyID)
+	}
+
+	kek, err := provider.Key(ctx, keyID)
+	if err != nil {
+		return "", fmt.Errorf("unable to retrieve encryption key '%s': %v", keyID, err)
+	}
+
+	dek, err := openWithKey(kek, wrappedDEK)
+	if err != nil {
+		return "", fmt.Errorf("unable to unwrap data encryption key with key '%s': %v", keyID, err)
+	}
+
+	plaintext, err := openWithKey(dek, ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("unable to decrypt value: %v", err)
+	}
+
+	return string(plaintext), nil
+}
+
+// parseEncryptedValue splits an encrypted value into its key ID, wrapped DEK and ciphertext.
+func parseEncryptedValue(value string) (keyID string, wrappedDEK []byte, ciphertext []byte, err error) {
+
+	fields := strings.Split(strings.TrimPrefix(value, encryptedValuePrefix), ":")
+	if len(fields) != 3 || fields[0] == "" {
+		return "", nil, nil, fmt.Errorf("invalid encrypted value: unexpected format")
+	}
+
+	if wrappedDEK, err = base64.StdEncoding.DecodeString(fields[1]); err != nil {
+		return "", nil, nil, fmt.Errorf("invalid encrypted value: unable to decode data encryption key: %v", err)
+	}
+
+	if ciphertext, err = base64.StdEncoding.DecodeString(fields[2]); err != nil {
+		return "", nil, nil, fmt.Errorf("invalid encrypted value: unable to decode ciphertext: %v", err)
+	}
+
+	return fields[0], wrappedDEK, ciphertext, nil
+}
+
+// sealWithKey encrypts plaintext with AES-GCM, returning the nonce followed by the ciphertext.
+func sealWithKey(key []byte, plaintext []byte) ([]byte, error) {
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// openWithKey decrypts a value returned by sealWithKey.
+func openWithKey(key []byte, sealed []byte) ([]byte, error) {
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("sealed value is too short")
+	}
+
+	return gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+
+	if len(key) != encryptionKeyLength {
+		return nil, fmt.Errorf("invalid key length: expected %d bytes, got %d", encryptionKeyLength, len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// encryptSecretFields encrypts each of the fields in place, using the configured key provider. The returned function
+// restores the fields to their original (plaintext) values, and should be called once the encrypted values have been
+// written to the database.
+func encryptSecretFields(ctx context.Context, fields ...*string) (func(), error) {
+
+	provider := getEncryptionKeyProvider()
+
+	plaintextValues := make([]string, len(fields))
+	for idx := range fields {
+		plaintextValues[idx] = *fields[idx]
+	}
+
+	restore := func() {
+		for idx := range fields {
+			*fields[idx] = plaintextValues[idx]
+		}
+	}
+
+	for _, field := range fields {
+		encrypted, err := encryptValue(ctx, provider, *field)
+		if err != nil {
+			restore()
+			return nil, err
+		}
+		*field = encrypted
+	}
+
+	return restore, nil
+}
+
+// decryptSecretFields decrypts each of the fields in place, using the configured key provider.
+func decryptSecretFields(ctx context.Context, fields ...*string) error {
+
+	provider := getEncryptionKeyProvider()
+
+	for _, field := range fields {
+		plaintext, err := decryptValue(ctx, provider, *field)
+		if err != nil {
+			return err
+		}
+		*field = plaintext
+	}
+
+	return nil
+}
+
+// secretFields returns the fields of ClusterCredentials that are encrypted at rest.
+func (obj *ClusterCredentials) secretFields() []*string {
+	return []*string{&obj.Kube_config, &obj.Serviceaccount_bearer_token}
+}
+
+// validateSecretFieldLength verifies the plaintext values of the fields of ClusterCredentials that are encrypted at rest.
+// The columns of these fields were widened to fit encrypted values (migration 000026), so validateFieldLength only
+// verifies the encrypted values: here the plaintext values are verified against the original column sizes, so that
+// the limits do not change when encryption is enabled (and the values still fit if the migration is rolled back).
+func (obj *ClusterCredentials) validateSecretFieldLength() error {
+	return validatePlaintextFieldLengths([]plaintextFieldLength{
+		{"Kube_config", obj.Kube_config, clusterCredentialsKubeConfigPlaintextLength},
+		{"Serviceaccount_bearer_token", obj.Serviceaccount_bearer_token, clusterCredentialsServiceaccountBearerTokenPlaintextLength},
+	})
+}
+
+// secretFields returns the fields of RepositoryCredentials that are encrypted at rest.
+func (obj *RepositoryCredentials) secretFields() []*string {
+	return []*string{&obj.AuthPassword, &obj.AuthSSHKey}
+}
+
+// validateSecretFieldLength verifies the plaintext values of the fields of RepositoryCredentials that are encrypted at
+// rest, against the original column sizes (see ClusterCredentials.validateSecretFieldLength).
+func (obj *RepositoryCredentials) validateSecretFieldLength() error {
+	return validatePlaintextFieldLengths([]plaintextFieldLength{
+		{"AuthPassword", obj.AuthPassword, repositoryCredentialsAuthPasswordPlaintextLength},
+		{"AuthSSHKey", obj.AuthSSHKey, repositoryCredentialsAuthSSHKeyPlaintextLength},
+	})
+}
+
+type plaintextFieldLength struct {
+	fieldName     string
+	value         string
+	maximumLength int
+}
+
+func validatePlaintextFieldLengths(fields []plaintextFieldLength) error {
+	for _, field := range fields {
+		if len(field.value) > field.maximumLength {
+			return fmt.Errorf("%v value exceeds maximum size: max: %d, actual: %d", field.fieldName, field.maximumLength, len(field.value))
+		}
+	}
+	return nil
+}
+
+// ReencryptCredentials re-encrypts the sensitive fields of every ClusterCredentials and RepositoryCredentials row with
+// the current key of the configured key provider. This is used to:
+// - encrypt rows that were written before encryption was enabled
+// - rotate keys: once a new key has been made current, re-encrypt all rows so that the old key can be removed.
+//
+// Rows are processed in batches of 'batchSize'. Only the encrypted columns of each row are updated, and only if they
+// have not been modified since they were read (a row which is concurrently modified is re-read, and a row which is
+// concurrently deleted is skipped). Returns the number of ClusterCredentials and RepositoryCredentials rows that were
+// updated.
+func ReencryptCredentials(ctx context.Context, dbq DatabaseQueries, batchSize int) (clusterCredentialsUpdated int, repositoryCredentialsUpdated int, err error) {
+
+	if getEncryptionKeyProvider() == nil {
+		return 0, 0, fmt.Errorf("unable to re-encrypt credentials: no encryption key provider is configured")
+	}
+
+	if batchSize <= 0 {
+		return 0, 0, fmt.Errorf("invalid batch size: %d", batchSize)
+	}
+
+	for offset := 0; ; offset += batchSize {
+
+		var clusterCredentials []ClusterCredentials
+		if err := dbq.GetClusterCredentialsBatch(ctx, &clusterCredentials, batchSize, offset); err != nil {
+			return clusterCredentialsUpdated, repositoryCredentialsUpdated, fmt.Errorf("unable to retrieve ClusterCredentials batch: %v", err)
+		}
+
+		for _, clusterCreds := range clusterCredentials {
+			updated, err := reencryptRow(func() (bool, error) {
+				return dbq.ReencryptClusterCredentials(ctx, clusterCreds.Clustercredentials_cred_id)
+			})
+			if err != nil {
+				return clusterCredentialsUpdated, repositoryCredentialsUpdated, fmt.Errorf("unable to re-encrypt ClusterCredentials '%s': %v",
+					clusterCreds.Clustercredentials_cred_id, err)
+			}
+			if updated {
+				clusterCredentialsUpdated++
+			}
+		}
+
+		if len(clusterCredentials) < batchSize {
+			break
+		}
+	}
+
+	for offset := 0; ; offset += batchSize {
+
+		var repositoryCredentials []RepositoryCredentials
+		if err := dbq.GetRepositoryCredentialsBatch(ctx, &repositoryCredentials, batchSize, offset); err != nil {
+			return clusterCredentialsUpdated, repositoryCredentialsUpdated, fmt.Errorf("unable to retrieve RepositoryCredentials batch: %v", err)
+		}
+
+		for _, repoCreds := range repositoryCredentials {
+			updated, err := reencryptRow(func() (bool, error) {
+				return dbq.ReencryptRepositoryCredentials(ctx, repoCreds.RepositoryCredentialsID)
+			})
+			if err != nil {
+				return clusterCredentialsUpdated, repositoryCredentialsUpdated, fmt.Errorf("unable to re-encrypt RepositoryCredentials '%s': %v",
+					repoCreds.RepositoryCredentialsID, err)
+			}
+			if updated {
+				repositoryCredentialsUpdated++
+			}
+		}
+
+		if len(repositoryCredentials) < batchSize {
+			break
+		}
+	}
+
+	return clusterCredentialsUpdated, repositoryCredentialsUpdated, nil
+}
+
+// reencryptRow calls 'reencrypt' until the row is updated, up to reencryptAttempts times. Returns false if the row no
+// longer exists.
+func reencryptRow(reencrypt func() (bool, error)) (bool, error) {
+
+	for attempt := 1; attempt <= reencryptAttempts; attempt++ {
+		updated, err := reencrypt()
+		if err != nil {
+			if IsResultNotFoundError(err) {
+				return false, nil
+			}
+			return false, err
+		}
+		if updated {
+			return true, nil
+		}
+	}
+
+	return false, fmt.Errorf("the row was modified concurrently %d times", reencryptAttempts)
+}
+
+// parseEncryptionKeys parses the contents of a key directory or key Secret (see CurrentEncryptionKeyIDEntry),
+// returning the current key ID and all of the keys.
+func parseEncryptionKeys(entries map[string][]byte) (string, map[string][]byte, error) {
+
+	currentKeyIDBytes, exists := entries[CurrentEncryptionKeyIDEntry]
+	if !exists {
+		return "", nil, fmt.Errorf("missing '%s' entry", CurrentEncryptionKeyIDEntry)
+	}
+	currentKeyID := strings.TrimSpace(string(currentKeyIDBytes))
+
+	keys := map[string][]byte{}
+	for name, value := range entries {
+		if name == CurrentEncryptionKeyIDEntry {
+			continue
+		}
+
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(value)))
+		if err != nil {
+			return "", nil, fmt.Errorf("unable to decode key '%s': %v", name, err)
+		}
+		if len(key) != encryptionKeyLength {
+			return "", nil, fmt.Errorf("key '%s' has invalid length: expected %d bytes, got %d", name, encryptionKeyLength, len(key))
+		}
+		keys[name] = key
+	}
+
+	if _, exists := keys[currentKeyID]; !exists {
+		return "", nil, fmt.Errorf("current key '%s' was not found", currentKeyID)
+	}
+
+	return currentKeyID, keys, nil
+}
+
+// cachedEncryptionKeys caches the keys read by a provider, and re-reads them once they are older than encryptionKeyCacheTTL.
+type cachedEncryptionKeys struct {
+	mutex        sync.Mutex
+	currentKeyID string
+	keys         map[string][]byte
+	lastRead     time.Time
+
+	// readEntries returns the raw entries of the key directory or key Secret
+	readEntries func(ctx context.Context) (map[string][]byte, error)
+}
+
+func (c *cachedEncryptionKeys) get(ctx context.Context, forceRead bool) (string, map[string][]byte, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.keys != nil && !forceRead && time.Since(c.lastRead) < encryptionKeyCacheTTL {
+		return c.currentKeyID, c.keys, nil
+	}
+
+	entries, err := c.readEntries(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+
+	currentKeyID, keys, err := parseEncryptionKeys(entries)
+	if err != nil {
+		return "", nil, err
+	}
+
+	c.currentKeyID, c.keys, c.lastRead = currentKeyID, keys, time.Now()
+
+	return c.currentKeyID, c.keys, nil
+}
+
+func (c *cachedEncryptionKeys) currentKey(ctx context.Context) (string, []byte, error) {
+	currentKeyID, keys, err := c.get(ctx, false)
+	if err != nil {
+		return "", nil, err
+	}
+	return currentKeyID, keys[currentKeyID], nil
+}
+
+func (c *cachedEncryptionKeys) key(ctx context.Context, keyID string) ([]byte, error) {
+	_, keys, err := c.get(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if key, exists := keys[keyID]; exists {
+		return key, nil
+	}
+
+	// The key may have been added since the keys were last read, so read them again before giving up.
+	if _, keys, err = c.get(ctx, true); err != nil {
+		return nil, err
+	}
+
+	if key, exists := keys[keyID]; exists {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("key '%s' was not found", keyID)
+}
+
+// FileEncryptionKeyProvider reads keys from the files of a local directory: usually a mounted Kubernetes Secret
+// volume. See CurrentEncryptionKeyIDEntry for the expected directory contents.
+type FileEncryptionKeyProvider struct {
+	dir  string
+	keys *cachedEncryptionKeys
+}
+
+var _ EncryptionKeyProvider = &FileEncryptionKeyProvider{}
+
+func NewFileEncryptionKeyProvider(dir string) *FileEncryptionKeyProvider {
+	res := &FileEncryptionKeyProvider{dir: dir}
+	res.keys = &cachedEncryptionKeys{readEntries: res.readEntries}
+	return res
+}
+
+func (p *FileEncryptionKeyProvider) CurrentKey(ctx context.Context) (string, []byte, error) {
+	return p.keys.currentKey(ctx)
+}
+
+func (p *FileEncryptionKeyProvider) Key(ctx context.Context, keyID string) ([]byte, error) {
+	return p.keys.key(ctx, keyID)
+}
+
+func (p *FileEncryptionKeyProvider) readEntries(_ context.Context) (map[string][]byte, error) {
+
+	dirEntries, err := os.ReadDir(p.dir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read encryption key directory '%s': %v", p.dir, err)
+	}
+
+	res := map[string][]byte{}
+	for _, dirEntry := range dirEntries {
+
+		// Skip hidden files: Kubernetes Secret volumes contain '..data' symlinks and timestamped directories.
+		if strings.HasPrefix(dirEntry.Name(), ".") {
+			continue
+		}
+
+		path := filepath.Join(p.dir, dirEntry.Name())
+
+		// Use Stat rather than the DirEntry, so that symlinks are followed.
+		fileInfo, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read encryption key file '%s': %v", path, err)
+		}
+		if fileInfo.IsDir() {
+			continue
+		}
+
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read encryption key file '%s': %v", path, err)
+		}
+		res[dirEntry.Name()] = contents
+	}
+
+	return res, nil
+}
+
+// KubernetesSecretEncryptionKeyProvider reads keys from the data of a Kubernetes Secret.
+// See CurrentEncryptionKeyIDEntry for the expected Secret data.
+type KubernetesSecretEncryptionKeyProvider struct {
+	k8sClient client.Reader
+	secretKey client.ObjectKey
+	keys      *cachedEncryptionKeys
+}
+
+var _ EncryptionKeyProvider = &KubernetesSecretEncryptionKeyProvider{}
+
+func NewKubernetesSecretEncryptionKeyProvider(k8sClient client.Reader, namespace string, name string) *KubernetesSecretEncryptionKeyProvider {
+	res := &KubernetesSecretEncryptionKeyProvider{
+		k8sClient: k8sClient,
+		secretKey: client.ObjectKey{Namespace: namespace, Name: name},
+	}
+	res.keys = &cachedEncryptionKeys{readEntries: res.readEntries}
+	return res
+}
+
+func (p *KubernetesSecretEncryptionKeyProvider) CurrentKey(ctx context.Context) (string, []byte, error) {
+	return p.keys.currentKey(ctx)
+}
+
+func (p *KubernetesSecretEncryptionKeyProvider) Key(ctx context.Context, keyID string) ([]byte, error) {
+	return p.keys.key(ctx, keyID)
+}
+
+func (p *KubernetesSecretEncryptionKeyProvider) readEntries(ctx context.Context) (map[string][]byte, error) {
+
+	if p.k8sClient == nil {
+		return nil, errors.New("kubernetes client is nil")
+	}
+
+	secret := corev1.Secret{}
+	if err := p.k8sClient.Get(ctx, p.secretKey, &secret); err != nil {
+		return nil, fmt.Errorf("unable to retrieve encryption key Secret '%s': %v", p.secretKey.String(), err)
+	}
+
+	return secret.Data, nil
+}
@@ -0,0 +1,286 @@
+package db_test
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	db "github.com/redhat-appstudio/managed-gitops/backend-shared/db"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// newTestEncryptionKey returns a base64-encoded AES-256 key, filled with the given byte.
+func newTestEncryptionKey(b byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string([]byte{b}), 32)))
+}
+
+// writeTestEncryptionKeyDir writes the given keys to a temporary key directory, with currentKeyID as the current key.
+func writeTestEncryptionKeyDir(currentKeyID string, keys map[string]string) string {
+	dir := GinkgoT().TempDir()
+
+	Expect(os.WriteFile(filepath.Join(dir, db.CurrentEncryptionKeyIDEntry), []byte(currentKeyID+"\n"), 0600)).To(Succeed())
+	for keyID, key := range keys {
+		Expect(os.WriteFile(filepath.Join(dir, keyID), []byte(key), 0600)).To(Succeed())
+	}
+
+	return dir
+}
+
+var _ = Describe("Encryption key provider tests", func() {
+
+	ctx := context.Background()
+
+	It("should read the current key and older keys from a key directory", func() {
+
+		dir := writeTestEncryptionKeyDir("key-2", map[string]string{
+			"key-1": newTestEncryptionKey('1'),
+			"key-2": newTestEncryptionKey('2'),
+		})
+
+		By("creating a hidden file, as is found in a mounted Secret volume, which should be ignored")
+		Expect(os.WriteFile(filepath.Join(dir, "..data"), []byte("not-a-key"), 0600)).To(Succeed())
+
+		provider := db.NewFileEncryptionKeyProvider(dir)
+
+		keyID, key, err := provider.CurrentKey(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(keyID).To(Equal("key-2"))
+		Expect(key).To(Equal([]byte(strings.Repeat("2", 32))))
+
+		key, err = provider.Key(ctx, "key-1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(key).To(Equal([]byte(strings.Repeat("1", 32))))
+
+		_, err = provider.Key(ctx, "key-3")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should return an error if the current key is missing or invalid", func() {
+
+		provider := db.NewFileEncryptionKeyProvider(writeTestEncryptionKeyDir("key-2", map[string]string{
+			"key-1": newTestEncryptionKey('1'),
+		}))
+		_, _, err := provider.CurrentKey(ctx)
+		Expect(err).To(HaveOccurred())
+
+		provider = db.NewFileEncryptionKeyProvider(writeTestEncryptionKeyDir("key-1", map[string]string{
+			"key-1": base64.StdEncoding.EncodeToString([]byte("too-short")),
+		}))
+		_, _, err = provider.CurrentKey(ctx)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should read keys from a Kubernetes Secret", func() {
+
+		scheme := runtime.NewScheme()
+		Expect(corev1.AddToScheme(scheme)).To(Succeed())
+
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "gitops-db-encryption-keys",
+				Namespace: "gitops",
+			},
+			Data: map[string][]byte{
+				db.CurrentEncryptionKeyIDEntry: []byte("key-1"),
+				"key-1":                        []byte(newTestEncryptionKey('1')),
+			},
+		}
+
+		k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+
+		provider := db.NewKubernetesSecretEncryptionKeyProvider(k8sClient, secret.Namespace, secret.Name)
+
+		keyID, key, err := provider.CurrentKey(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(keyID).To(Equal("key-1"))
+		Expect(key).To(Equal([]byte(strings.Repeat("1", 32))))
+	})
+	It("should parse a reference to an encryption key Secret", func() {
+
+		namespace, name, err := db.ParseEncryptionKeySecret("gitops/gitops-db-encryption-keys")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(namespace).To(Equal("gitops"))
+		Expect(name).To(Equal("gitops-db-encryption-keys"))
+
+		for _, invalid := range []string{"gitops-db-encryption-keys", "gitops/", "/gitops-db-encryption-keys", "a/b/c"} {
+			_, _, err = db.ParseEncryptionKeySecret(invalid)
+			Expect(err).To(HaveOccurred(), invalid)
+		}
+	})
+})
+
+var _ = Describe("Encryption of credentials at rest", func() {
+
+	var ctx context.Context
+	var dbq db.AllDatabaseQueries
+
+	BeforeEach(func() {
+		err := db.SetupForTestingDBGinkgo()
+		Expect(err).ToNot(HaveOccurred())
+
+		ctx = context.Background()
+
+		dbq, err = db.NewUnsafePostgresDBQueries(true, true)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		db.SetEncryptionKeyProvider(nil)
+		dbq.CloseDatabase()
+	})
+
+	It("should transparently encrypt ClusterCredentials and RepositoryCredentials, and re-encrypt them with a new key", func() {
+
+		By("enabling encryption, with 'key-1' as the current key")
+		db.SetEncryptionKeyProvider(db.NewFileEncryptionKeyProvider(writeTestEncryptionKeyDir("key-1", map[string]string{
+			"key-1": newTestEncryptionKey('1'),
+		})))
+
+		clusterCreds := db.ClusterCredentials{
+			Host:                        "test-host",
+			Kube_config:                 "test-kube_config",
+			Kube_config_context:         "test-kube_config_context",
+			Serviceaccount_bearer_token: "test-serviceaccount_bearer_token",
+			Serviceaccount_ns:           "test-serviceaccount_ns",
+		}
+		Expect(dbq.CreateClusterCredentials(ctx, &clusterCreds)).To(Succeed())
+
+		By("verifying the caller's object still contains the plaintext values")
+		Expect(clusterCreds.Kube_config).To(Equal("test-kube_config"))
+		Expect(clusterCreds.Serviceaccount_bearer_token).To(Equal("test-serviceaccount_bearer_token"))
+
+		_, _, _, gitopsEngineInstance, _, err := db.CreateSampleData(dbq)
+		Expect(err).ToNot(HaveOccurred())
+
+		clusterUser := &db.ClusterUser{
+			Clusteruser_id: "test-encryption-user",
+			User_name:      "test-encryption-user",
+		}
+		Expect(dbq.CreateClusterUser(ctx, clusterUser)).To(Succeed())
+
+		repoCreds := db.RepositoryCredentials{
+			UserID:          clusterUser.Clusteruser_id,
+			PrivateURL:      "https://github.com/test-org/test-repo.git",
+			AuthUsername:    "test-auth-username",
+			AuthPassword:    "test-auth-password",
+			AuthSSHKey:      "test-auth-ssh-key",
+			SecretObj:       "test-secret-obj",
+			EngineClusterID: gitopsEngineInstance.Gitopsengineinstance_id,
+		}
+		Expect(dbq.CreateRepositoryCredentials(ctx, &repoCreds)).To(Succeed())
+		Expect(repoCreds.AuthPassword).To(Equal("test-auth-password"))
+
+		By("verifying the values are decrypted when they are read")
+		fetchedClusterCreds := db.ClusterCredentials{Clustercredentials_cred_id: clusterCreds.Clustercredentials_cred_id}
+		Expect(dbq.GetClusterCredentialsById(ctx, &fetchedClusterCreds)).To(Succeed())
+		Expect(fetchedClusterCreds).To(Equal(clusterCreds))
+
+		fetchedRepoCreds, err := dbq.GetRepositoryCredentialsByID(ctx, repoCreds.RepositoryCredentialsID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fetchedRepoCreds.AuthPassword).To(Equal("test-auth-password"))
+		Expect(fetchedRepoCreds.AuthSSHKey).To(Equal("test-auth-ssh-key"))
+
+		By("verifying the values are stored encrypted, by disabling encryption and attempting to read them")
+		db.SetEncryptionKeyProvider(nil)
+		err = dbq.GetClusterCredentialsById(ctx, &db.ClusterCredentials{Clustercredentials_cred_id: clusterCreds.Clustercredentials_cred_id})
+		Expect(err).To(HaveOccurred())
+		_, err = dbq.GetRepositoryCredentialsByID(ctx, repoCreds.RepositoryCredentialsID)
+		Expect(err).To(HaveOccurred())
+
+		By("rotating to 'key-2', and re-encrypting all rows")
+		db.SetEncryptionKeyProvider(db.NewFileEncryptionKeyProvider(writeTestEncryptionKeyDir("key-2", map[string]string{
+			"key-1": newTestEncryptionKey('1'),
+			"key-2": newTestEncryptionKey('2'),
+		})))
+
+		clusterCredsUpdated, repoCredsUpdated, err := db.ReencryptCredentials(ctx, dbq, 2)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(clusterCredsUpdated).To(BeNumerically(">=", 1))
+		Expect(repoCredsUpdated).To(BeNumerically(">=", 1))
+
+		By("removing 'key-1', and verifying the rows can still be read")
+		db.SetEncryptionKeyProvider(db.NewFileEncryptionKeyProvider(writeTestEncryptionKeyDir("key-2", map[string]string{
+			"key-2": newTestEncryptionKey('2'),
+		})))
+
+		fetchedClusterCreds = db.ClusterCredentials{Clustercredentials_cred_id: clusterCreds.Clustercredentials_cred_id}
+		Expect(dbq.GetClusterCredentialsById(ctx, &fetchedClusterCreds)).To(Succeed())
+		Expect(fetchedClusterCreds.Kube_config).To(Equal("test-kube_config"))
+		Expect(fetchedClusterCreds.Serviceaccount_bearer_token).To(Equal("test-serviceaccount_bearer_token"))
+
+		fetchedRepoCreds, err = dbq.GetRepositoryCredentialsByID(ctx, repoCreds.RepositoryCredentialsID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fetchedRepoCreds.AuthPassword).To(Equal("test-auth-password"))
+		Expect(fetchedRepoCreds.AuthSSHKey).To(Equal("test-auth-ssh-key"))
+	})
+
+	It("should only re-encrypt the secret columns, and limit the plaintext values of RepositoryCredentials to the original column sizes", func() {
+
+		db.SetEncryptionKeyProvider(db.NewFileEncryptionKeyProvider(writeTestEncryptionKeyDir("key-1", map[string]string{
+			"key-1": newTestEncryptionKey('1'),
+		})))
+
+		_, _, _, gitopsEngineInstance, _, err := db.CreateSampleData(dbq)
+		Expect(err).ToNot(HaveOccurred())
+
+		clusterUser := &db.ClusterUser{
+			Clusteruser_id: "test-encryption-user",
+			User_name:      "test-encryption-user",
+		}
+		Expect(dbq.CreateClusterUser(ctx, clusterUser)).To(Succeed())
+
+		repoCreds := db.RepositoryCredentials{
+			UserID:          clusterUser.Clusteruser_id,
+			PrivateURL:      "https://github.com/test-org/test-repo.git",
+			AuthUsername:    "test-auth-username",
+			AuthPassword:    strings.Repeat("p", 1025),
+			SecretObj:       "test-secret-obj",
+			EngineClusterID: gitopsEngineInstance.Gitopsengineinstance_id,
+		}
+
+		By("rejecting a password which exceeds the original column size")
+		err = dbq.CreateRepositoryCredentials(ctx, &repoCreds)
+		Expect(db.IsMaxLengthError(err)).To(BeTrue())
+
+		repoCreds.RepositoryCredentialsID = ""
+		repoCreds.AuthPassword = "test-auth-password"
+		Expect(dbq.CreateRepositoryCredentials(ctx, &repoCreds)).To(Succeed())
+
+		repoCreds.AuthSSHKey = strings.Repeat("s", 1025)
+		Expect(db.IsMaxLengthError(dbq.UpdateRepositoryCredentials(ctx, &repoCreds))).To(BeTrue())
+
+		By("re-encrypting the row with 'key-2'")
+		db.SetEncryptionKeyProvider(db.NewFileEncryptionKeyProvider(writeTestEncryptionKeyDir("key-2", map[string]string{
+			"key-1": newTestEncryptionKey('1'),
+			"key-2": newTestEncryptionKey('2'),
+		})))
+
+		updated, err := dbq.ReencryptRepositoryCredentials(ctx, repoCreds.RepositoryCredentialsID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(updated).To(BeTrue())
+
+		By("verifying the row can be read with only 'key-2', and its other columns are unchanged")
+		db.SetEncryptionKeyProvider(db.NewFileEncryptionKeyProvider(writeTestEncryptionKeyDir("key-2", map[string]string{
+			"key-2": newTestEncryptionKey('2'),
+		})))
+
+		fetchedRepoCreds, err := dbq.GetRepositoryCredentialsByID(ctx, repoCreds.RepositoryCredentialsID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fetchedRepoCreds.AuthPassword).To(Equal("test-auth-password"))
+		Expect(fetchedRepoCreds.AuthUsername).To(Equal("test-auth-username"))
+		Expect(fetchedRepoCreds.SecretObj).To(Equal("test-secret-obj"))
+
+		By("skipping a row which no longer exists")
+		_, err = dbq.DeleteRepositoryCredentialsByID(ctx, repoCreds.RepositoryCredentialsID)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = dbq.ReencryptRepositoryCredentials(ctx, repoCreds.RepositoryCredentialsID)
+		Expect(db.IsResultNotFoundError(err)).To(BeTrue())
+	})
+})
@@ -0,0 +1,146 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/go-pg/pg/v10"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// OperationStateChangedChannel is the PostgreSQL LISTEN/NOTIFY channel on which Operation state changes are published.
+// The payload of each notification is the ID of the Operation.
+const OperationStateChangedChannel = "operation_state_changed"
+
+// OperationStateChangeSubscriber is implemented by DatabaseQueries implementations that are able to notify callers when
+// the state of an Operation changes (for example, PostgreSQLDatabaseQueries, via LISTEN/NOTIFY).
+type OperationStateChangeSubscriber interface {
+
+	// SubscribeToOperationStateChanges returns a channel that receives a value whenever a state change is published
+	// for the given Operation. The returned function must be called to unsubscribe, once the caller is no longer interested.
+	//
+	// Notifications may be lost (for example, while the listener is reconnecting to the database), so callers should
+	// also periodically check the state of the Operation.
+	SubscribeToOperationStateChanges(ctx context.Context, operationID string) (<-chan struct{}, func(), error)
+}
+
+// NotifyOperationStateChanged publishes a notification, on OperationStateChangedChannel, that the state of the given
+// Operation has changed.
+func (dbq *PostgreSQLDatabaseQueries) NotifyOperationStateChanged(ctx context.Context, operationID string) error {
+
+	if err := validateQueryParams(operationID, dbq); err != nil {
+		return err
+	}
+
+	if _, err := dbq.dbConnection.ExecContext(ctx, "SELECT pg_notify(?, ?)", OperationStateChangedChannel, operationID); err != nil {
+		return fmt.Errorf("unable to notify operation state change: %v, %v", err, operationID)
+	}
+
+	return nil
+}
+
+func (dbq *PostgreSQLDatabaseQueries) SubscribeToOperationStateChanges(ctx context.Context, operationID string) (<-chan struct{}, func(), error) {
+
+	if err := validateQueryParams(operationID, dbq); err != nil {
+		return nil, nil, err
+	}
+
+	dbq.operationStateListenerMutex.Lock()
+	if dbq.operationStateListener == nil {
+		listener, err := newOperationStateListener(ctx, dbq.dbConnection)
+		if err != nil {
+			dbq.operationStateListenerMutex.Unlock()
+			return nil, nil, err
+		}
+		dbq.operationStateListener = listener
+	}
+	listener := dbq.operationStateListener
+	dbq.operationStateListenerMutex.Unlock()
+
+	ch, unsubscribe := listener.subscribe(operationID)
+
+	return ch, unsubscribe, nil
+}
+
+// operationStateListener maintains a single LISTEN connection to the database, and forwards the notifications it
+// receives to the subscribers of the corresponding Operation.
+type operationStateListener struct {
+	pgListener *pg.Listener
+
+	mutex sync.Mutex
+	// subscribers is a map from Operation ID to the set of subscriber channels for that Operation
+	subscribers map[string]map[chan struct{}]bool
+}
+
+func newOperationStateListener(ctx context.Context, dbConnection *pg.DB) (*operationStateListener, error) {
+
+	// Listen is called separately from creating the listener, so that we are able to return any error that occurs.
+	pgListener := dbConnection.Listen(ctx)
+	if err := pgListener.Listen(ctx, OperationStateChangedChannel); err != nil {
+		_ = pgListener.Close()
+		return nil, fmt.Errorf("unable to listen on channel '%s': %v", OperationStateChangedChannel, err)
+	}
+
+	res := &operationStateListener{
+		pgListener:  pgListener,
+		subscribers: map[string]map[chan struct{}]bool{},
+	}
+
+	go res.forwardNotifications()
+
+	return res, nil
+}
+
+// forwardNotifications forwards notifications to subscribers, until the listener is closed. The go-pg listener
+// channel automatically reconnects on connection failure.
+func (osl *operationStateListener) forwardNotifications() {
+
+	for notification := range osl.pgListener.Channel() {
+
+		if notification.Channel != OperationStateChangedChannel {
+			continue
+		}
+
+		osl.mutex.Lock()
+		for ch := range osl.subscribers[notification.Payload] {
+			// Never block the listener: a single pending notification is sufficient for a subscriber to re-check the Operation.
+			select {
+			case ch <- struct{}{}:
+			default:
+			}
+		}
+		osl.mutex.Unlock()
+	}
+
+	log.FromContext(context.Background()).V(1).Info("Operation state listener channel was closed")
+}
+
+func (osl *operationStateListener) subscribe(operationID string) (<-chan struct{}, func()) {
+
+	ch := make(chan struct{}, 1)
+
+	osl.mutex.Lock()
+	defer osl.mutex.Unlock()
+
+	if osl.subscribers[operationID] == nil {
+		osl.subscribers[operationID] = map[chan struct{}]bool{}
+	}
+	osl.subscribers[operationID][ch] = true
+
+	unsubscribe := func() {
+		osl.mutex.Lock()
+		defer osl.mutex.Unlock()
+
+		delete(osl.subscribers[operationID], ch)
+		if len(osl.subscribers[operationID]) == 0 {
+			delete(osl.subscribers, operationID)
+		}
+	}
+
+	return ch, unsubscribe
+}
+
+func (osl *operationStateListener) close() error {
+	return osl.pgListener.Close()
+}
@@ -123,6 +123,25 @@ var _ = Describe("Operations Test", func() {
 
 	})
 
+	It("Should notify subscribers when NotifyOperationStateChanged is called for their Operation", func() {
+
+		subscriber, ok := dbq.(db.OperationStateChangeSubscriber)
+		Expect(ok).To(BeTrue())
+
+		ch, unsubscribe, err := subscriber.SubscribeToOperationStateChanges(ctx, "test-operation-1")
+		Expect(err).ToNot(HaveOccurred())
+		defer unsubscribe()
+
+		otherCh, otherUnsubscribe, err := subscriber.SubscribeToOperationStateChanges(ctx, "test-operation-2")
+		Expect(err).ToNot(HaveOccurred())
+		defer otherUnsubscribe()
+
+		Expect(dbq.NotifyOperationStateChanged(ctx, "test-operation-1")).To(Succeed())
+
+		Eventually(ch, "10s").Should(Receive())
+		Consistently(otherCh, "1s").ShouldNot(Receive())
+	})
+
 	It("Should Get Operation in batch.", func() {
 
 		ctx = context.Background()
@@ -59,6 +59,7 @@ type UnsafeDatabaseQueries interface {
 	UnsafeListAllRepositoryCredentials(ctx context.Context, repositoryCredentials *[]RepositoryCredentials) error
 	UnsafeListAllAppProjectRepositories(ctx context.Context, appRepositories *[]AppProjectRepository) error
 	UnsafeListAllAppProjectManagedEnvironments(ctx context.Context, appProjectManagedEnv *[]AppProjectManagedEnvironment) error
+	UnsafeListAllAppProjectSyncWindows(ctx context.Context, appProjectSyncWindows *[]AppProjectSyncWindow) error
 	UnsafeListAllApplicationOwners(ctx context.Context, obj *[]ApplicationOwner) error
 }
 
@@ -74,6 +75,7 @@ type DatabaseQueries interface {
 	CreateRepositoryCredentials(ctx context.Context, obj *RepositoryCredentials) error
 	UpdateRepositoryCredentials(ctx context.Context, obj *RepositoryCredentials) error
 	CreateClusterCredentials(ctx context.Context, obj *ClusterCredentials) error
+	UpdateClusterCredentials(ctx context.Context, obj *ClusterCredentials) error
 	CreateClusterUser(ctx context.Context, obj *ClusterUser) error
 	CreateGitopsEngineCluster(ctx context.Context, obj *GitopsEngineCluster) error
 	CreateGitopsEngineInstance(ctx context.Context, obj *GitopsEngineInstance) error
@@ -118,6 +120,13 @@ type DatabaseQueries interface {
 	// Get RepositoryCredentials in a batch. Batch size defined by 'limit' and starting point of batch is defined by 'offSet'.
 	GetRepositoryCredentialsBatch(ctx context.Context, repositoryCredentials *[]RepositoryCredentials, limit, offSet int) error
 
+	// ListRepositoryCredentialsByClusterUserID returns all the RepositoryCredentials rows of the given ClusterUser.
+	ListRepositoryCredentialsByClusterUserID(ctx context.Context, clusterUserID string, repositoryCredentials *[]RepositoryCredentials) error
+
+	// UpdateRepositoryCredentialsEngineInstance moves the RepositoryCredentials row to the GitopsEngineInstance
+	// 'toEngineInstanceID', only if it is on 'fromEngineInstanceID'. Returns the number of rows updated.
+	UpdateRepositoryCredentialsEngineInstance(ctx context.Context, id string, fromEngineInstanceID string, toEngineInstanceID string) (int, error)
+
 	// Get SyncOperations in a batch. Batch size defined by 'limit' and starting point of batch is defined by 'offSet'.
 	GetSyncOperationsBatch(ctx context.Context, syncOperations *[]SyncOperation, limit, offSet int) error
 
@@ -136,6 +145,16 @@ type DatabaseQueries interface {
 	// Get ClusterCredentials in a batch. Batch size defined by 'limit' and starting point of batch is defined by 'offSet'.
 	GetClusterCredentialsBatch(ctx context.Context, clusterCredentials *[]ClusterCredentials, limit, offSet int) error
 
+	// ReencryptClusterCredentials re-encrypts the secret fields of the ClusterCredentials row with the current key.
+	// Only the secret columns are updated, and only if they still contain the values that were read: returns false if
+	// they were modified concurrently.
+	ReencryptClusterCredentials(ctx context.Context, id string) (bool, error)
+
+	// ReencryptRepositoryCredentials re-encrypts the secret fields of the RepositoryCredentials row with the current
+	// key. Only the secret columns are updated, and only if they still contain the values that were read: returns
+	// false if they were modified concurrently.
+	ReencryptRepositoryCredentials(ctx context.Context, id string) (bool, error)
+
 	// Get Operation in a batch. Batch size defined by 'limit' and starting point of batch is defined by 'offSet'.
 	GetOperationBatch(ctx context.Context, operations *[]Operation, limit, offSet int) error
 
@@ -177,6 +196,16 @@ type DatabaseQueries interface {
 
 	ListClusterAccessesByManagedEnvironmentID(ctx context.Context, managedEnvironmentID string, clusterAccesses *[]ClusterAccess) error
 
+	// ListClusterAccessesByClusterUserID returns all the ClusterAccess rows of the given ClusterUser.
+	ListClusterAccessesByClusterUserID(ctx context.Context, clusterUserID string, clusterAccesses *[]ClusterAccess) error
+
+	// CountApplicationsForGitopsEngineInstance returns the number of Applications that are hosted on the given GitopsEngineInstance
+	CountApplicationsForGitopsEngineInstance(ctx context.Context, gitopsEngineInstanceID string) (int, error)
+
+	// UpdateApplicationEngineInstance updates the GitopsEngineInstance and spec field of the Application, only if the row
+	// still has the expected GitopsEngineInstance and spec field. Returns the number of rows updated.
+	UpdateApplicationEngineInstance(ctx context.Context, obj *Application, expectedEngineInstanceID string, expectedSpecField string) (int, error)
+
 	// ListApplicationsForManagedEnvironment returns a list of all Applications that reference the specified ManagedEnvironment row
 	ListApplicationsForManagedEnvironment(ctx context.Context, managedEnvironmentID string, applications *[]Application) (int, error)
 
@@ -234,6 +263,19 @@ type DatabaseQueries interface {
 
 	// CountAppProjectManagedEnvironmentByClusterUserID number of appProjectManagedEnv by clusteruser_id
 	CountAppProjectManagedEnvironmentByClusterUserID(ctx context.Context, obj *AppProjectManagedEnvironment) (int, error)
+
+	// CreateAppProjectSyncWindow creates appProjectSyncWindow in database
+	CreateAppProjectSyncWindow(ctx context.Context, obj *AppProjectSyncWindow) error
+
+	// ListAppProjectSyncWindowByClusterUserId returns a list of all appProjectSyncWindows that reference the specified clusteruser_id row.
+	ListAppProjectSyncWindowByClusterUserId(ctx context.Context,
+		clusteruser_id string, appProjectSyncWindows *[]AppProjectSyncWindow) error
+
+	// DeleteAppProjectSyncWindowByAppProjectSyncWindowID deletes appProjectSyncWindow by its primary key
+	DeleteAppProjectSyncWindowByAppProjectSyncWindowID(ctx context.Context, obj *AppProjectSyncWindow) (int, error)
+
+	// DeleteAppProjectSyncWindowsByClusterUserId deletes all the appProjectSyncWindows that reference the specified clusteruser_id row.
+	DeleteAppProjectSyncWindowsByClusterUserId(ctx context.Context, clusteruser_id string) (int, error)
 }
 
 // ApplicationScopedQueries are the set of database queries that act on application DB resources:
@@ -263,6 +305,10 @@ type ApplicationScopedQueries interface {
 
 	UpdateOperation(ctx context.Context, obj *Operation) error
 
+	// NotifyOperationStateChanged notifies any subscribers (for example, callers of CreateOperation that are waiting
+	// for the Operation to complete) that the state of the Operation has changed.
+	NotifyOperationStateChanged(ctx context.Context, operationID string) error
+
 	CreateOperation(ctx context.Context, obj *Operation, ownerId string) error
 	GetOperationById(ctx context.Context, operation *Operation) error
 	ListOperationsByResourceIdAndTypeAndOwnerId(ctx context.Context, resourceID string, resourceType OperationResourceType,
@@ -278,6 +324,9 @@ type ApplicationScopedQueries interface {
 	DeleteSyncOperationById(ctx context.Context, id string) (int, error)
 	UpdateSyncOperation(ctx context.Context, obj *SyncOperation) error
 
+	// UpdateSyncOperationStatus updates only the fields of the SyncOperation that describe the outcome of the sync.
+	UpdateSyncOperationStatus(ctx context.Context, obj *SyncOperation) error
+
 	CreateApplication(ctx context.Context, obj *Application) error
 	CheckedCreateApplication(ctx context.Context, obj *Application, ownerId string) error
 	GetApplicationById(ctx context.Context, application *Application) error
@@ -357,6 +406,11 @@ type PostgreSQLDatabaseQueries struct {
 	// allowClose: if true, calling Close on PostgreSQLDatabaseQueries will close the connection pool; if false,
 	// the close operation will be ignored.
 	allowClose bool
+
+	// operationStateListener is lazily created on the first call to SubscribeToOperationStateChanges, and is shared by
+	// all subscribers. See operation_notifications.go.
+	operationStateListener      *operationStateListener
+	operationStateListenerMutex sync.Mutex
 }
 
 var internalSharedDBEntity internalSharedDBConnectionPool
@@ -485,6 +539,15 @@ func (dbq *PostgreSQLDatabaseQueries) CloseDatabase() {
 		//
 		// It is rare to Close a DB, as the DB handle is meant to be
 		// long-lived and shared between many goroutines.
+		dbq.operationStateListenerMutex.Lock()
+		if dbq.operationStateListener != nil {
+			if err := dbq.operationStateListener.close(); err != nil {
+				log.Error(err, "Error occurred on closing the operation state listener")
+			}
+			dbq.operationStateListener = nil
+		}
+		dbq.operationStateListenerMutex.Unlock()
+
 		err := dbq.dbConnection.Close()
 		if err != nil {
 			log.Error(err, "Error occurred on CloseDatabase()")
@@ -34,6 +34,16 @@ func (dbq *PostgreSQLDatabaseQueries) CreateRepositoryCredentials(ctx context.Co
 
 	obj.Created_on = time.Now()
 
+	if err := obj.validateSecretFieldLength(); err != nil {
+		return err
+	}
+
+	restoreSecretFields, err := encryptSecretFields(ctx, obj.secretFields()...)
+	if err != nil {
+		return fmt.Errorf("%v: %w", errCreateRepositoryCredentials, err)
+	}
+	defer restoreSecretFields()
+
 	result, err := dbq.dbConnection.Model(obj).Context(ctx).Insert()
 	if err != nil {
 		return fmt.Errorf("%v: %w", errCreateRepositoryCredentials, err)
@@ -75,6 +85,10 @@ func (dbq *PostgreSQLDatabaseQueries) GetRepositoryCredentialsByID(ctx context.C
 		return obj, fmt.Errorf("%v: %w", errGetRepositoryCredentials, err)
 	}
 
+	if err = decryptSecretFields(ctx, obj.secretFields()...); err != nil {
+		return obj, fmt.Errorf("%v: %w", errGetRepositoryCredentials, err)
+	}
+
 	return obj, nil
 }
 
@@ -86,6 +100,16 @@ func (dbq *PostgreSQLDatabaseQueries) UpdateRepositoryCredentials(ctx context.Co
 		return err
 	}
 
+	if err := obj.validateSecretFieldLength(); err != nil {
+		return err
+	}
+
+	restoreSecretFields, err := encryptSecretFields(ctx, obj.secretFields()...)
+	if err != nil {
+		return fmt.Errorf("%v: %w", errUpdateRepositoryCredentials, err)
+	}
+	defer restoreSecretFields()
+
 	result, err := dbq.dbConnection.Model(obj).WherePK().Context(ctx).Update()
 	if err != nil {
 		return fmt.Errorf("%v: %w", errUpdateRepositoryCredentials, err)
@@ -98,6 +122,41 @@ func (dbq *PostgreSQLDatabaseQueries) UpdateRepositoryCredentials(ctx context.Co
 	return nil
 }
 
+func (dbq *PostgreSQLDatabaseQueries) ReencryptRepositoryCredentials(ctx context.Context, id string) (bool, error) {
+	if err := validateQueryParams(id, dbq); err != nil {
+		return false, err
+	}
+
+	obj := &RepositoryCredentials{RepositoryCredentialsID: id}
+	if err := dbq.dbConnection.Model(obj).WherePK().Context(ctx).Select(); err != nil {
+		return false, fmt.Errorf("%v: %w", errGetRepositoryCredentials, err)
+	}
+
+	// The values as they are stored, which must not have changed when the row is updated
+	storedPassword, storedSSHKey := obj.AuthPassword, obj.AuthSSHKey
+
+	if err := decryptSecretFields(ctx, obj.secretFields()...); err != nil {
+		return false, fmt.Errorf("%v: %w", errGetRepositoryCredentials, err)
+	}
+
+	if _, err := encryptSecretFields(ctx, obj.secretFields()...); err != nil {
+		return false, fmt.Errorf("%v: %w", errUpdateRepositoryCredentials, err)
+	}
+
+	result, err := dbq.dbConnection.Model(obj).Context(ctx).
+		Set("repo_cred_pass = ?", obj.AuthPassword).
+		Set("repo_cred_ssh = ?", obj.AuthSSHKey).
+		WherePK().
+		Where("repo_cred_pass = ?", storedPassword).
+		Where("repo_cred_ssh = ?", storedSSHKey).
+		Update()
+	if err != nil {
+		return false, fmt.Errorf("%v: %w", errUpdateRepositoryCredentials, err)
+	}
+
+	return result.RowsAffected() == 1, nil
+}
+
 func (dbq *PostgreSQLDatabaseQueries) UnsafeListAllRepositoryCredentials(ctx context.Context, repositoryCredentials *[]RepositoryCredentials) error {
 
 	if err := validateUnsafeQueryParamsNoPK(dbq); err != nil {
@@ -110,9 +169,78 @@ func (dbq *PostgreSQLDatabaseQueries) UnsafeListAllRepositoryCredentials(ctx con
 		return err
 	}
 
+	for idx := range *repositoryCredentials {
+		if err := decryptSecretFields(ctx, (*repositoryCredentials)[idx].secretFields()...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ListRepositoryCredentialsByClusterUserID returns all the RepositoryCredentials rows of the given ClusterUser.
+func (dbq *PostgreSQLDatabaseQueries) ListRepositoryCredentialsByClusterUserID(ctx context.Context, clusterUserID string,
+	repositoryCredentials *[]RepositoryCredentials) error {
+
+	if err := validateQueryParamsEntity(repositoryCredentials, dbq); err != nil {
+		return err
+	}
+
+	if err := isEmptyValues("ListRepositoryCredentialsByClusterUserID",
+		"clusterUserID", clusterUserID); err != nil {
+		return err
+	}
+
+	var dbResults []RepositoryCredentials
+
+	if err := dbq.dbConnection.Model(&dbResults).
+		Where("repo_cred_user_id = ?", clusterUserID).
+		Context(ctx).
+		Select(); err != nil {
+
+		return fmt.Errorf("%v: %w", errGetRepositoryCredentials, err)
+	}
+
+	for idx := range dbResults {
+		if err := decryptSecretFields(ctx, dbResults[idx].secretFields()...); err != nil {
+			return fmt.Errorf("%v: %w", errGetRepositoryCredentials, err)
+		}
+	}
+
+	*repositoryCredentials = dbResults
+
 	return nil
 }
 
+// UpdateRepositoryCredentialsEngineInstance moves the RepositoryCredentials row to the GitopsEngineInstance
+// 'toEngineInstanceID', only if it is on 'fromEngineInstanceID'. Only the engine instance column is updated, so the
+// (encrypted) credentials of the row are not modified. Returns the number of rows updated.
+func (dbq *PostgreSQLDatabaseQueries) UpdateRepositoryCredentialsEngineInstance(ctx context.Context, id string, fromEngineInstanceID string,
+	toEngineInstanceID string) (int, error) {
+
+	if err := validateQueryParams(id, dbq); err != nil {
+		return 0, err
+	}
+
+	if err := isEmptyValues("UpdateRepositoryCredentialsEngineInstance",
+		"fromEngineInstanceID", fromEngineInstanceID,
+		"toEngineInstanceID", toEngineInstanceID); err != nil {
+		return 0, err
+	}
+
+	result, err := dbq.dbConnection.Model((*RepositoryCredentials)(nil)).
+		Set("repo_cred_engine_id = ?", toEngineInstanceID).
+		Where("repositorycredentials_id = ?", id).
+		Where("repo_cred_engine_id = ?", fromEngineInstanceID).
+		Context(ctx).
+		Update()
+	if err != nil {
+		return 0, fmt.Errorf("%v: %w", errUpdateRepositoryCredentials, err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
 func (obj *RepositoryCredentials) Dispose(ctx context.Context, dbq DatabaseQueries) error {
 	if dbq == nil {
 		return fmt.Errorf("missing database interface in RepositoryCredentials dispose")
@@ -122,14 +250,30 @@ func (obj *RepositoryCredentials) Dispose(ctx context.Context, dbq DatabaseQueri
 	return err
 }
 
+// HasSecretRef returns true if the credentials are not stored in the row, but instead should be read from
+// the referenced Secret.
+func (obj *RepositoryCredentials) HasSecretRef() bool {
+	return obj.SecretRefName != ""
+}
+
 // Get RepositoryCredentials in a batch. Batch size defined by 'limit' and starting point of batch is defined by 'offSet'.
 // For example if you want RepositoryCredentials starting from 51-150 then set the limit to 100 and offset to 50.
 func (dbq *PostgreSQLDatabaseQueries) GetRepositoryCredentialsBatch(ctx context.Context, repositoryCredentials *[]RepositoryCredentials, limit, offSet int) error {
-	return dbq.dbConnection.
+	if err := dbq.dbConnection.
 		Model(repositoryCredentials).
 		Order("seq_id ASC").
 		Limit(limit).   // Batch size
 		Offset(offSet). // offset+1 is starting point of batch
 		Context(ctx).
-		Select()
+		Select(); err != nil {
+		return err
+	}
+
+	for idx := range *repositoryCredentials {
+		if err := decryptSecretFields(ctx, (*repositoryCredentials)[idx].secretFields()...); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
@@ -211,6 +211,57 @@ var _ = Describe("RepositoryCredentials Tests", func() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(listOfRepositoryCredentialsFromDB).To(HaveLen(3))
 		})
+
+		It("Should list the RepositoryCredentials of a ClusterUser, and move them to another GitopsEngineInstance", func() {
+
+			By("Creating a second GitopsEngineInstance on the same cluster")
+			otherGitopsEngineInstance := db.GitopsEngineInstance{
+				Gitopsengineinstance_id: "test-other-engine-instance-id",
+				Namespace_name:          "test-other-namespace",
+				Namespace_uid:           "test-other-namespace-uid",
+				EngineCluster_id:        gitopsEngineInstance.EngineCluster_id,
+			}
+			err = dbq.CreateGitopsEngineInstance(ctx, &otherGitopsEngineInstance)
+			Expect(err).ToNot(HaveOccurred())
+
+			gitopsRepositoryCredentials := db.RepositoryCredentials{
+				RepositoryCredentialsID: "test-" + uuid.NewString(),
+				UserID:                  clusterUser.Clusteruser_id,
+				PrivateURL:              "https://test-private-url",
+				AuthUsername:            "test-auth-username",
+				AuthPassword:            "test-auth-password",
+				AuthSSHKey:              "test-auth-ssh-key",
+				SecretObj:               "test-secret-obj",
+				EngineClusterID:         gitopsEngineInstance.Gitopsengineinstance_id,
+			}
+			err = dbq.CreateRepositoryCredentials(ctx, &gitopsRepositoryCredentials)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Listing the RepositoryCredentials of the ClusterUser")
+			var repositoryCredentials []db.RepositoryCredentials
+			err = dbq.ListRepositoryCredentialsByClusterUserID(ctx, clusterUser.Clusteruser_id, &repositoryCredentials)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(repositoryCredentials).To(HaveLen(1))
+			Expect(repositoryCredentials[0].RepositoryCredentialsID).To(Equal(gitopsRepositoryCredentials.RepositoryCredentialsID))
+			Expect(repositoryCredentials[0].AuthPassword).To(Equal(gitopsRepositoryCredentials.AuthPassword))
+
+			By("Moving the RepositoryCredentials to the other GitopsEngineInstance")
+			rowsAffected, err := dbq.UpdateRepositoryCredentialsEngineInstance(ctx, gitopsRepositoryCredentials.RepositoryCredentialsID,
+				gitopsEngineInstance.Gitopsengineinstance_id, otherGitopsEngineInstance.Gitopsengineinstance_id)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rowsAffected).To(Equal(1))
+
+			fetch, err := dbq.GetRepositoryCredentialsByID(ctx, gitopsRepositoryCredentials.RepositoryCredentialsID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fetch.EngineClusterID).To(Equal(otherGitopsEngineInstance.Gitopsengineinstance_id))
+			Expect(fetch.AuthPassword).To(Equal(gitopsRepositoryCredentials.AuthPassword))
+
+			By("Not moving the RepositoryCredentials if they are not on the expected GitopsEngineInstance")
+			rowsAffected, err = dbq.UpdateRepositoryCredentialsEngineInstance(ctx, gitopsRepositoryCredentials.RepositoryCredentialsID,
+				gitopsEngineInstance.Gitopsengineinstance_id, otherGitopsEngineInstance.Gitopsengineinstance_id)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rowsAffected).To(BeZero())
+		})
 	})
 
 	Context("Test Dispose function for RepositoryCredentials", func() {
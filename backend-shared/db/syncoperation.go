@@ -2,7 +2,9 @@ package db
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -11,6 +13,115 @@ const (
 	SyncOperation_DesiredState_Terminated = "Terminated"
 )
 
+// The values of the 'phase' field of SyncOperation, which correspond to the phase of the GitOpsDeploymentSyncRun.
+const (
+	SyncOperation_Phase_Pending    = "Pending"
+	SyncOperation_Phase_Running    = "Running"
+	SyncOperation_Phase_Succeeded  = "Succeeded"
+	SyncOperation_Phase_Failed     = "Failed"
+	SyncOperation_Phase_Terminated = "Terminated"
+)
+
+// SyncOperationResource identifies a resource that should be synchronized by a SyncOperation.
+// The 'resources' field of SyncOperation contains a JSON list of these.
+type SyncOperationResource struct {
+	Group     string `json:"group,omitempty"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// SetResources sets the 'resources' field of the SyncOperation, to the given list of resources.
+func (obj *SyncOperation) SetResources(resources []SyncOperationResource) error {
+
+	if len(resources) == 0 {
+		obj.Resources = ""
+		return nil
+	}
+
+	resourcesBytes, err := json.Marshal(resources)
+	if err != nil {
+		return fmt.Errorf("unable to marshal SyncOperation resources: %v", err)
+	}
+
+	obj.Resources = string(resourcesBytes)
+
+	return nil
+}
+
+// GetResources returns the list of resources from the 'resources' field of the SyncOperation. An empty list
+// indicates that all resources should be synced.
+func (obj *SyncOperation) GetResources() ([]SyncOperationResource, error) {
+
+	if IsEmpty(obj.Resources) {
+		return nil, nil
+	}
+
+	var resources []SyncOperationResource
+	if err := json.Unmarshal([]byte(obj.Resources), &resources); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal SyncOperation resources: %v", err)
+	}
+
+	return resources, nil
+}
+
+// SyncOperationRollback identifies the deployment history entry of the Argo CD Application to roll back to.
+// The 'rollback_to' field of SyncOperation contains this, as JSON. Exactly one of ID or Revision is set.
+type SyncOperationRollback struct {
+	ID       *int64 `json:"id,omitempty"`
+	Revision string `json:"revision,omitempty"`
+}
+
+// SetRollbackTo sets the 'rollback_to' field of the SyncOperation. A nil value indicates the SyncOperation is a sync,
+// rather than a rollback.
+func (obj *SyncOperation) SetRollbackTo(rollbackTo *SyncOperationRollback) error {
+
+	if rollbackTo == nil {
+		obj.Rollback_to = ""
+		return nil
+	}
+
+	rollbackToBytes, err := json.Marshal(rollbackTo)
+	if err != nil {
+		return fmt.Errorf("unable to marshal SyncOperation rollback: %v", err)
+	}
+
+	obj.Rollback_to = string(rollbackToBytes)
+
+	return nil
+}
+
+// GetRollbackTo returns the deployment history entry from the 'rollback_to' field of the SyncOperation, or nil if the
+// SyncOperation is a sync, rather than a rollback.
+func (obj *SyncOperation) GetRollbackTo() (*SyncOperationRollback, error) {
+
+	if IsEmpty(obj.Rollback_to) {
+		return nil, nil
+	}
+
+	rollbackTo := &SyncOperationRollback{}
+	if err := json.Unmarshal([]byte(obj.Rollback_to), rollbackTo); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal SyncOperation rollback: %v", err)
+	}
+
+	return rollbackTo, nil
+}
+
+// SetSyncOptions sets the 'sync_options' field of the SyncOperation, to the given list of sync options.
+func (obj *SyncOperation) SetSyncOptions(syncOptions []string) {
+	obj.Sync_options = strings.Join(syncOptions, ",")
+}
+
+// GetSyncOptions returns the list of sync options from the 'sync_options' field of the SyncOperation.
+func (obj *SyncOperation) GetSyncOptions() []string {
+
+	if IsEmpty(obj.Sync_options) {
+		return nil
+	}
+
+	return strings.Split(obj.Sync_options, ",")
+}
+
 func (dbq *PostgreSQLDatabaseQueries) GetSyncOperationById(ctx context.Context, syncOperation *SyncOperation) error {
 
 	if err := validateQueryParamsEntity(syncOperation, dbq); err != nil {
@@ -141,6 +252,40 @@ func (dbq *PostgreSQLDatabaseQueries) UpdateSyncOperation(ctx context.Context, o
 	return nil
 }
 
+// UpdateSyncOperationStatus updates only the fields of the SyncOperation that describe the outcome of the sync
+// (phase, message, start/finish times, synced revision and sync result). The remaining fields of the row are not modified,
+// which ensures that concurrent changes to the desired state of the SyncOperation are not overwritten.
+func (dbq *PostgreSQLDatabaseQueries) UpdateSyncOperationStatus(ctx context.Context, obj *SyncOperation) error {
+
+	if err := validateQueryParamsEntity(obj, dbq); err != nil {
+		return err
+	}
+
+	if err := isEmptyValues("UpdateSyncOperationStatus",
+		"syncoperation_id", obj.SyncOperation_id,
+		"phase", obj.Phase,
+	); err != nil {
+		return err
+	}
+
+	if err := validateFieldLength(obj); err != nil {
+		return err
+	}
+
+	result, err := dbq.dbConnection.Model(obj).
+		Column("phase", "phase_message", "started_at", "finished_at", "synced_revision", "sync_result").
+		WherePK().Context(ctx).Update()
+	if err != nil {
+		return fmt.Errorf("error on updating SyncOperation status: %v, %v", err, obj.SyncOperation_id)
+	}
+
+	if result.RowsAffected() != 1 {
+		return NewResultNotFoundError(fmt.Sprintf("unexpected number of rows affected: %d, %v", result.RowsAffected(), obj.SyncOperation_id))
+	}
+
+	return nil
+}
+
 // UpdateSyncOperationRemoveApplicationField locates any SyncOperations that reference 'applicationID', and sets the
 // applicationID field to nil.
 func (dbq *PostgreSQLDatabaseQueries) UpdateSyncOperationRemoveApplicationField(ctx context.Context, applicationId string) (int, error) {
@@ -112,6 +112,136 @@ var _ = Describe("SyncOperation Tests", func() {
 
 		})
 
+		It("Should persist the sync options of the SyncOperation", func() {
+			syncOperation := db.SyncOperation{
+				SyncOperation_id:    "test-sync-options",
+				Application_id:      application.Application_id,
+				DeploymentNameField: "testDeployment",
+				Revision:            "testRev",
+				DesiredState:        db.SyncOperation_DesiredState_Running,
+				Prune:               true,
+				Dry_run:             true,
+				Force:               true,
+				Replace:             true,
+			}
+			syncOperation.SetSyncOptions([]string{"ServerSideApply=true", "PruneLast=true"})
+			err := syncOperation.SetResources([]db.SyncOperationResource{
+				{Group: "apps", Kind: "Deployment", Name: "my-deployment", Namespace: "my-namespace"},
+				{Kind: "Namespace", Name: "my-namespace"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			err = dbq.CreateSyncOperation(ctx, &syncOperation)
+			Expect(err).ToNot(HaveOccurred())
+
+			fetchRow := db.SyncOperation{
+				SyncOperation_id: syncOperation.SyncOperation_id,
+			}
+			err = dbq.GetSyncOperationById(ctx, &fetchRow)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fetchRow.Prune).To(BeTrue())
+			Expect(fetchRow.Dry_run).To(BeTrue())
+			Expect(fetchRow.Force).To(BeTrue())
+			Expect(fetchRow.Replace).To(BeTrue())
+			Expect(fetchRow.GetSyncOptions()).To(Equal([]string{"ServerSideApply=true", "PruneLast=true"}))
+
+			resources, err := fetchRow.GetResources()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resources).To(Equal([]db.SyncOperationResource{
+				{Group: "apps", Kind: "Deployment", Name: "my-deployment", Namespace: "my-namespace"},
+				{Kind: "Namespace", Name: "my-namespace"},
+			}))
+
+			By("verifying that the default options are read from the existing row")
+			fetchRow = db.SyncOperation{
+				SyncOperation_id: insertRow.SyncOperation_id,
+			}
+			err = dbq.GetSyncOperationById(ctx, &fetchRow)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fetchRow.Prune).To(BeFalse())
+			Expect(fetchRow.GetSyncOptions()).To(BeEmpty())
+			resources, err = fetchRow.GetResources()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resources).To(BeEmpty())
+		})
+
+		It("Should persist the rollback target of the SyncOperation", func() {
+			historyID := int64(0)
+			syncOperation := db.SyncOperation{
+				SyncOperation_id:    "test-sync-rollback",
+				Application_id:      application.Application_id,
+				DeploymentNameField: "testDeployment",
+				Revision:            "testRev",
+				DesiredState:        db.SyncOperation_DesiredState_Running,
+			}
+			err := syncOperation.SetRollbackTo(&db.SyncOperationRollback{ID: &historyID})
+			Expect(err).ToNot(HaveOccurred())
+
+			err = dbq.CreateSyncOperation(ctx, &syncOperation)
+			Expect(err).ToNot(HaveOccurred())
+
+			fetchRow := db.SyncOperation{
+				SyncOperation_id: syncOperation.SyncOperation_id,
+			}
+			err = dbq.GetSyncOperationById(ctx, &fetchRow)
+			Expect(err).ToNot(HaveOccurred())
+
+			rollbackTo, err := fetchRow.GetRollbackTo()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rollbackTo).ToNot(BeNil())
+			Expect(rollbackTo.ID).ToNot(BeNil())
+			Expect(*rollbackTo.ID).To(Equal(historyID))
+			Expect(rollbackTo.Revision).To(BeEmpty())
+
+			By("verifying that a SyncOperation without a rollback target is not a rollback")
+			fetchRow = db.SyncOperation{
+				SyncOperation_id: insertRow.SyncOperation_id,
+			}
+			err = dbq.GetSyncOperationById(ctx, &fetchRow)
+			Expect(err).ToNot(HaveOccurred())
+			rollbackTo, err = fetchRow.GetRollbackTo()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rollbackTo).To(BeNil())
+		})
+
+		It("Should update only the status fields of the SyncOperation, in UpdateSyncOperationStatus", func() {
+			startedAt := time.Now().Add(-time.Minute).Truncate(time.Second).UTC()
+			finishedAt := time.Now().Truncate(time.Second).UTC()
+
+			statusUpdate := db.SyncOperation{
+				SyncOperation_id: insertRow.SyncOperation_id,
+				// The desired state should not be modified by the status update
+				DesiredState:    db.SyncOperation_DesiredState_Running,
+				Phase:           db.SyncOperation_Phase_Succeeded,
+				Phase_message:   "successfully synced (all tasks run)",
+				Started_at:      startedAt,
+				Finished_at:     finishedAt,
+				Synced_revision: "f0b2ab8c8e8a4b1f8d6e3d3c2b1a0f9e8d7c6b5a",
+				Sync_result:     []byte("sync-result"),
+			}
+			err := dbq.UpdateSyncOperationStatus(ctx, &statusUpdate)
+			Expect(err).ToNot(HaveOccurred())
+
+			fetchRow := db.SyncOperation{
+				SyncOperation_id: insertRow.SyncOperation_id,
+			}
+			err = dbq.GetSyncOperationById(ctx, &fetchRow)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fetchRow.DesiredState).To(Equal(insertRow.DesiredState))
+			Expect(fetchRow.Revision).To(Equal(insertRow.Revision))
+			Expect(fetchRow.Phase).To(Equal(statusUpdate.Phase))
+			Expect(fetchRow.Phase_message).To(Equal(statusUpdate.Phase_message))
+			Expect(fetchRow.Started_at.Equal(startedAt)).To(BeTrue())
+			Expect(fetchRow.Finished_at.Equal(finishedAt)).To(BeTrue())
+			Expect(fetchRow.Synced_revision).To(Equal(statusUpdate.Synced_revision))
+			Expect(fetchRow.Sync_result).To(Equal(statusUpdate.Sync_result))
+
+			By("verifying that an error is returned if the SyncOperation doesn't exist")
+			statusUpdate.SyncOperation_id = "test-does-not-exist"
+			err = dbq.UpdateSyncOperationStatus(ctx, &statusUpdate)
+			Expect(db.IsResultNotFoundError(err)).To(BeTrue())
+		})
+
 		It("Should Get SyncOperation in batch.", func() {
 			var testClusterUser = &db.ClusterUser{
 				Clusteruser_id: "test-user",
@@ -194,6 +194,22 @@ func SetupForTestingDBGinkgo() error {
 		}
 	}
 
+	var appProjectSyncWindows []AppProjectSyncWindow
+
+	err = dbq.UnsafeListAllAppProjectSyncWindows(ctx, &appProjectSyncWindows)
+	Expect(err).ToNot(HaveOccurred())
+
+	for idx := range appProjectSyncWindows {
+		item := appProjectSyncWindows[idx]
+		if strings.HasPrefix(item.Clusteruser_id, "test-") || strings.HasPrefix(item.AppprojectSyncwindowID, "test-") {
+			rowsAffected, err := dbq.DeleteAppProjectSyncWindowByAppProjectSyncWindowID(ctx, &item)
+			Expect(err).ToNot(HaveOccurred())
+			if err == nil {
+				Expect(rowsAffected).Should(Equal(1))
+			}
+		}
+	}
+
 	var operations []Operation
 	err = dbq.UnsafeListAllOperations(ctx, &operations)
 	Expect(err).ToNot(HaveOccurred())
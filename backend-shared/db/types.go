@@ -122,6 +122,14 @@ type ClusterCredentials struct {
 	// -- - This corresponds to the Argo CD cluster secret field of the same name.
 	ClusterResources bool `pg:"cluster_resources"`
 
+	// -- Reference to the Kubernetes Secret that contains the credentials (a kubeconfig), when the credentials are
+	// -- not copied into this row. If set, 'serviceaccount_bearer_token' is empty, and the token is instead read
+	// -- from the Secret whenever it is needed.
+	// -- - Secret_ref_resource_version is the resourceVersion of the Secret at the time the credentials were validated.
+	Secret_ref_namespace        string `pg:"secret_ref_namespace"`
+	Secret_ref_name             string `pg:"secret_ref_name"`
+	Secret_ref_resource_version string `pg:"secret_ref_resource_version"`
+
 	// -- Created_on field will tell us how old resources are
 	Created_on time.Time `pg:"created_on"`
 }
@@ -183,6 +191,37 @@ const (
 	OperationResourceType_Application           OperationResourceType = "Application"
 	OperationResourceType_RepositoryCredentials OperationResourceType = "RepositoryCredentials"
 	OperationResourceType_GitOpsEngineInstance  OperationResourceType = "GitOpsEngineInstance"
+
+	// OperationResourceType_Rollback points to a SyncOperation row whose 'rollback_to' field is set: the cluster-agent
+	// will roll back the Argo CD Application, rather than sync it.
+	OperationResourceType_Rollback OperationResourceType = "Rollback"
+
+	// OperationResourceType_ApplicationMigrationStage points to an Application row that is being migrated to the
+	// Operation's GitOps engine instance: the cluster-agent creates a copy of the Argo CD Application on that instance,
+	// with automated sync disabled.
+	OperationResourceType_ApplicationMigrationStage OperationResourceType = "ApplicationMigrationStage"
+
+	// OperationResourceType_ApplicationMigrationOrphan points to an Application row that is no longer (or not yet) hosted
+	// on the Operation's GitOps engine instance: the cluster-agent deletes the Argo CD Application from that instance,
+	// without deleting the resources that it deployed.
+	OperationResourceType_ApplicationMigrationOrphan OperationResourceType = "ApplicationMigrationOrphan"
+)
+
+// OperationPriority controls the order in which the cluster-agent processes the Operations of a single user:
+// Operations with a higher priority are processed first.
+type OperationPriority int
+
+const (
+	// OperationPriority_SelfHeal is used for Operations that are created by the GitOps Service itself, in order to
+	// correct drift between the database, the user's namespace, and Argo CD.
+	OperationPriority_SelfHeal OperationPriority = -100
+
+	// OperationPriority_Default is used for Operations that are created in response to a change made by the user.
+	OperationPriority_Default OperationPriority = 0
+
+	// OperationPriority_UserInitiatedSync is used for Operations that are created in response to a sync that was
+	// explicitly requested by the user (for example, via a GitOpsDeploymentSyncRun).
+	OperationPriority_UserInitiatedSync OperationPriority = 100
 )
 
 // Operation
@@ -240,6 +279,10 @@ type Operation struct {
 
 	// -- Amount of time to wait in seconds after last_state_update for a completed/failed operation to be garbage collected.
 	GC_expiration_time int `pg:"gc_expiration_time"`
+
+	// -- The priority of the operation, relative to other operations of the same user: higher priority operations are processed first.
+	// See OperationPriority_* constants for possible values.
+	Priority OperationPriority `pg:"priority,use_zero"`
 }
 
 // Application represents an Argo CD Application CR within an Argo CD namespace.
@@ -410,6 +453,38 @@ type SyncOperation struct {
 
 	DesiredState string `pg:"desired_state"`
 
+	// The options of the sync, from the corresponding fields of the GitOpsDeploymentSyncRun CR
+	Prune   bool `pg:"prune"`
+	Dry_run bool `pg:"dry_run"`
+	Force   bool `pg:"force"`
+	Replace bool `pg:"replace"`
+
+	// Resources is a JSON list of the resources to sync: see SyncOperationResource. If empty, all resources are synced.
+	Resources string `pg:"resources"`
+
+	// Sync_options is a comma-separated list of sync options (for example, 'ServerSideApply=true')
+	Sync_options string `pg:"sync_options"`
+
+	// Rollback_to is the JSON-encoded deployment history entry to roll back to: see SyncOperationRollback.
+	// If empty, the SyncOperation is a sync rather than a rollback.
+	Rollback_to string `pg:"rollback_to"`
+
+	// The outcome of the sync operation, as observed by the cluster-agent. See the SyncOperation_Phase_* constants.
+	Phase string `pg:"phase"`
+
+	// Phase_message is a human-readable message describing the outcome of the sync operation
+	Phase_message string `pg:"phase_message"`
+
+	// The times at which the sync operation started and completed
+	Started_at  time.Time `pg:"started_at"`
+	Finished_at time.Time `pg:"finished_at"`
+
+	// Synced_revision is the revision (git commit SHA) that was synced
+	Synced_revision string `pg:"synced_revision"`
+
+	// Sync_result is the compressed list of the Argo CD sync results of each individual resource
+	Sync_result []byte `pg:"sync_result"`
+
 	Created_on time.Time `pg:"created_on"`
 }
 
@@ -467,6 +542,14 @@ type RepositoryCredentials struct {
 	// -- Foreign key to: GitopsEngineInstance.Gitopsengineinstance_id
 	EngineClusterID string `pg:"repo_cred_engine_id,notnull"`
 
+	// SecretRefNamespace, SecretRefName and SecretRefResourceVersion reference the Kubernetes Secret that contains the
+	// credentials, when the credentials are not copied into this row. If set, AuthUsername, AuthPassword and AuthSSHKey
+	// are empty, and are instead read from the Secret whenever they are needed.
+	// -- SecretRefResourceVersion is the resourceVersion of the Secret at the time the row was last updated.
+	SecretRefNamespace       string `pg:"repo_cred_secret_ref_namespace"`
+	SecretRefName            string `pg:"repo_cred_secret_ref_name"`
+	SecretRefResourceVersion string `pg:"repo_cred_secret_ref_resource_version"`
+
 	// SeqID is used only for debugging purposes. It helps us to keep track of the order that rows are created.
 	SeqID int64 `pg:"seq_id"`
 
@@ -513,6 +596,42 @@ type AppProjectManagedEnvironment struct {
 	Created_on time.Time `pg:"created_on"`
 }
 
+// AppProjectSyncWindow is a sync window of a GitOpsDeployment, which is added to the AppProject of the user
+type AppProjectSyncWindow struct {
+
+	//lint:ignore U1000 used by go-pg
+	tableName struct{} `pg:"appprojectsyncwindow"` //nolint
+
+	AppprojectSyncwindowID string `pg:"appproject_syncwindow_id,pk,notnull"`
+
+	// -- Foreign key to: ClusterUser.clusteruser_id
+	Clusteruser_id string `pg:"clusteruser_id"`
+
+	// Name of the Argo CD Application that the sync window applies to
+	// Value: gitopsdepl-(uid of the gitopsdeployment)
+	Application_name string `pg:"application_name"`
+
+	// Whether the window allows or denies syncs: 'allow' or 'deny'
+	Kind string `pg:"kind"`
+
+	// Schedule is the time the window will begin, in cron format
+	Schedule string `pg:"schedule"`
+
+	// Duration is the amount of time the window will be open, e.g. '1h'
+	Duration string `pg:"duration"`
+
+	// Manual_sync enables manual syncs when they would otherwise be blocked by the window
+	Manual_sync bool `pg:"manual_sync"`
+
+	// Time_zone of the schedule (optional)
+	Time_zone string `pg:"time_zone"`
+
+	SeqID int64 `pg:"seq_id"`
+
+	// -- Created_on field will tell us how old resources are
+	Created_on time.Time `pg:"created_on"`
+}
+
 // ApplicationOwner indicates which Applications are owned by which user(s)
 type ApplicationOwner struct {
 
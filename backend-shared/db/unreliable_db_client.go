@@ -34,6 +34,29 @@ func (cdb *ChaosDBClient) UpdateOperation(ctx context.Context, obj *Operation) e
 
 }
 
+func (cdb *ChaosDBClient) NotifyOperationStateChanged(ctx context.Context, operationID string) error {
+
+	if err := shouldSimulateFailure("NotifyOperationStateChanged", operationID); err != nil {
+		return err
+	}
+
+	return cdb.InnerClient.NotifyOperationStateChanged(ctx, operationID)
+}
+
+func (cdb *ChaosDBClient) SubscribeToOperationStateChanges(ctx context.Context, operationID string) (<-chan struct{}, func(), error) {
+
+	subscriber, ok := cdb.InnerClient.(OperationStateChangeSubscriber)
+	if !ok {
+		return nil, nil, fmt.Errorf("inner client does not support subscribing to operation state changes")
+	}
+
+	if err := shouldSimulateFailure("SubscribeToOperationStateChanges", operationID); err != nil {
+		return nil, nil, err
+	}
+
+	return subscriber.SubscribeToOperationStateChanges(ctx, operationID)
+}
+
 func (cdb *ChaosDBClient) CreateOperation(ctx context.Context, obj *Operation, ownerId string) error {
 
 	if err := shouldSimulateFailure("CreateOperation", obj); err != nil {
@@ -143,6 +166,16 @@ func (cdb *ChaosDBClient) UpdateSyncOperation(ctx context.Context, obj *SyncOper
 
 }
 
+func (cdb *ChaosDBClient) UpdateSyncOperationStatus(ctx context.Context, obj *SyncOperation) error {
+
+	if err := shouldSimulateFailure("UpdateSyncOperationStatus", obj); err != nil {
+		return err
+	}
+
+	return cdb.InnerClient.UpdateSyncOperationStatus(ctx, obj)
+
+}
+
 func (cdb *ChaosDBClient) GetSyncOperationsBatch(ctx context.Context, syncOperations *[]SyncOperation, limit, offSet int) error {
 
 	if err := shouldSimulateFailure("GetSyncOperationsBatch", syncOperations, limit, offSet); err != nil {
@@ -453,6 +486,16 @@ func (cdb *ChaosDBClient) CreateClusterCredentials(ctx context.Context, obj *Clu
 
 }
 
+func (cdb *ChaosDBClient) UpdateClusterCredentials(ctx context.Context, obj *ClusterCredentials) error {
+
+	if err := shouldSimulateFailure("UpdateClusterCredentials", obj); err != nil {
+		return err
+	}
+
+	return cdb.InnerClient.UpdateClusterCredentials(ctx, obj)
+
+}
+
 func (cdb *ChaosDBClient) CreateClusterUser(ctx context.Context, obj *ClusterUser) error {
 
 	if err := shouldSimulateFailure("CreateClusterUser", obj); err != nil {
@@ -711,6 +754,24 @@ func (cdb *ChaosDBClient) GetRepositoryCredentialsByID(ctx context.Context, id s
 
 }
 
+func (cdb *ChaosDBClient) ReencryptClusterCredentials(ctx context.Context, id string) (bool, error) {
+
+	if err := shouldSimulateFailure("ReencryptClusterCredentials", id); err != nil {
+		return false, err
+	}
+
+	return cdb.InnerClient.ReencryptClusterCredentials(ctx, id)
+}
+
+func (cdb *ChaosDBClient) ReencryptRepositoryCredentials(ctx context.Context, id string) (bool, error) {
+
+	if err := shouldSimulateFailure("ReencryptRepositoryCredentials", id); err != nil {
+		return false, err
+	}
+
+	return cdb.InnerClient.ReencryptRepositoryCredentials(ctx, id)
+}
+
 func (cdb *ChaosDBClient) GetRepositoryCredentialsBatch(ctx context.Context, repositoryCredentials *[]RepositoryCredentials, limit, offSet int) error {
 
 	if err := shouldSimulateFailure("GetRepositoryCredentialsBatch", repositoryCredentials, limit, offSet); err != nil {
@@ -906,6 +967,53 @@ func (cdb *ChaosDBClient) GetClusterAccessBatch(ctx context.Context, clusterAcce
 	return cdb.InnerClient.GetClusterAccessBatch(ctx, clusterAccess, limit, offSet)
 }
 
+func (cdb *ChaosDBClient) ListClusterAccessesByClusterUserID(ctx context.Context, clusterUserID string, clusterAccesses *[]ClusterAccess) error {
+
+	if err := shouldSimulateFailure("ListClusterAccessesByClusterUserID", clusterUserID, clusterAccesses); err != nil {
+		return err
+	}
+
+	return cdb.InnerClient.ListClusterAccessesByClusterUserID(ctx, clusterUserID, clusterAccesses)
+
+}
+
+func (cdb *ChaosDBClient) ListRepositoryCredentialsByClusterUserID(ctx context.Context, clusterUserID string, repositoryCredentials *[]RepositoryCredentials) error {
+
+	if err := shouldSimulateFailure("ListRepositoryCredentialsByClusterUserID", clusterUserID, repositoryCredentials); err != nil {
+		return err
+	}
+
+	return cdb.InnerClient.ListRepositoryCredentialsByClusterUserID(ctx, clusterUserID, repositoryCredentials)
+}
+
+func (cdb *ChaosDBClient) UpdateRepositoryCredentialsEngineInstance(ctx context.Context, id string, fromEngineInstanceID string, toEngineInstanceID string) (int, error) {
+
+	if err := shouldSimulateFailure("UpdateRepositoryCredentialsEngineInstance", id, fromEngineInstanceID, toEngineInstanceID); err != nil {
+		return 0, err
+	}
+
+	return cdb.InnerClient.UpdateRepositoryCredentialsEngineInstance(ctx, id, fromEngineInstanceID, toEngineInstanceID)
+}
+
+func (cdb *ChaosDBClient) UpdateApplicationEngineInstance(ctx context.Context, obj *Application, expectedEngineInstanceID string, expectedSpecField string) (int, error) {
+
+	if err := shouldSimulateFailure("UpdateApplicationEngineInstance", obj, expectedEngineInstanceID, expectedSpecField); err != nil {
+		return 0, err
+	}
+
+	return cdb.InnerClient.UpdateApplicationEngineInstance(ctx, obj, expectedEngineInstanceID, expectedSpecField)
+}
+
+func (cdb *ChaosDBClient) CountApplicationsForGitopsEngineInstance(ctx context.Context, gitopsEngineInstanceID string) (int, error) {
+
+	if err := shouldSimulateFailure("CountApplicationsForGitopsEngineInstance", gitopsEngineInstanceID); err != nil {
+		return 0, err
+	}
+
+	return cdb.InnerClient.CountApplicationsForGitopsEngineInstance(ctx, gitopsEngineInstanceID)
+
+}
+
 func (cdb *ChaosDBClient) ListApplicationsForManagedEnvironment(ctx context.Context, managedEnvironmentID string, applications *[]Application) (int, error) {
 
 	if err := shouldSimulateFailure("ListApplicationsForManagedEnvironment", managedEnvironmentID, applications); err != nil {
@@ -1062,6 +1170,35 @@ func (cdb *ChaosDBClient) CountAppProjectManagedEnvironmentByClusterUserID(ctx c
 	return cdb.InnerClient.CountAppProjectManagedEnvironmentByClusterUserID(ctx, obj)
 }
 
+func (cdb *ChaosDBClient) CreateAppProjectSyncWindow(ctx context.Context, obj *AppProjectSyncWindow) error {
+	if err := shouldSimulateFailure("CreateAppProjectSyncWindow", obj); err != nil {
+		return err
+	}
+	return cdb.InnerClient.CreateAppProjectSyncWindow(ctx, obj)
+}
+
+func (cdb *ChaosDBClient) ListAppProjectSyncWindowByClusterUserId(ctx context.Context,
+	clusteruser_id string, appProjectSyncWindows *[]AppProjectSyncWindow) error {
+	if err := shouldSimulateFailure("ListAppProjectSyncWindowByClusterUserId", clusteruser_id, appProjectSyncWindows); err != nil {
+		return err
+	}
+	return cdb.InnerClient.ListAppProjectSyncWindowByClusterUserId(ctx, clusteruser_id, appProjectSyncWindows)
+}
+
+func (cdb *ChaosDBClient) DeleteAppProjectSyncWindowByAppProjectSyncWindowID(ctx context.Context, obj *AppProjectSyncWindow) (int, error) {
+	if err := shouldSimulateFailure("DeleteAppProjectSyncWindowByAppProjectSyncWindowID", obj); err != nil {
+		return 0, err
+	}
+	return cdb.InnerClient.DeleteAppProjectSyncWindowByAppProjectSyncWindowID(ctx, obj)
+}
+
+func (cdb *ChaosDBClient) DeleteAppProjectSyncWindowsByClusterUserId(ctx context.Context, clusteruser_id string) (int, error) {
+	if err := shouldSimulateFailure("DeleteAppProjectSyncWindowsByClusterUserId", clusteruser_id); err != nil {
+		return 0, err
+	}
+	return cdb.InnerClient.DeleteAppProjectSyncWindowsByClusterUserId(ctx, clusteruser_id)
+}
+
 func (cdb *ChaosDBClient) CreateApplicationOwner(ctx context.Context, obj *ApplicationOwner) error {
 	if err := shouldSimulateFailure("CreateApplicationOwner", obj); err != nil {
 		return err
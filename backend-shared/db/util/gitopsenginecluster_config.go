@@ -0,0 +1,60 @@
+package util
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redhat-appstudio/managed-gitops/backend-shared/db"
+	sharedutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// GetRESTConfigForGitOpsEngineCluster returns the rest.Config of the GitOpsEngineCluster with the given ID, from the
+// kubeconfig Secret that is referenced by the ClusterCredentials of the GitOpsEngineCluster. A nil rest.Config is
+// returned if the ClusterCredentials do not reference a Secret: this indicates the engine cluster is the same cluster as backend.
+func GetRESTConfigForGitOpsEngineCluster(ctx context.Context, gitopsEngineClusterID string, serviceClient client.Client,
+	dbQueries db.DatabaseQueries) (*rest.Config, error) {
+
+	gitopsEngineCluster := db.GitopsEngineCluster{Gitopsenginecluster_id: gitopsEngineClusterID}
+	if err := dbQueries.GetGitopsEngineClusterById(ctx, &gitopsEngineCluster); err != nil {
+		return nil, fmt.Errorf("unable to retrieve GitopsEngineCluster '%s': %v", gitopsEngineClusterID, err)
+	}
+
+	clusterCreds := db.ClusterCredentials{Clustercredentials_cred_id: gitopsEngineCluster.Clustercredentials_id}
+	if err := dbQueries.GetClusterCredentialsById(ctx, &clusterCreds); err != nil {
+		return nil, fmt.Errorf("unable to retrieve ClusterCredentials of GitopsEngineCluster '%s': %v", gitopsEngineClusterID, err)
+	}
+
+	if clusterCreds.Secret_ref_name == "" {
+		return nil, nil
+	}
+
+	return GetRESTConfigFromKubeconfigSecret(ctx, serviceClient, clusterCreds.Secret_ref_namespace, clusterCreds.Secret_ref_name,
+		clusterCreds.Kube_config_context)
+}
+
+// GetRESTConfigFromKubeconfigSecret returns the rest.Config for the given context of the kubeconfig in the given Secret.
+func GetRESTConfigFromKubeconfigSecret(ctx context.Context, k8sClient client.Client, secretNamespace string, secretName string,
+	kubeConfigContext string) (*rest.Config, error) {
+
+	secret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretName,
+			Namespace: secretNamespace,
+		},
+	}
+	if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(&secret), &secret); err != nil {
+		return nil, fmt.Errorf("unable to retrieve kubeconfig Secret '%s' in namespace '%s': %v", secretName, secretNamespace, err)
+	}
+
+	kubeconfig, exists := secret.Data[sharedutil.ManagedEnvironmentSecretKubeconfigKey]
+	if !exists {
+		return nil, fmt.Errorf("missing %s field in Secret '%s' in namespace '%s'", sharedutil.ManagedEnvironmentSecretKubeconfigKey,
+			secretName, secretNamespace)
+	}
+
+	return sharedutil.RESTConfigFromKubeconfig(kubeconfig, kubeConfigContext)
+}
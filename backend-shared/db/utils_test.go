@@ -151,4 +151,48 @@ var _ = Describe("Test utility functions", func() {
 			Expect(IsMaxLengthError(nil)).To(BeFalse())
 		})
 	})
+
+	Context("Ensure the plaintext values of the encrypted fields of RepositoryCredentials are limited to the original column sizes.", func() {
+		It("Should return an error for a password or SSH key that exceeds the original column size", func() {
+			repoCreds := RepositoryCredentials{AuthPassword: strings.Repeat("p", 1024), AuthSSHKey: strings.Repeat("s", 1024)}
+			Expect(repoCreds.validateSecretFieldLength()).To(Succeed())
+
+			repoCreds.AuthPassword += "p"
+			Expect(IsMaxLengthError(repoCreds.validateSecretFieldLength())).To(BeTrue())
+
+			repoCreds = RepositoryCredentials{AuthSSHKey: strings.Repeat("s", 1025)}
+			Expect(IsMaxLengthError(repoCreds.validateSecretFieldLength())).To(BeTrue())
+		})
+	})
+
+	Context("Ensure reencryptRow retries rows that are modified concurrently, and skips rows that are deleted.", func() {
+		It("Should retry until the row is updated", func() {
+			attempts := 0
+			updated, err := reencryptRow(func() (bool, error) {
+				attempts++
+				return attempts == 2, nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(updated).To(BeTrue())
+			Expect(attempts).To(Equal(2))
+		})
+
+		It("Should return an error if the row is always modified concurrently", func() {
+			attempts := 0
+			_, err := reencryptRow(func() (bool, error) {
+				attempts++
+				return false, nil
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(attempts).To(Equal(reencryptAttempts))
+		})
+
+		It("Should skip a row that no longer exists", func() {
+			updated, err := reencryptRow(func() (bool, error) {
+				return false, NewResultNotFoundError("error on retrieving ClusterCredentials")
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(updated).To(BeFalse())
+		})
+	})
 })
@@ -0,0 +1,133 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// This file is responsible for restricting the requests that are made to URLs which are provided by users (for
+// example, container image registries, promotion check webhooks, and the Git repositories of GitOpsDeploymentSet
+// generators), so that they cannot be used to reach hosts inside the cluster:
+// - The URL must be an https URL, and its host must not be cluster-internal (see ValidateExternalURL).
+// - The IP addresses that the host resolves to are checked when connecting (see ExternalDialControl).
+// - Redirects are subject to the same restrictions.
+
+// NewExternalHTTPClient returns an HTTP client which may only connect to hosts outside of the cluster. Requests
+// should also be checked with ValidateExternalURL before they are sent.
+func NewExternalHTTPClient() *http.Client {
+	return &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &http.Transport{
+			// A proxy is not used, as the address of the host could then not be checked when connecting
+			DialContext:         (&net.Dialer{Timeout: 30 * time.Second, Control: ExternalDialControl}).DialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+		// A redirect must be to an https URL, on a host which would also be allowed for the original request
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if err := ValidateExternalURL(req.URL); err != nil {
+				return fmt.Errorf("redirect is not allowed: %v", err)
+			}
+			if len(via) >= 10 {
+				return fmt.Errorf("stopped after 10 redirects")
+			}
+			return nil
+		},
+	}
+}
+
+// ValidateExternalURL returns an error if the URL is not an https URL, or its host is cluster-internal (see
+// ValidateExternalHost).
+func ValidateExternalURL(externalURL *url.URL) error {
+
+	if externalURL.Scheme != "https" {
+		return fmt.Errorf("'%s' is not an https URL", externalURL.Redacted())
+	}
+
+	if externalURL.Hostname() == "" {
+		return fmt.Errorf("'%s' does not have a host", externalURL.Redacted())
+	}
+
+	return ValidateExternalHost(externalURL.Hostname())
+}
+
+// ValidateExternalHost returns an error if the host is cluster-internal: 'localhost', a single-label name (which
+// would be resolved using the DNS search domains of the cluster), or a name within a cluster domain.
+// - IP addresses are checked when connecting, by ExternalDialControl, which also applies to the addresses that host
+// names resolve to.
+func ValidateExternalHost(host string) error {
+
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "" {
+		return fmt.Errorf("the host is empty")
+	}
+
+	if net.ParseIP(host) != nil {
+		return nil
+	}
+
+	if host == "localhost" || !strings.Contains(host, ".") {
+		return fmt.Errorf("host '%s' is cluster-internal", host)
+	}
+
+	for _, suffix := range []string{".localhost", ".local", ".svc", ".internal"} {
+		if strings.HasSuffix(host, suffix) {
+			return fmt.Errorf("host '%s' is cluster-internal", host)
+		}
+	}
+
+	return nil
+}
+
+// ValidateExternalHostAddresses resolves the host, and returns an error if any of its IP addresses is cluster-internal
+// (see ExternalDialControl). It is used for clients which do not allow the address to be checked when connecting
+// (such as Git over ssh).
+func ValidateExternalHostAddresses(ctx context.Context, host string) error {
+
+	if err := ValidateExternalHost(host); err != nil {
+		return err
+	}
+
+	addresses, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("unable to resolve host '%s': %v", host, err)
+	}
+
+	for _, address := range addresses {
+		if isClusterInternalIP(address.IP) {
+			return fmt.Errorf("host '%s' resolves to the cluster-internal address '%s'", host, address.IP)
+		}
+	}
+
+	return nil
+}
+
+// ExternalDialControl prevents connections to loopback, link-local, private (which includes the Pod and Service
+// networks of the cluster) and unspecified IP addresses. It is called with the resolved address of the host.
+func ExternalDialControl(network string, address string, _ syscall.RawConn) error {
+
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("invalid address '%s': %v", address, err)
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("invalid IP address '%s'", host)
+	}
+
+	if isClusterInternalIP(ip) {
+		return fmt.Errorf("connecting to the cluster-internal address '%s' is not allowed", host)
+	}
+
+	return nil
+}
+
+func isClusterInternalIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsPrivate() || ip.IsUnspecified()
+}
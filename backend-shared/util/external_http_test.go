@@ -0,0 +1,61 @@
+package util
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("External HTTP Test", func() {
+
+	Context("Testing the restrictions on the hosts of external URLs", func() {
+
+		It("should only allow https URLs, with hosts which are not cluster-internal", func() {
+			for _, externalURL := range []string{"https://quay.io/v2/", "https://registry.example.com:5000/token", "https://203.0.113.10/v2/"} {
+				parsedURL, err := url.Parse(externalURL)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ValidateExternalURL(parsedURL)).To(Succeed(), externalURL)
+			}
+
+			for _, externalURL := range []string{"http://quay.io/v2/", "https:///v2/", "https://localhost:5000/v2/", "https://registry:5000/v2/",
+				"https://registry.my-namespace.svc/v2/", "https://registry.my-namespace.svc.cluster.local./v2/", "https://metadata.google.internal/"} {
+				parsedURL, err := url.Parse(externalURL)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ValidateExternalURL(parsedURL)).ToNot(Succeed(), externalURL)
+			}
+		})
+
+		It("should not connect to loopback, link-local, private or unspecified addresses", func() {
+			Expect(ExternalDialControl("tcp", "203.0.113.10:443", nil)).To(Succeed())
+			Expect(ExternalDialControl("tcp", "[2001:db8::1]:443", nil)).To(Succeed())
+
+			for _, address := range []string{"127.0.0.1:443", "169.254.169.254:80", "10.96.0.1:443", "192.168.1.1:443", "0.0.0.0:443", "[::1]:443", "[fe80::1]:443", "[fd00::1]:443"} {
+				Expect(ExternalDialControl("tcp", address, nil)).ToNot(Succeed(), address)
+			}
+		})
+
+		It("should not allow hosts which resolve to cluster-internal addresses", func() {
+			Expect(ValidateExternalHostAddresses(context.Background(), "127.0.0.1")).ToNot(Succeed())
+			Expect(ValidateExternalHostAddresses(context.Background(), "10.0.0.1")).ToNot(Succeed())
+			Expect(ValidateExternalHostAddresses(context.Background(), "my-service.my-namespace.svc")).ToNot(Succeed())
+			Expect(ValidateExternalHostAddresses(context.Background(), "203.0.113.10")).To(Succeed())
+		})
+
+		It("should not follow redirects to cluster-internal hosts", func() {
+			httpClient := NewExternalHTTPClient()
+
+			for _, redirectURL := range []string{"http://example.com/check", "https://my-service.my-namespace.svc/check"} {
+				req, err := http.NewRequest(http.MethodPost, redirectURL, nil)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(httpClient.CheckRedirect(req, nil)).ToNot(Succeed(), redirectURL)
+			}
+
+			req, err := http.NewRequest(http.MethodPost, "https://example.com/check", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(httpClient.CheckRedirect(req, nil)).To(Succeed())
+		})
+	})
+})
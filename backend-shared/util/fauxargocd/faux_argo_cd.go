@@ -1,6 +1,9 @@
 package fauxargocd
 
 import (
+	"fmt"
+	"strconv"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -9,6 +12,11 @@ import (
 //
 // This is used to build Argo CD YAML/JSON for use by the cluster agent.
 // This should NOT be used to parse actual K8s resources.
+//
+// Note: the Application spec field (and the Application status stored in the database) are marshalled with gopkg.in/yaml.v2,
+// which ignores 'json' tags and uses the lowercased field name as the key. New optional fields should thus also declare
+// a `yaml:",omitempty"` tag (keeping the default key), so that empty values are not written to the spec field. Otherwise
+// (for example) a nil slice would be written as '[]', which would no longer match the Argo CD Application on the cluster.
 
 // Application is a definition of Application resource.
 type FauxApplication struct {
@@ -31,7 +39,7 @@ type FauxTypeMeta struct {
 // ApplicationSpec represents desired application state. Contains link to repository with application definition and additional parameters link definition revision.
 type FauxApplicationSpec struct {
 	// Source is a reference to the location of the application's manifests or chart
-	Source ApplicationSource `json:"source" protobuf:"bytes,1,opt,name=source"`
+	Source ApplicationSource `json:"source" yaml:",omitempty" protobuf:"bytes,1,opt,name=source"`
 	// Destination is a reference to the target Kubernetes server and namespace
 	Destination ApplicationDestination `json:"destination" protobuf:"bytes,2,name=destination"`
 	// Project is a reference to the project this application belongs to.
@@ -39,6 +47,23 @@ type FauxApplicationSpec struct {
 	Project string `json:"project" protobuf:"bytes,3,name=project"`
 	// SyncPolicy controls when and how a sync will be performed
 	SyncPolicy *SyncPolicy `json:"syncPolicy,omitempty" protobuf:"bytes,4,name=syncPolicy"`
+	// IgnoreDifferences is a list of resources and their fields which should be ignored during comparison
+	IgnoreDifferences IgnoreDifferences `json:"ignoreDifferences,omitempty" yaml:",omitempty" protobuf:"bytes,5,name=ignoreDifferences"`
+	// Sources is a reference to the location of the application's manifests or chart
+	Sources ApplicationSources `json:"sources,omitempty" yaml:",omitempty" protobuf:"bytes,8,opt,name=sources"`
+}
+
+// IgnoreDifferences is a list of resources and their fields which should be ignored during comparison
+type IgnoreDifferences []ResourceIgnoreDifferences
+
+// ResourceIgnoreDifferences contains resource filter and list of json paths which should be ignored during comparison with live state.
+type ResourceIgnoreDifferences struct {
+	Group             string   `json:"group,omitempty" yaml:",omitempty" protobuf:"bytes,1,opt,name=group"`
+	Kind              string   `json:"kind" protobuf:"bytes,2,opt,name=kind"`
+	Name              string   `json:"name,omitempty" yaml:",omitempty" protobuf:"bytes,3,opt,name=name"`
+	Namespace         string   `json:"namespace,omitempty" yaml:",omitempty" protobuf:"bytes,4,opt,name=namespace"`
+	JSONPointers      []string `json:"jsonPointers,omitempty" yaml:",omitempty" protobuf:"bytes,5,opt,name=jsonPointers"`
+	JQPathExpressions []string `json:"jqPathExpressions,omitempty" yaml:",omitempty" protobuf:"bytes,6,opt,name=jqPathExpressions"`
 }
 
 // ApplicationSource contains all required information about the source of an application
@@ -54,6 +79,110 @@ type ApplicationSource struct {
 	// In case of Git, this can be commit, tag, or branch. If omitted, will equal to HEAD.
 	// In case of Helm, this is a semver tag for the Chart's version.
 	TargetRevision string `json:"targetRevision,omitempty" protobuf:"bytes,4,opt,name=targetRevision"`
+
+	// Helm holds helm specific options
+	Helm *ApplicationSourceHelm `json:"helm,omitempty" yaml:",omitempty" protobuf:"bytes,7,opt,name=helm"`
+
+	// Kustomize holds kustomize specific options
+	Kustomize *ApplicationSourceKustomize `json:"kustomize,omitempty" yaml:",omitempty" protobuf:"bytes,8,opt,name=kustomize"`
+
+	// Chart is a Helm chart name, and must be specified for applications sourced from a Helm repo.
+	Chart string `json:"chart,omitempty" yaml:",omitempty" protobuf:"bytes,12,opt,name=chart"`
+
+	// Ref is reference to another source within sources field. This field will not be used if used with a `source` tag.
+	Ref string `json:"ref,omitempty" yaml:",omitempty" protobuf:"bytes,13,opt,name=ref"`
+}
+
+// ApplicationSourceKustomize holds options specific to an Application source specific to Kustomize
+type ApplicationSourceKustomize struct {
+	// NamePrefix is a prefix appended to resources for Kustomize apps
+	NamePrefix string `json:"namePrefix,omitempty" yaml:",omitempty" protobuf:"bytes,1,opt,name=namePrefix"`
+	// NameSuffix is a suffix appended to resources for Kustomize apps
+	NameSuffix string `json:"nameSuffix,omitempty" yaml:",omitempty" protobuf:"bytes,2,opt,name=nameSuffix"`
+	// Images is a list of Kustomize image override specifications
+	Images KustomizeImages `json:"images,omitempty" yaml:",omitempty" protobuf:"bytes,3,opt,name=images"`
+	// CommonLabels is a list of additional labels to add to rendered manifests
+	CommonLabels map[string]string `json:"commonLabels,omitempty" yaml:",omitempty" protobuf:"bytes,4,opt,name=commonLabels"`
+	// CommonAnnotations is a list of additional annotations to add to rendered manifests
+	CommonAnnotations map[string]string `json:"commonAnnotations,omitempty" yaml:",omitempty" protobuf:"bytes,6,opt,name=commonAnnotations"`
+	// Replicas is a list of Kustomize Replicas override specifications
+	Replicas KustomizeReplicas `json:"replicas,omitempty" yaml:",omitempty" protobuf:"bytes,11,opt,name=replicas"`
+}
+
+// KustomizeImage represents a Kustomize image definition in the format [old_image_name=]<image_name>:<image_tag>
+type KustomizeImage string
+
+// KustomizeImages is a list of Kustomize images
+type KustomizeImages []KustomizeImage
+
+// KustomizeReplica overrides the number of replicas of a Deployment or StatefulSet with a given name
+type KustomizeReplica struct {
+	// Name of Deployment or StatefulSet
+	Name string `json:"name" protobuf:"bytes,1,name=name"`
+	// Number of replicas
+	Count KustomizeReplicaCount `json:"count" protobuf:"bytes,2,name=count"`
+}
+
+// KustomizeReplicaCount is the number of replicas of a KustomizeReplica.
+//
+// Argo CD declares this field as an IntOrString. When the status of an Argo CD Application is stored in the database, it is
+// marshalled with gopkg.in/yaml.v2, which writes an IntOrString as a struct (rather than as an int or string). This type is
+// able to read both forms, but is always written as an integer.
+type KustomizeReplicaCount int64
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (c *KustomizeReplicaCount) UnmarshalYAML(unmarshal func(interface{}) error) error {
+
+	var count int64
+	if err := unmarshal(&count); err == nil {
+		*c = KustomizeReplicaCount(count)
+		return nil
+	}
+
+	intOrString := struct {
+		IntVal int64  `yaml:"intval"`
+		StrVal string `yaml:"strval"`
+	}{}
+	if err := unmarshal(&intOrString); err != nil {
+		return err
+	}
+
+	if intOrString.StrVal != "" {
+		count, err := strconv.ParseInt(intOrString.StrVal, 10, 64)
+		if err != nil {
+			return fmt.Errorf("unable to parse replica count '%s': %v", intOrString.StrVal, err)
+		}
+		*c = KustomizeReplicaCount(count)
+		return nil
+	}
+
+	*c = KustomizeReplicaCount(intOrString.IntVal)
+	return nil
+}
+
+// KustomizeReplicas is a list of Kustomize Replicas override specifications
+type KustomizeReplicas []KustomizeReplica
+
+// ApplicationSourceHelm holds helm specific options
+type ApplicationSourceHelm struct {
+	// ValuesFiles is a list of Helm value files to use when generating a template
+	ValueFiles []string `json:"valueFiles,omitempty" yaml:",omitempty" protobuf:"bytes,1,opt,name=valueFiles"`
+	// Parameters is a list of Helm parameters which are passed to the helm template command upon manifest generation
+	Parameters []HelmParameter `json:"parameters,omitempty" yaml:",omitempty" protobuf:"bytes,2,opt,name=parameters"`
+	// ReleaseName is the Helm release name to use. If omitted it will use the application name
+	ReleaseName string `json:"releaseName,omitempty" yaml:",omitempty" protobuf:"bytes,3,opt,name=releaseName"`
+	// Values specifies Helm values to be passed to helm template, typically defined as a block
+	Values string `json:"values,omitempty" yaml:",omitempty" protobuf:"bytes,4,opt,name=values"`
+}
+
+// HelmParameter is a parameter that's passed to helm template during manifest generation
+type HelmParameter struct {
+	// Name is the name of the Helm parameter
+	Name string `json:"name,omitempty" yaml:",omitempty" protobuf:"bytes,1,opt,name=name"`
+	// Value is the value for the Helm parameter
+	Value string `json:"value,omitempty" yaml:",omitempty" protobuf:"bytes,2,opt,name=value"`
+	// ForceString determines whether to tell Helm to interpret booleans and numbers as strings
+	ForceString bool `json:"forceString,omitempty" yaml:",omitempty" protobuf:"bytes,3,opt,name=forceString"`
 }
 
 // ApplicationDestination holds information about the application's destination
@@ -75,6 +204,8 @@ type FauxComparedTo struct {
 	Source ApplicationSource `json:"source"`
 	// Destination is a reference to the target Kubernetes server and namespace
 	Destination ApplicationDestination `json:"destination"`
+	// Sources is a reference to the application's multiple sources used for comparison
+	Sources ApplicationSources `json:"sources,omitempty" yaml:",omitempty"`
 }
 
 // SyncPolicy controls when a sync will be performed in response to updates in git
@@ -128,6 +259,51 @@ type FauxApplicationStatus struct {
 	Conditions []ApplicationCondition `json:"conditions,omitempty" protobuf:"bytes,5,opt,name=conditions"`
 	// OperationState contains information about any ongoing operations, such as a sync
 	OperationState *OperationState `json:"operationState,omitempty" protobuf:"bytes,7,opt,name=operationState"`
+	// History contains information about the application's sync history
+	History RevisionHistories `json:"history,omitempty" protobuf:"bytes,6,opt,name=history"`
+	// ResourceTree is the resource tree of the application, as reported by the Argo CD API server. This field is not
+	// part of the Argo CD Application status: it is added by the cluster-agent.
+	ResourceTree *ApplicationTree `json:"resourceTree,omitempty"`
+}
+
+// ApplicationTree holds the nodes of the resource tree of an application: the resources which are directly managed by
+// the application, and their children. It contains a subset of the fields of the Argo CD ApplicationTree.
+type ApplicationTree struct {
+	Nodes []ResourceNode `json:"nodes,omitempty"`
+}
+
+// ResourceNode is a live resource of the resource tree of an application.
+type ResourceNode struct {
+	ResourceRef ResourceRef   `json:"resourceRef"`
+	ParentRefs  []ResourceRef `json:"parentRefs,omitempty"`
+	Health      *HealthStatus `json:"health,omitempty"`
+}
+
+// ResourceRef references a resource of the resource tree of an application.
+type ResourceRef struct {
+	Group     string `json:"group,omitempty"`
+	Version   string `json:"version,omitempty"`
+	Kind      string `json:"kind,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+	UID       string `json:"uid,omitempty"`
+}
+
+// RevisionHistories is a array of history, oldest first and newest last
+type RevisionHistories []RevisionHistory
+
+// RevisionHistory contains history information about a previous sync
+type RevisionHistory struct {
+	// Revision holds the revision the sync was performed against
+	Revision string `json:"revision,omitempty" protobuf:"bytes,2,opt,name=revision"`
+	// DeployedAt holds the time the sync operation completed
+	DeployedAt metav1.Time `json:"deployedAt" protobuf:"bytes,4,opt,name=deployedAt"`
+	// ID is an auto incrementing identifier of the RevisionHistory
+	ID int64 `json:"id" protobuf:"bytes,5,opt,name=id"`
+	// Source is a reference to the application source used for the sync operation
+	Source ApplicationSource `json:"source,omitempty" protobuf:"bytes,6,opt,name=source"`
+	// DeployStartedAt holds the time the sync operation started
+	DeployStartedAt *metav1.Time `json:"deployStartedAt,omitempty" protobuf:"bytes,7,opt,name=deployStartedAt"`
 }
 
 // ResourceStatus holds the current sync and health status of a resource
@@ -0,0 +1,93 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// LocateContextThatMatchesAPIURL examines a kubeconfig (Config struct), and looks for the context that
+// matches the cluster with the given API URL.
+// See 'sharedresourceloop_managedend_test.go' (in backend) for an example of a kubeconfig.
+func LocateContextThatMatchesAPIURL(config *clientcmdapi.Config, apiURL string) (string, clientcmdapi.Context, error) {
+	var matchingClusterName string
+
+	// Look for the cluster with the given API URL
+	for clusterName := range config.Clusters {
+		cluster := config.Clusters[clusterName]
+		if strings.EqualFold(cluster.Server, apiURL) {
+			matchingClusterName = clusterName
+			// matchingCluster = cluster
+			break
+		}
+	}
+	if matchingClusterName == "" {
+		return "", clientcmdapi.Context{}, fmt.Errorf("the kubeconfig did not have a cluster entry that matched the API URL '%s'", apiURL)
+	}
+
+	// Look for the context that matches the cluster above
+	var matchingContextName string
+	var matchingContext *clientcmdapi.Context
+	for contextName := range config.Contexts {
+		context := config.Contexts[contextName]
+		if context.Cluster == matchingClusterName {
+			matchingContextName = contextName
+			matchingContext = context
+		}
+	}
+	if matchingContextName == "" {
+		return "", clientcmdapi.Context{}, fmt.Errorf("the kubeconfig did not have a context that matched "+
+			"the cluster specified in the API URL of the GitOpsDeploymentManagedEnvironment. Context "+
+			"was expected to reference cluster '%s'", matchingClusterName)
+	}
+
+	return matchingContextName, *matchingContext, nil
+}
+
+// ExtractBearerTokenFromKubeconfig returns the service account bearer token of the user of the kubeconfig context
+// that matches the given API URL.
+func ExtractBearerTokenFromKubeconfig(kubeconfig []byte, apiURL string) (string, error) {
+
+	config, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse kubeconfig data: %w", err)
+	}
+
+	matchingContextName, matchingContext, err := LocateContextThatMatchesAPIURL(config, apiURL)
+	if err != nil {
+		return "", err
+	}
+
+	authInfo, exists := config.AuthInfos[matchingContext.AuthInfo]
+	if !exists || authInfo == nil {
+		return "", fmt.Errorf("unable to extract remote cluster configuration from kubeconfig, missing auth info for %s", matchingContextName)
+	}
+
+	if authInfo.Token == "" {
+		return "", fmt.Errorf("kubeconfig must have a service account token for the user in context \"%s\"", matchingContextName)
+	}
+
+	return authInfo.Token, nil
+}
+
+// RESTConfigFromKubeconfig returns a rest.Config for the given context of the kubeconfig. If contextName is empty, the
+// current context of the kubeconfig is used.
+func RESTConfigFromKubeconfig(kubeconfig []byte, contextName string) (*rest.Config, error) {
+
+	config, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse kubeconfig data: %w", err)
+	}
+
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: contextName}
+
+	restConfig, err := clientcmd.NewNonInteractiveClientConfig(*config, contextName, overrides, nil).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate client configuration from kubeconfig: %w", err)
+	}
+
+	return restConfig, nil
+}
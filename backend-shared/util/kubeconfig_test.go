@@ -0,0 +1,76 @@
+package util
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Kubeconfig Util Unit Tests", func() {
+
+	const kubeconfig = `
+apiVersion: v1
+kind: Config
+clusters:
+- cluster:
+    server: https://api.cluster-a.example.com:6443
+  name: cluster-a
+- cluster:
+    server: https://api.cluster-b.example.com:6443
+  name: cluster-b
+contexts:
+- context:
+    cluster: cluster-a
+    user: user-a
+  name: context-a
+- context:
+    cluster: cluster-b
+    user: user-b
+  name: context-b
+users:
+- name: user-a
+  user:
+    token: token-a
+- name: user-b
+  user:
+    client-certificate-data: ""
+`
+
+	Context("Testing the ExtractBearerTokenFromKubeconfig() function", func() {
+
+		It("should return the token of the user of the context that matches the API URL", func() {
+			token, err := ExtractBearerTokenFromKubeconfig([]byte(kubeconfig), "https://API.cluster-a.example.com:6443")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(token).To(Equal("token-a"))
+		})
+
+		It("should return an error if the user of the matching context does not have a token", func() {
+			_, err := ExtractBearerTokenFromKubeconfig([]byte(kubeconfig), "https://api.cluster-b.example.com:6443")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should return an error if no cluster matches the API URL", func() {
+			_, err := ExtractBearerTokenFromKubeconfig([]byte(kubeconfig), "https://api.cluster-c.example.com:6443")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should return an error if the kubeconfig is invalid", func() {
+			_, err := ExtractBearerTokenFromKubeconfig([]byte("{not-a-kubeconfig"), "https://api.cluster-a.example.com:6443")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("Testing the RESTConfigFromKubeconfig() function", func() {
+
+		It("should return the configuration of the given context", func() {
+			restConfig, err := RESTConfigFromKubeconfig([]byte(kubeconfig), "context-a")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(restConfig.Host).To(Equal("https://api.cluster-a.example.com:6443"))
+			Expect(restConfig.BearerToken).To(Equal("token-a"))
+		})
+
+		It("should return an error if the context does not exist", func() {
+			_, err := RESTConfigFromKubeconfig([]byte(kubeconfig), "context-c")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
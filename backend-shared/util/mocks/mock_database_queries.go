@@ -306,6 +306,21 @@ func (mr *MockDatabaseQueriesMockRecorder) CountAppProjectRepositoryByClusterUse
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountAppProjectRepositoryByClusterUserID", reflect.TypeOf((*MockDatabaseQueries)(nil).CountAppProjectRepositoryByClusterUserID), arg0, arg1)
 }
 
+// CountApplicationsForGitopsEngineInstance mocks base method.
+func (m *MockDatabaseQueries) CountApplicationsForGitopsEngineInstance(arg0 context.Context, arg1 string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountApplicationsForGitopsEngineInstance", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountApplicationsForGitopsEngineInstance indicates an expected call of CountApplicationsForGitopsEngineInstance.
+func (mr *MockDatabaseQueriesMockRecorder) CountApplicationsForGitopsEngineInstance(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountApplicationsForGitopsEngineInstance", reflect.TypeOf((*MockDatabaseQueries)(nil).CountApplicationsForGitopsEngineInstance), arg0, arg1)
+}
+
 // CountOperationDBRowsByState mocks base method.
 func (m *MockDatabaseQueries) CountOperationDBRowsByState(arg0 context.Context, arg1 *db.Operation) ([]db.OperationStateCount, error) {
 	m.ctrl.T.Helper()
@@ -378,6 +393,20 @@ func (mr *MockDatabaseQueriesMockRecorder) CreateAppProjectRepository(arg0, arg1
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAppProjectRepository", reflect.TypeOf((*MockDatabaseQueries)(nil).CreateAppProjectRepository), arg0, arg1)
 }
 
+// CreateAppProjectSyncWindow mocks base method.
+func (m *MockDatabaseQueries) CreateAppProjectSyncWindow(arg0 context.Context, arg1 *db.AppProjectSyncWindow) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAppProjectSyncWindow", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAppProjectSyncWindow indicates an expected call of CreateAppProjectSyncWindow.
+func (mr *MockDatabaseQueriesMockRecorder) CreateAppProjectSyncWindow(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAppProjectSyncWindow", reflect.TypeOf((*MockDatabaseQueries)(nil).CreateAppProjectSyncWindow), arg0, arg1)
+}
+
 // CreateApplication mocks base method.
 func (m *MockDatabaseQueries) CreateApplication(arg0 context.Context, arg1 *db.Application) error {
 	m.ctrl.T.Helper()
@@ -634,6 +663,36 @@ func (mr *MockDatabaseQueriesMockRecorder) DeleteAppProjectRepositoryByClusterUs
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAppProjectRepositoryByClusterUserAndRepoURL", reflect.TypeOf((*MockDatabaseQueries)(nil).DeleteAppProjectRepositoryByClusterUserAndRepoURL), arg0, arg1)
 }
 
+// DeleteAppProjectSyncWindowByAppProjectSyncWindowID mocks base method.
+func (m *MockDatabaseQueries) DeleteAppProjectSyncWindowByAppProjectSyncWindowID(arg0 context.Context, arg1 *db.AppProjectSyncWindow) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAppProjectSyncWindowByAppProjectSyncWindowID", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteAppProjectSyncWindowByAppProjectSyncWindowID indicates an expected call of DeleteAppProjectSyncWindowByAppProjectSyncWindowID.
+func (mr *MockDatabaseQueriesMockRecorder) DeleteAppProjectSyncWindowByAppProjectSyncWindowID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAppProjectSyncWindowByAppProjectSyncWindowID", reflect.TypeOf((*MockDatabaseQueries)(nil).DeleteAppProjectSyncWindowByAppProjectSyncWindowID), arg0, arg1)
+}
+
+// DeleteAppProjectSyncWindowsByClusterUserId mocks base method.
+func (m *MockDatabaseQueries) DeleteAppProjectSyncWindowsByClusterUserId(arg0 context.Context, arg1 string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAppProjectSyncWindowsByClusterUserId", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteAppProjectSyncWindowsByClusterUserId indicates an expected call of DeleteAppProjectSyncWindowsByClusterUserId.
+func (mr *MockDatabaseQueriesMockRecorder) DeleteAppProjectSyncWindowsByClusterUserId(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAppProjectSyncWindowsByClusterUserId", reflect.TypeOf((*MockDatabaseQueries)(nil).DeleteAppProjectSyncWindowsByClusterUserId), arg0, arg1)
+}
+
 // DeleteApplicationById mocks base method.
 func (m *MockDatabaseQueries) DeleteApplicationById(arg0 context.Context, arg1 string) (int, error) {
 	m.ctrl.T.Helper()
@@ -1378,6 +1437,20 @@ func (mr *MockDatabaseQueriesMockRecorder) ListAppProjectRepositoryByClusterUser
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAppProjectRepositoryByClusterUserId", reflect.TypeOf((*MockDatabaseQueries)(nil).ListAppProjectRepositoryByClusterUserId), arg0, arg1, arg2)
 }
 
+// ListAppProjectSyncWindowByClusterUserId mocks base method.
+func (m *MockDatabaseQueries) ListAppProjectSyncWindowByClusterUserId(arg0 context.Context, arg1 string, arg2 *[]db.AppProjectSyncWindow) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAppProjectSyncWindowByClusterUserId", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ListAppProjectSyncWindowByClusterUserId indicates an expected call of ListAppProjectSyncWindowByClusterUserId.
+func (mr *MockDatabaseQueriesMockRecorder) ListAppProjectSyncWindowByClusterUserId(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAppProjectSyncWindowByClusterUserId", reflect.TypeOf((*MockDatabaseQueries)(nil).ListAppProjectSyncWindowByClusterUserId), arg0, arg1, arg2)
+}
+
 // ListApplicationsForManagedEnvironment mocks base method.
 func (m *MockDatabaseQueries) ListApplicationsForManagedEnvironment(arg0 context.Context, arg1 string, arg2 *[]db.Application) (int, error) {
 	m.ctrl.T.Helper()
@@ -1393,6 +1466,20 @@ func (mr *MockDatabaseQueriesMockRecorder) ListApplicationsForManagedEnvironment
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListApplicationsForManagedEnvironment", reflect.TypeOf((*MockDatabaseQueries)(nil).ListApplicationsForManagedEnvironment), arg0, arg1, arg2)
 }
 
+// ListClusterAccessesByClusterUserID mocks base method.
+func (m *MockDatabaseQueries) ListClusterAccessesByClusterUserID(arg0 context.Context, arg1 string, arg2 *[]db.ClusterAccess) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListClusterAccessesByClusterUserID", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ListClusterAccessesByClusterUserID indicates an expected call of ListClusterAccessesByClusterUserID.
+func (mr *MockDatabaseQueriesMockRecorder) ListClusterAccessesByClusterUserID(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListClusterAccessesByClusterUserID", reflect.TypeOf((*MockDatabaseQueries)(nil).ListClusterAccessesByClusterUserID), arg0, arg1, arg2)
+}
+
 // ListClusterAccessesByManagedEnvironmentID mocks base method.
 func (m *MockDatabaseQueries) ListClusterAccessesByManagedEnvironmentID(arg0 context.Context, arg1 string, arg2 *[]db.ClusterAccess) error {
 	m.ctrl.T.Helper()
@@ -1491,6 +1578,64 @@ func (mr *MockDatabaseQueriesMockRecorder) ListOperationsToBeGarbageCollected(ar
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOperationsToBeGarbageCollected", reflect.TypeOf((*MockDatabaseQueries)(nil).ListOperationsToBeGarbageCollected), arg0, arg1)
 }
 
+// ListRepositoryCredentialsByClusterUserID mocks base method.
+func (m *MockDatabaseQueries) ListRepositoryCredentialsByClusterUserID(arg0 context.Context, arg1 string, arg2 *[]db.RepositoryCredentials) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRepositoryCredentialsByClusterUserID", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ListRepositoryCredentialsByClusterUserID indicates an expected call of ListRepositoryCredentialsByClusterUserID.
+func (mr *MockDatabaseQueriesMockRecorder) ListRepositoryCredentialsByClusterUserID(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRepositoryCredentialsByClusterUserID", reflect.TypeOf((*MockDatabaseQueries)(nil).ListRepositoryCredentialsByClusterUserID), arg0, arg1, arg2)
+}
+
+// NotifyOperationStateChanged mocks base method.
+func (m *MockDatabaseQueries) NotifyOperationStateChanged(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NotifyOperationStateChanged", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// NotifyOperationStateChanged indicates an expected call of NotifyOperationStateChanged.
+func (mr *MockDatabaseQueriesMockRecorder) NotifyOperationStateChanged(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NotifyOperationStateChanged", reflect.TypeOf((*MockDatabaseQueries)(nil).NotifyOperationStateChanged), arg0, arg1)
+}
+
+// ReencryptClusterCredentials mocks base method.
+func (m *MockDatabaseQueries) ReencryptClusterCredentials(arg0 context.Context, arg1 string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReencryptClusterCredentials", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReencryptClusterCredentials indicates an expected call of ReencryptClusterCredentials.
+func (mr *MockDatabaseQueriesMockRecorder) ReencryptClusterCredentials(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReencryptClusterCredentials", reflect.TypeOf((*MockDatabaseQueries)(nil).ReencryptClusterCredentials), arg0, arg1)
+}
+
+// ReencryptRepositoryCredentials mocks base method.
+func (m *MockDatabaseQueries) ReencryptRepositoryCredentials(arg0 context.Context, arg1 string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReencryptRepositoryCredentials", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReencryptRepositoryCredentials indicates an expected call of ReencryptRepositoryCredentials.
+func (mr *MockDatabaseQueriesMockRecorder) ReencryptRepositoryCredentials(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReencryptRepositoryCredentials", reflect.TypeOf((*MockDatabaseQueries)(nil).ReencryptRepositoryCredentials), arg0, arg1)
+}
+
 // RemoveManagedEnvironmentFromAllApplications mocks base method.
 func (m *MockDatabaseQueries) RemoveManagedEnvironmentFromAllApplications(arg0 context.Context, arg1 string, arg2 *[]db.Application) (int, error) {
 	m.ctrl.T.Helper()
@@ -1534,6 +1679,21 @@ func (mr *MockDatabaseQueriesMockRecorder) UpdateApplication(arg0, arg1 interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateApplication", reflect.TypeOf((*MockDatabaseQueries)(nil).UpdateApplication), arg0, arg1)
 }
 
+// UpdateApplicationEngineInstance mocks base method.
+func (m *MockDatabaseQueries) UpdateApplicationEngineInstance(arg0 context.Context, arg1 *db.Application, arg2 string, arg3 string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateApplicationEngineInstance", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateApplicationEngineInstance indicates an expected call of UpdateApplicationEngineInstance.
+func (mr *MockDatabaseQueriesMockRecorder) UpdateApplicationEngineInstance(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateApplicationEngineInstance", reflect.TypeOf((*MockDatabaseQueries)(nil).UpdateApplicationEngineInstance), arg0, arg1, arg2, arg3)
+}
+
 // UpdateApplicationState mocks base method.
 func (m *MockDatabaseQueries) UpdateApplicationState(arg0 context.Context, arg1 *db.ApplicationState) error {
 	m.ctrl.T.Helper()
@@ -1548,6 +1708,20 @@ func (mr *MockDatabaseQueriesMockRecorder) UpdateApplicationState(arg0, arg1 int
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateApplicationState", reflect.TypeOf((*MockDatabaseQueries)(nil).UpdateApplicationState), arg0, arg1)
 }
 
+// UpdateClusterCredentials mocks base method.
+func (m *MockDatabaseQueries) UpdateClusterCredentials(arg0 context.Context, arg1 *db.ClusterCredentials) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateClusterCredentials", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateClusterCredentials indicates an expected call of UpdateClusterCredentials.
+func (mr *MockDatabaseQueriesMockRecorder) UpdateClusterCredentials(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateClusterCredentials", reflect.TypeOf((*MockDatabaseQueries)(nil).UpdateClusterCredentials), arg0, arg1)
+}
+
 // UpdateClusterUser mocks base method.
 func (m *MockDatabaseQueries) UpdateClusterUser(arg0 context.Context, arg1 *db.ClusterUser) error {
 	m.ctrl.T.Helper()
@@ -1618,6 +1792,21 @@ func (mr *MockDatabaseQueriesMockRecorder) UpdateRepositoryCredentials(arg0, arg
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRepositoryCredentials", reflect.TypeOf((*MockDatabaseQueries)(nil).UpdateRepositoryCredentials), arg0, arg1)
 }
 
+// UpdateRepositoryCredentialsEngineInstance mocks base method.
+func (m *MockDatabaseQueries) UpdateRepositoryCredentialsEngineInstance(arg0 context.Context, arg1 string, arg2 string, arg3 string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRepositoryCredentialsEngineInstance", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateRepositoryCredentialsEngineInstance indicates an expected call of UpdateRepositoryCredentialsEngineInstance.
+func (mr *MockDatabaseQueriesMockRecorder) UpdateRepositoryCredentialsEngineInstance(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRepositoryCredentialsEngineInstance", reflect.TypeOf((*MockDatabaseQueries)(nil).UpdateRepositoryCredentialsEngineInstance), arg0, arg1, arg2, arg3)
+}
+
 // UpdateSyncOperation mocks base method.
 func (m *MockDatabaseQueries) UpdateSyncOperation(arg0 context.Context, arg1 *db.SyncOperation) error {
 	m.ctrl.T.Helper()
@@ -1646,3 +1835,17 @@ func (mr *MockDatabaseQueriesMockRecorder) UpdateSyncOperationRemoveApplicationF
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSyncOperationRemoveApplicationField", reflect.TypeOf((*MockDatabaseQueries)(nil).UpdateSyncOperationRemoveApplicationField), arg0, arg1)
 }
+
+// UpdateSyncOperationStatus mocks base method.
+func (m *MockDatabaseQueries) UpdateSyncOperationStatus(arg0 context.Context, arg1 *db.SyncOperation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSyncOperationStatus", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSyncOperationStatus indicates an expected call of UpdateSyncOperationStatus.
+func (mr *MockDatabaseQueriesMockRecorder) UpdateSyncOperationStatus(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSyncOperationStatus", reflect.TypeOf((*MockDatabaseQueries)(nil).UpdateSyncOperationStatus), arg0, arg1)
+}
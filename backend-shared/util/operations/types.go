@@ -162,6 +162,7 @@ func createOperationInternal(ctx context.Context, waitForOperation bool, dbOpera
 		Last_state_update:       time.Now(),
 		State:                   db.OperationState_Waiting,
 		Human_readable_state:    "",
+		Priority:                dbOperationParam.Priority,
 	}
 	if customOperationId != "" {
 		dbOperation.Operation_id = customOperationId
@@ -258,10 +259,29 @@ func generateUniqueOperationCRName(dbOperation db.Operation, uniqueIdFn func(db.
 }
 
 // waitForOperationToComplete waits for an Operation database entry to have 'Completed' or 'Failed' status.
+//
+// If supported by dbQueries, the cluster-agent's notification of the Operation state change (via PostgreSQL LISTEN/NOTIFY)
+// is used to wake up as soon as the Operation completes. The Operation row is still polled (at a lower frequency), in
+// case a notification is lost.
 func waitForOperationToComplete(ctx context.Context, dbOperation *db.Operation, dbQueries db.ApplicationScopedQueries, log logr.Logger) error {
 
 	backoff := sharedutil.ExponentialBackoff{Factor: 2, Min: time.Duration(100 * time.Millisecond), Max: time.Duration(10 * time.Second), Jitter: true}
 
+	var operationStateChanged <-chan struct{}
+	if subscriber, ok := dbQueries.(db.OperationStateChangeSubscriber); ok {
+
+		ch, unsubscribe, err := subscriber.SubscribeToOperationStateChanges(ctx, dbOperation.Operation_id)
+		if err != nil {
+			log.Error(err, "unable to subscribe to operation state changes, falling back to polling", "operationID", dbOperation.Operation_id)
+		} else {
+			defer unsubscribe()
+			operationStateChanged = ch
+
+			// Since we will be notified when the Operation completes, poll less frequently
+			backoff = sharedutil.ExponentialBackoff{Factor: 2, Min: time.Duration(1 * time.Second), Max: time.Duration(30 * time.Second), Jitter: true}
+		}
+	}
+
 	for {
 
 		isComplete, err := IsOperationComplete(ctx, dbOperation, dbQueries)
@@ -274,7 +294,19 @@ func waitForOperationToComplete(ctx context.Context, dbOperation *db.Operation,
 			break
 		}
 
-		backoff.DelayOnFail(ctx)
+		if operationStateChanged == nil {
+			backoff.DelayOnFail(ctx)
+
+		} else {
+			// Wait until either we are notified of a state change, or until the next poll interval
+			timer := time.NewTimer(backoff.IncreaseAndReturnNewDuration())
+			select {
+			case <-operationStateChanged:
+			case <-timer.C:
+			case <-ctx.Done():
+			}
+			timer.Stop()
+		}
 
 		// Break if the request is cancelled, or the timeout expires
 		select {
@@ -2,13 +2,16 @@ package operations
 
 import (
 	"context"
+	"time"
 
 	"github.com/go-logr/logr"
+	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	operation "github.com/redhat-appstudio/managed-gitops/backend-shared/apis/managed-gitops/v1alpha1"
 	"github.com/redhat-appstudio/managed-gitops/backend-shared/db"
 	sharedutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util"
+	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/mocks"
 	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/tests"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -408,3 +411,74 @@ var _ = Describe("Testing CleanupOperation function", func() {
 		})
 	})
 })
+
+// subscribingMockDatabaseQueries is a mock DatabaseQueries which also supports subscribing to Operation state changes.
+type subscribingMockDatabaseQueries struct {
+	*mocks.MockDatabaseQueries
+
+	operationStateChanged chan struct{}
+}
+
+func (s *subscribingMockDatabaseQueries) SubscribeToOperationStateChanges(ctx context.Context, operationID string) (<-chan struct{}, func(), error) {
+	return s.operationStateChanged, func() {}, nil
+}
+
+var _ = Describe("Testing waitForOperationToComplete function", func() {
+
+	It("should return as soon as a state change notification is received for the Operation, rather than waiting for the next poll", func() {
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		mockCtrl := gomock.NewController(GinkgoT())
+		defer mockCtrl.Finish()
+
+		dbq := &subscribingMockDatabaseQueries{
+			MockDatabaseQueries:   mocks.NewMockDatabaseQueries(mockCtrl),
+			operationStateChanged: make(chan struct{}, 1),
+		}
+
+		By("returning 'In_Progress' on the first check, and 'Completed' on subsequent checks")
+		gomock.InOrder(
+			dbq.EXPECT().GetOperationById(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, operation *db.Operation) error {
+				operation.State = db.OperationState_In_Progress
+				// Simulate the cluster-agent completing the Operation, and notifying us
+				dbq.operationStateChanged <- struct{}{}
+				return nil
+			}),
+			dbq.EXPECT().GetOperationById(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, operation *db.Operation) error {
+				operation.State = db.OperationState_Completed
+				return nil
+			}),
+		)
+
+		start := time.Now()
+		err := waitForOperationToComplete(ctx, &db.Operation{Operation_id: "test-operation"}, dbq, log.FromContext(ctx))
+		Expect(err).ToNot(HaveOccurred())
+
+		By("verifying we didn't wait for the (minimum 1 second) fallback poll interval")
+		Expect(time.Since(start)).To(BeNumerically("<", 1*time.Second))
+	})
+
+	It("should return an error if the context is cancelled before the Operation completes", func() {
+
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer cancel()
+
+		mockCtrl := gomock.NewController(GinkgoT())
+		defer mockCtrl.Finish()
+
+		dbq := &subscribingMockDatabaseQueries{
+			MockDatabaseQueries:   mocks.NewMockDatabaseQueries(mockCtrl),
+			operationStateChanged: make(chan struct{}, 1),
+		}
+
+		dbq.EXPECT().GetOperationById(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, operation *db.Operation) error {
+			operation.State = db.OperationState_In_Progress
+			return nil
+		}).AnyTimes()
+
+		err := waitForOperationToComplete(ctx, &db.Operation{Operation_id: "test-operation"}, dbq, log.FromContext(ctx))
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -3,6 +3,7 @@ package util
 import (
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -62,6 +63,11 @@ import (
 // will NOT be de-duplicated: instead it will wait for the task from 1 to complete.
 // - Tasks will only be de-duplicated from waitingTasks.
 // - Because of this de-duplication, tasks submitted to the task retry loop must be idempotent.
+//
+// Tasks may optionally be added with a tenant and a priority (see TaskSchedulingOptions). When there are more
+// tasks ready to run than there are available runners, runners are shared fairly between tenants, so that a
+// single tenant with many tasks is not able to starve the tasks of other tenants. Priority is applied before
+// fairness: a task is only started once no task with a higher priority (of any tenant) is waiting to start.
 
 type TaskRetryLoop struct {
 	inputChan chan taskRetryLoopMessage
@@ -70,6 +76,28 @@ type TaskRetryLoop struct {
 	debugName string
 }
 
+// TaskRetryLoopOptions are optional settings which control how a TaskRetryLoop schedules tasks.
+type TaskRetryLoopOptions struct {
+
+	// TenantWeight returns the weight of a tenant: when runners are contended, each tenant receives a share of
+	// runners proportional to its weight. If nil (or if a value < 1 is returned), every tenant has a weight of 1.
+	TenantWeight func(tenant string) int
+
+	// ReportQueueDepth, if non-nil, is periodically called with the number of waiting and active tasks, per tenant.
+	ReportQueueDepth func(waitingTasksByTenant map[string]int, activeTasksByTenant map[string]int)
+}
+
+// TaskSchedulingOptions control the order in which a task is started, relative to other tasks that are ready to run.
+type TaskSchedulingOptions struct {
+
+	// Tenant is the tenant that the task belongs to: runners are shared fairly between tenants.
+	Tenant string
+
+	// Priority of the task: within a tenant, tasks with a higher priority are started before tasks with a lower
+	// priority. Tasks with the same priority are started in the order in which they were added.
+	Priority int
+}
+
 // RetryableTask should be implemented for any task that wants to run in the task retry loop.
 type RetryableTask interface {
 	// Returns bool (true if the task should be retried, for example because it failed, false otherwise),
@@ -82,13 +110,19 @@ type RetryableTask interface {
 
 // AddTaskIfNotPresent will queue a task to run within the task retry loop
 func (loop *TaskRetryLoop) AddTaskIfNotPresent(name string, task RetryableTask, backoff ExponentialBackoff) {
+	loop.AddTaskIfNotPresentWithSchedulingOptions(name, task, backoff, TaskSchedulingOptions{})
+}
+
+// AddTaskIfNotPresentWithSchedulingOptions will queue a task to run within the task retry loop, using the given tenant and priority.
+func (loop *TaskRetryLoop) AddTaskIfNotPresentWithSchedulingOptions(name string, task RetryableTask, backoff ExponentialBackoff, options TaskSchedulingOptions) {
 
 	loop.inputChan <- taskRetryLoopMessage{
 		msgType: taskRetryLoop_addTask,
 		payload: taskRetryMessage_addTask{
-			name:    name,
-			task:    task,
-			backoff: backoff,
+			name:              name,
+			task:              task,
+			backoff:           backoff,
+			schedulingOptions: options,
 		},
 	}
 }
@@ -104,6 +138,9 @@ const (
 
 const (
 	minimumEventTick = time.Duration(time.Millisecond * 200)
+
+	// reportQueueDepthInterval is how often TaskRetryLoopOptions.ReportQueueDepth is called
+	reportQueueDepthInterval = time.Duration(time.Second * 5)
 )
 
 type taskRetryLoopMessage struct {
@@ -112,9 +149,10 @@ type taskRetryLoopMessage struct {
 }
 
 type taskRetryMessage_addTask struct {
-	name    string
-	backoff ExponentialBackoff
-	task    RetryableTask
+	name              string
+	backoff           ExponentialBackoff
+	task              RetryableTask
+	schedulingOptions TaskSchedulingOptions
 }
 type taskRetryMessage_removeTask struct {
 	name string
@@ -127,13 +165,17 @@ type taskRetryMessage_workCompleted struct {
 }
 
 func NewTaskRetryLoop(debugName string) (loop *TaskRetryLoop) {
+	return NewTaskRetryLoopWithOptions(debugName, TaskRetryLoopOptions{})
+}
+
+func NewTaskRetryLoopWithOptions(debugName string, options TaskRetryLoopOptions) (loop *TaskRetryLoop) {
 
 	res := &TaskRetryLoop{
 		inputChan: make(chan taskRetryLoopMessage),
 		debugName: debugName,
 	}
 
-	go internalTaskRetryLoop(res.inputChan, res.debugName)
+	go internalTaskRetryLoop(res.inputChan, res.debugName, options)
 
 	// Ensure the message queue logic runs at least every 200 msecs
 	go func() {
@@ -170,6 +212,7 @@ type waitingTaskEntry struct {
 	task                   RetryableTask
 	backoff                ExponentialBackoff
 	nextScheduledRetryTime *time.Time
+	schedulingOptions      TaskSchedulingOptions
 }
 
 func (wte *waitingTaskContainer) isWorkAvailable() bool {
@@ -181,6 +224,16 @@ func (wte *waitingTaskContainer) addTask(entry waitingTaskEntry, log logr.Logger
 	// Check if the task already exists in the list (by name)
 	if _, exists := wte.waitingTasksByName[entry.name]; exists {
 		log.V(logutil.LogLevel_Debug).Info("skipping duplicate task in addTask", "taskName", entry.name)
+
+		// The duplicate task is ignored, but if it has a higher priority, the waiting task is raised to that priority,
+		// so that it is not started later than the duplicate would have been.
+		for idx := range wte.waitingTasks {
+			waitingTask := &wte.waitingTasks[idx]
+			if waitingTask.name == entry.name && waitingTask.schedulingOptions.Priority < entry.schedulingOptions.Priority {
+				waitingTask.schedulingOptions.Priority = entry.schedulingOptions.Priority
+				wte.waitingTasksByName[entry.name] = *waitingTask
+			}
+		}
 		return
 	}
 
@@ -191,12 +244,13 @@ func (wte *waitingTaskContainer) addTask(entry waitingTaskEntry, log logr.Logger
 
 // internalTaskEntry represents a single active (currently running) task
 type internalTaskEntry struct {
-	name         string
-	task         RetryableTask
-	backoff      ExponentialBackoff
-	taskContext  context.Context
-	cancelFunc   context.CancelFunc
-	creationTime time.Time
+	name              string
+	task              RetryableTask
+	backoff           ExponentialBackoff
+	taskContext       context.Context
+	cancelFunc        context.CancelFunc
+	creationTime      time.Time
+	schedulingOptions TaskSchedulingOptions
 }
 
 const (
@@ -205,7 +259,7 @@ const (
 	ReportActiveTasksEveryXMinutes = 10 * time.Minute
 )
 
-func internalTaskRetryLoop(inputChan chan taskRetryLoopMessage, debugName string) {
+func internalTaskRetryLoop(inputChan chan taskRetryLoopMessage, debugName string, options TaskRetryLoopOptions) {
 
 	ctx := context.Background()
 	log := log.FromContext(ctx).WithName("task-retry-loop").WithValues("task-retry-name", debugName)
@@ -223,6 +277,8 @@ func internalTaskRetryLoop(inputChan chan taskRetryLoopMessage, debugName string
 
 	nextReportActiveTasks := time.Now().Add(ReportActiveTasksEveryXMinutes)
 
+	nextReportQueueDepth := time.Now()
+
 	for {
 
 		// Every X minutes, report how many tasks are in progress, and how many are waiting. This allows us
@@ -234,57 +290,48 @@ func internalTaskRetryLoop(inputChan chan taskRetryLoopMessage, debugName string
 			nextReportActiveTasks = time.Now().Add(ReportActiveTasksEveryXMinutes)
 		}
 
+		if options.ReportQueueDepth != nil && time.Now().After(nextReportQueueDepth) {
+			options.ReportQueueDepth(countTasksByTenant(waitingTaskContainer.waitingTasks, activeTaskMap))
+			nextReportQueueDepth = time.Now().Add(reportQueueDepthInterval)
+		}
+
 		// Queue more running tasks if we have resources
 		if waitingTaskContainer.isWorkAvailable() && len(activeTaskMap) < maxActiveRunners {
 
-			updatedWaitingTasks := []waitingTaskEntry{}
+			tasksToStart := selectTasksToStart(waitingTaskContainer.waitingTasks, activeTaskMap,
+				maxActiveRunners-len(activeTaskMap), options.TenantWeight, time.Now())
 
-			// TODO: GITOPSRVCE-68 - PERF - this is an inefficient algorithm for queuing tasks, because it causes an allocation and iteration through the entire list on every received event
+			startedTasks := map[int]bool{}
 
-			for idx := range waitingTaskContainer.waitingTasks {
+			for _, idx := range tasksToStart {
 
 				task := waitingTaskContainer.waitingTasks[idx]
 
-				startTask := false
-				{
-					if task.nextScheduledRetryTime == nil {
-						startTask = true
-					} else if time.Now().After(*task.nextScheduledRetryTime) {
-						startTask = true
-					}
+				prevActiveTaskMapSize := len(activeTaskMap) // used for sanity tests
+				prevWaitingTasksByNameSize := len(waitingTaskContainer.waitingTasksByName)
 
-					// Don't start a task (yet) if it's already running
-					if _, exists := activeTaskMap[task.name]; exists {
-						startTask = false
-					}
-				}
-
-				// If the task is ready to go, and we still have room for it, start it
-				if startTask && len(activeTaskMap) < maxActiveRunners {
+				startNewTask(task, &waitingTaskContainer, activeTaskMap, inputChan, log)
+				startedTasks[idx] = true
 
-					prevActiveTaskMapSize := len(activeTaskMap) // used for sanity tests
-					prevWaitingTasksByNameSize := len(waitingTaskContainer.waitingTasksByName)
-
-					startNewTask(task, &waitingTaskContainer, activeTaskMap, inputChan, log)
+				// Sanity check the task start
+				if len(activeTaskMap) != prevActiveTaskMapSize+1 {
+					log.Error(nil, "SEVERE: active task map did not grow after startNewTask was called")
+				}
+				if len(waitingTaskContainer.waitingTasksByName) != prevWaitingTasksByNameSize-1 {
+					log.Error(nil, "SEVERE: waiting tasks by name did not shrink after startNewTask was called")
+				}
+			}
 
-					// Sanity check the task start
-					if len(activeTaskMap) != prevActiveTaskMapSize+1 {
-						log.Error(nil, "SEVERE: active task map did not grow after startNewTask was called")
-					}
-					if len(waitingTaskContainer.waitingTasksByName) != prevWaitingTasksByNameSize-1 {
-						log.Error(nil, "SEVERE: waiting tasks by name did not shrink after startNewTask was called")
+			if len(startedTasks) > 0 {
+				// replace the waitingTask var, with a new list with started tasks removed
+				updatedWaitingTasks := make([]waitingTaskEntry, 0, len(waitingTaskContainer.waitingTasks)-len(startedTasks))
+				for idx := range waitingTaskContainer.waitingTasks {
+					if !startedTasks[idx] {
+						updatedWaitingTasks = append(updatedWaitingTasks, waitingTaskContainer.waitingTasks[idx])
 					}
-
-				} else {
-					// Otherwise, we don't yet have room for it, or it isn't ready yet, so keep it in the list
-					// of waiting tasks.
-					updatedWaitingTasks = append(updatedWaitingTasks, task)
 				}
+				waitingTaskContainer.waitingTasks = updatedWaitingTasks
 			}
-
-			// replace the waitingTask var, with a new list with started tasks removed
-			waitingTaskContainer.waitingTasks = updatedWaitingTasks
-
 		}
 
 		// After we have ensured our task queue is full, pull the next message from the channel.
@@ -302,9 +349,10 @@ func internalTaskRetryLoop(inputChan chan taskRetryLoopMessage, debugName string
 			}
 
 			newWaitingTaskEntry := waitingTaskEntry{
-				name:    addTaskMsg.name,
-				task:    addTaskMsg.task,
-				backoff: addTaskMsg.backoff}
+				name:              addTaskMsg.name,
+				task:              addTaskMsg.task,
+				backoff:           addTaskMsg.backoff,
+				schedulingOptions: addTaskMsg.schedulingOptions}
 
 			waitingTaskContainer.addTask(newWaitingTaskEntry, log)
 
@@ -364,7 +412,8 @@ func internalTaskRetryLoop(inputChan chan taskRetryLoopMessage, debugName string
 					name:                   taskEntry.name,
 					task:                   taskEntry.task,
 					nextScheduledRetryTime: &nextScheduledRetryTime,
-					backoff:                taskEntry.backoff}
+					backoff:                taskEntry.backoff,
+					schedulingOptions:      taskEntry.schedulingOptions}
 
 				waitingTaskContainer.addTask(waitingTaskEntry, log)
 			}
@@ -388,10 +437,11 @@ func startNewTask(taskToStart waitingTaskEntry, waitingTaskContainer *waitingTas
 	delete(waitingTaskContainer.waitingTasksByName, taskName)
 
 	newTaskEntry := internalTaskEntry{
-		name:         taskName,
-		task:         taskToStart.task,
-		backoff:      taskToStart.backoff,
-		creationTime: time.Now(),
+		name:              taskName,
+		task:              taskToStart.task,
+		backoff:           taskToStart.backoff,
+		creationTime:      time.Now(),
+		schedulingOptions: taskToStart.schedulingOptions,
 	}
 
 	activeTaskMap[taskName] = newTaskEntry
@@ -402,6 +452,128 @@ func startNewTask(taskToStart waitingTaskEntry, waitingTaskContainer *waitingTas
 
 }
 
+// selectTasksToStart returns the indices (into waitingTasks) of the tasks that should be started next, up to a
+// maximum of 'availableRunners' tasks.
+// - A task is only eligible to start if its scheduled retry time has passed, and a task with the same name is not already running.
+// - Tasks with a higher priority are started first, whichever tenant they belong to.
+// - When more tasks of the same priority are eligible than there are available runners, runners are shared between
+// tenants in proportion to their weight: the next task is always taken from the tenant with the lowest ratio of
+// active tasks to weight.
+// - Otherwise, tasks are started in the order they were added.
+func selectTasksToStart(waitingTasks []waitingTaskEntry, activeTaskMap map[string]internalTaskEntry, availableRunners int,
+	tenantWeight func(tenant string) int, now time.Time) []int {
+
+	// eligibleTasksByTenant is a map from tenant -> indices of the eligible waiting tasks of that tenant
+	eligibleTasksByTenant := map[string][]int{}
+
+	// tenants is the list of tenants with eligible tasks, in the order in which they were first seen; this ensures
+	// that ties between tenants are broken consistently, in favour of the tenant with the oldest waiting task.
+	tenants := []string{}
+
+	for idx := range waitingTasks {
+		task := waitingTasks[idx]
+
+		if task.nextScheduledRetryTime != nil && !now.After(*task.nextScheduledRetryTime) {
+			continue
+		}
+
+		// Don't start a task (yet) if it's already running
+		if _, exists := activeTaskMap[task.name]; exists {
+			continue
+		}
+
+		tenant := task.schedulingOptions.Tenant
+		if _, exists := eligibleTasksByTenant[tenant]; !exists {
+			tenants = append(tenants, tenant)
+		}
+		eligibleTasksByTenant[tenant] = append(eligibleTasksByTenant[tenant], idx)
+	}
+
+	for _, taskIndices := range eligibleTasksByTenant {
+		// A stable sort ensures tasks of the same priority remain in the order in which they were added
+		sort.SliceStable(taskIndices, func(i, j int) bool {
+			return waitingTasks[taskIndices[i]].schedulingOptions.Priority > waitingTasks[taskIndices[j]].schedulingOptions.Priority
+		})
+	}
+
+	activeTasksByTenant := map[string]int{}
+	for _, activeTask := range activeTaskMap {
+		activeTasksByTenant[activeTask.schedulingOptions.Tenant]++
+	}
+
+	res := []int{}
+
+	for len(res) < availableRunners {
+
+		// Only the tenants whose next task has the highest priority are candidates: the next task of each tenant is
+		// its highest priority task, since the tasks of each tenant are sorted by priority
+		highestPriority := 0
+		priorityFound := false
+		for _, tenant := range tenants {
+			if len(eligibleTasksByTenant[tenant]) == 0 {
+				continue
+			}
+			priority := waitingTasks[eligibleTasksByTenant[tenant][0]].schedulingOptions.Priority
+			if !priorityFound || priority > highestPriority {
+				highestPriority = priority
+				priorityFound = true
+			}
+		}
+
+		var selectedTenant string
+		var selectedTenantShare float64
+		tenantFound := false
+
+		for _, tenant := range tenants {
+			if len(eligibleTasksByTenant[tenant]) == 0 ||
+				waitingTasks[eligibleTasksByTenant[tenant][0]].schedulingOptions.Priority != highestPriority {
+				continue
+			}
+
+			weight := 1
+			if tenantWeight != nil {
+				if w := tenantWeight(tenant); w > 1 {
+					weight = w
+				}
+			}
+
+			share := float64(activeTasksByTenant[tenant]) / float64(weight)
+			if !tenantFound || share < selectedTenantShare {
+				selectedTenant = tenant
+				selectedTenantShare = share
+				tenantFound = true
+			}
+		}
+
+		if !tenantFound {
+			// No more eligible tasks
+			break
+		}
+
+		res = append(res, eligibleTasksByTenant[selectedTenant][0])
+		eligibleTasksByTenant[selectedTenant] = eligibleTasksByTenant[selectedTenant][1:]
+		activeTasksByTenant[selectedTenant]++
+	}
+
+	return res
+}
+
+// countTasksByTenant returns the number of waiting tasks, and the number of active tasks, for each tenant.
+func countTasksByTenant(waitingTasks []waitingTaskEntry, activeTaskMap map[string]internalTaskEntry) (map[string]int, map[string]int) {
+
+	waitingTasksByTenant := map[string]int{}
+	for idx := range waitingTasks {
+		waitingTasksByTenant[waitingTasks[idx].schedulingOptions.Tenant]++
+	}
+
+	activeTasksByTenant := map[string]int{}
+	for _, activeTask := range activeTaskMap {
+		activeTasksByTenant[activeTask.schedulingOptions.Tenant]++
+	}
+
+	return waitingTasksByTenant, activeTasksByTenant
+}
+
 // internalStartTaskRunner starts a new goroutine that is responsible for running the given task, and then returning the result to internalTaskRetryLoop
 func internalStartTaskRunner(taskEntry *internalTaskEntry, workComplete chan taskRetryLoopMessage, log logr.Logger) (context.Context, context.CancelFunc) {
 
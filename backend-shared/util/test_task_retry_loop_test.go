@@ -102,6 +102,35 @@ var _ = Describe("Task Retry Loop Unit Tests", func() {
 		})
 	})
 
+	Context("addTask Test", func() {
+
+		It("ensures that adding a duplicate task with a higher priority raises the priority of the waiting task", func() {
+
+			waitingTaskContainer := waitingTaskContainer{
+				waitingTasksByName: make(map[string]any),
+				waitingTasks:       []waitingTaskEntry{},
+			}
+
+			waitingTaskContainer.addTask(waitingTaskEntry{name: "test-task", task: &mockEmptyTask{},
+				schedulingOptions: TaskSchedulingOptions{Tenant: "tenant-a", Priority: 1}}, log)
+
+			By("adding a duplicate with a lower priority, which should not change the waiting task")
+			waitingTaskContainer.addTask(waitingTaskEntry{name: "test-task", task: &mockEmptyTask{},
+				schedulingOptions: TaskSchedulingOptions{Tenant: "tenant-a", Priority: 0}}, log)
+
+			Expect(waitingTaskContainer.waitingTasks).To(HaveLen(1))
+			Expect(waitingTaskContainer.waitingTasks[0].schedulingOptions.Priority).To(Equal(1))
+
+			By("adding a duplicate with a higher priority, which should raise the priority of the waiting task")
+			waitingTaskContainer.addTask(waitingTaskEntry{name: "test-task", task: &mockEmptyTask{},
+				schedulingOptions: TaskSchedulingOptions{Tenant: "tenant-a", Priority: 5}}, log)
+
+			Expect(waitingTaskContainer.waitingTasks).To(HaveLen(1))
+			Expect(waitingTaskContainer.waitingTasks[0].schedulingOptions.Priority).To(Equal(5))
+			Expect(waitingTaskContainer.waitingTasksByName["test-task"].(waitingTaskEntry).schedulingOptions.Priority).To(Equal(5))
+		})
+	})
+
 	Context("startNewTask Test", func() {
 
 		It("ensures that calling startTask removes the task from 'waitingTasksByName'", func() {
@@ -126,6 +155,168 @@ var _ = Describe("Task Retry Loop Unit Tests", func() {
 		})
 	})
 
+	Context("selectTasksToStart tests", func() {
+
+		newWaitingTask := func(name string, tenant string, priority int) waitingTaskEntry {
+			return waitingTaskEntry{
+				name:              name,
+				task:              &mockEmptyTask{},
+				schedulingOptions: TaskSchedulingOptions{Tenant: tenant, Priority: priority},
+			}
+		}
+
+		selectedTaskNames := func(waitingTasks []waitingTaskEntry, indices []int) []string {
+			res := []string{}
+			for _, idx := range indices {
+				res = append(res, waitingTasks[idx].name)
+			}
+			return res
+		}
+
+		It("should start tasks in the order they were added, if no tenant or priority is specified", func() {
+
+			waitingTasks := []waitingTaskEntry{
+				newWaitingTask("a", "", 0),
+				newWaitingTask("b", "", 0),
+				newWaitingTask("c", "", 0),
+			}
+
+			res := selectTasksToStart(waitingTasks, map[string]internalTaskEntry{}, 2, nil, time.Now())
+			Expect(selectedTaskNames(waitingTasks, res)).To(Equal([]string{"a", "b"}))
+		})
+
+		It("should not start tasks that are already running, or whose retry time has not yet passed", func() {
+
+			future := time.Now().Add(time.Hour)
+
+			waitingTasks := []waitingTaskEntry{
+				newWaitingTask("a", "", 0),
+				newWaitingTask("b", "", 0),
+				newWaitingTask("c", "", 0),
+			}
+			waitingTasks[1].nextScheduledRetryTime = &future
+
+			activeTaskMap := map[string]internalTaskEntry{"a": {name: "a"}}
+
+			res := selectTasksToStart(waitingTasks, activeTaskMap, 10, nil, time.Now())
+			Expect(selectedTaskNames(waitingTasks, res)).To(Equal([]string{"c"}))
+		})
+
+		It("should share runners fairly between tenants, so that a busy tenant doesn't starve other tenants", func() {
+
+			waitingTasks := []waitingTaskEntry{}
+			for i := 0; i < 100; i++ {
+				waitingTasks = append(waitingTasks, newWaitingTask(fmt.Sprintf("busy-%d", i), "busy-tenant", 0))
+			}
+			waitingTasks = append(waitingTasks, newWaitingTask("quiet-0", "quiet-tenant", 0))
+			waitingTasks = append(waitingTasks, newWaitingTask("quiet-1", "quiet-tenant", 0))
+
+			res := selectTasksToStart(waitingTasks, map[string]internalTaskEntry{}, 4, nil, time.Now())
+			Expect(selectedTaskNames(waitingTasks, res)).To(ConsistOf("busy-0", "busy-1", "quiet-0", "quiet-1"))
+		})
+
+		It("should take into account the tasks of a tenant that are already running", func() {
+
+			waitingTasks := []waitingTaskEntry{
+				newWaitingTask("busy-2", "busy-tenant", 0),
+				newWaitingTask("quiet-0", "quiet-tenant", 0),
+				newWaitingTask("quiet-1", "quiet-tenant", 0),
+			}
+
+			activeTaskMap := map[string]internalTaskEntry{
+				"busy-0": {name: "busy-0", schedulingOptions: TaskSchedulingOptions{Tenant: "busy-tenant"}},
+				"busy-1": {name: "busy-1", schedulingOptions: TaskSchedulingOptions{Tenant: "busy-tenant"}},
+			}
+
+			res := selectTasksToStart(waitingTasks, activeTaskMap, 2, nil, time.Now())
+			Expect(selectedTaskNames(waitingTasks, res)).To(Equal([]string{"quiet-0", "quiet-1"}))
+		})
+
+		It("should share runners between tenants in proportion to their weight", func() {
+
+			waitingTasks := []waitingTaskEntry{}
+			for i := 0; i < 10; i++ {
+				waitingTasks = append(waitingTasks, newWaitingTask(fmt.Sprintf("a-%d", i), "tenant-a", 0))
+				waitingTasks = append(waitingTasks, newWaitingTask(fmt.Sprintf("b-%d", i), "tenant-b", 0))
+			}
+
+			tenantWeight := func(tenant string) int {
+				if tenant == "tenant-a" {
+					return 3
+				}
+				return 1
+			}
+
+			res := selectTasksToStart(waitingTasks, map[string]internalTaskEntry{}, 8, tenantWeight, time.Now())
+
+			tenantATasks := 0
+			for _, idx := range res {
+				if waitingTasks[idx].schedulingOptions.Tenant == "tenant-a" {
+					tenantATasks++
+				}
+			}
+			Expect(res).To(HaveLen(8))
+			Expect(tenantATasks).To(Equal(6))
+		})
+
+		It("should start higher priority tasks of a tenant first", func() {
+
+			waitingTasks := []waitingTaskEntry{
+				newWaitingTask("self-heal-0", "tenant", -1),
+				newWaitingTask("default-0", "tenant", 0),
+				newWaitingTask("sync-0", "tenant", 1),
+				newWaitingTask("self-heal-1", "tenant", -1),
+				newWaitingTask("sync-1", "tenant", 1),
+			}
+
+			res := selectTasksToStart(waitingTasks, map[string]internalTaskEntry{}, 10, nil, time.Now())
+			Expect(selectedTaskNames(waitingTasks, res)).To(Equal([]string{"sync-0", "sync-1", "default-0", "self-heal-0", "self-heal-1"}))
+		})
+
+		It("should start higher priority tasks before lower priority tasks of other tenants, and share runners fairly between tasks of the same priority", func() {
+
+			waitingTasks := []waitingTaskEntry{}
+			for i := 0; i < 10; i++ {
+				waitingTasks = append(waitingTasks, newWaitingTask(fmt.Sprintf("busy-self-heal-%d", i), "busy-tenant", -1))
+			}
+			waitingTasks = append(waitingTasks, newWaitingTask("quiet-self-heal-0", "quiet-tenant", -1))
+			waitingTasks = append(waitingTasks, newWaitingTask("busy-sync-0", "busy-tenant", 1))
+			waitingTasks = append(waitingTasks, newWaitingTask("other-sync-0", "other-tenant", 1))
+
+			activeTaskMap := map[string]internalTaskEntry{
+				"busy-0": {name: "busy-0", schedulingOptions: TaskSchedulingOptions{Tenant: "busy-tenant"}},
+				"busy-1": {name: "busy-1", schedulingOptions: TaskSchedulingOptions{Tenant: "busy-tenant"}},
+			}
+
+			By("starting the sync tasks of every tenant, even though the quiet tenant has fewer active tasks")
+			res := selectTasksToStart(waitingTasks, activeTaskMap, 2, nil, time.Now())
+			Expect(selectedTaskNames(waitingTasks, res)).To(ConsistOf("busy-sync-0", "other-sync-0"))
+
+			By("sharing the remaining runners fairly between the self-heal tasks of the tenants")
+			res = selectTasksToStart(waitingTasks, activeTaskMap, 4, nil, time.Now())
+			Expect(selectedTaskNames(waitingTasks, res)).To(Equal([]string{"other-sync-0", "busy-sync-0", "quiet-self-heal-0", "busy-self-heal-0"}))
+		})
+	})
+
+	Context("countTasksByTenant tests", func() {
+
+		It("should return the number of waiting and active tasks for each tenant", func() {
+
+			waitingTasks := []waitingTaskEntry{
+				{name: "a", schedulingOptions: TaskSchedulingOptions{Tenant: "tenant-a"}},
+				{name: "b", schedulingOptions: TaskSchedulingOptions{Tenant: "tenant-a"}},
+				{name: "c", schedulingOptions: TaskSchedulingOptions{Tenant: "tenant-b"}},
+			}
+			activeTaskMap := map[string]internalTaskEntry{
+				"d": {name: "d", schedulingOptions: TaskSchedulingOptions{Tenant: "tenant-b"}},
+			}
+
+			waiting, active := countTasksByTenant(waitingTasks, activeTaskMap)
+			Expect(waiting).To(Equal(map[string]int{"tenant-a": 2, "tenant-b": 1}))
+			Expect(active).To(Equal(map[string]int{"tenant-b": 1}))
+		})
+	})
+
 	Context("internalTaskRunner tests", func() {
 
 		It("ensures that the task provided runs as expected", func() {
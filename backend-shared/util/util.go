@@ -33,6 +33,9 @@ const (
 
 	ManagedEnvironmentSecretType = "managed-gitops.redhat.com/managed-environment"
 
+	// ManagedEnvironmentSecretKubeconfigKey is the key of the kubeconfig field within a managed environment Secret
+	ManagedEnvironmentSecretKubeconfigKey = "kubeconfig"
+
 	RepositoryCredentialSecretType = "managed-gitops.redhat.com/repository-credential"
 
 	Log_JobKey      = "job"                    // Clean up job key
@@ -191,3 +194,10 @@ func AppProjectIsolationEnabled() bool {
 
 	return strings.EqualFold(os.Getenv("ENABLE_APPPROJECT_ISOLATION"), "true")
 }
+
+// CredentialsSecretReferenceEnabled is a feature flag: when enabled, the ClusterCredentials and RepositoryCredentials
+// database rows store a reference to the user's Secret, rather than a copy of the credentials it contains. To enable it,
+// set the environment variable on the backend controller.
+func CredentialsSecretReferenceEnabled() bool {
+	return strings.EqualFold(os.Getenv("ENABLE_CREDENTIALS_SECRET_REFERENCE"), "true")
+}
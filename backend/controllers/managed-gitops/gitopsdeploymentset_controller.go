@@ -0,0 +1,382 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package managedgitops
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	managedgitopsv1alpha1 "github.com/redhat-appstudio/managed-gitops/backend-shared/apis/managed-gitops/v1alpha1"
+	sharedutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util"
+	logutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util/log"
+	"github.com/redhat-appstudio/managed-gitops/backend/sharding"
+)
+
+const (
+	// gitDirectoryGeneratorRequeueInterval is how often a GitOpsDeploymentSet with a gitDirectory generator is reconciled,
+	// to detect directories that were added to (or removed from) the Git repository.
+	gitDirectoryGeneratorRequeueInterval = 3 * time.Minute
+)
+
+// GitOpsDeploymentSetReconciler reconciles a GitOpsDeploymentSet object, by generating a GitOpsDeployment for every
+// parameter set that is produced by its generators. GitOpsDeployments that are no longer generated are deleted.
+type GitOpsDeploymentSetReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// ListGitDirectories is used by the gitDirectory generator. Defaults to DefaultGitDirectoryLister, if nil.
+	ListGitDirectories GitDirectoryLister
+
+	// RequeueEvents, if non-nil, receives the GitOpsDeploymentSets that should be reconciled even though they have not
+	// changed: for example, when this backend replica becomes responsible for their namespace (see RequeueResourcesInNamespaces).
+	RequeueEvents <-chan event.GenericEvent
+}
+
+//+kubebuilder:rbac:groups=managed-gitops.redhat.com,resources=gitopsdeploymentsets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=managed-gitops.redhat.com,resources=gitopsdeploymentsets/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=managed-gitops.redhat.com,resources=gitopsdeploymentsets/finalizers,verbs=update
+//+kubebuilder:rbac:groups=managed-gitops.redhat.com,resources=gitopsdeployments,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=managed-gitops.redhat.com,resources=gitopsdeploymentmanagedenvironments,verbs=get;list;watch
+//+kubebuilder:rbac:groups=managed-gitops.redhat.com,resources=gitopsdeploymentrepositorycredentials,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+func (r *GitOpsDeploymentSetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+
+	log := log.FromContext(ctx).
+		WithName(logutil.LogLogger_managed_gitops).
+		WithValues("request", req)
+
+	rClient := sharedutil.IfEnabledSimulateUnreliableClient(r.Client)
+
+	deploymentSet := &managedgitopsv1alpha1.GitOpsDeploymentSet{}
+	if err := rClient.Get(ctx, req.NamespacedName, deploymentSet); err != nil {
+		if apierr.IsNotFound(err) {
+			// The generated GitOpsDeployments are deleted by Kubernetes garbage collection, via their owner reference
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	if deploymentSet.DeletionTimestamp != nil {
+		return ctrl.Result{}, nil
+	}
+
+	namespace := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: req.Namespace,
+		},
+	}
+	if err := rClient.Get(ctx, client.ObjectKeyFromObject(&namespace), &namespace); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// When the backend is sharded, only the replica that is responsible for the namespace reconciles it.
+	if !sharding.IsNamespaceOwnedByThisReplica(string(namespace.UID)) {
+		return ctrl.Result{}, nil
+	}
+
+	result := ctrl.Result{}
+	for _, generator := range deploymentSet.Spec.Generators {
+		if generator.GitDirectory != nil {
+			result.RequeueAfter = gitDirectoryGeneratorRequeueInterval
+		}
+	}
+
+	reconcileErr := r.reconcileGitOpsDeployments(ctx, rClient, deploymentSet, log)
+
+	if err := r.updateStatus(ctx, rClient, deploymentSet, reconcileErr, log); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	var deploymentSetErr gitOpsDeploymentSetError
+	if reconcileErr != nil && !errors.As(reconcileErr, &deploymentSetErr) {
+		// Unexpected errors (for example, errors from the K8s API) are retried
+		return ctrl.Result{}, reconcileErr
+	}
+
+	return result, nil
+}
+
+// reconcileGitOpsDeployments creates, updates and deletes the GitOpsDeployments of the GitOpsDeploymentSet.
+//
+// If the GitOpsDeployments could not be generated, no GitOpsDeployments are deleted: for example, we should not delete
+// every GitOpsDeployment of a gitDirectory generator because the Git repository is temporarily unavailable.
+func (r *GitOpsDeploymentSetReconciler) reconcileGitOpsDeployments(ctx context.Context, k8sClient client.Client,
+	deploymentSet *managedgitopsv1alpha1.GitOpsDeploymentSet, log logr.Logger) error {
+
+	gitDirectoryLister := r.ListGitDirectories
+	if gitDirectoryLister == nil {
+		gitDirectoryLister = DefaultGitDirectoryLister
+	}
+
+	paramSets, err := generateParameters(ctx, k8sClient, gitDirectoryLister, *deploymentSet)
+	if err != nil {
+		return err
+	}
+
+	desired := map[string]*managedgitopsv1alpha1.GitOpsDeployment{}
+	for _, params := range paramSets {
+
+		gitopsDepl, err := renderGitOpsDeployment(*deploymentSet, params)
+		if err != nil {
+			return err
+		}
+
+		if _, exists := desired[gitopsDepl.Name]; exists {
+			return newGitOpsDeploymentSetError(managedgitopsv1alpha1.GitOpsDeploymentSetReasonInvalidTemplate,
+				"the template generates more than one GitOpsDeployment named '%s': the name must contain parameters which are unique for every parameter set", gitopsDepl.Name)
+		}
+
+		if err := controllerutil.SetControllerReference(deploymentSet, gitopsDepl, r.Scheme); err != nil {
+			return err
+		}
+
+		desired[gitopsDepl.Name] = gitopsDepl
+	}
+
+	var nameConflictErr error
+
+	for _, gitopsDepl := range desired {
+
+		existing := &managedgitopsv1alpha1.GitOpsDeployment{}
+		if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(gitopsDepl), existing); err != nil {
+			if !apierr.IsNotFound(err) {
+				return err
+			}
+
+			if err := k8sClient.Create(ctx, gitopsDepl); err != nil {
+				return err
+			}
+			logutil.LogAPIResourceChangeEvent(gitopsDepl.Namespace, gitopsDepl.Name, gitopsDepl, logutil.ResourceCreated, log)
+			continue
+		}
+
+		if !metav1.IsControlledBy(existing, deploymentSet) {
+			// Don't modify a GitOpsDeployment that was not generated by this GitOpsDeploymentSet
+			nameConflictErr = newGitOpsDeploymentSetError(managedgitopsv1alpha1.GitOpsDeploymentSetReasonNameConflict,
+				"GitOpsDeployment '%s' already exists, and was not generated by this GitOpsDeploymentSet", existing.Name)
+			continue
+		}
+
+		if reflect.DeepEqual(existing.Spec, gitopsDepl.Spec) &&
+			reflect.DeepEqual(existing.Labels, gitopsDepl.Labels) &&
+			reflect.DeepEqual(existing.Annotations, gitopsDepl.Annotations) {
+			continue
+		}
+
+		existing.Spec = gitopsDepl.Spec
+		existing.Labels = gitopsDepl.Labels
+		existing.Annotations = gitopsDepl.Annotations
+
+		if err := k8sClient.Update(ctx, existing); err != nil {
+			return err
+		}
+		logutil.LogAPIResourceChangeEvent(existing.Namespace, existing.Name, existing, logutil.ResourceModified, log)
+	}
+
+	// Delete the GitOpsDeployments which were generated by this GitOpsDeploymentSet, but are no longer generated
+	owned, err := listOwnedGitOpsDeployments(ctx, k8sClient, *deploymentSet)
+	if err != nil {
+		return err
+	}
+
+	for idx := range owned {
+		gitopsDepl := owned[idx]
+
+		if _, exists := desired[gitopsDepl.Name]; exists {
+			continue
+		}
+
+		if err := k8sClient.Delete(ctx, &gitopsDepl); err != nil && !apierr.IsNotFound(err) {
+			return err
+		}
+		logutil.LogAPIResourceChangeEvent(gitopsDepl.Namespace, gitopsDepl.Name, gitopsDepl, logutil.ResourceDeleted, log)
+	}
+
+	return nameConflictErr
+}
+
+// listOwnedGitOpsDeployments returns the GitOpsDeployments that were generated by the GitOpsDeploymentSet.
+func listOwnedGitOpsDeployments(ctx context.Context, k8sClient client.Client,
+	deploymentSet managedgitopsv1alpha1.GitOpsDeploymentSet) ([]managedgitopsv1alpha1.GitOpsDeployment, error) {
+
+	var gitopsDeplList managedgitopsv1alpha1.GitOpsDeploymentList
+	if err := k8sClient.List(ctx, &gitopsDeplList, &client.ListOptions{Namespace: deploymentSet.Namespace},
+		client.MatchingLabels{managedgitopsv1alpha1.GitOpsDeploymentSetLabel: gitOpsDeploymentSetLabelValue(deploymentSet.Name)}); err != nil {
+		return nil, err
+	}
+
+	res := []managedgitopsv1alpha1.GitOpsDeployment{}
+	for _, gitopsDepl := range gitopsDeplList.Items {
+		if metav1.IsControlledBy(&gitopsDepl, &deploymentSet) {
+			res = append(res, gitopsDepl)
+		}
+	}
+
+	return res, nil
+}
+
+// updateStatus updates the ErrorOccurred condition of the GitOpsDeploymentSet, and the summary of the status of the
+// GitOpsDeployments that it generated.
+func (r *GitOpsDeploymentSetReconciler) updateStatus(ctx context.Context, k8sClient client.Client,
+	deploymentSet *managedgitopsv1alpha1.GitOpsDeploymentSet, reconcileErr error, log logr.Logger) error {
+
+	owned, err := listOwnedGitOpsDeployments(ctx, k8sClient, *deploymentSet)
+	if err != nil {
+		return err
+	}
+
+	newStatus := deploymentSet.Status.DeepCopy()
+	newStatus.Summary = managedgitopsv1alpha1.GitOpsDeploymentSetSummary{}
+	newStatus.Deployments = nil
+
+	for _, gitopsDepl := range owned {
+
+		newStatus.Deployments = append(newStatus.Deployments, managedgitopsv1alpha1.GitOpsDeploymentSetDeploymentStatus{
+			Name:   gitopsDepl.Name,
+			Sync:   gitopsDepl.Status.Sync.Status,
+			Health: gitopsDepl.Status.Health.Status,
+		})
+
+		newStatus.Summary.Total++
+		if gitopsDepl.Status.Sync.Status == managedgitopsv1alpha1.SyncStatusCodeSynced {
+			newStatus.Summary.Synced++
+		}
+		if gitopsDepl.Status.Health.Status == managedgitopsv1alpha1.HeathStatusCodeHealthy {
+			newStatus.Summary.Healthy++
+		}
+	}
+
+	errorOccurredCondition := metav1.Condition{
+		Type:    managedgitopsv1alpha1.GitOpsDeploymentSetConditionErrorOccurred,
+		Status:  metav1.ConditionFalse,
+		Reason:  managedgitopsv1alpha1.GitOpsDeploymentSetReasonSucceeded,
+		Message: "",
+	}
+	if reconcileErr != nil {
+		errorOccurredCondition.Status = metav1.ConditionTrue
+		errorOccurredCondition.Reason = managedgitopsv1alpha1.GitOpsDeploymentSetReasonKubeError
+		errorOccurredCondition.Message = reconcileErr.Error()
+
+		var deploymentSetErr gitOpsDeploymentSetError
+		if errors.As(reconcileErr, &deploymentSetErr) {
+			errorOccurredCondition.Reason = deploymentSetErr.reason
+		}
+	}
+	apimeta.SetStatusCondition(&newStatus.Conditions, errorOccurredCondition)
+
+	if reflect.DeepEqual(*newStatus, deploymentSet.Status) {
+		return nil
+	}
+
+	deploymentSet.Status = *newStatus
+	if err := k8sClient.Status().Update(ctx, deploymentSet); err != nil {
+		return fmt.Errorf("unable to update status of GitOpsDeploymentSet: %v", err)
+	}
+	log.V(logutil.LogLevel_Debug).Info("updated status of GitOpsDeploymentSet")
+
+	return nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *GitOpsDeploymentSetReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	controllerBuilder := ctrl.NewControllerManagedBy(mgr).
+		For(&managedgitopsv1alpha1.GitOpsDeploymentSet{},
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		// The sync/health status of the generated GitOpsDeployments is summarized in the status of the GitOpsDeploymentSet:
+		// other status-only updates of the GitOpsDeployments are ignored.
+		Owns(&managedgitopsv1alpha1.GitOpsDeployment{},
+			builder.WithPredicates(predicate.Or(predicate.GenerationChangedPredicate{}, summarizedStatusChangedPredicate()))).
+		Watches(
+			&source.Kind{Type: &managedgitopsv1alpha1.GitOpsDeploymentManagedEnvironment{}},
+			handler.EnqueueRequestsFromMapFunc(r.findGitOpsDeploymentSetsForManagedEnvironment))
+
+	if r.RequeueEvents != nil {
+		controllerBuilder = controllerBuilder.Watches(&source.Channel{Source: r.RequeueEvents}, &handler.EnqueueRequestForObject{})
+	}
+
+	return controllerBuilder.Complete(r)
+}
+
+// summarizedStatusChangedPredicate passes updates of a GitOpsDeployment that change the sync or health status, which
+// are summarized in the status of the owning GitOpsDeploymentSet.
+func summarizedStatusChangedPredicate() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldDepl, oldOk := e.ObjectOld.(*managedgitopsv1alpha1.GitOpsDeployment)
+			newDepl, newOk := e.ObjectNew.(*managedgitopsv1alpha1.GitOpsDeployment)
+			if !oldOk || !newOk {
+				return false
+			}
+			return oldDepl.Status.Sync.Status != newDepl.Status.Sync.Status ||
+				oldDepl.Status.Health.Status != newDepl.Status.Health.Status
+		},
+	}
+}
+
+// findGitOpsDeploymentSetsForManagedEnvironment returns the GitOpsDeploymentSets (in the namespace of the
+// GitOpsDeploymentManagedEnvironment) which have a managedEnvironment generator.
+func (r *GitOpsDeploymentSetReconciler) findGitOpsDeploymentSetsForManagedEnvironment(managedEnv client.Object) []reconcile.Request {
+	ctx := context.Background()
+	handlerLog := log.FromContext(ctx).
+		WithName(logutil.LogLogger_managed_gitops)
+
+	var deploymentSetList managedgitopsv1alpha1.GitOpsDeploymentSetList
+	if err := r.List(ctx, &deploymentSetList, &client.ListOptions{Namespace: managedEnv.GetNamespace()}); err != nil {
+		handlerLog.Error(err, "unable to list GitOpsDeploymentSets")
+		return []reconcile.Request{}
+	}
+
+	requests := []reconcile.Request{}
+
+	for idx := range deploymentSetList.Items {
+		deploymentSet := deploymentSetList.Items[idx]
+
+		for _, generator := range deploymentSet.Spec.Generators {
+			if generator.ManagedEnvironment != nil {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: client.ObjectKeyFromObject(&deploymentSet),
+				})
+				break
+			}
+		}
+	}
+
+	return requests
+}
@@ -0,0 +1,414 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package managedgitops
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	managedgitopsv1alpha1 "github.com/redhat-appstudio/managed-gitops/backend-shared/apis/managed-gitops/v1alpha1"
+	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/tests"
+	corev1 "k8s.io/api/core/v1"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/apimachinery/pkg/util/validation"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+var _ = Describe("GitOpsDeploymentSet Controller Test", func() {
+
+	Context("Generic tests", func() {
+
+		var ctx context.Context
+		var k8sClient client.Client
+		var namespace *corev1.Namespace
+		var reconciler GitOpsDeploymentSetReconciler
+
+		// gitDirectories and gitDirectoriesErr are returned by the mock GitDirectoryLister
+		var gitDirectories []string
+		var gitDirectoriesErr error
+
+		BeforeEach(func() {
+			ctx = context.Background()
+
+			scheme, argocdNamespace, kubesystemNamespace, _, err := tests.GenericTestSetup()
+			Expect(err).ToNot(HaveOccurred())
+
+			namespace = &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "my-user",
+					UID:  uuid.NewUUID(),
+				},
+			}
+
+			k8sClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(argocdNamespace, kubesystemNamespace, namespace).Build()
+
+			gitDirectories = nil
+			gitDirectoriesErr = nil
+
+			reconciler = GitOpsDeploymentSetReconciler{
+				Client: k8sClient,
+				Scheme: scheme,
+				ListGitDirectories: func(ctx context.Context, repoURL string, revision string, secret *corev1.Secret) ([]string, error) {
+					return gitDirectories, gitDirectoriesErr
+				},
+			}
+		})
+
+		newGitOpsDeploymentSet := func(generators ...managedgitopsv1alpha1.GitOpsDeploymentSetGenerator) *managedgitopsv1alpha1.GitOpsDeploymentSet {
+			return &managedgitopsv1alpha1.GitOpsDeploymentSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "my-deployment-set",
+					Namespace: namespace.Name,
+					UID:       uuid.NewUUID(),
+				},
+				Spec: managedgitopsv1alpha1.GitOpsDeploymentSetSpec{
+					Generators: generators,
+					Template: managedgitopsv1alpha1.GitOpsDeploymentSetTemplate{
+						Metadata: managedgitopsv1alpha1.GitOpsDeploymentSetTemplateMeta{
+							Name:   "{{env}}-deployment",
+							Labels: map[string]string{"env": "{{env}}"},
+						},
+						Spec: managedgitopsv1alpha1.GitOpsDeploymentSpec{
+							Source: managedgitopsv1alpha1.ApplicationSource{
+								RepoURL: "https://github.com/redhat-appstudio/managed-gitops",
+								Path:    "environments/{{ env }}",
+							},
+							Destination: managedgitopsv1alpha1.ApplicationDestination{
+								Namespace: "{{env}}",
+							},
+							Type: managedgitopsv1alpha1.GitOpsDeploymentSpecType_Automated,
+						},
+					},
+				},
+			}
+		}
+
+		listGenerator := func(envs ...string) managedgitopsv1alpha1.GitOpsDeploymentSetGenerator {
+			elements := []map[string]string{}
+			for _, env := range envs {
+				elements = append(elements, map[string]string{"env": env})
+			}
+			return managedgitopsv1alpha1.GitOpsDeploymentSetGenerator{
+				List: &managedgitopsv1alpha1.ListGenerator{Elements: elements},
+			}
+		}
+
+		reconcileSet := func(deploymentSet *managedgitopsv1alpha1.GitOpsDeploymentSet) ctrl.Result {
+			res, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deploymentSet)})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(deploymentSet), deploymentSet)).To(Succeed())
+			return res
+		}
+
+		listGeneratedNames := func(deploymentSet *managedgitopsv1alpha1.GitOpsDeploymentSet) []string {
+			owned, err := listOwnedGitOpsDeployments(ctx, k8sClient, *deploymentSet)
+			Expect(err).ToNot(HaveOccurred())
+			names := []string{}
+			for _, gitopsDepl := range owned {
+				names = append(names, gitopsDepl.Name)
+			}
+			return names
+		}
+
+		expectErrorOccurredCondition := func(deploymentSet *managedgitopsv1alpha1.GitOpsDeploymentSet, status metav1.ConditionStatus, reason string) {
+			condition := apimeta.FindStatusCondition(deploymentSet.Status.Conditions, managedgitopsv1alpha1.GitOpsDeploymentSetConditionErrorOccurred)
+			Expect(condition).ToNot(BeNil())
+			Expect(condition.Status).To(Equal(status))
+			Expect(condition.Reason).To(Equal(reason))
+		}
+
+		It("should generate a GitOpsDeployment for every element of a list generator, and prune those that are no longer generated", func() {
+
+			deploymentSet := newGitOpsDeploymentSet(listGenerator("staging", "prod"))
+			Expect(k8sClient.Create(ctx, deploymentSet)).To(Succeed())
+
+			reconcileSet(deploymentSet)
+
+			By("verifying the generated GitOpsDeployments")
+			Expect(listGeneratedNames(deploymentSet)).To(ConsistOf("staging-deployment", "prod-deployment"))
+
+			gitopsDepl := &managedgitopsv1alpha1.GitOpsDeployment{}
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace.Name, Name: "staging-deployment"}, gitopsDepl)).To(Succeed())
+			Expect(gitopsDepl.Spec.Source.Path).To(Equal("environments/staging"))
+			Expect(gitopsDepl.Spec.Destination.Namespace).To(Equal("staging"))
+			Expect(gitopsDepl.Labels).To(HaveKeyWithValue("env", "staging"))
+			Expect(gitopsDepl.Labels).To(HaveKeyWithValue(managedgitopsv1alpha1.GitOpsDeploymentSetLabel, deploymentSet.Name))
+			Expect(metav1.IsControlledBy(gitopsDepl, deploymentSet)).To(BeTrue())
+
+			By("verifying the status summary")
+			expectErrorOccurredCondition(deploymentSet, metav1.ConditionFalse, managedgitopsv1alpha1.GitOpsDeploymentSetReasonSucceeded)
+			Expect(deploymentSet.Status.Summary.Total).To(Equal(2))
+			Expect(deploymentSet.Status.Deployments).To(HaveLen(2))
+
+			By("reporting the status of the generated GitOpsDeployments in the summary")
+			gitopsDepl.Status.Sync.Status = managedgitopsv1alpha1.SyncStatusCodeSynced
+			gitopsDepl.Status.Health.Status = managedgitopsv1alpha1.HeathStatusCodeHealthy
+			Expect(k8sClient.Status().Update(ctx, gitopsDepl)).To(Succeed())
+
+			reconcileSet(deploymentSet)
+			Expect(deploymentSet.Status.Summary).To(Equal(managedgitopsv1alpha1.GitOpsDeploymentSetSummary{Total: 2, Synced: 1, Healthy: 1}))
+
+			By("removing an element, and updating the template")
+			deploymentSet.Spec.Generators = []managedgitopsv1alpha1.GitOpsDeploymentSetGenerator{listGenerator("staging")}
+			deploymentSet.Spec.Template.Spec.Source.Path = "overlays/{{env}}"
+			Expect(k8sClient.Update(ctx, deploymentSet)).To(Succeed())
+
+			reconcileSet(deploymentSet)
+
+			Expect(listGeneratedNames(deploymentSet)).To(ConsistOf("staging-deployment"))
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(gitopsDepl), gitopsDepl)).To(Succeed())
+			Expect(gitopsDepl.Spec.Source.Path).To(Equal("overlays/staging"))
+
+			err := k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace.Name, Name: "prod-deployment"}, &managedgitopsv1alpha1.GitOpsDeployment{})
+			Expect(apierr.IsNotFound(err)).To(BeTrue())
+			Expect(deploymentSet.Status.Summary.Total).To(Equal(1))
+		})
+
+		It("should generate a GitOpsDeployment for every matching directory of a gitDirectory generator", func() {
+
+			gitDirectories = []string{"environments", "environments/staging", "environments/prod", "environments/test", "components"}
+
+			deploymentSet := newGitOpsDeploymentSet(managedgitopsv1alpha1.GitOpsDeploymentSetGenerator{
+				GitDirectory: &managedgitopsv1alpha1.GitDirectoryGenerator{
+					RepoURL: "https://github.com/redhat-appstudio/managed-gitops",
+					Directories: []managedgitopsv1alpha1.GitDirectoryGeneratorItem{
+						{Path: "environments/*"},
+						{Path: "environments/test", Exclude: true},
+					},
+				},
+			})
+			deploymentSet.Spec.Template.Metadata.Name = "{{path.basename}}-deployment"
+			deploymentSet.Spec.Template.Spec.Source.Path = "{{path}}"
+			Expect(k8sClient.Create(ctx, deploymentSet)).To(Succeed())
+
+			res := reconcileSet(deploymentSet)
+			Expect(res.RequeueAfter).To(Equal(gitDirectoryGeneratorRequeueInterval))
+
+			Expect(listGeneratedNames(deploymentSet)).To(ConsistOf("staging-deployment", "prod-deployment"))
+
+			gitopsDepl := &managedgitopsv1alpha1.GitOpsDeployment{}
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace.Name, Name: "prod-deployment"}, gitopsDepl)).To(Succeed())
+			Expect(gitopsDepl.Spec.Source.Path).To(Equal("environments/prod"))
+
+			By("failing to list the directories of the repository, the GitOpsDeployments should not be pruned")
+			gitDirectoriesErr = fmt.Errorf("repository is unavailable")
+			deploymentSet.Spec.Template.Metadata.Labels = map[string]string{"updated": "true"}
+			Expect(k8sClient.Update(ctx, deploymentSet)).To(Succeed())
+
+			reconcileSet(deploymentSet)
+
+			Expect(listGeneratedNames(deploymentSet)).To(ConsistOf("staging-deployment", "prod-deployment"))
+			expectErrorOccurredCondition(deploymentSet, metav1.ConditionTrue, managedgitopsv1alpha1.GitOpsDeploymentSetReasonGeneratorError)
+		})
+
+		It("should generate a GitOpsDeployment for every GitOpsDeploymentManagedEnvironment that matches the selector", func() {
+
+			for _, name := range []string{"staging", "prod", "other"} {
+				managedEnv := &managedgitopsv1alpha1.GitOpsDeploymentManagedEnvironment{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      name,
+						Namespace: namespace.Name,
+						Labels:    map[string]string{"tier": "production"},
+					},
+					Spec: managedgitopsv1alpha1.GitOpsDeploymentManagedEnvironmentSpec{
+						APIURL: "https://" + name + ".example.com:6443",
+					},
+				}
+				if name == "other" {
+					managedEnv.Labels = map[string]string{"tier": "development"}
+				}
+				Expect(k8sClient.Create(ctx, managedEnv)).To(Succeed())
+			}
+
+			deploymentSet := newGitOpsDeploymentSet(managedgitopsv1alpha1.GitOpsDeploymentSetGenerator{
+				ManagedEnvironment: &managedgitopsv1alpha1.ManagedEnvironmentGenerator{
+					Selector: metav1.LabelSelector{MatchLabels: map[string]string{"tier": "production"}},
+				},
+			})
+			deploymentSet.Spec.Template.Metadata.Name = "{{name}}-deployment"
+			deploymentSet.Spec.Template.Spec.Destination.Environment = "{{name}}"
+			deploymentSet.Spec.Template.Spec.Destination.Namespace = "{{labels.tier}}"
+			Expect(k8sClient.Create(ctx, deploymentSet)).To(Succeed())
+
+			reconcileSet(deploymentSet)
+
+			Expect(listGeneratedNames(deploymentSet)).To(ConsistOf("staging-deployment", "prod-deployment"))
+
+			gitopsDepl := &managedgitopsv1alpha1.GitOpsDeployment{}
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace.Name, Name: "prod-deployment"}, gitopsDepl)).To(Succeed())
+			Expect(gitopsDepl.Spec.Destination.Environment).To(Equal("prod"))
+			Expect(gitopsDepl.Spec.Destination.Namespace).To(Equal("production"))
+
+			By("verifying that the GitOpsDeploymentSet is reconciled when a GitOpsDeploymentManagedEnvironment changes")
+			requests := reconciler.findGitOpsDeploymentSetsForManagedEnvironment(&managedgitopsv1alpha1.GitOpsDeploymentManagedEnvironment{
+				ObjectMeta: metav1.ObjectMeta{Name: "staging", Namespace: namespace.Name},
+			})
+			Expect(requests).To(HaveLen(1))
+			Expect(requests[0].NamespacedName).To(Equal(client.ObjectKeyFromObject(deploymentSet)))
+		})
+
+		It("should not modify a GitOpsDeployment that was not generated by the GitOpsDeploymentSet", func() {
+
+			existing := &managedgitopsv1alpha1.GitOpsDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "staging-deployment",
+					Namespace: namespace.Name,
+				},
+				Spec: managedgitopsv1alpha1.GitOpsDeploymentSpec{
+					Source: managedgitopsv1alpha1.ApplicationSource{
+						RepoURL: "https://github.com/redhat-appstudio/managed-gitops",
+						Path:    "user-path",
+					},
+					Type: managedgitopsv1alpha1.GitOpsDeploymentSpecType_Manual,
+				},
+			}
+			Expect(k8sClient.Create(ctx, existing)).To(Succeed())
+
+			deploymentSet := newGitOpsDeploymentSet(listGenerator("staging", "prod"))
+			Expect(k8sClient.Create(ctx, deploymentSet)).To(Succeed())
+
+			reconcileSet(deploymentSet)
+
+			Expect(listGeneratedNames(deploymentSet)).To(ConsistOf("prod-deployment"))
+			expectErrorOccurredCondition(deploymentSet, metav1.ConditionTrue, managedgitopsv1alpha1.GitOpsDeploymentSetReasonNameConflict)
+
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(existing), existing)).To(Succeed())
+			Expect(existing.Spec.Source.Path).To(Equal("user-path"))
+		})
+
+		It("should report an error if the generators or template are invalid", func() {
+
+			By("specifying a generator without any fields")
+			deploymentSet := newGitOpsDeploymentSet(managedgitopsv1alpha1.GitOpsDeploymentSetGenerator{})
+			Expect(k8sClient.Create(ctx, deploymentSet)).To(Succeed())
+
+			reconcileSet(deploymentSet)
+			expectErrorOccurredCondition(deploymentSet, metav1.ConditionTrue, managedgitopsv1alpha1.GitOpsDeploymentSetReasonInvalidGenerator)
+
+			By("specifying a template that generates the same name for every parameter set")
+			deploymentSet.Spec.Generators = []managedgitopsv1alpha1.GitOpsDeploymentSetGenerator{listGenerator("staging", "prod")}
+			deploymentSet.Spec.Template.Metadata.Name = "my-deployment"
+			Expect(k8sClient.Update(ctx, deploymentSet)).To(Succeed())
+
+			reconcileSet(deploymentSet)
+			expectErrorOccurredCondition(deploymentSet, metav1.ConditionTrue, managedgitopsv1alpha1.GitOpsDeploymentSetReasonInvalidTemplate)
+			Expect(listGeneratedNames(deploymentSet)).To(BeEmpty())
+
+			By("specifying a gitDirectory generator with a repository inside of the cluster")
+			deploymentSet.Spec.Generators = []managedgitopsv1alpha1.GitOpsDeploymentSetGenerator{{
+				GitDirectory: &managedgitopsv1alpha1.GitDirectoryGenerator{
+					RepoURL:     "https://gitea.my-namespace.svc/org/repo",
+					Directories: []managedgitopsv1alpha1.GitDirectoryGeneratorItem{{Path: "*"}},
+				},
+			}}
+			Expect(k8sClient.Update(ctx, deploymentSet)).To(Succeed())
+
+			reconcileSet(deploymentSet)
+			expectErrorOccurredCondition(deploymentSet, metav1.ConditionTrue, managedgitopsv1alpha1.GitOpsDeploymentSetReasonInvalidGenerator)
+		})
+
+		It("should label the generated GitOpsDeployments with a valid label value, when the name of the GitOpsDeploymentSet is too long", func() {
+
+			deploymentSet := newGitOpsDeploymentSet(listGenerator("staging"))
+			deploymentSet.Name = strings.Repeat("my-long-deployment-set-name.", 4) + "end"
+			Expect(k8sClient.Create(ctx, deploymentSet)).To(Succeed())
+
+			reconcileSet(deploymentSet)
+
+			Expect(listGeneratedNames(deploymentSet)).To(ConsistOf("staging-deployment"))
+
+			gitopsDepl := &managedgitopsv1alpha1.GitOpsDeployment{}
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace.Name, Name: "staging-deployment"}, gitopsDepl)).To(Succeed())
+			labelValue := gitopsDepl.Labels[managedgitopsv1alpha1.GitOpsDeploymentSetLabel]
+			Expect(validation.IsValidLabelValue(labelValue)).To(BeEmpty())
+			Expect(labelValue).To(HavePrefix("my-long-deployment-set-name."))
+			Expect(labelValue).ToNot(Equal(gitOpsDeploymentSetLabelValue(deploymentSet.Name + "-other")))
+		})
+	})
+
+	Context("Template rendering", func() {
+
+		It("should replace known parameters, and leave unknown parameters unchanged", func() {
+			params := map[string]string{"env": "staging", "path.basename": "dev"}
+
+			Expect(renderString("{{env}}-{{ path.basename }}", params)).To(Equal("staging-dev"))
+			Expect(renderString("{{unknown}}", params)).To(Equal("{{unknown}}"))
+			Expect(renderString("no-parameters", params)).To(Equal("no-parameters"))
+		})
+
+		It("should only allow https and ssh repository URLs, on hosts outside of the cluster", func() {
+			for repoURL, expectedHost := range map[string]string{
+				"https://github.com/redhat-appstudio/managed-gitops":   "github.com",
+				"ssh://git@github.com/redhat-appstudio/managed-gitops": "github.com",
+				"git@gitlab.example.com:org/repo.git":                  "gitlab.example.com",
+			} {
+				host, err := gitRepositoryHost(repoURL)
+				Expect(err).ToNot(HaveOccurred(), repoURL)
+				Expect(host).To(Equal(expectedHost))
+			}
+
+			for _, repoURL := range []string{"http://github.com/org/repo", "file:///tmp/repo", "git://github.com/org/repo",
+				"/tmp/repo", "https://localhost/org/repo", "ssh://git@gitea:2222/org/repo", "git@gitea.my-namespace.svc:org/repo.git"} {
+				_, err := gitRepositoryHost(repoURL)
+				Expect(err).To(HaveOccurred(), repoURL)
+			}
+		})
+
+		It("should filter the directories using the included and excluded paths", func() {
+			directories := []string{"b/two", "a", "b/one", "b", "c/one"}
+
+			Expect(filterGitDirectories(directories, []managedgitopsv1alpha1.GitDirectoryGeneratorItem{
+				{Path: "b/*"},
+				{Path: "a"},
+				{Path: "b/two", Exclude: true},
+			})).To(Equal([]string{"a", "b/one"}))
+		})
+	})
+
+	Context("Watched GitOpsDeployments", func() {
+
+		It("should only pass status updates of a generated GitOpsDeployment that change its sync or health status", func() {
+			oldDepl := &managedgitopsv1alpha1.GitOpsDeployment{}
+			oldDepl.Status.Sync.Status = managedgitopsv1alpha1.SyncStatusCodeOutOfSync
+			oldDepl.Status.Health.Status = managedgitopsv1alpha1.HeathStatusCodeProgressing
+
+			By("ignoring a status update that changes neither")
+			newDepl := oldDepl.DeepCopy()
+			newDepl.Status.ReconciledState.Source.Path = "resources"
+			Expect(summarizedStatusChangedPredicate().Update(event.UpdateEvent{ObjectOld: oldDepl, ObjectNew: newDepl})).To(BeFalse())
+
+			By("passing a status update that changes the sync status")
+			newDepl = oldDepl.DeepCopy()
+			newDepl.Status.Sync.Status = managedgitopsv1alpha1.SyncStatusCodeSynced
+			Expect(summarizedStatusChangedPredicate().Update(event.UpdateEvent{ObjectOld: oldDepl, ObjectNew: newDepl})).To(BeTrue())
+
+			By("passing a status update that changes the health status")
+			newDepl = oldDepl.DeepCopy()
+			newDepl.Status.Health.Status = managedgitopsv1alpha1.HeathStatusCodeHealthy
+			Expect(summarizedStatusChangedPredicate().Update(event.UpdateEvent{ObjectOld: oldDepl, ObjectNew: newDepl})).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,526 @@
+package managedgitops
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/go-git/go-git/v5/storage/memory"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	managedgitopsv1alpha1 "github.com/redhat-appstudio/managed-gitops/backend-shared/apis/managed-gitops/v1alpha1"
+	sharedutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util"
+	"github.com/redhat-appstudio/managed-gitops/backend/eventloop/shared_resource_loop"
+)
+
+// gitOpsDeploymentSetError is an error that prevents the GitOpsDeployments of a GitOpsDeploymentSet from being generated.
+// The reason is reported in the ErrorOccurred condition of the GitOpsDeploymentSet.
+type gitOpsDeploymentSetError struct {
+	reason string
+	err    error
+}
+
+func (e gitOpsDeploymentSetError) Error() string {
+	return e.err.Error()
+}
+
+func newGitOpsDeploymentSetError(reason string, format string, a ...any) gitOpsDeploymentSetError {
+	return gitOpsDeploymentSetError{reason: reason, err: fmt.Errorf(format, a...)}
+}
+
+// GitDirectoryLister returns the paths of all the directories of a Git repository, at the given revision (the default
+// branch, if empty). 'secret' contains the credentials of the repository, and is nil for a public repository.
+type GitDirectoryLister func(ctx context.Context, repoURL string, revision string, secret *corev1.Secret) ([]string, error)
+
+var (
+	// DefaultGitDirectoryLister is the GitDirectoryLister used everywhere (except unit tests)
+	DefaultGitDirectoryLister GitDirectoryLister = listGitDirectories
+)
+
+const (
+	// gitDirectoryListTimeout is the maximum time taken to clone a repository, to list its directories
+	gitDirectoryListTimeout = 2 * time.Minute
+
+	// maxGitDirectoryCloneSize is the maximum (uncompressed) size of the objects of the in memory clone of a repository
+	maxGitDirectoryCloneSize = 256 * 1024 * 1024
+
+	// maxLabelValueLength is the maximum length of a label value
+	maxLabelValueLength = 63
+)
+
+// generateParameters returns the parameter sets that are produced by the generators of the GitOpsDeploymentSet.
+func generateParameters(ctx context.Context, k8sClient client.Client, gitDirectoryLister GitDirectoryLister,
+	deploymentSet managedgitopsv1alpha1.GitOpsDeploymentSet) ([]map[string]string, error) {
+
+	res := []map[string]string{}
+
+	for idx, generator := range deploymentSet.Spec.Generators {
+
+		specified := 0
+		for _, isSet := range []bool{generator.List != nil, generator.GitDirectory != nil, generator.ManagedEnvironment != nil} {
+			if isSet {
+				specified++
+			}
+		}
+		if specified != 1 {
+			return nil, newGitOpsDeploymentSetError(managedgitopsv1alpha1.GitOpsDeploymentSetReasonInvalidGenerator,
+				"exactly one of list, gitDirectory and managedEnvironment must be specified in .spec.generators[%d]", idx)
+		}
+
+		var params []map[string]string
+		var err error
+
+		if generator.List != nil {
+			params = generateListParameters(*generator.List)
+
+		} else if generator.GitDirectory != nil {
+			params, err = generateGitDirectoryParameters(ctx, k8sClient, gitDirectoryLister, deploymentSet.Namespace, *generator.GitDirectory)
+
+		} else if generator.ManagedEnvironment != nil {
+			params, err = generateManagedEnvironmentParameters(ctx, k8sClient, deploymentSet.Namespace, *generator.ManagedEnvironment)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, params...)
+	}
+
+	return res, nil
+}
+
+func generateListParameters(generator managedgitopsv1alpha1.ListGenerator) []map[string]string {
+
+	res := []map[string]string{}
+
+	for _, element := range generator.Elements {
+		params := map[string]string{}
+		for key, value := range element {
+			params[key] = value
+		}
+		res = append(res, params)
+	}
+
+	return res
+}
+
+func generateGitDirectoryParameters(ctx context.Context, k8sClient client.Client, gitDirectoryLister GitDirectoryLister,
+	namespace string, generator managedgitopsv1alpha1.GitDirectoryGenerator) ([]map[string]string, error) {
+
+	if generator.RepoURL == "" {
+		return nil, newGitOpsDeploymentSetError(managedgitopsv1alpha1.GitOpsDeploymentSetReasonInvalidGenerator,
+			"repoURL must be specified in gitDirectory generator")
+	}
+
+	if _, err := gitRepositoryHost(generator.RepoURL); err != nil {
+		return nil, newGitOpsDeploymentSetError(managedgitopsv1alpha1.GitOpsDeploymentSetReasonInvalidGenerator,
+			"invalid repoURL in gitDirectory generator: %v", err)
+	}
+
+	for _, item := range generator.Directories {
+		if _, err := path.Match(item.Path, ""); err != nil {
+			return nil, newGitOpsDeploymentSetError(managedgitopsv1alpha1.GitOpsDeploymentSetReasonInvalidGenerator,
+				"invalid path '%s' in gitDirectory generator: %v", item.Path, err)
+		}
+	}
+
+	secret, err := getRepositoryCredentialSecret(ctx, k8sClient, namespace, generator.RepoURL)
+	if err != nil {
+		return nil, err
+	}
+
+	directories, err := gitDirectoryLister(ctx, generator.RepoURL, generator.Revision, secret)
+	if err != nil {
+		return nil, newGitOpsDeploymentSetError(managedgitopsv1alpha1.GitOpsDeploymentSetReasonGeneratorError,
+			"unable to list the directories of repository '%s': %v", generator.RepoURL, err)
+	}
+
+	res := []map[string]string{}
+	for _, directory := range filterGitDirectories(directories, generator.Directories) {
+		res = append(res, map[string]string{
+			"path":          directory,
+			"path.basename": path.Base(directory),
+		})
+	}
+
+	return res, nil
+}
+
+// filterGitDirectories returns the (sorted) directories which match at least one of the included paths, and none of the
+// excluded paths.
+func filterGitDirectories(directories []string, items []managedgitopsv1alpha1.GitDirectoryGeneratorItem) []string {
+
+	res := []string{}
+
+	for _, directory := range directories {
+
+		included := false
+		excluded := false
+
+		for _, item := range items {
+			if matches, _ := path.Match(item.Path, directory); !matches {
+				continue
+			}
+			if item.Exclude {
+				excluded = true
+			} else {
+				included = true
+			}
+		}
+
+		if included && !excluded {
+			res = append(res, directory)
+		}
+	}
+
+	sort.Strings(res)
+
+	return res
+}
+
+// getRepositoryCredentialSecret returns the Secret of the GitOpsDeploymentRepositoryCredential for the repository, in
+// the namespace, or nil if there is no such GitOpsDeploymentRepositoryCredential.
+func getRepositoryCredentialSecret(ctx context.Context, k8sClient client.Client, namespace string, repoURL string) (*corev1.Secret, error) {
+
+	var repoCredList managedgitopsv1alpha1.GitOpsDeploymentRepositoryCredentialList
+	if err := k8sClient.List(ctx, &repoCredList, &client.ListOptions{Namespace: namespace}); err != nil {
+		return nil, newGitOpsDeploymentSetError(managedgitopsv1alpha1.GitOpsDeploymentSetReasonKubeError,
+			"unable to list GitOpsDeploymentRepositoryCredentials: %v", err)
+	}
+
+	normalizedRepoURL := shared_resource_loop.NormalizeGitURL(repoURL)
+
+	for _, repoCred := range repoCredList.Items {
+
+		if shared_resource_loop.NormalizeGitURL(repoCred.Spec.Repository) != normalizedRepoURL {
+			continue
+		}
+
+		secret := &corev1.Secret{}
+		if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: repoCred.Spec.Secret}, secret); err != nil {
+			return nil, newGitOpsDeploymentSetError(managedgitopsv1alpha1.GitOpsDeploymentSetReasonKubeError,
+				"unable to retrieve Secret '%s' of GitOpsDeploymentRepositoryCredential '%s': %v", repoCred.Spec.Secret, repoCred.Name, err)
+		}
+		return secret, nil
+	}
+
+	return nil, nil
+}
+
+// gitRepositoryHost returns the host of the URL of a Git repository. The URL must be an https URL, or an ssh URL
+// (either 'ssh://' or the scp-like 'user@host:path' syntax), and the host must not be cluster-internal.
+func gitRepositoryHost(repoURL string) (string, error) {
+
+	var host string
+
+	if strings.Contains(repoURL, "://") {
+		parsedURL, err := url.Parse(repoURL)
+		if err != nil {
+			return "", fmt.Errorf("unable to parse '%s': %v", repoURL, err)
+		}
+		if parsedURL.Scheme != "https" && parsedURL.Scheme != "ssh" {
+			return "", fmt.Errorf("'%s' is not an https or ssh URL", parsedURL.Redacted())
+		}
+		host = parsedURL.Hostname()
+
+	} else {
+		// The scp-like syntax of ssh URLs, for example 'git@github.com:org/repo.git'
+		userAndHost, _, found := strings.Cut(repoURL, ":")
+		if !found || !strings.Contains(userAndHost, "@") {
+			return "", fmt.Errorf("'%s' is not an https or ssh URL", repoURL)
+		}
+		host = userAndHost[strings.LastIndex(userAndHost, "@")+1:]
+	}
+
+	if err := sharedutil.ValidateExternalHost(host); err != nil {
+		return "", err
+	}
+
+	return host, nil
+}
+
+// sizeLimitedStorage is an in memory storage of a Git repository, which returns an error once the size of its
+// objects exceeds the limit.
+type sizeLimitedStorage struct {
+	*memory.Storage
+	size  int64
+	limit int64
+}
+
+func (s *sizeLimitedStorage) SetEncodedObject(obj plumbing.EncodedObject) (plumbing.Hash, error) {
+	s.size += obj.Size()
+	if s.size > s.limit {
+		return plumbing.ZeroHash, fmt.Errorf("the repository is larger than %d bytes", s.limit)
+	}
+	return s.Storage.SetEncodedObject(obj)
+}
+
+// listGitDirectories performs a shallow (in memory) clone of the repository, and returns the paths of its directories.
+// - The repository must be on a host outside of the cluster (see gitRepositoryHost), and the clone is limited in
+// time and size.
+func listGitDirectories(ctx context.Context, repoURL string, revision string, secret *corev1.Secret) ([]string, error) {
+
+	host, err := gitRepositoryHost(repoURL)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, gitDirectoryListTimeout)
+	defer cancel()
+
+	// The Git client connects using its own transport, so the addresses of the host are checked before cloning
+	if err := sharedutil.ValidateExternalHostAddresses(ctx, host); err != nil {
+		return nil, err
+	}
+
+	newStorage := func() *sizeLimitedStorage {
+		return &sizeLimitedStorage{Storage: memory.NewStorage(), limit: maxGitDirectoryCloneSize}
+	}
+
+	var auth transport.AuthMethod
+	if secret != nil {
+		if sshKey := string(secret.Data["sshPrivateKey"]); sshKey != "" {
+			publicKeys, err := ssh.NewPublicKeys("git", []byte(sshKey), "")
+			if err != nil {
+				return nil, err
+			}
+			auth = publicKeys
+		} else {
+			auth = &http.BasicAuth{
+				Username: string(secret.Data["username"]),
+				Password: string(secret.Data["password"]),
+			}
+		}
+	}
+
+	cloneOptions := func(referenceName plumbing.ReferenceName) *git.CloneOptions {
+		return &git.CloneOptions{
+			URL:           repoURL,
+			Auth:          auth,
+			ReferenceName: referenceName,
+			SingleBranch:  true,
+			Depth:         1,
+			NoCheckout:    true,
+			Tags:          git.NoTags,
+		}
+	}
+
+	var repo *git.Repository
+
+	if revision == "" || revision == "HEAD" {
+		repo, err = git.CloneContext(ctx, newStorage(), nil, cloneOptions(""))
+	} else {
+		// The revision may be either a branch or a tag
+		repo, err = git.CloneContext(ctx, newStorage(), nil, cloneOptions(plumbing.NewBranchReferenceName(revision)))
+		if err != nil {
+			repo, err = git.CloneContext(ctx, newStorage(), nil, cloneOptions(plumbing.NewTagReferenceName(revision)))
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return nil, err
+	}
+
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	directories := []string{}
+	walker := object.NewTreeWalker(tree, true, nil)
+	defer walker.Close()
+
+	for {
+		name, entry, err := walker.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if entry.Mode == filemode.Dir {
+			directories = append(directories, name)
+		}
+	}
+
+	return directories, nil
+}
+
+func generateManagedEnvironmentParameters(ctx context.Context, k8sClient client.Client, namespace string,
+	generator managedgitopsv1alpha1.ManagedEnvironmentGenerator) ([]map[string]string, error) {
+
+	selector, err := metav1.LabelSelectorAsSelector(&generator.Selector)
+	if err != nil {
+		return nil, newGitOpsDeploymentSetError(managedgitopsv1alpha1.GitOpsDeploymentSetReasonInvalidGenerator,
+			"invalid selector in managedEnvironment generator: %v", err)
+	}
+
+	var managedEnvList managedgitopsv1alpha1.GitOpsDeploymentManagedEnvironmentList
+	if err := k8sClient.List(ctx, &managedEnvList, &client.ListOptions{Namespace: namespace}); err != nil {
+		return nil, newGitOpsDeploymentSetError(managedgitopsv1alpha1.GitOpsDeploymentSetReasonKubeError,
+			"unable to list GitOpsDeploymentManagedEnvironments: %v", err)
+	}
+
+	sort.Slice(managedEnvList.Items, func(i, j int) bool {
+		return managedEnvList.Items[i].Name < managedEnvList.Items[j].Name
+	})
+
+	res := []map[string]string{}
+	for _, managedEnv := range managedEnvList.Items {
+
+		if !selector.Matches(labels.Set(managedEnv.Labels)) {
+			continue
+		}
+
+		params := map[string]string{
+			"name":   managedEnv.Name,
+			"apiURL": managedEnv.Spec.APIURL,
+		}
+		for key, value := range managedEnv.Labels {
+			params["labels."+key] = value
+		}
+		res = append(res, params)
+	}
+
+	return res, nil
+}
+
+// renderGitOpsDeployment returns the GitOpsDeployment that is generated from the template of the GitOpsDeploymentSet,
+// for the given parameter set.
+func renderGitOpsDeployment(deploymentSet managedgitopsv1alpha1.GitOpsDeploymentSet, params map[string]string) (*managedgitopsv1alpha1.GitOpsDeployment, error) {
+
+	template := deploymentSet.Spec.Template
+
+	gitopsDepl := &managedgitopsv1alpha1.GitOpsDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        renderString(template.Metadata.Name, params),
+			Namespace:   deploymentSet.Namespace,
+			Labels:      map[string]string{},
+			Annotations: map[string]string{},
+		},
+	}
+
+	if errs := validation.IsDNS1123Subdomain(gitopsDepl.Name); len(errs) > 0 {
+		return nil, newGitOpsDeploymentSetError(managedgitopsv1alpha1.GitOpsDeploymentSetReasonInvalidTemplate,
+			"invalid GitOpsDeployment name '%s' generated from template: %s", gitopsDepl.Name, strings.Join(errs, ", "))
+	}
+
+	for key, value := range template.Metadata.Labels {
+		gitopsDepl.Labels[renderString(key, params)] = renderString(value, params)
+	}
+	for key, value := range template.Metadata.Annotations {
+		gitopsDepl.Annotations[renderString(key, params)] = renderString(value, params)
+	}
+	gitopsDepl.Labels[managedgitopsv1alpha1.GitOpsDeploymentSetLabel] = gitOpsDeploymentSetLabelValue(deploymentSet.Name)
+
+	// Replace the parameters in every string value of the spec, by converting it to (and from) JSON
+	specJSON, err := json.Marshal(template.Spec)
+	if err != nil {
+		return nil, newGitOpsDeploymentSetError(managedgitopsv1alpha1.GitOpsDeploymentSetReasonInvalidTemplate,
+			"unable to marshal template spec: %v", err)
+	}
+
+	var spec any
+	if err := json.Unmarshal(specJSON, &spec); err != nil {
+		return nil, newGitOpsDeploymentSetError(managedgitopsv1alpha1.GitOpsDeploymentSetReasonInvalidTemplate,
+			"unable to unmarshal template spec: %v", err)
+	}
+
+	if specJSON, err = json.Marshal(renderValue(spec, params)); err != nil {
+		return nil, newGitOpsDeploymentSetError(managedgitopsv1alpha1.GitOpsDeploymentSetReasonInvalidTemplate,
+			"unable to marshal rendered template spec: %v", err)
+	}
+
+	if err := json.Unmarshal(specJSON, &gitopsDepl.Spec); err != nil {
+		return nil, newGitOpsDeploymentSetError(managedgitopsv1alpha1.GitOpsDeploymentSetReasonInvalidTemplate,
+			"unable to unmarshal rendered template spec: %v", err)
+	}
+
+	return gitopsDepl, nil
+}
+
+// renderValue replaces the parameters in every string of a value that was unmarshalled from JSON.
+func renderValue(value any, params map[string]string) any {
+
+	switch typedValue := value.(type) {
+	case string:
+		return renderString(typedValue, params)
+
+	case []any:
+		for i := range typedValue {
+			typedValue[i] = renderValue(typedValue[i], params)
+		}
+		return typedValue
+
+	case map[string]any:
+		for key := range typedValue {
+			typedValue[key] = renderValue(typedValue[key], params)
+		}
+		return typedValue
+	}
+
+	return value
+}
+
+// renderString replaces '{{parameter}}' (or '{{ parameter }}') with the value of the parameter. Unknown parameters are
+// not replaced.
+func renderString(str string, params map[string]string) string {
+
+	if !strings.Contains(str, "{{") {
+		return str
+	}
+
+	for key, value := range params {
+		str = strings.ReplaceAll(str, "{{"+key+"}}", value)
+		str = strings.ReplaceAll(str, "{{ "+key+" }}", value)
+	}
+
+	return str
+}
+
+// gitOpsDeploymentSetLabelValue returns the value of the GitOpsDeploymentSetLabel of the GitOpsDeployments that are
+// generated by the GitOpsDeploymentSet. A name which is too long to be a label value is truncated, and suffixed with
+// its hash: the label is only used to select the candidates, which are then filtered by their owner reference.
+func gitOpsDeploymentSetLabelValue(deploymentSetName string) string {
+
+	if len(deploymentSetName) <= maxLabelValueLength {
+		return deploymentSetName
+	}
+
+	hash := sha256.Sum256([]byte(deploymentSetName))
+	suffix := hex.EncodeToString(hash[:])[:16]
+
+	// A label value must begin and end with an alphanumeric character
+	prefix := strings.TrimRight(deploymentSetName[:maxLabelValueLength-len(suffix)-1], "-.")
+
+	return prefix + "-" + suffix
+}
@@ -0,0 +1,135 @@
+package managedgitops
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	managedgitopsv1alpha1 "github.com/redhat-appstudio/managed-gitops/backend-shared/apis/managed-gitops/v1alpha1"
+	logutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util/log"
+	"github.com/redhat-appstudio/managed-gitops/backend/eventloop/eventlooptypes"
+	"github.com/redhat-appstudio/managed-gitops/backend/eventloop/preprocess_event_loop"
+)
+
+// RequeueResourcesInNamespaces sends a 'modified' event to the preprocess event loop, for every GitOps Service API
+// resource in the namespaces that match 'namespaceFilter' (which is called with the UID of the namespace).
+//
+// GitOpsDeploymentSets are not processed by the preprocess event loop: they are instead sent to 'deploymentSetEvents',
+// which is watched by the GitOpsDeploymentSet controller (see GitOpsDeploymentSetReconciler.RequeueEvents).
+//
+// This is used when a backend replica becomes responsible for new namespaces (for example, because another replica
+// was removed), to ensure that the resources in those namespaces are reconciled, even if they have not changed.
+func RequeueResourcesInNamespaces(ctx context.Context, k8sClient client.Client,
+	preprocessEventLoop *preprocess_event_loop.PreprocessEventLoop, deploymentSetEvents chan<- event.GenericEvent,
+	namespaceFilter func(namespaceUID string) bool) error {
+
+	log := log.FromContext(ctx).WithName(logutil.LogLogger_managed_gitops)
+
+	// Cache of namespace name -> namespace UID. An empty UID means the namespace could not be retrieved.
+	namespaceUIDs := map[string]string{}
+
+	getNamespaceUID := func(namespaceName string) (string, error) {
+		if uid, exists := namespaceUIDs[namespaceName]; exists {
+			if uid == "" {
+				return "", fmt.Errorf("namespace '%s' could not be retrieved", namespaceName)
+			}
+			return uid, nil
+		}
+
+		namespace := corev1.Namespace{}
+		if err := k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, &namespace); err != nil {
+			namespaceUIDs[namespaceName] = ""
+			return "", fmt.Errorf("unable to retrieve namespace '%s': %v", namespaceName, err)
+		}
+
+		namespaceUIDs[namespaceName] = string(namespace.UID)
+		return string(namespace.UID), nil
+	}
+
+	// shouldRequeue returns the UID of the namespace of the resource, and whether the resource should be requeued.
+	// A namespace that cannot be retrieved is logged (once) and skipped, so that it doesn't prevent the resources of
+	// the remaining namespaces from being requeued.
+	shouldRequeue := func(obj client.Object) (string, bool) {
+
+		_, alreadyFailed := namespaceUIDs[obj.GetNamespace()]
+
+		namespaceUID, err := getNamespaceUID(obj.GetNamespace())
+		if err != nil {
+			if !alreadyFailed {
+				log.Error(err, "unable to requeue the resources of namespace", "namespace", obj.GetNamespace())
+			}
+			return "", false
+		}
+
+		return namespaceUID, namespaceFilter(namespaceUID)
+	}
+
+	// requeue sends an event for the resource to the preprocess event loop.
+	requeue := func(obj client.Object, resourceType eventlooptypes.GitOpsResourceType, eventType eventlooptypes.EventLoopEventType) {
+
+		namespaceUID, ok := shouldRequeue(obj)
+		if !ok {
+			return
+		}
+
+		req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(obj)}
+		preprocessEventLoop.EventReceived(req, resourceType, k8sClient, eventType, namespaceUID)
+	}
+
+	var managedEnvList managedgitopsv1alpha1.GitOpsDeploymentManagedEnvironmentList
+	if err := k8sClient.List(ctx, &managedEnvList); err != nil {
+		return fmt.Errorf("unable to list GitOpsDeploymentManagedEnvironments: %v", err)
+	}
+	for idx := range managedEnvList.Items {
+		requeue(&managedEnvList.Items[idx], eventlooptypes.GitOpsDeploymentManagedEnvironmentTypeName, eventlooptypes.ManagedEnvironmentModified)
+	}
+
+	var repoCredList managedgitopsv1alpha1.GitOpsDeploymentRepositoryCredentialList
+	if err := k8sClient.List(ctx, &repoCredList); err != nil {
+		return fmt.Errorf("unable to list GitOpsDeploymentRepositoryCredentials: %v", err)
+	}
+	for idx := range repoCredList.Items {
+		requeue(&repoCredList.Items[idx], eventlooptypes.GitOpsDeploymentRepositoryCredentialTypeName, eventlooptypes.RepositoryCredentialModified)
+	}
+
+	var gitopsDeplList managedgitopsv1alpha1.GitOpsDeploymentList
+	if err := k8sClient.List(ctx, &gitopsDeplList); err != nil {
+		return fmt.Errorf("unable to list GitOpsDeployments: %v", err)
+	}
+	for idx := range gitopsDeplList.Items {
+		requeue(&gitopsDeplList.Items[idx], eventlooptypes.GitOpsDeploymentTypeName, eventlooptypes.DeploymentModified)
+	}
+
+	var syncRunList managedgitopsv1alpha1.GitOpsDeploymentSyncRunList
+	if err := k8sClient.List(ctx, &syncRunList); err != nil {
+		return fmt.Errorf("unable to list GitOpsDeploymentSyncRuns: %v", err)
+	}
+	for idx := range syncRunList.Items {
+		requeue(&syncRunList.Items[idx], eventlooptypes.GitOpsDeploymentSyncRunTypeName, eventlooptypes.SyncRunModified)
+	}
+
+	var deploymentSetList managedgitopsv1alpha1.GitOpsDeploymentSetList
+	if err := k8sClient.List(ctx, &deploymentSetList); err != nil {
+		return fmt.Errorf("unable to list GitOpsDeploymentSets: %v", err)
+	}
+	for idx := range deploymentSetList.Items {
+		deploymentSet := &deploymentSetList.Items[idx]
+		if _, ok := shouldRequeue(deploymentSet); !ok {
+			continue
+		}
+
+		select {
+		case deploymentSetEvents <- event.GenericEvent{Object: deploymentSet}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
@@ -11,6 +11,7 @@ import (
 	logutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util/log"
 	"github.com/redhat-appstudio/managed-gitops/backend/eventloop/eventlooptypes"
 	"github.com/redhat-appstudio/managed-gitops/backend/eventloop/shared_resource_loop"
+	"github.com/redhat-appstudio/managed-gitops/backend/sharding"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -142,6 +143,9 @@ type applicationEventQueueLoopState struct {
 
 	// syncOperationEventRunnerShutdown is true if the runner has shut down, false otherwise
 	syncOperationEventRunnerShutdown bool
+
+	// workspaceID is the UID of the namespace containing the GitOpsDeployment
+	workspaceID string
 }
 
 // applicationEventQueueLoop is the main function of the application event loop: it accepts messages from the
@@ -175,10 +179,12 @@ func applicationEventQueueLoop(ctx context.Context, k8sClient client.Client,
 		syncOperationEventRunner: aerFactory.createNewApplicationEventLoopRunner(ctx, input, sharedResourceEventLoop, gitopsDeploymentName,
 			gitopsDeploymentNamespace, workspaceID, "sync-operation", ExistingK8sClientFactory{existingK8sClient: k8sClient}),
 		syncOperationEventRunnerShutdown: false,
+
+		workspaceID: workspaceID,
 	}
 
 	// Start the ticker, which will -- every X seconds -- instruct the GitOpsDeployment CR fields to update
-	startNewStatusUpdateTimer(ctx, k8sClient, input, log)
+	startNewStatusUpdateTimer(ctx, k8sClient, input, workspaceID, log)
 
 	for {
 		// Block on waiting for more events for this application
@@ -301,7 +307,7 @@ func processApplicationEventQueueLoopMessage(ctx context.Context, newEvent Reque
 			// After we finish processing a previous status tick, start the timer to queue up a new one.
 			// This ensures we are always reminded to do a status update.
 			state.activeDeploymentEvent = nil
-			startNewStatusUpdateTimer(ctx, k8sClient, input, log)
+			startNewStatusUpdateTimer(ctx, k8sClient, input, state.workspaceID, log)
 
 		} else if eventLoopMessage.ReqResource == eventlooptypes.GitOpsDeploymentTypeName ||
 			eventLoopMessage.ReqResource == eventlooptypes.GitOpsDeploymentManagedEnvironmentTypeName {
@@ -418,7 +424,7 @@ func processApplicationEventQueueLoopMessage(ctx context.Context, newEvent Reque
 // startNewStatusUpdateTimer will send a timer tick message to the application event loop in X seconds.
 // This tick informs the runner that it needs to update the status field of the Deployment.
 func startNewStatusUpdateTimer(ctx context.Context, k8sClient client.Client, input chan RequestMessage,
-	log logr.Logger) {
+	workspaceID string, log logr.Logger) {
 
 	// Up to 1 second of jitter
 	// #nosec
@@ -429,6 +435,19 @@ func startNewStatusUpdateTimer(ctx context.Context, k8sClient client.Client, inp
 	go func() {
 
 		<-statusUpdateTimer.C
+
+		// If the namespace is now owned by another backend replica, that replica is responsible for updating the
+		// status: wait for the next tick, in case ownership moves back to this replica.
+		for !sharding.IsNamespaceOwnedByThisReplica(workspaceID) {
+			statusUpdateTimer.Reset(deploymentStatusTickRate + jitter)
+			select {
+			case <-ctx.Done():
+				statusUpdateTimer.Stop()
+				return
+			case <-statusUpdateTimer.C:
+			}
+		}
+
 		tickMessage := RequestMessage{
 			Message: eventlooptypes.EventLoopMessage{
 				Event: &eventlooptypes.EventLoopEvent{
@@ -8,6 +8,7 @@ import (
 	"github.com/redhat-appstudio/managed-gitops/backend/condition"
 	"github.com/redhat-appstudio/managed-gitops/backend/eventloop/shared_resource_loop"
 	"github.com/redhat-appstudio/managed-gitops/backend/metrics"
+	"github.com/redhat-appstudio/managed-gitops/backend/sharding"
 
 	"github.com/go-logr/logr"
 	managedgitopsv1alpha1 "github.com/redhat-appstudio/managed-gitops/backend-shared/apis/managed-gitops/v1alpha1"
@@ -123,6 +124,16 @@ func applicationEventLoopRunner(outerContext context.Context, inputChannel chan
 			default:
 			}
 
+			// If sharding is enabled, and the namespace has been handed off to another backend replica, then that replica
+			// is responsible for the event (it requeues the resources of the namespaces that it gains): shutdown the runner.
+			namespaceWorkComplete, namespaceOwned := sharding.StartNamespaceWork(namespaceID)
+			if !namespaceOwned {
+				log.Info("namespace is owned by another backend replica: applicationEventLoopRunner is shutting down",
+					"event", eventlooptypes.StringEventLoopEvent(&newEvent))
+				signalledShutdown = true
+				break inner_for
+			}
+
 			_, err := sharedutil.CatchPanic(func() error {
 
 				action := applicationEventLoopRunner_Action{
@@ -133,6 +144,9 @@ func applicationEventLoopRunner(outerContext context.Context, inputChannel chan
 					log:                     log,
 					workspaceID:             namespaceID,
 					k8sClientFactory:        k8sFactory,
+					queueEvent: func(event eventlooptypes.EventLoopEvent) {
+						queueApplicationEventLoopEvent(outerContext, informWorkCompleteChan, event)
+					},
 				}
 
 				var err error
@@ -178,6 +192,8 @@ func applicationEventLoopRunner(outerContext context.Context, inputChannel chan
 				return err
 			})
 
+			namespaceWorkComplete()
+
 			log.V(logutil.LogLevel_Debug).Info("completed processing event")
 
 			if err == nil {
@@ -216,6 +232,23 @@ func applicationEventLoopRunner(outerContext context.Context, inputChannel chan
 	log.Info("ApplicationEventLoopRunner goroutine terminated.", "signalledShutdown", signalledShutdown)
 }
 
+// queueApplicationEventLoopEvent sends the event to the application event loop (via its input channel), from a new
+// goroutine: the application event loop may itself be waiting to send work to the runner.
+func queueApplicationEventLoopEvent(ctx context.Context, applicationEventLoopInput chan RequestMessage, event eventlooptypes.EventLoopEvent) {
+	go func() {
+		select {
+		case applicationEventLoopInput <- RequestMessage{
+			Message: eventlooptypes.EventLoopMessage{
+				Event:       &event,
+				MessageType: eventlooptypes.ApplicationEventLoopMessageType_Event,
+			},
+			ResponseChan: nil,
+		}:
+		case <-ctx.Done():
+		}
+	}()
+}
+
 // handleManagedEnvironmentModified_shouldInformGitOpsDeployment returns true if the GitOpsDeployment CR references
 // the ManagedEnvironment resource that changed, false otherwise.
 func handleManagedEnvironmentModified_shouldInformGitOpsDeployment(ctx context.Context, gitopsDeployment managedgitopsv1alpha1.GitOpsDeployment,
@@ -357,6 +390,7 @@ func handleManagedEnvironmentModified(ctx context.Context, expectedResourceName
 			log:                     action.log,
 			workspaceID:             action.workspaceID,
 			k8sClientFactory:        shared_resource_loop.DefaultK8sClientFactory{},
+			queueEvent:              action.queueEvent,
 		}
 
 		signalledShutdown, err := handleDeploymentModified(ctx, newEvent, newAction, dbQueries, log)
@@ -435,4 +469,8 @@ type applicationEventLoopRunner_Action struct {
 
 	// k8sClientFactory enabled the creation of K8s API clients to target various environments
 	k8sClientFactory shared_resource_loop.SRLK8sClientFactory
+
+	// queueEvent queues an event on the application event loop of the runner, to be processed after the current event.
+	// May be nil in unit tests.
+	queueEvent func(eventlooptypes.EventLoopEvent)
 }
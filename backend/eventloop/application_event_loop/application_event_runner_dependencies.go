@@ -0,0 +1,246 @@
+package application_event_loop
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	managedgitopsv1alpha1 "github.com/redhat-appstudio/managed-gitops/backend-shared/apis/managed-gitops/v1alpha1"
+	logutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util/log"
+	"github.com/redhat-appstudio/managed-gitops/backend/condition"
+	"github.com/redhat-appstudio/managed-gitops/backend/eventloop/eventlooptypes"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// This file is responsible for processing the dependencies (.spec.dependsOn) of a GitOpsDeployment:
+// - A GitOpsDeployment is not synced until each of the GitOpsDeployments that it depends on is both Synced and Healthy.
+// - While waiting, automated sync is disabled on the Argo CD Application, and SyncRuns are held: a held SyncRun has
+//   the 'WaitingForDependencies' condition, and is processed again once the dependencies are ready.
+// - The 'WaitingForDependencies' condition reports which dependencies are not yet ready, or the cycle of dependencies
+//   that the GitOpsDeployment is part of.
+
+// getUnreadyDependencies returns the names of the GitOpsDeployments in .spec.dependsOn that are not both Synced and
+// Healthy. A dependency which does not exist (yet) is not ready.
+//
+// It also returns the dependency cycle that the GitOpsDeployment is part of, if any: the webhook rejects cycles, but
+// cannot prevent them when GitOpsDeployments that depend on each other are created concurrently. A GitOpsDeployment in
+// a cycle is never synced, as its dependencies can never become ready.
+func getUnreadyDependencies(ctx context.Context, k8sClient client.Client,
+	gitopsDeployment *managedgitopsv1alpha1.GitOpsDeployment) ([]string, []string, error) {
+
+	dependencyCycle, err := findDependencyCycle(ctx, k8sClient, gitopsDeployment)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	unreadyDependencies := []string{}
+
+	for _, dependency := range gitopsDeployment.Spec.DependsOn {
+
+		dependencyDepl := &managedgitopsv1alpha1.GitOpsDeployment{}
+
+		if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: gitopsDeployment.Namespace, Name: dependency}, dependencyDepl); err != nil {
+			if apierr.IsNotFound(err) {
+				unreadyDependencies = append(unreadyDependencies, dependency)
+				continue
+			}
+			return nil, nil, fmt.Errorf("unable to retrieve dependency '%s' of GitOpsDeployment '%s': %v", dependency, gitopsDeployment.Name, err)
+		}
+
+		if !isGitOpsDeploymentSyncedAndHealthy(dependencyDepl) {
+			unreadyDependencies = append(unreadyDependencies, dependency)
+		}
+	}
+
+	return unreadyDependencies, dependencyCycle, nil
+}
+
+// findDependencyCycle returns the names of the GitOpsDeployments of a dependency cycle from the GitOpsDeployment back to
+// itself (starting and ending with the GitOpsDeployment), or nil if the GitOpsDeployment is not part of a cycle.
+func findDependencyCycle(ctx context.Context, k8sClient client.Client, gitopsDeployment *managedgitopsv1alpha1.GitOpsDeployment) ([]string, error) {
+
+	visited := map[string]bool{}
+
+	var visit func(dependsOn []string, path []string) ([]string, error)
+	visit = func(dependsOn []string, path []string) ([]string, error) {
+
+		for _, dependency := range dependsOn {
+
+			if dependency == gitopsDeployment.Name {
+				return append(append([]string{}, path...), dependency), nil
+			}
+
+			if visited[dependency] {
+				continue
+			}
+			visited[dependency] = true
+
+			dependencyDepl := &managedgitopsv1alpha1.GitOpsDeployment{}
+			if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: gitopsDeployment.Namespace, Name: dependency}, dependencyDepl); err != nil {
+				if apierr.IsNotFound(err) {
+					continue
+				}
+				return nil, fmt.Errorf("unable to retrieve dependency '%s' of GitOpsDeployment '%s': %v", dependency, gitopsDeployment.Name, err)
+			}
+
+			cycle, err := visit(dependencyDepl.Spec.DependsOn, append(path, dependency))
+			if err != nil || cycle != nil {
+				return cycle, err
+			}
+		}
+
+		return nil, nil
+	}
+
+	return visit(gitopsDeployment.Spec.DependsOn, []string{gitopsDeployment.Name})
+}
+
+// isGitOpsDeploymentSyncedAndHealthy returns true if the status of the GitOpsDeployment reports that it is both Synced
+// and Healthy.
+func isGitOpsDeploymentSyncedAndHealthy(gitopsDeployment *managedgitopsv1alpha1.GitOpsDeployment) bool {
+
+	// A dependency which is being deleted will never become ready
+	if isGitOpsDeploymentDeleted(gitopsDeployment) {
+		return false
+	}
+
+	return gitopsDeployment.Status.Sync.Status == managedgitopsv1alpha1.SyncStatusCodeSynced &&
+		gitopsDeployment.Status.Health.Status == managedgitopsv1alpha1.HeathStatusCodeHealthy
+}
+
+// waitingForDependenciesMessage returns the message of the WaitingForDependencies condition, for the given unready
+// dependencies and dependency cycle.
+func waitingForDependenciesMessage(unreadyDependencies []string, dependencyCycle []string) string {
+	if len(dependencyCycle) > 0 {
+		return fmt.Sprintf("GitOpsDeployment(s) depend on each other, and so will never be synced: %s", strings.Join(dependencyCycle, " -> "))
+	}
+	return fmt.Sprintf("waiting for GitOpsDeployment(s) to be Synced and Healthy: %s", strings.Join(unreadyDependencies, ", "))
+}
+
+// updateWaitingForDependenciesCondition sets the WaitingForDependencies condition of the GitOpsDeployment, based on
+// the current state of its dependencies.
+//
+// If the dependencies have become ready (or are no longer ready) since the condition was last set, a deployment
+// modified event is queued on the runner, so that the Argo CD Application of the GitOpsDeployment is updated (enabling
+// or disabling automated sync) once the current event has been processed. Once the dependencies are ready, a SyncRun
+// modified event is queued for each SyncRun that is held.
+func (a *applicationEventLoopRunner_Action) updateWaitingForDependenciesCondition(ctx context.Context,
+	gitopsDeployment *managedgitopsv1alpha1.GitOpsDeployment) error {
+
+	conditionManager := condition.NewConditionManager()
+
+	wasWaiting := false
+	for _, c := range gitopsDeployment.Status.Conditions {
+		if c.Type == managedgitopsv1alpha1.GitOpsDeploymentConditionWaitingForDependencies {
+			wasWaiting = c.Status == managedgitopsv1alpha1.GitOpsConditionStatusTrue
+		}
+	}
+
+	if len(gitopsDeployment.Spec.DependsOn) == 0 {
+		// The dependencies were removed from the GitOpsDeployment: the Application has already been updated when the
+		// spec changed, so we only need to resolve the condition.
+		if wasWaiting {
+			conditionManager.SetCondition(&gitopsDeployment.Status.Conditions, managedgitopsv1alpha1.GitOpsDeploymentConditionWaitingForDependencies,
+				managedgitopsv1alpha1.GitOpsConditionStatusFalse, managedgitopsv1alpha1.GitopsDeploymentReasonDependenciesReady, "")
+		}
+		return a.queueHeldSyncRunEvents(ctx, gitopsDeployment)
+	}
+
+	unreadyDependencies, dependencyCycle, err := getUnreadyDependencies(ctx, a.workspaceClient, gitopsDeployment)
+	if err != nil {
+		return err
+	}
+
+	waiting := len(unreadyDependencies) > 0 || len(dependencyCycle) > 0
+
+	if waiting != wasWaiting {
+		a.log.V(logutil.LogLevel_Debug).Info("dependencies of GitOpsDeployment changed state", "waiting", waiting)
+
+		// Regenerate the Argo CD Application of the GitOpsDeployment, which enables (or disables) automated sync.
+		a.queueDeploymentModifiedEvent(gitopsDeployment)
+	}
+
+	if waiting {
+		reason := managedgitopsv1alpha1.GitopsDeploymentReasonWaitingForDependencies
+		if len(dependencyCycle) > 0 {
+			reason = managedgitopsv1alpha1.GitopsDeploymentReasonDependencyCycle
+		}
+		conditionManager.SetCondition(&gitopsDeployment.Status.Conditions, managedgitopsv1alpha1.GitOpsDeploymentConditionWaitingForDependencies,
+			managedgitopsv1alpha1.GitOpsConditionStatusTrue, reason, waitingForDependenciesMessage(unreadyDependencies, dependencyCycle))
+
+		return nil
+	}
+
+	conditionManager.SetCondition(&gitopsDeployment.Status.Conditions, managedgitopsv1alpha1.GitOpsDeploymentConditionWaitingForDependencies,
+		managedgitopsv1alpha1.GitOpsConditionStatusFalse, managedgitopsv1alpha1.GitopsDeploymentReasonDependenciesReady, "")
+
+	return a.queueHeldSyncRunEvents(ctx, gitopsDeployment)
+}
+
+// queueHeldSyncRunEvents queues a SyncRun modified event on the runner, for each GitOpsDeploymentSyncRun of the
+// GitOpsDeployment that is held waiting for the dependencies of the GitOpsDeployment: see handleSyncRunModified.
+func (a *applicationEventLoopRunner_Action) queueHeldSyncRunEvents(ctx context.Context, gitopsDeployment *managedgitopsv1alpha1.GitOpsDeployment) error {
+
+	var syncRunList managedgitopsv1alpha1.GitOpsDeploymentSyncRunList
+	if err := a.workspaceClient.List(ctx, &syncRunList, client.InNamespace(gitopsDeployment.Namespace)); err != nil {
+		return fmt.Errorf("unable to list GitOpsDeploymentSyncRuns in namespace '%s': %v", gitopsDeployment.Namespace, err)
+	}
+
+	for idx := range syncRunList.Items {
+		syncRun := syncRunList.Items[idx]
+
+		if syncRun.Spec.GitopsDeploymentName != gitopsDeployment.Name || !isSyncRunWaitingForDependencies(&syncRun) {
+			continue
+		}
+
+		if a.queueEvent == nil {
+			// Only expected in unit tests, where the action is not processed by a runner
+			a.log.V(logutil.LogLevel_Debug).Info("unable to queue SyncRun modified event: the action has no event queue")
+			return nil
+		}
+
+		a.queueEvent(eventlooptypes.EventLoopEvent{
+			EventType:   eventlooptypes.SyncRunModified,
+			Request:     ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&syncRun)},
+			Client:      a.workspaceClient,
+			ReqResource: eventlooptypes.GitOpsDeploymentSyncRunTypeName,
+			WorkspaceID: a.workspaceID,
+		})
+	}
+
+	return nil
+}
+
+// isSyncRunWaitingForDependencies returns true if the GitOpsDeploymentSyncRun is held, waiting for the dependencies of
+// its GitOpsDeployment.
+func isSyncRunWaitingForDependencies(syncRun *managedgitopsv1alpha1.GitOpsDeploymentSyncRun) bool {
+
+	for _, c := range syncRun.Status.Conditions {
+		if c.Type == managedgitopsv1alpha1.GitOpsDeploymentSyncRunConditionWaitingForDependencies {
+			return c.Status == managedgitopsv1alpha1.GitOpsConditionStatusTrue
+		}
+	}
+
+	return false
+}
+
+// queueDeploymentModifiedEvent queues a deployment modified event for the GitOpsDeployment on the application event
+// loop of the runner, to be processed after the current event.
+func (a *applicationEventLoopRunner_Action) queueDeploymentModifiedEvent(gitopsDeployment *managedgitopsv1alpha1.GitOpsDeployment) {
+
+	if a.queueEvent == nil {
+		// Only expected in unit tests, where the action is not processed by a runner
+		a.log.V(logutil.LogLevel_Debug).Info("unable to queue deployment modified event: the action has no event queue")
+		return
+	}
+
+	a.queueEvent(eventlooptypes.EventLoopEvent{
+		EventType:   eventlooptypes.DeploymentModified,
+		Request:     ctrl.Request{NamespacedName: client.ObjectKeyFromObject(gitopsDeployment)},
+		Client:      a.workspaceClient,
+		ReqResource: eventlooptypes.GitOpsDeploymentTypeName,
+		WorkspaceID: a.workspaceID,
+	})
+}
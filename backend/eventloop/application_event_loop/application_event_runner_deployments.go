@@ -3,9 +3,11 @@ package application_event_loop
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
 	"strings"
+	"time"
 
 	"sigs.k8s.io/yaml"
 
@@ -46,6 +48,18 @@ const (
 	appProjectPrefix       = "app-project-"
 )
 
+var (
+	// errInvalidHelmValues is returned by createSpecField if the Helm values of the GitOpsDeployment are not valid YAML
+	errInvalidHelmValues = errors.New("the .spec.source.helm.values field must be a valid YAML object")
+
+	// errUnsupportedSourceCharacters is returned by createSpecField if the Helm or Kustomize fields of the GitOpsDeployment
+	// contain characters which cannot be included in the generated Application
+	errUnsupportedSourceCharacters = errors.New("the Helm parameters, Helm release name and Kustomize fields of the GitOpsDeployment source must not contain quotes, line breaks, ampersands, semicolons or percent signs")
+
+	// errSpecFieldTooLong is returned by createSpecField if the generated Application would not fit in the database
+	errSpecFieldTooLong = errors.New("the GitOpsDeployment source definition is too large: reduce the size of the .spec.source (or .spec.sources) field")
+)
+
 // This file is responsible for processing events related to GitOpsDeployment CR.
 
 // applicationEventRunner_handleDeploymentModified handles GitOpsDeployment resource events, ensuring that the
@@ -111,17 +125,9 @@ func (a *applicationEventLoopRunner_Action) applicationEventRunner_handleDeploym
 
 	if !isGitOpsDeploymentDeleted(gitopsDeployment) {
 		// Perform basic validation of GitOpsDeployment values
-
-		if gitopsDeployment.Spec.Source.Path == "" {
-			userError := managedgitopsv1alpha1.GitOpsDeploymentUserError_PathIsRequired
-			return signalledShutdown_false, nil, nil, deploymentModifiedResult_Failed,
-				gitopserrors.NewUserDevError(userError, fmt.Errorf(userError))
-
-		} else if gitopsDeployment.Spec.Source.Path == "/" {
-			userError := managedgitopsv1alpha1.GitOpsDeploymentUserError_InvalidPathSlash
+		if userError := validateGitOpsDeploymentSource(gitopsDeployment.Spec); userError != "" {
 			return signalledShutdown_false, nil, nil, deploymentModifiedResult_Failed,
 				gitopserrors.NewUserDevError(userError, fmt.Errorf(userError))
-
 		}
 	}
 
@@ -277,6 +283,12 @@ func (a applicationEventLoopRunner_Action) handleNewGitOpsDeplEvent(ctx context.
 		return nil, nil, deploymentModifiedResult_Failed, gitopserrors.NewDevOnlyError(fmt.Errorf("unexpected error on reconciling appproject repos: %w", err))
 	}
 
+	// Automated sync is disabled until the dependencies of the GitOpsDeployment are Synced and Healthy
+	unreadyDependencies, dependencyCycle, err := getUnreadyDependencies(ctx, a.workspaceClient, &gitopsDeployment)
+	if err != nil {
+		return nil, nil, deploymentModifiedResult_Failed, gitopserrors.NewDevOnlyError(err)
+	}
+
 	specFieldInput := argoCDSpecInput{
 		crName:               appName,
 		crNamespace:          engineInstance.Namespace_name,
@@ -286,8 +298,13 @@ func (a applicationEventLoopRunner_Action) handleNewGitOpsDeplEvent(ctx context.
 		sourceRepoURL:        gitopsDeployment.Spec.Source.RepoURL,
 		sourcePath:           gitopsDeployment.Spec.Source.Path,
 		sourceTargetRevision: gitopsDeployment.Spec.Source.TargetRevision,
+		sourceChart:          gitopsDeployment.Spec.Source.Chart,
+		sourceHelm:           convertHelmSource(gitopsDeployment.Spec.Source.Helm),
+		sourceKustomize:      convertKustomizeSource(gitopsDeployment.Spec.Source.Kustomize),
+		sources:              convertApplicationSources(gitopsDeployment.Spec.Sources),
+		ignoreDifferences:    convertIgnoreDifferences(gitopsDeployment.Spec.IgnoreDifferences),
 		// syncOptions:       if non-empty, it gets updated below.
-		automated: strings.EqualFold(gitopsDeployment.Spec.Type, managedgitopsv1alpha1.GitOpsDeploymentSpecType_Automated),
+		automated: strings.EqualFold(gitopsDeployment.Spec.Type, managedgitopsv1alpha1.GitOpsDeploymentSpecType_Automated) && len(unreadyDependencies) == 0 && len(dependencyCycle) == 0,
 		project:   appProjectPrefix + clusterUser.Clusteruser_id,
 	}
 
@@ -307,8 +324,43 @@ func (a applicationEventLoopRunner_Action) handleNewGitOpsDeplEvent(ctx context.
 
 	}
 
+	if gitopsDeployment.Spec.SyncPolicy != nil && gitopsDeployment.Spec.SyncPolicy.Automated != nil {
+		userErr := checkValidAutomatedSyncPolicy(gitopsDeployment.Spec)
+
+		if userErr != nil {
+			return nil, nil, deploymentModifiedResult_Failed, userErr
+		}
+
+		specFieldInput.automatedPolicy = convertSyncPolicyAutomated(gitopsDeployment.Spec.SyncPolicy.Automated)
+	}
+
+	if gitopsDeployment.Spec.SyncPolicy != nil && gitopsDeployment.Spec.SyncPolicy.Retry != nil {
+		userErr := checkValidRetryStrategy(*gitopsDeployment.Spec.SyncPolicy.Retry)
+
+		if userErr != nil {
+			return nil, nil, deploymentModifiedResult_Failed, userErr
+		}
+
+		specFieldInput.retry = convertRetryStrategy(*gitopsDeployment.Spec.SyncPolicy.Retry)
+	}
+
+	if userErr := checkValidIgnoreDifferences(gitopsDeployment.Spec.IgnoreDifferences); userErr != nil {
+		return nil, nil, deploymentModifiedResult_Failed, userErr
+	}
+
+	if userErr := checkValidSyncWindows(gitopsDeployment.Spec.SyncWindows); userErr != nil {
+		return nil, nil, deploymentModifiedResult_Failed, userErr
+	}
+
+	if userErr := checkValidApplicationSources(gitopsDeployment.Spec); userErr != nil {
+		return nil, nil, deploymentModifiedResult_Failed, userErr
+	}
+
 	specFieldText, err := createSpecField(specFieldInput)
 	if err != nil {
+		if userErr := specFieldUserError(err); userErr != nil {
+			return nil, nil, deploymentModifiedResult_Failed, userErr
+		}
 		a.log.Error(err, "SEVERE: unable to marshal generated YAML")
 		return nil, nil, deploymentModifiedResult_Failed, gitopserrors.NewDevOnlyError(err)
 	}
@@ -598,23 +650,22 @@ func (a applicationEventLoopRunner_Action) handleUpdatedGitOpsDeplEvent(ctx cont
 	}
 
 	isWorkspaceTarget := gitopsDeployment.Spec.Destination.Environment == ""
-	managedEnv, engineInstance, destinationName, err := a.reconcileManagedEnvironmentOfGitOpsDeployment(ctx, gitopsDeployment, apiNamespace, isWorkspaceTarget)
+	managedEnv, _, destinationName, err := a.reconcileManagedEnvironmentOfGitOpsDeployment(ctx, gitopsDeployment, apiNamespace, isWorkspaceTarget)
 	if err != nil {
 		userError := "unable to reconcile the ManagedEnvironment resource. Ensure that the ManagedEnvironment exists, it references a Secret, and the Secret is valid"
 		devError := fmt.Errorf("unable to get or create managed environment: %v", err)
 		return nil, nil, deploymentModifiedResult_Failed, gitopserrors.NewUserDevError(userError, devError)
 	}
 
-	if engineInstance == nil {
-		// If engineInstance from reconcileManagedEnvironmentOfGitOpsDeployment is nil, instead get the engine instance from
-		// the application.
-		engineInstance = &db.GitopsEngineInstance{
-			Gitopsengineinstance_id: application.Engine_instance_inst_id,
-		}
-		if err := dbQueries.GetGitopsEngineInstanceById(ctx, engineInstance); err != nil {
-			return nil, nil, deploymentModifiedResult_Failed,
-				gitopserrors.NewDevOnlyError(fmt.Errorf("unable to retrieve GitOpsEngineInstance for existing GitOpsDeployment: %v", err))
-		}
+	// An existing Application stays on the GitOps engine instance that is hosting it (it only moves to another instance
+	// when it is explicitly migrated, see docs/gitops-engine-migration.md), so we use the engine instance of the
+	// Application row, rather than the instance that new Applications of this user would be placed on.
+	engineInstance := &db.GitopsEngineInstance{
+		Gitopsengineinstance_id: application.Engine_instance_inst_id,
+	}
+	if err := dbQueries.GetGitopsEngineInstanceById(ctx, engineInstance); err != nil {
+		return nil, nil, deploymentModifiedResult_Failed,
+			gitopserrors.NewDevOnlyError(fmt.Errorf("unable to retrieve GitOpsEngineInstance for existing GitOpsDeployment: %v", err))
 	}
 
 	// Sanity check that the application.name matches the expected value set in handleCreateGitOpsEvent
@@ -645,6 +696,12 @@ func (a applicationEventLoopRunner_Action) handleUpdatedGitOpsDeplEvent(ctx cont
 		return nil, nil, deploymentModifiedResult_Failed, gitopserrors.NewDevOnlyError(fmt.Errorf("unexpected error on reconciling appproject repos: %w", err))
 	}
 
+	// Automated sync is disabled until the dependencies of the GitOpsDeployment are Synced and Healthy
+	unreadyDependencies, dependencyCycle, err := getUnreadyDependencies(ctx, a.workspaceClient, &gitopsDeployment)
+	if err != nil {
+		return nil, nil, deploymentModifiedResult_Failed, gitopserrors.NewDevOnlyError(err)
+	}
+
 	specFieldInput := argoCDSpecInput{
 		crName:               application.Name,
 		crNamespace:          engineInstance.Namespace_name,
@@ -653,8 +710,13 @@ func (a applicationEventLoopRunner_Action) handleUpdatedGitOpsDeplEvent(ctx cont
 		sourceRepoURL:        gitopsDeployment.Spec.Source.RepoURL,
 		sourcePath:           gitopsDeployment.Spec.Source.Path,
 		sourceTargetRevision: gitopsDeployment.Spec.Source.TargetRevision,
+		sourceChart:          gitopsDeployment.Spec.Source.Chart,
+		sourceHelm:           convertHelmSource(gitopsDeployment.Spec.Source.Helm),
+		sourceKustomize:      convertKustomizeSource(gitopsDeployment.Spec.Source.Kustomize),
+		sources:              convertApplicationSources(gitopsDeployment.Spec.Sources),
+		ignoreDifferences:    convertIgnoreDifferences(gitopsDeployment.Spec.IgnoreDifferences),
 		// syncOptions:       if non-empty, it gets updated below.
-		automated: strings.EqualFold(gitopsDeployment.Spec.Type, managedgitopsv1alpha1.GitOpsDeploymentSpecType_Automated),
+		automated: strings.EqualFold(gitopsDeployment.Spec.Type, managedgitopsv1alpha1.GitOpsDeploymentSpecType_Automated) && len(unreadyDependencies) == 0 && len(dependencyCycle) == 0,
 		project:   appProjectPrefix + clusterUser.Clusteruser_id,
 	}
 
@@ -671,6 +733,34 @@ func (a applicationEventLoopRunner_Action) handleUpdatedGitOpsDeplEvent(ctx cont
 		specFieldInput.syncOptions = managedgitopsv1alpha1.SyncOptionToStringSlice(gitopsDeployment.Spec.SyncPolicy.SyncOptions)
 	}
 
+	if gitopsDeployment.Spec.SyncPolicy != nil && gitopsDeployment.Spec.SyncPolicy.Automated != nil {
+		if err := checkValidAutomatedSyncPolicy(gitopsDeployment.Spec); err != nil {
+			return nil, nil, deploymentModifiedResult_Failed, err
+		}
+
+		specFieldInput.automatedPolicy = convertSyncPolicyAutomated(gitopsDeployment.Spec.SyncPolicy.Automated)
+	}
+
+	if gitopsDeployment.Spec.SyncPolicy != nil && gitopsDeployment.Spec.SyncPolicy.Retry != nil {
+		if err := checkValidRetryStrategy(*gitopsDeployment.Spec.SyncPolicy.Retry); err != nil {
+			return nil, nil, deploymentModifiedResult_Failed, err
+		}
+
+		specFieldInput.retry = convertRetryStrategy(*gitopsDeployment.Spec.SyncPolicy.Retry)
+	}
+
+	if err := checkValidIgnoreDifferences(gitopsDeployment.Spec.IgnoreDifferences); err != nil {
+		return nil, nil, deploymentModifiedResult_Failed, err
+	}
+
+	if err := checkValidSyncWindows(gitopsDeployment.Spec.SyncWindows); err != nil {
+		return nil, nil, deploymentModifiedResult_Failed, err
+	}
+
+	if err := checkValidApplicationSources(gitopsDeployment.Spec); err != nil {
+		return nil, nil, deploymentModifiedResult_Failed, err
+	}
+
 	shouldUpdateApplication := false
 
 	if appProjectDBRowsUpdated {
@@ -681,6 +771,9 @@ func (a applicationEventLoopRunner_Action) handleUpdatedGitOpsDeplEvent(ctx cont
 	{
 		specFieldResult, err := createSpecField(specFieldInput)
 		if err != nil {
+			if userErr := specFieldUserError(err); userErr != nil {
+				return nil, nil, deploymentModifiedResult_Failed, userErr
+			}
 			log.Error(err, "SEVERE: Unable to parse generated spec field")
 			return nil, nil, deploymentModifiedResult_Failed, gitopserrors.NewDevOnlyError(err)
 		}
@@ -1014,6 +1107,7 @@ func (a *applicationEventLoopRunner_Action) applicationEventRunner_handleUpdateD
 	gitopsDeployment.Status.Health.Message = appStatus.Health.Message
 	gitopsDeployment.Status.Sync.Status = managedgitopsv1alpha1.SyncStatusCode(appStatus.Sync.Status)
 	gitopsDeployment.Status.Sync.Revision = appStatus.Sync.Revision
+	gitopsDeployment.Status.Sync.Revisions = appStatus.Sync.Revisions
 
 	// We update the GitopsDeployment .status.conditions with the conditions from the Argo CD Application, if the conditions column of ApplicationState row is non empty.
 	newGitopsDeplConditions := []managedgitopsv1alpha1.GitOpsDeploymentCondition{}
@@ -1036,6 +1130,10 @@ func (a *applicationEventLoopRunner_Action) applicationEventRunner_handleUpdateD
 	// Go through the existing conditions and check if they are present in the list of new conditions. If they are absent then it can marked as resolved.
 	for _, c := range gitopsDeployment.Status.Conditions {
 		reason := c.Type + "Resolved"
+		if c.Type == managedgitopsv1alpha1.GitOpsDeploymentConditionWaitingForDependencies {
+			// The WaitingForDependencies condition is not reported by Argo CD: it is updated below.
+			continue
+		}
 		if !conditionManager.HasCondition(&newGitopsDeplConditions, c.Type) && c.Reason != managedgitopsv1alpha1.GitOpsDeploymentReasonType(reason) {
 			conditionManager.SetCondition(&gitopsDeployment.Status.Conditions, c.Type, managedgitopsv1alpha1.GitOpsConditionStatusFalse, managedgitopsv1alpha1.GitOpsDeploymentReasonType(reason), "")
 		}
@@ -1049,6 +1147,20 @@ func (a *applicationEventLoopRunner_Action) applicationEventRunner_handleUpdateD
 		return crUpdated_false, err
 	}
 
+	gitopsDeployment.Status.History, err = extractRevisionHistory(appStatus.History)
+	if err != nil {
+		a.log.Error(err, "unable to extract history from ApplicationState table.")
+		return crUpdated_false, err
+	}
+
+	// Update gitopsDeployment status with the GitOps engine instance that the Application is hosted on. If any error occurs
+	// while retrieving it, the existing value is preserved: if necessary, it will be updated on the next tick.
+	if engineInstanceStatus, err := getGitOpsEngineInstanceStatus(ctx, mapping.Application_id, dbQueries); err != nil {
+		a.log.V(logutil.LogLevel_Warn).Info("unable to retrieve GitOps engine instance of Application in tick status update", "error", err.Error())
+	} else {
+		gitopsDeployment.Status.GitOpsEngineInstance = engineInstanceStatus
+	}
+
 	comparedTo := appStatus.Sync.ComparedTo
 
 	// If the `comparedTo` value from Argo CD has a non-empty destination name field, then retrieve the corresponding `GitOpsDeploymentManagedEnvironment` resource that has that name,
@@ -1070,12 +1182,29 @@ func (a *applicationEventLoopRunner_Action) applicationEventRunner_handleUpdateD
 	}
 
 	// Update gitopsDeployment status with reconciledState
-	gitopsDeployment.Status.ReconciledState.Source.Path = comparedTo.Source.Path
-	gitopsDeployment.Status.ReconciledState.Source.RepoURL = comparedTo.Source.RepoURL
-	gitopsDeployment.Status.ReconciledState.Source.Branch = comparedTo.Source.TargetRevision
+	gitopsDeployment.Status.ReconciledState.Source = extractReconciledSource(comparedTo.Source)
+	gitopsDeployment.Status.ReconciledState.Sources = nil
+	for _, source := range comparedTo.Sources {
+		gitopsDeployment.Status.ReconciledState.Sources = append(gitopsDeployment.Status.ReconciledState.Sources, extractReconciledSource(source))
+	}
 	gitopsDeployment.Status.ReconciledState.Destination.Name = comparedTo.Destination.Name
 	gitopsDeployment.Status.ReconciledState.Destination.Namespace = comparedTo.Destination.Namespace
 
+	// Update gitopsDeployment status with the current state of its sync windows
+	gitopsDeployment.Status.SyncWindows = generateSyncWindowsStatus(gitopsDeployment.Spec.SyncWindows, time.Now())
+
+	// Update the WaitingForDependencies condition, based on the current state of the dependencies of the GitOpsDeployment
+	if err := a.updateWaitingForDependenciesCondition(ctx, gitopsDeployment); err != nil {
+		a.log.Error(err, "unable to update the WaitingForDependencies condition in tick status update")
+		return crUpdated_false, err
+	}
+
+	// Update the GitOpsDeploymentResourceTree of the GitOpsDeployment, which contains the full resource tree
+	if err := a.reconcileGitOpsDeploymentResourceTree(ctx, gitopsDeployment, appStatus); err != nil {
+		a.log.Error(err, "unable to update GitOpsDeploymentResourceTree in tick status update")
+		return crUpdated_false, err
+	}
+
 	// If nothing has changed in the status field, our work is done.
 	if reflect.DeepEqual(gitopsDeployment.Status, originalGitOpsDeployment.Status) {
 		return crUpdated_false, nil
@@ -1094,6 +1223,26 @@ func (a *applicationEventLoopRunner_Action) applicationEventRunner_handleUpdateD
 
 }
 
+// getGitOpsEngineInstanceStatus returns the GitOps engine instance that the given Application is hosted on.
+func getGitOpsEngineInstanceStatus(ctx context.Context, applicationID string, dbQueries db.ApplicationScopedQueries) (*managedgitopsv1alpha1.GitOpsEngineInstanceStatus, error) {
+
+	application := db.Application{Application_id: applicationID}
+	if err := dbQueries.GetApplicationById(ctx, &application); err != nil {
+		return nil, err
+	}
+
+	engineInstance := db.GitopsEngineInstance{Gitopsengineinstance_id: application.Engine_instance_inst_id}
+	if err := dbQueries.GetGitopsEngineInstanceById(ctx, &engineInstance); err != nil {
+		return nil, err
+	}
+
+	return &managedgitopsv1alpha1.GitOpsEngineInstanceStatus{
+		ID:        engineInstance.Gitopsengineinstance_id,
+		Namespace: engineInstance.Namespace_name,
+		ClusterID: engineInstance.EngineCluster_id,
+	}, nil
+}
+
 // gitOpsDeploymentAdapter is an "adapter" for GitOpsDeployment allowing you to easily plug any other related
 // API component (i.e. for adding Conditions, look at setGitOpsDeploymentCondition() method)
 // Same principle can be used for others, e.g. Finalizers, or any other field which is part of the GitOpsDeployment CRD
@@ -1179,8 +1328,7 @@ func checkValidSyncOption(syncOptions []managedgitopsv1alpha1.SyncOption) gitops
 		match := true
 
 		// Check for SyncOption string
-		if !(syncOptionString == managedgitopsv1alpha1.SyncOptions_CreateNamespace_true ||
-			syncOptionString == managedgitopsv1alpha1.SyncOptions_CreateNamespace_false) {
+		if !managedgitopsv1alpha1.IsSupportedSyncOption(syncOptionString) {
 			match = false
 		}
 
@@ -1195,6 +1343,174 @@ func checkValidSyncOption(syncOptions []managedgitopsv1alpha1.SyncOption) gitops
 	return nil
 }
 
+// checkValidAutomatedSyncPolicy returns a user error if .spec.syncPolicy.automated is specified for a GitOpsDeployment that is not automated
+func checkValidAutomatedSyncPolicy(spec managedgitopsv1alpha1.GitOpsDeploymentSpec) gitopserrors.UserError {
+
+	if spec.SyncPolicy == nil || spec.SyncPolicy.Automated == nil {
+		return nil
+	}
+
+	if !strings.EqualFold(spec.Type, managedgitopsv1alpha1.GitOpsDeploymentSpecType_Automated) {
+		userError := managedgitopsv1alpha1.GitOpsDeploymentUserError_AutomatedNotAutomated
+		devError := fmt.Errorf("invalid automated sync policy for GitOpsDeployment of type: %s", spec.Type)
+
+		return gitopserrors.NewUserDevError(userError, devError)
+	}
+
+	return nil
+}
+
+// checkValidRetryStrategy returns a user error if the backoff of .spec.syncPolicy.retry cannot be used by Argo CD
+func checkValidRetryStrategy(retry managedgitopsv1alpha1.RetryStrategy) gitopserrors.UserError {
+
+	if err := managedgitopsv1alpha1.ValidateRetryStrategy(retry); err != nil {
+		return gitopserrors.NewUserDevError(err.Error(), fmt.Errorf("invalid retry strategy: %w", err))
+	}
+
+	return nil
+}
+
+// checkValidIgnoreDifferences returns a user error if an entry of .spec.ignoreDifferences cannot be used by Argo CD
+func checkValidIgnoreDifferences(ignoreDifferences managedgitopsv1alpha1.IgnoreDifferences) gitopserrors.UserError {
+
+	if err := managedgitopsv1alpha1.ValidateIgnoreDifferences(ignoreDifferences); err != nil {
+		return gitopserrors.NewUserDevError(err.Error(), fmt.Errorf("invalid ignoreDifferences: %w", err))
+	}
+
+	return nil
+}
+
+// checkValidSyncWindows returns a user error if a sync window of .spec.syncWindows cannot be used by Argo CD
+func checkValidSyncWindows(syncWindows []managedgitopsv1alpha1.SyncWindow) gitopserrors.UserError {
+
+	userError := "the .spec.syncWindows field is invalid: each window must specify a kind of 'allow' or 'deny', a schedule in cron format, a duration such as '1h', and an optional valid time zone"
+
+	for _, syncWindow := range syncWindows {
+
+		if syncWindow.Kind != managedgitopsv1alpha1.SyncWindowKind_Allow && syncWindow.Kind != managedgitopsv1alpha1.SyncWindowKind_Deny {
+			return gitopserrors.NewUserDevError(userError, fmt.Errorf("invalid sync window kind: %s", syncWindow.Kind))
+		}
+
+		if _, err := isSyncWindowActive(syncWindow, time.Now()); err != nil {
+			return gitopserrors.NewUserDevError(userError, err)
+		}
+	}
+
+	return nil
+}
+
+// checkValidApplicationSources returns a user error if the Helm or Kustomize fields of a source of the GitOpsDeployment
+// cannot be used by Argo CD
+func checkValidApplicationSources(spec managedgitopsv1alpha1.GitOpsDeploymentSpec) gitopserrors.UserError {
+
+	sources := managedgitopsv1alpha1.ApplicationSources{spec.Source}
+	if spec.HasMultipleSources() {
+		sources = spec.Sources
+	}
+
+	for _, source := range sources {
+		if err := managedgitopsv1alpha1.ValidateApplicationSource(source); err != nil {
+			return gitopserrors.NewUserDevError(err.Error(), fmt.Errorf("invalid source '%s': %w", source.RepoURL, err))
+		}
+	}
+
+	return nil
+}
+
+// isSyncWindowActive returns true if the sync window is open at the given time: that is, if the window began (as
+// defined by its schedule) less than 'duration' ago.
+func isSyncWindowActive(syncWindow managedgitopsv1alpha1.SyncWindow, currentTime time.Time) (bool, error) {
+
+	schedule, err := managedgitopsv1alpha1.SyncWindowScheduleParser.Parse(syncWindow.Schedule)
+	if err != nil {
+		return false, fmt.Errorf("invalid sync window schedule '%s': %w", syncWindow.Schedule, err)
+	}
+
+	duration, err := time.ParseDuration(syncWindow.Duration)
+	if err != nil || duration <= 0 {
+		return false, fmt.Errorf("invalid sync window duration: '%s'", syncWindow.Duration)
+	}
+
+	location := time.UTC
+	if syncWindow.TimeZone != "" {
+		if location, err = time.LoadLocation(syncWindow.TimeZone); err != nil {
+			return false, fmt.Errorf("invalid sync window time zone '%s': %w", syncWindow.TimeZone, err)
+		}
+	}
+
+	// The schedule is evaluated in the location of the time it is given
+	currentTime = currentTime.In(location)
+
+	nextWindowStart := schedule.Next(currentTime.Add(-duration))
+
+	return nextWindowStart.Before(currentTime), nil
+}
+
+// generateSyncWindowsStatus returns the state of the given sync windows at the given time, or nil if there are no
+// sync windows. Whether syncs are allowed is determined using the same rules as Argo CD:
+// - While a 'deny' window is active, syncs are denied (manual syncs are allowed only if all active 'deny' windows enable them)
+// - Otherwise, if 'allow' windows are defined but none are active, syncs are denied (manual syncs are allowed only if all inactive 'allow' windows enable them)
+func generateSyncWindowsStatus(syncWindows []managedgitopsv1alpha1.SyncWindow, currentTime time.Time) *managedgitopsv1alpha1.SyncWindowsStatus {
+
+	if len(syncWindows) == 0 {
+		return nil
+	}
+
+	var activeWindows, inactiveAllowWindows []managedgitopsv1alpha1.SyncWindow
+
+	for _, syncWindow := range syncWindows {
+
+		active, err := isSyncWindowActive(syncWindow, currentTime)
+		if err != nil {
+			// Invalid sync windows are reported via the conditions of the GitOpsDeployment, so they are ignored here.
+			continue
+		}
+
+		if active {
+			activeWindows = append(activeWindows, syncWindow)
+		} else if syncWindow.Kind == managedgitopsv1alpha1.SyncWindowKind_Allow {
+			inactiveAllowWindows = append(inactiveAllowWindows, syncWindow)
+		}
+	}
+
+	allManualSync := func(windows []managedgitopsv1alpha1.SyncWindow) bool {
+		for _, window := range windows {
+			if !window.ManualSync {
+				return false
+			}
+		}
+		return true
+	}
+
+	var activeDenyWindows []managedgitopsv1alpha1.SyncWindow
+	activeAllowWindow := false
+	for _, activeWindow := range activeWindows {
+		if activeWindow.Kind == managedgitopsv1alpha1.SyncWindowKind_Deny {
+			activeDenyWindows = append(activeDenyWindows, activeWindow)
+		} else {
+			activeAllowWindow = true
+		}
+	}
+
+	res := &managedgitopsv1alpha1.SyncWindowsStatus{
+		Active:            len(activeWindows) > 0,
+		ActiveWindows:     activeWindows,
+		SyncAllowed:       true,
+		ManualSyncAllowed: true,
+	}
+
+	if len(activeDenyWindows) > 0 {
+		res.SyncAllowed = false
+		res.ManualSyncAllowed = allManualSync(activeDenyWindows)
+
+	} else if !activeAllowWindow && len(inactiveAllowWindows) > 0 {
+		res.SyncAllowed = false
+		res.ManualSyncAllowed = allManualSync(inactiveAllowWindows)
+	}
+
+	return res
+}
+
 type argoCDSpecInput struct {
 	// MAKE SURE YOU SANITIZE ANY NEW FIELDS THAT ARE ADDED!!!!
 	crName      string
@@ -1206,9 +1522,21 @@ type argoCDSpecInput struct {
 	sourceRepoURL        string
 	sourcePath           string
 	sourceTargetRevision string
-	syncOptions          []string
+	sourceChart          string
+	sourceHelm           *fauxargocd.ApplicationSourceHelm
+	sourceKustomize      *fauxargocd.ApplicationSourceKustomize
+	// sources is only set for a GitOpsDeployment with multiple sources, in which case the single source fields above are ignored
+	sources     []fauxargocd.ApplicationSource
+	syncOptions []string
 	// MAKE SURE YOU SANITIZE ANY NEW FIELDS THAT ARE ADDED!!!!
 	automated bool
+	// automatedPolicy is only used if automated is true. If nil, prune, selfHeal and allowEmpty are all enabled.
+	automatedPolicy *fauxargocd.SyncPolicyAutomated
+	// MAKE SURE YOU SANITIZE ANY NEW FIELDS THAT ARE ADDED!!!!
+	// retry overrides the default retry strategy of an automated Application, if non-nil
+	retry *fauxargocd.RetryStrategy
+	// MAKE SURE YOU SANITIZE ANY NEW FIELDS THAT ARE ADDED!!!!
+	ignoreDifferences fauxargocd.IgnoreDifferences
 	// MAKE SURE YOU SANITIZE ANY NEW FIELDS THAT ARE ADDED!!!!
 	project string
 
@@ -1238,6 +1566,221 @@ func createSpecField(fieldsParam argoCDSpecInput) (string, error) {
 		return res
 	}
 
+	// validate returns an error if sanitize would change the input. It is used for the Helm and Kustomize fields,
+	// which are also rejected by the GitOpsDeployment webhook (and checkValidApplicationSources) if they contain
+	// these characters, rather than being silently changed.
+	validate := func(input string) (string, error) {
+		if sanitize(input) != input {
+			return "", fmt.Errorf("%w: '%s'", errUnsupportedSourceCharacters, input)
+		}
+		return input, nil
+	}
+
+	// sanitizeHelm sanitizes the Helm fields. Since the values field is a YAML document, it cannot be sanitized
+	// like the other fields: instead it is parsed and re-marshalled, which ensures that it only contains YAML.
+	sanitizeHelm := func(input *fauxargocd.ApplicationSourceHelm) (*fauxargocd.ApplicationSourceHelm, error) {
+		if input == nil {
+			return nil, nil
+		}
+
+		releaseName, err := validate(input.ReleaseName)
+		if err != nil {
+			return nil, err
+		}
+
+		res := &fauxargocd.ApplicationSourceHelm{
+			ReleaseName: releaseName,
+		}
+
+		if len(input.ValueFiles) > 0 {
+			res.ValueFiles = sanitizeArray(input.ValueFiles)
+		}
+
+		for _, param := range input.Parameters {
+			name, err := validate(param.Name)
+			if err != nil {
+				return nil, err
+			}
+
+			value, err := validate(param.Value)
+			if err != nil {
+				return nil, err
+			}
+
+			res.Parameters = append(res.Parameters, fauxargocd.HelmParameter{
+				Name:        name,
+				Value:       value,
+				ForceString: param.ForceString,
+			})
+		}
+
+		if input.Values != "" {
+			values := map[string]interface{}{}
+			if err := goyaml.Unmarshal([]byte(input.Values), &values); err != nil {
+				return nil, fmt.Errorf("%w: %v", errInvalidHelmValues, err)
+			}
+
+			valuesBytes, err := goyaml.Marshal(values)
+			if err != nil {
+				return nil, err
+			}
+			res.Values = string(valuesBytes)
+		}
+
+		return res, nil
+	}
+
+	validateMap := func(input map[string]string) (map[string]string, error) {
+		if len(input) == 0 {
+			return nil, nil
+		}
+		res := map[string]string{}
+		for key, value := range input {
+			if _, err := validate(key); err != nil {
+				return nil, err
+			}
+			if _, err := validate(value); err != nil {
+				return nil, err
+			}
+			res[key] = value
+		}
+		return res, nil
+	}
+
+	// sanitizeKustomize validates the Kustomize fields, returning an error if they contain characters which would
+	// otherwise be removed by sanitize.
+	sanitizeKustomize := func(input *fauxargocd.ApplicationSourceKustomize) (*fauxargocd.ApplicationSourceKustomize, error) {
+		if input == nil {
+			return nil, nil
+		}
+
+		res := &fauxargocd.ApplicationSourceKustomize{}
+
+		var err error
+		if res.NamePrefix, err = validate(input.NamePrefix); err != nil {
+			return nil, err
+		}
+		if res.NameSuffix, err = validate(input.NameSuffix); err != nil {
+			return nil, err
+		}
+		if res.CommonLabels, err = validateMap(input.CommonLabels); err != nil {
+			return nil, err
+		}
+		if res.CommonAnnotations, err = validateMap(input.CommonAnnotations); err != nil {
+			return nil, err
+		}
+
+		for _, image := range input.Images {
+			if _, err := validate(string(image)); err != nil {
+				return nil, err
+			}
+			res.Images = append(res.Images, image)
+		}
+
+		for _, replica := range input.Replicas {
+			if _, err := validate(replica.Name); err != nil {
+				return nil, err
+			}
+			res.Replicas = append(res.Replicas, fauxargocd.KustomizeReplica{
+				Name:  replica.Name,
+				Count: replica.Count,
+			})
+		}
+
+		return res, nil
+	}
+
+	sanitizeSource := func(input fauxargocd.ApplicationSource) (fauxargocd.ApplicationSource, error) {
+		helm, err := sanitizeHelm(input.Helm)
+		if err != nil {
+			return fauxargocd.ApplicationSource{}, err
+		}
+
+		kustomize, err := sanitizeKustomize(input.Kustomize)
+		if err != nil {
+			return fauxargocd.ApplicationSource{}, err
+		}
+
+		return fauxargocd.ApplicationSource{
+			RepoURL:        sanitize(input.RepoURL),
+			Path:           sanitize(input.Path),
+			TargetRevision: sanitize(input.TargetRevision),
+			Chart:          sanitize(input.Chart),
+			Helm:           helm,
+			Kustomize:      kustomize,
+			Ref:            sanitize(input.Ref),
+		}, nil
+	}
+
+	sanitizeRetry := func(input *fauxargocd.RetryStrategy) *fauxargocd.RetryStrategy {
+		if input == nil {
+			return nil
+		}
+
+		res := &fauxargocd.RetryStrategy{
+			Limit: input.Limit,
+		}
+
+		if input.Backoff != nil {
+			res.Backoff = &fauxargocd.Backoff{
+				Duration:    sanitize(input.Backoff.Duration),
+				Factor:      input.Backoff.Factor,
+				MaxDuration: sanitize(input.Backoff.MaxDuration),
+			}
+		}
+
+		return res
+	}
+
+	sanitizeIgnoreDifferences := func(input fauxargocd.IgnoreDifferences) fauxargocd.IgnoreDifferences {
+		// JQ path expressions may legitimately contain quotes and other special characters (for example,
+		// 'select(.name == "istio-proxy")'), so only line breaks are removed from them.
+		sanitizeJQPathExpressions := func(expressions []string) []string {
+			var res []string
+			for _, expression := range expressions {
+				expression = strings.ReplaceAll(expression, "\r", "")
+				expression = strings.ReplaceAll(expression, "\n", "")
+				res = append(res, expression)
+			}
+			return res
+		}
+
+		var res fauxargocd.IgnoreDifferences
+		for _, ignoreDifference := range input {
+			sanitized := fauxargocd.ResourceIgnoreDifferences{
+				Group:             sanitize(ignoreDifference.Group),
+				Kind:              sanitize(ignoreDifference.Kind),
+				Name:              sanitize(ignoreDifference.Name),
+				Namespace:         sanitize(ignoreDifference.Namespace),
+				JQPathExpressions: sanitizeJQPathExpressions(ignoreDifference.JQPathExpressions),
+			}
+			if len(ignoreDifference.JSONPointers) > 0 {
+				sanitized.JSONPointers = sanitizeArray(ignoreDifference.JSONPointers)
+			}
+			res = append(res, sanitized)
+		}
+		return res
+	}
+
+	sourceHelm, err := sanitizeHelm(fieldsParam.sourceHelm)
+	if err != nil {
+		return "", err
+	}
+
+	sourceKustomize, err := sanitizeKustomize(fieldsParam.sourceKustomize)
+	if err != nil {
+		return "", err
+	}
+
+	var sources []fauxargocd.ApplicationSource
+	for _, source := range fieldsParam.sources {
+		sanitizedSource, err := sanitizeSource(source)
+		if err != nil {
+			return "", err
+		}
+		sources = append(sources, sanitizedSource)
+	}
+
 	fields := argoCDSpecInput{
 		// MAKE SURE YOU SANITIZE ANY NEW FIELDS THAT ARE ADDED!!!!
 		crName:               sanitize(fieldsParam.crName),
@@ -1248,8 +1791,15 @@ func createSpecField(fieldsParam argoCDSpecInput) (string, error) {
 		sourceRepoURL:        sanitize(fieldsParam.sourceRepoURL),
 		sourcePath:           sanitize(fieldsParam.sourcePath),
 		sourceTargetRevision: sanitize(fieldsParam.sourceTargetRevision),
+		sourceChart:          sanitize(fieldsParam.sourceChart),
+		sourceHelm:           sourceHelm,
+		sourceKustomize:      sourceKustomize,
+		sources:              sources,
 		syncOptions:          sanitizeArray(fieldsParam.syncOptions),
 		automated:            fieldsParam.automated,
+		automatedPolicy:      fieldsParam.automatedPolicy,
+		retry:                sanitizeRetry(fieldsParam.retry),
+		ignoreDifferences:    sanitizeIgnoreDifferences(fieldsParam.ignoreDifferences),
 		project:              sanitize(fieldsParam.project),
 		// MAKE SURE YOU SANITIZE ANY NEW FIELDS THAT ARE ADDED!!!!
 		// Hopefully you are getting the message, here :)
@@ -1269,22 +1819,37 @@ func createSpecField(fieldsParam argoCDSpecInput) (string, error) {
 				RepoURL:        fields.sourceRepoURL,
 				Path:           fields.sourcePath,
 				TargetRevision: fields.sourceTargetRevision,
+				Chart:          fields.sourceChart,
+				Helm:           fields.sourceHelm,
+				Kustomize:      fields.sourceKustomize,
 			},
 			Destination: fauxargocd.ApplicationDestination{
 				Name:      fields.destinationName,
 				Namespace: fields.destinationNamespace,
 			},
-			Project: fields.project,
+			IgnoreDifferences: fields.ignoreDifferences,
+			Project:           fields.project,
 		},
 	}
 
+	// An Argo CD Application with multiple sources should not also define .spec.source
+	if len(fields.sources) > 0 {
+		application.Spec.Source = fauxargocd.ApplicationSource{}
+		application.Spec.Sources = fields.sources
+	}
+
 	if fields.automated {
+		automatedPolicy := &fauxargocd.SyncPolicyAutomated{
+			Prune:      true,
+			SelfHeal:   true,
+			AllowEmpty: true,
+		}
+		if fields.automatedPolicy != nil {
+			automatedPolicy = fields.automatedPolicy
+		}
+
 		application.Spec.SyncPolicy = &fauxargocd.SyncPolicy{
-			Automated: &fauxargocd.SyncPolicyAutomated{
-				Prune:      true,
-				SelfHeal:   true,
-				AllowEmpty: true,
-			},
+			Automated: automatedPolicy,
 			SyncOptions: fauxargocd.SyncOptions{
 				prunePropagationPolicy,
 			},
@@ -1303,6 +1868,15 @@ func createSpecField(fieldsParam argoCDSpecInput) (string, error) {
 		application.Spec.SyncPolicy = nil
 	}
 
+	if fields.retry != nil {
+
+		if application.Spec.SyncPolicy == nil {
+			application.Spec.SyncPolicy = &fauxargocd.SyncPolicy{}
+		}
+
+		application.Spec.SyncPolicy.Retry = fields.retry
+	}
+
 	if len(fields.syncOptions) > 0 {
 
 		if application.Spec.SyncPolicy == nil {
@@ -1324,9 +1898,218 @@ func createSpecField(fieldsParam argoCDSpecInput) (string, error) {
 		return "", err
 	}
 
+	if len(resBytes) > db.ApplicationSpecFieldLength {
+		return "", fmt.Errorf("%w: spec field length %d exceeds maximum %d", errSpecFieldTooLong, len(resBytes), db.ApplicationSpecFieldLength)
+	}
+
 	return string(resBytes), nil
 }
 
+// specFieldUserError returns a user error if the error returned by createSpecField was caused by the contents of the
+// GitOpsDeployment, or nil otherwise.
+func specFieldUserError(err error) gitopserrors.UserError {
+	for _, userErr := range []error{errInvalidHelmValues, errUnsupportedSourceCharacters, errSpecFieldTooLong} {
+		if errors.Is(err, userErr) {
+			return gitopserrors.NewUserDevError(userErr.Error(), err)
+		}
+	}
+	return nil
+}
+
+// validateGitOpsDeploymentSource performs basic validation of the source(s) of a GitOpsDeployment, returning a user
+// error message if the source is invalid, or "" otherwise.
+func validateGitOpsDeploymentSource(spec managedgitopsv1alpha1.GitOpsDeploymentSpec) string {
+
+	if !spec.HasMultipleSources() {
+		// A path is only required when the source is not a Helm chart
+		if spec.Source.Path == "" && spec.Source.Chart == "" {
+			return managedgitopsv1alpha1.GitOpsDeploymentUserError_PathIsRequired
+		} else if spec.Source.Path == "/" {
+			return managedgitopsv1alpha1.GitOpsDeploymentUserError_InvalidPathSlash
+		} else if spec.Source.Path != "" && spec.Source.Chart != "" {
+			return managedgitopsv1alpha1.GitOpsDeploymentUserError_PathAndChart
+		}
+		return ""
+	}
+
+	if spec.Source.RepoURL != "" || spec.Source.Path != "" || spec.Source.Chart != "" {
+		return managedgitopsv1alpha1.GitOpsDeploymentUserError_SourceAndSources
+	}
+
+	for _, source := range spec.Sources {
+		// A source which only provides files (such as Helm values) to the other sources, via 'ref', does not need a path
+		if source.Path == "" && source.Chart == "" && source.Ref == "" {
+			return managedgitopsv1alpha1.GitOpsDeploymentUserError_SourcesPathIsRequired
+		} else if source.Path == "/" {
+			return managedgitopsv1alpha1.GitOpsDeploymentUserError_SourcesPathSlash
+		} else if source.Path != "" && source.Chart != "" {
+			return managedgitopsv1alpha1.GitOpsDeploymentUserError_SourcesPathAndChart
+		}
+	}
+
+	return ""
+}
+
+// convertSyncPolicyAutomated converts the automated sync policy of a GitOpsDeployment into the corresponding Argo CD
+// Application sync policy. Fields which are not specified default to true.
+func convertSyncPolicyAutomated(automated *managedgitopsv1alpha1.SyncPolicyAutomated) *fauxargocd.SyncPolicyAutomated {
+	if automated == nil {
+		return nil
+	}
+
+	valueOrTrue := func(value *bool) bool {
+		return value == nil || *value
+	}
+
+	return &fauxargocd.SyncPolicyAutomated{
+		Prune:      valueOrTrue(automated.Prune),
+		SelfHeal:   valueOrTrue(automated.SelfHeal),
+		AllowEmpty: valueOrTrue(automated.AllowEmpty),
+	}
+}
+
+// convertRetryStrategy converts the retry strategy of a GitOpsDeployment into the corresponding Argo CD Application retry strategy
+func convertRetryStrategy(retry managedgitopsv1alpha1.RetryStrategy) *fauxargocd.RetryStrategy {
+	res := &fauxargocd.RetryStrategy{
+		Limit: retry.Limit,
+	}
+
+	if retry.Backoff != nil {
+		res.Backoff = &fauxargocd.Backoff{
+			Duration:    retry.Backoff.Duration,
+			Factor:      retry.Backoff.Factor,
+			MaxDuration: retry.Backoff.MaxDuration,
+		}
+	}
+
+	return res
+}
+
+// convertIgnoreDifferences converts the ignored differences of a GitOpsDeployment into the corresponding Argo CD Application field
+func convertIgnoreDifferences(ignoreDifferences managedgitopsv1alpha1.IgnoreDifferences) fauxargocd.IgnoreDifferences {
+	var res fauxargocd.IgnoreDifferences
+
+	for _, ignoreDifference := range ignoreDifferences {
+		res = append(res, fauxargocd.ResourceIgnoreDifferences{
+			Group:             ignoreDifference.Group,
+			Kind:              ignoreDifference.Kind,
+			Name:              ignoreDifference.Name,
+			Namespace:         ignoreDifference.Namespace,
+			JSONPointers:      ignoreDifference.JSONPointers,
+			JQPathExpressions: ignoreDifference.JQPathExpressions,
+		})
+	}
+
+	return res
+}
+
+// convertApplicationSources converts the sources of a multi-source GitOpsDeployment into the corresponding Argo CD Application sources
+func convertApplicationSources(sources managedgitopsv1alpha1.ApplicationSources) []fauxargocd.ApplicationSource {
+	var res []fauxargocd.ApplicationSource
+
+	for _, source := range sources {
+		res = append(res, fauxargocd.ApplicationSource{
+			RepoURL:        source.RepoURL,
+			Path:           source.Path,
+			TargetRevision: source.TargetRevision,
+			Chart:          source.Chart,
+			Helm:           convertHelmSource(source.Helm),
+			Kustomize:      convertKustomizeSource(source.Kustomize),
+			Ref:            source.Ref,
+		})
+	}
+
+	return res
+}
+
+// extractReconciledSource converts a source of the .status.sync.comparedTo field of an Argo CD Application into the GitOpsDeployment representation
+func extractReconciledSource(source fauxargocd.ApplicationSource) managedgitopsv1alpha1.GitOpsDeploymentSource {
+	return managedgitopsv1alpha1.GitOpsDeploymentSource{
+		Path:      source.Path,
+		RepoURL:   source.RepoURL,
+		Branch:    source.TargetRevision,
+		Chart:     source.Chart,
+		Kustomize: extractKustomizeSource(source.Kustomize),
+	}
+}
+
+// convertHelmSource converts the Helm options of a GitOpsDeployment source into the corresponding Argo CD Application options
+func convertHelmSource(helm *managedgitopsv1alpha1.ApplicationSourceHelm) *fauxargocd.ApplicationSourceHelm {
+	if helm == nil {
+		return nil
+	}
+
+	res := &fauxargocd.ApplicationSourceHelm{
+		ValueFiles:  helm.ValueFiles,
+		ReleaseName: helm.ReleaseName,
+		Values:      helm.Values,
+	}
+
+	for _, param := range helm.Parameters {
+		res.Parameters = append(res.Parameters, fauxargocd.HelmParameter{
+			Name:        param.Name,
+			Value:       param.Value,
+			ForceString: param.ForceString,
+		})
+	}
+
+	return res
+}
+
+// convertKustomizeSource converts the Kustomize options of a GitOpsDeployment source into the corresponding Argo CD Application options
+func convertKustomizeSource(kustomize *managedgitopsv1alpha1.ApplicationSourceKustomize) *fauxargocd.ApplicationSourceKustomize {
+	if kustomize == nil {
+		return nil
+	}
+
+	res := &fauxargocd.ApplicationSourceKustomize{
+		NamePrefix:        kustomize.NamePrefix,
+		NameSuffix:        kustomize.NameSuffix,
+		CommonLabels:      kustomize.CommonLabels,
+		CommonAnnotations: kustomize.CommonAnnotations,
+	}
+
+	for _, image := range kustomize.Images {
+		res.Images = append(res.Images, fauxargocd.KustomizeImage(image))
+	}
+
+	for _, replica := range kustomize.Replicas {
+		res.Replicas = append(res.Replicas, fauxargocd.KustomizeReplica{
+			Name:  replica.Name,
+			Count: fauxargocd.KustomizeReplicaCount(replica.Count),
+		})
+	}
+
+	return res
+}
+
+// extractKustomizeSource converts the Kustomize options of an Argo CD Application source into the GitOpsDeployment representation
+func extractKustomizeSource(kustomize *fauxargocd.ApplicationSourceKustomize) *managedgitopsv1alpha1.ApplicationSourceKustomize {
+	if kustomize == nil {
+		return nil
+	}
+
+	res := &managedgitopsv1alpha1.ApplicationSourceKustomize{
+		NamePrefix:        kustomize.NamePrefix,
+		NameSuffix:        kustomize.NameSuffix,
+		CommonLabels:      kustomize.CommonLabels,
+		CommonAnnotations: kustomize.CommonAnnotations,
+	}
+
+	for _, image := range kustomize.Images {
+		res.Images = append(res.Images, managedgitopsv1alpha1.KustomizeImage(image))
+	}
+
+	for _, replica := range kustomize.Replicas {
+		res.Replicas = append(res.Replicas, managedgitopsv1alpha1.KustomizeReplica{
+			Name:  replica.Name,
+			Count: int32(replica.Count),
+		})
+	}
+
+	return res
+}
+
 // Decompress byte array received from ApplicationState table and convert it into Application status.
 func decompressApplicationStatus(statusBytes []byte) (*fauxargocd.FauxApplicationStatus, error) {
 	appStatus := &fauxargocd.FauxApplicationStatus{}
@@ -1348,12 +2131,14 @@ func extractResourceStatus(resources []fauxargocd.ResourceStatus) []managedgitop
 
 	for index, resource := range resources {
 		resourceStatus[index] = managedgitopsv1alpha1.ResourceStatus{
-			Group:     resource.Group,
-			Version:   resource.Version,
-			Kind:      resource.Kind,
-			Namespace: resource.Namespace,
-			Name:      resource.Name,
-			Status:    managedgitopsv1alpha1.SyncStatusCode(resource.Status),
+			Group:           resource.Group,
+			Version:         resource.Version,
+			Kind:            resource.Kind,
+			Namespace:       resource.Namespace,
+			Name:            resource.Name,
+			Status:          managedgitopsv1alpha1.SyncStatusCode(resource.Status),
+			RequiresPruning: resource.RequiresPruning,
+			Hook:            resource.Hook,
 		}
 		if resource.Health != nil {
 			resourceStatus[index].Health = &managedgitopsv1alpha1.HealthStatus{
@@ -1385,6 +2170,25 @@ func extractOperationState(fauxOpState *fauxargocd.OperationState) (*managedgito
 	return opState, nil
 }
 
+func extractRevisionHistory(fauxHistory fauxargocd.RevisionHistories) ([]managedgitopsv1alpha1.RevisionHistory, error) {
+	if len(fauxHistory) == 0 {
+		return nil, nil
+	}
+
+	historyBytes, err := json.Marshal(fauxHistory)
+	if err != nil {
+		return nil, err
+	}
+
+	history := []managedgitopsv1alpha1.RevisionHistory{}
+	err = json.Unmarshal(historyBytes, &history)
+	if err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
 func getInt64Pointer(i int) *int64 {
 	i64 := int64(i)
 	return &i64
@@ -13,12 +13,14 @@ import (
 	sharedutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util"
 	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/fauxargocd"
 	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/tests"
+	"github.com/redhat-appstudio/managed-gitops/backend/eventloop/eventlooptypes"
 	"github.com/redhat-appstudio/managed-gitops/backend/eventloop/shared_resource_loop"
 	"gopkg.in/yaml.v2"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/uuid"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	fake "sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -119,6 +121,743 @@ var _ = Describe("Application Event Runner Deployments", func() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(application).To(Equal(getValidApplication(true)))
 		})
+
+		It("Input spec with a Helm chart source should set the chart and helm fields", func() {
+			input := getFakeArgoCDSpecInput(false, false)
+			input.sourcePath = ""
+			input.sourceChart = "my-chart"
+			input.sourceTargetRevision = "1.2.3"
+			input.sourceHelm = &fauxargocd.ApplicationSourceHelm{
+				ValueFiles:  []string{"values-prod.yaml"},
+				ReleaseName: "my-release",
+				Values:      "replicaCount: 2\n",
+				Parameters: []fauxargocd.HelmParameter{
+					{Name: "image.tag", Value: "v1", ForceString: true},
+				},
+			}
+
+			applicationStr, err := createSpecField(input)
+			Expect(err).ToNot(HaveOccurred())
+
+			application := fauxargocd.FauxApplication{}
+			Expect(yaml.Unmarshal([]byte(applicationStr), &application)).To(Succeed())
+
+			Expect(application.Spec.Source.Path).To(BeEmpty())
+			Expect(application.Spec.Source.Chart).To(Equal("my-chart"))
+			Expect(application.Spec.Source.TargetRevision).To(Equal("1.2.3"))
+			Expect(application.Spec.Source.Helm).ToNot(BeNil())
+			Expect(application.Spec.Source.Helm.ValueFiles).To(Equal([]string{"values-prod.yaml"}))
+			Expect(application.Spec.Source.Helm.ReleaseName).To(Equal("my-release"))
+			Expect(application.Spec.Source.Helm.Values).To(Equal("replicaCount: 2\n"))
+			Expect(application.Spec.Source.Helm.Parameters).To(Equal([]fauxargocd.HelmParameter{
+				{Name: "image.tag", Value: "v1", ForceString: true},
+			}))
+		})
+
+		It("Input spec with a Helm parameter value containing an unsupported character should return an error", func() {
+			input := getFakeArgoCDSpecInput(false, false)
+			input.sourceHelm = &fauxargocd.ApplicationSourceHelm{
+				Parameters: []fauxargocd.HelmParameter{{Name: "image.tag", Value: "v1\n"}},
+			}
+
+			_, err := createSpecField(input)
+			Expect(err).To(MatchError(errUnsupportedSourceCharacters))
+			Expect(specFieldUserError(err)).ToNot(BeNil())
+		})
+
+		It("Input spec with invalid Helm values should return an error", func() {
+			input := getFakeArgoCDSpecInput(false, false)
+			input.sourceHelm = &fauxargocd.ApplicationSourceHelm{
+				Values: "- not\n- a map",
+			}
+
+			_, err := createSpecField(input)
+			Expect(err).To(MatchError(errInvalidHelmValues))
+		})
+
+		It("Input spec with Kustomize overrides should set the kustomize field", func() {
+			input := getFakeArgoCDSpecInput(false, false)
+			input.sourceKustomize = convertKustomizeSource(&managedgitopsv1alpha1.ApplicationSourceKustomize{
+				NamePrefix:   "prod-",
+				Images:       managedgitopsv1alpha1.KustomizeImages{"quay.io/org/app:v2"},
+				CommonLabels: map[string]string{"team": "payments"},
+				Replicas:     managedgitopsv1alpha1.KustomizeReplicas{{Name: "app", Count: 3}},
+			})
+
+			applicationStr, err := createSpecField(input)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(applicationStr).ToNot(ContainSubstring("helm"),
+				"empty optional fields should not be written to the spec field")
+
+			application := fauxargocd.FauxApplication{}
+			Expect(yaml.Unmarshal([]byte(applicationStr), &application)).To(Succeed())
+
+			Expect(application.Spec.Source.Kustomize).To(Equal(&fauxargocd.ApplicationSourceKustomize{
+				NamePrefix:   "prod-",
+				Images:       fauxargocd.KustomizeImages{"quay.io/org/app:v2"},
+				CommonLabels: map[string]string{"team": "payments"},
+				Replicas:     fauxargocd.KustomizeReplicas{{Name: "app", Count: 3}},
+			}))
+		})
+
+		It("Input spec with a Kustomize field containing an unsupported character should return an error", func() {
+			input := getFakeArgoCDSpecInput(false, false)
+			input.sourceKustomize = convertKustomizeSource(&managedgitopsv1alpha1.ApplicationSourceKustomize{
+				CommonAnnotations: map[string]string{"example.com/owner": "payments;"},
+			})
+
+			_, err := createSpecField(input)
+			Expect(err).To(MatchError(errUnsupportedSourceCharacters))
+		})
+	})
+
+	Context("checkValidApplicationSources should validate the Helm and Kustomize fields of each source", func() {
+
+		It("should return a user error if a Helm parameter value of a source contains an unsupported character", func() {
+			spec := managedgitopsv1alpha1.GitOpsDeploymentSpec{
+				Sources: managedgitopsv1alpha1.ApplicationSources{{
+					RepoURL: "https://charts.example.com",
+					Chart:   "my-chart",
+					Helm:    &managedgitopsv1alpha1.ApplicationSourceHelm{Parameters: []managedgitopsv1alpha1.HelmParameter{{Name: "image.tag", Value: "'v1'"}}},
+				}},
+			}
+
+			userErr := checkValidApplicationSources(spec)
+			Expect(userErr).ToNot(BeNil())
+			Expect(userErr.UserError()).To(ContainSubstring(".spec.source.helm.parameters"))
+		})
+
+		It("should accept a value file which is outside of the path of the source, but within the repository", func() {
+			spec := managedgitopsv1alpha1.GitOpsDeploymentSpec{
+				Source: managedgitopsv1alpha1.ApplicationSource{
+					RepoURL: "https://github.com/test/test",
+					Path:    "apps/my-app",
+					Helm:    &managedgitopsv1alpha1.ApplicationSourceHelm{ValueFiles: []string{"../../envs/prod/values.yaml"}},
+				},
+			}
+
+			Expect(checkValidApplicationSources(spec)).To(BeNil())
+
+			spec.Source.Helm.ValueFiles = []string{"../../../values.yaml"}
+			Expect(checkValidApplicationSources(spec)).ToNot(BeNil())
+		})
+	})
+
+	Context("createSpecField should generate the automated sync policy of the GitOpsDeployment", func() {
+
+		It("Input spec with an automated sync policy should only enable the specified automated sync behaviours", func() {
+			prune, allowEmpty := false, false
+			input := argoCDSpecInput{
+				crName:               "sample-depl",
+				crNamespace:          "workspace",
+				destinationNamespace: "prod",
+				sourceRepoURL:        "https://github.com/test/test",
+				sourcePath:           "environments/prod",
+				automated:            true,
+				automatedPolicy: convertSyncPolicyAutomated(&managedgitopsv1alpha1.SyncPolicyAutomated{
+					Prune:      &prune,
+					AllowEmpty: &allowEmpty,
+				}),
+				syncOptions: managedgitopsv1alpha1.SyncOptionToStringSlice(managedgitopsv1alpha1.SyncOptions{
+					managedgitopsv1alpha1.SyncOptions_ServerSideApply_true,
+				}),
+			}
+
+			applicationStr, err := createSpecField(input)
+			Expect(err).ToNot(HaveOccurred())
+
+			application := fauxargocd.FauxApplication{}
+			Expect(yaml.Unmarshal([]byte(applicationStr), &application)).To(Succeed())
+
+			Expect(application.Spec.SyncPolicy).ToNot(BeNil())
+			Expect(application.Spec.SyncPolicy.Automated).To(Equal(&fauxargocd.SyncPolicyAutomated{
+				Prune:      false,
+				SelfHeal:   true,
+				AllowEmpty: false,
+			}))
+			Expect(application.Spec.SyncPolicy.SyncOptions).To(Equal(fauxargocd.SyncOptions{
+				prunePropagationPolicy,
+				string(managedgitopsv1alpha1.SyncOptions_ServerSideApply_true),
+			}))
+		})
+
+		It("Input spec with an automated sync policy should ignore it, if the GitOpsDeployment is not automated", func() {
+			prune := false
+			input := argoCDSpecInput{
+				crName:          "sample-depl",
+				sourceRepoURL:   "https://github.com/test/test",
+				sourcePath:      "environments/prod",
+				automated:       false,
+				automatedPolicy: convertSyncPolicyAutomated(&managedgitopsv1alpha1.SyncPolicyAutomated{Prune: &prune}),
+			}
+
+			applicationStr, err := createSpecField(input)
+			Expect(err).ToNot(HaveOccurred())
+
+			application := fauxargocd.FauxApplication{}
+			Expect(yaml.Unmarshal([]byte(applicationStr), &application)).To(Succeed())
+			Expect(application.Spec.SyncPolicy).To(BeNil())
+		})
+	})
+
+	Context("createSpecField should generate the retry strategy of the GitOpsDeployment", func() {
+
+		retry := managedgitopsv1alpha1.RetryStrategy{
+			Limit: 5,
+			Backoff: &managedgitopsv1alpha1.Backoff{
+				Duration:    "10s\n",
+				Factor:      getInt64Pointer(3),
+				MaxDuration: "10m",
+			},
+		}
+
+		expectedRetry := &fauxargocd.RetryStrategy{
+			Limit: 5,
+			Backoff: &fauxargocd.Backoff{
+				Duration:    "10s",
+				Factor:      getInt64Pointer(3),
+				MaxDuration: "10m",
+			},
+		}
+
+		It("Input spec with a retry strategy should override the default retry strategy of an automated Application", func() {
+			input := argoCDSpecInput{
+				crName:        "sample-depl",
+				sourceRepoURL: "https://github.com/test/test",
+				sourcePath:    "environments/prod",
+				automated:     true,
+				retry:         convertRetryStrategy(retry),
+			}
+
+			applicationStr, err := createSpecField(input)
+			Expect(err).ToNot(HaveOccurred())
+
+			application := fauxargocd.FauxApplication{}
+			Expect(yaml.Unmarshal([]byte(applicationStr), &application)).To(Succeed())
+
+			Expect(application.Spec.SyncPolicy).ToNot(BeNil())
+			Expect(application.Spec.SyncPolicy.Automated).ToNot(BeNil())
+			Expect(application.Spec.SyncPolicy.Retry).To(Equal(expectedRetry))
+		})
+
+		It("Input spec with a retry strategy should set the retry strategy of a manual Application", func() {
+			input := argoCDSpecInput{
+				crName:        "sample-depl",
+				sourceRepoURL: "https://github.com/test/test",
+				sourcePath:    "environments/prod",
+				automated:     false,
+				retry:         convertRetryStrategy(retry),
+			}
+
+			applicationStr, err := createSpecField(input)
+			Expect(err).ToNot(HaveOccurred())
+
+			application := fauxargocd.FauxApplication{}
+			Expect(yaml.Unmarshal([]byte(applicationStr), &application)).To(Succeed())
+
+			Expect(application.Spec.SyncPolicy).ToNot(BeNil())
+			Expect(application.Spec.SyncPolicy.Automated).To(BeNil())
+			Expect(application.Spec.SyncPolicy.Retry).To(Equal(expectedRetry))
+		})
+	})
+
+	Context("createSpecField should generate the ignoreDifferences of the GitOpsDeployment", func() {
+
+		It("Input spec with ignoreDifferences should be sanitized and set in the Application", func() {
+			input := argoCDSpecInput{
+				crName:        "sample-depl",
+				sourceRepoURL: "https://github.com/test/test",
+				sourcePath:    "environments/prod",
+				ignoreDifferences: convertIgnoreDifferences(managedgitopsv1alpha1.IgnoreDifferences{
+					{
+						Group:        "apps",
+						Kind:         "Deployment;",
+						Name:         "my-deployment",
+						JSONPointers: []string{"/spec/replicas"},
+					},
+					{
+						Kind:              "ConfigMap",
+						JQPathExpressions: []string{".data[\"generated\"]\n"},
+					},
+				}),
+			}
+
+			applicationStr, err := createSpecField(input)
+			Expect(err).ToNot(HaveOccurred())
+
+			application := fauxargocd.FauxApplication{}
+			Expect(yaml.Unmarshal([]byte(applicationStr), &application)).To(Succeed())
+
+			Expect(application.Spec.IgnoreDifferences).To(Equal(fauxargocd.IgnoreDifferences{
+				{
+					Group:        "apps",
+					Kind:         "Deployment",
+					Name:         "my-deployment",
+					JSONPointers: []string{"/spec/replicas"},
+				},
+				{
+					Kind:              "ConfigMap",
+					JQPathExpressions: []string{".data[\"generated\"]"},
+				},
+			}))
+		})
+
+		It("Input spec without ignoreDifferences should not set the field in the Application", func() {
+			input := argoCDSpecInput{
+				crName:        "sample-depl",
+				sourceRepoURL: "https://github.com/test/test",
+				sourcePath:    "environments/prod",
+			}
+
+			applicationStr, err := createSpecField(input)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(applicationStr).ToNot(ContainSubstring("ignoredifferences"))
+		})
+	})
+
+	Context("checkValidIgnoreDifferences should validate the entries of ignoreDifferences", func() {
+
+		DescribeTable("validates the ignoreDifferences entry",
+			func(ignoreDifference managedgitopsv1alpha1.ResourceIgnoreDifferences, expectValid bool) {
+				userErr := checkValidIgnoreDifferences(managedgitopsv1alpha1.IgnoreDifferences{ignoreDifference})
+				if expectValid {
+					Expect(userErr).To(BeNil())
+				} else {
+					Expect(userErr).ToNot(BeNil())
+				}
+			},
+			Entry("a JSON pointer", managedgitopsv1alpha1.ResourceIgnoreDifferences{Group: "apps", Kind: "Deployment", JSONPointers: []string{"/spec/replicas"}}, true),
+			Entry("a JQ path expression", managedgitopsv1alpha1.ResourceIgnoreDifferences{Kind: "ConfigMap", JQPathExpressions: []string{".data"}}, true),
+			Entry("no kind", managedgitopsv1alpha1.ResourceIgnoreDifferences{JSONPointers: []string{"/spec/replicas"}}, false),
+			Entry("no paths", managedgitopsv1alpha1.ResourceIgnoreDifferences{Kind: "Deployment"}, false),
+			Entry("a JSON pointer without a leading slash", managedgitopsv1alpha1.ResourceIgnoreDifferences{Kind: "Deployment", JSONPointers: []string{"spec/replicas"}}, false),
+		)
+	})
+
+	Context("checkValidRetryStrategy should validate the backoff of the retry strategy", func() {
+
+		DescribeTable("validates the retry strategy",
+			func(backoff *managedgitopsv1alpha1.Backoff, expectValid bool) {
+				userErr := checkValidRetryStrategy(managedgitopsv1alpha1.RetryStrategy{Limit: 3, Backoff: backoff})
+				if expectValid {
+					Expect(userErr).To(BeNil())
+				} else {
+					Expect(userErr).ToNot(BeNil())
+				}
+			},
+			Entry("no backoff", nil, true),
+			Entry("durations in seconds", &managedgitopsv1alpha1.Backoff{Duration: "5", MaxDuration: "180"}, true),
+			Entry("duration strings", &managedgitopsv1alpha1.Backoff{Duration: "5s", Factor: getInt64Pointer(2), MaxDuration: "3m"}, true),
+			Entry("an invalid duration", &managedgitopsv1alpha1.Backoff{Duration: "five seconds"}, false),
+			Entry("an invalid max duration", &managedgitopsv1alpha1.Backoff{MaxDuration: "-3m"}, false),
+			Entry("a factor less than 1", &managedgitopsv1alpha1.Backoff{Factor: getInt64Pointer(0)}, false),
+			Entry("a max duration less than the duration", &managedgitopsv1alpha1.Backoff{Duration: "5m", MaxDuration: "180"}, false),
+		)
+	})
+
+	Context("checkValidSyncOption should only accept the supported sync options", func() {
+
+		DescribeTable("validates the sync option",
+			func(syncOption managedgitopsv1alpha1.SyncOption, expectValid bool) {
+				userErr := checkValidSyncOption([]managedgitopsv1alpha1.SyncOption{syncOption})
+				if expectValid {
+					Expect(userErr).To(BeNil())
+				} else {
+					Expect(userErr).ToNot(BeNil())
+				}
+			},
+			Entry("CreateNamespace=true", managedgitopsv1alpha1.SyncOptions_CreateNamespace_true, true),
+			Entry("ServerSideApply=true", managedgitopsv1alpha1.SyncOptions_ServerSideApply_true, true),
+			Entry("PruneLast=false", managedgitopsv1alpha1.SyncOptions_PruneLast_false, true),
+			Entry("ApplyOutOfSyncOnly=true", managedgitopsv1alpha1.SyncOptions_ApplyOutOfSyncOnly_true, true),
+			Entry("Replace=true", managedgitopsv1alpha1.SyncOptions_Replace_true, true),
+			Entry("RespectIgnoreDifferences=true", managedgitopsv1alpha1.SyncOptions_RespectIgnoreDifferences_true, true),
+			Entry("a misspelled option", managedgitopsv1alpha1.SyncOption("ServerSideApply=yes"), false),
+			Entry("an unsupported option", managedgitopsv1alpha1.SyncOption("Validate=false"), false),
+		)
+	})
+
+	Context("checkValidAutomatedSyncPolicy should only accept an automated sync policy for automated GitOpsDeployments", func() {
+
+		It("accepts an automated sync policy for an automated GitOpsDeployment", func() {
+			spec := managedgitopsv1alpha1.GitOpsDeploymentSpec{
+				Type:       managedgitopsv1alpha1.GitOpsDeploymentSpecType_Automated,
+				SyncPolicy: &managedgitopsv1alpha1.SyncPolicy{Automated: &managedgitopsv1alpha1.SyncPolicyAutomated{}},
+			}
+			Expect(checkValidAutomatedSyncPolicy(spec)).To(BeNil())
+		})
+
+		It("rejects an automated sync policy for a manual GitOpsDeployment", func() {
+			spec := managedgitopsv1alpha1.GitOpsDeploymentSpec{
+				Type:       managedgitopsv1alpha1.GitOpsDeploymentSpecType_Manual,
+				SyncPolicy: &managedgitopsv1alpha1.SyncPolicy{Automated: &managedgitopsv1alpha1.SyncPolicyAutomated{}},
+			}
+			userErr := checkValidAutomatedSyncPolicy(spec)
+			Expect(userErr).ToNot(BeNil())
+			Expect(userErr.UserError()).To(Equal(managedgitopsv1alpha1.GitOpsDeploymentUserError_AutomatedNotAutomated))
+		})
+	})
+
+	Context("createSpecField should generate a valid argocd Application with multiple sources", func() {
+
+		It("Input spec with multiple sources should set the sources field, and not the source field", func() {
+			input := argoCDSpecInput{
+				crName:               "sample-depl",
+				crNamespace:          "workspace",
+				destinationNamespace: "prod",
+				destinationName:      "in-cluster",
+				project:              "app-project-cluster-user-id",
+				sources: convertApplicationSources(managedgitopsv1alpha1.ApplicationSources{
+					{
+						RepoURL:        "https://charts.example.com",
+						Chart:          "my-chart",
+						TargetRevision: "1.2.3",
+						Helm: &managedgitopsv1alpha1.ApplicationSourceHelm{
+							ValueFiles: []string{"$values/environments/prod/values.yaml"},
+						},
+					},
+					{
+						RepoURL: "https://github.com/test/values;",
+						Ref:     "values",
+					},
+				}),
+			}
+
+			applicationStr, err := createSpecField(input)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("verifying that the .spec.source field is not set")
+			specField := map[string]interface{}{}
+			Expect(yaml.Unmarshal([]byte(applicationStr), &specField)).To(Succeed())
+			Expect(specField["spec"]).ToNot(HaveKey("source"))
+
+			application := fauxargocd.FauxApplication{}
+			Expect(yaml.Unmarshal([]byte(applicationStr), &application)).To(Succeed())
+
+			Expect(application.Spec.Sources).To(Equal(fauxargocd.ApplicationSources{
+				{
+					RepoURL:        "https://charts.example.com",
+					Chart:          "my-chart",
+					TargetRevision: "1.2.3",
+					Helm: &fauxargocd.ApplicationSourceHelm{
+						ValueFiles: []string{"$values/environments/prod/values.yaml"},
+					},
+				},
+				{
+					RepoURL: "https://github.com/test/values",
+					Ref:     "values",
+				},
+			}))
+		})
+
+		It("Input spec which is too large for the database should return an error", func() {
+			input := argoCDSpecInput{
+				crName:     "sample-depl",
+				sourcePath: "environments/prod",
+			}
+			for i := 0; i < db.ApplicationSpecFieldLength/50; i++ {
+				input.sources = append(input.sources, fauxargocd.ApplicationSource{
+					RepoURL: fmt.Sprintf("https://github.com/test/a-repository-with-a-fairly-long-name-%d", i),
+					Path:    "environments/prod",
+				})
+			}
+
+			_, err := createSpecField(input)
+			Expect(err).To(MatchError(errSpecFieldTooLong))
+			Expect(specFieldUserError(err)).ToNot(BeNil())
+		})
+	})
+
+	Context("validateGitOpsDeploymentSource should validate the source(s) of a GitOpsDeployment", func() {
+
+		DescribeTable("returns the expected user error",
+			func(spec managedgitopsv1alpha1.GitOpsDeploymentSpec, expectedUserError string) {
+				Expect(validateGitOpsDeploymentSource(spec)).To(Equal(expectedUserError))
+			},
+			Entry("single source with a path", managedgitopsv1alpha1.GitOpsDeploymentSpec{
+				Source: managedgitopsv1alpha1.ApplicationSource{RepoURL: "https://github.com/test/test", Path: "environments/prod"},
+			}, ""),
+			Entry("single source without a path or chart", managedgitopsv1alpha1.GitOpsDeploymentSpec{
+				Source: managedgitopsv1alpha1.ApplicationSource{RepoURL: "https://github.com/test/test"},
+			}, managedgitopsv1alpha1.GitOpsDeploymentUserError_PathIsRequired),
+			Entry("multiple sources, including a ref-only source", managedgitopsv1alpha1.GitOpsDeploymentSpec{
+				Sources: managedgitopsv1alpha1.ApplicationSources{
+					{RepoURL: "https://charts.example.com", Chart: "my-chart"},
+					{RepoURL: "https://github.com/test/values", Ref: "values"},
+				},
+			}, ""),
+			Entry("both source and sources", managedgitopsv1alpha1.GitOpsDeploymentSpec{
+				Source:  managedgitopsv1alpha1.ApplicationSource{RepoURL: "https://github.com/test/test", Path: "environments/prod"},
+				Sources: managedgitopsv1alpha1.ApplicationSources{{RepoURL: "https://github.com/test/test", Path: "environments/prod"}},
+			}, managedgitopsv1alpha1.GitOpsDeploymentUserError_SourceAndSources),
+			Entry("a source without a path, chart or ref", managedgitopsv1alpha1.GitOpsDeploymentSpec{
+				Sources: managedgitopsv1alpha1.ApplicationSources{{RepoURL: "https://github.com/test/test"}},
+			}, managedgitopsv1alpha1.GitOpsDeploymentUserError_SourcesPathIsRequired),
+			Entry("a source with a '/' path", managedgitopsv1alpha1.GitOpsDeploymentSpec{
+				Sources: managedgitopsv1alpha1.ApplicationSources{{RepoURL: "https://github.com/test/test", Path: "/"}},
+			}, managedgitopsv1alpha1.GitOpsDeploymentUserError_SourcesPathSlash),
+			Entry("a source with both a path and a chart", managedgitopsv1alpha1.GitOpsDeploymentSpec{
+				Sources: managedgitopsv1alpha1.ApplicationSources{{RepoURL: "https://github.com/test/test", Path: "chart", Chart: "my-chart"}},
+			}, managedgitopsv1alpha1.GitOpsDeploymentUserError_SourcesPathAndChart),
+		)
+	})
+
+	Context("sync windows should be validated, and their state reported in the status", func() {
+
+		// 10:00 UTC on a Monday
+		currentTime := time.Date(2023, time.May, 15, 10, 0, 0, 0, time.UTC)
+
+		officeHours := managedgitopsv1alpha1.SyncWindow{
+			Kind:     managedgitopsv1alpha1.SyncWindowKind_Deny,
+			Schedule: "0 9 * * 1-5",
+			Duration: "8h",
+		}
+
+		DescribeTable("checkValidSyncWindows returns a user error for invalid windows",
+			func(syncWindow managedgitopsv1alpha1.SyncWindow, expectValid bool) {
+				userErr := checkValidSyncWindows([]managedgitopsv1alpha1.SyncWindow{syncWindow})
+				if expectValid {
+					Expect(userErr).To(BeNil())
+				} else {
+					Expect(userErr).ToNot(BeNil())
+				}
+			},
+			Entry("a valid window", officeHours, true),
+			Entry("a valid window with a time zone", managedgitopsv1alpha1.SyncWindow{Kind: "allow", Schedule: "0 22 * * *", Duration: "1h30m", TimeZone: "Europe/London"}, true),
+			Entry("an invalid kind", managedgitopsv1alpha1.SyncWindow{Kind: "maybe", Schedule: "0 9 * * *", Duration: "1h"}, false),
+			Entry("an invalid schedule", managedgitopsv1alpha1.SyncWindow{Kind: "allow", Schedule: "every day", Duration: "1h"}, false),
+			Entry("an invalid duration", managedgitopsv1alpha1.SyncWindow{Kind: "allow", Schedule: "0 9 * * *", Duration: "1 hour"}, false),
+			Entry("an invalid time zone", managedgitopsv1alpha1.SyncWindow{Kind: "allow", Schedule: "0 9 * * *", Duration: "1h", TimeZone: "Mars/Olympus"}, false),
+		)
+
+		It("isSyncWindowActive reports whether the window is open at the given time", func() {
+			active, err := isSyncWindowActive(officeHours, currentTime)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(active).To(BeTrue())
+
+			active, err = isSyncWindowActive(officeHours, currentTime.Add(8*time.Hour))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(active).To(BeFalse())
+
+			By("evaluating the schedule in the time zone of the window")
+			// 09:00 in New York is 13:00 UTC (during daylight saving time), so the window is not yet open at 10:00 UTC
+			newYorkOfficeHours := officeHours
+			newYorkOfficeHours.TimeZone = "America/New_York"
+			active, err = isSyncWindowActive(newYorkOfficeHours, currentTime)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(active).To(BeFalse())
+
+			active, err = isSyncWindowActive(newYorkOfficeHours, currentTime.Add(4*time.Hour))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(active).To(BeTrue())
+		})
+
+		It("generateSyncWindowsStatus applies the same rules as Argo CD", func() {
+			By("returning nil if there are no sync windows")
+			Expect(generateSyncWindowsStatus(nil, currentTime)).To(BeNil())
+
+			By("denying syncs while a deny window is active")
+			Expect(generateSyncWindowsStatus([]managedgitopsv1alpha1.SyncWindow{officeHours}, currentTime)).To(Equal(&managedgitopsv1alpha1.SyncWindowsStatus{
+				Active:            true,
+				ActiveWindows:     []managedgitopsv1alpha1.SyncWindow{officeHours},
+				SyncAllowed:       false,
+				ManualSyncAllowed: false,
+			}))
+
+			By("allowing manual syncs while a deny window that enables them is active")
+			officeHoursManualSync := officeHours
+			officeHoursManualSync.ManualSync = true
+			Expect(generateSyncWindowsStatus([]managedgitopsv1alpha1.SyncWindow{officeHoursManualSync}, currentTime)).To(Equal(&managedgitopsv1alpha1.SyncWindowsStatus{
+				Active:            true,
+				ActiveWindows:     []managedgitopsv1alpha1.SyncWindow{officeHoursManualSync},
+				SyncAllowed:       false,
+				ManualSyncAllowed: true,
+			}))
+
+			By("allowing syncs once the deny window has closed")
+			Expect(generateSyncWindowsStatus([]managedgitopsv1alpha1.SyncWindow{officeHours}, currentTime.Add(8*time.Hour))).To(Equal(&managedgitopsv1alpha1.SyncWindowsStatus{
+				Active:            false,
+				SyncAllowed:       true,
+				ManualSyncAllowed: true,
+			}))
+
+			By("denying syncs outside of an allow window")
+			nightly := managedgitopsv1alpha1.SyncWindow{
+				Kind:     managedgitopsv1alpha1.SyncWindowKind_Allow,
+				Schedule: "0 22 * * *",
+				Duration: "2h",
+			}
+			Expect(generateSyncWindowsStatus([]managedgitopsv1alpha1.SyncWindow{nightly}, currentTime)).To(Equal(&managedgitopsv1alpha1.SyncWindowsStatus{
+				Active:            false,
+				SyncAllowed:       false,
+				ManualSyncAllowed: false,
+			}))
+
+			By("allowing syncs inside of an allow window")
+			Expect(generateSyncWindowsStatus([]managedgitopsv1alpha1.SyncWindow{nightly}, currentTime.Add(13*time.Hour))).To(Equal(&managedgitopsv1alpha1.SyncWindowsStatus{
+				Active:            true,
+				ActiveWindows:     []managedgitopsv1alpha1.SyncWindow{nightly},
+				SyncAllowed:       true,
+				ManualSyncAllowed: true,
+			}))
+		})
+	})
+
+	Context("decompressApplicationStatus should read the Kustomize fields of the Argo CD Application status", func() {
+		It("reads a Kustomize replica count that was stored as an IntOrString", func() {
+
+			// Argo CD declares the replica count as an IntOrString, which is written as a struct by CompressObject
+			type argoCDKustomizeReplica struct {
+				Name  string
+				Count intstr.IntOrString
+			}
+			argoCDStatus := map[string]interface{}{
+				"sync": map[string]interface{}{
+					"status": "Synced",
+					"comparedto": map[string]interface{}{
+						"source": map[string]interface{}{
+							"repourl": "https://github.com/test/test",
+							"kustomize": map[string]interface{}{
+								"images":   []string{"quay.io/org/app:v2"},
+								"replicas": []argoCDKustomizeReplica{{Name: "app", Count: intstr.FromInt(3)}},
+							},
+						},
+					},
+				},
+			}
+			statusBytes, err := sharedutil.CompressObject(argoCDStatus)
+			Expect(err).ToNot(HaveOccurred())
+
+			appStatus, err := decompressApplicationStatus(statusBytes)
+			Expect(err).ToNot(HaveOccurred())
+
+			kustomize := extractKustomizeSource(appStatus.Sync.ComparedTo.Source.Kustomize)
+			Expect(kustomize).To(Equal(&managedgitopsv1alpha1.ApplicationSourceKustomize{
+				Images:   managedgitopsv1alpha1.KustomizeImages{"quay.io/org/app:v2"},
+				Replicas: managedgitopsv1alpha1.KustomizeReplicas{{Name: "app", Count: 3}},
+			}))
+		})
+	})
+
+	Context("getUnreadyDependencies should report the dependencies which are not Synced and Healthy", func() {
+		It("returns the dependencies which do not exist, or are not both Synced and Healthy", func() {
+
+			scheme := runtime.NewScheme()
+			Expect(managedgitopsv1alpha1.AddToScheme(scheme)).To(Succeed())
+
+			newGitOpsDeployment := func(name string, sync managedgitopsv1alpha1.SyncStatusCode, health managedgitopsv1alpha1.HealthStatusCode) *managedgitopsv1alpha1.GitOpsDeployment {
+				return &managedgitopsv1alpha1.GitOpsDeployment{
+					ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "my-namespace"},
+					Status: managedgitopsv1alpha1.GitOpsDeploymentStatus{
+						Sync:   managedgitopsv1alpha1.SyncStatus{Status: sync},
+						Health: managedgitopsv1alpha1.HealthStatus{Status: health},
+					},
+				}
+			}
+
+			database := newGitOpsDeployment("database", managedgitopsv1alpha1.SyncStatusCodeSynced, managedgitopsv1alpha1.HeathStatusCodeHealthy)
+			cache := newGitOpsDeployment("cache", managedgitopsv1alpha1.SyncStatusCodeSynced, managedgitopsv1alpha1.HeathStatusCodeProgressing)
+			queue := newGitOpsDeployment("queue", managedgitopsv1alpha1.SyncStatusCodeOutOfSync, managedgitopsv1alpha1.HeathStatusCodeHealthy)
+
+			k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(database, cache, queue).Build()
+
+			frontend := newGitOpsDeployment("frontend", "", "")
+			frontend.Spec.DependsOn = []string{"database", "cache", "queue", "storage"}
+
+			unreadyDependencies, dependencyCycle, err := getUnreadyDependencies(context.Background(), k8sClient, frontend)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(unreadyDependencies).To(Equal([]string{"cache", "queue", "storage"}))
+			Expect(dependencyCycle).To(BeEmpty())
+
+			By("returning no dependencies once they are all Synced and Healthy")
+			frontend.Spec.DependsOn = []string{"database"}
+			unreadyDependencies, dependencyCycle, err = getUnreadyDependencies(context.Background(), k8sClient, frontend)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(unreadyDependencies).To(BeEmpty())
+			Expect(dependencyCycle).To(BeEmpty())
+		})
+
+		It("returns the dependency cycle that the GitOpsDeployment is part of", func() {
+
+			scheme := runtime.NewScheme()
+			Expect(managedgitopsv1alpha1.AddToScheme(scheme)).To(Succeed())
+
+			newGitOpsDeployment := func(name string, dependsOn ...string) *managedgitopsv1alpha1.GitOpsDeployment {
+				return &managedgitopsv1alpha1.GitOpsDeployment{
+					ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "my-namespace"},
+					Spec:       managedgitopsv1alpha1.GitOpsDeploymentSpec{DependsOn: dependsOn},
+					Status: managedgitopsv1alpha1.GitOpsDeploymentStatus{
+						Sync:   managedgitopsv1alpha1.SyncStatus{Status: managedgitopsv1alpha1.SyncStatusCodeSynced},
+						Health: managedgitopsv1alpha1.HealthStatus{Status: managedgitopsv1alpha1.HeathStatusCodeHealthy},
+					},
+				}
+			}
+
+			// frontend -> backend -> database -> frontend, with cache as a dependency outside of the cycle
+			frontend := newGitOpsDeployment("frontend", "cache", "backend")
+			backend := newGitOpsDeployment("backend", "database")
+			database := newGitOpsDeployment("database", "frontend")
+			cache := newGitOpsDeployment("cache")
+
+			k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(frontend, backend, database, cache).Build()
+
+			unreadyDependencies, dependencyCycle, err := getUnreadyDependencies(context.Background(), k8sClient, frontend)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(unreadyDependencies).To(BeEmpty())
+			Expect(dependencyCycle).To(Equal([]string{"frontend", "backend", "database", "frontend"}))
+			Expect(waitingForDependenciesMessage(unreadyDependencies, dependencyCycle)).To(ContainSubstring("frontend -> backend -> database -> frontend"))
+
+			By("not reporting a cycle for a GitOpsDeployment that is not part of it")
+			_, dependencyCycle, err = getUnreadyDependencies(context.Background(), k8sClient, cache)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(dependencyCycle).To(BeEmpty())
+		})
+	})
+
+	Context("queueHeldSyncRunEvents should queue an event for each SyncRun that is waiting for dependencies", func() {
+		It("queues a SyncRun modified event only for the held SyncRuns of the GitOpsDeployment", func() {
+
+			scheme := runtime.NewScheme()
+			Expect(managedgitopsv1alpha1.AddToScheme(scheme)).To(Succeed())
+
+			gitopsDepl := &managedgitopsv1alpha1.GitOpsDeployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "frontend", Namespace: "my-namespace"},
+			}
+
+			newSyncRun := func(name string, gitopsDeploymentName string, held bool) *managedgitopsv1alpha1.GitOpsDeploymentSyncRun {
+				syncRun := &managedgitopsv1alpha1.GitOpsDeploymentSyncRun{
+					ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "my-namespace"},
+					Spec:       managedgitopsv1alpha1.GitOpsDeploymentSyncRunSpec{GitopsDeploymentName: gitopsDeploymentName},
+				}
+				if held {
+					syncRun.Status.Conditions = []managedgitopsv1alpha1.GitOpsDeploymentSyncRunCondition{{
+						Type:   managedgitopsv1alpha1.GitOpsDeploymentSyncRunConditionWaitingForDependencies,
+						Status: managedgitopsv1alpha1.GitOpsConditionStatusTrue,
+						Reason: managedgitopsv1alpha1.SyncRunReasonWaitingForDependencies,
+					}}
+				}
+				return syncRun
+			}
+
+			k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				newSyncRun("held", gitopsDepl.Name, true),
+				newSyncRun("not-held", gitopsDepl.Name, false),
+				newSyncRun("other-deployment", "backend", true)).Build()
+
+			queuedEvents := []eventlooptypes.EventLoopEvent{}
+			action := applicationEventLoopRunner_Action{
+				workspaceClient: k8sClient,
+				log:             log.Log,
+				queueEvent: func(event eventlooptypes.EventLoopEvent) {
+					queuedEvents = append(queuedEvents, event)
+				},
+			}
+
+			Expect(action.queueHeldSyncRunEvents(context.Background(), gitopsDepl)).To(Succeed())
+			Expect(queuedEvents).To(HaveLen(1))
+			Expect(queuedEvents[0].EventType).To(Equal(eventlooptypes.SyncRunModified))
+			Expect(queuedEvents[0].Request.Name).To(Equal("held"))
+		})
 	})
 })
 
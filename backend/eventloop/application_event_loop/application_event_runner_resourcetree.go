@@ -0,0 +1,258 @@
+package application_event_loop
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	managedgitopsv1alpha1 "github.com/redhat-appstudio/managed-gitops/backend-shared/apis/managed-gitops/v1alpha1"
+	"github.com/redhat-appstudio/managed-gitops/backend-shared/db"
+	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/fauxargocd"
+	logutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util/log"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// This file is responsible for maintaining the GitOpsDeploymentResourceTree of a GitOpsDeployment, which is generated
+// from the resource tree that the cluster-agent stores in the ApplicationState of the Application.
+
+// The limits on the size of a GitOpsDeploymentResourceTree, which ensure that the resource tree of a large deployment
+// does not exceed the maximum size of a K8s object.
+const (
+	// maxResourceTreeNodes is the maximum number of resources that are stored in a GitOpsDeploymentResourceTree
+	maxResourceTreeNodes = 2000
+
+	// maxResourceTreeSize is the maximum total size of the (JSON) resources that are stored in a
+	// GitOpsDeploymentResourceTree, leaving room below the 1.5 MiB limit of etcd for the rest of the object
+	maxResourceTreeSize = 1024 * 1024
+
+	// maxResourceTreeMessageLength is the maximum number of characters of the health and hook messages of a resource
+	maxResourceTreeMessageLength = 1024
+)
+
+// reconcileGitOpsDeploymentResourceTree creates or updates the GitOpsDeploymentResourceTree of the GitOpsDeployment,
+// based on the (decompressed) ApplicationState of its Application.
+//
+// If the ApplicationState does not contain a resource tree (for example, because the cluster-agent was unable to
+// retrieve it from Argo CD), the existing GitOpsDeploymentResourceTree is left unchanged.
+func (a *applicationEventLoopRunner_Action) reconcileGitOpsDeploymentResourceTree(ctx context.Context,
+	gitopsDeployment *managedgitopsv1alpha1.GitOpsDeployment, appStatus *fauxargocd.FauxApplicationStatus) error {
+
+	if appStatus.ResourceTree == nil {
+		return nil
+	}
+
+	nodes, truncated := generateResourceTreeNodes(appStatus)
+
+	resourceTree := &managedgitopsv1alpha1.GitOpsDeploymentResourceTree{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      gitopsDeployment.Name,
+			Namespace: gitopsDeployment.Namespace,
+		},
+	}
+
+	if err := a.workspaceClient.Get(ctx, client.ObjectKeyFromObject(resourceTree), resourceTree); err != nil {
+
+		if !apierr.IsNotFound(err) {
+			return fmt.Errorf("unable to retrieve GitOpsDeploymentResourceTree '%s': %v", resourceTree.Name, err)
+		}
+
+		// The GitOpsDeploymentResourceTree is owned by the GitOpsDeployment, so that it is deleted along with it.
+		resourceTree.OwnerReferences = []metav1.OwnerReference{{
+			APIVersion: managedgitopsv1alpha1.GroupVersion.String(),
+			Kind:       "GitOpsDeployment",
+			Name:       gitopsDeployment.Name,
+			UID:        gitopsDeployment.UID,
+			Controller: &[]bool{true}[0],
+		}}
+
+		if err := a.workspaceClient.Create(ctx, resourceTree); err != nil {
+			return fmt.Errorf("unable to create GitOpsDeploymentResourceTree '%s': %v", resourceTree.Name, err)
+		}
+
+		a.log.V(logutil.LogLevel_Debug).Info("Created GitOpsDeploymentResourceTree")
+
+	} else if reflect.DeepEqual(resourceTree.Status.Nodes, nodes) && resourceTree.Status.Truncated == truncated {
+		// If nothing has changed in the tree, our work is done.
+		return nil
+	}
+
+	now := metav1.Now()
+	resourceTree.Status = managedgitopsv1alpha1.GitOpsDeploymentResourceTreeStatus{
+		Nodes:       nodes,
+		Truncated:   truncated,
+		LastUpdated: &now,
+	}
+
+	if err := a.workspaceClient.Status().Update(ctx, resourceTree); err != nil {
+		return fmt.Errorf("unable to update status of GitOpsDeploymentResourceTree '%s': %v", resourceTree.Name, err)
+	}
+
+	return nil
+}
+
+// generateResourceTreeNodes combines the resources of the Application status, the nodes of the resource tree, and the
+// hook results of the last sync operation, into the nodes of a GitOpsDeploymentResourceTree.
+// - Returns true if the nodes were truncated: either messages which exceeded maxResourceTreeMessageLength, or the list
+// of nodes, if it exceeded maxResourceTreeNodes or maxResourceTreeSize.
+func generateResourceTreeNodes(appStatus *fauxargocd.FauxApplicationStatus) ([]managedgitopsv1alpha1.ResourceTreeNode, bool) {
+
+	nodes := []managedgitopsv1alpha1.ResourceTreeNode{}
+
+	// nodeIndex is a map from the group/kind/namespace/name of a resource, to its index in 'nodes'
+	nodeIndex := map[string]int{}
+
+	nodeKey := func(group, kind, namespace, name string) string {
+		return fmt.Sprintf("%s/%s/%s/%s", group, kind, namespace, name)
+	}
+
+	convertRef := func(ref fauxargocd.ResourceRef) managedgitopsv1alpha1.ResourceTreeNodeRef {
+		return managedgitopsv1alpha1.ResourceTreeNodeRef{
+			Group:     ref.Group,
+			Kind:      ref.Kind,
+			Namespace: ref.Namespace,
+			Name:      ref.Name,
+			UID:       ref.UID,
+		}
+	}
+
+	convertHealth := func(health *fauxargocd.HealthStatus) *managedgitopsv1alpha1.HealthStatus {
+		if health == nil {
+			return nil
+		}
+		return &managedgitopsv1alpha1.HealthStatus{
+			Status:  managedgitopsv1alpha1.HealthStatusCode(health.Status),
+			Message: health.Message,
+		}
+	}
+
+	// 1) The resources which are directly managed by the Application: these have a sync status, and may need pruning.
+	for _, resource := range appStatus.Resources {
+
+		node := managedgitopsv1alpha1.ResourceTreeNode{
+			ResourceTreeNodeRef: managedgitopsv1alpha1.ResourceTreeNodeRef{
+				Group:     resource.Group,
+				Kind:      resource.Kind,
+				Namespace: resource.Namespace,
+				Name:      resource.Name,
+			},
+			Version:         resource.Version,
+			Status:          managedgitopsv1alpha1.SyncStatusCode(resource.Status),
+			Health:          convertHealth(resource.Health),
+			RequiresPruning: resource.RequiresPruning,
+			SyncWave:        resource.SyncWave,
+		}
+		if resource.Hook {
+			node.Hook = &managedgitopsv1alpha1.ResourceTreeNodeHook{}
+		}
+
+		nodeIndex[nodeKey(resource.Group, resource.Kind, resource.Namespace, resource.Name)] = len(nodes)
+		nodes = append(nodes, node)
+	}
+
+	// 2) The live resources of the resource tree: these are either resources which are directly managed by the
+	// Application (which are already in the list), or their children.
+	if appStatus.ResourceTree != nil {
+		for _, treeNode := range appStatus.ResourceTree.Nodes {
+
+			ref := treeNode.ResourceRef
+
+			if index, exists := nodeIndex[nodeKey(ref.Group, ref.Kind, ref.Namespace, ref.Name)]; exists {
+				nodes[index].UID = ref.UID
+				if nodes[index].Health == nil {
+					nodes[index].Health = convertHealth(treeNode.Health)
+				}
+				continue
+			}
+
+			node := managedgitopsv1alpha1.ResourceTreeNode{
+				ResourceTreeNodeRef: convertRef(ref),
+				Version:             ref.Version,
+				Health:              convertHealth(treeNode.Health),
+			}
+			for _, parentRef := range treeNode.ParentRefs {
+				node.ParentRefs = append(node.ParentRefs, convertRef(parentRef))
+			}
+
+			nodeIndex[nodeKey(ref.Group, ref.Kind, ref.Namespace, ref.Name)] = len(nodes)
+			nodes = append(nodes, node)
+		}
+	}
+
+	// 3) The results of the hooks of the last sync operation. A hook which no longer exists (for example, because of
+	// its deletion policy) is still included, so that its result is reported.
+	if appStatus.OperationState != nil && appStatus.OperationState.SyncResult != nil {
+		for _, result := range appStatus.OperationState.SyncResult.Resources {
+
+			if result == nil || result.HookType == "" {
+				continue
+			}
+
+			hook := &managedgitopsv1alpha1.ResourceTreeNodeHook{
+				Type:    managedgitopsv1alpha1.HookType(result.HookType),
+				Phase:   managedgitopsv1alpha1.OperationPhase(result.HookPhase),
+				Message: result.Message,
+			}
+
+			if index, exists := nodeIndex[nodeKey(result.Group, result.Kind, result.Namespace, result.Name)]; exists {
+				nodes[index].Hook = hook
+				continue
+			}
+
+			nodeIndex[nodeKey(result.Group, result.Kind, result.Namespace, result.Name)] = len(nodes)
+			nodes = append(nodes, managedgitopsv1alpha1.ResourceTreeNode{
+				ResourceTreeNodeRef: managedgitopsv1alpha1.ResourceTreeNodeRef{
+					Group:     result.Group,
+					Kind:      result.Kind,
+					Namespace: result.Namespace,
+					Name:      result.Name,
+				},
+				Version: result.Version,
+				Hook:    hook,
+			})
+		}
+	}
+
+	return truncateResourceTreeNodes(nodes)
+}
+
+// truncateResourceTreeNodes truncates the messages of the nodes to maxResourceTreeMessageLength, and the list of nodes
+// to maxResourceTreeNodes and maxResourceTreeSize. Returns true if anything was truncated.
+func truncateResourceTreeNodes(nodes []managedgitopsv1alpha1.ResourceTreeNode) ([]managedgitopsv1alpha1.ResourceTreeNode, bool) {
+
+	truncated := false
+
+	truncateMessage := func(message *string) {
+		if truncatedMessage := db.TruncateVarchar(*message, maxResourceTreeMessageLength); truncatedMessage != *message {
+			*message = truncatedMessage
+			truncated = true
+		}
+	}
+
+	size := 0
+
+	for idx := range nodes {
+
+		if idx == maxResourceTreeNodes {
+			return nodes[:idx], true
+		}
+
+		node := &nodes[idx]
+		if node.Health != nil {
+			truncateMessage(&node.Health.Message)
+		}
+		if node.Hook != nil {
+			truncateMessage(&node.Hook.Message)
+		}
+
+		// A node always consists of strings, numbers and booleans, which can be marshalled
+		nodeJSON, _ := json.Marshal(node)
+		if size += len(nodeJSON); size > maxResourceTreeSize {
+			return nodes[:idx], true
+		}
+	}
+
+	return nodes, truncated
+}
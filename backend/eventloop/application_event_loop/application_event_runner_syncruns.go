@@ -2,6 +2,7 @@ package application_event_loop
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -9,10 +10,12 @@ import (
 	db "github.com/redhat-appstudio/managed-gitops/backend-shared/db"
 	dbutil "github.com/redhat-appstudio/managed-gitops/backend-shared/db/util"
 	sharedutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util"
+	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/fauxargocd"
 	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/gitopserrors"
 	logutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util/log"
 	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/operations"
 	"github.com/redhat-appstudio/managed-gitops/backend/eventloop/eventlooptypes"
+	goyaml "gopkg.in/yaml.v2"
 	corev1 "k8s.io/api/core/v1"
 	apierr "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -24,6 +27,8 @@ const (
 	ErrDeploymentNameIsImmutable = "deployment name field is immutable: changing it from its initial value is not supported"
 
 	ErrRevisionIsImmutable = "revision change is not supported: changing it from its initial value is not supported"
+
+	ErrSyncOptionsAreImmutable = "sync options are immutable: changing the prune, dryRun, force, replace, resources, syncOptions or rollbackTo fields from their initial values is not supported"
 )
 
 // This file is responsible for processing events related to GitOpsDeploymentSyncRun CR.
@@ -327,6 +332,30 @@ func (a *applicationEventLoopRunner_Action) applicationEventRunner_handleSyncRun
 			// have seen the GitOpsDeplSyncRun CR.
 			// Create it in the DB and create the operation.
 
+			// The sync is held until the dependencies of the GitOpsDeployment are Synced and Healthy: the SyncRun is
+			// processed again once they are (see queueHeldSyncRunEvents).
+			unreadyDependencies, dependencyCycle, err := getUnreadyDependencies(ctx, a.workspaceClient, gitopsDepl)
+			if err != nil {
+				return gitopserrors.NewDevOnlyError(err)
+			}
+			if len(unreadyDependencies) > 0 || len(dependencyCycle) > 0 {
+				log.Info("GitOpsDeploymentSyncRun is held, waiting for the dependencies of the GitOpsDeployment", "unreadyDependencies", unreadyDependencies)
+
+				if err := setGitOpsDeploymentSyncRunCondition(ctx, a.workspaceClient, syncRunCR, managedgitopsv1alpha1.GitOpsDeploymentSyncRunConditionWaitingForDependencies,
+					managedgitopsv1alpha1.SyncRunReasonWaitingForDependencies, managedgitopsv1alpha1.GitOpsConditionStatusTrue,
+					waitingForDependenciesMessage(unreadyDependencies, dependencyCycle)); err != nil {
+					return gitopserrors.NewDevOnlyError(fmt.Errorf("unable to update the status of GitOpsDeploymentSyncRun: %v", err))
+				}
+				return nil
+			}
+
+			if isSyncRunWaitingForDependencies(syncRunCR) {
+				if err := setGitOpsDeploymentSyncRunCondition(ctx, a.workspaceClient, syncRunCR, managedgitopsv1alpha1.GitOpsDeploymentSyncRunConditionWaitingForDependencies,
+					managedgitopsv1alpha1.SyncRunReasonDependenciesReady, managedgitopsv1alpha1.GitOpsConditionStatusFalse, ""); err != nil {
+					return gitopserrors.NewDevOnlyError(fmt.Errorf("unable to update the status of GitOpsDeploymentSyncRun: %v", err))
+				}
+			}
+
 			return a.handleNewGitOpsDeplSyncRunEvent(ctx, syncRunCR, dbQueries, application, gitopsEngineInstance, namespace, *clusterUser)
 		}
 
@@ -427,6 +456,7 @@ func (a *applicationEventLoopRunner_Action) handleDeletedGitOpsDeplSyncRunEvent(
 		Instance_id:   gitopsEngineInstance.Gitopsengineinstance_id,
 		Resource_id:   syncOperation.SyncOperation_id,
 		Resource_type: db.OperationResourceType_SyncOperation,
+		Priority:      db.OperationPriority_UserInitiatedSync,
 	}
 
 	// 2) Create the operation, in order to inform the cluster agent it needs to cancel the sync operation
@@ -508,6 +538,12 @@ func (a *applicationEventLoopRunner_Action) handleNewGitOpsDeplSyncRunEvent(ctx
 		DeploymentNameField: syncRunCRParam.Spec.GitopsDeploymentName,
 		Revision:            syncRunCRParam.Spec.RevisionID,
 		DesiredState:        db.SyncOperation_DesiredState_Running,
+		Phase:               db.SyncOperation_Phase_Pending,
+	}
+	if err := setSyncOperationOptions(syncOperation, syncRunCRParam.Spec); err != nil {
+		log.Error(err, "unable to set the sync options of the sync operation")
+
+		return gitopserrors.NewDevOnlyError(err)
 	}
 	if err := dbQueries.CreateSyncOperation(ctx, syncOperation); err != nil {
 		log.Error(err, "unable to create sync operation in database")
@@ -540,6 +576,11 @@ func (a *applicationEventLoopRunner_Action) handleNewGitOpsDeplSyncRunEvent(ctx
 	log.Info(fmt.Sprintf("Created a ApiCRToDBMapping: (APIResourceType: %s, APIResourceUID: %s, DBRelationType: %s)", newApiCRToDBMapping.APIResourceType, newApiCRToDBMapping.APIResourceUID, newApiCRToDBMapping.DBRelationType))
 	createdResources = append(createdResources, &newApiCRToDBMapping)
 
+	if err := updateGitOpsDeploymentSyncRunStatus(ctx, a.workspaceClient, syncRunCRParam, *syncOperation); err != nil {
+		// Not a fatal error: the status will be updated again once the sync operation has completed.
+		log.Error(err, "unable to update the status of GitOpsDeploymentSyncRun to pending")
+	}
+
 	operationClient, err := a.k8sClientFactory.GetK8sClientForGitOpsEngineInstance(ctx, gitopsEngineInstance)
 	if err != nil {
 		log.Error(err, "unable to retrieve gitopsengine instance from handleSyncRunModified")
@@ -557,6 +598,12 @@ func (a *applicationEventLoopRunner_Action) handleNewGitOpsDeplSyncRunEvent(ctx
 		Instance_id:   gitopsEngineInstance.Gitopsengineinstance_id,
 		Resource_id:   syncOperation.SyncOperation_id,
 		Resource_type: db.OperationResourceType_SyncOperation,
+		Priority:      db.OperationPriority_UserInitiatedSync,
+	}
+
+	// A SyncRun with rollbackTo set is processed as a rollback, rather than a sync, by the cluster-agent
+	if syncRunCRParam.Spec.RollbackTo != nil {
+		dbOperationInput.Resource_type = db.OperationResourceType_Rollback
 	}
 
 	k8sOperation, dbOperation, err := operations.CreateOperation(ctx, false, dbOperationInput, clusterUser.Clusteruser_id,
@@ -612,19 +659,131 @@ outer_for:
 				log.Info("The SyncRun CR UID has changed, versus the SyncRun CR that we began with, exiting the sync process")
 				break outer_for
 			}
+
+			// Report the phase of the sync operation while it is in progress, such as when the cluster-agent has started it
+			if err := updateInProgressSyncRunStatus(ctx, a.workspaceClient, dbQueries, currentSyncRunCR, syncOperation.SyncOperation_id); err != nil {
+				// Not a fatal error: the status will be updated again once the sync operation has completed.
+				log.Error(err, "unable to update the status of GitOpsDeploymentSyncRun, while the sync operation is in progress")
+			}
 		}
 
 		backoff.DelayOnFail(ctx)
 
 	}
 
+	// Report the outcome of the sync operation, as recorded by the cluster-agent, in the status of the SyncRun
+	var statusErr error
+	if err := dbQueries.GetSyncOperationById(ctx, syncOperation); err != nil {
+		log.Error(err, "unable to retrieve sync operation, after the sync operation completed", "syncOperationID", syncOperation.SyncOperation_id)
+		statusErr = err
+	} else if err := updateGitOpsDeploymentSyncRunStatus(ctx, a.workspaceClient, syncRunCRParam, *syncOperation); err != nil && !apierr.IsNotFound(err) {
+		log.Error(err, "unable to update the status of GitOpsDeploymentSyncRun, after the sync operation completed")
+		statusErr = err
+	}
+
 	if err := operations.CleanupOperation(ctx, *dbOperation, *k8sOperation, dbQueries, operationClient, !a.testOnlySkipCreateOperation, log); err != nil {
 		return gitopserrors.NewDevOnlyError(err)
 	}
 
+	if statusErr != nil {
+		return gitopserrors.NewDevOnlyError(statusErr)
+	}
+
 	return nil
 }
 
+// updateGitOpsDeploymentSyncRunStatus updates the .status of the GitOpsDeploymentSyncRun (phase, start/finish times, synced
+// revision and per-resource results) to reflect the outcome of the sync, as recorded in the SyncOperation row.
+func updateGitOpsDeploymentSyncRunStatus(ctx context.Context, k8sClient client.Client, syncRunCRParam *managedgitopsv1alpha1.GitOpsDeploymentSyncRun,
+	syncOperation db.SyncOperation) error {
+
+	syncRunCR := &managedgitopsv1alpha1.GitOpsDeploymentSyncRun{}
+	if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(syncRunCRParam), syncRunCR); err != nil {
+		return err
+	}
+
+	// Don't update a SyncRun that was deleted and recreated: the new SyncRun will have its own SyncOperation
+	if syncRunCR.UID != syncRunCRParam.UID {
+		return nil
+	}
+
+	resources, err := decompressSyncResult(syncOperation.Sync_result)
+	if err != nil {
+		return err
+	}
+
+	syncRunCR.Status.Phase = managedgitopsv1alpha1.SyncRunPhase(syncOperation.Phase)
+	if syncRunCR.Status.Phase == "" {
+		syncRunCR.Status.Phase = managedgitopsv1alpha1.SyncRunPhase_Pending
+	}
+	syncRunCR.Status.Message = syncOperation.Phase_message
+	syncRunCR.Status.Revision = syncOperation.Synced_revision
+	syncRunCR.Status.Resources = resources
+
+	syncRunCR.Status.StartedAt = nil
+	if !syncOperation.Started_at.IsZero() {
+		startedAt := metav1.NewTime(syncOperation.Started_at)
+		syncRunCR.Status.StartedAt = &startedAt
+	}
+
+	syncRunCR.Status.FinishedAt = nil
+	if !syncOperation.Finished_at.IsZero() {
+		finishedAt := metav1.NewTime(syncOperation.Finished_at)
+		syncRunCR.Status.FinishedAt = &finishedAt
+	}
+
+	return k8sClient.Status().Update(ctx, syncRunCR)
+}
+
+// updateInProgressSyncRunStatus updates the .status of the GitOpsDeploymentSyncRun if the phase of its SyncOperation
+// has changed since the status was last updated: for example, from Pending to Running, once the cluster-agent has
+// started the sync operation.
+func updateInProgressSyncRunStatus(ctx context.Context, k8sClient client.Client, dbQueries db.ApplicationScopedQueries,
+	syncRunCR *managedgitopsv1alpha1.GitOpsDeploymentSyncRun, syncOperationID string) error {
+
+	syncOperation := db.SyncOperation{SyncOperation_id: syncOperationID}
+	if err := dbQueries.GetSyncOperationById(ctx, &syncOperation); err != nil {
+		return err
+	}
+
+	if syncOperation.Phase == "" || managedgitopsv1alpha1.SyncRunPhase(syncOperation.Phase) == syncRunCR.Status.Phase {
+		return nil
+	}
+
+	return updateGitOpsDeploymentSyncRunStatus(ctx, k8sClient, syncRunCR, syncOperation)
+}
+
+// decompressSyncResult converts the compressed Argo CD sync result of each resource, from the SyncOperation row, into
+// the ResourceResults of the GitOpsDeploymentSyncRun status.
+func decompressSyncResult(syncResult []byte) (managedgitopsv1alpha1.ResourceResults, error) {
+
+	if len(syncResult) == 0 {
+		return nil, nil
+	}
+
+	syncResultBytes, err := sharedutil.DecompressObject(syncResult)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress SyncOperation sync result: %v", err)
+	}
+
+	var fauxResources fauxargocd.ResourceResults
+	if err := goyaml.Unmarshal(syncResultBytes, &fauxResources); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal SyncOperation sync result: %v", err)
+	}
+
+	fauxResourcesBytes, err := json.Marshal(fauxResources)
+	if err != nil {
+		return nil, err
+	}
+
+	var resources managedgitopsv1alpha1.ResourceResults
+	if err := json.Unmarshal(fauxResourcesBytes, &resources); err != nil {
+		return nil, err
+	}
+
+	return resources, nil
+}
+
 // handleUpdatedGitOpsDeplSyncRunEvent handles GitOpsDeploymentSyncRun events where the user has just updated an existing GitOpsDeploymentSyncRun resource.
 // In this case, we need to ensure that the immutable fields GitOpsDeploymentName and RevisionID are not updated.
 //
@@ -646,9 +805,66 @@ func (a *applicationEventLoopRunner_Action) handleUpdatedGitOpsDeplSyncRunEvent(
 		return gitopserrors.NewUserDevError(ErrRevisionIsImmutable, err)
 	}
 
+	expectedSyncOperation := db.SyncOperation{}
+	if err := setSyncOperationOptions(&expectedSyncOperation, syncRunCR.Spec); err != nil {
+		log.Error(err, "unable to set the sync options of the sync operation")
+		return gitopserrors.NewDevOnlyError(err)
+	}
+
+	if syncOperation.Prune != expectedSyncOperation.Prune || syncOperation.Dry_run != expectedSyncOperation.Dry_run ||
+		syncOperation.Force != expectedSyncOperation.Force || syncOperation.Replace != expectedSyncOperation.Replace ||
+		syncOperation.Resources != expectedSyncOperation.Resources || syncOperation.Sync_options != expectedSyncOperation.Sync_options ||
+		syncOperation.Rollback_to != expectedSyncOperation.Rollback_to {
+
+		err := fmt.Errorf(ErrSyncOptionsAreImmutable)
+		log.Error(err, ErrSyncOptionsAreImmutable)
+		return gitopserrors.NewUserDevError(ErrSyncOptionsAreImmutable, err)
+	}
+
+	// Ensure the status of the SyncRun is consistent with the outcome of the sync operation
+	if err := updateGitOpsDeploymentSyncRunStatus(ctx, a.workspaceClient, syncRunCR, syncOperation); err != nil {
+		log.Error(err, "unable to update the status of GitOpsDeploymentSyncRun")
+		return gitopserrors.NewDevOnlyError(err)
+	}
+
 	return nil
 }
 
+// setSyncOperationOptions sets the sync option fields of the SyncOperation (prune, dry run, resources, rollback target, etc)
+// based on the corresponding fields of the GitOpsDeploymentSyncRun.
+func setSyncOperationOptions(syncOperation *db.SyncOperation, syncRunSpec managedgitopsv1alpha1.GitOpsDeploymentSyncRunSpec) error {
+
+	syncOperation.Prune = syncRunSpec.Prune
+	syncOperation.Dry_run = syncRunSpec.DryRun
+	syncOperation.Force = syncRunSpec.Force
+	syncOperation.Replace = syncRunSpec.Replace
+	syncOperation.SetSyncOptions(managedgitopsv1alpha1.SyncOptionToStringSlice(syncRunSpec.SyncOptions))
+
+	var resources []db.SyncOperationResource
+	for _, resource := range syncRunSpec.Resources {
+		resources = append(resources, db.SyncOperationResource{
+			Group:     resource.Group,
+			Kind:      resource.Kind,
+			Name:      resource.Name,
+			Namespace: resource.Namespace,
+		})
+	}
+
+	if err := syncOperation.SetResources(resources); err != nil {
+		return err
+	}
+
+	var rollbackTo *db.SyncOperationRollback
+	if syncRunSpec.RollbackTo != nil {
+		rollbackTo = &db.SyncOperationRollback{
+			ID:       syncRunSpec.RollbackTo.ID,
+			Revision: syncRunSpec.RollbackTo.Revision,
+		}
+	}
+
+	return syncOperation.SetRollbackTo(rollbackTo)
+}
+
 func (a *applicationEventLoopRunner_Action) cleanupOldSyncDBEntry(ctx context.Context, apiCRToDB *db.APICRToDatabaseMapping,
 	clusterUser db.ClusterUser, dbQueries db.ApplicationScopedQueries) error {
 
@@ -670,28 +886,34 @@ func (a *applicationEventLoopRunner_Action) cleanupOldSyncDBEntry(ctx context.Co
 		log.Info("Sync Operation deleted with ID: " + apiCRToDB.DBRelationKey)
 	}
 
+	// Both sync and rollback Operations may reference the SyncOperation
 	var operations []db.Operation
-	if err := dbQueries.ListOperationsByResourceIdAndTypeAndOwnerId(ctx, apiCRToDB.DBRelationKey, db.OperationResourceType_SyncOperation,
-		&operations, clusterUser.Clusteruser_id); err != nil {
+	for _, resourceType := range []db.OperationResourceType{db.OperationResourceType_SyncOperation, db.OperationResourceType_Rollback} {
 
-		log.Error(err, "unable to retrieve operations pointing to sync operation", "key", apiCRToDB.DBRelationKey)
-		return err
-	} else {
-		// Delete the operations that reference this SyncOperation
-		for idx := range operations {
-			operationId := operations[idx].Operation_id
+		var operationsOfType []db.Operation
+		if err := dbQueries.ListOperationsByResourceIdAndTypeAndOwnerId(ctx, apiCRToDB.DBRelationKey, resourceType,
+			&operationsOfType, clusterUser.Clusteruser_id); err != nil {
 
-			log := log.WithValues("operationId", operationId)
+			log.Error(err, "unable to retrieve operations pointing to sync operation", "key", apiCRToDB.DBRelationKey, "resourceType", resourceType)
+			return err
+		}
+		operations = append(operations, operationsOfType...)
+	}
 
-			rowsDeleted, err := dbQueries.CheckedDeleteOperationById(ctx, operationId, clusterUser.Clusteruser_id)
-			if err != nil {
-				log.Error(err, "unable to delete old operation")
-				return err
-			} else if rowsDeleted == 0 {
-				log.V(logutil.LogLevel_Warn).Error(err, "unexpected number of deleted rows when deleting old operation")
-			} else {
-				log.Info("Operation deleted with ID: " + operationId)
-			}
+	// Delete the operations that reference this SyncOperation
+	for idx := range operations {
+		operationId := operations[idx].Operation_id
+
+		log := log.WithValues("operationId", operationId)
+
+		rowsDeleted, err := dbQueries.CheckedDeleteOperationById(ctx, operationId, clusterUser.Clusteruser_id)
+		if err != nil {
+			log.Error(err, "unable to delete old operation")
+			return err
+		} else if rowsDeleted == 0 {
+			log.V(logutil.LogLevel_Warn).Error(err, "unexpected number of deleted rows when deleting old operation")
+		} else {
+			log.Info("Operation deleted with ID: " + operationId)
 		}
 	}
 
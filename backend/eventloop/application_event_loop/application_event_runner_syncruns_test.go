@@ -3,13 +3,17 @@ package application_event_loop
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	matcher "github.com/onsi/gomega/types"
 	managedgitopsv1alpha1 "github.com/redhat-appstudio/managed-gitops/backend-shared/apis/managed-gitops/v1alpha1"
 	db "github.com/redhat-appstudio/managed-gitops/backend-shared/db"
 	sharedutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util"
+	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/fauxargocd"
+	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/mocks"
 	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/tests"
 	"github.com/redhat-appstudio/managed-gitops/backend/eventloop/shared_resource_loop"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -116,6 +120,14 @@ var _ = Describe("Application Event Runner SyncRuns", func() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(syncOperation.DeploymentNameField).Should(Equal(gitopsDeplSyncRun.Spec.GitopsDeploymentName))
 			Expect(syncOperation.Revision).Should(Equal(gitopsDeplSyncRun.Spec.RevisionID))
+			Expect(syncOperation.Phase).Should(Equal(db.SyncOperation_Phase_Pending))
+
+			By("verify if the status of the SyncRun reflects the phase of the SyncOperation")
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(gitopsDeplSyncRun), gitopsDeplSyncRun)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(gitopsDeplSyncRun.Status.Phase).Should(Equal(managedgitopsv1alpha1.SyncRunPhase_Pending))
+			Expect(gitopsDeplSyncRun.Status.StartedAt).Should(BeNil())
+			Expect(gitopsDeplSyncRun.Status.FinishedAt).Should(BeNil())
 
 			By("verify if an Operation CR is created")
 			operationCreated, operationDeleted := false, false
@@ -181,6 +193,127 @@ var _ = Describe("Application Event Runner SyncRuns", func() {
 			userDevErr = applicationAction.applicationEventRunner_handleSyncRunModifiedInternal(ctx, dbQueries)
 			Expect(userDevErr.DevError().Error()).Should(Equal(ErrRevisionIsImmutable))
 			Expect(userDevErr.UserError()).Should(Equal(ErrRevisionIsImmutable))
+
+			gitopsDeplSyncRun.Spec.RevisionID = "HEAD"
+			err = k8sClient.Update(ctx, gitopsDeplSyncRun)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("verify if the sync option fields of GitOpsDeploymentSyncRun are immutable")
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(gitopsDeplSyncRun), gitopsDeplSyncRun)
+			Expect(err).ToNot(HaveOccurred())
+
+			gitopsDeplSyncRun.Spec.Prune = true
+			err = k8sClient.Update(ctx, gitopsDeplSyncRun)
+			Expect(err).ToNot(HaveOccurred())
+			userDevErr = applicationAction.applicationEventRunner_handleSyncRunModifiedInternal(ctx, dbQueries)
+			Expect(userDevErr.DevError().Error()).Should(Equal(ErrSyncOptionsAreImmutable))
+			Expect(userDevErr.UserError()).Should(Equal(ErrSyncOptionsAreImmutable))
+		})
+
+		It("should store the sync options of the GitOpsDeploymentSyncRun in the SyncOperation", func() {
+			newSyncRun := &managedgitopsv1alpha1.GitOpsDeploymentSyncRun{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "gitops-syncrun-with-options",
+					Namespace: gitopsDepl.Namespace,
+					UID:       uuid.NewUUID(),
+				},
+				Spec: managedgitopsv1alpha1.GitOpsDeploymentSyncRunSpec{
+					GitopsDeploymentName: gitopsDepl.Name,
+					RevisionID:           "HEAD",
+					Prune:                true,
+					DryRun:               true,
+					Replace:              true,
+					Resources: []managedgitopsv1alpha1.SyncRunResource{
+						{Group: "apps", Kind: "Deployment", Name: "my-deployment", Namespace: "my-namespace"},
+					},
+					SyncOptions: managedgitopsv1alpha1.SyncOptions{managedgitopsv1alpha1.SyncOptions_ServerSideApply_true},
+				},
+			}
+			err := k8sClient.Create(ctx, newSyncRun)
+			Expect(err).ToNot(HaveOccurred())
+
+			newAppAction := applicationAction
+			newAppAction.eventResourceName = newSyncRun.Name
+			userDevErr := newAppAction.applicationEventRunner_handleSyncRunModifiedInternal(ctx, dbQueries)
+			Expect(userDevErr).To(BeNil())
+
+			mapping := db.APICRToDatabaseMapping{
+				APIResourceType: db.APICRToDatabaseMapping_ResourceType_GitOpsDeploymentSyncRun,
+				APIResourceUID:  string(newSyncRun.UID),
+				DBRelationType:  db.APICRToDatabaseMapping_DBRelationType_SyncOperation,
+			}
+			err = dbQueries.GetDatabaseMappingForAPICR(ctx, &mapping)
+			Expect(err).ToNot(HaveOccurred())
+
+			syncOperation := db.SyncOperation{SyncOperation_id: mapping.DBRelationKey}
+			err = dbQueries.GetSyncOperationById(ctx, &syncOperation)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(syncOperation.Prune).To(BeTrue())
+			Expect(syncOperation.Dry_run).To(BeTrue())
+			Expect(syncOperation.Force).To(BeFalse())
+			Expect(syncOperation.Replace).To(BeTrue())
+			Expect(syncOperation.GetSyncOptions()).To(Equal([]string{"ServerSideApply=true"}))
+
+			resources, err := syncOperation.GetResources()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resources).To(Equal([]db.SyncOperationResource{
+				{Group: "apps", Kind: "Deployment", Name: "my-deployment", Namespace: "my-namespace"},
+			}))
+		})
+
+		It("should create a Rollback Operation for a GitOpsDeploymentSyncRun with rollbackTo", func() {
+			historyID := int64(2)
+			newSyncRun := &managedgitopsv1alpha1.GitOpsDeploymentSyncRun{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "gitops-syncrun-with-rollback",
+					Namespace: gitopsDepl.Namespace,
+					UID:       uuid.NewUUID(),
+				},
+				Spec: managedgitopsv1alpha1.GitOpsDeploymentSyncRunSpec{
+					GitopsDeploymentName: gitopsDepl.Name,
+					Prune:                true,
+					RollbackTo:           &managedgitopsv1alpha1.SyncRunRollback{ID: &historyID},
+				},
+			}
+			err := k8sClient.Create(ctx, newSyncRun)
+			Expect(err).ToNot(HaveOccurred())
+
+			newAppAction := applicationAction
+			newAppAction.eventResourceName = newSyncRun.Name
+			userDevErr := newAppAction.applicationEventRunner_handleSyncRunModifiedInternal(ctx, dbQueries)
+			Expect(userDevErr).To(BeNil())
+
+			mapping := db.APICRToDatabaseMapping{
+				APIResourceType: db.APICRToDatabaseMapping_ResourceType_GitOpsDeploymentSyncRun,
+				APIResourceUID:  string(newSyncRun.UID),
+				DBRelationType:  db.APICRToDatabaseMapping_DBRelationType_SyncOperation,
+			}
+			err = dbQueries.GetDatabaseMappingForAPICR(ctx, &mapping)
+			Expect(err).ToNot(HaveOccurred())
+
+			syncOperation := db.SyncOperation{SyncOperation_id: mapping.DBRelationKey}
+			err = dbQueries.GetSyncOperationById(ctx, &syncOperation)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(syncOperation.Prune).To(BeTrue())
+
+			rollbackTo, err := syncOperation.GetRollbackTo()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rollbackTo).ToNot(BeNil())
+			Expect(*rollbackTo.ID).To(Equal(historyID))
+
+			By("verifying that the Operation pointing to the SyncOperation is a Rollback")
+			var operations []db.Operation
+			err = dbQueries.UnsafeListAllOperations(ctx, &operations)
+			Expect(err).ToNot(HaveOccurred())
+
+			rollbackOperationFound := false
+			for _, operation := range operations {
+				if operation.Resource_id == syncOperation.SyncOperation_id {
+					Expect(operation.Resource_type).To(Equal(db.OperationResourceType_Rollback))
+					rollbackOperationFound = true
+				}
+			}
+			Expect(rollbackOperationFound).To(BeTrue())
 		})
 
 		It("should terminate the SyncOperation and create an Operation when the SyncRun CR is deleted", func() {
@@ -376,6 +509,138 @@ var _ = Describe("Application Event Runner SyncRuns", func() {
 		})
 	})
 
+	Context("Update GitOpsDeploymentSyncRun status", func() {
+
+		var (
+			ctx       context.Context
+			k8sClient client.Client
+			syncRunCR *managedgitopsv1alpha1.GitOpsDeploymentSyncRun
+		)
+
+		BeforeEach(func() {
+			scheme, _, _, workspace, err := tests.GenericTestSetup()
+			Expect(err).ToNot(HaveOccurred())
+
+			ctx = context.Background()
+
+			syncRunCR = &managedgitopsv1alpha1.GitOpsDeploymentSyncRun{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-syncrun",
+					Namespace: workspace.Name,
+					UID:       uuid.NewUUID(),
+				},
+			}
+
+			k8sClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(syncRunCR).Build()
+		})
+
+		It("should set the phase, times, revision and resource results from the SyncOperation", func() {
+
+			syncResult, err := sharedutil.CompressObject(&fauxargocd.ResourceResults{
+				{
+					Group:     "apps",
+					Version:   "v1",
+					Kind:      "Deployment",
+					Namespace: "jane",
+					Name:      "component-a",
+					Status:    "Synced",
+					Message:   "deployment.apps/component-a created",
+					SyncPhase: "Sync",
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			startedAt := time.Now().Add(-time.Minute).Truncate(time.Second)
+			finishedAt := time.Now().Truncate(time.Second)
+
+			syncOperation := db.SyncOperation{
+				SyncOperation_id: "test-sync-operation",
+				Phase:            db.SyncOperation_Phase_Succeeded,
+				Phase_message:    "successfully synced (all tasks run)",
+				Started_at:       startedAt,
+				Finished_at:      finishedAt,
+				Synced_revision:  "0c3ef8b2a8d4f1a0c5b3d3a3c9b2a7f1e6d5c4b3",
+				Sync_result:      syncResult,
+			}
+
+			err = updateGitOpsDeploymentSyncRunStatus(ctx, k8sClient, syncRunCR, syncOperation)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(syncRunCR), syncRunCR)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(syncRunCR.Status.Phase).Should(Equal(managedgitopsv1alpha1.SyncRunPhase_Succeeded))
+			Expect(syncRunCR.Status.Message).Should(Equal(syncOperation.Phase_message))
+			Expect(syncRunCR.Status.Revision).Should(Equal(syncOperation.Synced_revision))
+			Expect(syncRunCR.Status.StartedAt).ShouldNot(BeNil())
+			Expect(syncRunCR.Status.StartedAt.Time.Equal(startedAt)).To(BeTrue())
+			Expect(syncRunCR.Status.FinishedAt).ShouldNot(BeNil())
+			Expect(syncRunCR.Status.FinishedAt.Time.Equal(finishedAt)).To(BeTrue())
+
+			Expect(syncRunCR.Status.Resources).Should(HaveLen(1))
+			Expect(*syncRunCR.Status.Resources[0]).Should(Equal(managedgitopsv1alpha1.ResourceResult{
+				Group:     "apps",
+				Version:   "v1",
+				Kind:      "Deployment",
+				Namespace: "jane",
+				Name:      "component-a",
+				Status:    "Synced",
+				Message:   "deployment.apps/component-a created",
+				SyncPhase: "Sync",
+			}))
+		})
+
+		It("should default the phase to Pending and leave the times unset, if the SyncOperation has not started", func() {
+
+			err := updateGitOpsDeploymentSyncRunStatus(ctx, k8sClient, syncRunCR, db.SyncOperation{SyncOperation_id: "test-sync-operation"})
+			Expect(err).ToNot(HaveOccurred())
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(syncRunCR), syncRunCR)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(syncRunCR.Status.Phase).Should(Equal(managedgitopsv1alpha1.SyncRunPhase_Pending))
+			Expect(syncRunCR.Status.StartedAt).Should(BeNil())
+			Expect(syncRunCR.Status.FinishedAt).Should(BeNil())
+			Expect(syncRunCR.Status.Resources).Should(BeEmpty())
+		})
+
+		It("should report the Running phase, once the cluster-agent has started the SyncOperation", func() {
+
+			startedAt := time.Now().Truncate(time.Second)
+
+			mockCtrl := gomock.NewController(GinkgoT())
+			defer mockCtrl.Finish()
+
+			dbQueries := mocks.NewMockDatabaseQueries(mockCtrl)
+			dbQueries.EXPECT().GetSyncOperationById(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, syncOperation *db.SyncOperation) error {
+				syncOperation.Phase = db.SyncOperation_Phase_Running
+				syncOperation.Started_at = startedAt
+				return nil
+			}).Times(2)
+
+			By("updating the status of a Pending SyncRun")
+			syncRunCR.Status.Phase = managedgitopsv1alpha1.SyncRunPhase_Pending
+			err := updateInProgressSyncRunStatus(ctx, k8sClient, dbQueries, syncRunCR, "test-sync-operation")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(syncRunCR), syncRunCR)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(syncRunCR.Status.Phase).Should(Equal(managedgitopsv1alpha1.SyncRunPhase_Running))
+			Expect(syncRunCR.Status.StartedAt).ShouldNot(BeNil())
+			Expect(syncRunCR.Status.StartedAt.Time.Equal(startedAt)).To(BeTrue())
+			Expect(syncRunCR.Status.FinishedAt).Should(BeNil())
+
+			By("not updating the status again, while the phase is unchanged")
+			resourceVersion := syncRunCR.ResourceVersion
+			err = updateInProgressSyncRunStatus(ctx, k8sClient, dbQueries, syncRunCR, "test-sync-operation")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(syncRunCR), syncRunCR)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(syncRunCR.ResourceVersion).Should(Equal(resourceVersion))
+		})
+	})
+
 	Context("Set GitOpsDeploymentSyncRun conditions", func() {
 
 		var (
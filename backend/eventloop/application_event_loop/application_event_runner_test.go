@@ -7,6 +7,7 @@ import (
 
 	"fmt"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/gitopserrors"
 
@@ -418,6 +419,11 @@ var _ = Describe("ApplicationEventLoop Test", func() {
 			Expect(gitopsDeployment.Status.ReconciledState.Source.Branch).To(Equal(reconciledobj.Source.TargetRevision))
 			Expect(gitopsDeployment.Status.ReconciledState.Destination.Namespace).To(Equal(reconciledobj.Destination.Namespace))
 
+			By("verifying the GitOps engine instance of the Application is reported in the status")
+			Expect(gitopsDeployment.Status.GitOpsEngineInstance).ToNot(BeNil())
+			Expect(gitopsDeployment.Status.GitOpsEngineInstance.ID).ToNot(BeEmpty())
+			Expect(gitopsDeployment.Status.GitOpsEngineInstance.Namespace).ToNot(BeEmpty())
+
 			Expect(gitopsDeployment.Status.OperationState).ToNot(BeNil())
 			opStateBytes, err := json.Marshal(appStatus.OperationState)
 			Expect(err).ToNot(HaveOccurred())
@@ -1063,6 +1069,186 @@ var _ = Describe("ApplicationEventLoop Test", func() {
 			Expect(resourceStatus[0].Health).To(BeNil())
 			Expect(resourceStatus).To(Equal([]managedgitopsv1alpha1.ResourceStatus{expectedResource}))
 		})
+
+		It("should include whether the resource requires pruning, and whether it is a hook", func() {
+			resourceStatus := extractResourceStatus([]fauxargocd.ResourceStatus{
+				{Kind: "ConfigMap", Name: "old-config", RequiresPruning: true},
+				{Group: "batch", Kind: "Job", Name: "db-migration", Hook: true},
+			})
+			Expect(resourceStatus).To(HaveLen(2))
+			Expect(resourceStatus[0].RequiresPruning).To(BeTrue())
+			Expect(resourceStatus[0].Hook).To(BeFalse())
+			Expect(resourceStatus[1].RequiresPruning).To(BeFalse())
+			Expect(resourceStatus[1].Hook).To(BeTrue())
+		})
+	})
+
+	Context("Test generateResourceTreeNodes and reconcileGitOpsDeploymentResourceTree functions", func() {
+
+		appStatus := &fauxargocd.FauxApplicationStatus{
+			Resources: []fauxargocd.ResourceStatus{
+				{
+					Group: "apps", Version: "v1", Kind: "Deployment", Namespace: "jane", Name: "my-deployment",
+					Status: fauxargocd.SyncStatusCodeSynced,
+					Health: &fauxargocd.HealthStatus{Status: fauxargocd.HealthStatusProgressing, Message: "Waiting for rollout"},
+				},
+				{
+					Version: "v1", Kind: "ConfigMap", Namespace: "jane", Name: "old-config",
+					Status: fauxargocd.SyncStatusCodeOutOfSync, RequiresPruning: true,
+				},
+				{
+					Group: "batch", Version: "v1", Kind: "Job", Namespace: "jane", Name: "db-migration",
+					Hook: true,
+				},
+			},
+			ResourceTree: &fauxargocd.ApplicationTree{
+				Nodes: []fauxargocd.ResourceNode{
+					{
+						ResourceRef: fauxargocd.ResourceRef{Group: "apps", Version: "v1", Kind: "Deployment", Namespace: "jane", Name: "my-deployment", UID: "deployment-uid"},
+					},
+					{
+						ResourceRef: fauxargocd.ResourceRef{Group: "apps", Version: "v1", Kind: "ReplicaSet", Namespace: "jane", Name: "my-deployment-abc", UID: "replicaset-uid"},
+						ParentRefs:  []fauxargocd.ResourceRef{{Group: "apps", Kind: "Deployment", Namespace: "jane", Name: "my-deployment", UID: "deployment-uid"}},
+						Health:      &fauxargocd.HealthStatus{Status: fauxargocd.HealthStatusHealthy},
+					},
+				},
+			},
+			OperationState: &fauxargocd.OperationState{
+				SyncResult: &fauxargocd.SyncOperationResult{
+					Resources: fauxargocd.ResourceResults{
+						{
+							Group: "batch", Version: "v1", Kind: "Job", Namespace: "jane", Name: "db-migration",
+							HookType: "PreSync", HookPhase: "Succeeded", Message: "job completed",
+						},
+						{
+							Group: "apps", Version: "v1", Kind: "Deployment", Namespace: "jane", Name: "my-deployment",
+							Message: "deployment.apps/my-deployment configured",
+						},
+					},
+				},
+			},
+		}
+
+		expectedNodes := []managedgitopsv1alpha1.ResourceTreeNode{
+			{
+				ResourceTreeNodeRef: managedgitopsv1alpha1.ResourceTreeNodeRef{Group: "apps", Kind: "Deployment", Namespace: "jane", Name: "my-deployment", UID: "deployment-uid"},
+				Version:             "v1",
+				Status:              managedgitopsv1alpha1.SyncStatusCodeSynced,
+				Health:              &managedgitopsv1alpha1.HealthStatus{Status: managedgitopsv1alpha1.HeathStatusCodeProgressing, Message: "Waiting for rollout"},
+			},
+			{
+				ResourceTreeNodeRef: managedgitopsv1alpha1.ResourceTreeNodeRef{Kind: "ConfigMap", Namespace: "jane", Name: "old-config"},
+				Version:             "v1",
+				Status:              managedgitopsv1alpha1.SyncStatusCodeOutOfSync,
+				RequiresPruning:     true,
+			},
+			{
+				ResourceTreeNodeRef: managedgitopsv1alpha1.ResourceTreeNodeRef{Group: "batch", Kind: "Job", Namespace: "jane", Name: "db-migration"},
+				Version:             "v1",
+				Hook:                &managedgitopsv1alpha1.ResourceTreeNodeHook{Type: "PreSync", Phase: "Succeeded", Message: "job completed"},
+			},
+			{
+				ResourceTreeNodeRef: managedgitopsv1alpha1.ResourceTreeNodeRef{Group: "apps", Kind: "ReplicaSet", Namespace: "jane", Name: "my-deployment-abc", UID: "replicaset-uid"},
+				Version:             "v1",
+				ParentRefs:          []managedgitopsv1alpha1.ResourceTreeNodeRef{{Group: "apps", Kind: "Deployment", Namespace: "jane", Name: "my-deployment", UID: "deployment-uid"}},
+				Health:              &managedgitopsv1alpha1.HealthStatus{Status: managedgitopsv1alpha1.HeathStatusCodeHealthy},
+			},
+		}
+
+		It("should combine the resources, the resource tree and the hook results into the nodes of the tree", func() {
+			nodes, truncated := generateResourceTreeNodes(appStatus)
+			Expect(truncated).To(BeFalse())
+			Expect(nodes).To(Equal(expectedNodes))
+		})
+
+		It("should truncate long messages, and truncate the nodes to the maximum number and size of a tree", func() {
+
+			By("truncating a long health message, on a character boundary")
+			nodes, truncated := truncateResourceTreeNodes([]managedgitopsv1alpha1.ResourceTreeNode{
+				{
+					ResourceTreeNodeRef: managedgitopsv1alpha1.ResourceTreeNodeRef{Kind: "Pod", Name: "my-pod"},
+					Health:              &managedgitopsv1alpha1.HealthStatus{Message: strings.Repeat("é", maxResourceTreeMessageLength+1)},
+				},
+			})
+			Expect(truncated).To(BeTrue())
+			Expect(nodes).To(HaveLen(1))
+			Expect(utf8.RuneCountInString(nodes[0].Health.Message)).To(Equal(maxResourceTreeMessageLength))
+			Expect(nodes[0].Health.Message).To(HaveSuffix("é..."))
+
+			By("truncating the nodes to the maximum number of nodes")
+			nodes, truncated = truncateResourceTreeNodes(make([]managedgitopsv1alpha1.ResourceTreeNode, maxResourceTreeNodes+1))
+			Expect(truncated).To(BeTrue())
+			Expect(nodes).To(HaveLen(maxResourceTreeNodes))
+
+			nodes, truncated = truncateResourceTreeNodes(make([]managedgitopsv1alpha1.ResourceTreeNode, maxResourceTreeNodes))
+			Expect(truncated).To(BeFalse())
+			Expect(nodes).To(HaveLen(maxResourceTreeNodes))
+
+			By("truncating the nodes to the maximum size of the tree")
+			largeNodes := []managedgitopsv1alpha1.ResourceTreeNode{}
+			for i := 0; i < maxResourceTreeNodes/2; i++ {
+				largeNodes = append(largeNodes, managedgitopsv1alpha1.ResourceTreeNode{
+					ResourceTreeNodeRef: managedgitopsv1alpha1.ResourceTreeNodeRef{Kind: "ConfigMap", Name: fmt.Sprintf("%d-%s", i, strings.Repeat("a", 2000))},
+				})
+			}
+			nodes, truncated = truncateResourceTreeNodes(largeNodes)
+			Expect(truncated).To(BeTrue())
+			Expect(len(nodes)).To(BeNumerically("<", len(largeNodes)))
+
+			nodesJSON, err := json.Marshal(nodes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(nodesJSON)).To(BeNumerically("<=", maxResourceTreeSize+len(nodes)+1))
+		})
+
+		It("should create the GitOpsDeploymentResourceTree, owned by the GitOpsDeployment, and update it when the tree changes", func() {
+
+			scheme, argocdNamespace, kubesystemNamespace, workspace, err := tests.GenericTestSetup()
+			Expect(err).ToNot(HaveOccurred())
+
+			gitopsDepl := &managedgitopsv1alpha1.GitOpsDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "my-gitops-depl",
+					Namespace: workspace.Name,
+					UID:       uuid.NewUUID(),
+				},
+			}
+
+			k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(gitopsDepl, workspace, argocdNamespace, kubesystemNamespace).Build()
+
+			a := applicationEventLoopRunner_Action{
+				workspaceClient: k8sClient,
+				log:             log.FromContext(context.Background()),
+			}
+
+			By("leaving the tree unchanged if the ApplicationState does not contain a resource tree")
+			err = a.reconcileGitOpsDeploymentResourceTree(context.Background(), gitopsDepl, &fauxargocd.FauxApplicationStatus{})
+			Expect(err).ToNot(HaveOccurred())
+
+			resourceTree := &managedgitopsv1alpha1.GitOpsDeploymentResourceTree{}
+			err = k8sClient.Get(context.Background(), client.ObjectKeyFromObject(gitopsDepl), resourceTree)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			By("creating the tree")
+			err = a.reconcileGitOpsDeploymentResourceTree(context.Background(), gitopsDepl, appStatus)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = k8sClient.Get(context.Background(), client.ObjectKeyFromObject(gitopsDepl), resourceTree)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resourceTree.Status.Nodes).To(Equal(expectedNodes))
+			Expect(resourceTree.OwnerReferences).To(HaveLen(1))
+			Expect(resourceTree.OwnerReferences[0].UID).To(Equal(gitopsDepl.UID))
+
+			By("updating the tree when a resource has been pruned")
+			prunedStatus := *appStatus
+			prunedStatus.Resources = []fauxargocd.ResourceStatus{appStatus.Resources[0], appStatus.Resources[2]}
+
+			err = a.reconcileGitOpsDeploymentResourceTree(context.Background(), gitopsDepl, &prunedStatus)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = k8sClient.Get(context.Background(), client.ObjectKeyFromObject(gitopsDepl), resourceTree)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resourceTree.Status.Nodes).To(Equal([]managedgitopsv1alpha1.ResourceTreeNode{expectedNodes[0], expectedNodes[2], expectedNodes[3]}))
+		})
 	})
 
 	Context("Test extractOperationState function", func() {
@@ -1111,6 +1297,50 @@ var _ = Describe("ApplicationEventLoop Test", func() {
 		})
 	})
 
+	Context("Test extractRevisionHistory function", func() {
+		It("should convert the history of the compressed Application status to GitOpsDeployment RevisionHistory", func() {
+			deployStartedAt := metav1.NewTime(time.Now().Add(-time.Minute).Truncate(time.Second))
+			deployedAt := metav1.NewTime(time.Now().Truncate(time.Second))
+
+			appStatusBytes, err := sharedutil.CompressObject(fauxargocd.FauxApplicationStatus{
+				History: fauxargocd.RevisionHistories{
+					{
+						ID:              0,
+						Revision:        "abc",
+						DeployStartedAt: &deployStartedAt,
+						DeployedAt:      deployedAt,
+						Source: fauxargocd.ApplicationSource{
+							RepoURL: "https://github.com/test/test",
+							Path:    "environments/overlays/dev",
+						},
+					},
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			appStatus, err := decompressApplicationStatus(appStatusBytes)
+			Expect(err).ToNot(HaveOccurred())
+
+			history, err := extractRevisionHistory(appStatus.History)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(history).To(HaveLen(1))
+			Expect(history[0].ID).To(Equal(int64(0)))
+			Expect(history[0].Revision).To(Equal("abc"))
+			Expect(history[0].DeployStartedAt.Time.Equal(deployStartedAt.Time)).To(BeTrue())
+			Expect(history[0].DeployedAt.Time.Equal(deployedAt.Time)).To(BeTrue())
+			Expect(history[0].Source).To(Equal(managedgitopsv1alpha1.ApplicationSource{
+				RepoURL: "https://github.com/test/test",
+				Path:    "environments/overlays/dev",
+			}))
+		})
+
+		It("should return nil if there is no history", func() {
+			history, err := extractRevisionHistory(nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(history).To(BeNil())
+		})
+	})
+
 	Context("Test removeFinalizerIfExist function", func() {
 
 		var (
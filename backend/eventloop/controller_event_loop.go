@@ -5,6 +5,7 @@ import (
 
 	logutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util/log"
 	"github.com/redhat-appstudio/managed-gitops/backend/eventloop/eventlooptypes"
+	"github.com/redhat-appstudio/managed-gitops/backend/sharding"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
@@ -18,6 +19,7 @@ import (
 //       Events on namespace 'B' go to the Workspace Event Loop instance responsible for handling namespace 'B'. Etc.
 // - Ensure a new Workspace Event Loop goroutine is running for each active workspace. (When a new workspace is first
 //   encountered, a new instance of the goroutine is started).
+// - If sharding is enabled, ignore events for namespaces that are owned by another backend replica.
 
 // Cardinality: A single instance of the controller event loop (e.g. a single goroutine) exists for the whole of
 // the GitOps Service backend.
@@ -85,6 +87,13 @@ func controllerEventLoopRouter(input chan eventlooptypes.EventLoopEvent, workspa
 		log.V(logutil.LogLevel_Debug).Info("eventLoop received event",
 			"event", eventlooptypes.StringEventLoopEvent(&event))
 
+		// If sharding is enabled, events for namespaces that are owned by another backend replica are ignored: that
+		// replica is responsible for processing them.
+		if !sharding.IsNamespaceOwnedByThisReplica(event.WorkspaceID) {
+			log.V(logutil.LogLevel_Debug).Info("ignoring event for namespace that is owned by another backend replica")
+			continue
+		}
+
 		workspaceEntryVal, ok := workspaceEntries[event.WorkspaceID]
 		if !ok {
 
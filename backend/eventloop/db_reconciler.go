@@ -1214,7 +1214,20 @@ func cleanOrphanedEntriesfromTable_ClusterUser(ctx context.Context, dbQueries db
 				userDB.Clusteruser_id != db.SpecialClusterUserName &&
 				time.Since(userDB.Created_on) > waitTimeforRowDelete {
 
-				// 1) Remove the user from database
+				// 1) Remove the sync windows of the user, as they reference the user via a foreign key
+				if rowsDeleted, err := dbQueries.DeleteAppProjectSyncWindowsByClusterUserId(ctx, userDB.Clusteruser_id); err != nil {
+					log.Error(err, "Error occurred in cleanOrphanedEntriesfromTable_ClusterUser while deleting AppProjectSyncWindows of ClusterUser: "+userDB.Clusteruser_id)
+
+					if res == nil {
+						res = fmt.Errorf("error occurred in cleanOrphanedEntriesfromTable_ClusterUser while deleting AppProjectSyncWindows: %w", err)
+					}
+					continue
+
+				} else if rowsDeleted > 0 {
+					log.Info("Deleted AppProjectSyncWindows of orphaned ClusterUser", "clusterUserID", userDB.Clusteruser_id, "rowsDeleted", rowsDeleted)
+				}
+
+				// 2) Remove the user from database
 				if err := deleteDbEntry(ctx, userDB.Clusteruser_id, dbType_ClusterUser, nil, dbQueries, log); err != nil {
 					log.Error(err, "Error occurred in cleanOrphanedEntriesfromTable_ClusterUser while deleting ClusterUser entry : "+userDB.Clusteruser_id+" from DB.")
 
@@ -1624,6 +1637,7 @@ func createOperation(ctx context.Context, gitopsengineinstanceId, resourceId, na
 		Instance_id:   gitopsengineinstanceId,
 		Resource_id:   resourceId,
 		Resource_type: resourceType,
+		Priority:      db.OperationPriority_SelfHeal,
 	}
 
 	// Get Special user created for internal use,
@@ -2142,6 +2142,44 @@ var _ = Describe("DB Clean-up Function Tests", func() {
 			Expect(db.IsResultNotFoundError(err)).To(BeTrue())
 		})
 
+		It("Should delete ClusterUser and its AppProjectSyncWindows, if it is not used in any other table and it's created time is more than 'waitTimeforRowDelete'.", func() {
+
+			defer dbq.CloseDatabase()
+
+			// Set "Created_on" field to > waitTimeForRowDelete
+			user.Created_on = time.Now().Add(-1 * (waitTimeforRowDelete + 1*time.Second))
+
+			By("Create Cluster user, with a sync window.")
+
+			err := dbq.CreateClusterUser(ctx, &user)
+			Expect(err).ToNot(HaveOccurred())
+
+			syncWindow := db.AppProjectSyncWindow{
+				Clusteruser_id:   user.Clusteruser_id,
+				Application_name: "test-app",
+				Kind:             "deny",
+				Schedule:         "0 9 * * 1-5",
+				Duration:         "8h",
+			}
+			err = dbq.CreateAppProjectSyncWindow(ctx, &syncWindow)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Call clean-up function.")
+
+			Expect(cleanOrphanedEntriesfromTable_ClusterUser(ctx, dbq, k8sClient, true, log)).To(Succeed())
+
+			By("Verify that ClusterUser entry and its sync window are deleted from DB.")
+
+			err = dbq.GetClusterUserByUsername(ctx, &user)
+			Expect(err).To(HaveOccurred())
+			Expect(db.IsResultNotFoundError(err)).To(BeTrue())
+
+			var syncWindows []db.AppProjectSyncWindow
+			err = dbq.ListAppProjectSyncWindowByClusterUserId(ctx, user.Clusteruser_id, &syncWindows)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(syncWindows).To(BeEmpty())
+		})
+
 		It("Should not delete ClusterUser, if it is not used in any other table but it's created time is less than 'waitTimeforRowDelete'.", func() {
 
 			defer dbq.CloseDatabase()
@@ -3,12 +3,16 @@ package eventlooptypes
 import (
 	"context"
 	"fmt"
+	"sync"
+	"time"
 
 	gitopsv1alpha1 "github.com/redhat-appstudio/managed-gitops/backend-shared/apis/managed-gitops/v1alpha1"
 	"github.com/redhat-appstudio/managed-gitops/backend-shared/db"
+	dbutil "github.com/redhat-appstudio/managed-gitops/backend-shared/db/util"
 	sharedutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -132,16 +136,103 @@ func GetWorkspaceIDFromNamespaceID(namespace corev1.Namespace) string {
 }
 
 // GetK8sClientForGitOpsEngineInstance returns a client for accessing resources from a GitOpsEngine cluster based on the environment.
-// Returns a normal client that targets the same cluster as backend.
+//   - If the GitOpsEngineCluster of the instance references a kubeconfig Secret (as is the case for engine instances that are
+//     running on a separate cluster), a client for that cluster is returned.
+//   - Otherwise, a normal client that targets the same cluster as backend is returned.
+//
+// The client of each instance is cached for engineInstanceClientCacheTTL, so that the engine cluster and kubeconfig
+// Secret are not looked up (and a new client is not created) on every call.
 func GetK8sClientForGitOpsEngineInstance(ctx context.Context, gitopsEngineInstance *db.GitopsEngineInstance) (client.Client, error) {
 
-	// TODO: GITOPSRVCE-66: Update this once we support using Argo CD instances that are running on a separate cluster	serviceClient, err := GetK8sClientForServiceWorkspace()
+	if gitopsEngineInstance == nil || gitopsEngineInstance.EngineCluster_id == "" {
+		return GetK8sClientForServiceWorkspace()
+	}
+
+	if k8sClient, exists := engineInstanceClientCache.get(*gitopsEngineInstance, time.Now()); exists {
+		return k8sClient, nil
+	}
+
+	k8sClient, err := newK8sClientForGitOpsEngineInstance(ctx, gitopsEngineInstance)
+	if err != nil {
+		// Errors are not cached, so that the client is created again on the next call
+		return nil, err
+	}
+
+	engineInstanceClientCache.set(*gitopsEngineInstance, k8sClient, time.Now())
+
+	return k8sClient, nil
+}
+
+// engineInstanceClientCacheTTL is the time after which the cached client of a GitOpsEngine instance is created again,
+// so that changes to the kubeconfig Secret of the engine cluster are picked up.
+const engineInstanceClientCacheTTL = 5 * time.Minute
+
+// engineInstanceClientCache caches the client of each GitOpsEngine instance, by engine instance ID.
+var engineInstanceClientCache = &k8sClientCache{}
+
+type k8sClientCache struct {
+	mutex   sync.Mutex
+	entries map[string]k8sClientCacheEntry
+}
+
+type k8sClientCacheEntry struct {
+	// engineClusterID is the GitOpsEngine cluster of the instance, when the client was created
+	engineClusterID string
+	client          client.Client
+	expireTime      time.Time
+}
+
+func (c *k8sClientCache) get(gitopsEngineInstance db.GitopsEngineInstance, now time.Time) (client.Client, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry, exists := c.entries[gitopsEngineInstance.Gitopsengineinstance_id]
+	if !exists || entry.engineClusterID != gitopsEngineInstance.EngineCluster_id || now.After(entry.expireTime) {
+		return nil, false
+	}
+	return entry.client, true
+}
+
+func (c *k8sClientCache) set(gitopsEngineInstance db.GitopsEngineInstance, k8sClient client.Client, now time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.entries == nil {
+		c.entries = map[string]k8sClientCacheEntry{}
+	}
+
+	c.entries[gitopsEngineInstance.Gitopsengineinstance_id] = k8sClientCacheEntry{
+		engineClusterID: gitopsEngineInstance.EngineCluster_id,
+		client:          k8sClient,
+		expireTime:      now.Add(engineInstanceClientCacheTTL),
+	}
+}
+
+// newK8sClientForGitOpsEngineInstance creates a client for the cluster of the given GitOpsEngine instance: see
+// GetK8sClientForGitOpsEngineInstance.
+func newK8sClientForGitOpsEngineInstance(ctx context.Context, gitopsEngineInstance *db.GitopsEngineInstance) (client.Client, error) {
+
 	serviceClient, err := GetK8sClientForServiceWorkspace()
 	if err != nil {
 		return nil, err
 	}
 
-	return serviceClient, nil
+	dbQueries, err := db.NewSharedProductionPostgresDBQueries(false)
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to database: %v", err)
+	}
+
+	restConfig, err := dbutil.GetRESTConfigForGitOpsEngineCluster(ctx, gitopsEngineInstance.EngineCluster_id, serviceClient, dbQueries)
+	if err != nil {
+		return nil, err
+	}
+
+	if restConfig == nil {
+		// The engine cluster is the same cluster as backend
+		return serviceClient, nil
+	}
+
+	return newK8sClientForRESTConfig(restConfig)
 }
 
 // GetK8sClientForServiceWorkspace returns a client for service provider workspace
@@ -151,8 +242,13 @@ func GetK8sClientForServiceWorkspace() (client.Client, error) {
 		return nil, err
 	}
 
+	return newK8sClientForRESTConfig(config)
+}
+
+func newK8sClientForRESTConfig(config *rest.Config) (client.Client, error) {
+
 	scheme := runtime.NewScheme()
-	err = gitopsv1alpha1.AddToScheme(scheme)
+	err := gitopsv1alpha1.AddToScheme(scheme)
 	if err != nil {
 		return nil, err
 	}
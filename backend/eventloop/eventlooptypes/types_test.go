@@ -1,10 +1,14 @@
 package eventlooptypes
 
 import (
+	"time"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/redhat-appstudio/managed-gitops/backend-shared/db"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 var _ = Describe("eventlooptypes test", func() {
@@ -94,4 +98,36 @@ var _ = Describe("eventlooptypes test", func() {
 		)
 
 	})
+
+	Context("k8sClientCache", func() {
+
+		It("should return the cached client of an engine instance, until it expires or the engine cluster changes", func() {
+
+			cache := &k8sClientCache{}
+			now := time.Now()
+
+			instance := db.GitopsEngineInstance{Gitopsengineinstance_id: "instance-id", EngineCluster_id: "cluster-id"}
+
+			By("returning nothing for an instance that is not cached")
+			_, exists := cache.get(instance, now)
+			Expect(exists).To(BeFalse())
+
+			k8sClient := fake.NewClientBuilder().Build()
+			cache.set(instance, k8sClient, now)
+
+			By("returning the cached client")
+			cachedClient, exists := cache.get(instance, now.Add(time.Minute))
+			Expect(exists).To(BeTrue())
+			Expect(cachedClient).To(BeIdenticalTo(k8sClient))
+
+			By("not returning the client once it has expired")
+			_, exists = cache.get(instance, now.Add(engineInstanceClientCacheTTL+time.Second))
+			Expect(exists).To(BeFalse())
+
+			By("not returning the client if the instance has moved to another engine cluster")
+			instance.EngineCluster_id = "other-cluster-id"
+			_, exists = cache.get(instance, now)
+			Expect(exists).To(BeFalse())
+		})
+	})
 })
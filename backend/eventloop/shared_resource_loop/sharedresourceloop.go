@@ -11,6 +11,7 @@ import (
 	"github.com/redhat-appstudio/managed-gitops/backend-shared/db"
 	dbutil "github.com/redhat-appstudio/managed-gitops/backend-shared/db/util"
 	sharedutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util"
+	argosharedutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util/argocd"
 	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/gitopserrors"
 	logutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util/log"
 	corev1 "k8s.io/api/core/v1"
@@ -50,6 +51,7 @@ type SharedResourceEventLoop struct {
 }
 
 // ReconcileAppProjectRepositories ensures that the necessary AppProjectRepository database rows exists in the database, and that they are consistent with the GitOpsDeployment/GitOpsDeploymentRepositoryCredentials defined in the given Namespace.
+// Likewise, it ensures that the AppProjectSyncWindow database rows are consistent with the sync windows of the GitOpsDeployments in the Namespace.
 //
 // parameters:
 // - gitRepoURLUnnormalizedOfRequest is the repository URL defined in the GitOpDeployment or GitOpsDeploymentRepositoryCredential for which
@@ -513,7 +515,7 @@ func (srel *SharedResourceEventLoop) processSharedResourceMessage(ctx context.Co
 			}
 
 			repositoryCredential, err = internalProcessMessage_ReconcileRepositoryCredential(ctx,
-				payload.repositoryCredentialCRName, msg.workspaceNamespace, repoCredValidationFunction, msg.workspaceClient, dbQueries, true, log)
+				payload.repositoryCredentialCRName, msg.workspaceNamespace, repoCredValidationFunction, msg.workspaceClient, payload.k8sClientFactory, dbQueries, true, log)
 
 		} else {
 			err = fmt.Errorf("SEVERE - unexpected cast in internalSharedResourceEventLoop")
@@ -566,7 +568,17 @@ func (srel *SharedResourceEventLoop) processSharedResourceMessage(ctx context.Co
 
 func internalProcessMessage_reconcileAppProjectRepositories(ctx context.Context, payload sharedResourceLoopMessage_reconcileAppProjectRepositoriesRequest, namespace corev1.Namespace, workspaceClient client.Client, dbQueries db.DatabaseQueries, l logr.Logger) (bool, error) {
 
-	return reconcileAppProjectRepositories(ctx, namespace, workspaceClient, dbQueries, l)
+	reposUpdated, err := reconcileAppProjectRepositories(ctx, namespace, workspaceClient, dbQueries, l)
+	if err != nil {
+		return false, err
+	}
+
+	syncWindowsUpdated, err := reconcileAppProjectSyncWindows(ctx, namespace, workspaceClient, dbQueries, l)
+	if err != nil {
+		return false, err
+	}
+
+	return reposUpdated || syncWindowsUpdated, nil
 }
 
 // reconcileAppProjectRepositories ensures that the necessary AppProjectRepository database rows exists in the database, and that they are consistent with the GitOpsDeployment/GitOpsDeploymentRepositoryCredentials defined in the Namespace.
@@ -611,13 +623,22 @@ func reconcileAppProjectRepositories(ctx context.Context, namespace corev1.Names
 	}
 
 	for _, gitopsDepl := range gitopsDeployments.Items {
-		gitURLOfGitOpsDepl := NormalizeGitURL(gitopsDepl.Spec.Source.RepoURL)
 
-		expectedEntry := db.AppProjectRepository{
-			Clusteruser_id: clusterUser.Clusteruser_id,
-			RepoURL:        gitURLOfGitOpsDepl,
+		// A GitOpsDeployment with multiple sources requires an entry for the repository of each source
+		sources := []managedgitopsv1alpha1.ApplicationSource{gitopsDepl.Spec.Source}
+		if gitopsDepl.Spec.HasMultipleSources() {
+			sources = gitopsDepl.Spec.Sources
+		}
+
+		for _, source := range sources {
+			gitURLOfGitOpsDepl := NormalizeGitURL(source.RepoURL)
+
+			expectedEntry := db.AppProjectRepository{
+				Clusteruser_id: clusterUser.Clusteruser_id,
+				RepoURL:        gitURLOfGitOpsDepl,
+			}
+			expectedDBEntries[gitURLOfGitOpsDepl] = expectedEntry
 		}
-		expectedDBEntries[gitURLOfGitOpsDepl] = expectedEntry
 	}
 
 	resDatabaseUpdated := false // Whether or not the database was updated by this call
@@ -671,6 +692,113 @@ func reconcileAppProjectRepositories(ctx context.Context, namespace corev1.Names
 	return resDatabaseUpdated, nil
 }
 
+// appProjectSyncWindowKey uniquely identifies a sync window of an Argo CD Application, and is used to compare the
+// sync windows in the database with the sync windows of the GitOpsDeployments in the Namespace.
+type appProjectSyncWindowKey struct {
+	applicationName string
+	kind            string
+	schedule        string
+	duration        string
+	manualSync      bool
+	timeZone        string
+}
+
+func newAppProjectSyncWindowKey(syncWindow db.AppProjectSyncWindow) appProjectSyncWindowKey {
+	return appProjectSyncWindowKey{
+		applicationName: syncWindow.Application_name,
+		kind:            syncWindow.Kind,
+		schedule:        syncWindow.Schedule,
+		duration:        syncWindow.Duration,
+		manualSync:      syncWindow.Manual_sync,
+		timeZone:        syncWindow.Time_zone,
+	}
+}
+
+// reconcileAppProjectSyncWindows ensures that the AppProjectSyncWindow database rows of the user are consistent with the
+// sync windows of the GitOpsDeployments defined in the Namespace.
+func reconcileAppProjectSyncWindows(ctx context.Context, namespace corev1.Namespace, workspaceClient client.Client, dbQueries db.DatabaseQueries, l logr.Logger) (bool, error) {
+
+	clusterUser, _, err := internalProcessMessage_GetOrCreateClusterUserByNamespaceUID(ctx, namespace, dbQueries, l)
+	if err != nil || clusterUser == nil {
+		return false, fmt.Errorf("unable to retrieve cluster user in reconcileAppProjectSyncWindows, from namespace '%s': %w", namespace.Name, err)
+	}
+
+	var syncWindowsInDB []db.AppProjectSyncWindow
+	if err := dbQueries.ListAppProjectSyncWindowByClusterUserId(ctx, clusterUser.Clusteruser_id, &syncWindowsInDB); err != nil {
+		return false, fmt.Errorf("unable to list app project sync windows from DB when reconciling AppProjectSyncWindow: %w", err)
+	}
+
+	var gitopsDeployments managedgitopsv1alpha1.GitOpsDeploymentList
+	if err := workspaceClient.List(ctx, &gitopsDeployments, &client.ListOptions{Namespace: namespace.Name}); err != nil {
+		return false, fmt.Errorf("unable to list GitOpsDeployments when reconciling AppProjectSyncWindow in Namespace '%s': %w", namespace.Name, err)
+	}
+
+	// map: sync window key -> expected db entry
+	expectedDBEntries := map[appProjectSyncWindowKey]db.AppProjectSyncWindow{}
+
+	for _, gitopsDepl := range gitopsDeployments.Items {
+
+		// A GitOpsDeployment that is being deleted (or whose Namespace is being deleted) no longer requires its sync windows
+		if gitopsDepl.DeletionTimestamp != nil || namespace.DeletionTimestamp != nil {
+			continue
+		}
+
+		for _, syncWindow := range gitopsDepl.Spec.SyncWindows {
+			expectedEntry := db.AppProjectSyncWindow{
+				Clusteruser_id:   clusterUser.Clusteruser_id,
+				Application_name: argosharedutil.GenerateArgoCDApplicationName(string(gitopsDepl.UID)),
+				Kind:             string(syncWindow.Kind),
+				Schedule:         syncWindow.Schedule,
+				Duration:         syncWindow.Duration,
+				Manual_sync:      syncWindow.ManualSync,
+				Time_zone:        syncWindow.TimeZone,
+			}
+			expectedDBEntries[newAppProjectSyncWindowKey(expectedEntry)] = expectedEntry
+		}
+	}
+
+	resDatabaseUpdated := false // Whether or not the database was updated by this call
+
+	// For each existing entry in the database, delete it if it is no longer expected (or is a duplicate)
+	for idx := range syncWindowsInDB {
+
+		syncWindowFromDB := syncWindowsInDB[idx]
+
+		key := newAppProjectSyncWindowKey(syncWindowFromDB)
+
+		if _, exists := expectedDBEntries[key]; exists {
+			// The entry already exists in the DB, so there is no more work to do for it.
+			delete(expectedDBEntries, key)
+			continue
+		}
+
+		if numDeleted, err := dbQueries.DeleteAppProjectSyncWindowByAppProjectSyncWindowID(ctx, &syncWindowFromDB); err != nil {
+			return false, fmt.Errorf("unable to delete AppProjectSyncWindow which was identified as no longer being required: %w", err)
+
+		} else if numDeleted == 0 {
+			l.V(logutil.LogLevel_Warn).Info("unexpected number of results when deleting AppProjectSyncWindow which was identified as no longer being required", syncWindowFromDB.GetAsLogKeyValues()...)
+		} else {
+			l.Info("deleted AppProjectSyncWindow which was identified as no longer in use", syncWindowFromDB.GetAsLogKeyValues()...)
+			resDatabaseUpdated = true
+		}
+	}
+
+	// Finally, the entries that are still defined in 'expectedDBEntries' need to be created.
+	for key := range expectedDBEntries {
+
+		newSyncWindow := expectedDBEntries[key]
+
+		if err := dbQueries.CreateAppProjectSyncWindow(ctx, &newSyncWindow); err != nil {
+			return false, fmt.Errorf("unable to create AppProjectSyncWindow: %w", err)
+		}
+
+		l.Info("created new AppProjectSyncWindow", newSyncWindow.GetAsLogKeyValues()...)
+		resDatabaseUpdated = true
+	}
+
+	return resDatabaseUpdated, nil
+}
+
 func deleteRepoCredFromDB(ctx context.Context, repoCredRow db.RepositoryCredentials, repositoryCredentialCRNamespace corev1.Namespace, apiNamespaceClient client.Client, dbQueries db.DatabaseQueries, l logr.Logger) (bool, error) {
 	const retry, noRetry = true, false
 
@@ -718,10 +846,30 @@ func compareAndModifyClusterResourceWithDatabaseRow(cr managedgitopsv1alpha1.Git
 		isRepoUpdateNeeded = true
 	}
 
-	// Fetch these data from the secret
-	authUsername := string(secret.Data["username"])
-	authPassword := string(secret.Data["password"])
-	authSSHKey := string(secret.Data["sshPrivateKey"])
+	// If enabled, the row should reference the Secret, rather than contain a copy of the credentials.
+	// - The cluster-agent reads the credentials from the Secret, so the Operation that is created when the resourceVersion
+	//   changes is sufficient for changes to the Secret to propagate to Argo CD.
+	var authUsername, authPassword, authSSHKey string
+	var secretRefNamespace, secretRefName, secretRefResourceVersion string
+	if sharedutil.CredentialsSecretReferenceEnabled() {
+		secretRefNamespace = secret.Namespace
+		secretRefName = secret.Name
+		secretRefResourceVersion = secret.ResourceVersion
+	} else {
+		// Fetch these data from the secret
+		authUsername = string(secret.Data["username"])
+		authPassword = string(secret.Data["password"])
+		authSSHKey = string(secret.Data["sshPrivateKey"])
+	}
+
+	var isSecretRefUpdateNeeded bool
+	if secretRefNamespace != dbr.SecretRefNamespace || secretRefName != dbr.SecretRefName || secretRefResourceVersion != dbr.SecretRefResourceVersion {
+		l.Info("Secret reference changed", "oldResourceVersion", dbr.SecretRefResourceVersion, "newResourceVersion", secretRefResourceVersion)
+		dbr.SecretRefNamespace = secretRefNamespace
+		dbr.SecretRefName = secretRefName
+		dbr.SecretRefResourceVersion = secretRefResourceVersion
+		isSecretRefUpdateNeeded = true
+	}
 
 	// Compare the data from the secret with the data from the DB
 	var isAuthUsernameUpdateNeeded bool
@@ -746,7 +894,7 @@ func compareAndModifyClusterResourceWithDatabaseRow(cr managedgitopsv1alpha1.Git
 	}
 
 	return isSecretUpdateNeeded || isRepoUpdateNeeded || isAuthUsernameUpdateNeeded ||
-		isAuthPasswordUpdateNeeded || isAuthSSHKeyUpdateNeeded
+		isAuthPasswordUpdateNeeded || isAuthSSHKeyUpdateNeeded || isSecretRefUpdateNeeded
 }
 
 func internalProcessMessage_GetGitopsEngineInstanceById(ctx context.Context, id string, dbq db.DatabaseQueries) (*db.GitopsEngineInstance, error) {
@@ -814,7 +962,7 @@ func internalProcessMessage_GetOrCreateSharedResources(ctx context.Context, gito
 			fmt.Errorf("unable to get or created managed env on deployment modified event: %w", err)
 	}
 
-	engineInstance, isNewInstance, gitopsEngineCluster, uerr := internalDetermineGitOpsEngineInstance(ctx, *clusterUser, gitopsEngineClient, dbQueries, l)
+	engineInstance, isNewInstance, gitopsEngineCluster, uerr := internalDetermineGitOpsEngineInstance(ctx, *clusterUser, workspaceNamespace, gitopsEngineClient, dbQueries, l)
 	if uerr != nil {
 		return SharedResourceManagedEnvContainer{},
 			createUnknownErrorEnvInitCondition(), fmt.Errorf("unable to determine gitops engine instance: %w", uerr.DevError())
@@ -847,18 +995,26 @@ func internalProcessMessage_GetOrCreateSharedResources(ctx context.Context, gito
 }
 
 // Whenever a new Argo CD Application needs to be created, we need to find an Argo CD instance
-// that is available to use it. In the future, when we have multiple instances, there would
-// be an algorithm that intelligently places applications -> instances, to ensure that
-// there are no Argo CD instances that are overloaded (have too many users).
+// that is available to use it.
 //
-// However, at the moment we are using a single shared Argo CD instnace, so we will
-// just return that.
+// If a placement configuration file is configured, the user is placed on one of the instances from that file (see
+// sharedresourceloop_engineplacement.go). Otherwise, the single shared Argo CD instance, on the same cluster as the
+// backend, is returned.
 //
 // The bool return value is 'true' if GitOpsEngineInstance is created; 'false' if it already exists in DB or in case of failure.
-//
-// This logic would be improved by https://issues.redhat.com/browse/GITOPSRVCE-73 (and others)
-func internalDetermineGitOpsEngineInstance(ctx context.Context, user db.ClusterUser, k8sClient client.Client, dbq db.DatabaseQueries, l logr.Logger) (*db.GitopsEngineInstance, bool, *db.GitopsEngineCluster, gitopserrors.ConditionError) {
-	// TODO: GITOPSRVCE-73 - Once we have a way to distribute work between Argo CD instances, update this function.
+func internalDetermineGitOpsEngineInstance(ctx context.Context, user db.ClusterUser, workspaceNamespace corev1.Namespace,
+	k8sClient client.Client, dbq db.DatabaseQueries, l logr.Logger) (*db.GitopsEngineInstance, bool, *db.GitopsEngineCluster, gitopserrors.ConditionError) {
+
+	placementConfig, err := getGitOpsEnginePlacementConfig()
+	if err != nil {
+		devError := fmt.Errorf("unable to read GitOps engine placement configuration: %w", err)
+		return nil, false, nil, gitopserrors.NewUserConditionError(gitopserrors.UnknownError, devError, string(managedgitopsv1alpha1.ConditionReasonUnknownError))
+	}
+
+	if placementConfig != nil {
+		return placeUserOnGitOpsEngineInstance(ctx, *placementConfig, user, workspaceNamespace, k8sClient, dbq, l)
+	}
+
 	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: dbutil.GetGitOpsEngineSingleInstanceNamespace()}}
 	if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(namespace), namespace); err != nil {
 		devError := fmt.Errorf("unable to retrieve gitopsengine namespace in internalDetermineGitOpsEngineInstanceForNewApplication: %w", err)
@@ -880,17 +1036,6 @@ func internalDetermineGitOpsEngineInstance(ctx context.Context, user db.ClusterU
 		return nil, false, nil, gitopserrors.NewUserConditionError(userMsg, devError, string(managedgitopsv1alpha1.ConditionReasonDatabaseError))
 	}
 
-	// When we support multiple Argo CD instance, the algorithm would initially be:
-	//
-	// algorithm input:
-	// - user
-	//
-	// output:
-	// - gitops engine instance
-	//
-	// In a way that ensures that applications are balanced between instances.
-	// Preliminary thoughts: https://docs.google.com/document/d/15E8d5frNuTFEdCHMlNSk0LQr6DI7BtiypxIC2AnW-OQ/edit#
-
 	return gitopsEngineInstance, isNewInstance, gitopsEngineCluster, nil
 }
 
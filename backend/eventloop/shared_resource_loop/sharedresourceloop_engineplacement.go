@@ -0,0 +1,436 @@
+package shared_resource_loop
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	managedgitopsv1alpha1 "github.com/redhat-appstudio/managed-gitops/backend-shared/apis/managed-gitops/v1alpha1"
+	"github.com/redhat-appstudio/managed-gitops/backend-shared/db"
+	dbutil "github.com/redhat-appstudio/managed-gitops/backend-shared/db/util"
+	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/gitopserrors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// GitOps engine instance placement
+//
+// By default, every user is placed on the single Argo CD instance that is running on the same cluster as the backend
+// (in the namespace from the ARGO_CD_NAMESPACE environment variable).
+//
+// If the GITOPS_ENGINE_PLACEMENT_CONFIG_FILE environment variable is set, the file it points to (usually mounted from
+// a ConfigMap) defines the Argo CD instances that users may be placed on, and how they are placed. When a user is first
+// placed, the following rules are applied, in order:
+//  1. Tenant pinning: if the user's namespace is listed in 'tenantPinning', the user is placed on that instance.
+//  2. Label: only instances whose 'namespaceSelector' matches the labels of the user's namespace are eligible (an
+//     instance without a 'namespaceSelector' matches every namespace).
+//  3. Capacity: instances that already host 'maxApplications' Applications are not eligible. Of the remaining instances,
+//     the user is placed on the instance that hosts the fewest Applications.
+//
+// Once a user has been placed on an instance (that is, once a ClusterAccess row exists for the user), the user remains
+// on that instance.
+
+const (
+	// GitOpsEnginePlacementConfigFileEnv is the environment variable containing the path of the placement configuration file.
+	GitOpsEnginePlacementConfigFileEnv = "GITOPS_ENGINE_PLACEMENT_CONFIG_FILE"
+)
+
+// GitOpsEnginePlacementConfig is the contents of the placement configuration file.
+type GitOpsEnginePlacementConfig struct {
+	// EngineInstances are the Argo CD instances that users may be placed on.
+	EngineInstances []GitOpsEngineInstanceConfig `json:"engineInstances"`
+
+	// TenantPinning is a map from the name of a user's namespace, to the name of the engine instance that the user must be placed on.
+	TenantPinning map[string]string `json:"tenantPinning,omitempty"`
+}
+
+// GitOpsEngineInstanceConfig describes an Argo CD instance that users may be placed on.
+type GitOpsEngineInstanceConfig struct {
+	// Name uniquely identifies the instance within the placement configuration.
+	Name string `json:"name"`
+
+	// Namespace is the namespace of the Argo CD instance, on its cluster.
+	Namespace string `json:"namespace"`
+
+	// ClusterSecret references a Secret (on the backend cluster) containing a 'kubeconfig' for the cluster of the Argo CD
+	// instance. If not set, the Argo CD instance is on the same cluster as the backend.
+	ClusterSecret *GitOpsEngineClusterSecretReference `json:"clusterSecret,omitempty"`
+
+	// NamespaceSelector restricts the instance to users whose namespace matches the selector.
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// MaxApplications is the maximum number of Applications that may be hosted on the instance. 0 is unlimited.
+	MaxApplications int `json:"maxApplications,omitempty"`
+}
+
+// GitOpsEngineClusterSecretReference references a Secret containing a 'kubeconfig'.
+type GitOpsEngineClusterSecretReference struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+
+	// Context is the kubeconfig context to use. If not set, the current context of the kubeconfig is used.
+	Context string `json:"context,omitempty"`
+}
+
+// ParseGitOpsEnginePlacementConfig parses and validates the contents of a placement configuration file.
+func ParseGitOpsEnginePlacementConfig(contents []byte) (*GitOpsEnginePlacementConfig, error) {
+
+	var config GitOpsEnginePlacementConfig
+	if err := yaml.UnmarshalStrict(contents, &config); err != nil {
+		return nil, fmt.Errorf("unable to parse GitOps engine placement configuration: %w", err)
+	}
+
+	if len(config.EngineInstances) == 0 {
+		return nil, fmt.Errorf("GitOps engine placement configuration must contain at least one engine instance")
+	}
+
+	names := map[string]bool{}
+	for _, instance := range config.EngineInstances {
+		if instance.Name == "" || instance.Namespace == "" {
+			return nil, fmt.Errorf("GitOps engine instance must have a name and a namespace")
+		}
+		if names[instance.Name] {
+			return nil, fmt.Errorf("GitOps engine instance name '%s' is not unique", instance.Name)
+		}
+		names[instance.Name] = true
+
+		if instance.ClusterSecret != nil && (instance.ClusterSecret.Name == "" || instance.ClusterSecret.Namespace == "") {
+			return nil, fmt.Errorf("clusterSecret of GitOps engine instance '%s' must have a name and a namespace", instance.Name)
+		}
+
+		if instance.NamespaceSelector != nil {
+			if _, err := metav1.LabelSelectorAsSelector(instance.NamespaceSelector); err != nil {
+				return nil, fmt.Errorf("namespaceSelector of GitOps engine instance '%s' is invalid: %w", instance.Name, err)
+			}
+		}
+
+		if instance.MaxApplications < 0 {
+			return nil, fmt.Errorf("maxApplications of GitOps engine instance '%s' must not be negative", instance.Name)
+		}
+	}
+
+	for namespaceName, instanceName := range config.TenantPinning {
+		if !names[instanceName] {
+			return nil, fmt.Errorf("namespace '%s' is pinned to GitOps engine instance '%s', which does not exist", namespaceName, instanceName)
+		}
+	}
+
+	return &config, nil
+}
+
+// placementConfigCache caches the placement configuration file, so that it is only re-read when it is modified.
+var placementConfigCache struct {
+	mutex   sync.Mutex
+	path    string
+	modTime time.Time
+	config  *GitOpsEnginePlacementConfig
+}
+
+// getGitOpsEnginePlacementConfig returns the placement configuration, or nil if no placement configuration file is configured.
+func getGitOpsEnginePlacementConfig() (*GitOpsEnginePlacementConfig, error) {
+
+	path := os.Getenv(GitOpsEnginePlacementConfigFileEnv)
+	if path == "" {
+		return nil, nil
+	}
+
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read GitOps engine placement configuration file: %w", err)
+	}
+
+	placementConfigCache.mutex.Lock()
+	defer placementConfigCache.mutex.Unlock()
+
+	if placementConfigCache.config != nil && placementConfigCache.path == path && placementConfigCache.modTime.Equal(fileInfo.ModTime()) {
+		return placementConfigCache.config, nil
+	}
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read GitOps engine placement configuration file: %w", err)
+	}
+
+	config, err := ParseGitOpsEnginePlacementConfig(contents)
+	if err != nil {
+		return nil, err
+	}
+
+	placementConfigCache.path = path
+	placementConfigCache.modTime = fileInfo.ModTime()
+	placementConfigCache.config = config
+
+	return config, nil
+}
+
+// engineInstanceCandidate is an engine instance that a user may be placed on, along with its current number of Applications.
+type engineInstanceCandidate struct {
+	config           GitOpsEngineInstanceConfig
+	applicationCount int
+}
+
+// selectEngineInstanceConfig applies the placement rules to select an engine instance for a user in the given namespace.
+// 'countApplications' returns the number of Applications hosted on the given engine instance: an instance whose
+// Applications cannot be counted (for example, because its cluster is unreachable) is logged, and not selected.
+func selectEngineInstanceConfig(placementConfig GitOpsEnginePlacementConfig, workspaceNamespace corev1.Namespace,
+	countApplications func(GitOpsEngineInstanceConfig) (int, error), l logr.Logger) (*GitOpsEngineInstanceConfig, error) {
+
+	// 1) Tenant pinning
+	if pinnedInstanceName, exists := placementConfig.TenantPinning[workspaceNamespace.Name]; exists {
+		for idx := range placementConfig.EngineInstances {
+			if placementConfig.EngineInstances[idx].Name == pinnedInstanceName {
+				return &placementConfig.EngineInstances[idx], nil
+			}
+		}
+		return nil, fmt.Errorf("namespace '%s' is pinned to GitOps engine instance '%s', which does not exist", workspaceNamespace.Name, pinnedInstanceName)
+	}
+
+	var selected *engineInstanceCandidate
+
+	for _, instance := range placementConfig.EngineInstances {
+
+		// 2) Label
+		if instance.NamespaceSelector != nil {
+			selector, err := metav1.LabelSelectorAsSelector(instance.NamespaceSelector)
+			if err != nil {
+				return nil, err
+			}
+			if !selector.Matches(labels.Set(workspaceNamespace.Labels)) {
+				continue
+			}
+		}
+
+		// 3) Capacity
+		applicationCount, err := countApplications(instance)
+		if err != nil {
+			l.Error(err, "Skipping GitOps engine instance that is unavailable for placement", "engineInstanceName", instance.Name)
+			continue
+		}
+		if instance.MaxApplications > 0 && applicationCount >= instance.MaxApplications {
+			continue
+		}
+
+		if selected == nil || applicationCount < selected.applicationCount {
+			selected = &engineInstanceCandidate{config: instance, applicationCount: applicationCount}
+		}
+	}
+
+	if selected == nil {
+		return nil, fmt.Errorf("no GitOps engine instance is available for namespace '%s'", workspaceNamespace.Name)
+	}
+
+	return &selected.config, nil
+}
+
+// getExistingGitOpsEngineInstanceOfUser returns the engine instance that the user has previously been placed on, or nil if
+// the user has not yet been placed.
+func getExistingGitOpsEngineInstanceOfUser(ctx context.Context, user db.ClusterUser, dbq db.DatabaseQueries) (*db.GitopsEngineInstance, *db.GitopsEngineCluster, error) {
+
+	var clusterAccesses []db.ClusterAccess
+	if err := dbq.ListClusterAccessesByClusterUserID(ctx, user.Clusteruser_id, &clusterAccesses); err != nil {
+		return nil, nil, err
+	}
+
+	for _, clusterAccess := range clusterAccesses {
+
+		gitopsEngineInstance := db.GitopsEngineInstance{Gitopsengineinstance_id: clusterAccess.Clusteraccess_gitops_engine_instance_id}
+		if err := dbq.GetGitopsEngineInstanceById(ctx, &gitopsEngineInstance); err != nil {
+			if db.IsResultNotFoundError(err) {
+				continue
+			}
+			return nil, nil, err
+		}
+
+		gitopsEngineCluster := db.GitopsEngineCluster{Gitopsenginecluster_id: gitopsEngineInstance.EngineCluster_id}
+		if err := dbq.GetGitopsEngineClusterById(ctx, &gitopsEngineCluster); err != nil {
+			return nil, nil, err
+		}
+
+		return &gitopsEngineInstance, &gitopsEngineCluster, nil
+	}
+
+	return nil, nil, nil
+}
+
+// gitopsEngineInstanceNamespaces are the namespaces that identify an engine instance: the namespace of the instance,
+// and the kube-system namespace of its cluster.
+type gitopsEngineInstanceNamespaces struct {
+	namespace           corev1.Namespace
+	kubeSystemNamespace corev1.Namespace
+}
+
+// getGitOpsEngineInstanceNamespaces retrieves the namespaces of the given engine instance from its cluster. An error is
+// returned if the cluster of the instance is unreachable.
+func getGitOpsEngineInstanceNamespaces(ctx context.Context, instanceConfig GitOpsEngineInstanceConfig, k8sClient client.Client,
+	k8sClientFactory SRLK8sClientFactory) (*gitopsEngineInstanceNamespaces, error) {
+
+	// Retrieve a client for the cluster of the engine instance
+	engineClusterClient := k8sClient
+	if instanceConfig.ClusterSecret != nil {
+		restConfig, err := dbutil.GetRESTConfigFromKubeconfigSecret(ctx, k8sClient, instanceConfig.ClusterSecret.Namespace,
+			instanceConfig.ClusterSecret.Name, instanceConfig.ClusterSecret.Context)
+		if err != nil {
+			return nil, err
+		}
+
+		if engineClusterClient, err = k8sClientFactory.BuildK8sClient(restConfig); err != nil {
+			return nil, fmt.Errorf("unable to create client for GitOps engine instance '%s': %w", instanceConfig.Name, err)
+		}
+	}
+
+	res := gitopsEngineInstanceNamespaces{
+		namespace:           corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: instanceConfig.Namespace}},
+		kubeSystemNamespace: corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: serviceAccountNamespaceKubeSystem}},
+	}
+
+	if err := engineClusterClient.Get(ctx, client.ObjectKeyFromObject(&res.namespace), &res.namespace); err != nil {
+		return nil, fmt.Errorf("unable to retrieve namespace of GitOps engine instance '%s': %w", instanceConfig.Name, err)
+	}
+
+	if err := engineClusterClient.Get(ctx, client.ObjectKeyFromObject(&res.kubeSystemNamespace), &res.kubeSystemNamespace); err != nil {
+		return nil, fmt.Errorf("unable to retrieve kube-system namespace of GitOps engine instance '%s': %w", instanceConfig.Name, err)
+	}
+
+	return &res, nil
+}
+
+// getExistingGitOpsEngineInstanceForNamespace returns the database row of the engine instance in the given namespace, or
+// nil if the row does not exist. Unlike GetOrCreateGitopsEngineInstanceByInstanceNamespaceUID, the row is not created.
+func getExistingGitOpsEngineInstanceForNamespace(ctx context.Context, namespace corev1.Namespace, dbq db.DatabaseQueries) (*db.GitopsEngineInstance, error) {
+
+	dbResourceMapping := db.KubernetesToDBResourceMapping{
+		KubernetesResourceType: db.K8sToDBMapping_Namespace,
+		KubernetesResourceUID:  string(namespace.UID),
+		DBRelationType:         db.K8sToDBMapping_GitopsEngineInstance,
+	}
+	if err := dbq.GetDBResourceMappingForKubernetesResource(ctx, &dbResourceMapping); err != nil {
+		if db.IsResultNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	gitopsEngineInstance := db.GitopsEngineInstance{Gitopsengineinstance_id: dbResourceMapping.DBRelationKey}
+	if err := dbq.GetGitopsEngineInstanceById(ctx, &gitopsEngineInstance); err != nil {
+		if db.IsResultNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &gitopsEngineInstance, nil
+}
+
+// getOrCreateGitOpsEngineInstanceForConfig returns the database row of the given engine instance, creating it (and
+// the corresponding GitopsEngineCluster) if it does not exist.
+//
+// The bool return value is 'true' if GitOpsEngineInstance is created; 'false' if it already exists in DB or in case of failure.
+func getOrCreateGitOpsEngineInstanceForConfig(ctx context.Context, instanceConfig GitOpsEngineInstanceConfig,
+	namespaces gitopsEngineInstanceNamespaces, dbq db.DatabaseQueries, l logr.Logger) (*db.GitopsEngineInstance, bool, *db.GitopsEngineCluster, error) {
+
+	gitopsEngineInstance, isNewInstance, gitopsEngineCluster, err := dbutil.GetOrCreateGitopsEngineInstanceByInstanceNamespaceUID(ctx,
+		namespaces.namespace, string(namespaces.kubeSystemNamespace.UID), dbq, l)
+	if err != nil {
+		return nil, false, nil, err
+	}
+
+	if instanceConfig.ClusterSecret != nil {
+		// Ensure the ClusterCredentials of the engine cluster reference the kubeconfig Secret, so that clients for the
+		// engine cluster can be created from the GitopsEngineCluster row (see GetK8sClientForGitOpsEngineInstance).
+		clusterCreds := db.ClusterCredentials{Clustercredentials_cred_id: gitopsEngineCluster.Clustercredentials_id}
+		if err := dbq.GetClusterCredentialsById(ctx, &clusterCreds); err != nil {
+			return nil, false, nil, err
+		}
+
+		if clusterCreds.Secret_ref_namespace != instanceConfig.ClusterSecret.Namespace ||
+			clusterCreds.Secret_ref_name != instanceConfig.ClusterSecret.Name ||
+			clusterCreds.Kube_config_context != instanceConfig.ClusterSecret.Context {
+
+			clusterCreds.Secret_ref_namespace = instanceConfig.ClusterSecret.Namespace
+			clusterCreds.Secret_ref_name = instanceConfig.ClusterSecret.Name
+			clusterCreds.Kube_config_context = instanceConfig.ClusterSecret.Context
+			clusterCreds.Serviceaccount_bearer_token = ""
+
+			if err := dbq.UpdateClusterCredentials(ctx, &clusterCreds); err != nil {
+				l.Error(err, "Unable to update ClusterCredentials of GitopsEngineCluster", clusterCreds.GetAsLogKeyValues()...)
+				return nil, false, nil, err
+			}
+			l.Info("Updated ClusterCredentials of GitopsEngineCluster to reference kubeconfig Secret", clusterCreds.GetAsLogKeyValues()...)
+		}
+	}
+
+	return gitopsEngineInstance, isNewInstance, gitopsEngineCluster, nil
+}
+
+// placeUserOnGitOpsEngineInstance determines the GitOpsEngineInstance of a user, using the placement configuration.
+func placeUserOnGitOpsEngineInstance(ctx context.Context, placementConfig GitOpsEnginePlacementConfig, user db.ClusterUser,
+	workspaceNamespace corev1.Namespace, k8sClient client.Client, dbq db.DatabaseQueries, l logr.Logger) (*db.GitopsEngineInstance, bool, *db.GitopsEngineCluster, gitopserrors.ConditionError) {
+
+	// If the user has already been placed on an instance, continue to use it.
+	existingInstance, existingCluster, err := getExistingGitOpsEngineInstanceOfUser(ctx, user, dbq)
+	if err != nil {
+		devError := fmt.Errorf("unable to retrieve existing engine instance of user: %w", err)
+		return nil, false, nil, gitopserrors.NewUserConditionError(gitopserrors.UnknownError, devError, string(managedgitopsv1alpha1.ConditionReasonDatabaseError))
+	}
+	if existingInstance != nil {
+		return existingInstance, false, existingCluster, nil
+	}
+
+	k8sClientFactory := DefaultK8sClientFactory{}
+
+	// The namespaces of each candidate are retrieved at most once: retrieving them requires the cluster of the instance.
+	engineInstanceNamespaces := map[string]*gitopsEngineInstanceNamespaces{}
+
+	getNamespaces := func(instanceConfig GitOpsEngineInstanceConfig) (*gitopsEngineInstanceNamespaces, error) {
+		if res, exists := engineInstanceNamespaces[instanceConfig.Name]; exists {
+			return res, nil
+		}
+		res, err := getGitOpsEngineInstanceNamespaces(ctx, instanceConfig, k8sClient, k8sClientFactory)
+		if err != nil {
+			return nil, err
+		}
+		engineInstanceNamespaces[instanceConfig.Name] = res
+		return res, nil
+	}
+
+	// The Applications of each candidate are counted from its existing GitopsEngineInstance row: rows are only created
+	// for the instance the user is placed on. An instance without a row does not yet host any Applications.
+	selectedConfig, err := selectEngineInstanceConfig(placementConfig, workspaceNamespace, func(instanceConfig GitOpsEngineInstanceConfig) (int, error) {
+		namespaces, err := getNamespaces(instanceConfig)
+		if err != nil {
+			return 0, err
+		}
+		existingInstance, err := getExistingGitOpsEngineInstanceForNamespace(ctx, namespaces.namespace, dbq)
+		if err != nil || existingInstance == nil {
+			return 0, err
+		}
+		return dbq.CountApplicationsForGitopsEngineInstance(ctx, existingInstance.Gitopsengineinstance_id)
+	}, l)
+	if err != nil {
+		devError := fmt.Errorf("unable to place user on a GitOps engine instance: %w", err)
+		return nil, false, nil, gitopserrors.NewUserConditionError(gitopserrors.UnknownError, devError, string(managedgitopsv1alpha1.ConditionReasonUnknownError))
+	}
+
+	namespaces, err := getNamespaces(*selectedConfig)
+	if err != nil {
+		devError := fmt.Errorf("unable to reach engine instance '%s' for new application: %w", selectedConfig.Name, err)
+		return nil, false, nil, gitopserrors.NewUserConditionError(gitopserrors.UnknownError, devError, string(managedgitopsv1alpha1.ConditionReasonUnknownError))
+	}
+
+	instance, isNewInstance, cluster, err := getOrCreateGitOpsEngineInstanceForConfig(ctx, *selectedConfig, *namespaces, dbq, l)
+	if err != nil {
+		devError := fmt.Errorf("unable to get or create engine instance '%s' for new application: %w", selectedConfig.Name, err)
+		return nil, false, nil, gitopserrors.NewUserConditionError(gitopserrors.UnknownError, devError, string(managedgitopsv1alpha1.ConditionReasonDatabaseError))
+	}
+
+	l.Info("Placed user on GitOps engine instance", "engineInstanceName", selectedConfig.Name,
+		"gitopsEngineInstanceID", instance.Gitopsengineinstance_id)
+
+	return instance, isNewInstance, cluster, nil
+}
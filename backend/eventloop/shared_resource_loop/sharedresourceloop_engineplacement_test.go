@@ -0,0 +1,133 @@
+package shared_resource_loop
+
+import (
+	"fmt"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("GitOps engine instance placement tests", func() {
+
+	const placementConfigYAML = `
+engineInstances:
+- name: local
+  namespace: gitops-service-argocd
+  maxApplications: 10
+- name: remote-eu
+  namespace: gitops-service-argocd
+  clusterSecret:
+    name: remote-eu-kubeconfig
+    namespace: gitops
+  namespaceSelector:
+    matchLabels:
+      region: eu
+- name: remote-us
+  namespace: gitops-service-argocd
+  clusterSecret:
+    name: remote-us-kubeconfig
+    namespace: gitops
+  maxApplications: 5
+tenantPinning:
+  pinned-tenant: remote-us
+`
+
+	newNamespace := func(name string, labels map[string]string) corev1.Namespace {
+		return corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+	}
+
+	countFromMap := func(counts map[string]int) func(GitOpsEngineInstanceConfig) (int, error) {
+		return func(instanceConfig GitOpsEngineInstanceConfig) (int, error) {
+			return counts[instanceConfig.Name], nil
+		}
+	}
+
+	Context("Testing ParseGitOpsEnginePlacementConfig", func() {
+
+		It("should parse a valid placement configuration", func() {
+			config, err := ParseGitOpsEnginePlacementConfig([]byte(placementConfigYAML))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config.EngineInstances).To(HaveLen(3))
+			Expect(config.EngineInstances[1].ClusterSecret.Name).To(Equal("remote-eu-kubeconfig"))
+			Expect(config.TenantPinning).To(HaveKeyWithValue("pinned-tenant", "remote-us"))
+		})
+
+		DescribeTable("should reject an invalid placement configuration",
+			func(contents string) {
+				_, err := ParseGitOpsEnginePlacementConfig([]byte(contents))
+				Expect(err).To(HaveOccurred())
+			},
+			Entry("no engine instances", "engineInstances: []"),
+			Entry("missing namespace", "engineInstances:\n- name: a"),
+			Entry("duplicate names", "engineInstances:\n- name: a\n  namespace: x\n- name: a\n  namespace: y"),
+			Entry("incomplete clusterSecret", "engineInstances:\n- name: a\n  namespace: x\n  clusterSecret:\n    name: s"),
+			Entry("negative maxApplications", "engineInstances:\n- name: a\n  namespace: x\n  maxApplications: -1"),
+			Entry("pinned to unknown instance", "engineInstances:\n- name: a\n  namespace: x\ntenantPinning:\n  t: b"),
+			Entry("unknown field", "engineInstances:\n- name: a\n  namespace: x\n  unknown: true"),
+		)
+	})
+
+	Context("Testing selectEngineInstanceConfig", func() {
+
+		var config *GitOpsEnginePlacementConfig
+
+		BeforeEach(func() {
+			var err error
+			config, err = ParseGitOpsEnginePlacementConfig([]byte(placementConfigYAML))
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should place a pinned tenant on its instance, regardless of capacity", func() {
+			selected, err := selectEngineInstanceConfig(*config, newNamespace("pinned-tenant", nil),
+				countFromMap(map[string]int{"remote-us": 100}), logr.Discard())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(selected.Name).To(Equal("remote-us"))
+		})
+
+		It("should only consider instances whose namespaceSelector matches the namespace", func() {
+			selected, err := selectEngineInstanceConfig(*config, newNamespace("tenant", map[string]string{"region": "eu"}),
+				countFromMap(map[string]int{"local": 5, "remote-eu": 6, "remote-us": 3}), logr.Discard())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(selected.Name).To(Equal("remote-us"))
+
+			selected, err = selectEngineInstanceConfig(*config, newNamespace("tenant", map[string]string{"region": "us"}),
+				countFromMap(map[string]int{"local": 5, "remote-eu": 0, "remote-us": 3}), logr.Discard())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(selected.Name).To(Equal("remote-us"), "remote-eu should not be selected, as its namespaceSelector does not match")
+		})
+
+		It("should place the user on the least loaded instance that has capacity", func() {
+			selected, err := selectEngineInstanceConfig(*config, newNamespace("tenant", nil),
+				countFromMap(map[string]int{"local": 4, "remote-us": 5}), logr.Discard())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(selected.Name).To(Equal("local"), "remote-us has fewer Applications, but is at capacity")
+		})
+
+		It("should return an error if no instance has capacity", func() {
+			_, err := selectEngineInstanceConfig(*config, newNamespace("tenant", nil),
+				countFromMap(map[string]int{"local": 10, "remote-us": 5}), logr.Discard())
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should skip an instance whose Applications cannot be counted, such as an unreachable instance", func() {
+			selected, err := selectEngineInstanceConfig(*config, newNamespace("tenant", nil),
+				func(instanceConfig GitOpsEngineInstanceConfig) (int, error) {
+					if instanceConfig.Name == "local" {
+						return 0, fmt.Errorf("simulated error")
+					}
+					return 4, nil
+				}, logr.Discard())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(selected.Name).To(Equal("remote-us"))
+		})
+
+		It("should return an error if the Applications of no instance can be counted", func() {
+			_, err := selectEngineInstanceConfig(*config, newNamespace("tenant", nil),
+				func(GitOpsEngineInstanceConfig) (int, error) { return 0, fmt.Errorf("simulated error") }, logr.Discard())
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -23,13 +23,12 @@ import (
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
-	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	controllerLog "sigs.k8s.io/controller-runtime/pkg/log"
 )
 
 const (
-	KubeconfigKey                 = "kubeconfig"
+	KubeconfigKey                 = sharedutil.ManagedEnvironmentSecretKubeconfigKey
 	UnableToCreateRestConfigError = "unable to create k8s client from restConfig from managed environment secret"
 )
 
@@ -212,17 +211,34 @@ func internalProcessMessage_internalReconcileSharedManagedEnv(ctx context.Contex
 	}
 
 	// We found the managed env, now verify that the ManagedEnv's .spec values match the corresponding fields in the ClusterCredentials row
+	// - Likewise, if the ClusterCredentials reference a Secret, it should be the Secret referenced by the ManagedEnv (and vice versa)
 	if clusterCreds.Host != managedEnvironmentCR.Spec.APIURL ||
 		clusterCreds.AllowInsecureSkipTLSVerify != managedEnvironmentCR.Spec.AllowInsecureSkipTLSVerify ||
 		clusterCreds.ClusterResources != managedEnvironmentCR.Spec.ClusterResources ||
-		clusterCreds.Namespaces != managedEnvNamespaceSliceList {
+		clusterCreds.Namespaces != managedEnvNamespaceSliceList ||
+		clusterCreds.HasSecretRef() != shouldReferenceManagedEnvironmentSecret(managedEnvironmentCR) ||
+		(clusterCreds.HasSecretRef() && (clusterCreds.Secret_ref_namespace != secretCR.Namespace || clusterCreds.Secret_ref_name != secretCR.Name)) {
 		// C) If at least one of the fields in the managed env CR has changed, then replace the cluster credentials of the managed environment
 		return replaceExistingManagedEnv(ctx, gitopsEngineClient, workspaceClient, *clusterUser, isNewUser, managedEnvironmentCR, secretCR, *managedEnv,
 			workspaceNamespace, k8sClientFactory, dbQueries, log)
 	}
 
+	// If the cluster credentials reference the Secret (rather than containing a copy of the token), then read the token
+	// from the Secret, so that we can verify it below.
+	verifiableClusterCreds := *clusterCreds
+	if clusterCreds.HasSecretRef() {
+		token, err := sharedutil.ExtractBearerTokenFromKubeconfig(secretCR.Data[KubeconfigKey], clusterCreds.Host)
+		if err != nil {
+			log.Info("was unable to extract token from the Secret referenced by cluster credentials, so acquiring new ones.", "clusterCreds", clusterCreds.Clustercredentials_cred_id)
+			// The Secret no longer contains a valid token: reacquire, which will report the problem in the ManagedEnv status.
+			return replaceExistingManagedEnv(ctx, gitopsEngineClient, workspaceClient, *clusterUser, isNewUser, managedEnvironmentCR, secretCR, *managedEnv,
+				workspaceNamespace, k8sClientFactory, dbQueries, log)
+		}
+		verifiableClusterCreds.Serviceaccount_bearer_token = token
+	}
+
 	// Verify that we are able to connect to the cluster using the service account token we stored
-	validClusterCreds, err := verifyClusterCredentialsWithNamespaceList(ctx, *clusterCreds, managedEnvironmentCR, k8sClientFactory)
+	validClusterCreds, err := verifyClusterCredentialsWithNamespaceList(ctx, verifiableClusterCreds, managedEnvironmentCR, k8sClientFactory)
 	if !validClusterCreds || err != nil {
 		log.Info("was unable to connect using provided cluster credentials, so acquiring new ones.", "clusterCreds", clusterCreds.Clustercredentials_cred_id)
 		// D) If the cluster credentials appear to no longer be valid (we're no longer able to connect), then reacquire using the
@@ -231,6 +247,22 @@ func internalProcessMessage_internalReconcileSharedManagedEnv(ctx context.Contex
 			workspaceNamespace, k8sClientFactory, dbQueries, log)
 	}
 
+	// If the referenced Secret has changed (for example, the token was rotated), and the new token is valid, then record the
+	// resourceVersion of the Secret that we validated.
+	// - The cluster-agent reads the token from the Secret when generating the Argo CD cluster secret, so no other changes are required.
+	if clusterCreds.HasSecretRef() && clusterCreds.Secret_ref_resource_version != secretCR.ResourceVersion {
+		clusterCreds.Secret_ref_resource_version = secretCR.ResourceVersion
+
+		if err := dbQueries.UpdateClusterCredentials(ctx, clusterCreds); err != nil {
+			log.Error(err, "Unable to update resource version of Secret referenced by ClusterCredentials", clusterCreds.GetAsLogKeyValues()...)
+
+			return newSharedResourceManagedEnvContainer(),
+				createGenericDatabaseErrorEnvInitCondition(managedEnvironmentCR), userError_false,
+				fmt.Errorf("unable to update cluster credentials '%s': %w", clusterCreds.Clustercredentials_cred_id, err)
+		}
+		log.Info("Updated resource version of Secret referenced by ClusterCredentials", clusterCreds.GetAsLogKeyValues()...)
+	}
+
 	// The API url hasn't changed, the existing service account still works, so no more work needed.
 
 	// E) We already have an existing managed env from the database, so get or create the remaining items for it
@@ -571,7 +603,7 @@ func wrapManagedEnv(ctx context.Context, managedEnv db.ManagedEnvironment, works
 	bool, *db.ClusterAccess, bool, *db.GitopsEngineCluster, gitopserrors.ConditionError) {
 
 	engineInstance, isNewInstance, gitopsEngineCluster, err :=
-		internalDetermineGitOpsEngineInstance(ctx, clusterUser, gitopsEngineClient, dbQueries, log)
+		internalDetermineGitOpsEngineInstance(ctx, clusterUser, workspaceNamespace, gitopsEngineClient, dbQueries, log)
 
 	if err != nil {
 		log.Error(err.DevError(), "unable to determine gitops engine instance")
@@ -873,7 +905,7 @@ func createNewClusterCredentials(ctx context.Context, managedEnvironment managed
 
 	}
 
-	matchingContextName, matchingContext, err := locateContextThatMatchesAPIURL(config, managedEnvironment.Spec.APIURL)
+	matchingContextName, matchingContext, err := sharedutil.LocateContextThatMatchesAPIURL(config, managedEnvironment.Spec.APIURL)
 	if err != nil {
 		return db.ClusterCredentials{},
 			convertErrToEnvInitCondition(managedgitopsv1alpha1.ConditionReasonUnableToLocateContext, err, managedEnvironment),
@@ -1002,6 +1034,16 @@ func createNewClusterCredentials(ctx context.Context, managedEnvironment managed
 		}
 	}
 
+	// If enabled, store a reference to the Secret, rather than a copy of the token it contains.
+	// - This is only possible when the token is from the Secret: when we created the ServiceAccount ourselves, there
+	//   is no Secret containing its token, so the token must be stored.
+	if shouldReferenceManagedEnvironmentSecret(managedEnvironment) {
+		clusterCredentials.Serviceaccount_bearer_token = ""
+		clusterCredentials.Secret_ref_namespace = secret.Namespace
+		clusterCredentials.Secret_ref_name = secret.Name
+		clusterCredentials.Secret_ref_resource_version = secret.ResourceVersion
+	}
+
 	if err := dbQueries.CreateClusterCredentials(ctx, &clusterCredentials); err != nil {
 		log.Error(err, "Unable to create ClusterCredentials for ManagedEnvironment", clusterCredentials.GetAsLogKeyValues()...)
 
@@ -1019,42 +1061,10 @@ func createNewClusterCredentials(ctx context.Context, managedEnvironment managed
 
 }
 
-// locateContextThatMatchesAPIURL examines a kubeconfig (Config struct), and looks for the context that
-// matches the cluster with the given API URL.
-// See 'sharedresourceloop_managedend_test.go' for an example of a kubeconfig.
-func locateContextThatMatchesAPIURL(config *clientcmdapi.Config, apiURL string) (string, clientcmdapi.Context, error) {
-	var matchingClusterName string
-
-	// Look for the cluster with the given API URL
-	for clusterName := range config.Clusters {
-		cluster := config.Clusters[clusterName]
-		if strings.EqualFold(cluster.Server, apiURL) {
-			matchingClusterName = clusterName
-			// matchingCluster = cluster
-			break
-		}
-	}
-	if matchingClusterName == "" {
-		return "", clientcmdapi.Context{}, fmt.Errorf("the kubeconfig did not have a cluster entry that matched the API URL '%s'", apiURL)
-	}
-
-	// Look for the context that matches the cluster above
-	var matchingContextName string
-	var matchingContext *clientcmdapi.Context
-	for contextName := range config.Contexts {
-		context := config.Contexts[contextName]
-		if context.Cluster == matchingClusterName {
-			matchingContextName = contextName
-			matchingContext = context
-		}
-	}
-	if matchingContextName == "" {
-		return "", clientcmdapi.Context{}, fmt.Errorf("the kubeconfig did not have a context that matched "+
-			"the cluster specified in the API URL of the GitOpsDeploymentManagedEnvironment. Context "+
-			"was expected to reference cluster '%s'", matchingClusterName)
-	}
-
-	return matchingContextName, *matchingContext, nil
+// shouldReferenceManagedEnvironmentSecret returns true if the ClusterCredentials of the managed environment should reference
+// the managed environment Secret, rather than contain a copy of the token from the Secret.
+func shouldReferenceManagedEnvironmentSecret(managedEnvironment managedgitopsv1alpha1.GitOpsDeploymentManagedEnvironment) bool {
+	return sharedutil.CredentialsSecretReferenceEnabled() && !managedEnvironment.Spec.CreateNewServiceAccount
 }
 
 func isCertificateSignedByUnknownAuthority(err error) bool {
@@ -40,6 +40,7 @@ func internalProcessMessage_ReconcileRepositoryCredential(ctx context.Context,
 	repositoryCredentialCRNamespace corev1.Namespace,
 	validateRepoURLFunction ValidateRepoURLAndCredentialsFunction,
 	apiNamespaceClient client.Client,
+	k8sClientFactory SRLK8sClientFactory,
 	dbQueries db.DatabaseQueries, shouldWait bool, l logr.Logger) (*db.RepositoryCredentials, error) {
 
 	resourceNS := repositoryCredentialCRNamespace.Name
@@ -50,12 +51,22 @@ func internalProcessMessage_ReconcileRepositoryCredential(ctx context.Context,
 			repositoryCredentialCRName, string(repositoryCredentialCRNamespace.UID), err)
 	}
 
-	gitopsEngineInstance, _, _, uerr := internalDetermineGitOpsEngineInstance(ctx, *clusterUser, apiNamespaceClient, dbQueries, l)
+	gitopsEngineInstance, _, _, uerr := internalDetermineGitOpsEngineInstance(ctx, *clusterUser, repositoryCredentialCRNamespace, apiNamespaceClient, dbQueries, l)
 	if uerr != nil {
 		return nil, fmt.Errorf("unable to retrieve cluster user while processing GitOpsRepositoryCredentials: '%s' in namespace: '%s': Error: %w",
 			repositoryCredentialCRName, string(repositoryCredentialCRNamespace.UID), uerr.DevError())
 	}
 
+	// The Operations of the RepositoryCredential are created on the cluster of the GitOps engine instance, which is not
+	// necessarily the same cluster as the API namespace.
+	getOperationClient := func() (client.Client, error) {
+		operationClient, err := k8sClientFactory.GetK8sClientForGitOpsEngineInstance(ctx, gitopsEngineInstance)
+		if err != nil {
+			return nil, fmt.Errorf("unable to retrieve client for GitOpsEngineInstance '%s': %w", gitopsEngineInstance.Gitopsengineinstance_id, err)
+		}
+		return operationClient, nil
+	}
+
 	// Note: this may be nil in some if-else branches
 	gitopsDeploymentRepositoryCredentialCR := &managedgitopsv1alpha1.GitOpsDeploymentRepositoryCredential{}
 
@@ -141,13 +152,17 @@ func internalProcessMessage_ReconcileRepositoryCredential(ctx context.Context,
 
 				// We need to fire-up an Operation as well
 				l.Info("Creating an Operation for the deleted RepositoryCredential DB row", "RepositoryCredential ID", repositoryCredentialPrimaryKey)
+				operationClient, err := getOperationClient()
+				if err != nil {
+					return nil, err
+				}
 				if operationDBID, err = createRepoCredOperation(ctx, dbRepoCred, *clusterUser, gitopsEngineInstance.Namespace_name, dbQueries,
-					apiNamespaceClient, shouldWait, l); err != nil {
+					operationClient, shouldWait, l); err != nil {
 
 					l.Error(err, "Error creating an Operation for the deleted RepositoryCredential DB row", "RepositoryCredential ID", repositoryCredentialPrimaryKey)
 					return nil, err
 				}
-				if err := CleanRepoCredOperation(ctx, dbRepoCred, *clusterUser, gitopsEngineInstance.Namespace_name, dbQueries, apiNamespaceClient, operationDBID, l); err != nil {
+				if err := CleanRepoCredOperation(ctx, dbRepoCred, *clusterUser, gitopsEngineInstance.Namespace_name, dbQueries, operationClient, operationDBID, l); err != nil {
 					l.Error(err, "Error cleaning up the Operation for the deleted RepositoryCredential DB row", "RepositoryCredential ID", repositoryCredentialPrimaryKey)
 					return nil, err
 				}
@@ -242,6 +257,14 @@ func internalProcessMessage_ReconcileRepositoryCredential(ctx context.Context,
 			EngineClusterID: gitopsEngineInstance.Gitopsengineinstance_id, // comply with the constraint 'fk_gitopsengineinstance_id',
 		}
 
+		// If enabled, store a reference to the Secret, rather than a copy of the credentials it contains.
+		if sharedutil.CredentialsSecretReferenceEnabled() {
+			dbRepoCred.AuthUsername, dbRepoCred.AuthPassword, dbRepoCred.AuthSSHKey = "", "", ""
+			dbRepoCred.SecretRefNamespace = secret.Namespace
+			dbRepoCred.SecretRefName = secret.Name
+			dbRepoCred.SecretRefResourceVersion = secret.ResourceVersion
+		}
+
 		if err := dbQueries.CreateRepositoryCredentials(ctx, &dbRepoCred); err != nil {
 			l.Error(err, "Error creating RepositoryCredential row in DB", "DebugErr", errCreateDBRepoCred, "CR Name", repositoryCredentialCRName, "Namespace", resourceNS)
 			return nil, fmt.Errorf("unable to create repository credential in the database: %v", err)
@@ -282,11 +305,15 @@ func internalProcessMessage_ReconcileRepositoryCredential(ctx context.Context,
 
 		l.Info(fmt.Sprintf("Created a ApiCRToDBMapping: (APIResourceType: %s, APIResourceUID: %s, DBRelationType: %s)", newApiCRToDBMapping.APIResourceType, newApiCRToDBMapping.APIResourceUID, newApiCRToDBMapping.DBRelationType))
 
-		operationDBID, err := createRepoCredOperation(ctx, dbRepoCred, *clusterUser, gitopsEngineInstance.Namespace_name, dbQueries, apiNamespaceClient, shouldWait, l)
+		operationClient, err := getOperationClient()
+		if err != nil {
+			return nil, err
+		}
+		operationDBID, err := createRepoCredOperation(ctx, dbRepoCred, *clusterUser, gitopsEngineInstance.Namespace_name, dbQueries, operationClient, shouldWait, l)
 		if err != nil {
 			return nil, err
 		}
-		if err := CleanRepoCredOperation(ctx, dbRepoCred, *clusterUser, gitopsEngineInstance.Namespace_name, dbQueries, apiNamespaceClient, operationDBID, l); err != nil {
+		if err := CleanRepoCredOperation(ctx, dbRepoCred, *clusterUser, gitopsEngineInstance.Namespace_name, dbQueries, operationClient, operationDBID, l); err != nil {
 			l.Error(err, "unable to clean up operation", "Operation ID", operationDBID)
 			return nil, err
 		}
@@ -347,11 +374,16 @@ func internalProcessMessage_ReconcileRepositoryCredential(ctx context.Context,
 				return nil, err
 			}
 
-			if operationDBID, err = createRepoCredOperation(ctx, dbRepoCred, *clusterUser, resourceNS, dbQueries, apiNamespaceClient, shouldWait, l); err != nil {
+			operationClient, err := getOperationClient()
+			if err != nil {
+				return nil, err
+			}
+
+			if operationDBID, err = createRepoCredOperation(ctx, dbRepoCred, *clusterUser, gitopsEngineInstance.Namespace_name, dbQueries, operationClient, shouldWait, l); err != nil {
 				return nil, err
 			}
 
-			if err := CleanRepoCredOperation(ctx, dbRepoCred, *clusterUser, resourceNS, dbQueries, apiNamespaceClient, operationDBID, l); err != nil {
+			if err := CleanRepoCredOperation(ctx, dbRepoCred, *clusterUser, gitopsEngineInstance.Namespace_name, dbQueries, operationClient, operationDBID, l); err != nil {
 				return nil, err
 			}
 		}
@@ -421,7 +453,7 @@ func CleanRepoCredOperation(ctx context.Context, dbRepoCred db.RepositoryCredent
 }
 
 func createRepoCredOperation(ctx context.Context, dbRepoCred db.RepositoryCredentials, clusterUser db.ClusterUser, operationNS string,
-	dbQueries db.DatabaseQueries, apiNamespaceClient client.Client, shouldWait bool, l logr.Logger) (string, error) {
+	dbQueries db.DatabaseQueries, operationClient client.Client, shouldWait bool, l logr.Logger) (string, error) {
 
 	dbOperationInput := db.Operation{
 		Instance_id:             dbRepoCred.EngineClusterID,
@@ -432,7 +464,7 @@ func createRepoCredOperation(ctx context.Context, dbRepoCred db.RepositoryCreden
 	}
 
 	operationCR, operationDB, err := operations.CreateOperation(ctx, shouldWait, dbOperationInput, clusterUser.Clusteruser_id, operationNS, dbQueries,
-		apiNamespaceClient, l)
+		operationClient, l)
 	if err != nil {
 		errV := fmt.Errorf("unable to create operation: %v", err)
 		return "", errV
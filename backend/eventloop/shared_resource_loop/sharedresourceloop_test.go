@@ -397,7 +397,7 @@ var _ = Describe("SharedResourceEventLoop Test", func() {
 			repositoryCredentialCRNamespace.Name = gitopsEngineInstance.Namespace_name
 			repositoryCredentialCRNamespace.UID = types.UID(gitopsEngineInstance.Namespace_uid)
 
-			dbRepoCred, err := internalProcessMessage_ReconcileRepositoryCredential(ctx, cr.Name, repositoryCredentialCRNamespace, mock_returnValidRepositoryCredentials, k8sClient, dbq, false, l)
+			dbRepoCred, err := internalProcessMessage_ReconcileRepositoryCredential(ctx, cr.Name, repositoryCredentialCRNamespace, mock_returnValidRepositoryCredentials, k8sClient, MockSRLK8sClientFactory{fakeClient: k8sClient}, dbq, false, l)
 
 			// Negative test (there is no Secret)
 			Expect(err).To(HaveOccurred())
@@ -420,7 +420,7 @@ var _ = Describe("SharedResourceEventLoop Test", func() {
 
 			// Create again the CR
 			// Expected: Since there's no DB entry for the CR, it will create an operation
-			dbRepoCred, err = internalProcessMessage_ReconcileRepositoryCredential(ctx, cr.Name, repositoryCredentialCRNamespace, mock_returnValidRepositoryCredentials, k8sClient, dbq, false, l)
+			dbRepoCred, err = internalProcessMessage_ReconcileRepositoryCredential(ctx, cr.Name, repositoryCredentialCRNamespace, mock_returnValidRepositoryCredentials, k8sClient, MockSRLK8sClientFactory{fakeClient: k8sClient}, dbq, false, l)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(dbRepoCred).NotTo(BeNil())
 
@@ -498,7 +498,7 @@ var _ = Describe("SharedResourceEventLoop Test", func() {
 
 			// Re-running should not error
 			fmt.Println("Re-running the internalProcessMessage_ReconcileRepositoryCredential()")
-			dbRepoCred, err = internalProcessMessage_ReconcileRepositoryCredential(ctx, cr.Name, repositoryCredentialCRNamespace, mock_returnValidRepositoryCredentials, k8sClient, dbq, false, l)
+			dbRepoCred, err = internalProcessMessage_ReconcileRepositoryCredential(ctx, cr.Name, repositoryCredentialCRNamespace, mock_returnValidRepositoryCredentials, k8sClient, MockSRLK8sClientFactory{fakeClient: k8sClient}, dbq, false, l)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(dbRepoCred).NotTo(BeNil())
 
@@ -522,7 +522,7 @@ var _ = Describe("SharedResourceEventLoop Test", func() {
 			err = dbq.UpdateRepositoryCredentials(ctx, dbRepoCred)
 			Expect(err).ToNot(HaveOccurred())
 
-			dbRepoCred, err = internalProcessMessage_ReconcileRepositoryCredential(ctx, cr.Name, repositoryCredentialCRNamespace, mock_returnValidRepositoryCredentials, k8sClient, dbq, false, l)
+			dbRepoCred, err = internalProcessMessage_ReconcileRepositoryCredential(ctx, cr.Name, repositoryCredentialCRNamespace, mock_returnValidRepositoryCredentials, k8sClient, MockSRLK8sClientFactory{fakeClient: k8sClient}, dbq, false, l)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(dbRepoCred).ToNot(BeNil())
 
@@ -564,7 +564,7 @@ var _ = Describe("SharedResourceEventLoop Test", func() {
 			Expect(err).To(HaveOccurred()) // err unexpected number of rows affected:
 			// Expect(err).ToNot(HaveOccurred())
 
-			dbRepoCred, err = internalProcessMessage_ReconcileRepositoryCredential(ctx, cr.Name, repositoryCredentialCRNamespace, mock_returnValidRepositoryCredentials, k8sClient, dbq, false, l)
+			dbRepoCred, err = internalProcessMessage_ReconcileRepositoryCredential(ctx, cr.Name, repositoryCredentialCRNamespace, mock_returnValidRepositoryCredentials, k8sClient, MockSRLK8sClientFactory{fakeClient: k8sClient}, dbq, false, l)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(dbRepoCred).ToNot(BeNil())
 
@@ -591,7 +591,7 @@ var _ = Describe("SharedResourceEventLoop Test", func() {
 			// Expected: Since there is no GitOpsDeploymentRepositoryCredential CR, it will delete the DB entry
 			err = k8sClient.Delete(ctx, cr)
 			Expect(err).ToNot(HaveOccurred())
-			dbRepoCred, err = internalProcessMessage_ReconcileRepositoryCredential(ctx, cr.Name, repositoryCredentialCRNamespace, mock_returnValidRepositoryCredentials, k8sClient, dbq, false, l)
+			dbRepoCred, err = internalProcessMessage_ReconcileRepositoryCredential(ctx, cr.Name, repositoryCredentialCRNamespace, mock_returnValidRepositoryCredentials, k8sClient, MockSRLK8sClientFactory{fakeClient: k8sClient}, dbq, false, l)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(dbRepoCred).To(BeNil())
 
@@ -614,7 +614,7 @@ var _ = Describe("SharedResourceEventLoop Test", func() {
 
 			// Negative test: Try again to reconcile the RepositoryCredential
 			// Expected: It should not error (both db row and CR should be deleted). Nothing we can do.
-			dbRepoCred, err = internalProcessMessage_ReconcileRepositoryCredential(ctx, cr.Name, repositoryCredentialCRNamespace, mock_returnValidRepositoryCredentials, k8sClient, dbq, false, l)
+			dbRepoCred, err = internalProcessMessage_ReconcileRepositoryCredential(ctx, cr.Name, repositoryCredentialCRNamespace, mock_returnValidRepositoryCredentials, k8sClient, MockSRLK8sClientFactory{fakeClient: k8sClient}, dbq, false, l)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(dbRepoCred).To(BeNil())
 
@@ -755,7 +755,7 @@ var _ = Describe("SharedResourceEventLoop Test", func() {
 			err = k8sClient.Create(ctx, secret)
 			Expect(err).ToNot(HaveOccurred())
 
-			dbRepoCred, err := internalProcessMessage_ReconcileRepositoryCredential(ctx, cr.Name, repositoryCredentialCRNamespace, mock_returnValidRepositoryCredentials, k8sClient, dbq, false, l)
+			dbRepoCred, err := internalProcessMessage_ReconcileRepositoryCredential(ctx, cr.Name, repositoryCredentialCRNamespace, mock_returnValidRepositoryCredentials, k8sClient, MockSRLK8sClientFactory{fakeClient: k8sClient}, dbq, false, l)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(dbRepoCred).NotTo(BeNil())
 
@@ -878,7 +878,7 @@ var _ = Describe("SharedResourceEventLoop Test", func() {
 			err = k8sClient.Create(ctx, secret)
 			Expect(err).ToNot(HaveOccurred())
 
-			dbRepoCred, err := internalProcessMessage_ReconcileRepositoryCredential(ctx, cr.Name, repositoryCredentialCRNamespace, mock_returnValidRepositoryCredentials, k8sClient, dbq, false, l)
+			dbRepoCred, err := internalProcessMessage_ReconcileRepositoryCredential(ctx, cr.Name, repositoryCredentialCRNamespace, mock_returnValidRepositoryCredentials, k8sClient, MockSRLK8sClientFactory{fakeClient: k8sClient}, dbq, false, l)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(dbRepoCred).NotTo(BeNil())
 
@@ -906,7 +906,7 @@ var _ = Describe("SharedResourceEventLoop Test", func() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(appProjectRepositoryDB).NotTo(BeNil())
 
-			dbRepoCred, err = internalProcessMessage_ReconcileRepositoryCredential(ctx, cr.Name, repositoryCredentialCRNamespace, mock_returnValidRepositoryCredentials, k8sClient, dbq, false, l)
+			dbRepoCred, err = internalProcessMessage_ReconcileRepositoryCredential(ctx, cr.Name, repositoryCredentialCRNamespace, mock_returnValidRepositoryCredentials, k8sClient, MockSRLK8sClientFactory{fakeClient: k8sClient}, dbq, false, l)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(dbRepoCred).NotTo(BeNil())
 			Expect(dbRepoCred.PrivateURL).To(Equal("http://github.com/jgwest/my-repo"))
@@ -1001,7 +1001,7 @@ var _ = Describe("SharedResourceEventLoop Test", func() {
 			repositoryCredentialCRNamespace.Name = gitopsEngineInstance.Namespace_name
 			repositoryCredentialCRNamespace.UID = types.UID(gitopsEngineInstance.Namespace_uid)
 
-			dbRepoCred, err := internalProcessMessage_ReconcileRepositoryCredential(ctx, cr.Name, repositoryCredentialCRNamespace, mock_returnValidRepositoryCredentials, k8sClient, dbq, false, l)
+			dbRepoCred, err := internalProcessMessage_ReconcileRepositoryCredential(ctx, cr.Name, repositoryCredentialCRNamespace, mock_returnValidRepositoryCredentials, k8sClient, MockSRLK8sClientFactory{fakeClient: k8sClient}, dbq, false, l)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(dbRepoCred).ToNot(BeNil())
 
@@ -1123,6 +1123,41 @@ var _ = Describe("SharedResourceEventLoop Test", func() {
 			})
 		})
 
+		When("a GitOpsDeployment has multiple sources, which are not in the database", func() {
+
+			It("should create a new AppProjectRepository in the database for each source", func() {
+
+				valuesRepoURL := "http://github.com/test-my-fake-org/my-fake-values-repo"
+
+				By("creating a GitOpsDeployment referencing two git repos")
+
+				gitopsDepl := managedgitopsv1alpha1.GitOpsDeployment{
+					ObjectMeta: metav1.ObjectMeta{Name: "my-gitops-depl", Namespace: namespace.Name},
+					Spec: managedgitopsv1alpha1.GitOpsDeploymentSpec{
+						Sources: managedgitopsv1alpha1.ApplicationSources{
+							{RepoURL: gitRepoURL, Path: "chart"},
+							{RepoURL: valuesRepoURL, Ref: "values"},
+						},
+					},
+				}
+				Expect(k8sClient.Create(ctx, &gitopsDepl)).Error().ToNot(HaveOccurred())
+
+				By("calling the function being tested")
+				dbUpdated, err := reconcileAppProjectRepositories(ctx, namespace, k8sClient, dbq, l)
+				Expect(dbUpdated).To(BeTrue())
+				Expect(err).ToNot(HaveOccurred())
+
+				By("verifying an AppProjectRepository exists in the database for each source")
+				res := []db.AppProjectRepository{}
+				Expect(dbq.ListAppProjectRepositoryByClusterUserId(ctx, clusterUser.Clusteruser_id, &res)).Error().ToNot(HaveOccurred())
+
+				Expect(res).To(HaveLen(2))
+
+				repoURLs := []string{res[0].RepoURL, res[1].RepoURL}
+				Expect(repoURLs).To(ConsistOf(gitRepoURL, valuesRepoURL))
+			})
+		})
+
 		When("AppProject exists in GitOpsDeploymentRepositoryCredential, but not in database", func() {
 
 			It("should create a new AppProjectRepository in the database", func() {
@@ -17,6 +17,7 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"os"
 	"strings"
@@ -32,7 +33,9 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 
 	managedgitopsv1alpha1 "github.com/redhat-appstudio/managed-gitops/backend-shared/apis/managed-gitops/v1alpha1"
@@ -43,6 +46,7 @@ import (
 	"github.com/redhat-appstudio/managed-gitops/backend/eventloop"
 	"github.com/redhat-appstudio/managed-gitops/backend/eventloop/preprocess_event_loop"
 	"github.com/redhat-appstudio/managed-gitops/backend/eventloop/shared_resource_loop"
+	"github.com/redhat-appstudio/managed-gitops/backend/sharding"
 	crzap "sigs.k8s.io/controller-runtime/pkg/log/zap"
 	//+kubebuilder:scaffold:imports
 )
@@ -111,6 +115,13 @@ func main() {
 		return
 	}
 
+	if sharding.ShardingEnabled() && enableLeaderElection {
+		// When sharding is enabled, the controllers must run on every replica: only the singleton jobs are leader-elected.
+		// Instead, the ShardManager fences the ownership of each namespace, so that it is only processed by one replica.
+		setupLog.Info("backend sharding is enabled: controller manager leader election is disabled")
+		enableLeaderElection = false
+	}
+
 	mgr, err := ctrl.NewManager(restConfig, ctrl.Options{
 		Scheme:                 scheme,
 		MetricsBindAddress:     metricsAddr,
@@ -124,6 +135,12 @@ func main() {
 		os.Exit(1)
 	}
 
+	// The API reader is used, rather than the cached client, so that only the key Secret is read (and not watched).
+	if err := db.ConfigureEncryptionKeyProviderFromSecretEnv(mgr.GetAPIReader()); err != nil {
+		setupLog.Error(err, "unable to configure database encryption key provider")
+		os.Exit(1)
+	}
+
 	preprocessEventLoop := preprocess_event_loop.NewPreprocessEventLoop()
 
 	if err = (&managedgitopscontrollers.GitOpsDeploymentReconciler{
@@ -158,6 +175,17 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "GitOpsDeploymentManagedEnvironment")
 		os.Exit(1)
 	}
+	// GitOpsDeploymentSets that are requeued after a backend shard rebalance
+	deploymentSetRequeueEvents := make(chan event.GenericEvent)
+
+	if err = (&managedgitopscontrollers.GitOpsDeploymentSetReconciler{
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		RequeueEvents: deploymentSetRequeueEvents,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "GitOpsDeploymentSet")
+		os.Exit(1)
+	}
 
 	// If the webhook is not disabled, start listening on the webhook URL
 	if !strings.EqualFold(os.Getenv("DISABLE_APPSTUDIO_WEBHOOK"), "true") {
@@ -185,11 +213,20 @@ func main() {
 
 	//+kubebuilder:scaffold:builder
 
-	startDBReconciler(mgr)
-	startRepoCredReconciler(mgr)
-	startDBMetricsReconciler(mgr)
+	singletonStartFuncs := []func(){
+		func() { startDBReconciler(mgr) },
+		func() { startRepoCredReconciler(mgr) },
+		func() { startDBMetricsReconciler(mgr) },
+		func() { startClusterReconciler(mgr) },
+	}
 
-	startClusterReconciler(mgr)
+	if sharding.ShardingEnabled() {
+		setupSharding(ctx, mgr, restConfig, preprocessEventLoop, deploymentSetRequeueEvents, singletonStartFuncs)
+	} else {
+		for _, startFunc := range singletonStartFuncs {
+			startFunc()
+		}
+	}
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
@@ -213,6 +250,63 @@ func main() {
 
 }
 
+// setupSharding configures this replica to process only the namespaces that are assigned to it, and to start the
+// singleton jobs only if it is elected leader.
+func setupSharding(ctx context.Context, mgr ctrl.Manager, restConfig *rest.Config,
+	preprocessEventLoop *preprocess_event_loop.PreprocessEventLoop, deploymentSetRequeueEvents chan<- event.GenericEvent,
+	singletonStartFuncs []func()) {
+
+	identity, err := sharding.ReplicaIdentity()
+	if err != nil {
+		setupLog.Error(err, "unable to determine backend replica identity")
+		os.Exit(1)
+	}
+
+	namespace, err := sharding.ReplicaNamespace()
+	if err != nil {
+		setupLog.Error(err, "unable to determine backend namespace")
+		os.Exit(1)
+	}
+
+	setupLog.Info("backend sharding is enabled", "identity", identity, "namespace", namespace)
+
+	shardManager := sharding.NewShardManager(mgr.GetClient(), mgr.GetAPIReader(), namespace, identity)
+
+	// When the set of replicas changes, reconcile the resources of the namespaces that this replica has gained.
+	shardManager.OnRebalance(func(previous *sharding.HashRing, current *sharding.HashRing) {
+		go func() {
+			gainedNamespace := func(namespaceUID string) bool {
+				return current.Owner(namespaceUID) == identity &&
+					(previous == nil || previous.Owner(namespaceUID) != identity)
+			}
+
+			if err := managedgitopscontrollers.RequeueResourcesInNamespaces(ctx, mgr.GetClient(), preprocessEventLoop,
+				deploymentSetRequeueEvents, gainedNamespace); err != nil {
+				setupLog.Error(err, "unable to requeue resources after backend shard rebalance")
+			}
+		}()
+	})
+
+	if err := mgr.Add(shardManager); err != nil {
+		setupLog.Error(err, "unable to add shard manager")
+		os.Exit(1)
+	}
+
+	sharding.SetNamespaceOwnership(shardManager)
+
+	go func() {
+		err := sharding.RunSingletonsWithLeaderElection(ctx, restConfig, namespace, identity, func() {
+			setupLog.Error(nil, "lost leadership of singleton jobs, exiting")
+			os.Exit(1)
+		}, singletonStartFuncs...)
+
+		if err != nil {
+			setupLog.Error(err, "unable to run leader election for singleton jobs")
+			os.Exit(1)
+		}
+	}()
+}
+
 func startDBReconciler(mgr ctrl.Manager) {
 
 	dbQueries, err := db.NewSharedProductionPostgresDBQueries(false)
@@ -0,0 +1,118 @@
+package sharding
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+)
+
+// defaultVirtualNodesPerMember is the number of points that each member occupies on the hash ring: more points
+// lead to a more even distribution of keys between members.
+const defaultVirtualNodesPerMember = 128
+
+// HashRing is a consistent hash ring: each key is owned by exactly one member, and when a member is added to, or
+// removed from, the ring, only the keys owned by that member move to/from other members.
+//
+// A HashRing is immutable, and thus safe to share between goroutines.
+type HashRing struct {
+	// members is the sorted list of members of the ring
+	members []string
+
+	// points is the sorted list of positions on the ring, and pointOwners is the member that owns each point
+	points      []uint32
+	pointOwners map[uint32]string
+}
+
+// NewHashRing returns a hash ring containing the given members. The order of members does not matter: rings
+// created from the same set of members are always identical.
+func NewHashRing(members []string) *HashRing {
+
+	res := &HashRing{
+		members:     uniqueSortedMembers(members),
+		points:      []uint32{},
+		pointOwners: map[uint32]string{},
+	}
+
+	for _, member := range res.members {
+		for i := 0; i < defaultVirtualNodesPerMember; i++ {
+			point := hashKey(member + "#" + strconv.Itoa(i))
+
+			// On the (unlikely) collision of two points, the member that sorts first wins, to keep the ring deterministic
+			if _, exists := res.pointOwners[point]; exists {
+				continue
+			}
+			res.pointOwners[point] = member
+			res.points = append(res.points, point)
+		}
+	}
+
+	sort.Slice(res.points, func(i, j int) bool { return res.points[i] < res.points[j] })
+
+	return res
+}
+
+// Owner returns the member that owns the given key, or "" if the ring has no members.
+func (ring *HashRing) Owner(key string) string {
+
+	if ring == nil || len(ring.points) == 0 {
+		return ""
+	}
+
+	hash := hashKey(key)
+
+	// Find the first point on the ring that is >= the hash of the key, wrapping around to the first point if needed.
+	idx := sort.Search(len(ring.points), func(i int) bool { return ring.points[i] >= hash })
+	if idx == len(ring.points) {
+		idx = 0
+	}
+
+	return ring.pointOwners[ring.points[idx]]
+}
+
+// Members returns the sorted list of members of the ring.
+func (ring *HashRing) Members() []string {
+	if ring == nil {
+		return nil
+	}
+	return append([]string{}, ring.members...)
+}
+
+// HasSameMembers returns true if both rings contain the same members (and thus assign keys identically), false otherwise.
+func (ring *HashRing) HasSameMembers(other *HashRing) bool {
+
+	if ring == nil || other == nil {
+		return ring == other
+	}
+
+	if len(ring.members) != len(other.members) {
+		return false
+	}
+	for idx := range ring.members {
+		if ring.members[idx] != other.members[idx] {
+			return false
+		}
+	}
+	return true
+}
+
+func hashKey(key string) uint32 {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(key))
+	return hash.Sum32()
+}
+
+func uniqueSortedMembers(members []string) []string {
+
+	seen := map[string]bool{}
+	res := []string{}
+	for _, member := range members {
+		if member == "" || seen[member] {
+			continue
+		}
+		seen[member] = true
+		res = append(res, member)
+	}
+	sort.Strings(res)
+
+	return res
+}
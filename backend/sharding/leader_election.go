@@ -0,0 +1,92 @@
+package sharding
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	logutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util/log"
+)
+
+const (
+	// SingletonLeaderElectionLeaseName is the name of the Lease that is used to elect the backend replica which runs
+	// the singleton jobs (for example, the database reconciler).
+	SingletonLeaderElectionLeaseName = "gitops-backend-singletons"
+
+	singletonLeaseDuration = 30 * time.Second
+	singletonRenewDeadline = 20 * time.Second
+	singletonRetryPeriod   = 5 * time.Second
+)
+
+// RunSingletonsWithLeaderElection blocks until this replica is elected leader (or the context is cancelled), and then
+// calls each of the startFuncs once. The singleton jobs do not support being stopped, so if leadership is later
+// lost, onLeadershipLost is called (which would usually exit the process, so that the jobs are not run by two
+// replicas at once).
+//
+// This function should be called from its own goroutine.
+func RunSingletonsWithLeaderElection(ctx context.Context, restConfig *rest.Config, namespace string, identity string,
+	onLeadershipLost func(), startFuncs ...func()) error {
+
+	log := log.FromContext(ctx).WithName(logutil.LogLogger_managed_gitops).
+		WithValues("leaseName", SingletonLeaderElectionLeaseName, "leaseNamespace", namespace, "identity", identity)
+
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return fmt.Errorf("unable to create clientset for leader election: %w", err)
+	}
+
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      SingletonLeaderElectionLeaseName,
+			Namespace: namespace,
+		},
+		Client: clientset.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: identity,
+		},
+	}
+
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		LeaseDuration:   singletonLeaseDuration,
+		RenewDeadline:   singletonRenewDeadline,
+		RetryPeriod:     singletonRetryPeriod,
+		ReleaseOnCancel: true,
+		Name:            SingletonLeaderElectionLeaseName,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				log.Info("Elected as leader: starting singleton jobs")
+				for _, startFunc := range startFuncs {
+					startFunc()
+				}
+			},
+			OnStoppedLeading: func() {
+				if ctx.Err() != nil {
+					// Leadership was released due to shutdown
+					return
+				}
+				log.Error(nil, "Lost leadership of singleton jobs")
+				onLeadershipLost()
+			},
+			OnNewLeader: func(currentLeader string) {
+				if currentLeader != identity {
+					log.Info("Singleton jobs are running on another replica", "leader", currentLeader)
+				}
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("unable to create leader elector: %w", err)
+	}
+
+	elector.Run(ctx)
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package sharding
+
+import "sync"
+
+// NamespaceOwnership determines whether this backend replica is responsible for processing the events of a namespace.
+type NamespaceOwnership interface {
+	IsOwner(namespaceUID string) bool
+
+	// StartWork returns true if this replica is responsible for the namespace, in which case the returned function
+	// must be called once the work is complete.
+	StartWork(namespaceUID string) (func(), bool)
+}
+
+// AllNamespacesOwnership is used when sharding is disabled: this replica is responsible for every namespace.
+type AllNamespacesOwnership struct{}
+
+func (AllNamespacesOwnership) IsOwner(namespaceUID string) bool {
+	return true
+}
+
+func (AllNamespacesOwnership) StartWork(namespaceUID string) (func(), bool) {
+	return func() {}, true
+}
+
+var (
+	// namespaceOwnershipMutex should be owned before reading/writing namespaceOwnership
+	namespaceOwnershipMutex sync.RWMutex
+	namespaceOwnership      NamespaceOwnership = AllNamespacesOwnership{}
+)
+
+// SetNamespaceOwnership sets the NamespaceOwnership that is used by IsNamespaceOwnedByThisReplica. If nil, this replica
+// is responsible for every namespace.
+func SetNamespaceOwnership(ownership NamespaceOwnership) {
+	namespaceOwnershipMutex.Lock()
+	defer namespaceOwnershipMutex.Unlock()
+
+	if ownership == nil {
+		ownership = AllNamespacesOwnership{}
+	}
+	namespaceOwnership = ownership
+}
+
+// IsNamespaceOwnedByThisReplica returns true if this backend replica is responsible for processing the events of
+// the namespace with the given UID, false otherwise.
+func IsNamespaceOwnedByThisReplica(namespaceUID string) bool {
+	namespaceOwnershipMutex.RLock()
+	defer namespaceOwnershipMutex.RUnlock()
+
+	return namespaceOwnership.IsOwner(namespaceUID)
+}
+
+// StartNamespaceWork returns true if this backend replica is responsible for processing the events of the namespace
+// with the given UID, in which case the returned function must be called once the work is complete: the namespace is
+// not handed off to another replica while work is in progress.
+func StartNamespaceWork(namespaceUID string) (func(), bool) {
+	namespaceOwnershipMutex.RLock()
+	defer namespaceOwnershipMutex.RUnlock()
+
+	return namespaceOwnership.StartWork(namespaceUID)
+}
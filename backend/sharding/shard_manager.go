@@ -0,0 +1,461 @@
+package sharding
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	logutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util/log"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// Sharding of namespaces between backend replicas
+//
+// When sharding is enabled, each backend replica is only responsible for processing the events of a subset of the
+// namespaces that contain GitOps Service API resources:
+// - Each replica maintains a Lease (the 'membership Lease') in the backend namespace, which it renews periodically.
+// - Each replica periodically lists the membership Leases, to determine which replicas are alive.
+// - The namespaces are assigned to the live replicas using a consistent hash ring (keyed by namespace UID), so that
+//   when a replica is added or removed, only the namespaces of that replica move to/from other replicas.
+// - When the set of live replicas changes, namespaces are handed off between replicas (see below), and each replica is
+//   then informed (via the rebalance callbacks) so that it may reconcile the namespaces it has gained.
+//
+// As the controllers run on every replica (controller manager leader election is disabled when sharding is enabled),
+// ownership of a namespace is fenced, so that two replicas never process the events of a namespace at the same time:
+// - Handoff: a replica stops owning the namespaces it has lost as soon as it observes the new set of live replicas.
+//   Once its in-progress work on those namespaces has drained, it acknowledges the new set of live replicas on its
+//   Lease (the ShardMembersAnnotation). A replica only begins owning the namespaces it has gained once every live
+//   replica has acknowledged the new set of live replicas: at that point, the hand off is 'settled'.
+// - Self-fencing: a replica which has not renewed its Lease within (lease duration - renew interval) owns no
+//   namespaces, as the other replicas may be about to consider it dead and take over its namespaces.
+
+const (
+	// EnableShardingEnv is the environment variable that enables sharding of namespaces between backend replicas.
+	EnableShardingEnv = "ENABLE_BACKEND_SHARDING"
+
+	// PodNameEnv and PodNamespaceEnv are set (via the downward API) to the name and namespace of the backend Pod.
+	PodNameEnv      = "POD_NAME"
+	PodNamespaceEnv = "POD_NAMESPACE"
+
+	// ShardMemberLabel is the label that is applied to the membership Lease of each backend replica.
+	ShardMemberLabel = "managed-gitops.redhat.com/backend-shard-member"
+
+	// ShardMembersAnnotation is the annotation of the membership Lease of each backend replica, which acknowledges the
+	// set of live replicas (comma-separated, sorted) that the replica has handed off its namespaces for.
+	ShardMembersAnnotation = "managed-gitops.redhat.com/backend-shard-members"
+
+	shardLeaseNamePrefix = "gitops-backend-shard-"
+
+	defaultShardLeaseDuration = 30 * time.Second
+	defaultShardRenewInterval = 10 * time.Second
+
+	// handoffRetryInterval is how often the Leases are renewed/listed while a hand off is not yet settled.
+	handoffRetryInterval = 2 * time.Second
+
+	serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+)
+
+// ShardingEnabled returns true if sharding of namespaces between backend replicas is enabled, false otherwise.
+func ShardingEnabled() bool {
+	return strings.EqualFold(os.Getenv(EnableShardingEnv), "true")
+}
+
+// ReplicaIdentity returns a name that uniquely identifies this backend replica: the name of the Pod, if available,
+// otherwise the hostname.
+func ReplicaIdentity() (string, error) {
+
+	if podName := os.Getenv(PodNameEnv); podName != "" {
+		return podName, nil
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "", fmt.Errorf("unable to determine replica identity: %w", err)
+	}
+	return hostname, nil
+}
+
+// ReplicaNamespace returns the namespace that the backend is running in, which is where Leases are created.
+func ReplicaNamespace() (string, error) {
+
+	if podNamespace := os.Getenv(PodNamespaceEnv); podNamespace != "" {
+		return podNamespace, nil
+	}
+
+	contents, err := os.ReadFile(serviceAccountNamespaceFile)
+	if err != nil {
+		return "", fmt.Errorf("unable to determine the namespace of the backend, set the %s environment variable: %w", PodNamespaceEnv, err)
+	}
+
+	return strings.TrimSpace(string(contents)), nil
+}
+
+// RebalanceFunc is called whenever a hand off of namespaces between backend replicas is settled. 'previous' is nil on
+// the first call.
+type RebalanceFunc func(previous *HashRing, current *HashRing)
+
+// ShardManager maintains the membership Lease of this backend replica, and determines which namespaces this replica
+// is responsible for.
+type ShardManager struct {
+	// k8sClient is used to create/update/delete this replica's Lease
+	k8sClient client.Client
+
+	// apiReader is used to read the Leases of all replicas. An uncached reader is used, so that an informer on
+	// Leases is not required.
+	apiReader client.Reader
+
+	namespace string
+	identity  string
+
+	leaseDuration time.Duration
+	renewInterval time.Duration
+
+	// mutex should be owned before reading/writing any of the fields below
+	mutex sync.RWMutex
+
+	// ring is the hash ring of the live replicas, as most recently observed
+	ring *HashRing
+
+	// settledRing is the most recent hash ring that all live replicas have acknowledged
+	settledRing *HashRing
+
+	// inProgressWork is the number of units of work in progress, by namespace UID (see StartWork)
+	inProgressWork map[string]int
+
+	// lastRenewTime is the time of the most recent successful renewal of this replica's Lease
+	lastRenewTime time.Time
+
+	rebalanceFuncs []RebalanceFunc
+}
+
+var _ manager.Runnable = &ShardManager{}
+var _ manager.LeaderElectionRunnable = &ShardManager{}
+var _ NamespaceOwnership = &ShardManager{}
+
+func NewShardManager(k8sClient client.Client, apiReader client.Reader, namespace string, identity string) *ShardManager {
+	return &ShardManager{
+		k8sClient:      k8sClient,
+		apiReader:      apiReader,
+		namespace:      namespace,
+		identity:       identity,
+		leaseDuration:  defaultShardLeaseDuration,
+		renewInterval:  defaultShardRenewInterval,
+		inProgressWork: map[string]int{},
+	}
+}
+
+// OnRebalance registers a function to be called whenever a hand off of namespaces between backend replicas is settled.
+// Functions are called synchronously from the ShardManager's goroutine, so should return quickly.
+func (sm *ShardManager) OnRebalance(fn RebalanceFunc) {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	sm.rebalanceFuncs = append(sm.rebalanceFuncs, fn)
+}
+
+// IsOwner returns true if this replica is responsible for the namespace with the given UID. Before the first hand off
+// is settled, no namespaces are owned: the rebalance callbacks are called once it is settled.
+func (sm *ShardManager) IsOwner(namespaceUID string) bool {
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+
+	return sm.isOwnerLocked(namespaceUID, time.Now())
+}
+
+// StartWork returns true if this replica is responsible for the namespace with the given UID, in which case the
+// returned function must be called once the work is complete: the namespace is not handed off to another replica
+// while work is in progress.
+func (sm *ShardManager) StartWork(namespaceUID string) (func(), bool) {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	if !sm.isOwnerLocked(namespaceUID, time.Now()) {
+		return nil, false
+	}
+
+	sm.inProgressWork[namespaceUID]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			sm.mutex.Lock()
+			defer sm.mutex.Unlock()
+
+			sm.inProgressWork[namespaceUID]--
+			if sm.inProgressWork[namespaceUID] <= 0 {
+				delete(sm.inProgressWork, namespaceUID)
+			}
+		})
+	}, true
+}
+
+// isOwnerLocked returns true if the namespace is owned by this replica in both the observed and the settled hash
+// rings, and this replica has not fenced itself. The mutex must be owned by the caller.
+func (sm *ShardManager) isOwnerLocked(namespaceUID string, now time.Time) bool {
+
+	if sm.lastRenewTime.IsZero() || now.Sub(sm.lastRenewTime) > sm.leaseDuration-sm.renewInterval {
+		return false
+	}
+
+	return sm.ring.Owner(namespaceUID) == sm.identity && sm.settledRing.Owner(namespaceUID) == sm.identity
+}
+
+// isDrainedLocked returns true if there is no work in progress on namespaces which this replica has lost in the
+// observed hash ring. The mutex must be owned by the caller.
+func (sm *ShardManager) isDrainedLocked() bool {
+
+	for namespaceUID := range sm.inProgressWork {
+		if sm.ring.Owner(namespaceUID) != sm.identity {
+			return false
+		}
+	}
+	return true
+}
+
+// isSettled returns true if all the live replicas have acknowledged the observed hash ring.
+func (sm *ShardManager) isSettled() bool {
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+
+	return sm.ring != nil && sm.ring.HasSameMembers(sm.settledRing)
+}
+
+// Identity returns the identity of this replica, as used on the hash ring.
+func (sm *ShardManager) Identity() string {
+	return sm.identity
+}
+
+// NeedLeaderElection returns false: the ShardManager must run on every replica.
+func (sm *ShardManager) NeedLeaderElection() bool {
+	return false
+}
+
+// Start renews this replica's Lease, and updates the set of live replicas, every renewInterval (or more often while a
+// hand off is not yet settled), until the context is cancelled.
+func (sm *ShardManager) Start(ctx context.Context) error {
+
+	log := log.FromContext(ctx).WithName(logutil.LogLogger_managed_gitops).
+		WithValues("shardIdentity", sm.identity, "shardNamespace", sm.namespace)
+
+	log.Info("ShardManager started")
+
+	for {
+		if err := sm.renewLease(ctx); err != nil {
+			log.Error(err, "unable to renew shard membership Lease")
+		}
+
+		if err := sm.updateMembership(ctx, log); err != nil {
+			log.Error(err, "unable to update shard membership")
+		}
+
+		wait := sm.renewInterval
+		if !sm.isSettled() {
+			wait = handoffRetryInterval
+		}
+
+		select {
+		case <-ctx.Done():
+			// Delete our Lease on shutdown, so that the other replicas are able to take over our namespaces
+			// without waiting for the Lease to expire.
+			if err := sm.deleteLease(context.Background()); err != nil {
+				log.Error(err, "unable to delete shard membership Lease on shutdown")
+			}
+			log.Info("ShardManager stopped")
+			return nil
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (sm *ShardManager) leaseName() string {
+	return shardLeaseNamePrefix + sm.identity
+}
+
+// renewLease creates this replica's Lease if it doesn't exist, otherwise updates its renew time. Once the work on the
+// namespaces that this replica has lost has drained, the observed hash ring is acknowledged.
+func (sm *ShardManager) renewLease(ctx context.Context) error {
+
+	renewTime := time.Now()
+	now := metav1.NewMicroTime(renewTime)
+	leaseDurationSeconds := int32(sm.leaseDuration.Seconds())
+
+	sm.mutex.RLock()
+	acknowledgedMembers := ""
+	if sm.ring != nil && sm.isDrainedLocked() {
+		acknowledgedMembers = membersKey(sm.ring)
+	}
+	sm.mutex.RUnlock()
+
+	lease := &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      sm.leaseName(),
+			Namespace: sm.namespace,
+		},
+	}
+
+	if err := sm.apiReader.Get(ctx, client.ObjectKeyFromObject(lease), lease); err != nil {
+		if !apierr.IsNotFound(err) {
+			return err
+		}
+
+		lease.Labels = map[string]string{ShardMemberLabel: "true"}
+		lease.Annotations = map[string]string{ShardMembersAnnotation: acknowledgedMembers}
+		lease.Spec = coordinationv1.LeaseSpec{
+			HolderIdentity:       &sm.identity,
+			LeaseDurationSeconds: &leaseDurationSeconds,
+			AcquireTime:          &now,
+			RenewTime:            &now,
+		}
+		if err := sm.k8sClient.Create(ctx, lease); err != nil {
+			return err
+		}
+		sm.setLastRenewTime(renewTime)
+		return nil
+	}
+
+	if lease.Labels == nil {
+		lease.Labels = map[string]string{}
+	}
+	lease.Labels[ShardMemberLabel] = "true"
+	if acknowledgedMembers != "" {
+		if lease.Annotations == nil {
+			lease.Annotations = map[string]string{}
+		}
+		lease.Annotations[ShardMembersAnnotation] = acknowledgedMembers
+	}
+	lease.Spec.HolderIdentity = &sm.identity
+	lease.Spec.LeaseDurationSeconds = &leaseDurationSeconds
+	lease.Spec.RenewTime = &now
+
+	if err := sm.k8sClient.Update(ctx, lease); err != nil {
+		return err
+	}
+	sm.setLastRenewTime(renewTime)
+	return nil
+}
+
+// setLastRenewTime records the time of the latest successful renewal of this replica's Lease: the time before the
+// renewal was sent, as the other replicas may have observed the renewal at any time after that.
+func (sm *ShardManager) setLastRenewTime(renewTime time.Time) {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	sm.lastRenewTime = renewTime
+}
+
+func (sm *ShardManager) deleteLease(ctx context.Context) error {
+
+	lease := &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      sm.leaseName(),
+			Namespace: sm.namespace,
+		},
+	}
+
+	if err := sm.k8sClient.Delete(ctx, lease); err != nil && !apierr.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// updateMembership lists the membership Leases of all replicas, and updates the hash ring if the set of live replicas
+// has changed. Once all the live replicas have acknowledged the hash ring, the hand off is settled, and the rebalance
+// functions are called.
+func (sm *ShardManager) updateMembership(ctx context.Context, log logr.Logger) error {
+
+	var leaseList coordinationv1.LeaseList
+	if err := sm.apiReader.List(ctx, &leaseList, client.InNamespace(sm.namespace), client.MatchingLabels{ShardMemberLabel: "true"}); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	newRing := NewHashRing(liveMembers(leaseList.Items, sm.identity, now))
+
+	sm.mutex.Lock()
+	previousRing := sm.ring
+	if previousRing == nil || !previousRing.HasSameMembers(newRing) {
+		sm.ring = newRing
+		log.Info("Backend shard membership changed", "previousMembers", previousRing.Members(), "members", newRing.Members())
+	}
+
+	if sm.ring.HasSameMembers(sm.settledRing) || !allMembersAcknowledged(leaseList.Items, sm.ring, now) {
+		sm.mutex.Unlock()
+		return nil
+	}
+
+	previousSettledRing := sm.settledRing
+	sm.settledRing = sm.ring
+	settledRing := sm.settledRing
+	rebalanceFuncs := append([]RebalanceFunc{}, sm.rebalanceFuncs...)
+	sm.mutex.Unlock()
+
+	log.Info("Backend shard hand off settled", "previousMembers", previousSettledRing.Members(), "members", settledRing.Members())
+
+	for _, fn := range rebalanceFuncs {
+		fn(previousSettledRing, settledRing)
+	}
+
+	return nil
+}
+
+// membersKey returns the value of the ShardMembersAnnotation which acknowledges the given hash ring.
+func membersKey(ring *HashRing) string {
+	return strings.Join(ring.Members(), ",")
+}
+
+// allMembersAcknowledged returns true if every member of the hash ring has an unexpired Lease which acknowledges it.
+func allMembersAcknowledged(leases []coordinationv1.Lease, ring *HashRing, now time.Time) bool {
+
+	acknowledged := map[string]bool{}
+	for idx := range leases {
+		lease := leases[idx]
+
+		if isLeaseLive(lease, now) && lease.Annotations[ShardMembersAnnotation] == membersKey(ring) {
+			acknowledged[*lease.Spec.HolderIdentity] = true
+		}
+	}
+
+	for _, member := range ring.Members() {
+		if !acknowledged[member] {
+			return false
+		}
+	}
+	return true
+}
+
+// liveMembers returns the identities of the replicas whose Leases have not expired. The current replica is always
+// considered to be live.
+func liveMembers(leases []coordinationv1.Lease, currentIdentity string, now time.Time) []string {
+
+	res := []string{currentIdentity}
+
+	for idx := range leases {
+		lease := leases[idx]
+
+		if isLeaseLive(lease, now) {
+			res = append(res, *lease.Spec.HolderIdentity)
+		}
+	}
+
+	return res
+}
+
+// isLeaseLive returns true if the Lease has a holder, and has not expired.
+func isLeaseLive(lease coordinationv1.Lease, now time.Time) bool {
+
+	if lease.Spec.HolderIdentity == nil || lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return false
+	}
+
+	expiry := lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second)
+	return !now.After(expiry)
+}
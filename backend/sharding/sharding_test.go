@@ -0,0 +1,268 @@
+package sharding
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var _ = Describe("HashRing tests", func() {
+
+	keys := []string{}
+	for i := 0; i < 1000; i++ {
+		keys = append(keys, fmt.Sprintf("namespace-uid-%d", i))
+	}
+
+	It("should assign keys identically, regardless of the order of members", func() {
+		ring1 := NewHashRing([]string{"replica-a", "replica-b", "replica-c"})
+		ring2 := NewHashRing([]string{"replica-c", "replica-a", "replica-b", "replica-a"})
+
+		Expect(ring1.HasSameMembers(ring2)).To(BeTrue())
+		Expect(ring1.Members()).To(Equal([]string{"replica-a", "replica-b", "replica-c"}))
+
+		for _, key := range keys {
+			Expect(ring1.Owner(key)).To(Equal(ring2.Owner(key)))
+		}
+	})
+
+	It("should distribute keys between all members", func() {
+		ring := NewHashRing([]string{"replica-a", "replica-b", "replica-c"})
+
+		keysPerMember := map[string]int{}
+		for _, key := range keys {
+			keysPerMember[ring.Owner(key)]++
+		}
+
+		Expect(keysPerMember).To(HaveLen(3))
+		for _, count := range keysPerMember {
+			// An even distribution would be ~333 keys per member
+			Expect(count).To(BeNumerically(">", 200))
+		}
+	})
+
+	It("should only move the keys of a member, when a member is added or removed", func() {
+		ring := NewHashRing([]string{"replica-a", "replica-b", "replica-c"})
+
+		By("adding a member: keys should only move to the new member")
+		ringWithNewMember := NewHashRing([]string{"replica-a", "replica-b", "replica-c", "replica-d"})
+		for _, key := range keys {
+			if ring.Owner(key) != ringWithNewMember.Owner(key) {
+				Expect(ringWithNewMember.Owner(key)).To(Equal("replica-d"))
+			}
+		}
+
+		By("removing a member: only the keys of the removed member should move")
+		ringWithoutMember := NewHashRing([]string{"replica-a", "replica-c"})
+		for _, key := range keys {
+			if ring.Owner(key) != "replica-b" {
+				Expect(ringWithoutMember.Owner(key)).To(Equal(ring.Owner(key)))
+			}
+		}
+	})
+
+	It("should return no owner for an empty or nil ring", func() {
+		Expect(NewHashRing(nil).Owner("key")).To(Equal(""))
+
+		var nilRing *HashRing
+		Expect(nilRing.Owner("key")).To(Equal(""))
+		Expect(nilRing.HasSameMembers(NewHashRing(nil))).To(BeFalse())
+	})
+})
+
+var _ = Describe("ShardManager tests", func() {
+
+	const namespace = "gitops"
+
+	newLease := func(identity string, renewTime time.Time) *coordinationv1.Lease {
+		leaseDurationSeconds := int32(30)
+		microRenewTime := metav1.NewMicroTime(renewTime)
+		return &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      shardLeaseNamePrefix + identity,
+				Namespace: namespace,
+				Labels:    map[string]string{ShardMemberLabel: "true"},
+			},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       &identity,
+				LeaseDurationSeconds: &leaseDurationSeconds,
+				RenewTime:            &microRenewTime,
+			},
+		}
+	}
+
+	It("should consider only replicas with unexpired Leases to be live, and always include the current replica", func() {
+		now := time.Now()
+
+		leases := []coordinationv1.Lease{
+			*newLease("replica-a", now.Add(-10*time.Second)),
+			*newLease("replica-b", now.Add(-time.Minute)),
+			{},
+		}
+
+		Expect(liveMembers(leases, "replica-c", now)).To(ConsistOf("replica-a", "replica-c"))
+	})
+
+	It("should create its Lease, determine the live replicas, and call the rebalance functions only once the hand off is settled", func() {
+
+		ctx := context.Background()
+
+		scheme := runtime.NewScheme()
+		Expect(coordinationv1.AddToScheme(scheme)).To(Succeed())
+
+		otherLease := newLease("replica-a", time.Now())
+		otherLease.Annotations = map[string]string{ShardMembersAnnotation: "replica-a,replica-b"}
+
+		k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(otherLease).Build()
+
+		sm := NewShardManager(k8sClient, k8sClient, namespace, "replica-b")
+
+		rebalanceCalls := 0
+		var currentRing *HashRing
+		sm.OnRebalance(func(previous *HashRing, current *HashRing) {
+			rebalanceCalls++
+			currentRing = current
+		})
+
+		By("verifying no namespaces are owned before the live replicas are determined")
+		Expect(sm.IsOwner("namespace-uid")).To(BeFalse())
+
+		Expect(sm.renewLease(ctx)).To(Succeed())
+		Expect(sm.updateMembership(ctx, log.FromContext(ctx))).To(Succeed())
+
+		lease := &coordinationv1.Lease{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: shardLeaseNamePrefix + "replica-b"}, lease)).To(Succeed())
+		Expect(*lease.Spec.HolderIdentity).To(Equal("replica-b"))
+
+		By("verifying no namespaces are owned until this replica has acknowledged the live replicas")
+		Expect(rebalanceCalls).To(Equal(0))
+		Expect(sm.isSettled()).To(BeFalse())
+		Expect(sm.IsOwner("namespace-uid")).To(BeFalse())
+
+		Expect(sm.renewLease(ctx)).To(Succeed())
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(lease), lease)).To(Succeed())
+		Expect(lease.Annotations[ShardMembersAnnotation]).To(Equal("replica-a,replica-b"))
+
+		Expect(sm.updateMembership(ctx, log.FromContext(ctx))).To(Succeed())
+		Expect(sm.isSettled()).To(BeTrue())
+		Expect(rebalanceCalls).To(Equal(1))
+		Expect(currentRing.Members()).To(Equal([]string{"replica-a", "replica-b"}))
+		Expect(sm.IsOwner("namespace-uid")).To(Equal(currentRing.Owner("namespace-uid") == "replica-b"))
+
+		By("renewing again with no change in replicas, the rebalance functions should not be called")
+		Expect(sm.renewLease(ctx)).To(Succeed())
+		Expect(sm.updateMembership(ctx, log.FromContext(ctx))).To(Succeed())
+		Expect(rebalanceCalls).To(Equal(1))
+
+		By("deleting the Lease of the other replica, this replica should own every namespace once it has acknowledged")
+		Expect(k8sClient.Delete(ctx, otherLease)).To(Succeed())
+		Expect(sm.updateMembership(ctx, log.FromContext(ctx))).To(Succeed())
+		Expect(rebalanceCalls).To(Equal(1))
+
+		Expect(sm.renewLease(ctx)).To(Succeed())
+		Expect(sm.updateMembership(ctx, log.FromContext(ctx))).To(Succeed())
+		Expect(rebalanceCalls).To(Equal(2))
+		Expect(sm.IsOwner("namespace-uid")).To(BeTrue())
+
+		By("owning no namespaces once the Lease has not been renewed for too long")
+		sm.setLastRenewTime(time.Now().Add(-sm.leaseDuration))
+		Expect(sm.IsOwner("namespace-uid")).To(BeFalse())
+
+		By("deleting this replica's Lease on shutdown")
+		Expect(sm.deleteLease(ctx)).To(Succeed())
+		Expect(sm.deleteLease(ctx)).To(Succeed())
+	})
+
+	It("should not hand off a namespace until the work in progress on it is complete", func() {
+
+		ctx := context.Background()
+
+		scheme := runtime.NewScheme()
+		Expect(coordinationv1.AddToScheme(scheme)).To(Succeed())
+
+		k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+		sm := NewShardManager(k8sClient, k8sClient, namespace, "replica-a")
+
+		By("settling with this replica as the only live replica")
+		for i := 0; i < 2; i++ {
+			Expect(sm.renewLease(ctx)).To(Succeed())
+			Expect(sm.updateMembership(ctx, log.FromContext(ctx))).To(Succeed())
+		}
+		Expect(sm.isSettled()).To(BeTrue())
+
+		By("finding a namespace that moves to a new replica")
+		ringWithNewMember := NewHashRing([]string{"replica-a", "replica-b"})
+		namespaceUID := ""
+		for i := 0; namespaceUID == ""; i++ {
+			if key := fmt.Sprintf("namespace-uid-%d", i); ringWithNewMember.Owner(key) == "replica-b" {
+				namespaceUID = key
+			}
+		}
+
+		workComplete, owned := sm.StartWork(namespaceUID)
+		Expect(owned).To(BeTrue())
+
+		By("adding a new replica: the namespace should no longer be owned, but not yet acknowledged")
+		newReplicaLease := newLease("replica-b", time.Now())
+		newReplicaLease.Annotations = map[string]string{ShardMembersAnnotation: "replica-a,replica-b"}
+		Expect(k8sClient.Create(ctx, newReplicaLease)).To(Succeed())
+
+		Expect(sm.updateMembership(ctx, log.FromContext(ctx))).To(Succeed())
+		Expect(sm.IsOwner(namespaceUID)).To(BeFalse())
+
+		_, owned = sm.StartWork(namespaceUID)
+		Expect(owned).To(BeFalse())
+
+		Expect(sm.renewLease(ctx)).To(Succeed())
+		Expect(sm.updateMembership(ctx, log.FromContext(ctx))).To(Succeed())
+		Expect(sm.isSettled()).To(BeFalse())
+
+		lease := &coordinationv1.Lease{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: shardLeaseNamePrefix + "replica-a"}, lease)).To(Succeed())
+		Expect(lease.Annotations[ShardMembersAnnotation]).To(Equal("replica-a"))
+
+		By("completing the work: the new replicas should be acknowledged")
+		workComplete()
+		workComplete()
+
+		Expect(sm.renewLease(ctx)).To(Succeed())
+		Expect(sm.updateMembership(ctx, log.FromContext(ctx))).To(Succeed())
+		Expect(sm.isSettled()).To(BeTrue())
+
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(lease), lease)).To(Succeed())
+		Expect(lease.Annotations[ShardMembersAnnotation]).To(Equal("replica-a,replica-b"))
+	})
+})
+
+var _ = Describe("Namespace ownership tests", func() {
+
+	AfterEach(func() {
+		SetNamespaceOwnership(nil)
+	})
+
+	It("should own all namespaces by default, and use the configured NamespaceOwnership otherwise", func() {
+		Expect(IsNamespaceOwnedByThisReplica("namespace-uid")).To(BeTrue())
+
+		workComplete, owned := StartNamespaceWork("namespace-uid")
+		Expect(owned).To(BeTrue())
+		workComplete()
+
+		SetNamespaceOwnership(NewShardManager(nil, nil, "gitops", "replica-a"))
+		Expect(IsNamespaceOwnedByThisReplica("namespace-uid")).To(BeFalse())
+
+		_, owned = StartNamespaceWork("namespace-uid")
+		Expect(owned).To(BeFalse())
+
+		SetNamespaceOwnership(nil)
+		Expect(IsNamespaceOwnedByThisReplica("namespace-uid")).To(BeTrue())
+	})
+})
@@ -22,6 +22,7 @@ import (
 	"reflect"
 
 	"strings"
+	"sync"
 	"time"
 
 	apierr "k8s.io/apimachinery/pkg/api/errors"
@@ -31,10 +32,12 @@ import (
 	"github.com/redhat-appstudio/managed-gitops/backend-shared/db"
 	sharedutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util"
 	argosharedutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util/argocd"
+	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/fauxargocd"
 	logutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util/log"
 	"github.com/redhat-appstudio/managed-gitops/cluster-agent/controllers"
 	"github.com/redhat-appstudio/managed-gitops/cluster-agent/controllers/argoproj.io/application_info_cache"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -52,6 +55,87 @@ type ApplicationReconciler struct {
 	Cache *application_info_cache.ApplicationInfoCache
 
 	DB db.DatabaseQueries
+
+	// SecretRefClient is a client of the cluster that contains the Secrets referenced by cluster credentials (see
+	// controllers.NewSecretRefClient): it is used by the namespace reconciler.
+	SecretRefClient client.Client
+
+	// GetResourceTree, if non-nil, is used to retrieve the resource tree of the Argo CD Application, which is stored
+	// in the ApplicationState alongside the Application status. If nil, the resource tree is not stored.
+	GetResourceTree func(ctx context.Context, app appv1.Application) (*fauxargocd.ApplicationTree, error)
+
+	// resourceTrees caches the result of GetResourceTree for each Argo CD Application
+	resourceTrees resourceTreeCache
+}
+
+// resourceTreeCache caches the resource tree of each Argo CD Application, by the resourceVersion of the Application:
+// the resource tree is only retrieved again (via the Argo CD API) once the Application has changed.
+type resourceTreeCache struct {
+	mutex   sync.Mutex
+	entries map[types.NamespacedName]resourceTreeCacheEntry
+}
+
+type resourceTreeCacheEntry struct {
+	resourceVersion string
+	resourceTree    *fauxargocd.ApplicationTree
+}
+
+// getResourceTree returns the resource tree of the Argo CD Application, calling GetResourceTree only if the tree has
+// not already been retrieved for the current resourceVersion of the Application.
+func (r *ApplicationReconciler) getResourceTree(ctx context.Context, app appv1.Application) (*fauxargocd.ApplicationTree, error) {
+
+	key := types.NamespacedName{Namespace: app.Namespace, Name: app.Name}
+
+	if resourceTree, exists := r.resourceTrees.get(key, app.ResourceVersion); exists {
+		return resourceTree, nil
+	}
+
+	resourceTree, err := r.GetResourceTree(ctx, app)
+	if err != nil {
+		// Errors are not cached, so that the resource tree is retrieved again on the next reconcile
+		return nil, err
+	}
+
+	r.resourceTrees.set(key, app.ResourceVersion, resourceTree)
+
+	return resourceTree, nil
+}
+
+func (c *resourceTreeCache) get(key types.NamespacedName, resourceVersion string) (*fauxargocd.ApplicationTree, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry, exists := c.entries[key]
+	if !exists || entry.resourceVersion != resourceVersion {
+		return nil, false
+	}
+	return entry.resourceTree, true
+}
+
+func (c *resourceTreeCache) set(key types.NamespacedName, resourceVersion string, resourceTree *fauxargocd.ApplicationTree) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.entries == nil {
+		c.entries = map[types.NamespacedName]resourceTreeCacheEntry{}
+	}
+	c.entries[key] = resourceTreeCacheEntry{resourceVersion: resourceVersion, resourceTree: resourceTree}
+}
+
+func (c *resourceTreeCache) delete(key types.NamespacedName) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	delete(c.entries, key)
+}
+
+// applicationStatusWithResourceTree is the Argo CD Application status, as stored (compressed) in the ApplicationState
+// row of the Application, along with the resource tree of the Application (if available).
+// - This is read by the backend as a fauxargocd.FauxApplicationStatus.
+type applicationStatusWithResourceTree struct {
+	appv1.ApplicationStatus `yaml:",inline"`
+
+	ResourceTree *fauxargocd.ApplicationTree `yaml:"resourcetree,omitempty"`
 }
 
 //+kubebuilder:rbac:groups=argoproj.io,resources=applications,verbs=get;list;watch;create;update;patch;delete
@@ -77,6 +161,7 @@ func (r *ApplicationReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	if err := r.Get(ctx, req.NamespacedName, &app); err != nil {
 		if apierr.IsNotFound(err) {
 			log.Info("Application deleted")
+			r.resourceTrees.delete(req.NamespacedName)
 			return ctrl.Result{}, nil
 		} else {
 			log.Error(err, "Unexpected error on retrieving Application")
@@ -121,6 +206,13 @@ func (r *ApplicationReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 
 	log = log.WithValues(logutil.Log_ApplicationID, applicationDB.Application_id)
 
+	// An Application that is being migrated between GitOps engine instances has an Argo CD Application on both
+	// instances: only the status of the Argo CD Application on the instance that is hosting it should be reported.
+	if _, exists := app.Annotations[controllers.ArgoCDApplicationMigrationAnnotation]; exists {
+		log.V(logutil.LogLevel_Debug).Info("Skipping status of Argo CD Application that is being migrated")
+		return ctrl.Result{}, nil
+	}
+
 	var err error
 	app.Status.Sync.ComparedTo, err = convertComparedTo(app)
 	if err != nil {
@@ -128,6 +220,16 @@ func (r *ApplicationReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, err
 	}
 
+	appStatus := applicationStatusWithResourceTree{ApplicationStatus: app.Status}
+
+	if r.GetResourceTree != nil {
+		// If the resource tree is unavailable, the rest of the status is still stored: the backend keeps reporting
+		// the last known resource tree.
+		if appStatus.ResourceTree, err = r.getResourceTree(ctx, app); err != nil {
+			log.V(logutil.LogLevel_Warn).Info("Unable to retrieve resource tree of Application", "error", err.Error())
+		}
+	}
+
 	// 3) Does there exist an ApplicationState for this Application, already?
 	applicationState := &db.ApplicationState{
 		Applicationstate_application_id: applicationDB.Application_id,
@@ -137,7 +239,7 @@ func (r *ApplicationReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		if db.IsResultNotFoundError(errGet) {
 
 			// 3a) ApplicationState doesn't exist: so create it
-			appStatusBytes, err := sharedutil.CompressObject(app.Status)
+			appStatusBytes, err := sharedutil.CompressObject(appStatus)
 			if err != nil {
 				log.Error(err, "Failed to compress the Argo CD Application status", "name", app.Name, "namespace", app.Namespace)
 				return ctrl.Result{}, err
@@ -157,7 +259,7 @@ func (r *ApplicationReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	}
 
 	// 4) ApplicationState already exists, so just update it.
-	appStatusBytes, err := sharedutil.CompressObject(app.Status)
+	appStatusBytes, err := sharedutil.CompressObject(appStatus)
 	if err != nil {
 		log.Error(err, "Failed to compress the Argo CD Application status", "name", app.Name, "namespace", app.Namespace)
 		return ctrl.Result{}, err
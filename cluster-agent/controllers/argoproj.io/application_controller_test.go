@@ -2,6 +2,7 @@ package argoprojio
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -666,6 +667,50 @@ var _ = Describe("Application Controller", func() {
 			Expect(byteArr).NotTo(BeEmpty())
 		})
 	})
+
+	Context("Test getResourceTree function", func() {
+		It("Should only retrieve the resource tree again when the resourceVersion of the Application changes", func() {
+			ctx := context.Background()
+
+			calls := 0
+			var getErr error
+			reconciler := ApplicationReconciler{
+				GetResourceTree: func(ctx context.Context, app appv1.Application) (*fauxargocd.ApplicationTree, error) {
+					calls++
+					if getErr != nil {
+						return nil, getErr
+					}
+					return &fauxargocd.ApplicationTree{}, nil
+				},
+			}
+
+			app := appv1.Application{ObjectMeta: metav1.ObjectMeta{Name: "my-app", Namespace: "gitops-service-argocd", ResourceVersion: "1"}}
+
+			By("retrieving the resource tree once for the same resourceVersion")
+			for i := 0; i < 3; i++ {
+				tree, err := reconciler.getResourceTree(ctx, app)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(tree).ToNot(BeNil())
+			}
+			Expect(calls).To(Equal(1))
+
+			By("retrieving the resource tree again once the resourceVersion changes")
+			app.ResourceVersion = "2"
+			_, err := reconciler.getResourceTree(ctx, app)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(calls).To(Equal(2))
+
+			By("not caching errors")
+			app.ResourceVersion = "3"
+			getErr = fmt.Errorf("simulated error")
+			_, err = reconciler.getResourceTree(ctx, app)
+			Expect(err).To(HaveOccurred())
+			getErr = nil
+			_, err = reconciler.getResourceTree(ctx, app)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(calls).To(Equal(4))
+		})
+	})
 })
 
 var _ = Describe("Namespace Reconciler Tests.", func() {
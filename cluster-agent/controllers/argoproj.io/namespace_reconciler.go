@@ -80,7 +80,7 @@ func (r *ApplicationReconciler) startTimerForNextCycle(ctx context.Context, name
 			}
 
 			// Recreate Secrets that are required by Applications and RepositoryCredentials, but missing from cluster.
-			if err := recreateClusterSecrets(ctx, r.DB, r.Client, log); err != nil {
+			if err := recreateClusterSecrets(ctx, r.DB, r.Client, r.SecretRefClient, log); err != nil {
 				log.Error(err, "error on recreating cluster secrets")
 			}
 
@@ -97,7 +97,7 @@ func (r *ApplicationReconciler) startTimerForNextCycle(ctx context.Context, name
 
 }
 
-func syncCRsWithDB_Applications(ctx context.Context, dbQueries db.DatabaseQueries, client client.Client, logger logr.Logger) error {
+func syncCRsWithDB_Applications(ctx context.Context, dbQueries db.DatabaseQueries, k8sClient client.Client, logger logr.Logger) error {
 
 	log := logger.WithValues(sharedutil.Log_JobKey, sharedutil.Log_JobKeyValue).
 		WithValues(sharedutil.Log_JobTypeKey, "CR_Applications")
@@ -106,8 +106,14 @@ func syncCRsWithDB_Applications(ctx context.Context, dbQueries db.DatabaseQuerie
 	// map: applications IDs seen (string) -> (map value not used)
 	processedApplicationIds := make(map[string]any)
 
+	// map: IDs of the applications that are hosted on another GitopsEngineInstance (string) -> (map value not used)
+	// - These may have been migrated from this instance: see cleanOrphanedCRsfromCluster_Applications.
+	otherInstanceApplicationIds := make(map[string]any)
+
+	// Only the Argo CD Applications in the namespace of this agent's GitopsEngineInstance are listed: the Argo CD
+	// Applications of other instances on this cluster are not orphans of this instance (see getGitopsEngineInstanceIDsOfAgent).
 	argoApplicationList := appv1.ApplicationList{}
-	if err := client.List(ctx, &argoApplicationList); err != nil {
+	if err := k8sClient.List(ctx, &argoApplicationList, client.InNamespace(dbutil.GetGitOpsEngineSingleInstanceNamespace())); err != nil {
 		log.Error(err, "Error occurred in Namespace Reconciler while fetching list of ArgoCD Applications")
 		return fmt.Errorf("error occurred in Namespace Reconciler while fetching list of ArgoCD Applications: %w", err)
 	}
@@ -128,12 +134,27 @@ func syncCRsWithDB_Applications(ctx context.Context, dbQueries db.DatabaseQuerie
 	var res error
 
 	// Delete operation resources created during previous run.
-	if err := syncCRsWithDB_Applications_Delete_Operations(ctx, dbQueries, client, log); err != nil {
+	if err := syncCRsWithDB_Applications_Delete_Operations(ctx, dbQueries, k8sClient, log); err != nil {
 		if res == nil {
 			res = err
 		}
 	}
 
+	// Only the Application rows of this agent's GitopsEngineInstance are processed: the rows of other instances (for
+	// example, instances on other clusters) are processed by the cluster-agent of those instances.
+	engineInstanceIDs, err := getGitopsEngineInstanceIDsOfAgent(ctx, dbQueries, k8sClient, log)
+	if err != nil {
+		log.Error(err, "Error occurred in Namespace Reconciler while fetching GitopsEngineInstances")
+		return fmt.Errorf("error occurred in Namespace Reconciler while fetching GitopsEngineInstances: %w", err)
+	}
+
+	if len(engineInstanceIDs) == 0 {
+		// Without an instance there are no Application rows for this agent, and no Argo CD Applications should be
+		// treated as orphaned.
+		log.Info("Skipping Application sync, as no GitopsEngineInstance exists for this cluster-agent.")
+		return res
+	}
+
 	// Continuously iterate and fetch batches until all entries of Application table are processed.
 	for {
 
@@ -162,7 +183,12 @@ func syncCRsWithDB_Applications(ctx context.Context, dbQueries db.DatabaseQuerie
 		// Iterate over batch received above.
 		for _, applicationRowFromDB := range listOfApplicationsFromDB {
 
-			if err := processApplicationRowToSyncWithCluster(ctx, applicationRowFromDB, &processedApplicationIds, specialClusterUser, dbQueries, client, log); err != nil {
+			if _, exists := engineInstanceIDs[applicationRowFromDB.Engine_instance_inst_id]; !exists {
+				otherInstanceApplicationIds[applicationRowFromDB.Application_id] = false
+				continue
+			}
+
+			if err := processApplicationRowToSyncWithCluster(ctx, applicationRowFromDB, &processedApplicationIds, specialClusterUser, dbQueries, k8sClient, log); err != nil {
 				if res == nil {
 					res = fmt.Errorf("unable to processApplicationRowToSyncWithCluster: %w", err)
 				}
@@ -175,7 +201,7 @@ func syncCRsWithDB_Applications(ctx context.Context, dbQueries db.DatabaseQuerie
 
 	// Start a goroutine, because DeleteArgoCDApplication() function from cluster-agent/controllers may take some time to delete application.
 	go func() {
-		if _, err := cleanOrphanedCRsfromCluster_Applications(ctx, argoApplications, processedApplicationIds, client, log); err != nil {
+		if _, err := cleanOrphanedCRsfromCluster_Applications(ctx, argoApplications, processedApplicationIds, otherInstanceApplicationIds, k8sClient, log); err != nil {
 			log.Error(err, "unable to cleanOrphaned Argo CD Applications")
 		}
 	}()
@@ -226,6 +252,7 @@ func processApplicationRowToSyncWithCluster(ctx context.Context, applicationRowF
 			Instance_id:   applicationRowFromDB.Engine_instance_inst_id,
 			Resource_id:   applicationRowFromDB.Application_id,
 			Resource_type: db.OperationResourceType_Application,
+			Priority:      db.OperationPriority_SelfHeal,
 		}
 		engineInstanceDB := db.GitopsEngineInstance{
 			Gitopsengineinstance_id: dbOperationInput.Instance_id,
@@ -274,6 +301,7 @@ func processApplicationRowToSyncWithCluster(ctx context.Context, applicationRowF
 		Instance_id:   applicationRowFromDB.Engine_instance_inst_id,
 		Resource_id:   applicationRowFromDB.Application_id,
 		Resource_type: db.OperationResourceType_Application,
+		Priority:      db.OperationPriority_SelfHeal,
 	}
 	engineInstanceDB := db.GitopsEngineInstance{
 		Gitopsengineinstance_id: dbOperationInput.Instance_id,
@@ -349,8 +377,14 @@ func syncCRsWithDB_Applications_Delete_Operations(ctx context.Context, dbq db.Da
 	return res
 }
 
+// cleanOrphanedCRsfromCluster_Applications deletes the Argo CD Applications that do not have an Application row of this
+// agent's GitopsEngineInstance, and returns the deleted Applications.
+// - Applications that are part of a migration between instances (see operation_event_loop_migration.go) are skipped:
+// they are deleted by the migration itself.
+// - Applications whose row is hosted on another instance (in 'otherInstanceApplicationIds') were migrated away from this
+// instance: their resources are now managed by the other instance, so they are deleted without deleting their resources.
 func cleanOrphanedCRsfromCluster_Applications(ctx context.Context, argoApplications []appv1.Application, processedApplicationIds map[string]any,
-	client client.Client, log logr.Logger) ([]appv1.Application, error) {
+	otherInstanceApplicationIds map[string]any, client client.Client, log logr.Logger) ([]appv1.Application, error) {
 
 	if len(argoApplications) == 0 {
 		return []appv1.Application{}, nil
@@ -377,18 +411,40 @@ func cleanOrphanedCRsfromCluster_Applications(ctx context.Context, argoApplicati
 			continue
 		}
 
-		if _, ok := processedApplicationIds[application.Labels["databaseID"]]; !ok {
-			if err := controllers.DeleteArgoCDApplication(ctx, application, client, log); err != nil {
+		// Skip Applications that are being migrated between instances
+		if _, exists := application.Annotations[controllers.ArgoCDApplicationMigrationAnnotation]; exists {
+			continue
+		}
+
+		if _, ok := processedApplicationIds[application.Labels["databaseID"]]; ok {
+			continue
+		}
+
+		if _, ok := otherInstanceApplicationIds[application.Labels["databaseID"]]; ok {
+			if err := controllers.OrphanArgoCDApplication(ctx, application, client, log); err != nil {
 
-				log.Error(err, "unable to delete an orphaned Argo CD Application")
+				log.Error(err, "unable to orphan an Argo CD Application that is hosted on another instance")
 				if res == nil {
-					res = fmt.Errorf("unable to delete an orphaned Argo CD Application: %w", err)
+					res = fmt.Errorf("unable to orphan an Argo CD Application that is hosted on another instance: %w", err)
 				}
 
 			} else {
 				deletedOrphanedApplications = append(deletedOrphanedApplications, application)
-				log.Info("Deleted orphaned Argo CD Application")
+				log.Info("Deleted Argo CD Application that is hosted on another instance, without deleting its resources")
+			}
+			continue
+		}
+
+		if err := controllers.DeleteArgoCDApplication(ctx, application, client, log); err != nil {
+
+			log.Error(err, "unable to delete an orphaned Argo CD Application")
+			if res == nil {
+				res = fmt.Errorf("unable to delete an orphaned Argo CD Application: %w", err)
 			}
+
+		} else {
+			deletedOrphanedApplications = append(deletedOrphanedApplications, application)
+			log.Info("Deleted orphaned Argo CD Application")
 		}
 	}
 	return deletedOrphanedApplications, res
@@ -585,7 +641,7 @@ func cleanOrphanedCRsfromCluster_Operation(ctx context.Context, dbQueries db.Dat
 }
 
 // recreateClusterSecrets goes through list of ManagedEnvironments & RepositoryCredentials created in cluster and recreates Secrets that are missing from cluster.
-func recreateClusterSecrets(ctx context.Context, dbQueries db.DatabaseQueries, k8sClient client.Client, logger logr.Logger) error {
+func recreateClusterSecrets(ctx context.Context, dbQueries db.DatabaseQueries, k8sClient client.Client, secretRefClient client.Client, logger logr.Logger) error {
 
 	log := logger.WithValues(sharedutil.Log_JobKey, sharedutil.Log_JobKeyValue).
 		WithValues(sharedutil.Log_JobTypeKey, "CR_Secret_recreate")
@@ -642,7 +698,7 @@ func recreateClusterSecrets(ctx context.Context, dbQueries db.DatabaseQueries, k
 		}
 		namespacesProcessed[instance.Namespace_uid] = nil
 
-		if err := recreateClusterSecrets_ManagedEnvironments(ctx, dbQueries, k8sClient, listOfClusterAccessFromDB, listOfApplicationFromDB, instance, log); err != nil {
+		if err := recreateClusterSecrets_ManagedEnvironments(ctx, dbQueries, k8sClient, secretRefClient, listOfClusterAccessFromDB, listOfApplicationFromDB, instance, log); err != nil {
 
 			if res == nil {
 				res = fmt.Errorf("unable to recreate cluster secret for managed envs: %w", err)
@@ -658,7 +714,8 @@ func recreateClusterSecrets(ctx context.Context, dbQueries db.DatabaseQueries, k
 }
 
 // recreateClusterSecrets_ManagedEnvironments goes through list of ManagedEnvironments created in cluster and recreates Secrets that are missing from cluster.
-func recreateClusterSecrets_ManagedEnvironments(ctx context.Context, dbQueries db.DatabaseQueries, k8sClient client.Client, listOfClusterAccessFromDB []db.ClusterAccess, listOfApplicationFromDB []db.Application, instance db.GitopsEngineInstance, logger logr.Logger) error {
+// Secrets generated from ClusterCredentials that reference a user Secret are also regenerated, if the user Secret has changed.
+func recreateClusterSecrets_ManagedEnvironments(ctx context.Context, dbQueries db.DatabaseQueries, k8sClient client.Client, secretRefClient client.Client, listOfClusterAccessFromDB []db.ClusterAccess, listOfApplicationFromDB []db.Application, instance db.GitopsEngineInstance, logger logr.Logger) error {
 
 	log := logger.WithValues(sharedutil.Log_JobKey, sharedutil.Log_JobKeyValue).
 		WithValues(sharedutil.Log_JobTypeKey, "CR_Secret_recreate_managedEnv")
@@ -683,7 +740,7 @@ func recreateClusterSecrets_ManagedEnvironments(ctx context.Context, dbQueries d
 			continue
 		}
 
-		if err := recreateManagedEnvClusterSecretFromClusterAccess(ctx, clusterAccess, instance, listOfApplicationFromDB, specialClusterUser, dbQueries, k8sClient, log); err != nil {
+		if err := recreateManagedEnvClusterSecretFromClusterAccess(ctx, clusterAccess, instance, listOfApplicationFromDB, specialClusterUser, dbQueries, k8sClient, secretRefClient, log); err != nil {
 
 			if res == nil {
 				res = fmt.Errorf("unable to recreate managed env cluster secret: %w", err)
@@ -695,7 +752,7 @@ func recreateClusterSecrets_ManagedEnvironments(ctx context.Context, dbQueries d
 	return res
 }
 
-func recreateManagedEnvClusterSecretFromClusterAccess(ctx context.Context, clusterAccess db.ClusterAccess, instance db.GitopsEngineInstance, listOfApplicationFromDB []db.Application, specialClusterUser db.ClusterUser, dbQueries db.DatabaseQueries, k8sClient client.Client, log logr.Logger) error {
+func recreateManagedEnvClusterSecretFromClusterAccess(ctx context.Context, clusterAccess db.ClusterAccess, instance db.GitopsEngineInstance, listOfApplicationFromDB []db.Application, specialClusterUser db.ClusterUser, dbQueries db.DatabaseQueries, k8sClient client.Client, secretRefClient client.Client, log logr.Logger) error {
 
 	// This ClusterAccess is using current GitOpsEngineInstance,
 	// now find the ManagedEnvironment using this ClusterAccess.
@@ -733,34 +790,53 @@ func recreateManagedEnvClusterSecretFromClusterAccess(ctx context.Context, clust
 	argoSecret := corev1.Secret{}
 	if err := k8sClient.Get(ctx, types.NamespacedName{Name: secretName, Namespace: instance.Namespace_name}, &argoSecret); err != nil {
 
+		if !apierr.IsNotFound(err) {
+			log.Error(err, "Error occurred in recreateClusterSecrets_ManagedEnvironments while fetching Secret:"+secretName+" from Namespace: "+instance.Namespace_name)
+
+			return fmt.Errorf("error occurred in recreateClusterSecrets_ManagedEnvironments while fetching Secret:"+secretName+" from Namespace: %s, %w", instance.Namespace_name, err)
+		}
+
 		// If Secret is not present, then create Operation to recreate the Secret.
-		if apierr.IsNotFound(err) {
+		log.Info("Secret: " + secretName + " not found in Namespace:" + instance.Namespace_name + ", recreating it.")
 
-			log.Info("Secret: " + secretName + " not found in Namespace:" + instance.Namespace_name + ", recreating it.")
+	} else if clusterCreds.HasSecretRef() {
 
-			// We need to create an Operation to recreate the Secret, which requires Application details running in current ManagedEnvironment
-			// hence we iterate through list of Application entries from DB to find that Application.
-			if ok, application := getApplicationRunningInManagedEnvironment(listOfApplicationFromDB, managedEnvironment.Managedenvironment_id); ok {
+		// If the ClusterCredentials reference the user's Secret, and that Secret has changed since the Argo CD cluster
+		// secret was generated (for example, the token was rotated), then create an Operation to regenerate the Secret.
+		sourceSecretResourceVersion, err := controllers.ResolveClusterCredentialsSecretRef(ctx, secretRefClient, &clusterCreds)
+		if err != nil {
+			log.Error(err, "Error occurred in recreateClusterSecrets_ManagedEnvironments while resolving the Secret referenced by ClusterCredentials:"+clusterCreds.Clustercredentials_cred_id)
+			return fmt.Errorf("error occurred in recreateClusterSecrets_ManagedEnvironments while resolving the Secret referenced by ClusterCredentials: %w", err)
+		}
 
-				// We need to recreate Secret, to do that create Operation to inform Argo CD about it.
-				dbOperationInput := db.Operation{
-					Instance_id:   application.Engine_instance_inst_id,
-					Resource_id:   application.Application_id,
-					Resource_type: db.OperationResourceType_Application,
-				}
+		if argoSecret.Annotations[controllers.SourceSecretResourceVersionAnnotation] == sourceSecretResourceVersion {
+			return nil
+		}
 
-				if _, _, err := operations.CreateOperation(ctx, false, dbOperationInput, specialClusterUser.Clusteruser_id, instance.Namespace_name, dbQueries, k8sClient, log); err != nil {
-					log.Error(err, "Error occurred in recreateClusterSecrets_ManagedEnvironments while creating Operation.")
-					return fmt.Errorf("error occurred in recreateClusterSecrets_ManagedEnvironments while creating Operation: %w", err)
-				}
+		log.Info("Secret: " + secretName + " in Namespace:" + instance.Namespace_name + " is out of date with the Secret referenced by ClusterCredentials, regenerating it.")
 
-				log.Info("Operation " + dbOperationInput.Operation_id + " is created to create Secret: managed-env-" + managedEnvironment.Managedenvironment_id)
-			}
-		} else {
-			log.Error(err, "Error occurred in recreateClusterSecrets_ManagedEnvironments while fetching Secret:"+secretName+" from Namespace: "+instance.Namespace_name)
+	} else {
+		return nil
+	}
 
-			return fmt.Errorf("error occurred in recreateClusterSecrets_ManagedEnvironments while fetching Secret:"+secretName+" from Namespace: %s, %w", instance.Namespace_name, err)
+	// We need to create an Operation to recreate the Secret, which requires Application details running in current ManagedEnvironment
+	// hence we iterate through list of Application entries from DB to find that Application.
+	if ok, application := getApplicationRunningInManagedEnvironment(listOfApplicationFromDB, managedEnvironment.Managedenvironment_id); ok {
+
+		// We need to recreate Secret, to do that create Operation to inform Argo CD about it.
+		dbOperationInput := db.Operation{
+			Instance_id:   application.Engine_instance_inst_id,
+			Resource_id:   application.Application_id,
+			Resource_type: db.OperationResourceType_Application,
+			Priority:      db.OperationPriority_SelfHeal,
+		}
+
+		if _, _, err := operations.CreateOperation(ctx, false, dbOperationInput, specialClusterUser.Clusteruser_id, instance.Namespace_name, dbQueries, k8sClient, log); err != nil {
+			log.Error(err, "Error occurred in recreateClusterSecrets_ManagedEnvironments while creating Operation.")
+			return fmt.Errorf("error occurred in recreateClusterSecrets_ManagedEnvironments while creating Operation: %w", err)
 		}
+
+		log.Info("Operation " + dbOperationInput.Operation_id + " is created to create Secret: managed-env-" + managedEnvironment.Managedenvironment_id)
 	}
 
 	return nil
@@ -808,6 +884,7 @@ func recreateClusterSecrets_RepositoryCredentials(ctx context.Context, dbQueries
 						Instance_id:   instance.Gitopsengineinstance_id,
 						Resource_id:   repositoryCredentials.RepositoryCredentialsID,
 						Resource_type: db.OperationResourceType_RepositoryCredentials,
+						Priority:      db.OperationPriority_SelfHeal,
 					}
 
 					if _, _, err := operations.CreateOperation(ctx, false, dbOperationInput, specialClusterUser.Clusteruser_id, instance.Namespace_name, dbQueries, k8sClient, log); err != nil {
@@ -939,6 +1016,25 @@ func getListOfGitopsEngineInstancesForCurrentCluster(ctx context.Context, dbQuer
 	return gitopsEngineInstance, nil
 }
 
+// getGitopsEngineInstanceIDsOfAgent returns the IDs of the GitopsEngineInstances on the cluster where the service is
+// running, which are in the Argo CD namespace of this cluster-agent.
+func getGitopsEngineInstanceIDsOfAgent(ctx context.Context, dbQueries db.DatabaseQueries, k8sClient client.Client, log logr.Logger) (map[string]any, error) {
+
+	gitopsEngineInstances, err := getListOfGitopsEngineInstancesForCurrentCluster(ctx, dbQueries, k8sClient, log)
+	if err != nil {
+		return nil, err
+	}
+
+	res := map[string]any{}
+	for _, gitopsEngineInstance := range gitopsEngineInstances {
+		if gitopsEngineInstance.Namespace_name == dbutil.GetGitOpsEngineSingleInstanceNamespace() {
+			res[gitopsEngineInstance.Gitopsengineinstance_id] = true
+		}
+	}
+
+	return res, nil
+}
+
 // getApplicationRunningInManagedEnvironment loops through Applications to find Application runing in given ManagedEnvironment.
 func getApplicationRunningInManagedEnvironment(applicationList []db.Application, managedEnvId string) (bool, db.Application) {
 
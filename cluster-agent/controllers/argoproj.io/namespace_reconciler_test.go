@@ -2,6 +2,7 @@ package argoprojio
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -15,6 +16,7 @@ import (
 	argosharedutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util/argocd"
 	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/operations"
 	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/tests"
+	"github.com/redhat-appstudio/managed-gitops/cluster-agent/controllers"
 	"github.com/redhat-appstudio/managed-gitops/cluster-agent/controllers/argoproj.io/application_info_cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -56,7 +58,7 @@ var _ = Describe("Namespace Reconciler Tests.", func() {
 
 			processedApplicationIds := map[string]any{"test-my-application-3": false, "test-my-application-5": false}
 
-			deletedArgoApplications, err := cleanOrphanedCRsfromCluster_Applications(ctx, argoApplications, processedApplicationIds, reconciler.Client, log)
+			deletedArgoApplications, err := cleanOrphanedCRsfromCluster_Applications(ctx, argoApplications, processedApplicationIds, map[string]any{}, reconciler.Client, log)
 			Expect(err).To(Succeed())
 
 			Expect(deletedArgoApplications).To(HaveLen(3))
@@ -67,6 +69,165 @@ var _ = Describe("Namespace Reconciler Tests.", func() {
 				Expect(ok).To(BeTrue())
 			}
 		})
+
+		It("Should not delete Argo CD Applications that are being migrated, and should orphan Applications that are hosted on another instance.", func() {
+			ctx := context.Background()
+			log := logger.FromContext(ctx)
+
+			scheme, _, _, _, err := tests.GenericTestSetup()
+			Expect(err).ToNot(HaveOccurred())
+
+			err = appv1.AddToScheme(scheme)
+			Expect(err).ToNot(HaveOccurred())
+
+			stagedApplication := appv1.Application{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test-staged-application",
+					Namespace:   dbutil.GetGitOpsEngineSingleInstanceNamespace(),
+					Labels:      map[string]string{controllers.ArgoCDApplicationDatabaseIDLabel: "test-staged-application"},
+					Annotations: map[string]string{controllers.ArgoCDApplicationMigrationAnnotation: "stage"},
+				},
+			}
+
+			migratedApplication := appv1.Application{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "test-migrated-application",
+					Namespace:  dbutil.GetGitOpsEngineSingleInstanceNamespace(),
+					Labels:     map[string]string{controllers.ArgoCDApplicationDatabaseIDLabel: "test-migrated-application"},
+					Finalizers: []string{"resources-finalizer.argocd.argoproj.io/background"},
+				},
+			}
+
+			k8sClient := &deletePropagationRecordingClient{
+				Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(&stagedApplication, &migratedApplication).Build(),
+				policies: map[string]metav1.DeletionPropagation{},
+			}
+
+			// Both Application rows are hosted on another instance
+			otherInstanceApplicationIds := map[string]any{"test-staged-application": false, "test-migrated-application": false}
+
+			deletedArgoApplications, err := cleanOrphanedCRsfromCluster_Applications(ctx, []appv1.Application{stagedApplication, migratedApplication},
+				map[string]any{}, otherInstanceApplicationIds, k8sClient, log)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deletedArgoApplications).To(HaveLen(1))
+			Expect(deletedArgoApplications[0].Name).To(Equal(migratedApplication.Name))
+
+			By("verifying the staged Application was not deleted")
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&stagedApplication), &stagedApplication)).To(Succeed())
+			Expect(stagedApplication.DeletionTimestamp).To(BeNil())
+
+			By("verifying the migrated Application was deleted without deleting its resources")
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(&migratedApplication), &migratedApplication)
+			Expect(apierr.IsNotFound(err)).To(BeTrue())
+			Expect(k8sClient.getPolicies()).To(HaveKeyWithValue(migratedApplication.Name, metav1.DeletePropagationOrphan))
+		})
+	})
+
+	Context("Testing syncCRsWithDB_Applications function during a migration.", func() {
+
+		It("should neither delete the Argo CD Application staged for a migration, nor the resources of an Application that was migrated away", func() {
+
+			err := db.SetupForTestingDBGinkgo()
+			Expect(err).ToNot(HaveOccurred())
+
+			ctx := context.Background()
+			log := logger.FromContext(ctx)
+
+			dbq, err := db.NewUnsafePostgresDBQueries(false, true)
+			Expect(err).ToNot(HaveOccurred())
+			defer dbq.CloseDatabase()
+
+			scheme, _, _, _, err := tests.GenericTestSetup()
+			Expect(err).ToNot(HaveOccurred())
+
+			err = appv1.AddToScheme(scheme)
+			Expect(err).ToNot(HaveOccurred())
+
+			kubeSystemNamespace := corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "kube-system",
+					UID:  "test-" + uuid.NewUUID(),
+				},
+			}
+
+			k8sClient := &deletePropagationRecordingClient{
+				Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(&kubeSystemNamespace).Build(),
+				policies: map[string]metav1.DeletionPropagation{},
+			}
+
+			By("creating the GitopsEngineInstance of this agent, and an Application row hosted on another instance")
+
+			gitopsEngineCluster, _, err := dbutil.GetOrCreateGitopsEngineClusterByKubeSystemNamespaceUID(ctx, string(kubeSystemNamespace.UID), dbq, log)
+			Expect(err).ToNot(HaveOccurred())
+
+			agentInstance := db.GitopsEngineInstance{
+				Gitopsengineinstance_id: "test-id-" + string(uuid.NewUUID()),
+				Namespace_name:          dbutil.GetGitOpsEngineSingleInstanceNamespace(),
+				Namespace_uid:           "test-ns-" + string(uuid.NewUUID()),
+				EngineCluster_id:        gitopsEngineCluster.Gitopsenginecluster_id,
+			}
+			Expect(dbq.CreateGitopsEngineInstance(ctx, &agentInstance)).To(Succeed())
+
+			_, managedEnvironment, _, otherInstance, _, err := db.CreateSampleData(dbq)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, dummyApplicationSpec, argoCDApplication, err := createDummyApplicationData()
+			Expect(err).ToNot(HaveOccurred())
+
+			applicationRow := db.Application{
+				Application_id:          "test-my-application",
+				Name:                    argoCDApplication.Name,
+				Spec_field:              dummyApplicationSpec,
+				Engine_instance_inst_id: otherInstance.Gitopsengineinstance_id,
+				Managed_environment_id:  managedEnvironment.Managedenvironment_id,
+			}
+			Expect(dbq.CreateApplication(ctx, &applicationRow)).To(Succeed())
+
+			By("staging the Argo CD Application on this agent's instance, while the row is being migrated to it")
+
+			argoCDApplication.Labels = map[string]string{controllers.ArgoCDApplicationDatabaseIDLabel: applicationRow.Application_id}
+			argoCDApplication.Annotations = map[string]string{controllers.ArgoCDApplicationMigrationAnnotation: "stage"}
+			Expect(k8sClient.Create(ctx, &argoCDApplication)).To(Succeed())
+
+			// An Argo CD Application of another instance on this cluster, whose row does not exist: it is not an orphan of
+			// this agent's instance.
+			otherNamespaceApplication := appv1.Application{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "other-namespace-application",
+					Namespace: "other-argocd-namespace",
+					Labels:    map[string]string{controllers.ArgoCDApplicationDatabaseIDLabel: "test-other-application-id"},
+				},
+			}
+			Expect(k8sClient.Create(ctx, &otherNamespaceApplication)).To(Succeed())
+
+			Expect(syncCRsWithDB_Applications(ctx, dbq, k8sClient, log)).To(Succeed())
+
+			Consistently(func() bool {
+				app := appv1.Application{}
+				return k8sClient.Get(ctx, client.ObjectKeyFromObject(&argoCDApplication), &app) == nil && app.DeletionTimestamp == nil
+			}, "2s", "100ms").Should(BeTrue())
+
+			By("verifying the Argo CD Application of a row that was migrated to another instance is deleted without its resources")
+
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&argoCDApplication), &argoCDApplication)).To(Succeed())
+			argoCDApplication.Annotations = nil
+			argoCDApplication.Finalizers = []string{"resources-finalizer.argocd.argoproj.io/background"}
+			Expect(k8sClient.Update(ctx, &argoCDApplication)).To(Succeed())
+
+			Expect(syncCRsWithDB_Applications(ctx, dbq, k8sClient, log)).To(Succeed())
+
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, client.ObjectKeyFromObject(&argoCDApplication), &appv1.Application{})
+				return apierr.IsNotFound(err)
+			}, "10s", "100ms").Should(BeTrue())
+
+			Expect(k8sClient.getPolicies()).To(HaveKeyWithValue(argoCDApplication.Name, metav1.DeletePropagationOrphan))
+
+			By("verifying the Argo CD Applications in the namespace of another instance are not processed")
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&otherNamespaceApplication), &otherNamespaceApplication)).To(Succeed())
+			Expect(otherNamespaceApplication.DeletionTimestamp).To(BeNil())
+			Expect(k8sClient.getPolicies()).ToNot(HaveKey(otherNamespaceApplication.Name))
+		})
 	})
 
 	Context("Testing syncCRsWithDB_Applications_Delete_Operations function", func() {
@@ -874,7 +1035,7 @@ var _ = Describe("Namespace Reconciler Tests.", func() {
 
 			By("Call function to recreate Secret if missing from cluster.")
 
-			Expect(recreateClusterSecrets(ctx, dbq, k8sClient, log)).To(Succeed())
+			Expect(recreateClusterSecrets(ctx, dbq, k8sClient, k8sClient, log)).To(Succeed())
 
 			By("Get list of Operations after calling function.")
 
@@ -895,7 +1056,7 @@ var _ = Describe("Namespace Reconciler Tests.", func() {
 
 			By("Call function to recreate Secret if missing from cluster.")
 
-			Expect(recreateClusterSecrets(ctx, dbq, k8sClient, log)).To(Succeed())
+			Expect(recreateClusterSecrets(ctx, dbq, k8sClient, k8sClient, log)).To(Succeed())
 
 			By("Get list of Operations after calling function.")
 
@@ -912,7 +1073,7 @@ var _ = Describe("Namespace Reconciler Tests.", func() {
 
 			By("Call function to recreate Secret if missing from cluster.")
 
-			Expect(recreateClusterSecrets(ctx, dbq, k8sClient, log)).To(Succeed())
+			Expect(recreateClusterSecrets(ctx, dbq, k8sClient, k8sClient, log)).To(Succeed())
 
 			By("Get list of Operations after calling function.")
 
@@ -946,7 +1107,7 @@ var _ = Describe("Namespace Reconciler Tests.", func() {
 
 			By("Call function to recreate Secret if missing from cluster.")
 
-			Expect(recreateClusterSecrets(ctx, dbq, k8sClient, log)).To(Succeed())
+			Expect(recreateClusterSecrets(ctx, dbq, k8sClient, k8sClient, log)).To(Succeed())
 
 			By("Get list of Operations after calling function.")
 
@@ -1061,7 +1222,7 @@ var _ = Describe("Namespace Reconciler Tests.", func() {
 
 			By("Call function to recreate Secret if missing from cluster.")
 
-			Expect(recreateClusterSecrets(ctx, dbq, k8sClient, log)).To(Succeed())
+			Expect(recreateClusterSecrets(ctx, dbq, k8sClient, k8sClient, log)).To(Succeed())
 
 			By("Get list of Operations after calling function.")
 
@@ -1082,7 +1243,7 @@ var _ = Describe("Namespace Reconciler Tests.", func() {
 
 			By("Call function to recreate Secret if missing from cluster.")
 
-			Expect(recreateClusterSecrets(ctx, dbq, k8sClient, log)).To(Succeed())
+			Expect(recreateClusterSecrets(ctx, dbq, k8sClient, k8sClient, log)).To(Succeed())
 
 			By("Get list of Operations after calling function.")
 
@@ -1099,7 +1260,7 @@ var _ = Describe("Namespace Reconciler Tests.", func() {
 
 			By("Call function to recreate Secret if missing from cluster.")
 
-			Expect(recreateClusterSecrets(ctx, dbq, k8sClient, log)).To(Succeed())
+			Expect(recreateClusterSecrets(ctx, dbq, k8sClient, k8sClient, log)).To(Succeed())
 
 			By("Get list of Operations after calling function.")
 
@@ -1107,4 +1268,98 @@ var _ = Describe("Namespace Reconciler Tests.", func() {
 			Expect(operation).To(HaveLen(1))
 		})
 	})
+
+	Context("Testing getGitopsEngineInstanceIDsOfAgent function.", func() {
+
+		It("should only return the GitopsEngineInstances of this cluster, in the Argo CD namespace of the cluster-agent", func() {
+
+			err := db.SetupForTestingDBGinkgo()
+			Expect(err).ToNot(HaveOccurred())
+
+			ctx := context.Background()
+			log := logger.FromContext(ctx)
+
+			dbq, err := db.NewUnsafePostgresDBQueries(false, true)
+			Expect(err).ToNot(HaveOccurred())
+			defer dbq.CloseDatabase()
+
+			scheme, _, _, _, err := tests.GenericTestSetup()
+			Expect(err).ToNot(HaveOccurred())
+
+			k8sClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+			By("verifying no instances are returned when the GitopsEngineCluster does not yet exist")
+
+			kubeSystemNamepace := corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "kube-system",
+					UID:  "test-" + uuid.NewUUID(),
+				},
+			}
+			Expect(k8sClient.Create(ctx, &kubeSystemNamepace)).To(Succeed())
+
+			engineInstanceIDs, err := getGitopsEngineInstanceIDsOfAgent(ctx, dbq, k8sClient, log)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(engineInstanceIDs).To(BeEmpty())
+
+			By("creating an instance in the Argo CD namespace of the agent, and an instance in another namespace")
+
+			gitopsEngineCluster, _, err := dbutil.GetOrCreateGitopsEngineClusterByKubeSystemNamespaceUID(ctx, string(kubeSystemNamepace.UID), dbq, log)
+			Expect(err).ToNot(HaveOccurred())
+
+			agentInstance := db.GitopsEngineInstance{
+				Gitopsengineinstance_id: "test-id-" + string(uuid.NewUUID()),
+				Namespace_name:          dbutil.GetGitOpsEngineSingleInstanceNamespace(),
+				Namespace_uid:           "test-ns-" + string(uuid.NewUUID()),
+				EngineCluster_id:        gitopsEngineCluster.Gitopsenginecluster_id,
+			}
+			Expect(dbq.CreateGitopsEngineInstance(ctx, &agentInstance)).To(Succeed())
+
+			otherInstance := db.GitopsEngineInstance{
+				Gitopsengineinstance_id: "test-id-" + string(uuid.NewUUID()),
+				Namespace_name:          "test-ns-" + string(uuid.NewUUID()),
+				Namespace_uid:           "test-ns-" + string(uuid.NewUUID()),
+				EngineCluster_id:        gitopsEngineCluster.Gitopsenginecluster_id,
+			}
+			Expect(dbq.CreateGitopsEngineInstance(ctx, &otherInstance)).To(Succeed())
+
+			engineInstanceIDs, err = getGitopsEngineInstanceIDsOfAgent(ctx, dbq, k8sClient, log)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(engineInstanceIDs).To(HaveLen(1))
+			Expect(engineInstanceIDs).To(HaveKey(agentInstance.Gitopsengineinstance_id))
+		})
+	})
 })
+
+// deletePropagationRecordingClient records the propagation policy of each Delete, by object name.
+type deletePropagationRecordingClient struct {
+	client.Client
+
+	mutex    sync.Mutex
+	policies map[string]metav1.DeletionPropagation
+}
+
+func (c *deletePropagationRecordingClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+
+	deleteOptions := client.DeleteOptions{}
+	deleteOptions.ApplyOptions(opts)
+
+	if deleteOptions.PropagationPolicy != nil {
+		c.mutex.Lock()
+		c.policies[obj.GetName()] = *deleteOptions.PropagationPolicy
+		c.mutex.Unlock()
+	}
+
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+func (c *deletePropagationRecordingClient) getPolicies() map[string]metav1.DeletionPropagation {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	res := map[string]metav1.DeletionPropagation{}
+	for name, policy := range c.policies {
+		res[name] = policy
+	}
+	return res
+}
@@ -7,8 +7,10 @@ import (
 	"reflect"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	appv1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
+	"github.com/argoproj/gitops-engine/pkg/sync/common"
 	"github.com/go-logr/logr"
 	operation "github.com/redhat-appstudio/managed-gitops/backend-shared/apis/managed-gitops/v1alpha1"
 	"github.com/redhat-appstudio/managed-gitops/backend-shared/db"
@@ -48,6 +50,9 @@ import (
 // https://docs.google.com/document/d/1e1UwCbwK-Ew5ODWedqp_jZmhiZzYWaxEvIL-tqebMzo/edit#heading=h.9vyguee8vhow
 type OperationEventLoop struct {
 	eventLoopInputChannel chan operationEventLoopEvent
+
+	// secretRefClient is a K8s client of the cluster that contains the Secrets referenced by cluster/repository credentials
+	secretRefClient client.Client
 }
 
 // Functions that return a boolean indicating whether the request should be retried, should use these constants
@@ -57,13 +62,14 @@ const (
 	shouldRetryFalse = false
 )
 
-func NewOperationEventLoop() *OperationEventLoop {
+func NewOperationEventLoop(secretRefClient client.Client) *OperationEventLoop {
 	channel := make(chan operationEventLoopEvent)
 
 	res := &OperationEventLoop{}
 	res.eventLoopInputChannel = channel
+	res.secretRefClient = secretRefClient
 
-	go operationEventLoopRouter(channel)
+	go operationEventLoopRouter(channel, secretRefClient)
 
 	return res
 
@@ -72,15 +78,18 @@ func NewOperationEventLoop() *OperationEventLoop {
 type operationEventLoopEvent struct {
 	request ctrl.Request
 	client  client.Client
+
+	// secretRefClient is a K8s client of the cluster that contains the Secrets referenced by cluster/repository credentials
+	secretRefClient client.Client
 }
 
 func (evl *OperationEventLoop) EventReceived(req ctrl.Request, client client.Client) {
 
-	event := operationEventLoopEvent{request: req, client: client}
+	event := operationEventLoopEvent{request: req, client: client, secretRefClient: evl.secretRefClient}
 	evl.eventLoopInputChannel <- event
 }
 
-func operationEventLoopRouter(input chan operationEventLoopEvent) {
+func operationEventLoopRouter(input chan operationEventLoopEvent, secretRefClient client.Client) {
 
 	ctx := context.Background()
 
@@ -88,8 +97,6 @@ func operationEventLoopRouter(input chan operationEventLoopEvent) {
 		WithName(logutil.LogLogger_managed_gitops).
 		WithValues(logutil.Log_Component, logutil.Log_Component_Appstudio_Controller)
 
-	taskRetryLoop := sharedutil.NewTaskRetryLoop("cluster-agent")
-
 	log.Info("controllerEventLoopRouter started")
 
 	dbQueries, err := db.NewSharedProductionPostgresDBQueries(false)
@@ -98,6 +105,17 @@ func operationEventLoopRouter(input chan operationEventLoopEvent) {
 		return
 	}
 
+	// Operations are scheduled fairly between tenants (the users that own the Operations), so that a single user with
+	// many Operations is not able to starve the Operations of other users. The weights are configured by the names of
+	// the users' namespaces, which are on the cluster of secretRefClient.
+	tenantWeights := newOperationTenantWeights(operationTenantWeightsFromEnv(log))
+	tenantWeights.startResolving(ctx, secretRefClient, dbQueries, log)
+
+	taskRetryLoop := sharedutil.NewTaskRetryLoopWithOptions("cluster-agent", sharedutil.TaskRetryLoopOptions{
+		TenantWeight:     tenantWeights.weight,
+		ReportQueueDepth: metrics.SetOperationQueueDepth,
+	})
+
 	credentialService := utils.NewCredentialService(nil, false)
 
 	for {
@@ -106,6 +124,7 @@ func operationEventLoopRouter(input chan operationEventLoopEvent) {
 		// Generate the map key (which controls task concurrency) by retrieving the Operation from the database
 		// that corresponds to the Operation custom resource from the event.
 		var mapKey string
+		var schedulingOptions sharedutil.TaskSchedulingOptions
 		_, err := sharedutil.CatchPanic(func() error {
 
 			dbOperation, err := getDBOperationForEvent(ctx, newEvent, dbQueries, log)
@@ -122,6 +141,11 @@ func operationEventLoopRouter(input chan operationEventLoopEvent) {
 			// operations one at a time (i.e. non-concurrently)
 			mapKey = dbOperation.Instance_id + "-" + string(dbOperation.Resource_type) + "-" + dbOperation.Resource_id
 
+			schedulingOptions = sharedutil.TaskSchedulingOptions{
+				Tenant:   dbOperation.Operation_owner_user_id,
+				Priority: int(dbOperation.Priority),
+			}
+
 			return nil
 		})
 
@@ -138,14 +162,16 @@ func operationEventLoopRouter(input chan operationEventLoopEvent) {
 		// Queue a new task in the task retry loop for our event.
 		task := &processOperationEventTask{
 			event: operationEventLoopEvent{
-				request: newEvent.request,
-				client:  newEvent.client,
+				request:         newEvent.request,
+				client:          newEvent.client,
+				secretRefClient: newEvent.secretRefClient,
 			},
 			log:               log,
 			credentialService: credentialService,
 			syncFuncs:         defaultSyncFuncs(),
 		}
-		taskRetryLoop.AddTaskIfNotPresent(mapKey, task, sharedutil.ExponentialBackoff{Factor: 2, Min: time.Millisecond * 200, Max: time.Second * 10, Jitter: true})
+		taskRetryLoop.AddTaskIfNotPresentWithSchedulingOptions(mapKey, task,
+			sharedutil.ExponentialBackoff{Factor: 2, Min: time.Millisecond * 200, Max: time.Second * 10, Jitter: true}, schedulingOptions)
 
 	}
 
@@ -282,6 +308,14 @@ func (task *processOperationEventTask) PerformTask(taskContext context.Context)
 		}
 
 		task.log.Info("Updated Operation state", "operationID", dbOperation.Operation_id, "operationState", string(dbOperation.State))
+
+		// Wake up any callers that are waiting for the Operation to complete. If the notification fails, the callers will
+		// still eventually see the new state when they next poll, so there is no need to retry.
+		if dbOperation.State == db.OperationState_Completed || dbOperation.State == db.OperationState_Failed {
+			if err := dbQueries.NotifyOperationStateChanged(taskContext, dbOperation.Operation_id); err != nil {
+				task.log.Error(err, "unable to notify operation state change", "operationID", dbOperation.Operation_id)
+			}
+		}
 	}
 
 	return shouldRetry, err
@@ -421,6 +455,7 @@ func (task *processOperationEventTask) internalPerformTask(taskContext context.C
 		dbQueries:         dbQueries,
 		argoCDNamespace:   *argoCDNamespace,
 		eventClient:       eventClient,
+		secretRefClient:   task.event.secretRefClient,
 		credentialService: task.credentialService,
 		log:               log,
 		syncFuncs:         task.syncFuncs,
@@ -465,6 +500,17 @@ func (task *processOperationEventTask) internalPerformTask(taskContext context.C
 
 		return &dbOperation, shouldRetry, err
 
+	} else if dbOperation.Resource_type == db.OperationResourceType_Rollback {
+
+		// Process a Rollback event: a Rollback operation points to a SyncOperation, so it is processed in the same way
+		shouldRetry, err := processOperation_SyncOperation(taskContext, dbOperation, *operationCR, operationConfigParams)
+
+		if err != nil {
+			log.Error(err, "error occurred on processing the rollback application operation")
+		}
+
+		return &dbOperation, shouldRetry, err
+
 	} else if dbOperation.Resource_type == db.OperationResourceType_GitOpsEngineInstance {
 
 		// Process a SyncOperation event
@@ -476,6 +522,24 @@ func (task *processOperationEventTask) internalPerformTask(taskContext context.C
 
 		return nil, shouldRetry, err
 
+	} else if dbOperation.Resource_type == db.OperationResourceType_ApplicationMigrationStage {
+
+		shouldRetry, err := processOperation_ApplicationMigrationStage(taskContext, dbOperation, *operationCR, operationConfigParams)
+		if err != nil {
+			log.Error(err, "error occurred on processing the application migration stage operation")
+		}
+
+		return &dbOperation, shouldRetry, err
+
+	} else if dbOperation.Resource_type == db.OperationResourceType_ApplicationMigrationOrphan {
+
+		shouldRetry, err := processOperation_ApplicationMigrationOrphan(taskContext, dbOperation, *operationCR, operationConfigParams)
+		if err != nil {
+			log.Error(err, "error occurred on processing the application migration orphan operation")
+		}
+
+		return &dbOperation, shouldRetry, err
+
 	} else {
 		log.Error(nil, "SEVERE: unrecognized resource type: "+string(dbOperation.Resource_type))
 		return &dbOperation, shouldRetryFalse, nil
@@ -497,6 +561,10 @@ type operationConfig struct {
 	// eventClient is a K8s client object that can be used to interact with the cluster that Argo CD is on
 	eventClient client.Client
 
+	// secretRefClient is a K8s client of the cluster that contains the Secrets referenced by cluster/repository
+	// credentials: the cluster of the GitOps Service API namespaces, which may not be the cluster that Argo CD is on.
+	secretRefClient client.Client
+
 	// credentialService is used to retrieve login credentials for Argo CD
 	credentialService *utils.CredentialService
 
@@ -508,6 +576,7 @@ type operationConfig struct {
 }
 
 // Process a SyncOperation database entry, that was pointed to by an Operation CR.
+// The SyncOperation is either synced, or rolled back (for Operations of type Rollback), based on the Operation resource type.
 // returns shouldRetry, error
 func processOperation_SyncOperation(ctx context.Context, dbOperation db.Operation, crOperation operation.Operation,
 	opConfig operationConfig) (bool, error) {
@@ -561,8 +630,11 @@ func processOperation_SyncOperation(ctx context.Context, dbOperation db.Operatio
 	// 3) Process the event, based on whether the SyncOperation is requesting an app sync, or a terminate.
 	if dbSyncOperation.DesiredState == db.SyncOperation_DesiredState_Running {
 		// refresh the Application before syncing to make sure that the latest revision is deployed.
-		if err := opConfig.syncFuncs.refreshApp(ctx, opConfig.eventClient, dbApplication.Name, opConfig.argoCDNamespace.Name); err != nil {
-			return shouldRetryTrue, err
+		// (A rollback deploys a revision from the deployment history, so no refresh is required.)
+		if dbOperation.Resource_type != db.OperationResourceType_Rollback {
+			if err := opConfig.syncFuncs.refreshApp(ctx, opConfig.eventClient, dbApplication.Name, opConfig.argoCDNamespace.Name); err != nil {
+				return shouldRetryTrue, err
+			}
 		}
 
 		return runAppSync(ctx, dbOperation, *dbSyncOperation, &dbApplication, opConfig)
@@ -680,9 +752,10 @@ func isOperationRunning(ctx context.Context, k8sClient client.Client, appName, a
 	return true, nil
 }
 
-// syncFuncs is a wrapper over sync and terminate functions and is used in unit testing different sync scenarios
+// syncFuncs is a wrapper over sync, rollback and terminate functions and is used in unit testing different sync scenarios
 type syncFuncs struct {
-	appSync            func(context.Context, string, string, string, client.Client, *utils.CredentialService, bool) error
+	appSync            func(context.Context, string, string, string, client.Client, *utils.CredentialService, bool, utils.AppSyncOptions) (*appv1.OperationState, error)
+	appRollback        func(context.Context, string, string, client.Client, *utils.CredentialService, utils.AppRollbackOptions) (*appv1.OperationState, error)
 	terminateOperation func(context.Context, string, corev1.Namespace, *utils.CredentialService, client.Client, time.Duration, logr.Logger) error
 
 	refreshApp func(context.Context, client.Client, string, string) error
@@ -691,6 +764,7 @@ type syncFuncs struct {
 func defaultSyncFuncs() *syncFuncs {
 	return &syncFuncs{
 		appSync:            utils.AppSync,
+		appRollback:        utils.AppRollback,
 		terminateOperation: utils.TerminateOperation,
 		refreshApp:         refreshApplication,
 	}
@@ -702,22 +776,50 @@ func runAppSync(ctx context.Context, dbOperation db.Operation, dbSyncOperation d
 
 	log := opConfig.log
 
+	syncOptions, err := convertSyncOperationToAppSyncOptions(dbSyncOperation)
+	if err != nil {
+		log.Error(err, "unable to read the sync options of SyncOperation", "syncOperationID", dbSyncOperation.SyncOperation_id)
+		return shouldRetryFalse, err
+	}
+
+	// rollbackOptions is non-nil if the SyncOperation should be rolled back, rather than synced
+	var rollbackOptions *utils.AppRollbackOptions
+	if dbOperation.Resource_type == db.OperationResourceType_Rollback {
+		if rollbackOptions, err = convertSyncOperationToAppRollbackOptions(dbSyncOperation); err != nil {
+			log.Error(err, "unable to read the rollback target of SyncOperation", "syncOperationID", dbSyncOperation.SyncOperation_id)
+			return shouldRetryFalse, err
+		}
+	}
+
+	// Record that the sync operation has started
+	dbSyncOperation.Phase = db.SyncOperation_Phase_Running
+	dbSyncOperation.Started_at = time.Now()
+	if err := opConfig.dbQueries.UpdateSyncOperationStatus(ctx, &dbSyncOperation); err != nil {
+		log.Error(err, "unable to update the status of SyncOperation", "syncOperationID", dbSyncOperation.SyncOperation_id)
+	}
+
 	completeChan := make(chan bool)
 
-	var err error
+	// operationState is the final state of the Argo CD sync operation, if available
+	var operationState *appv1.OperationState
 
 	cancellableCtx, cancelFunc := context.WithCancel(ctx)
 
 	defer cancelFunc()
 
-	// Start the AppSync operation in a separate thread.
+	// Start the AppSync (or AppRollback) operation in a separate thread.
 	go func() {
-		err = opConfig.syncFuncs.appSync(cancellableCtx, dbApplication.Name, dbSyncOperation.Revision, opConfig.argoCDNamespace.Name, opConfig.eventClient,
-			opConfig.credentialService, false)
+		if rollbackOptions != nil {
+			operationState, err = opConfig.syncFuncs.appRollback(cancellableCtx, dbApplication.Name, opConfig.argoCDNamespace.Name, opConfig.eventClient,
+				opConfig.credentialService, *rollbackOptions)
+		} else {
+			operationState, err = opConfig.syncFuncs.appSync(cancellableCtx, dbApplication.Name, dbSyncOperation.Revision, opConfig.argoCDNamespace.Name, opConfig.eventClient,
+				opConfig.credentialService, false, syncOptions)
+		}
 
 		var failed bool
 		if err != nil {
-			log.Error(err, "app sync failed on application '"+dbApplication.Name+"'")
+			log.Error(err, "app sync failed on application '"+dbApplication.Name+"'", "rollback", rollbackOptions != nil)
 			failed = true
 		}
 
@@ -727,6 +829,9 @@ func runAppSync(ctx context.Context, dbOperation db.Operation, dbSyncOperation d
 
 	var shouldRetry bool
 
+	// syncOperationStatus is the final status of the sync operation, or nil if the SyncOperation no longer exists
+	var syncOperationStatus *db.SyncOperation
+
 	backoff := sharedutil.ExponentialBackoff{Factor: 1.5, Min: 2 * time.Second, Max: 15 * time.Second, Jitter: true}
 
 outer:
@@ -758,21 +863,174 @@ outer:
 			log.V(logutil.LogLevel_Debug).Info("SyncOperation no longer had desired running state.")
 			shouldRetry = shouldRetryFalse
 			err = nil
+			syncOperationStatus = generateTerminatedSyncOperationStatus(*dbSyncOperation)
 			break outer
 		}
 
 		// 3) Otherwise, continue waiting the AppSync operation to complete.
 		select {
 		case shouldRetry = <-completeChan:
+			syncOperationStatus = generateSyncOperationStatus(*dbSyncOperation, operationState, err, log)
 			break outer
 		default:
 			backoff.DelayOnFail(ctx)
 		}
 	}
 
+	// 4) Record the outcome of the sync operation in the SyncOperation row, so that it can be reported in the status of the SyncRun
+	if syncOperationStatus != nil {
+		if innerErr := opConfig.dbQueries.UpdateSyncOperationStatus(ctx, syncOperationStatus); innerErr != nil {
+			if db.IsResultNotFoundError(innerErr) {
+				log.V(logutil.LogLevel_Debug).Info("SyncOperation DB entry was no longer available, after AppSync.")
+			} else {
+				log.Error(innerErr, "unable to update the status of SyncOperation, after AppSync", "syncOperationID", syncOperationStatus.SyncOperation_id)
+				return shouldRetryTrue, innerErr
+			}
+		}
+	}
+
 	return shouldRetry, err
 }
 
+// generateSyncOperationStatus returns the status fields of the SyncOperation, based on the final state of the Argo CD sync
+// operation, and the error (if any) returned by AppSync.
+func generateSyncOperationStatus(dbSyncOperation db.SyncOperation, operationState *appv1.OperationState, syncErr error, log logr.Logger) *db.SyncOperation {
+
+	res := &db.SyncOperation{
+		SyncOperation_id: dbSyncOperation.SyncOperation_id,
+		Phase:            db.SyncOperation_Phase_Succeeded,
+		Started_at:       dbSyncOperation.Started_at,
+		Finished_at:      time.Now(),
+	}
+
+	if operationState != nil {
+
+		switch operationState.Phase {
+		case common.OperationRunning:
+			res.Phase = db.SyncOperation_Phase_Running
+		case common.OperationTerminating:
+			res.Phase = db.SyncOperation_Phase_Terminated
+		case common.OperationFailed, common.OperationError:
+			res.Phase = db.SyncOperation_Phase_Failed
+		}
+
+		res.Phase_message = operationState.Message
+
+		if !operationState.StartedAt.IsZero() {
+			res.Started_at = operationState.StartedAt.Time
+		}
+		if operationState.FinishedAt != nil {
+			res.Finished_at = operationState.FinishedAt.Time
+		}
+
+		if operationState.SyncResult != nil {
+			res.Synced_revision = operationState.SyncResult.Revision
+
+			if len(operationState.SyncResult.Resources) > 0 {
+				syncResult, err := sharedutil.CompressObject(operationState.SyncResult.Resources)
+				if err != nil {
+					log.Error(err, "unable to compress the sync result of the Argo CD operation")
+				} else {
+					res.Sync_result = syncResult
+				}
+			}
+		}
+	}
+
+	if syncErr != nil {
+		if res.Phase == db.SyncOperation_Phase_Succeeded {
+			res.Phase = db.SyncOperation_Phase_Failed
+		}
+		if res.Phase_message == "" {
+			res.Phase_message = syncErr.Error()
+		}
+	}
+
+	res.Phase_message = truncatePhaseMessage(res.Phase_message)
+
+	return res
+}
+
+// truncatePhaseMessage truncates the message to db.SyncOperationPhaseMessageLength bytes (the length that is verified
+// before the SyncOperation is updated), without splitting a multi-byte character.
+func truncatePhaseMessage(message string) string {
+
+	message = strings.ToValidUTF8(message, "?")
+
+	if len(message) <= db.SyncOperationPhaseMessageLength {
+		return message
+	}
+
+	end := db.SyncOperationPhaseMessageLength
+	for end > 0 && !utf8.RuneStart(message[end]) {
+		end--
+	}
+
+	return message[:end]
+}
+
+// generateTerminatedSyncOperationStatus returns the status fields of a SyncOperation that was terminated by the user
+// before it completed.
+func generateTerminatedSyncOperationStatus(dbSyncOperation db.SyncOperation) *db.SyncOperation {
+	return &db.SyncOperation{
+		SyncOperation_id: dbSyncOperation.SyncOperation_id,
+		Phase:            db.SyncOperation_Phase_Terminated,
+		Phase_message:    "the sync operation was terminated before it completed",
+		Started_at:       dbSyncOperation.Started_at,
+		Finished_at:      time.Now(),
+	}
+}
+
+// convertSyncOperationToAppSyncOptions returns the sync options (prune, dry run, resources, etc) that the user specified
+// in the GitOpsDeploymentSyncRun, as stored in the SyncOperation row.
+func convertSyncOperationToAppSyncOptions(dbSyncOperation db.SyncOperation) (utils.AppSyncOptions, error) {
+
+	res := utils.AppSyncOptions{
+		Prune:       dbSyncOperation.Prune,
+		DryRun:      dbSyncOperation.Dry_run,
+		Force:       dbSyncOperation.Force,
+		Replace:     dbSyncOperation.Replace,
+		SyncOptions: dbSyncOperation.GetSyncOptions(),
+	}
+
+	resources, err := dbSyncOperation.GetResources()
+	if err != nil {
+		return utils.AppSyncOptions{}, err
+	}
+
+	for _, resource := range resources {
+		res.Resources = append(res.Resources, appv1.SyncOperationResource{
+			Group:     resource.Group,
+			Kind:      resource.Kind,
+			Name:      resource.Name,
+			Namespace: resource.Namespace,
+		})
+	}
+
+	return res, nil
+}
+
+// convertSyncOperationToAppRollbackOptions returns the rollback target that the user specified in the rollbackTo field of
+// the GitOpsDeploymentSyncRun, as stored in the SyncOperation row.
+func convertSyncOperationToAppRollbackOptions(dbSyncOperation db.SyncOperation) (*utils.AppRollbackOptions, error) {
+
+	rollbackTo, err := dbSyncOperation.GetRollbackTo()
+	if err != nil {
+		return nil, err
+	}
+
+	if rollbackTo == nil {
+		return nil, fmt.Errorf("SyncOperation '%s' of a Rollback operation did not specify a rollback target", dbSyncOperation.SyncOperation_id)
+	}
+
+	return &utils.AppRollbackOptions{
+		ID:       rollbackTo.ID,
+		Revision: rollbackTo.Revision,
+		Prune:    dbSyncOperation.Prune,
+		DryRun:   dbSyncOperation.Dry_run,
+	}, nil
+}
+
 // processOperation_ManagedEnvironment handles an Operation that targets an Application.
 // Returns true if the task should be retried (eg due to failure), false otherwise.
 func processOperation_ManagedEnvironment(ctx context.Context, dbOperation db.Operation, crOperation operation.Operation,
@@ -930,7 +1188,14 @@ func processOperation_Application(ctx context.Context, dbOperation db.Operation,
 		return shouldRetryFalse, err
 	}
 
-	if specDiff != "" {
+	// If the Argo CD Application was staged on this instance by a migration, the migration is now complete: the
+	// Application row is hosted on this instance, so the status of the Argo CD Application should be reported.
+	_, completedMigration := app.Annotations[controllers.ArgoCDApplicationMigrationAnnotation]
+	if completedMigration {
+		delete(app.Annotations, controllers.ArgoCDApplicationMigrationAnnotation)
+	}
+
+	if specDiff != "" || completedMigration {
 		specFieldApp := &appv1.Application{}
 
 		if err := yaml.Unmarshal([]byte(dbApplication.Spec_field), specFieldApp); err != nil {
@@ -942,6 +1207,8 @@ func processOperation_Application(ctx context.Context, dbOperation db.Operation,
 
 		app.Spec.Destination = specFieldApp.Spec.Destination
 		app.Spec.Source = specFieldApp.Spec.Source
+		app.Spec.Sources = specFieldApp.Spec.Sources
+		app.Spec.IgnoreDifferences = specFieldApp.Spec.IgnoreDifferences
 		app.Spec.Project = specFieldApp.Spec.Project
 		app.Spec.SyncPolicy = specFieldApp.Spec.SyncPolicy
 
@@ -1227,12 +1494,23 @@ func ensureManagedEnvironmentExists(ctx context.Context, application db.Applicat
 	}
 
 	// B) Secret already exists, so compare
-	if reflect.DeepEqual(existingSecret.Data, expectedSecret.Data) {
+	expectedSourceSecretResourceVersion := expectedSecret.Annotations[controllers.SourceSecretResourceVersionAnnotation]
+	if reflect.DeepEqual(existingSecret.Data, expectedSecret.Data) &&
+		existingSecret.Annotations[controllers.SourceSecretResourceVersionAnnotation] == expectedSourceSecretResourceVersion {
 		// No work required, so exit.
 		return nil
 	}
 	existingSecret.Data = expectedSecret.Data
 
+	if expectedSourceSecretResourceVersion != "" {
+		if existingSecret.Annotations == nil {
+			existingSecret.Annotations = map[string]string{}
+		}
+		existingSecret.Annotations[controllers.SourceSecretResourceVersionAnnotation] = expectedSourceSecretResourceVersion
+	} else {
+		delete(existingSecret.Annotations, controllers.SourceSecretResourceVersionAnnotation)
+	}
+
 	// C) Secret exists, but is different from what is expected, so update it.
 	if err := opConfig.eventClient.Update(ctx, existingSecret); err != nil {
 		log.Error(err, "unable to update existing Argo CD cluster secret")
@@ -1286,6 +1564,13 @@ func generateExpectedClusterSecret(ctx context.Context, application db.Applicati
 		}
 	}
 
+	// If the cluster credentials reference the user's Secret (rather than containing a copy of the token), then read
+	// the token from the Secret. This ensures that changes to the Secret (e.g. token rotation) are picked up.
+	sourceSecretResourceVersion, err := controllers.ResolveClusterCredentialsSecretRef(ctx, opConfig.secretRefClient, clusterCredentials)
+	if err != nil {
+		return corev1.Secret{}, deleteSecret_false, err
+	}
+
 	if strings.Contains(clusterCredentials.Host, "?") || strings.Contains(clusterCredentials.Host, "&") {
 		return corev1.Secret{}, deleteSecret_false,
 			fmt.Errorf("the Kubernetes API URL contained unsupported characters: %v", clusterCredentials.Host)
@@ -1341,6 +1626,12 @@ func generateExpectedClusterSecret(ctx context.Context, application db.Applicati
 		managedEnvironmentSecret.Data["namespaces"] = ([]byte)(clusterCredentials.Namespaces)
 	}
 
+	if sourceSecretResourceVersion != "" {
+		managedEnvironmentSecret.Annotations = map[string]string{
+			controllers.SourceSecretResourceVersionAnnotation: sourceSecretResourceVersion,
+		}
+	}
+
 	return managedEnvironmentSecret, deleteSecret_false, nil
 
 }
@@ -1397,6 +1688,25 @@ func buildAppProject(ctx context.Context, dbOperation db.Operation, opConfig ope
 		Namespace: "*",
 	})
 
+	var appProjectSyncWindows []db.AppProjectSyncWindow
+	if err := opConfig.dbQueries.ListAppProjectSyncWindowByClusterUserId(ctx, dbOperation.Operation_owner_user_id, &appProjectSyncWindows); err != nil {
+		log.Error(err, "unable to list appProjectSyncWindows by cluster user id")
+		return nil, err
+	}
+
+	// Each sync window applies only to the Argo CD Application of the GitOpsDeployment that defined it
+	var syncWindows appv1.SyncWindows
+	for _, appProjectSyncWindow := range appProjectSyncWindows {
+		syncWindows = append(syncWindows, &appv1.SyncWindow{
+			Kind:         appProjectSyncWindow.Kind,
+			Schedule:     appProjectSyncWindow.Schedule,
+			Duration:     appProjectSyncWindow.Duration,
+			Applications: []string{appProjectSyncWindow.Application_name},
+			ManualSync:   appProjectSyncWindow.Manual_sync,
+			TimeZone:     appProjectSyncWindow.Time_zone,
+		})
+	}
+
 	appProject := &appv1.AppProject{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: appProjectPrefix + dbOperation.Operation_owner_user_id,
@@ -1408,6 +1718,7 @@ func buildAppProject(ctx context.Context, dbOperation db.Operation, opConfig ope
 		Spec: appv1.AppProjectSpec{
 			SourceRepos:  repoURLs,
 			Destinations: destinations,
+			SyncWindows:  syncWindows,
 		},
 	}
 
@@ -1461,5 +1772,12 @@ func appProjectEqual(existingAppProject, generatedAppProject *appv1.AppProject)
 		}
 	}
 
+	// The sync windows are generated in a consistent order, so they can be compared directly
+	if len(existingAppProject.Spec.SyncWindows) != 0 || len(generatedAppProject.Spec.SyncWindows) != 0 {
+		if !reflect.DeepEqual(existingAppProject.Spec.SyncWindows, generatedAppProject.Spec.SyncWindows) {
+			return false
+		}
+	}
+
 	return true
 }
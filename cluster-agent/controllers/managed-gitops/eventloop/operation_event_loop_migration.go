@@ -0,0 +1,224 @@
+package eventloop
+
+import (
+	"context"
+	"fmt"
+
+	appv1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
+	operation "github.com/redhat-appstudio/managed-gitops/backend-shared/apis/managed-gitops/v1alpha1"
+	"github.com/redhat-appstudio/managed-gitops/backend-shared/db"
+	argosharedutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util/argocd"
+	logutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util/log"
+	"github.com/redhat-appstudio/managed-gitops/cluster-agent/controllers"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// Migration of an Application row between GitOps engine instances
+//
+// An Application row is migrated from its current ('source') engine instance to a 'target' engine instance by
+// 'gitopsctl db migrate-application', which creates the following Operations:
+// 0) Before staging, the owner of the Application is given a ClusterAccess to the managed environment on the target
+//    instance, and their RepositoryCredentials rows are moved to the target instance, with a RepositoryCredentials
+//    Operation on the target instance for each.
+// 1) An ApplicationMigrationStage Operation on the target instance: we create a copy of the Argo CD Application on the
+//    target instance, with automated sync disabled. Since the copy has the same name, Argo CD on the target instance
+//    recognizes the resources that were deployed by the source instance.
+// 2) Once the copy is Synced/Healthy, the Application row is updated to point to the target instance, with a single
+//    conditional update on the source instance.
+// 3) An ApplicationMigrationOrphan Operation on the source instance: we delete the Argo CD Application from the source
+//    instance, without deleting its resources.
+// 4) An Application Operation on the target instance, which restores the sync policy of the Argo CD Application.
+//
+// Both migration Operation types are only valid for an instance that is NOT hosting the Application row, which ensures
+// that they can never disable the sync of (or orphan) the Argo CD Application that is actually in use.
+//
+// While the Argo CD Application is staged on the target instance (and after it has been orphaned on the source
+// instance), the namespace reconciler of each instance must not delete it: see cleanOrphanedCRsfromCluster_Applications.
+
+// processOperation_ApplicationMigrationStage creates (or updates) a copy of the Argo CD Application of the Application
+// row on the Operation's GitOps engine instance, with automated sync disabled.
+// returns shouldRetry, error
+func processOperation_ApplicationMigrationStage(ctx context.Context, dbOperation db.Operation, crOperation operation.Operation, opConfig operationConfig) (bool, error) {
+
+	if dbOperation.Resource_id == "" {
+		return shouldRetryTrue, fmt.Errorf("resource id was nil while processing operation: " + crOperation.Name)
+	}
+
+	dbApplication := &db.Application{
+		Application_id: dbOperation.Resource_id,
+	}
+
+	log := opConfig.log.WithValues("applicationID", dbApplication.Application_id)
+
+	if err := opConfig.dbQueries.GetApplicationById(ctx, dbApplication); err != nil {
+		if db.IsResultNotFoundError(err) {
+			return shouldRetryFalse, fmt.Errorf("unable to migrate Application '%s': the Application no longer exists", dbApplication.Application_id)
+		}
+		log.Error(err, "Unable to retrieve database Application row from database")
+		return shouldRetryTrue, err
+	}
+
+	if dbApplication.Engine_instance_inst_id == dbOperation.Instance_id {
+		return shouldRetryFalse, fmt.Errorf("unable to migrate Application '%s': it is already hosted on GitOps engine instance '%s'",
+			dbApplication.Application_id, dbOperation.Instance_id)
+	}
+
+	if dbApplication.Managed_environment_id == "" {
+		return shouldRetryFalse, fmt.Errorf("unable to migrate Application '%s': the Application does not have a valid managed environment",
+			dbApplication.Application_id)
+	}
+
+	if shouldRetry, err := createOrUpdateAppProjectWithValidation(ctx, dbOperation, opConfig, log); err != nil {
+		log.Error(err, "failed to call createOrUpdateAppProjectWithValidation function")
+		return shouldRetry, err
+	}
+
+	log = log.WithValues("argoCDApplicationName", dbApplication.Name)
+
+	stagedApp, err := generateStagedMigrationApplication(*dbApplication, opConfig.argoCDNamespace.Name)
+	if err != nil {
+		log.Error(err, "SEVERE: unable to unmarshal application spec field on staging Application migration")
+		return shouldRetryFalse, err
+	}
+
+	// Before we create the application, make sure that the managed environment exists that the application points to
+	if stagedApp.Spec.Destination.Name != argosharedutil.ArgoCDDefaultDestinationInCluster {
+		if err := ensureManagedEnvironmentExists(ctx, *dbApplication, opConfig, log); err != nil {
+			log.Error(err, "unable to ensure that managed environment exists")
+			return shouldRetryTrue, err
+		}
+	}
+
+	app := &appv1.Application{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      stagedApp.Name,
+			Namespace: stagedApp.Namespace,
+		},
+	}
+	if err := opConfig.eventClient.Get(ctx, client.ObjectKeyFromObject(app), app); err != nil {
+		if !apierr.IsNotFound(err) {
+			log.Error(err, "Unexpected error when attempting to retrieve Argo CD Application CR")
+			return shouldRetryTrue, err
+		}
+
+		if err := opConfig.eventClient.Create(ctx, stagedApp, &client.CreateOptions{}); err != nil {
+			log.Error(err, "Unable to create staged Argo CD Application CR")
+			return shouldRetryTrue, err
+		}
+		logutil.LogAPIResourceChangeEvent(stagedApp.Namespace, stagedApp.Name, stagedApp, logutil.ResourceCreated, log)
+
+		log.Info("Created staged Argo CD Application for migration")
+		return shouldRetryFalse, nil
+	}
+
+	// The staged Application already exists (for example, a previous migration attempt was interrupted): ensure it is
+	// consistent with the Application row.
+	if app.Labels == nil {
+		app.Labels = map[string]string{}
+	}
+	if app.Annotations == nil {
+		app.Annotations = map[string]string{}
+	}
+	app.Labels[controllers.ArgoCDApplicationDatabaseIDLabel] = dbApplication.Application_id
+	app.Annotations[controllers.ArgoCDApplicationMigrationAnnotation] = stagedApp.Annotations[controllers.ArgoCDApplicationMigrationAnnotation]
+	app.Spec = stagedApp.Spec
+
+	if err := opConfig.eventClient.Update(ctx, app); err != nil {
+		log.Error(err, "Unable to update staged Argo CD Application CR")
+		return shouldRetryTrue, err
+	}
+	logutil.LogAPIResourceChangeEvent(app.Namespace, app.Name, app, logutil.ResourceModified, log)
+
+	log.Info("Updated staged Argo CD Application for migration")
+
+	return shouldRetryFalse, nil
+}
+
+// generateStagedMigrationApplication returns the Argo CD Application that is staged on a GitOps engine instance (in
+// 'argoCDNamespace') when the Application row is migrated to that instance: this is the Argo CD Application of the
+// Application row, with automated sync disabled.
+func generateStagedMigrationApplication(dbApplication db.Application, argoCDNamespace string) (*appv1.Application, error) {
+
+	app := &appv1.Application{}
+
+	// Copy the contents of the Spec_field database column, into the Spec field of the Argo CD Application CR
+	if err := yaml.Unmarshal([]byte(dbApplication.Spec_field), app); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal spec field of Application '%s': %w", dbApplication.Application_id, err)
+	}
+
+	// The name and namespace are not taken from the spec field: it contains the namespace of the engine instance that
+	// is hosting the Application.
+	app.Name = dbApplication.Name
+	app.Namespace = argoCDNamespace
+
+	app.Labels = map[string]string{controllers.ArgoCDApplicationDatabaseIDLabel: dbApplication.Application_id}
+	app.Annotations = map[string]string{controllers.ArgoCDApplicationMigrationAnnotation: "stage"}
+
+	// Disable automated sync: the resources are still being synced by the engine instance that is hosting the Application.
+	if app.Spec.SyncPolicy != nil {
+		app.Spec.SyncPolicy.Automated = nil
+	}
+
+	return app, nil
+}
+
+// processOperation_ApplicationMigrationOrphan deletes the Argo CD Application of the Application row from the Operation's
+// GitOps engine instance, without deleting the resources that were deployed by it.
+// returns shouldRetry, error
+func processOperation_ApplicationMigrationOrphan(ctx context.Context, dbOperation db.Operation, crOperation operation.Operation, opConfig operationConfig) (bool, error) {
+
+	if dbOperation.Resource_id == "" {
+		return shouldRetryTrue, fmt.Errorf("resource id was nil while processing operation: " + crOperation.Name)
+	}
+
+	dbApplication := &db.Application{
+		Application_id: dbOperation.Resource_id,
+	}
+
+	log := opConfig.log.WithValues("applicationID", dbApplication.Application_id)
+
+	if err := opConfig.dbQueries.GetApplicationById(ctx, dbApplication); err != nil {
+		if db.IsResultNotFoundError(err) {
+			// The Application row no longer exists: the Argo CD Application (and its resources) will instead be deleted
+			// as part of the normal Application deletion handling.
+			log.Info("Application row no longer exists, so there is no Argo CD Application to orphan")
+			return shouldRetryFalse, nil
+		}
+		log.Error(err, "Unable to retrieve database Application row from database")
+		return shouldRetryTrue, err
+	}
+
+	if dbApplication.Engine_instance_inst_id == dbOperation.Instance_id {
+		return shouldRetryFalse, fmt.Errorf("unable to orphan the Argo CD Application of Application '%s': the Application is hosted on GitOps engine instance '%s'",
+			dbApplication.Application_id, dbOperation.Instance_id)
+	}
+
+	// Find the Application that has the corresponding databaseID label
+	list := appv1.ApplicationList{}
+	req, err := labels.NewRequirement(controllers.ArgoCDApplicationDatabaseIDLabel, selection.Equals, []string{dbApplication.Application_id})
+	if err != nil {
+		log.Error(err, "SEVERE: invalid label requirement")
+		return shouldRetryFalse, err
+	}
+	if err := opConfig.eventClient.List(ctx, &list, &client.ListOptions{
+		Namespace:     opConfig.argoCDNamespace.Name,
+		LabelSelector: labels.NewSelector().Add(*req),
+	}); err != nil {
+		log.Error(err, "unable to complete Argo CD Application list")
+		return shouldRetryTrue, err
+	}
+
+	for _, item := range list.Items {
+		if err := controllers.OrphanArgoCDApplication(ctx, item, opConfig.eventClient, log); err != nil {
+			log.Error(err, "unable to orphan Argo CD Application")
+			return shouldRetryTrue, err
+		}
+	}
+
+	return shouldRetryFalse, nil
+}
@@ -0,0 +1,206 @@
+package eventloop
+
+import (
+	"context"
+
+	appv1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	operation "github.com/redhat-appstudio/managed-gitops/backend-shared/apis/managed-gitops/v1alpha1"
+	"github.com/redhat-appstudio/managed-gitops/backend-shared/db"
+	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/fauxargocd"
+	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/tests"
+	"github.com/redhat-appstudio/managed-gitops/cluster-agent/controllers"
+	goyaml "gopkg.in/yaml.v2"
+	corev1 "k8s.io/api/core/v1"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// generateTestSpecField returns an Application spec field, as generated by the backend, for an Argo CD Application in the given namespace.
+func generateTestSpecField(name string, namespace string, automated bool) string {
+
+	fauxApplication := fauxargocd.FauxApplication{
+		FauxTypeMeta: fauxargocd.FauxTypeMeta{
+			Kind:       "Application",
+			APIVersion: "argoproj.io/v1alpha1",
+		},
+		FauxObjectMeta: fauxargocd.FauxObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: fauxargocd.FauxApplicationSpec{
+			Source: fauxargocd.ApplicationSource{
+				RepoURL: "https://github.com/redhat-appstudio/managed-gitops",
+				Path:    "resources/test-data/sample-gitops-repository/environments/overlays/dev",
+			},
+			Destination: fauxargocd.ApplicationDestination{
+				Name:      "in-cluster",
+				Namespace: "test-fake-namespace",
+			},
+			Project: "default",
+		},
+	}
+
+	if automated {
+		fauxApplication.Spec.SyncPolicy = &fauxargocd.SyncPolicy{
+			Automated: &fauxargocd.SyncPolicyAutomated{Prune: true},
+		}
+	}
+
+	specFieldBytes, err := goyaml.Marshal(fauxApplication)
+	Expect(err).ToNot(HaveOccurred())
+
+	return string(specFieldBytes)
+}
+
+var _ = Describe("Application migration Operation tests", func() {
+
+	Context("generateStagedMigrationApplication", func() {
+
+		It("should generate the Argo CD Application in the given namespace, with automated sync disabled", func() {
+
+			dbApplication := db.Application{
+				Application_id: "test-application-id",
+				Name:           "test-application",
+				Spec_field:     generateTestSpecField("test-application", "source-argocd-namespace", true),
+			}
+
+			app, err := generateStagedMigrationApplication(dbApplication, "target-argocd-namespace")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(app.Name).To(Equal("test-application"))
+			Expect(app.Namespace).To(Equal("target-argocd-namespace"))
+			Expect(app.Labels).To(HaveKeyWithValue(controllers.ArgoCDApplicationDatabaseIDLabel, dbApplication.Application_id))
+			Expect(app.Annotations).To(HaveKey(controllers.ArgoCDApplicationMigrationAnnotation))
+			Expect(app.Spec.Source.RepoURL).To(Equal("https://github.com/redhat-appstudio/managed-gitops"))
+			Expect(app.Spec.SyncPolicy).ToNot(BeNil())
+			Expect(app.Spec.SyncPolicy.Automated).To(BeNil())
+		})
+
+		It("should return an error if the spec field is invalid", func() {
+
+			_, err := generateStagedMigrationApplication(db.Application{Spec_field: "{invalid"}, "target-argocd-namespace")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("Processing ApplicationMigrationStage and ApplicationMigrationOrphan Operations", func() {
+
+		var ctx context.Context
+		var dbQueries db.AllDatabaseQueries
+		var k8sClient client.WithWatch
+		var opConfig operationConfig
+		var argocdNamespace *corev1.Namespace
+		var sourceEngineInstance *db.GitopsEngineInstance
+		var targetEngineInstance *db.GitopsEngineInstance
+		var application *db.Application
+
+		newOperation := func(resourceType db.OperationResourceType, instance *db.GitopsEngineInstance) db.Operation {
+			return db.Operation{
+				Operation_id:            "test-operation",
+				Instance_id:             instance.Gitopsengineinstance_id,
+				Resource_id:             application.Application_id,
+				Resource_type:           resourceType,
+				Operation_owner_user_id: "test-user",
+			}
+		}
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			logger := log.FromContext(ctx)
+
+			err := db.SetupForTestingDBGinkgo()
+			Expect(err).ToNot(HaveOccurred())
+
+			dbQueries, err = db.NewUnsafePostgresDBQueries(true, true)
+			Expect(err).ToNot(HaveOccurred())
+
+			scheme, argoNamespace, kubesystemNamespace, workspace, err := tests.GenericTestSetup()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(appv1.AddToScheme(scheme)).To(Succeed())
+			argocdNamespace = argoNamespace
+
+			k8sClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(workspace, argocdNamespace, kubesystemNamespace).Build()
+
+			var managedEnvironment *db.ManagedEnvironment
+			var engineCluster *db.GitopsEngineCluster
+			_, managedEnvironment, engineCluster, sourceEngineInstance, _, err = db.CreateSampleData(dbQueries)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("creating a target GitOps engine instance, in the Argo CD namespace of the fake client")
+			targetEngineInstance = &db.GitopsEngineInstance{
+				Gitopsengineinstance_id: "test-target-engine-instance",
+				Namespace_name:          argocdNamespace.Name,
+				Namespace_uid:           string(argocdNamespace.UID),
+				EngineCluster_id:        engineCluster.Gitopsenginecluster_id,
+			}
+			Expect(dbQueries.CreateGitopsEngineInstance(ctx, targetEngineInstance)).To(Succeed())
+
+			By("creating an Application that is hosted on the source instance")
+			application = &db.Application{
+				Application_id:          "test-migrated-application",
+				Name:                    "test-migrated-application",
+				Spec_field:              generateTestSpecField("test-migrated-application", sourceEngineInstance.Namespace_name, true),
+				Engine_instance_inst_id: sourceEngineInstance.Gitopsengineinstance_id,
+				Managed_environment_id:  managedEnvironment.Managedenvironment_id,
+			}
+			Expect(dbQueries.CreateApplication(ctx, application)).To(Succeed())
+
+			opConfig = operationConfig{
+				dbQueries:       dbQueries,
+				argoCDNamespace: *argocdNamespace,
+				eventClient:     k8sClient,
+				log:             logger,
+			}
+		})
+
+		AfterEach(func() {
+			dbQueries.CloseDatabase()
+		})
+
+		It("should stage a sync-disabled copy of the Argo CD Application on the target instance, and then orphan it", func() {
+
+			By("processing an ApplicationMigrationStage Operation for the target instance")
+			shouldRetry, err := processOperation_ApplicationMigrationStage(ctx, newOperation(db.OperationResourceType_ApplicationMigrationStage, targetEngineInstance),
+				operation.Operation{}, opConfig)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(shouldRetry).To(BeFalse())
+
+			app := &appv1.Application{ObjectMeta: metav1.ObjectMeta{Name: application.Name, Namespace: argocdNamespace.Name}}
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(app), app)).To(Succeed())
+			Expect(app.Labels).To(HaveKeyWithValue(controllers.ArgoCDApplicationDatabaseIDLabel, application.Application_id))
+			Expect(app.Annotations).To(HaveKey(controllers.ArgoCDApplicationMigrationAnnotation))
+			Expect(app.Spec.SyncPolicy.Automated).To(BeNil())
+
+			By("processing an ApplicationMigrationOrphan Operation for the target instance, the copy should be removed")
+			shouldRetry, err = processOperation_ApplicationMigrationOrphan(ctx, newOperation(db.OperationResourceType_ApplicationMigrationOrphan, targetEngineInstance),
+				operation.Operation{}, opConfig)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(shouldRetry).To(BeFalse())
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(app), app)
+			Expect(apierr.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("should not stage or orphan the Argo CD Application on the instance that is hosting the Application", func() {
+
+			By("moving the Application row to the target instance")
+			application.Engine_instance_inst_id = targetEngineInstance.Gitopsengineinstance_id
+			Expect(dbQueries.UpdateApplication(ctx, application)).To(Succeed())
+
+			shouldRetry, err := processOperation_ApplicationMigrationStage(ctx, newOperation(db.OperationResourceType_ApplicationMigrationStage, targetEngineInstance),
+				operation.Operation{}, opConfig)
+			Expect(err).To(HaveOccurred())
+			Expect(shouldRetry).To(BeFalse())
+
+			shouldRetry, err = processOperation_ApplicationMigrationOrphan(ctx, newOperation(db.OperationResourceType_ApplicationMigrationOrphan, targetEngineInstance),
+				operation.Operation{}, opConfig)
+			Expect(err).To(HaveOccurred())
+			Expect(shouldRetry).To(BeFalse())
+		})
+	})
+})
@@ -4,7 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/go-logr/logr"
 	"github.com/golang/mock/gomock"
@@ -26,12 +29,15 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	appv1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
+	"github.com/argoproj/gitops-engine/pkg/sync/common"
 	managedgitopsv1alpha1 "github.com/redhat-appstudio/managed-gitops/backend-shared/apis/managed-gitops/v1alpha1"
 	"github.com/redhat-appstudio/managed-gitops/backend-shared/db"
 	dbutil "github.com/redhat-appstudio/managed-gitops/backend-shared/db/util"
+	sharedutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util"
 	argosharedutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util/argocd"
 	sharedoperations "github.com/redhat-appstudio/managed-gitops/backend-shared/util/operations"
 	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/tests"
+	"github.com/redhat-appstudio/managed-gitops/cluster-agent/controllers"
 
 	argocdoperatorv1alph1 "github.com/argoproj-labs/argocd-operator/api/v1alpha1"
 	mockLog "github.com/redhat-appstudio/managed-gitops/backend-shared/util/log/mocks"
@@ -93,6 +99,7 @@ var _ = Describe("Operation Controller", func() {
 				dbQueries:         dbQueries,
 				argoCDNamespace:   *argoCDNamespace,
 				eventClient:       k8sClient,
+				secretRefClient:   k8sClient,
 				credentialService: nil,
 				log:               logger,
 				syncFuncs:         nil,
@@ -146,6 +153,85 @@ var _ = Describe("Operation Controller", func() {
 
 		})
 
+		It("generateExpectedClusterSecret should read the token from the referenced Secret, if the cluster credentials reference a Secret", func() {
+
+			By("creating the Secret that is referenced by the cluster credentials")
+			kubeconfig := `
+apiVersion: v1
+kind: Config
+clusters:
+- cluster:
+    server: https://my-cluster-url.com
+  name: my-cluster
+contexts:
+- context:
+    cluster: my-cluster
+    user: my-user
+  name: my-context
+users:
+- name: my-user
+  user:
+    token: token-from-secret
+`
+			managedEnvSecret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "my-managed-env-secret",
+					Namespace: workspace.Name,
+				},
+				Type: sharedutil.ManagedEnvironmentSecretType,
+				Data: map[string][]byte{
+					sharedutil.ManagedEnvironmentSecretKubeconfigKey: []byte(kubeconfig),
+				},
+			}
+
+			By("creating the referenced Secret on the API cluster, which is not the cluster that Argo CD is on")
+			apiClusterClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(workspace).Build()
+			Expect(apiClusterClient.Create(ctx, managedEnvSecret)).To(Succeed())
+			opConfigVal.secretRefClient = apiClusterClient
+
+			clusterCredentials := db.ClusterCredentials{
+				Clustercredentials_cred_id:  "test-cluster-creds-test",
+				Host:                        "https://my-cluster-url.com",
+				Kube_config:                 "kube-config",
+				Kube_config_context:         "kube-config-context",
+				Serviceaccount_ns:           "Serviceaccount_ns",
+				Secret_ref_namespace:        managedEnvSecret.Namespace,
+				Secret_ref_name:             managedEnvSecret.Name,
+				Secret_ref_resource_version: managedEnvSecret.ResourceVersion,
+			}
+			err := dbQueries.CreateClusterCredentials(ctx, &clusterCredentials)
+			Expect(err).ToNot(HaveOccurred())
+
+			managedEnvironment := db.ManagedEnvironment{
+				Managedenvironment_id: "test-managed-env",
+				Clustercredentials_id: clusterCredentials.Clustercredentials_cred_id,
+				Name:                  "my env",
+			}
+			err = dbQueries.CreateManagedEnvironment(ctx, &managedEnvironment)
+			Expect(err).ToNot(HaveOccurred())
+
+			applicationDB := &db.Application{
+				Application_id:          "test-my-application",
+				Name:                    name,
+				Spec_field:              "{}",
+				Engine_instance_inst_id: gitopsEngineInstance.Gitopsengineinstance_id,
+				Managed_environment_id:  managedEnvironment.Managedenvironment_id,
+			}
+			err = dbQueries.CreateApplication(ctx, applicationDB)
+			Expect(err).ToNot(HaveOccurred())
+
+			secret, shouldDelete, err := generateExpectedClusterSecret(ctx, *applicationDB, opConfigVal)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(shouldDelete).To(BeFalse())
+			Expect(string(secret.Data["config"])).To(ContainSubstring("token-from-secret"))
+			Expect(secret.Annotations[controllers.SourceSecretResourceVersionAnnotation]).To(Equal(managedEnvSecret.ResourceVersion))
+
+			By("deleting the referenced Secret, and verifying an error is returned")
+			Expect(apiClusterClient.Delete(ctx, managedEnvSecret)).To(Succeed())
+			_, _, err = generateExpectedClusterSecret(ctx, *applicationDB, opConfigVal)
+			Expect(err).To(HaveOccurred())
+		})
+
 		It("generateExpectedClusterSecret should reject an invalid URL containing query parameters", func() {
 
 			clusterCredentials := db.ClusterCredentials{
@@ -2093,6 +2179,55 @@ var _ = Describe("Operation Controller", func() {
 
 			})
 
+			It("Verify that the sync windows of the user are added to the AppProject", func() {
+				defer dbQueries.CloseDatabase()
+				defer testTeardown()
+
+				By("creating the ClusterUser and AppProjectSyncWindow rows in the database")
+				err = dbQueries.CreateClusterUser(ctx, testClusterUser)
+				Expect(err).ToNot(HaveOccurred())
+
+				appProjectSyncWindow := db.AppProjectSyncWindow{
+					AppprojectSyncwindowID: "test-app-syncwindow-id-1",
+					Clusteruser_id:         testClusterUser.Clusteruser_id,
+					Application_name:       "gitopsdepl-test-sync-window",
+					Kind:                   "deny",
+					Schedule:               "0 9 * * 1-5",
+					Duration:               "8h",
+					Manual_sync:            true,
+					Time_zone:              "Europe/London",
+				}
+				err = dbQueries.CreateAppProjectSyncWindow(ctx, &appProjectSyncWindow)
+				Expect(err).ToNot(HaveOccurred())
+
+				By("building the AppProject of the user")
+				dbOperation := db.Operation{
+					Operation_owner_user_id: testClusterUser.Clusteruser_id,
+				}
+				opConfig := operationConfig{
+					dbQueries:       dbQueries,
+					argoCDNamespace: corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}},
+					eventClient:     k8sClient,
+					log:             logger,
+				}
+
+				appProject, err := buildAppProject(ctx, dbOperation, opConfig, logger)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(appProject).ToNot(BeNil())
+
+				By("verifying the sync window is scoped to the Argo CD Application of the GitOpsDeployment")
+				Expect(appProject.Spec.SyncWindows).To(Equal(appv1.SyncWindows{
+					{
+						Kind:         "deny",
+						Schedule:     "0 9 * * 1-5",
+						Duration:     "8h",
+						Applications: []string{"gitopsdepl-test-sync-window"},
+						ManualSync:   true,
+						TimeZone:     "Europe/London",
+					},
+				}))
+			})
+
 			It("Verify appProjectEqual function works as expected", func() {
 				var isAppProjectEqual bool
 
@@ -2158,6 +2293,29 @@ var _ = Describe("Operation Controller", func() {
 				isAppProjectEqual = appProjectEqual(existingAppProject, generatedAppProject)
 				Expect(isAppProjectEqual).To(BeTrue())
 
+				By("verify whether existingAppProject and generatedAppProject have different SyncWindows and it should return false")
+				generatedAppProject.Spec.SyncWindows = appv1.SyncWindows{
+					{Kind: "deny", Schedule: "0 9 * * 1-5", Duration: "8h", Applications: []string{"gitopsdepl-1"}},
+				}
+
+				isAppProjectEqual = appProjectEqual(existingAppProject, generatedAppProject)
+				Expect(isAppProjectEqual).To(BeFalse())
+
+				existingAppProject.Spec.SyncWindows = appv1.SyncWindows{
+					{Kind: "deny", Schedule: "0 9 * * 1-5", Duration: "4h", Applications: []string{"gitopsdepl-1"}},
+				}
+
+				isAppProjectEqual = appProjectEqual(existingAppProject, generatedAppProject)
+				Expect(isAppProjectEqual).To(BeFalse())
+
+				By("verify whether existingAppProject and generatedAppProject have the same SyncWindows and it should return true")
+				existingAppProject.Spec.SyncWindows = appv1.SyncWindows{
+					{Kind: "deny", Schedule: "0 9 * * 1-5", Duration: "8h", Applications: []string{"gitopsdepl-1"}},
+				}
+
+				isAppProjectEqual = appProjectEqual(existingAppProject, generatedAppProject)
+				Expect(isAppProjectEqual).To(BeTrue())
+
 			})
 
 		})
@@ -2331,8 +2489,17 @@ var _ = Describe("Operation Controller", func() {
 
 				By("verify there is no retry for a successful sync")
 				task.syncFuncs = &syncFuncs{
-					appSync: func(ctx context.Context, s1, s2, s3 string, c client.Client, cs *utils.CredentialService, b bool) error {
-						return nil
+					appSync: func(ctx context.Context, s1, s2, s3 string, c client.Client, cs *utils.CredentialService, b bool, o utils.AppSyncOptions) (*appv1.OperationState, error) {
+						return &appv1.OperationState{
+							Phase:   common.OperationSucceeded,
+							Message: "successfully synced (all tasks run)",
+							SyncResult: &appv1.SyncOperationResult{
+								Revision: "f0b2ab8c8e8a4b1f8d6e3d3c2b1a0f9e8d7c6b5a",
+								Resources: appv1.ResourceResults{
+									{Kind: "ConfigMap", Namespace: "test", Name: "my-config-map", Status: common.ResultCodeSynced},
+								},
+							},
+						}, nil
 					},
 					refreshApp: refreshApplication,
 				}
@@ -2343,6 +2510,86 @@ var _ = Describe("Operation Controller", func() {
 
 				By("verify if the refresh annotation was added")
 				Expect(<-refreshAnnotationFound).To(Equal(struct{}{}))
+
+				By("verify the outcome of the sync was recorded in the SyncOperation row")
+				err = dbQueries.GetSyncOperationById(ctx, &syncOperation)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(syncOperation.Phase).To(Equal(db.SyncOperation_Phase_Succeeded))
+				Expect(syncOperation.Phase_message).To(Equal("successfully synced (all tasks run)"))
+				Expect(syncOperation.Synced_revision).To(Equal("f0b2ab8c8e8a4b1f8d6e3d3c2b1a0f9e8d7c6b5a"))
+				Expect(syncOperation.Started_at.IsZero()).To(BeFalse())
+				Expect(syncOperation.Finished_at.IsZero()).To(BeFalse())
+				Expect(syncOperation.Sync_result).ToNot(BeEmpty())
+			})
+
+			It("should roll back the Application for a Rollback Operation", func() {
+
+				By("create a SyncOperation with a rollback target in the database")
+				historyID := int64(1)
+				syncOperation := db.SyncOperation{
+					SyncOperation_id:    "test-syncoperation",
+					Application_id:      applicationDB.Application_id,
+					DeploymentNameField: "test",
+					DesiredState:        db.SyncOperation_DesiredState_Running,
+					Prune:               true,
+				}
+				err = syncOperation.SetRollbackTo(&db.SyncOperationRollback{ID: &historyID})
+				Expect(err).ToNot(HaveOccurred())
+				err = dbQueries.CreateSyncOperation(ctx, &syncOperation)
+				Expect(err).ToNot(HaveOccurred())
+
+				By("create Operation DB row of type Rollback, and CR, for the SyncOperation")
+				operationDB := &db.Operation{
+					Operation_id:            "test-operation",
+					Instance_id:             gitopsEngineInstanceID,
+					Resource_id:             syncOperation.SyncOperation_id,
+					Resource_type:           db.OperationResourceType_Rollback,
+					State:                   db.OperationState_Waiting,
+					Operation_owner_user_id: testClusterUser.Clusteruser_id,
+				}
+				err = dbQueries.CreateOperation(ctx, operationDB, operationDB.Operation_owner_user_id)
+				Expect(err).ToNot(HaveOccurred())
+
+				operationCR := &managedgitopsv1alpha1.Operation{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      name,
+						Namespace: namespace,
+					},
+					Spec: managedgitopsv1alpha1.OperationSpec{
+						OperationID: operationDB.Operation_id,
+					},
+				}
+				err = task.event.client.Create(ctx, operationCR)
+				Expect(err).ToNot(HaveOccurred())
+
+				By("verify the Application is rolled back rather than synced")
+				var rollbackOptions *utils.AppRollbackOptions
+				task.syncFuncs = &syncFuncs{
+					appSync: func(ctx context.Context, s1, s2, s3 string, c client.Client, cs *utils.CredentialService, b bool, o utils.AppSyncOptions) (*appv1.OperationState, error) {
+						return nil, fmt.Errorf("unexpected sync of a rollback operation")
+					},
+					appRollback: func(ctx context.Context, s1, s2 string, c client.Client, cs *utils.CredentialService, o utils.AppRollbackOptions) (*appv1.OperationState, error) {
+						rollbackOptions = &o
+						return &appv1.OperationState{
+							Phase:   common.OperationSucceeded,
+							Message: "successfully synced (all tasks run)",
+						}, nil
+					},
+					refreshApp: refreshApplication,
+				}
+
+				retry, err := task.PerformTask(ctx)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(retry).To(BeFalse())
+
+				Expect(rollbackOptions).ToNot(BeNil())
+				Expect(*rollbackOptions.ID).To(Equal(historyID))
+				Expect(rollbackOptions.Prune).To(BeTrue())
+
+				By("verify the outcome of the rollback was recorded in the SyncOperation row")
+				err = dbQueries.GetSyncOperationById(ctx, &syncOperation)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(syncOperation.Phase).To(Equal(db.SyncOperation_Phase_Succeeded))
 			})
 
 			It("should return an error and retry if the sync fails", func() {
@@ -2364,8 +2611,8 @@ var _ = Describe("Operation Controller", func() {
 				By("check if the sync failed error is returned with retry")
 				expectedErr := "sync failed due to xyz reason"
 				task.syncFuncs = &syncFuncs{
-					appSync: func(ctx context.Context, s1, s2, s3 string, c client.Client, cs *utils.CredentialService, b bool) error {
-						return fmt.Errorf(expectedErr)
+					appSync: func(ctx context.Context, s1, s2, s3 string, c client.Client, cs *utils.CredentialService, b bool, o utils.AppSyncOptions) (*appv1.OperationState, error) {
+						return nil, fmt.Errorf(expectedErr)
 					},
 					refreshApp: refreshApplication,
 				}
@@ -2376,6 +2623,12 @@ var _ = Describe("Operation Controller", func() {
 
 				By("verify if the refresh annotation was added")
 				Expect(<-refreshAnnotationFound).To(Equal(struct{}{}))
+
+				By("verify the failure was recorded in the SyncOperation row")
+				err = dbQueries.GetSyncOperationById(ctx, &syncOperation)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(syncOperation.Phase).To(Equal(db.SyncOperation_Phase_Failed))
+				Expect(syncOperation.Phase_message).To(Equal(expectedErr))
 			})
 
 			It("should return an error and retry if the refresh fails", func() {
@@ -2438,8 +2691,8 @@ var _ = Describe("Operation Controller", func() {
 				Expect(apierr.IsConflict(err)).To(BeTrue())
 
 				task.syncFuncs = &syncFuncs{
-					appSync: func(ctx context.Context, s1, s2, s3 string, c client.Client, cs *utils.CredentialService, b bool) error {
-						return nil
+					appSync: func(ctx context.Context, s1, s2, s3 string, c client.Client, cs *utils.CredentialService, b bool, o utils.AppSyncOptions) (*appv1.OperationState, error) {
+						return nil, nil
 					},
 					refreshApp: refreshApplication,
 				}
@@ -2460,8 +2713,8 @@ var _ = Describe("Operation Controller", func() {
 
 				By("check if SyncOperation not found error is handled")
 				task.syncFuncs = &syncFuncs{
-					appSync: func(ctx context.Context, s1, s2, s3 string, c client.Client, cs *utils.CredentialService, b bool) error {
-						return nil
+					appSync: func(ctx context.Context, s1, s2, s3 string, c client.Client, cs *utils.CredentialService, b bool, o utils.AppSyncOptions) (*appv1.OperationState, error) {
+						return nil, nil
 					},
 				}
 				expectedErr := "no results found for GetSyncOperationById: no rows in result set"
@@ -2488,8 +2741,8 @@ var _ = Describe("Operation Controller", func() {
 				createOperationDBAndCR(syncOperation.SyncOperation_id, gitopsEngineInstanceID)
 
 				task.syncFuncs = &syncFuncs{
-					appSync: func(ctx context.Context, s1, s2, s3 string, c client.Client, cs *utils.CredentialService, b bool) error {
-						return nil
+					appSync: func(ctx context.Context, s1, s2, s3 string, c client.Client, cs *utils.CredentialService, b bool, o utils.AppSyncOptions) (*appv1.OperationState, error) {
+						return nil, nil
 					},
 				}
 
@@ -3239,6 +3492,129 @@ var _ = Describe("Operation Controller", func() {
 	})
 })
 
+var _ = Describe("generateSyncOperationStatus function Test", func() {
+
+	dbSyncOperation := db.SyncOperation{
+		SyncOperation_id: "test-sync-operation",
+		Started_at:       time.Now().Add(-time.Minute),
+	}
+
+	It("should report a failed Argo CD operation as Failed", func() {
+		finishedAt := metav1.Now()
+		status := generateSyncOperationStatus(dbSyncOperation, &appv1.OperationState{
+			Phase:      common.OperationFailed,
+			Message:    "one or more objects failed to apply",
+			FinishedAt: &finishedAt,
+		}, fmt.Errorf("operation has completed with phase: Failed"), logr.Discard())
+
+		Expect(status.SyncOperation_id).To(Equal(dbSyncOperation.SyncOperation_id))
+		Expect(status.Phase).To(Equal(db.SyncOperation_Phase_Failed))
+		Expect(status.Phase_message).To(Equal("one or more objects failed to apply"))
+		Expect(status.Started_at).To(Equal(dbSyncOperation.Started_at))
+		Expect(status.Finished_at).To(Equal(finishedAt.Time))
+	})
+
+	It("should report an error without an Argo CD operation state as Failed, and truncate the message", func() {
+		status := generateSyncOperationStatus(dbSyncOperation, nil, fmt.Errorf("%s", strings.Repeat("a", db.SyncOperationPhaseMessageLength+1)), logr.Discard())
+		Expect(status.Phase).To(Equal(db.SyncOperation_Phase_Failed))
+		Expect(status.Phase_message).To(HaveLen(db.SyncOperationPhaseMessageLength))
+	})
+
+	It("should truncate a message with multi-byte characters on a character boundary", func() {
+		status := generateSyncOperationStatus(dbSyncOperation, nil, fmt.Errorf("%s", strings.Repeat("a€", db.SyncOperationPhaseMessageLength)), logr.Discard())
+		Expect(len(status.Phase_message)).To(BeNumerically("<=", db.SyncOperationPhaseMessageLength))
+		Expect(len(status.Phase_message)).To(BeNumerically(">", db.SyncOperationPhaseMessageLength-utf8.UTFMax))
+		Expect(utf8.ValidString(status.Phase_message)).To(BeTrue())
+		Expect(status.Phase_message).To(HavePrefix("a€a€"))
+	})
+
+	It("should report a terminating Argo CD operation as Terminated", func() {
+		status := generateSyncOperationStatus(dbSyncOperation, &appv1.OperationState{Phase: common.OperationTerminating}, nil, logr.Discard())
+		Expect(status.Phase).To(Equal(db.SyncOperation_Phase_Terminated))
+	})
+
+	It("should compress the per-resource results of the sync", func() {
+		resources := appv1.ResourceResults{
+			{Group: "apps", Version: "v1", Kind: "Deployment", Namespace: "test", Name: "my-deployment", Status: common.ResultCodeSynced},
+		}
+		status := generateSyncOperationStatus(dbSyncOperation, &appv1.OperationState{
+			Phase:      common.OperationSucceeded,
+			SyncResult: &appv1.SyncOperationResult{Revision: "abc123", Resources: resources},
+		}, nil, logr.Discard())
+
+		Expect(status.Phase).To(Equal(db.SyncOperation_Phase_Succeeded))
+		Expect(status.Synced_revision).To(Equal("abc123"))
+
+		expectedSyncResult, err := sharedutil.CompressObject(resources)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status.Sync_result).To(Equal(expectedSyncResult))
+	})
+})
+
+var _ = Describe("convertSyncOperationToAppSyncOptions function Test", func() {
+
+	It("should convert the options of the SyncOperation row to AppSyncOptions", func() {
+		dbSyncOperation := db.SyncOperation{
+			SyncOperation_id: "test-sync-operation",
+			Prune:            true,
+			Dry_run:          true,
+			Force:            true,
+		}
+		dbSyncOperation.SetSyncOptions([]string{"ServerSideApply=true"})
+		err := dbSyncOperation.SetResources([]db.SyncOperationResource{
+			{Group: "apps", Kind: "Deployment", Name: "my-deployment", Namespace: "my-namespace"},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		syncOptions, err := convertSyncOperationToAppSyncOptions(dbSyncOperation)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(syncOptions).To(Equal(utils.AppSyncOptions{
+			Prune:       true,
+			DryRun:      true,
+			Force:       true,
+			SyncOptions: []string{"ServerSideApply=true"},
+			Resources: []appv1.SyncOperationResource{
+				{Group: "apps", Kind: "Deployment", Name: "my-deployment", Namespace: "my-namespace"},
+			},
+		}))
+	})
+
+	It("should return the default options if none are set", func() {
+		syncOptions, err := convertSyncOperationToAppSyncOptions(db.SyncOperation{SyncOperation_id: "test-sync-operation"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(syncOptions).To(Equal(utils.AppSyncOptions{}))
+	})
+
+	It("should return an error if the resources field is invalid", func() {
+		_, err := convertSyncOperationToAppSyncOptions(db.SyncOperation{SyncOperation_id: "test-sync-operation", Resources: "not-json"})
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("convertSyncOperationToAppRollbackOptions function Test", func() {
+
+	It("should convert the rollback target of the SyncOperation row to AppRollbackOptions", func() {
+		dbSyncOperation := db.SyncOperation{
+			SyncOperation_id: "test-sync-operation",
+			Dry_run:          true,
+		}
+		err := dbSyncOperation.SetRollbackTo(&db.SyncOperationRollback{Revision: "abc"})
+		Expect(err).ToNot(HaveOccurred())
+
+		rollbackOptions, err := convertSyncOperationToAppRollbackOptions(dbSyncOperation)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(*rollbackOptions).To(Equal(utils.AppRollbackOptions{
+			Revision: "abc",
+			DryRun:   true,
+		}))
+	})
+
+	It("should return an error if the SyncOperation does not have a rollback target", func() {
+		_, err := convertSyncOperationToAppRollbackOptions(db.SyncOperation{SyncOperation_id: "test-sync-operation"})
+		Expect(err).To(HaveOccurred())
+	})
+})
+
 func testTeardown() {
 	err := db.SetupForTestingDBGinkgo()
 	Expect(err).ToNot(HaveOccurred())
@@ -3422,3 +3798,52 @@ func createApplicationWithSyncOption(syncOptionParam string) (appv1.Application,
 
 	return dummyApplicationSpec, string(dummyApplicationSpecBytes), nil
 }
+
+var _ = Describe("Operation tenant weights", func() {
+
+	AfterEach(func() {
+		os.Unsetenv(OperationTenantWeightsEnv)
+	})
+
+	It("should return no weights if the environment variable is not set", func() {
+		Expect(operationTenantWeightsFromEnv(logr.Discard())).To(BeEmpty())
+	})
+
+	It("should parse valid entries, and ignore invalid entries", func() {
+		os.Setenv(OperationTenantWeightsEnv, "team-a=2, team-b=4,team-c,team-d=0,team-e=abc,=3")
+
+		Expect(operationTenantWeightsFromEnv(logr.Discard())).To(Equal(map[string]int{
+			"team-a": 2,
+			"team-b": 4,
+		}))
+	})
+
+	It("should resolve the namespaces to the ClusterUsers that own the Operations", func() {
+		mockCtrl := gomock.NewController(GinkgoT())
+		defer mockCtrl.Finish()
+
+		k8sClient := fake.NewClientBuilder().WithObjects(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", UID: "team-a-uid"}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b", UID: "team-b-uid"}},
+		).Build()
+
+		mockDB := mocks.NewMockDatabaseQueries(mockCtrl)
+		mockDB.EXPECT().GetClusterUserByUsername(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, clusterUser *db.ClusterUser) error {
+				if clusterUser.User_name == "team-a-uid" {
+					clusterUser.Clusteruser_id = "user-a"
+					return nil
+				}
+				// The user of team-b has not yet created any resources
+				return db.NewResultNotFoundError("no ClusterUser")
+			}).Times(2)
+
+		By("resolving the weights of team-a and team-b, and ignoring team-c which does not exist")
+		tenantWeights := newOperationTenantWeights(map[string]int{"team-a": 2, "team-b": 4, "team-c": 3})
+		tenantWeights.resolve(context.Background(), k8sClient, mockDB, logr.Discard())
+
+		Expect(tenantWeights.weight("user-a")).To(Equal(2))
+		Expect(tenantWeights.weight("user-b")).To(Equal(0))
+		Expect(tenantWeights.weight("team-a")).To(Equal(0))
+	})
+})
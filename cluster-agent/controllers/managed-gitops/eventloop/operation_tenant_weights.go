@@ -0,0 +1,162 @@
+package eventloop
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/redhat-appstudio/managed-gitops/backend-shared/db"
+	corev1 "k8s.io/api/core/v1"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// OperationTenantWeightsEnv is the name of the environment variable which may be used to give a tenant (the user that
+// owns an Operation) a larger share of the cluster-agent's Operation runners, when they are contended. A tenant is
+// identified by the name of the user's namespace: the namespace that contains their GitOpsDeployments.
+// Format: comma-separated list of '(namespace name)=(weight)', for example: "team-a=2,team-b=4". The default weight is 1.
+const OperationTenantWeightsEnv = "OPERATION_TENANT_WEIGHTS"
+
+// operationTenantWeightsResolveInterval is how often the namespaces of OperationTenantWeightsEnv are resolved to
+// tenants, so that namespaces (and users) which are created after the cluster-agent has started are picked up.
+const operationTenantWeightsResolveInterval = 5 * time.Minute
+
+// operationTenantWeights are the weights of OperationTenantWeightsEnv, resolved from namespace names to the tenants of
+// Operations (the IDs of the ClusterUsers that own them). A ClusterUser is the user of a namespace, and its user name
+// is the UID of that namespace.
+type operationTenantWeights struct {
+	mutex sync.RWMutex
+
+	// weightsByNamespace is the weight of each namespace name, from OperationTenantWeightsEnv
+	weightsByNamespace map[string]int
+
+	// tenantsByNamespace is the ClusterUser ID of each namespace which has been resolved
+	tenantsByNamespace map[string]string
+
+	// weightsByTenant is the weight of each ClusterUser ID, for the namespaces which have been resolved
+	weightsByTenant map[string]int
+}
+
+func newOperationTenantWeights(weightsByNamespace map[string]int) *operationTenantWeights {
+	return &operationTenantWeights{
+		weightsByNamespace: weightsByNamespace,
+		tenantsByNamespace: map[string]string{},
+		weightsByTenant:    map[string]int{},
+	}
+}
+
+// weight returns the weight of the tenant, or 0 if the tenant does not have a configured weight.
+func (w *operationTenantWeights) weight(tenant string) int {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+
+	return w.weightsByTenant[tenant]
+}
+
+// startResolving resolves the weights every operationTenantWeightsResolveInterval, until the context is cancelled.
+func (w *operationTenantWeights) startResolving(ctx context.Context, k8sClient client.Reader, dbQueries db.DatabaseQueries, log logr.Logger) {
+
+	if len(w.weightsByNamespace) == 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(operationTenantWeightsResolveInterval)
+		defer ticker.Stop()
+
+		for {
+			w.resolve(ctx, k8sClient, dbQueries, log)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// resolve looks up the ClusterUser of each namespace of weightsByNamespace. A namespace which does not exist, or
+// whose ClusterUser does not exist (because the user has not yet created any resources), is skipped. If a namespace
+// could not be looked up, its previously resolved tenant keeps its weight.
+func (w *operationTenantWeights) resolve(ctx context.Context, k8sClient client.Reader, dbQueries db.DatabaseQueries, log logr.Logger) {
+
+	w.mutex.RLock()
+	previousTenantsByNamespace := w.tenantsByNamespace
+	w.mutex.RUnlock()
+
+	tenantsByNamespace := map[string]string{}
+
+	for namespaceName := range w.weightsByNamespace {
+
+		namespace := corev1.Namespace{}
+		if err := k8sClient.Get(ctx, client.ObjectKey{Name: namespaceName}, &namespace); err != nil {
+			if !apierr.IsNotFound(err) {
+				log.Error(err, "unable to retrieve namespace of "+OperationTenantWeightsEnv, "namespace", namespaceName)
+				if tenant, exists := previousTenantsByNamespace[namespaceName]; exists {
+					tenantsByNamespace[namespaceName] = tenant
+				}
+			}
+			continue
+		}
+
+		clusterUser := db.ClusterUser{User_name: string(namespace.UID)}
+		if err := dbQueries.GetClusterUserByUsername(ctx, &clusterUser); err != nil {
+			if !db.IsResultNotFoundError(err) {
+				log.Error(err, "unable to retrieve ClusterUser of namespace of "+OperationTenantWeightsEnv, "namespace", namespaceName)
+				if tenant, exists := previousTenantsByNamespace[namespaceName]; exists {
+					tenantsByNamespace[namespaceName] = tenant
+				}
+			}
+			continue
+		}
+
+		tenantsByNamespace[namespaceName] = clusterUser.Clusteruser_id
+	}
+
+	weightsByTenant := map[string]int{}
+	for namespaceName, tenant := range tenantsByNamespace {
+		weightsByTenant[tenant] = w.weightsByNamespace[namespaceName]
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.tenantsByNamespace = tenantsByNamespace
+	w.weightsByTenant = weightsByTenant
+}
+
+// operationTenantWeightsFromEnv parses OperationTenantWeightsEnv, returning the weight of each namespace name. Invalid
+// entries are logged and ignored.
+func operationTenantWeightsFromEnv(log logr.Logger) map[string]int {
+
+	res := map[string]int{}
+
+	value := strings.TrimSpace(os.Getenv(OperationTenantWeightsEnv))
+	if value == "" {
+		return res
+	}
+
+	for _, entry := range strings.Split(value, ",") {
+
+		namespaceName, weightStr, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found || namespaceName == "" {
+			log.Error(nil, "invalid entry in "+OperationTenantWeightsEnv+", ignoring", "entry", entry)
+			continue
+		}
+
+		weight, err := strconv.Atoi(weightStr)
+		if err != nil || weight < 1 {
+			log.Error(err, "invalid weight in "+OperationTenantWeightsEnv+", ignoring", "entry", entry)
+			continue
+		}
+
+		res[namespaceName] = weight
+	}
+
+	return res
+}
@@ -132,6 +132,19 @@ func processOperation_RepositoryCredentials(ctx context.Context, dbOperation db.
 
 	l.Info("Retrieved RepositoryCredentials DB row")
 
+	// If the row references the user's Secret (rather than containing a copy of the credentials), then read the
+	// credentials from the Secret.
+	sourceSecretResourceVersion, err := controllers.ResolveRepositoryCredentialsSecretRef(ctx, opConfig.secretRefClient, &dbRepositoryCredentials)
+	if err != nil {
+		l.Error(err, "unable to resolve the Secret referenced by the RepositoryCredentials row")
+
+		if apierr.IsNotFound(err) {
+			// The Secret no longer exists: the backend will update the row once the user has corrected the Secret.
+			return noRetry, err
+		}
+		return retry, err
+	}
+
 	// 3) Retrieve ArgoCD secret from the cluster.
 	argoCDSecret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
@@ -148,6 +161,7 @@ func processOperation_RepositoryCredentials(ctx context.Context, dbOperation db.
 			l.Info(errPrivateSecretNotFound)
 
 			convertRepoCredToSecret(dbRepositoryCredentials, argoCDSecret)
+			setSourceSecretResourceVersionAnnotation(argoCDSecret, sourceSecretResourceVersion)
 
 			if err := opConfig.eventClient.Create(ctx, argoCDSecret, &client.CreateOptions{}); err != nil {
 				l.Error(err, errPrivateSecretCreate)
@@ -174,6 +188,11 @@ func processOperation_RepositoryCredentials(ctx context.Context, dbOperation db.
 	decodedSecret := secretToRepoCred(argoCDSecret) // helpful function to decode []byte to string for easier comparison
 	isUpdateNeeded := compareClusterResourceWithDatabaseRow(dbRepositoryCredentials, argoCDSecret, l, decodedSecret)
 
+	if argoCDSecret.Annotations[controllers.SourceSecretResourceVersionAnnotation] != sourceSecretResourceVersion {
+		setSourceSecretResourceVersionAnnotation(argoCDSecret, sourceSecretResourceVersion)
+		isUpdateNeeded = true
+	}
+
 	if isUpdateNeeded {
 		l.Info("Updating Argo CD Repository Secret")
 		if err = opConfig.eventClient.Update(ctx, argoCDSecret); err != nil {
@@ -269,6 +288,20 @@ func compareClusterResourceWithDatabaseRow(dbRepositoryCredentials db.Repository
 	return isUpdateNeeded
 }
 
+// setSourceSecretResourceVersionAnnotation sets the resourceVersion of the user's Secret that the Argo CD Secret was
+// generated from, or removes the annotation if the Argo CD Secret was not generated from a Secret reference.
+func setSourceSecretResourceVersionAnnotation(secret *corev1.Secret, sourceSecretResourceVersion string) {
+	if sourceSecretResourceVersion == "" {
+		delete(secret.Annotations, controllers.SourceSecretResourceVersionAnnotation)
+		return
+	}
+
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	secret.Annotations[controllers.SourceSecretResourceVersionAnnotation] = sourceSecretResourceVersion
+}
+
 func convertRepoCredToSecret(repoCred db.RepositoryCredentials, secret *corev1.Secret) {
 	if secret.Data == nil {
 		secret.Data = make(map[string][]byte)
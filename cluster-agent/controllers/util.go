@@ -2,7 +2,10 @@ package controllers
 
 import (
 	"context"
+	"fmt"
+	"os"
 	"reflect"
+	"strings"
 	"time"
 
 	appv1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
@@ -10,8 +13,12 @@ import (
 	"github.com/redhat-appstudio/managed-gitops/backend-shared/db"
 	sharedutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util"
 	logutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util/log"
+	corev1 "k8s.io/api/core/v1"
 	apierr "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/yaml"
 )
@@ -23,6 +30,9 @@ const (
 
 const (
 	argoCDResourcesFinalizer = "resources-finalizer.argocd.argoproj.io/background"
+
+	// argoCDResourcesFinalizerPrefix matches both the foreground and background variants of the Argo CD resources finalizer
+	argoCDResourcesFinalizerPrefix = "resources-finalizer.argocd.argoproj.io"
 )
 
 const (
@@ -30,8 +40,93 @@ const (
 	ArgoCDClusterSecretDatabaseIDLabel = "databaseID"
 	ArgoCDApplicationDatabaseIDLabel   = "databaseID"
 	RepoCredDatabaseIDLabel            = "databaseID"
+
+	// SourceSecretResourceVersionAnnotation is added to Argo CD cluster/repository Secrets that were generated from credentials
+	// that reference a user's Secret (rather than credentials that were copied into the database). The value is the
+	// resourceVersion of the user's Secret that the Argo CD Secret was generated from.
+	SourceSecretResourceVersionAnnotation = "managed-gitops.redhat.com/source-secret-resource-version"
+
+	// ArgoCDApplicationMigrationAnnotation is added to Argo CD Applications that are not hosted on their GitOps engine
+	// instance, because the Application row is being migrated between instances: either the sync-disabled copy that
+	// is staged on the new instance, or the Application that is being orphaned on the old instance.
+	// The status of these Applications is not written to the ApplicationState table.
+	ArgoCDApplicationMigrationAnnotation = "managed-gitops.redhat.com/migration"
 )
 
+// APIClusterKubeconfigEnv is the environment variable containing the path of a kubeconfig file for the cluster that
+// contains the GitOps Service API namespaces: the user Secrets that are referenced by cluster/repository credentials
+// are read from that cluster. If not set, the cluster that the cluster-agent is running on is used.
+const APIClusterKubeconfigEnv = "API_CLUSTER_KUBECONFIG"
+
+// NewSecretRefClient returns a client of the cluster that contains the Secrets referenced by cluster/repository
+// credentials (see APIClusterKubeconfigEnv). 'localConfig' is the REST config of the cluster that the cluster-agent
+// is running on. The client is not cached, so that only the referenced Secrets are read (and not watched).
+func NewSecretRefClient(localConfig *rest.Config, scheme *runtime.Scheme) (client.Client, error) {
+
+	config := localConfig
+
+	if kubeconfigPath := os.Getenv(APIClusterKubeconfigEnv); kubeconfigPath != "" {
+		var err error
+		if config, err = clientcmd.BuildConfigFromFlags("", kubeconfigPath); err != nil {
+			return nil, fmt.Errorf("unable to read the kubeconfig of the API cluster from '%s': %w", kubeconfigPath, err)
+		}
+	}
+
+	return client.New(config, client.Options{Scheme: scheme})
+}
+
+// ResolveClusterCredentialsSecretRef reads the service account bearer token from the Secret referenced by the ClusterCredentials
+// row (if any), and sets it on the ClusterCredentials.
+// The Secret is read with secretRefClient, which must be a client of the cluster that contains the Secret (see
+// NewSecretRefClient): this is not necessarily the cluster that Argo CD is on.
+// Returns the resourceVersion of the referenced Secret, or "" if the ClusterCredentials do not reference a Secret.
+func ResolveClusterCredentialsSecretRef(ctx context.Context, secretRefClient client.Client, clusterCreds *db.ClusterCredentials) (string, error) {
+
+	if !clusterCreds.HasSecretRef() {
+		return "", nil
+	}
+
+	secret := corev1.Secret{}
+	if err := secretRefClient.Get(ctx, client.ObjectKey{Namespace: clusterCreds.Secret_ref_namespace, Name: clusterCreds.Secret_ref_name}, &secret); err != nil {
+		return "", fmt.Errorf("unable to retrieve Secret '%s' referenced by cluster credentials '%s': %w",
+			clusterCreds.Secret_ref_name, clusterCreds.Clustercredentials_cred_id, err)
+	}
+
+	token, err := sharedutil.ExtractBearerTokenFromKubeconfig(secret.Data[sharedutil.ManagedEnvironmentSecretKubeconfigKey], clusterCreds.Host)
+	if err != nil {
+		return "", fmt.Errorf("unable to extract token from Secret '%s' referenced by cluster credentials '%s': %w",
+			clusterCreds.Secret_ref_name, clusterCreds.Clustercredentials_cred_id, err)
+	}
+
+	clusterCreds.Serviceaccount_bearer_token = token
+
+	return secret.ResourceVersion, nil
+}
+
+// ResolveRepositoryCredentialsSecretRef reads the username, password and SSH key from the Secret referenced by the
+// RepositoryCredentials row (if any), and sets them on the RepositoryCredentials.
+// The Secret is read with secretRefClient, which must be a client of the cluster that contains the Secret (see
+// NewSecretRefClient): this is not necessarily the cluster that Argo CD is on.
+// Returns the resourceVersion of the referenced Secret, or "" if the RepositoryCredentials do not reference a Secret.
+func ResolveRepositoryCredentialsSecretRef(ctx context.Context, secretRefClient client.Client, repoCreds *db.RepositoryCredentials) (string, error) {
+
+	if !repoCreds.HasSecretRef() {
+		return "", nil
+	}
+
+	secret := corev1.Secret{}
+	if err := secretRefClient.Get(ctx, client.ObjectKey{Namespace: repoCreds.SecretRefNamespace, Name: repoCreds.SecretRefName}, &secret); err != nil {
+		return "", fmt.Errorf("unable to retrieve Secret '%s' referenced by repository credentials '%s': %w",
+			repoCreds.SecretRefName, repoCreds.RepositoryCredentialsID, err)
+	}
+
+	repoCreds.AuthUsername = string(secret.Data["username"])
+	repoCreds.AuthPassword = string(secret.Data["password"])
+	repoCreds.AuthSSHKey = string(secret.Data["sshPrivateKey"])
+
+	return secret.ResourceVersion, nil
+}
+
 // DeleteArgoCDApplication attempts to gracefully delete an Argo CD application:
 // - Issue a Delete to K8s API
 // - If the Application is not deleted after X minutes, remove the finalizer
@@ -196,6 +291,72 @@ func DeleteArgoCDApplication(ctx context.Context, appFromList appv1.Application,
 	return nil
 }
 
+// OrphanArgoCDApplication deletes an Argo CD Application, without deleting the resources that were deployed by it: the
+// Argo CD resources finalizer is removed before the Application is deleted, so Argo CD does not prune its resources.
+func OrphanArgoCDApplication(ctx context.Context, appFromList appv1.Application, eventClient client.Client, log logr.Logger) error {
+
+	log = log.WithValues("argoCDApplicationName", appFromList.Name, "argoCDApplicationNamespace", appFromList.Namespace, "argoCDApplicationUID", string(appFromList.UID))
+
+	app := &appv1.Application{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      appFromList.Name,
+			Namespace: appFromList.Namespace,
+		},
+	}
+
+	if err := eventClient.Get(ctx, client.ObjectKeyFromObject(app), app); err != nil {
+		if apierr.IsNotFound(err) {
+			// The Application no longer exists: no work to do.
+			return nil
+		}
+
+		log.Error(err, "Unable to retrieve Argo CD Application which we are attempting to orphan")
+		return err
+	}
+
+	if value, exists := app.Labels[ArgoCDApplicationDatabaseIDLabel]; !exists || value == "" {
+		log.V(logutil.LogLevel_Debug).Info("skipping non-GitOps Service application")
+		return nil
+	}
+
+	// Remove the Argo CD resources finalizers (both the foreground and background variants), and mark the Application
+	// as being migrated, so that its status is no longer reported while it is being deleted.
+	var finalizers []string
+	for _, finalizer := range app.Finalizers {
+		if !strings.HasPrefix(finalizer, argoCDResourcesFinalizerPrefix) {
+			finalizers = append(finalizers, finalizer)
+		}
+	}
+
+	if len(finalizers) != len(app.Finalizers) || app.Annotations[ArgoCDApplicationMigrationAnnotation] == "" {
+		app.Finalizers = finalizers
+		if app.Annotations == nil {
+			app.Annotations = map[string]string{}
+		}
+		app.Annotations[ArgoCDApplicationMigrationAnnotation] = "orphan"
+
+		if err := eventClient.Update(ctx, app); err != nil {
+			log.Error(err, "unable to remove resources finalizer from Application")
+			return err
+		}
+		logutil.LogAPIResourceChangeEvent(app.Namespace, app.Name, app, logutil.ResourceModified, log)
+	}
+
+	policy := metav1.DeletePropagationOrphan
+	if err := eventClient.Delete(ctx, app, &client.DeleteOptions{PropagationPolicy: &policy}); err != nil {
+		if apierr.IsNotFound(err) {
+			return nil
+		}
+		log.Error(err, "unable to delete Application")
+		return err
+	}
+	logutil.LogAPIResourceChangeEvent(app.Namespace, app.Name, app, logutil.ResourceDeleted, log)
+
+	log.Info("Argo CD Application was deleted, without deleting its resources")
+
+	return nil
+}
+
 // CompareApplication compares an Argo CD Application and the spec field of a DB Application row, returning "" if the same,
 // otherwise returning the specific difference.
 func CompareApplication(argoCDApp appv1.Application, dbApplication db.Application, log logr.Logger) (string, error) {
@@ -227,6 +388,10 @@ func CompareApplication(argoCDApp appv1.Application, dbApplication db.Applicatio
 	var specDiff string
 	if !reflect.DeepEqual(specFieldAppFromDB.Spec.Source, argoCDApp.Spec.Source) {
 		specDiff = "spec.source fields differ"
+	} else if !reflect.DeepEqual(specFieldAppFromDB.Spec.Sources, argoCDApp.Spec.Sources) {
+		specDiff = "spec.sources fields differ"
+	} else if !reflect.DeepEqual(specFieldAppFromDB.Spec.IgnoreDifferences, argoCDApp.Spec.IgnoreDifferences) {
+		specDiff = "spec.ignoreDifferences fields differ"
 	} else if !reflect.DeepEqual(specFieldAppFromDB.Spec.Destination, argoCDApp.Spec.Destination) {
 		specDiff = "spec.destination fields differ"
 	} else if specFieldAppFromDB.Spec.Project != argoCDApp.Spec.Project {
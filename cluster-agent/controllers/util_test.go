@@ -11,7 +11,9 @@ import (
 	"github.com/redhat-appstudio/managed-gitops/backend-shared/db"
 	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/fauxargocd"
 	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/tests"
+	goyaml "gopkg.in/yaml.v2"
 	corev1 "k8s.io/api/core/v1"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -241,6 +243,82 @@ var _ = Describe("Tests for the small number of utility functions in cluster-age
 
 	})
 
+	Context("OrphanArgoCDApplication tests", func() {
+
+		var ctx context.Context
+		var k8sClient client.WithWatch
+		var logger logr.Logger
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			logger = log.FromContext(ctx)
+
+			scheme, argocdNamespace, kubesystemNamespace, workspace, err := tests.GenericTestSetup()
+			Expect(err).ToNot(HaveOccurred())
+
+			err = appv1.AddToScheme(scheme)
+			Expect(err).ToNot(HaveOccurred())
+
+			k8sClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(workspace, argocdNamespace, kubesystemNamespace).Build()
+		})
+
+		It("should not delete an Argo CD Application which is missing the databaseID label", func() {
+
+			application := appv1.Application{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "my-name",
+					Namespace:  "my-namespace",
+					Finalizers: []string{argoCDResourcesFinalizer},
+				},
+			}
+			Expect(k8sClient.Create(ctx, &application)).To(Succeed())
+
+			Expect(OrphanArgoCDApplication(ctx, application, k8sClient, logger)).To(Succeed())
+
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&application), &application)).To(Succeed())
+			Expect(application.Finalizers).To(ConsistOf(argoCDResourcesFinalizer))
+		})
+
+		It("should remove the Argo CD resources finalizers, but not other finalizers, and then delete the Application", func() {
+
+			application := appv1.Application{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "my-name",
+					Namespace: "my-namespace",
+					Labels: map[string]string{
+						ArgoCDApplicationDatabaseIDLabel: "test-my-database-id-label",
+					},
+					Finalizers: []string{argoCDResourcesFinalizer, "resources-finalizer.argocd.argoproj.io", "some-other-finalizer"},
+				},
+			}
+			Expect(k8sClient.Create(ctx, &application)).To(Succeed())
+
+			Expect(OrphanArgoCDApplication(ctx, application, k8sClient, logger)).To(Succeed())
+
+			By("verifying the Application is being deleted, and only the unrelated finalizer remains")
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&application), &application)).To(Succeed())
+			Expect(application.DeletionTimestamp).ToNot(BeNil())
+			Expect(application.Finalizers).To(ConsistOf("some-other-finalizer"))
+			Expect(application.Annotations).To(HaveKey(ArgoCDApplicationMigrationAnnotation))
+
+			By("removing the unrelated finalizer, the Application should be deleted")
+			application.Finalizers = nil
+			Expect(k8sClient.Update(ctx, &application)).To(Succeed())
+			Expect(apierr.IsNotFound(k8sClient.Get(ctx, client.ObjectKeyFromObject(&application), &application))).To(BeTrue())
+		})
+
+		It("should not return an error if the Application does not exist", func() {
+
+			application := appv1.Application{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "my-name",
+					Namespace: "my-namespace",
+				},
+			}
+			Expect(OrphanArgoCDApplication(ctx, application, k8sClient, logger)).To(Succeed())
+		})
+	})
+
 	Context("Testing for CompareApplications function.", func() {
 		createDummyApplicationData := func() (fauxargocd.FauxApplication, string, appv1.Application, error) {
 			// Create dummy ArgoCD Application CR.
@@ -383,6 +461,78 @@ var _ = Describe("Tests for the small number of utility functions in cluster-age
 			applicationFromArgoCD.Spec.SyncPolicy.Automated.AllowEmpty = applicationFromDB.Spec.SyncPolicy.Automated.AllowEmpty
 		})
 
+		It("Should compare applications with multiple sources.", func() {
+
+			applicationFromDB, _, applicationFromArgoCD, err := createDummyApplicationData()
+			Expect(err).ToNot(HaveOccurred())
+
+			applicationFromDB.Spec.Source = fauxargocd.ApplicationSource{}
+			applicationFromDB.Spec.Sources = fauxargocd.ApplicationSources{
+				{RepoURL: "https://github.com/redhat-appstudio/managed-gitops", Path: "chart", Helm: &fauxargocd.ApplicationSourceHelm{
+					ValueFiles: []string{"$values/values-prod.yaml"},
+				}},
+				{RepoURL: "https://github.com/redhat-appstudio/managed-gitops-values", Ref: "values"},
+			}
+
+			applicationFromArgoCD.Spec.Source = nil
+			applicationFromArgoCD.Spec.Sources = appv1.ApplicationSources{
+				{RepoURL: "https://github.com/redhat-appstudio/managed-gitops", Path: "chart", Helm: &appv1.ApplicationSourceHelm{
+					ValueFiles: []string{"$values/values-prod.yaml"},
+				}},
+				{RepoURL: "https://github.com/redhat-appstudio/managed-gitops-values", Ref: "values"},
+			}
+
+			// The backend generates the spec field with gopkg.in/yaml.v2, which omits the empty .spec.source field
+			yamlData, err := goyaml.Marshal(applicationFromDB)
+			Expect(err).ToNot(HaveOccurred())
+
+			dbApp := db.Application{Spec_field: string(yamlData)}
+
+			log := log.FromContext(context.Background())
+
+			result, err := CompareApplication(applicationFromArgoCD, dbApp, log)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(BeEmpty())
+
+			applicationFromArgoCD.Spec.Sources[1].TargetRevision = "test"
+			result, err = CompareApplication(applicationFromArgoCD, dbApp, log)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal("spec.sources fields differ"))
+		})
+
+		It("Should compare applications with ignoreDifferences.", func() {
+
+			applicationFromDB, _, applicationFromArgoCD, err := createDummyApplicationData()
+			Expect(err).ToNot(HaveOccurred())
+
+			applicationFromDB.Spec.IgnoreDifferences = fauxargocd.IgnoreDifferences{
+				{Group: "apps", Kind: "Deployment", JSONPointers: []string{"/spec/replicas"}},
+				{Kind: "ConfigMap", Name: "generated", JQPathExpressions: []string{".data[\"timestamp\"]"}},
+			}
+
+			applicationFromArgoCD.Spec.IgnoreDifferences = appv1.IgnoreDifferences{
+				{Group: "apps", Kind: "Deployment", JSONPointers: []string{"/spec/replicas"}},
+				{Kind: "ConfigMap", Name: "generated", JQPathExpressions: []string{".data[\"timestamp\"]"}},
+			}
+
+			// The backend generates the spec field with gopkg.in/yaml.v2, which lowercases the field names
+			yamlData, err := goyaml.Marshal(applicationFromDB)
+			Expect(err).ToNot(HaveOccurred())
+
+			dbApp := db.Application{Spec_field: string(yamlData)}
+
+			log := log.FromContext(context.Background())
+
+			result, err := CompareApplication(applicationFromArgoCD, dbApp, log)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(BeEmpty())
+
+			applicationFromArgoCD.Spec.IgnoreDifferences[0].JSONPointers = []string{"/spec/template"}
+			result, err = CompareApplication(applicationFromArgoCD, dbApp, log)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal("spec.ignoreDifferences fields differ"))
+		})
+
 		It("Should compare applications if fields are nil.", func() {
 
 			// Convert a FauxApplication into a db.Application, by marshalling the FA back into YAML
@@ -26,12 +26,15 @@ import (
 	managedgitopsv1alpha1 "github.com/redhat-appstudio/managed-gitops/backend-shared/apis/managed-gitops/v1alpha1"
 	"github.com/redhat-appstudio/managed-gitops/backend-shared/db"
 	sharedutil "github.com/redhat-appstudio/managed-gitops/backend-shared/util"
+	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/fauxargocd"
+	agentcontrollers "github.com/redhat-appstudio/managed-gitops/cluster-agent/controllers"
 	argoprojiocontrollers "github.com/redhat-appstudio/managed-gitops/cluster-agent/controllers/argoproj.io"
 	"github.com/redhat-appstudio/managed-gitops/cluster-agent/controllers/argoproj.io/application_info_cache"
 	controllers "github.com/redhat-appstudio/managed-gitops/cluster-agent/controllers/managed-gitops"
 	"github.com/redhat-appstudio/managed-gitops/cluster-agent/controllers/managed-gitops/eventloop"
 	"github.com/redhat-appstudio/managed-gitops/cluster-agent/metrics"
 	argocdmetrics "github.com/redhat-appstudio/managed-gitops/cluster-agent/metrics/argocd"
+	"github.com/redhat-appstudio/managed-gitops/cluster-agent/utils"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -108,16 +111,30 @@ func main() {
 		os.Exit(1)
 	}
 
+	// The API reader is used, rather than the cached client, so that only the key Secret is read (and not watched).
+	if err := db.ConfigureEncryptionKeyProviderFromSecretEnv(mgr.GetAPIReader()); err != nil {
+		setupLog.Error(err, "unable to configure database encryption key provider")
+		os.Exit(1)
+	}
+
 	dbQueries, err := db.NewSharedProductionPostgresDBQueries(false)
 	if err != nil {
 		setupLog.Error(err, "never able to connect to database")
 		os.Exit(1)
 	}
 
+	// The Secrets referenced by cluster/repository credentials are in the GitOps Service API namespaces, which may be on
+	// another cluster than this one.
+	secretRefClient, err := agentcontrollers.NewSecretRefClient(restConfig, scheme)
+	if err != nil {
+		setupLog.Error(err, "unable to create client for Secrets referenced by credentials")
+		os.Exit(1)
+	}
+
 	if err = (&controllers.OperationReconciler{
 		Client:              mgr.GetClient(),
 		Scheme:              mgr.GetScheme(),
-		ControllerEventLoop: eventloop.NewOperationEventLoop(),
+		ControllerEventLoop: eventloop.NewOperationEventLoop(secretRefClient),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Operation")
 		os.Exit(1)
@@ -126,12 +143,18 @@ func main() {
 	operationsGC := controllers.NewGarbageCollector(dbQueries, mgr.GetClient())
 	operationsGC.StartGarbageCollector()
 
+	// Used to retrieve the resource tree of Argo CD Applications, via the Argo CD API
+	resourceTreeCredentialService := utils.NewCredentialService(nil, false)
+
 	if err = (&argoprojiocontrollers.ApplicationReconciler{
 		Client:                mgr.GetClient(),
 		Scheme:                mgr.GetScheme(),
 		DB:                    dbQueries,
 		DeletionTaskRetryLoop: sharedutil.NewTaskRetryLoop("application-reconciler"),
 		Cache:                 application_info_cache.NewApplicationInfoCache(),
+		GetResourceTree: func(ctx context.Context, app appv1.Application) (*fauxargocd.ApplicationTree, error) {
+			return utils.GetResourceTree(ctx, app.Name, app.Namespace, resourceTreeCredentialService, mgr.GetClient())
+		},
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Application")
 		os.Exit(1)
@@ -149,8 +172,9 @@ func main() {
 		setupLog.Error(err, "unable to create special cluster user")
 	}
 	namespacesReconciler := argoprojiocontrollers.ApplicationReconciler{
-		DB:     dbQueries,
-		Client: mgr.GetClient(),
+		DB:              dbQueries,
+		Client:          mgr.GetClient(),
+		SecretRefClient: secretRefClient,
 	}
 
 	// Trigger goroutine for workSpace/NameSpace reconciler
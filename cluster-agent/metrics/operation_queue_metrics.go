@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	operationQueueDepthState_waiting = "waiting"
+	operationQueueDepthState_active  = "active"
+)
+
+// The Operation queue metrics are not labelled by tenant (Operation owner): there is a tenant for every user, so
+// a tenant label would have an unbounded number of values.
+var (
+	OperationQueueDepth = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "operation_queue_depth",
+			Help: "Number of Operations that are waiting to be processed, or are being processed, by the cluster-agent",
+		},
+		[]string{"state"},
+	)
+
+	OperationQueueTenants = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "operation_queue_tenants",
+			Help: "Number of tenants (Operation owners) with Operations that are waiting to be processed, or are being processed, by the cluster-agent",
+		},
+		[]string{"state"},
+	)
+)
+
+// SetOperationQueueDepth sets the number of waiting and active Operations, and the number of tenants with waiting and
+// active Operations, from the number of waiting and active Operations of each tenant.
+func SetOperationQueueDepth(waitingByTenant map[string]int, activeByTenant map[string]int) {
+
+	for state, countByTenant := range map[string]map[string]int{
+		operationQueueDepthState_waiting: waitingByTenant,
+		operationQueueDepthState_active:  activeByTenant,
+	} {
+		total, tenants := 0, 0
+		for _, count := range countByTenant {
+			if count > 0 {
+				total += count
+				tenants++
+			}
+		}
+
+		OperationQueueDepth.WithLabelValues(state).Set(float64(total))
+		OperationQueueTenants.WithLabelValues(state).Set(float64(tenants))
+	}
+}
@@ -0,0 +1,29 @@
+package metrics
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+var _ = Describe("Test for Operation queue depth metrics", func() {
+
+	Context("Prometheus metrics responds to the number of waiting and active Operations", func() {
+
+		It("Test SetOperationQueueDepth function", func() {
+
+			SetOperationQueueDepth(map[string]int{"tenant-a": 3, "tenant-b": 1}, map[string]int{"tenant-a": 2})
+
+			Expect(testutil.ToFloat64(OperationQueueDepth.WithLabelValues("waiting"))).To(Equal(float64(4)))
+			Expect(testutil.ToFloat64(OperationQueueDepth.WithLabelValues("active"))).To(Equal(float64(2)))
+			Expect(testutil.ToFloat64(OperationQueueTenants.WithLabelValues("waiting"))).To(Equal(float64(2)))
+			Expect(testutil.ToFloat64(OperationQueueTenants.WithLabelValues("active"))).To(Equal(float64(1)))
+
+			By("verifying that the metrics are not labelled by tenant")
+			SetOperationQueueDepth(map[string]int{"tenant-a": 1, "tenant-c": 1}, map[string]int{})
+			Expect(testutil.CollectAndCount(OperationQueueDepth)).To(Equal(2))
+			Expect(testutil.ToFloat64(OperationQueueDepth.WithLabelValues("active"))).To(Equal(float64(0)))
+			Expect(testutil.ToFloat64(OperationQueueTenants.WithLabelValues("waiting"))).To(Equal(float64(2)))
+		})
+	})
+})
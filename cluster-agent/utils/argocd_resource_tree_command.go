@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/argoproj/argo-cd/v2/pkg/apiclient"
+	applicationpkg "github.com/argoproj/argo-cd/v2/pkg/apiclient/application"
+	appv1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
+	argoio "github.com/argoproj/argo-cd/v2/util/io"
+	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/fauxargocd"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// This file is loosely based on the 'argocd app resources' CLI command (https://github.com/argoproj/argo-cd/blob/0a46d37fc6af9fe0aa963bdd845e3d799aa0320d/cmd/argocd/commands/app_resources.go)
+
+// GetResourceTree calls the Argo CD GRPC API to retrieve the resource tree of an Argo CD Application: the resources
+// which are managed by the Application, and their children. The resource tree is not part of the status of the
+// Application CR: Argo CD only makes it available via its API.
+func GetResourceTree(ctx context.Context, appName string, namespaceName string, credentialService *CredentialService,
+	k8sClient client.Client) (*fauxargocd.ApplicationTree, error) {
+
+	namespace := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: namespaceName,
+		},
+	}
+
+	if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(namespace), namespace); err != nil {
+		return nil, fmt.Errorf("unable to retrieve namespace in GetResourceTree: %s, %v", namespaceName, err)
+	}
+
+	_, acdClient, err := credentialService.GetArgoCDLoginCredentials(ctx, namespaceName, string(namespace.UID), false, k8sClient)
+	if err != nil {
+		return nil, err
+	}
+
+	return getResourceTree(ctx, appName, acdClient)
+}
+
+func getResourceTree(ctx context.Context, appName string, acdClient apiclient.Client) (*fauxargocd.ApplicationTree, error) {
+
+	conn, appIf, err := acdClient.NewApplicationClient()
+	if err != nil {
+		return nil, fmt.Errorf("unable to create application client for resource tree: %v", err)
+	}
+	defer argoio.Close(conn)
+
+	tree, err := appIf.ResourceTree(ctx, &applicationpkg.ResourcesQuery{ApplicationName: &appName})
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve resource tree of Application '%s': %v", appName, err)
+	}
+
+	return ConvertApplicationTree(tree), nil
+}
+
+// ConvertApplicationTree converts the Argo CD resource tree into the (smaller) representation that is stored in
+// the database: orphaned nodes, hosts, and the informational fields of each node, are not included.
+func ConvertApplicationTree(tree *appv1.ApplicationTree) *fauxargocd.ApplicationTree {
+
+	if tree == nil {
+		return nil
+	}
+
+	convertRef := func(ref appv1.ResourceRef) fauxargocd.ResourceRef {
+		return fauxargocd.ResourceRef{
+			Group:     ref.Group,
+			Version:   ref.Version,
+			Kind:      ref.Kind,
+			Namespace: ref.Namespace,
+			Name:      ref.Name,
+			UID:       ref.UID,
+		}
+	}
+
+	res := &fauxargocd.ApplicationTree{}
+
+	for _, node := range tree.Nodes {
+
+		resNode := fauxargocd.ResourceNode{
+			ResourceRef: convertRef(node.ResourceRef),
+		}
+
+		for _, parentRef := range node.ParentRefs {
+			resNode.ParentRefs = append(resNode.ParentRefs, convertRef(parentRef))
+		}
+
+		if node.Health != nil {
+			resNode.Health = &fauxargocd.HealthStatus{
+				Status:  fauxargocd.HealthStatusCode(node.Health.Status),
+				Message: node.Health.Message,
+			}
+		}
+
+		res.Nodes = append(res.Nodes, resNode)
+	}
+
+	return res
+}
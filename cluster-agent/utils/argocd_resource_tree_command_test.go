@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"context"
+
+	applicationpkg "github.com/argoproj/argo-cd/v2/pkg/apiclient/application"
+	appv1 "github.com/argoproj/argo-cd/v2/pkg/apis/application/v1alpha1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/fauxargocd"
+	"github.com/redhat-appstudio/managed-gitops/cluster-agent/utils/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("Retrieve the resource tree of an Argo CD Application", func() {
+	Context("getResourceTree", func() {
+		It("returns the nodes of the tree, with their parents and health, without orphaned nodes", func() {
+
+			appName := "my-app"
+
+			deploymentRef := appv1.ResourceRef{Group: "apps", Version: "v1", Kind: "Deployment", Namespace: "jane", Name: "my-deployment", UID: "uid-1"}
+			replicaSetRef := appv1.ResourceRef{Group: "apps", Version: "v1", Kind: "ReplicaSet", Namespace: "jane", Name: "my-deployment-abc", UID: "uid-2"}
+
+			tree := &appv1.ApplicationTree{
+				Nodes: []appv1.ResourceNode{
+					{
+						ResourceRef: deploymentRef,
+						Health:      &appv1.HealthStatus{Status: "Healthy"},
+						Images:      []string{"quay.io/org/app:v1"},
+					},
+					{
+						ResourceRef: replicaSetRef,
+						ParentRefs:  []appv1.ResourceRef{deploymentRef},
+						Health:      &appv1.HealthStatus{Status: "Progressing", Message: "Waiting for rollout"},
+					},
+				},
+				OrphanedNodes: []appv1.ResourceNode{
+					{ResourceRef: appv1.ResourceRef{Kind: "ConfigMap", Namespace: "jane", Name: "orphan"}},
+				},
+			}
+
+			mockAppServiceClient := &mocks.ApplicationServiceClient{}
+			mockAppClient := &mocks.Client{}
+
+			mockAppClient.On("NewApplicationClient").Return(mockCloser{}, mockAppServiceClient, nil)
+			mockAppServiceClient.On("ResourceTree", mock.Anything, &applicationpkg.ResourcesQuery{ApplicationName: &appName}).Return(tree, nil)
+
+			res, err := getResourceTree(context.Background(), appName, mockAppClient)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(res).To(Equal(&fauxargocd.ApplicationTree{
+				Nodes: []fauxargocd.ResourceNode{
+					{
+						ResourceRef: fauxargocd.ResourceRef{Group: "apps", Version: "v1", Kind: "Deployment", Namespace: "jane", Name: "my-deployment", UID: "uid-1"},
+						Health:      &fauxargocd.HealthStatus{Status: "Healthy"},
+					},
+					{
+						ResourceRef: fauxargocd.ResourceRef{Group: "apps", Version: "v1", Kind: "ReplicaSet", Namespace: "jane", Name: "my-deployment-abc", UID: "uid-2"},
+						ParentRefs: []fauxargocd.ResourceRef{
+							{Group: "apps", Version: "v1", Kind: "Deployment", Namespace: "jane", Name: "my-deployment", UID: "uid-1"},
+						},
+						Health: &fauxargocd.HealthStatus{Status: "Progressing", Message: "Waiting for rollout"},
+					},
+				},
+			}))
+		})
+	})
+})
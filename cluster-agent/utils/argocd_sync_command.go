@@ -25,9 +25,27 @@ import (
 // This contents of this file are loosely based on the 'argocd app sync' CLI command:
 // https://github.com/argoproj/argo-cd/blob/0a46d37fc6af9fe0aa963bdd845e3d799aa0320d/cmd/argocd/commands/app.go#L1333
 
+// AppSyncOptions are the options of a sync operation, as specified by the user in the GitOpsDeploymentSyncRun CR.
+// The zero value performs a default sync of all resources.
+type AppSyncOptions struct {
+	// Prune deletes resources that are no longer defined in the GitOps repository
+	Prune bool
+	// DryRun simulates the sync, without modifying the resources on the cluster
+	DryRun bool
+	// Force forcefully applies resources, deleting and recreating them if required
+	Force bool
+	// Replace uses 'kubectl replace'/'kubectl create' rather than 'kubectl apply' to update resources
+	Replace bool
+	// Resources, if non-empty, is the subset of resources of the Application which should be synced
+	Resources []argoappv1.SyncOperationResource
+	// SyncOptions are sync options which apply only to this sync, e.g. 'ServerSideApply=true'
+	SyncOptions []string
+}
+
 // AppSync will trigger a synchronize application on the given Argo CD appliatication, in the given namespace.
+// The final state of the Argo CD sync operation is returned, if available (even if an error occurred).
 func AppSync(ctx context.Context, appName string, revision string, namespaceName string, k8sClient client.Client,
-	credentialsService *CredentialService, skipTLSTest bool) error {
+	credentialsService *CredentialService, skipTLSTest bool, syncOptions AppSyncOptions) (*argoappv1.OperationState, error) {
 
 	namespace := &corev1.Namespace{
 		ObjectMeta: metav1.ObjectMeta{
@@ -37,30 +55,27 @@ func AppSync(ctx context.Context, appName string, revision string, namespaceName
 
 	err := k8sClient.Get(ctx, client.ObjectKeyFromObject(namespace), namespace)
 	if err != nil {
-		return fmt.Errorf("unable to retrieve namespace in AppSync: %s, %v", namespaceName, err)
+		return nil, fmt.Errorf("unable to retrieve namespace in AppSync: %s, %v", namespaceName, err)
 	}
 
 	_, acdClient, err := credentialsService.GetArgoCDLoginCredentials(ctx, namespaceName, string(namespace.UID), false, k8sClient)
 	if err != nil {
-		return err
-	}
-
-	err = appSync(ctx, acdClient, appName, false, false, revision, false, "", false, false, 0, 0, 0, 0, 0)
-	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return appSync(ctx, acdClient, appName, syncOptions.DryRun, syncOptions.Replace, revision, syncOptions.Prune, "", syncOptions.Force, false, 0, 0, 0, 0, 0,
+		syncOptions.Resources, syncOptions.SyncOptions)
 
 }
 
 func appSync(ctx context.Context, acdClient argocdclient.Client, appName string, dryRun bool, replace bool, revision string, prune bool,
 	strategy string, force bool, async bool, timeout uint, retryLimit int64, retryBackoffDuration time.Duration,
-	retryBackoffMaxDuration time.Duration, retryBackoffFactor int64) error {
+	retryBackoffMaxDuration time.Duration, retryBackoffFactor int64, selectedResources []argoappv1.SyncOperationResource,
+	additionalSyncOptions []string) (*argoappv1.OperationState, error) {
 
 	conn, appIf, err := acdClient.NewApplicationClient()
 	if err != nil {
-		return fmt.Errorf("unable to retrieve acd client: %v", err)
+		return nil, fmt.Errorf("unable to retrieve acd client: %v", err)
 	}
 	defer argoio.Close(conn)
 
@@ -70,6 +85,12 @@ func appSync(ctx context.Context, acdClient argocdclient.Client, appName string,
 		if replace {
 			items = append(items, common.SyncOptionReplace)
 		}
+		for _, syncOption := range additionalSyncOptions {
+			if replace && syncOption == common.SyncOptionReplace {
+				continue
+			}
+			items = append(items, syncOption)
+		}
 
 		if len(items) == 0 {
 			// for prevent send even empty array if not need
@@ -83,7 +104,7 @@ func appSync(ctx context.Context, acdClient argocdclient.Client, appName string,
 		Name:        &appName,
 		DryRun:      &dryRun,
 		Revision:    &revision,
-		Resources:   nil,
+		Resources:   syncResourcesToPointers(selectedResources),
 		Prune:       &prune,
 		Manifests:   nil,
 		Infos:       []*argoappv1.Info{},
@@ -98,7 +119,7 @@ func appSync(ctx context.Context, acdClient argocdclient.Client, appName string,
 		syncReq.Strategy = &argoappv1.SyncStrategy{Hook: &argoappv1.SyncStrategyHook{}}
 		syncReq.Strategy.Hook.Force = force
 	default:
-		return fmt.Errorf("unknown sync strategy: '%s'", strategy)
+		return nil, fmt.Errorf("unknown sync strategy: '%s'", strategy)
 	}
 	if retryLimit > 0 {
 		syncReq.RetryStrategy = &argoappv1.RetryStrategy{
@@ -112,30 +133,155 @@ func appSync(ctx context.Context, acdClient argocdclient.Client, appName string,
 	}
 	_, err = appIf.Sync(ctx, &syncReq)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if async {
+		return nil, nil
+	}
+
+	app, err := waitOnApplicationStatus(ctx, acdClient, appName, timeout, false, false, true, false, selectedResources)
+	if err != nil {
+		return nil, err
 	}
 
-	if !async {
-		app, err := waitOnApplicationStatus(ctx, acdClient, appName, timeout, false, false, true, false, []argoappv1.SyncOperationResource{})
-		if err != nil {
-			return err
+	operationState := app.Status.OperationState
+
+	if !dryRun && operationState != nil {
+		if !operationState.Phase.Successful() {
+			return operationState, fmt.Errorf("operation has completed with phase: %s and message: %s", operationState.Phase, operationState.Message)
+		} else if len(selectedResources) == 0 && app.Status.Sync.Status != argoappv1.SyncStatusCodeSynced && operationState.SyncResult != nil {
+			// Only get resources to be pruned if sync was application-wide and final status is not synced
+			pruningRequired := operationState.SyncResult.Resources.PruningRequired()
+			if pruningRequired > 0 {
+				return operationState, fmt.Errorf("%d resources require pruning", pruningRequired)
+			}
 		}
+	}
 
-		if !dryRun {
-			operationState := app.Status.OperationState
-			if !operationState.Phase.Successful() {
-				return fmt.Errorf("operation has completed with phase: %s and message: %s", operationState.Phase, operationState.Message)
-			} else if /*len(selectedResources) == 0 &&*/ app.Status.Sync.Status != argoappv1.SyncStatusCodeSynced {
-				// Only get resources to be pruned if sync was application-wide and final status is not synced
-				pruningRequired := operationState.SyncResult.Resources.PruningRequired()
-				if pruningRequired > 0 {
-					return fmt.Errorf("%d resources require pruning", pruningRequired)
-				}
+	return operationState, nil
+}
+
+// syncResourcesToPointers converts the list of resources to the format expected by ApplicationSyncRequest: nil is
+// returned if the list is empty, which syncs all the resources of the Application.
+func syncResourcesToPointers(resources []argoappv1.SyncOperationResource) []*argoappv1.SyncOperationResource {
+	if len(resources) == 0 {
+		return nil
+	}
+
+	res := make([]*argoappv1.SyncOperationResource, 0, len(resources))
+	for i := range resources {
+		res = append(res, &resources[i])
+	}
+	return res
+}
+
+// AppRollbackOptions are the options of a rollback operation, as specified by the user in the GitOpsDeploymentSyncRun CR.
+type AppRollbackOptions struct {
+	// ID is the ID of the deployment history entry to roll back to. Ignored if Revision is set.
+	ID *int64
+	// Revision, if non-empty, rolls back to the most recent deployment history entry with this revision
+	Revision string
+	// Prune deletes resources that are no longer defined at the revision being rolled back to
+	Prune bool
+	// DryRun simulates the rollback, without modifying the resources on the cluster
+	DryRun bool
+}
+
+// AppRollback will trigger a rollback of the given Argo CD application, in the given namespace, to a previous entry of
+// its deployment history.
+// The final state of the Argo CD rollback operation is returned, if available (even if an error occurred).
+func AppRollback(ctx context.Context, appName string, namespaceName string, k8sClient client.Client,
+	credentialsService *CredentialService, rollbackOptions AppRollbackOptions) (*argoappv1.OperationState, error) {
+
+	namespace := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: namespaceName,
+		},
+	}
+
+	err := k8sClient.Get(ctx, client.ObjectKeyFromObject(namespace), namespace)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve namespace in AppRollback: %s, %v", namespaceName, err)
+	}
+
+	_, acdClient, err := credentialsService.GetArgoCDLoginCredentials(ctx, namespaceName, string(namespace.UID), false, k8sClient)
+	if err != nil {
+		return nil, err
+	}
+
+	return appRollback(ctx, acdClient, appName, rollbackOptions, 0)
+}
+
+// appRollback is loosely based on the 'argocd app rollback' CLI command.
+func appRollback(ctx context.Context, acdClient argocdclient.Client, appName string, rollbackOptions AppRollbackOptions,
+	timeout uint) (*argoappv1.OperationState, error) {
+
+	conn, appIf, err := acdClient.NewApplicationClient()
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve acd client: %v", err)
+	}
+	defer argoio.Close(conn)
+
+	app, err := appIf.Get(ctx, &applicationpkg.ApplicationQuery{Name: &appName})
+	if err != nil {
+		return nil, err
+	}
+
+	historyID, err := findRevisionHistoryID(app.Status.History, rollbackOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = appIf.Rollback(ctx, &applicationpkg.ApplicationRollbackRequest{
+		Name:   &appName,
+		Id:     &historyID,
+		DryRun: &rollbackOptions.DryRun,
+		Prune:  &rollbackOptions.Prune,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	app, err = waitOnApplicationStatus(ctx, acdClient, appName, timeout, false, false, true, false, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	operationState := app.Status.OperationState
+
+	if !rollbackOptions.DryRun && operationState != nil && !operationState.Phase.Successful() {
+		return operationState, fmt.Errorf("operation has completed with phase: %s and message: %s", operationState.Phase, operationState.Message)
+	}
+
+	return operationState, nil
+}
+
+// findRevisionHistoryID returns the ID of the deployment history entry that should be rolled back to: either the most
+// recent entry with the given revision, or the entry with the given ID.
+func findRevisionHistoryID(history argoappv1.RevisionHistories, rollbackOptions AppRollbackOptions) (int64, error) {
+
+	if rollbackOptions.Revision != "" {
+		// History is ordered oldest first, so search from the end for the most recent deployment of the revision
+		for i := len(history) - 1; i >= 0; i-- {
+			if history[i].Revision == rollbackOptions.Revision {
+				return history[i].ID, nil
 			}
 		}
+		return 0, fmt.Errorf("revision '%s' was not found in the deployment history of the application", rollbackOptions.Revision)
+	}
+
+	if rollbackOptions.ID == nil {
+		return 0, fmt.Errorf("either the ID or the revision of the deployment history entry to roll back to must be specified")
+	}
+
+	for _, entry := range history {
+		if entry.ID == *rollbackOptions.ID {
+			return entry.ID, nil
+		}
 	}
 
-	return nil
+	return 0, fmt.Errorf("ID '%d' was not found in the deployment history of the application", *rollbackOptions.ID)
 }
 
 // ResourceDiff tracks the state of a resource when waiting on an application status.
@@ -124,8 +124,44 @@ var _ = Describe("ArgoCD AppSync Command", func() {
 			}
 
 			cs := NewCredentialService(&clientGenerator, true)
-			err = AppSync(context.Background(), appName, "master", "openshift-gitops", k8sClient, cs, true)
+			_, err = AppSync(context.Background(), appName, "master", "openshift-gitops", k8sClient, cs, true, AppSyncOptions{})
 			Expect(err).ToNot(HaveOccurred())
 		})
 	})
 })
+
+var _ = Describe("ArgoCD AppRollback Command", func() {
+	Context("findRevisionHistoryID function Test", func() {
+
+		history := appv1.RevisionHistories{
+			{ID: 0, Revision: "abc"},
+			{ID: 1, Revision: "def"},
+			{ID: 2, Revision: "abc"},
+		}
+
+		It("should return the most recent deployment history entry of the revision", func() {
+			historyID, err := findRevisionHistoryID(history, AppRollbackOptions{Revision: "abc"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(historyID).To(Equal(int64(2)))
+		})
+
+		It("should return the deployment history entry with the given ID", func() {
+			id := int64(0)
+			historyID, err := findRevisionHistoryID(history, AppRollbackOptions{ID: &id})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(historyID).To(Equal(int64(0)))
+		})
+
+		It("should return an error if the revision or ID is not in the deployment history", func() {
+			_, err := findRevisionHistoryID(history, AppRollbackOptions{Revision: "xyz"})
+			Expect(err).To(HaveOccurred())
+
+			id := int64(3)
+			_, err = findRevisionHistoryID(history, AppRollbackOptions{ID: &id})
+			Expect(err).To(HaveOccurred())
+
+			_, err = findRevisionHistoryID(history, AppRollbackOptions{})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -25,13 +25,15 @@ CREATE TABLE ClusterCredentials (
 	host VARCHAR (512),
 
 	-- State 1) kube_config containing a token to a service account that has the permissions we need.
-	kube_config VARCHAR (65000),
+	-- - Encrypted at rest, when an encryption key provider is configured (see 'backend-shared/db/encryption.go')
+	kube_config VARCHAR (90000),
 
 	-- State 1) The name of a context within the kube_config 
 	kube_config_context VARCHAR (64),
 
 	-- State 2) ServiceAccount bearer token from the target manager cluster
-	serviceaccount_bearer_token VARCHAR (2048),
+	-- - Encrypted at rest, when an encryption key provider is configured (see 'backend-shared/db/encryption.go')
+	serviceaccount_bearer_token VARCHAR (3072),
 
 	-- State 2) The namespace of the ServiceAccount
 	serviceaccount_ns VARCHAR (128),
@@ -49,7 +51,15 @@ CREATE TABLE ClusterCredentials (
 
 	-- Whether or not Argo CD is able to deploy cluster-scoped resources using these cluster credentials
 	-- - This corresponds to the Argo CD cluster secret field of the same name.
-	cluster_resources BOOLEAN DEFAULT FALSE
+	cluster_resources BOOLEAN DEFAULT FALSE,
+
+	-- Reference to the Kubernetes Secret that contains the credentials (a kubeconfig), when the credentials are not
+	-- copied into this row. If set, 'serviceaccount_bearer_token' is empty, and the token is read from the Secret.
+	secret_ref_namespace VARCHAR (64),
+	secret_ref_name VARCHAR (256),
+
+	-- The resourceVersion of the referenced Secret at the time the credentials were validated
+	secret_ref_resource_version VARCHAR (64)
 
 );
 
@@ -218,6 +228,7 @@ CREATE TABLE Operation (
 	-- * GitopsEngineInstance (specified to CRUD an Argo instance, for example to create a new namespace and put Argo CD in it, then signal when it's done)
 	-- * Application (user creates a new Application via service/web UI)
 	-- * SyncOperation (user wants a GitOps engine sync operation performed)
+	-- * Rollback (user wants a GitOps engine rollback operation performed: points to a SyncOperation row)
 	resource_type VARCHAR(32) NOT NULL,
 
 	-- When the operation was created. Used for garbage collection, as operations should be short lived.
@@ -238,7 +249,14 @@ CREATE TABLE Operation (
 	human_readable_state VARCHAR ( 1024 ),
 
 	-- Amount of time to wait in seconds after last_state_update for a completed/failed operation to be garbage collected.
-	gc_expiration_time INT
+	gc_expiration_time INT,
+
+	-- The priority of the operation, relative to other operations of the same user: higher priority operations are processed first.
+	-- possible values:
+	-- * -100 (self-heal, created by the GitOps Service to correct drift)
+	-- * 0 (default, created in response to a change made by the user)
+	-- * 100 (user-initiated sync)
+	priority INT NOT NULL DEFAULT 0
 
 );
 
@@ -257,7 +275,7 @@ CREATE TABLE Application (
 	-- '.spec' field of the Application CR
 	-- Note: Rather than converting individual JSON fields into SQL Table fields, we just pull the whole spec field. 
 	-- In the future, it might be beneficial to pull out SOME of the fields, to reduce CPU time spent on json parsing
-	spec_field VARCHAR ( 16384 ) NOT NULL,
+	spec_field VARCHAR ( 65536 ) NOT NULL,
 
 	-- Which Argo CD instance it's hosted on
 	-- Foreign key to: GitopsEngineInstance.gitopsengineinstance_id
@@ -276,6 +294,9 @@ CREATE TABLE Application (
 
 );
 
+-- Used to count the number of Applications on each GitopsEngineInstance, when placing new users on an instance.
+CREATE INDEX idx_application_engine_instance ON Application(engine_instance_inst_id);
+
 -- ApplicationState is the Argo CD health/sync state of the Application
 CREATE TABLE ApplicationState (
 
@@ -449,6 +470,38 @@ CREATE TABLE SyncOperation (
 	-- values: Running, Terminated
 	desired_state VARCHAR(16) NOT NULL,	
 
+	-- The 'prune', 'dryRun', 'force' and 'replace' fields of the GitOpsDeploymentSyncRun CR
+	prune BOOLEAN DEFAULT FALSE,
+	dry_run BOOLEAN DEFAULT FALSE,
+	force BOOLEAN DEFAULT FALSE,
+	replace BOOLEAN DEFAULT FALSE,
+
+	-- The 'resources' field of the GitOpsDeploymentSyncRun CR, as a JSON list. If empty, all resources are synced.
+	resources VARCHAR(4096),
+
+	-- The 'syncOptions' field of the GitOpsDeploymentSyncRun CR, as a comma-separated list
+	sync_options VARCHAR(1024),
+
+	-- The 'rollbackTo' field of the GitOpsDeploymentSyncRun CR, as JSON. If empty, the SyncOperation is a sync rather than a rollback.
+	rollback_to VARCHAR(512),
+
+	-- The outcome of the sync operation, as observed by the cluster-agent
+	-- values: Pending, Running, Succeeded, Failed, Terminated
+	phase VARCHAR(16),
+
+	-- A human-readable message describing the outcome of the sync operation
+	phase_message VARCHAR(1024),
+
+	-- The times at which the sync operation started and completed
+	started_at TIMESTAMP,
+	finished_at TIMESTAMP,
+
+	-- The revision (git commit SHA) that was synced
+	synced_revision VARCHAR(256),
+
+	-- The compressed list of the Argo CD sync results of each individual resource
+	sync_result bytea,
+
 	seq_id serial,
 
 	-- When SyncOperation was created, which allow us to tell how old the resources are
@@ -475,10 +528,12 @@ CREATE TABLE RepositoryCredentials (
 	repo_cred_user VARCHAR (256),
 
 	-- Authorized password login for accessing the private Git repo
-	repo_cred_pass VARCHAR (1024),
+	-- - Encrypted at rest, when an encryption key provider is configured (see 'backend-shared/db/encryption.go')
+	repo_cred_pass VARCHAR (1536),
 
 	-- Alternative authentication method using an authorized private SSH key
-	repo_cred_ssh VARCHAR (1024),
+	-- - Encrypted at rest, when an encryption key provider is configured (see 'backend-shared/db/encryption.go')
+	repo_cred_ssh VARCHAR (1536),
 
 	-- The name of the Secret resource in the Argo CD Repository, in the GitOps Engine instance
 	repo_cred_secret VARCHAR(48) NOT NULL,
@@ -489,6 +544,14 @@ CREATE TABLE RepositoryCredentials (
 	repo_cred_engine_id VARCHAR(48) NOT NULL,
 	CONSTRAINT fk_gitopsengineinstance_id FOREIGN KEY (repo_cred_engine_id) REFERENCES GitopsEngineInstance(gitopsengineinstance_id) ON DELETE NO ACTION ON UPDATE NO ACTION,
 
+	-- Reference to the Kubernetes Secret that contains the credentials, when the credentials are not copied into this row.
+	-- If set, 'repo_cred_user', 'repo_cred_pass' and 'repo_cred_ssh' are empty, and are read from the Secret.
+	repo_cred_secret_ref_namespace VARCHAR (64),
+	repo_cred_secret_ref_name VARCHAR (256),
+
+	-- The resourceVersion of the referenced Secret at the time the row was last updated
+	repo_cred_secret_ref_resource_version VARCHAR (64),
+
 	seq_id serial,
 
 	-- When RepositoryCredentials was created, which allow us to tell how old the resources are
@@ -542,6 +605,42 @@ CREATE TABLE AppProjectManagedEnvironment (
 -- Add an index on clusteruser_id
 CREATE INDEX idx_userid_cluster_me ON AppProjectManagedEnvironment(clusteruser_id);
 
+-- AppProjectSyncWindow is used by ArgoCD AppProject: each row is a sync window of a GitOpsDeployment
+CREATE TABLE AppProjectSyncWindow (
+
+	-- Primary Key, that is an auto-generated UID
+	appproject_syncwindow_id VARCHAR(48) NOT NULL PRIMARY KEY,
+
+	-- Describes whose cluster this is (UID)
+	-- Foreign key to: ClusterUser.clusteruser_id
+	clusteruser_id VARCHAR (48) NOT NULL,
+	CONSTRAINT fk_clusteruser_id FOREIGN KEY (clusteruser_id) REFERENCES ClusterUser(clusteruser_id) ON DELETE NO ACTION ON UPDATE NO ACTION,
+
+	-- Name of the Argo CD Application that the sync window applies to
+	application_name VARCHAR (256) NOT NULL,
+
+	-- Whether the window allows or denies syncs: 'allow' or 'deny'
+	kind VARCHAR (16) NOT NULL,
+
+	-- The time the window will begin, in cron format
+	schedule VARCHAR (256) NOT NULL,
+
+	-- The amount of time the window will be open
+	duration VARCHAR (64) NOT NULL,
+
+	-- Whether manual syncs are enabled when they would otherwise be blocked by the window
+	manual_sync BOOLEAN DEFAULT FALSE,
+
+	-- Time zone of the schedule (optional)
+	time_zone VARCHAR (64),
+
+	created_on TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+	seq_id serial
+);
+-- Add an index on clusteruser_id
+CREATE INDEX idx_userid_cluster_sw ON AppProjectSyncWindow(clusteruser_id);
+
 -- ApplicationOwner indicates which Applications are owned by which user(s)
 CREATE TABLE ApplicationOwner (
 
@@ -222,12 +222,12 @@ var _ = Describe("Application Promotion Run E2E Tests.", func() {
 			Eventually(promotionRun, "3m", "1s").Should(promotionRunFixture.HaveStatusComplete(expectedPromotionRunStatus))
 		})
 
-		It("Should not support Auto Promotion.", func() {
+		It("Should report an error if the initial Environment of an automated promotion does not exist.", func() {
 
 			By("Create PromotionRun CR.")
 			promotionRun.Spec.ManualPromotion = appstudiosharedv1.ManualPromotionConfiguration{}
 			promotionRun.Spec.AutomatedPromotion = appstudiosharedv1.AutomatedPromotionConfiguration{
-				InitialEnvironment: "staging",
+				InitialEnvironment: "does-not-exist",
 			}
 
 			k8sClient, err := fixture.GetE2ETestUserWorkspaceKubeClient()
@@ -240,7 +240,7 @@ var _ = Describe("Application Promotion Run E2E Tests.", func() {
 				Conditions: []appstudiosharedv1.PromotionRunCondition{
 					{
 						Type:    appstudiosharedv1.PromotionRunConditionErrorOccurred,
-						Message: appstudiocontroller.ErrMessageAutomatedPromotionNotSupported,
+						Message: appstudiocontroller.ErrMessageInitialEnvironmentDoesNotExist + "does-not-exist",
 						Status:  appstudiosharedv1.PromotionRunConditionStatusTrue,
 						Reason:  appstudiosharedv1.PromotionRunReasonErrorOccurred,
 					},
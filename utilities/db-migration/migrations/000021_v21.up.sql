@@ -0,0 +1 @@
+ALTER TABLE Application ALTER COLUMN spec_field TYPE VARCHAR ( 65536 );
\ No newline at end of file
@@ -0,0 +1,35 @@
+-- AppProjectSyncWindow is used by ArgoCD AppProject: each row is a sync window of a GitOpsDeployment
+CREATE TABLE AppProjectSyncWindow (
+
+	-- Primary Key, that is an auto-generated UID
+	appproject_syncwindow_id VARCHAR(48) NOT NULL PRIMARY KEY,
+
+	-- Describes whose cluster this is (UID)
+	-- Foreign key to: ClusterUser.clusteruser_id
+	clusteruser_id VARCHAR (48) NOT NULL,
+	CONSTRAINT fk_clusteruser_id FOREIGN KEY (clusteruser_id) REFERENCES ClusterUser(clusteruser_id) ON DELETE NO ACTION ON UPDATE NO ACTION,
+
+	-- Name of the Argo CD Application that the sync window applies to
+	application_name VARCHAR (256) NOT NULL,
+
+	-- Whether the window allows or denies syncs: 'allow' or 'deny'
+	kind VARCHAR (16) NOT NULL,
+
+	-- The time the window will begin, in cron format
+	schedule VARCHAR (256) NOT NULL,
+
+	-- The amount of time the window will be open
+	duration VARCHAR (64) NOT NULL,
+
+	-- Whether manual syncs are enabled when they would otherwise be blocked by the window
+	manual_sync BOOLEAN DEFAULT FALSE,
+
+	-- Time zone of the schedule (optional)
+	time_zone VARCHAR (64),
+
+	created_on TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+	seq_id serial
+);
+-- Add an index on clusteruser_id
+CREATE INDEX idx_userid_cluster_sw ON AppProjectSyncWindow(clusteruser_id);
\ No newline at end of file
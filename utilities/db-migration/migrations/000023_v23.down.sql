@@ -0,0 +1,6 @@
+ALTER TABLE SyncOperation DROP COLUMN prune;
+ALTER TABLE SyncOperation DROP COLUMN dry_run;
+ALTER TABLE SyncOperation DROP COLUMN force;
+ALTER TABLE SyncOperation DROP COLUMN replace;
+ALTER TABLE SyncOperation DROP COLUMN resources;
+ALTER TABLE SyncOperation DROP COLUMN sync_options;
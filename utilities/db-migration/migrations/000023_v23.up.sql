@@ -0,0 +1,6 @@
+ALTER TABLE SyncOperation ADD COLUMN prune BOOLEAN DEFAULT FALSE;
+ALTER TABLE SyncOperation ADD COLUMN dry_run BOOLEAN DEFAULT FALSE;
+ALTER TABLE SyncOperation ADD COLUMN force BOOLEAN DEFAULT FALSE;
+ALTER TABLE SyncOperation ADD COLUMN replace BOOLEAN DEFAULT FALSE;
+ALTER TABLE SyncOperation ADD COLUMN resources VARCHAR (4096);
+ALTER TABLE SyncOperation ADD COLUMN sync_options VARCHAR (1024);
@@ -0,0 +1,6 @@
+ALTER TABLE SyncOperation DROP COLUMN phase;
+ALTER TABLE SyncOperation DROP COLUMN phase_message;
+ALTER TABLE SyncOperation DROP COLUMN started_at;
+ALTER TABLE SyncOperation DROP COLUMN finished_at;
+ALTER TABLE SyncOperation DROP COLUMN synced_revision;
+ALTER TABLE SyncOperation DROP COLUMN sync_result;
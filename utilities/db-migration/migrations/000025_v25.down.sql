@@ -0,0 +1 @@
+ALTER TABLE SyncOperation DROP COLUMN rollback_to;
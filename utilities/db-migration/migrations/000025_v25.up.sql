@@ -0,0 +1 @@
+ALTER TABLE SyncOperation ADD COLUMN rollback_to VARCHAR (512);
@@ -0,0 +1,14 @@
+-- The original column sizes can not hold encrypted values, which must not be truncated (or lost): refuse to roll back
+-- while any encrypted values exist (see docs/db-encryption.md).
+DO $$
+BEGIN
+	IF EXISTS (SELECT 1 FROM ClusterCredentials WHERE kube_config LIKE 'gitops-enc:v1:%' OR serviceaccount_bearer_token LIKE 'gitops-enc:v1:%')
+		OR EXISTS (SELECT 1 FROM RepositoryCredentials WHERE repo_cred_pass LIKE 'gitops-enc:v1:%' OR repo_cred_ssh LIKE 'gitops-enc:v1:%') THEN
+		RAISE EXCEPTION 'unable to roll back migration 000026: ClusterCredentials or RepositoryCredentials contain encrypted values';
+	END IF;
+END $$;
+
+ALTER TABLE ClusterCredentials ALTER COLUMN kube_config TYPE VARCHAR (65000);
+ALTER TABLE ClusterCredentials ALTER COLUMN serviceaccount_bearer_token TYPE VARCHAR (2048);
+ALTER TABLE RepositoryCredentials ALTER COLUMN repo_cred_pass TYPE VARCHAR (1024);
+ALTER TABLE RepositoryCredentials ALTER COLUMN repo_cred_ssh TYPE VARCHAR (1024);
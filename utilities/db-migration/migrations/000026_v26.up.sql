@@ -0,0 +1,4 @@
+ALTER TABLE ClusterCredentials ALTER COLUMN kube_config TYPE VARCHAR (90000);
+ALTER TABLE ClusterCredentials ALTER COLUMN serviceaccount_bearer_token TYPE VARCHAR (3072);
+ALTER TABLE RepositoryCredentials ALTER COLUMN repo_cred_pass TYPE VARCHAR (1536);
+ALTER TABLE RepositoryCredentials ALTER COLUMN repo_cred_ssh TYPE VARCHAR (1536);
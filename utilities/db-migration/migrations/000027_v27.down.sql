@@ -0,0 +1,6 @@
+ALTER TABLE ClusterCredentials DROP COLUMN secret_ref_namespace;
+ALTER TABLE ClusterCredentials DROP COLUMN secret_ref_name;
+ALTER TABLE ClusterCredentials DROP COLUMN secret_ref_resource_version;
+ALTER TABLE RepositoryCredentials DROP COLUMN repo_cred_secret_ref_namespace;
+ALTER TABLE RepositoryCredentials DROP COLUMN repo_cred_secret_ref_name;
+ALTER TABLE RepositoryCredentials DROP COLUMN repo_cred_secret_ref_resource_version;
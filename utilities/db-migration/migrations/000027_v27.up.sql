@@ -0,0 +1,6 @@
+ALTER TABLE ClusterCredentials ADD COLUMN secret_ref_namespace VARCHAR (64);
+ALTER TABLE ClusterCredentials ADD COLUMN secret_ref_name VARCHAR (256);
+ALTER TABLE ClusterCredentials ADD COLUMN secret_ref_resource_version VARCHAR (64);
+ALTER TABLE RepositoryCredentials ADD COLUMN repo_cred_secret_ref_namespace VARCHAR (64);
+ALTER TABLE RepositoryCredentials ADD COLUMN repo_cred_secret_ref_name VARCHAR (256);
+ALTER TABLE RepositoryCredentials ADD COLUMN repo_cred_secret_ref_resource_version VARCHAR (64);
@@ -0,0 +1 @@
+ALTER TABLE Operation DROP COLUMN priority;
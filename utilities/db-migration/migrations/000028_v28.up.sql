@@ -0,0 +1 @@
+ALTER TABLE Operation ADD COLUMN priority INT NOT NULL DEFAULT 0;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_application_engine_instance;
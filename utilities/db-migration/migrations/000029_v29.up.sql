@@ -0,0 +1 @@
+CREATE INDEX idx_application_engine_instance ON Application(engine_instance_inst_id);
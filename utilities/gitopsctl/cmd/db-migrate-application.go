@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	migrateapplication "github.com/redhat-appstudio/managed-gitops/utilities/gitopsctl/implementations/migrate-application"
+	"github.com/spf13/cobra"
+)
+
+var (
+	migrateTargetEngineInstanceID string
+	migrateTimeout                time.Duration
+)
+
+// migrateApplicationCmd represents the migrate-application command
+var migrateApplicationCmd = &cobra.Command{
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("expected a single argument, the ID of the Application row: use --help flag for details")
+		}
+
+		if migrateTargetEngineInstanceID == "" {
+			return fmt.Errorf("--target-engine-instance must be specified: use --help flag for details")
+		}
+
+		return nil
+	},
+	Use:   "migrate-application (application id)",
+	Short: "Migrate an Application to another GitOps engine (Argo CD) instance, without redeploying its resources",
+	Long: `
+Migrate an Application row (and the Argo CD Application that is generated from it) to another
+GitOps engine instance, without deleting (or redeploying) the resources that were deployed by it.
+
+The migration proceeds as follows:
+1) A copy of the Argo CD Application is created on the target instance, with automated sync disabled.
+2) Wait for the copy to be Synced and Healthy: since it has the same name as the original, Argo CD on
+   the target instance recognizes the resources that were deployed by the source instance.
+3) The Application row is updated to point to the target instance.
+4) The Argo CD Application is deleted from the source instance, without deleting its resources.
+5) The sync policy of the Argo CD Application on the target instance is restored.
+
+If the copy does not become Synced and Healthy within --timeout, it is removed from the target instance
+(again without deleting any resources), and the Application remains on the source instance.
+
+The command uses the current kubeconfig context, which should point to the cluster of the GitOps Service
+backend. The kubeconfig Secrets of remote GitOps engine clusters are read from that cluster.
+
+Examples:
+- gitopsctl db migrate-application 1b7c6d3e-51d2-4cd8-9f39-b9b12b6c8f0b --target-engine-instance 5ab6b0a4-e7b6-4bb0-a8a0-8a9ab3ec5b3d
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		migrateapplication.RunMigrateApplicationCommand(args[0], migrateTargetEngineInstanceID, migrateTimeout)
+
+	},
+}
+
+func init() {
+	dbCmd.AddCommand(migrateApplicationCmd)
+
+	migrateApplicationCmd.Flags().StringVar(&migrateTargetEngineInstanceID, "target-engine-instance", "", "ID of the GitopsEngineInstance row to migrate the Application to")
+	migrateApplicationCmd.Flags().DurationVar(&migrateTimeout, "timeout", 10*time.Minute, "How long to wait for the Argo CD Application on the target instance to be Synced and Healthy")
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	dbreencrypt "github.com/redhat-appstudio/managed-gitops/utilities/gitopsctl/implementations/db-reencrypt"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reencryptKeyDir    string
+	reencryptKeySecret string
+	reencryptBatchSize int
+)
+
+// reencryptCmd represents the reencrypt command
+var reencryptCmd = &cobra.Command{
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 0 {
+			return fmt.Errorf("unexpected arguments: use --help flag for details")
+		}
+
+		if (reencryptKeyDir == "") == (reencryptKeySecret == "") {
+			return fmt.Errorf("exactly one of --key-dir or --key-secret must be specified: use --help flag for details")
+		}
+
+		if reencryptKeySecret != "" && len(strings.Split(reencryptKeySecret, "/")) != 2 {
+			return fmt.Errorf("--key-secret must be of the form (namespace)/(name): use --help flag for details")
+		}
+
+		return nil
+	},
+	Use:   "reencrypt",
+	Short: "Re-encrypt the ClusterCredentials and RepositoryCredentials rows with the current encryption key",
+	Long: `
+Re-encrypt the sensitive fields of all ClusterCredentials and RepositoryCredentials rows
+with the current encryption key.
+
+- Rows that were written before encryption was enabled (plaintext) are encrypted.
+- Rows that were encrypted with an older key are re-encrypted with the current key.
+
+The keys are read either from a directory (--key-dir), or from a Kubernetes Secret 
+(--key-secret, using the current kubeconfig context). The directory/Secret contains:
+- 'current-key-id': the ID of the key to encrypt with
+- one entry per key: the key ID as the name, and a base64-encoded 32 byte key as the value
+
+To rotate keys:
+1) Add the new key to the directory/Secret, and set 'current-key-id' to the new key ID.
+2) Wait for the GitOps Service controllers to pick up the new key (or restart them).
+3) Run this command, to re-encrypt all rows with the new key.
+4) Remove the old key from the directory/Secret.
+
+Examples:
+- gitopsctl db reencrypt --key-dir /etc/gitops/db-encryption-keys
+- gitopsctl db reencrypt --key-secret gitops/gitops-db-encryption-keys
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		dbreencrypt.RunReencryptCommand(reencryptKeyDir, reencryptKeySecret, reencryptBatchSize)
+
+	},
+}
+
+func init() {
+	dbCmd.AddCommand(reencryptCmd)
+
+	reencryptCmd.Flags().StringVar(&reencryptKeyDir, "key-dir", "", "Directory containing the encryption keys")
+	reencryptCmd.Flags().StringVar(&reencryptKeySecret, "key-secret", "", "Kubernetes Secret containing the encryption keys, of the form (namespace)/(name)")
+	reencryptCmd.Flags().IntVar(&reencryptBatchSize, "batch-size", 100, "Number of rows to re-encrypt per batch")
+}
@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// dbCmd represents the db command
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Perform maintenance tasks against the GitOps Service database",
+	Long: `A subcommand that allows performing maintenance tasks against the GitOps Service database.
+
+The database connection is configured using the same environment variables as the
+GitOps Service controllers (e.g. DB_ADDR, DB_PASS).`,
+	// Run: func(cmd *cobra.Command, args []string) {
+	// },
+}
+
+func init() {
+	rootCmd.AddCommand(dbCmd)
+}
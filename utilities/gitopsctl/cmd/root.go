@@ -17,7 +17,8 @@ useful to them.)
 	
 The goal of this tool is to provide reusable commands which can be used to
 reduce the toil of supporting/debugging the GitOps Service.
-- Downloading the logs from OpenShift CI jobs`,
+- Downloading the logs from OpenShift CI jobs
+- Re-encrypting database credentials, and rotating the database encryption keys`,
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -0,0 +1,88 @@
+package dbreencrypt
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/redhat-appstudio/managed-gitops/backend-shared/db"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+)
+
+func RunReencryptCommand(keyDir string, keySecret string, batchSize int) {
+
+	if err := runReencryptCommandInternal(keyDir, keySecret, batchSize); err != nil {
+		fmt.Println("* Error:", err.Error())
+		os.Exit(1)
+		return
+	}
+}
+
+func runReencryptCommandInternal(keyDir string, keySecret string, batchSize int) error {
+
+	ctx := context.Background()
+
+	provider, err := newEncryptionKeyProvider(keyDir, keySecret)
+	if err != nil {
+		return err
+	}
+
+	// Verify the keys can be read before connecting to the database
+	currentKeyID, _, err := provider.CurrentKey(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to read current encryption key: %v", err)
+	}
+
+	db.SetEncryptionKeyProvider(provider)
+
+	fmt.Println("* Connecting to database")
+
+	// We use the 'unsafe' constructor here, rather than the shared production constructor, as we want to fail fast
+	// (rather than retrying indefinitely) when the database is not reachable.
+	dbq, err := db.NewUnsafePostgresDBQueries(false, false)
+	if err != nil {
+		return fmt.Errorf("unable to connect to database: %v", err)
+	}
+	defer dbq.CloseDatabase()
+
+	fmt.Println("* Re-encrypting credentials with key", currentKeyID)
+
+	clusterCredentialsUpdated, repositoryCredentialsUpdated, err := db.ReencryptCredentials(ctx, dbq, batchSize)
+
+	fmt.Println("* ClusterCredentials rows re-encrypted:", clusterCredentialsUpdated)
+	fmt.Println("* RepositoryCredentials rows re-encrypted:", repositoryCredentialsUpdated)
+
+	return err
+}
+
+func newEncryptionKeyProvider(keyDir string, keySecret string) (db.EncryptionKeyProvider, error) {
+
+	if keyDir != "" {
+		return db.NewFileEncryptionKeyProvider(keyDir), nil
+	}
+
+	namespace, name, err := db.ParseEncryptionKeySecret(keySecret)
+	if err != nil {
+		return nil, err
+	}
+
+	restConfig, err := config.GetConfig()
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve kubeconfig: %v", err)
+	}
+
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+
+	k8sClient, err := client.New(restConfig, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create Kubernetes client: %v", err)
+	}
+
+	return db.NewKubernetesSecretEncryptionKeyProvider(k8sClient, namespace, name), nil
+}
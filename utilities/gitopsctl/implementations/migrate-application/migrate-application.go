@@ -0,0 +1,496 @@
+package migrateapplication
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/go-logr/logr"
+	managedgitopsv1alpha1 "github.com/redhat-appstudio/managed-gitops/backend-shared/apis/managed-gitops/v1alpha1"
+	"github.com/redhat-appstudio/managed-gitops/backend-shared/db"
+	dbutil "github.com/redhat-appstudio/managed-gitops/backend-shared/db/util"
+	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/fauxargocd"
+	"github.com/redhat-appstudio/managed-gitops/backend-shared/util/operations"
+	goyaml "gopkg.in/yaml.v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+)
+
+const (
+	argoCDApplicationSynced  = "Synced"
+	argoCDApplicationHealthy = "Healthy"
+
+	pollInterval = 5 * time.Second
+
+	// switchEngineInstanceAttempts is the number of times the Application row is re-read and conditionally updated, if
+	// it was concurrently updated by the backend.
+	switchEngineInstanceAttempts = 5
+)
+
+func RunMigrateApplicationCommand(applicationID string, targetEngineInstanceID string, timeout time.Duration) {
+
+	if err := runMigrateApplicationCommandInternal(applicationID, targetEngineInstanceID, timeout); err != nil {
+		fmt.Println("* Error:", err.Error())
+		os.Exit(1)
+		return
+	}
+}
+
+// migration contains the state of a single Application migration
+type migration struct {
+	dbq db.DatabaseQueries
+
+	application db.Application
+
+	// ownerUserID is the ClusterUser that owns the Application: Operations are created as that user, so that the
+	// cluster-agent uses the user's AppProject.
+	ownerUserID string
+
+	sourceInstance db.GitopsEngineInstance
+	targetInstance db.GitopsEngineInstance
+
+	sourceClient client.Client
+	targetClient client.Client
+
+	// movedRepositoryCredentials are the IDs of the RepositoryCredentials rows that were moved to the target instance.
+	movedRepositoryCredentials []string
+
+	// createdClusterAccess is true if the ClusterAccess of the owner to the managed environment, on the target instance,
+	// was created by the migration.
+	createdClusterAccess bool
+}
+
+func runMigrateApplicationCommandInternal(applicationID string, targetEngineInstanceID string, timeout time.Duration) error {
+
+	ctx := context.Background()
+
+	restConfig, err := config.GetConfig()
+	if err != nil {
+		return fmt.Errorf("unable to retrieve kubeconfig: %v", err)
+	}
+
+	serviceClient, err := newK8sClient(restConfig)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("* Connecting to database")
+
+	// We use the 'unsafe' constructor here, rather than the shared production constructor, as we want to fail fast
+	// (rather than retrying indefinitely) when the database is not reachable.
+	dbq, err := db.NewUnsafePostgresDBQueries(false, false)
+	if err != nil {
+		return fmt.Errorf("unable to connect to database: %v", err)
+	}
+	defer dbq.CloseDatabase()
+
+	m, err := newMigration(ctx, applicationID, targetEngineInstanceID, serviceClient, dbq)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("* Migrating Application '%s' from GitOps engine instance '%s' (namespace '%s') to '%s' (namespace '%s')\n",
+		m.application.Application_id, m.sourceInstance.Gitopsengineinstance_id, m.sourceInstance.Namespace_name,
+		m.targetInstance.Gitopsengineinstance_id, m.targetInstance.Namespace_name)
+
+	return m.run(ctx, timeout)
+}
+
+func newMigration(ctx context.Context, applicationID string, targetEngineInstanceID string, serviceClient client.Client, dbq db.DatabaseQueries) (*migration, error) {
+
+	m := &migration{
+		dbq:         dbq,
+		application: db.Application{Application_id: applicationID},
+	}
+
+	if err := dbq.GetApplicationById(ctx, &m.application); err != nil {
+		return nil, fmt.Errorf("unable to retrieve Application '%s': %v", applicationID, err)
+	}
+
+	if m.application.Engine_instance_inst_id == targetEngineInstanceID {
+		return nil, fmt.Errorf("application '%s' is already hosted on GitOps engine instance '%s'", applicationID, targetEngineInstanceID)
+	}
+
+	if m.application.Managed_environment_id == "" {
+		return nil, fmt.Errorf("application '%s' does not have a valid managed environment, and so can't be migrated", applicationID)
+	}
+
+	applicationOwner := db.ApplicationOwner{ApplicationOwnerApplicationID: applicationID}
+	if err := dbq.GetApplicationOwnerByApplicationID(ctx, &applicationOwner); err != nil {
+		return nil, fmt.Errorf("unable to retrieve the owner of Application '%s': %v", applicationID, err)
+	}
+	m.ownerUserID = applicationOwner.ApplicationOwnerUserID
+
+	m.sourceInstance = db.GitopsEngineInstance{Gitopsengineinstance_id: m.application.Engine_instance_inst_id}
+	if err := dbq.GetGitopsEngineInstanceById(ctx, &m.sourceInstance); err != nil {
+		return nil, fmt.Errorf("unable to retrieve source GitOps engine instance '%s': %v", m.sourceInstance.Gitopsengineinstance_id, err)
+	}
+
+	m.targetInstance = db.GitopsEngineInstance{Gitopsengineinstance_id: targetEngineInstanceID}
+	if err := dbq.GetGitopsEngineInstanceById(ctx, &m.targetInstance); err != nil {
+		return nil, fmt.Errorf("unable to retrieve target GitOps engine instance '%s': %v", targetEngineInstanceID, err)
+	}
+
+	var err error
+	if m.sourceClient, err = newK8sClientForGitOpsEngineInstance(ctx, m.sourceInstance, serviceClient, dbq); err != nil {
+		return nil, err
+	}
+	if m.targetClient, err = newK8sClientForGitOpsEngineInstance(ctx, m.targetInstance, serviceClient, dbq); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (m *migration) run(ctx context.Context, timeout time.Duration) error {
+
+	fmt.Println("* Granting the owner of the Application access to the managed environment on the target instance")
+	if err := m.createTargetClusterAccess(ctx); err != nil {
+		return m.rollback(ctx, err)
+	}
+
+	fmt.Println("* Moving the repository credentials of the owner of the Application to the target instance")
+	if err := m.moveRepositoryCredentials(ctx); err != nil {
+		return m.rollback(ctx, err)
+	}
+
+	fmt.Println("* Staging a copy of the Argo CD Application on the target instance, with automated sync disabled")
+	if err := m.runOperation(ctx, db.OperationResourceType_ApplicationMigrationStage, m.application.Application_id, m.targetInstance, m.targetClient); err != nil {
+		return m.rollback(ctx, fmt.Errorf("unable to stage Argo CD Application on target instance: %v", err))
+	}
+
+	fmt.Println("* Waiting for the Argo CD Application on the target instance to be Synced and Healthy")
+	if err := waitForArgoCDApplicationSyncedAndHealthy(ctx, m.targetClient, m.targetInstance.Namespace_name, m.application.Name, timeout); err != nil {
+		return m.rollback(ctx, err)
+	}
+
+	fmt.Println("* Updating the Application row to point to the target instance")
+	if err := m.switchEngineInstance(ctx); err != nil {
+		return m.rollback(ctx, err)
+	}
+
+	// From this point on, the Application is hosted on the target instance, so we no longer roll back on failure.
+
+	fmt.Println("* Deleting the Argo CD Application from the source instance, without deleting its resources")
+	if err := m.runOperation(ctx, db.OperationResourceType_ApplicationMigrationOrphan, m.application.Application_id, m.sourceInstance, m.sourceClient); err != nil {
+		return fmt.Errorf("the Application is now hosted on the target instance, but the Argo CD Application could not be removed from the source instance: %v", err)
+	}
+
+	fmt.Println("* Restoring the sync policy of the Argo CD Application on the target instance")
+	if err := m.runOperation(ctx, db.OperationResourceType_Application, m.application.Application_id, m.targetInstance, m.targetClient); err != nil {
+		return fmt.Errorf("the Application is now hosted on the target instance, but its sync policy could not be restored: %v", err)
+	}
+
+	fmt.Println("* Removing the access of the owner of the Application to the managed environment on the source instance, if no longer used")
+	if err := m.deleteSourceClusterAccess(ctx); err != nil {
+		fmt.Println("* Warning: unable to remove the ClusterAccess on the source instance:", err)
+	}
+
+	fmt.Println("* Migration complete")
+
+	return nil
+}
+
+// rollback removes the staged Argo CD Application from the target instance (without deleting its resources), moves
+// the repository credentials back to the source instance, and removes the ClusterAccess created on the target
+// instance, after the migration failed. Returns the original error.
+func (m *migration) rollback(ctx context.Context, migrationErr error) error {
+
+	fmt.Println("* Migration failed, removing the staged Argo CD Application from the target instance:", migrationErr)
+
+	if err := m.runOperation(ctx, db.OperationResourceType_ApplicationMigrationOrphan, m.application.Application_id, m.targetInstance, m.targetClient); err != nil {
+		return fmt.Errorf("%v (and unable to remove the staged Argo CD Application from the target instance: %v)", migrationErr, err)
+	}
+
+	for _, repositoryCredentialsID := range m.movedRepositoryCredentials {
+		if _, err := m.dbq.UpdateRepositoryCredentialsEngineInstance(ctx, repositoryCredentialsID,
+			m.targetInstance.Gitopsengineinstance_id, m.sourceInstance.Gitopsengineinstance_id); err != nil {
+			return fmt.Errorf("%v (and unable to move repository credentials '%s' back to the source instance: %v)", migrationErr, repositoryCredentialsID, err)
+		}
+	}
+
+	if m.createdClusterAccess {
+		if _, err := m.dbq.DeleteClusterAccessById(ctx, m.ownerUserID, m.application.Managed_environment_id,
+			m.targetInstance.Gitopsengineinstance_id); err != nil {
+			return fmt.Errorf("%v (and unable to remove the ClusterAccess on the target instance: %v)", migrationErr, err)
+		}
+	}
+
+	return migrationErr
+}
+
+// createTargetClusterAccess gives the owner of the Application access to its managed environment on the target
+// instance, if they do not already have it. The cluster-agent of the target instance uses the ClusterAccess rows to
+// recreate the Argo CD cluster Secrets of the instance.
+func (m *migration) createTargetClusterAccess(ctx context.Context) error {
+
+	clusterAccess := db.ClusterAccess{
+		Clusteraccess_user_id:                   m.ownerUserID,
+		Clusteraccess_managed_environment_id:    m.application.Managed_environment_id,
+		Clusteraccess_gitops_engine_instance_id: m.targetInstance.Gitopsengineinstance_id,
+	}
+
+	if err := m.dbq.GetClusterAccessByPrimaryKey(ctx, &clusterAccess); err == nil {
+		return nil
+	} else if !db.IsResultNotFoundError(err) {
+		return fmt.Errorf("unable to retrieve ClusterAccess on the target instance: %v", err)
+	}
+
+	if err := m.dbq.CreateClusterAccess(ctx, &clusterAccess); err != nil {
+		return fmt.Errorf("unable to create ClusterAccess on the target instance: %v", err)
+	}
+	m.createdClusterAccess = true
+
+	return nil
+}
+
+// deleteSourceClusterAccess removes the access of the owner of the Application to its managed environment on the
+// source instance, if no other Application on the source instance uses that managed environment.
+func (m *migration) deleteSourceClusterAccess(ctx context.Context) error {
+
+	var applications []db.Application
+	if _, err := m.dbq.ListApplicationsForManagedEnvironment(ctx, m.application.Managed_environment_id, &applications); err != nil {
+		return fmt.Errorf("unable to list Applications of managed environment '%s': %v", m.application.Managed_environment_id, err)
+	}
+
+	for _, application := range applications {
+		if application.Engine_instance_inst_id == m.sourceInstance.Gitopsengineinstance_id {
+			// The managed environment is still used on the source instance.
+			return nil
+		}
+	}
+
+	if _, err := m.dbq.DeleteClusterAccessById(ctx, m.ownerUserID, m.application.Managed_environment_id,
+		m.sourceInstance.Gitopsengineinstance_id); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// moveRepositoryCredentials moves the RepositoryCredentials rows of the owner of the Application, from the source
+// instance to the target instance, and waits for the cluster-agent to create the corresponding Argo CD Secrets on the
+// target instance.
+//
+// The rows are moved (rather than copied), as each row is mapped to a single GitOpsDeploymentRepositoryCredential by
+// the backend. The Argo CD Secrets on the source instance are not deleted, as they remain in use by the other
+// Argo CD Applications of the owner on the source instance.
+func (m *migration) moveRepositoryCredentials(ctx context.Context) error {
+
+	var repositoryCredentials []db.RepositoryCredentials
+	if err := m.dbq.ListRepositoryCredentialsByClusterUserID(ctx, m.ownerUserID, &repositoryCredentials); err != nil {
+		return fmt.Errorf("unable to list repository credentials of user '%s': %v", m.ownerUserID, err)
+	}
+
+	for _, repositoryCredential := range repositoryCredentials {
+
+		if repositoryCredential.EngineClusterID != m.sourceInstance.Gitopsengineinstance_id {
+			continue
+		}
+
+		rowsUpdated, err := m.dbq.UpdateRepositoryCredentialsEngineInstance(ctx, repositoryCredential.RepositoryCredentialsID,
+			m.sourceInstance.Gitopsengineinstance_id, m.targetInstance.Gitopsengineinstance_id)
+		if err != nil {
+			return fmt.Errorf("unable to move repository credentials '%s' to the target instance: %v", repositoryCredential.RepositoryCredentialsID, err)
+		}
+		if rowsUpdated != 1 {
+			// The row was deleted, or moved, since we listed it.
+			continue
+		}
+		m.movedRepositoryCredentials = append(m.movedRepositoryCredentials, repositoryCredential.RepositoryCredentialsID)
+
+		if err := m.runOperation(ctx, db.OperationResourceType_RepositoryCredentials, repositoryCredential.RepositoryCredentialsID,
+			m.targetInstance, m.targetClient); err != nil {
+			return fmt.Errorf("unable to create the repository credentials '%s' on the target instance: %v", repositoryCredential.RepositoryCredentialsID, err)
+		}
+	}
+
+	return nil
+}
+
+// switchEngineInstance updates the Application row to be hosted on the target instance.
+//
+// The row is updated with a single conditional update, which only succeeds if the row is still hosted on the source
+// instance, and its spec field is unchanged: if the backend updated the row while we were waiting, we re-read it and
+// try again.
+func (m *migration) switchEngineInstance(ctx context.Context) error {
+
+	for attempt := 0; attempt < switchEngineInstanceAttempts; attempt++ {
+
+		// Retrieve the latest version of the row, in case it was updated by the backend while we were waiting.
+		application := db.Application{Application_id: m.application.Application_id}
+		if err := m.dbq.GetApplicationById(ctx, &application); err != nil {
+			return fmt.Errorf("unable to retrieve Application '%s': %v", application.Application_id, err)
+		}
+
+		if application.Engine_instance_inst_id != m.sourceInstance.Gitopsengineinstance_id {
+			return fmt.Errorf("application '%s' was moved to GitOps engine instance '%s' during the migration",
+				application.Application_id, application.Engine_instance_inst_id)
+		}
+
+		expectedSpecField := application.Spec_field
+
+		specField, err := setSpecFieldNamespace(application.Spec_field, m.targetInstance.Namespace_name)
+		if err != nil {
+			return err
+		}
+
+		application.Spec_field = specField
+		application.Engine_instance_inst_id = m.targetInstance.Gitopsengineinstance_id
+
+		rowsUpdated, err := m.dbq.UpdateApplicationEngineInstance(ctx, &application, m.sourceInstance.Gitopsengineinstance_id, expectedSpecField)
+		if err != nil {
+			return fmt.Errorf("unable to update Application '%s': %v", application.Application_id, err)
+		}
+
+		if rowsUpdated == 1 {
+			m.application = application
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unable to update Application '%s': the row was concurrently modified %d times",
+		m.application.Application_id, switchEngineInstanceAttempts)
+}
+
+// runOperation creates an Operation of the given type for the given resource, on the given instance, and waits for
+// the cluster-agent to complete it.
+func (m *migration) runOperation(ctx context.Context, resourceType db.OperationResourceType, resourceID string,
+	instance db.GitopsEngineInstance, k8sClient client.Client) error {
+
+	dbOperationInput := db.Operation{
+		Instance_id:   instance.Gitopsengineinstance_id,
+		Resource_id:   resourceID,
+		Resource_type: resourceType,
+	}
+
+	k8sOperation, dbOperation, err := operations.CreateOperation(ctx, true, dbOperationInput, m.ownerUserID,
+		instance.Namespace_name, m.dbq, k8sClient, logr.Discard())
+	if err != nil {
+		return fmt.Errorf("unable to create %s Operation: %v", resourceType, err)
+	}
+
+	if dbOperation.State == db.OperationState_Failed {
+		err = fmt.Errorf("%s Operation failed: %s", resourceType, dbOperation.Human_readable_state)
+	}
+
+	if cleanupErr := operations.CleanupOperation(ctx, *dbOperation, *k8sOperation, m.dbq, k8sClient, true, logr.Discard()); cleanupErr != nil {
+		fmt.Println("* Warning: unable to clean up Operation", dbOperation.Operation_id, cleanupErr)
+	}
+
+	return err
+}
+
+// waitForArgoCDApplicationSyncedAndHealthy waits until the given Argo CD Application reports that it is Synced and Healthy.
+func waitForArgoCDApplicationSyncedAndHealthy(ctx context.Context, k8sClient client.Client, namespace string, name string, timeout time.Duration) error {
+
+	expireTime := time.Now().Add(timeout)
+
+	lastStatus := ""
+	for {
+		syncStatus, healthStatus, err := getArgoCDApplicationStatus(ctx, k8sClient, namespace, name)
+		if err != nil {
+			return err
+		}
+
+		if syncStatus == argoCDApplicationSynced && healthStatus == argoCDApplicationHealthy {
+			return nil
+		}
+
+		if status := fmt.Sprintf("sync: '%s', health: '%s'", syncStatus, healthStatus); status != lastStatus {
+			fmt.Println("  - Argo CD Application status is", status)
+			lastStatus = status
+		}
+
+		if time.Now().After(expireTime) {
+			return fmt.Errorf("the Argo CD Application on the target instance was not Synced and Healthy after %v (%s)", timeout, lastStatus)
+		}
+
+		time.Sleep(pollInterval)
+	}
+}
+
+// getArgoCDApplicationStatus returns the sync and health status of the given Argo CD Application.
+func getArgoCDApplicationStatus(ctx context.Context, k8sClient client.Client, namespace string, name string) (string, string, error) {
+
+	// An unstructured object is used, so that we do not need to depend on the Argo CD API types.
+	app := &unstructured.Unstructured{}
+	app.SetGroupVersionKind(schema.GroupVersionKind{Group: "argoproj.io", Version: "v1alpha1", Kind: "Application"})
+
+	if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, app); err != nil {
+		return "", "", fmt.Errorf("unable to retrieve Argo CD Application '%s' in namespace '%s': %v", name, namespace, err)
+	}
+
+	syncStatus, _, err := unstructured.NestedString(app.Object, "status", "sync", "status")
+	if err != nil {
+		return "", "", fmt.Errorf("unable to read sync status of Argo CD Application '%s': %v", name, err)
+	}
+
+	healthStatus, _, err := unstructured.NestedString(app.Object, "status", "health", "status")
+	if err != nil {
+		return "", "", fmt.Errorf("unable to read health status of Argo CD Application '%s': %v", name, err)
+	}
+
+	return syncStatus, healthStatus, nil
+}
+
+// setSpecFieldNamespace returns the given Application spec field, with the namespace of the Argo CD Application
+// replaced with the given namespace.
+func setSpecFieldNamespace(specField string, namespace string) (string, error) {
+
+	// The spec field is generated by the backend, from a FauxApplication, using gopkg.in/yaml.v2.
+	var application fauxargocd.FauxApplication
+	if err := goyaml.Unmarshal([]byte(specField), &application); err != nil {
+		return "", fmt.Errorf("unable to unmarshal Application spec field: %v", err)
+	}
+
+	application.Namespace = namespace
+
+	resBytes, err := goyaml.Marshal(application)
+	if err != nil {
+		return "", fmt.Errorf("unable to marshal Application spec field: %v", err)
+	}
+
+	return string(resBytes), nil
+}
+
+// newK8sClientForGitOpsEngineInstance returns a client for the cluster of the given GitOps engine instance: either the
+// current cluster, or a remote cluster whose kubeconfig Secret is referenced by the ClusterCredentials of the instance.
+func newK8sClientForGitOpsEngineInstance(ctx context.Context, instance db.GitopsEngineInstance, serviceClient client.Client,
+	dbq db.DatabaseQueries) (client.Client, error) {
+
+	restConfig, err := dbutil.GetRESTConfigForGitOpsEngineCluster(ctx, instance.EngineCluster_id, serviceClient, dbq)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve kubeconfig of GitOps engine instance '%s': %v", instance.Gitopsengineinstance_id, err)
+	}
+
+	if restConfig == nil {
+		return serviceClient, nil
+	}
+
+	return newK8sClient(restConfig)
+}
+
+func newK8sClient(restConfig *rest.Config) (client.Client, error) {
+
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	if err := managedgitopsv1alpha1.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+
+	k8sClient, err := client.New(restConfig, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create Kubernetes client: %v", err)
+	}
+
+	return k8sClient, nil
+}